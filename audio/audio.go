@@ -0,0 +1,264 @@
+// Package audio chains the readers, processors and writers of GoAudio into pipelines, such as
+//
+//	err := audio.Load("in.wav").Resample(44100).Normalize(-1).Fade(.1, .5).Save("out.wav")
+//
+// A step that fails stops the rest of the chain, the error is returned by Save, Frames or Err.
+// WithContext lets a context cancel the steps which are still to run.
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Audio is a sound moving through a pipeline
+type Audio struct {
+	frames   []wave.Frame
+	wfmt     wave.WaveFmt
+	ctx      context.Context
+	progress wave.Progress
+	err      error
+}
+
+// Load reads a .wav file to start a pipeline
+func Load(path string) *Audio {
+	w, err := wave.ReadWaveFile(path)
+	if err != nil {
+		return &Audio{ctx: context.Background(), err: fmt.Errorf("Load: %w", err)}
+	}
+	return &Audio{frames: w.Frames, wfmt: w.WaveFmt, ctx: context.Background()}
+}
+
+// Open reads a .wav file of a file system to start a pipeline, such as a file embedded with
+// go:embed
+func Open(fsys fs.FS, name string) *Audio {
+	w, err := wave.ReadWaveFromFS(fsys, name)
+	if err != nil {
+		return &Audio{ctx: context.Background(), err: fmt.Errorf("Open: %w", err)}
+	}
+	return &Audio{frames: w.Frames, wfmt: w.WaveFmt, ctx: context.Background()}
+}
+
+// FromFrames starts a pipeline from interleaved frames in a format
+func FromFrames(frames []wave.Frame, wfmt wave.WaveFmt) *Audio {
+	a := &Audio{frames: frames, wfmt: wfmt, ctx: context.Background()}
+	if wfmt.NumChannels < 1 || wfmt.SampleRate < 1 {
+		a.err = errors.New("FromFrames: Need at least one channel and a sample rate")
+	}
+	return a
+}
+
+// WithContext stops the steps after it with the error of the context once it is done. Long
+// steps, such as resampling and saving, also stop while they run.
+func (a *Audio) WithContext(ctx context.Context) *Audio {
+	a.ctx = ctx
+	return a
+}
+
+// WithProgress reports the progress of the long steps after it, resampling and saving, in
+// frames. Each of them reports from the start up to its total.
+func (a *Audio) WithProgress(progress wave.Progress) *Audio {
+	a.progress = progress
+	return a
+}
+
+// step runs f unless an earlier step failed, errors of f are wrapped with the name of the step
+func (a *Audio) step(name string, f func() error) *Audio {
+	if a.err != nil {
+		return a
+	}
+	if err := a.ctx.Err(); err != nil {
+		a.err = fmt.Errorf("%v: %w", name, err)
+		return a
+	}
+	if err := f(); err != nil {
+		a.err = fmt.Errorf("%v: %w", name, err)
+	}
+	return a
+}
+
+// Resample converts the audio to another sample rate
+func (a *Audio) Resample(sr int) *Audio {
+	return a.step("Resample", func() error {
+		frames, err := synth.ResampleWithProgress(a.ctx, a.frames, a.wfmt.NumChannels, a.wfmt.SampleRate, sr, a.progress)
+		if err != nil {
+			return err
+		}
+		// NewValidWaveFmt has no extension, which a format of WAVE_FORMAT_EXTENSIBLE keeps
+		wfmt := wave.NewWaveFmt(a.wfmt.AudioFormat, a.wfmt.NumChannels, sr, a.wfmt.BitsPerSample, a.wfmt.ExtraParams)
+		if err := wfmt.Validate(); err != nil {
+			return err
+		}
+		a.frames, a.wfmt = frames, wfmt
+		return nil
+	})
+}
+
+// BitDepth sets the bits per sample the audio is saved with, integers of 16, 24 or 32 bits or
+// floats of 32 or 64 bits. Floats are saved as integers for 16 and 24 bits.
+func (a *Audio) BitDepth(bits int) *Audio {
+	return a.step("BitDepth", func() error {
+		format := wave.PCM
+		if isFloat(a.wfmt) && (bits == 32 || bits == 64) {
+			format = wave.IEEE_FLOAT
+		}
+		wfmt, err := wave.NewValidWaveFmt(format, a.wfmt.NumChannels, a.wfmt.SampleRate, bits)
+		if err != nil {
+			return err
+		}
+		a.wfmt = wfmt
+		return nil
+	})
+}
+
+// isFloat tells whether the samples of the format are floats, which WAVE_FORMAT_EXTENSIBLE
+// stores in its extension
+func isFloat(wfmt wave.WaveFmt) bool {
+	if wfmt.AudioFormat == wave.EXTENSIBLE && len(wfmt.ExtraParams) >= 8 {
+		return binary.LittleEndian.Uint16(wfmt.ExtraParams[6:8]) == wave.IEEE_FLOAT
+	}
+	return wfmt.AudioFormat == wave.IEEE_FLOAT
+}
+
+// Gain changes the level by db decibels
+func (a *Audio) Gain(db float64) *Audio {
+	return a.Process(synth.NewGain(db))
+}
+
+// Normalize scales the audio so its peak is at db decibels below full scale
+func (a *Audio) Normalize(db float64) *Audio {
+	return a.step("Normalize", func() error {
+		peak := 0.0
+		for _, f := range a.frames {
+			peak = math.Max(peak, math.Abs(float64(f)))
+		}
+		if peak == 0 {
+			return nil
+		}
+		wave.ApplyGain(a.frames, wave.Frame(math.Pow(10, db/20)/peak))
+		return nil
+	})
+}
+
+// Fade fades the audio in over the first in seconds and out over the last out seconds
+func (a *Audio) Fade(in, out float64) *Audio {
+	return a.step("Fade", func() error {
+		if in < 0 || out < 0 {
+			return errors.New("Fades can not be negative")
+		}
+		channels := a.wfmt.NumChannels
+		samples := len(a.frames) / channels
+		fadeIn := int(in * float64(a.wfmt.SampleRate))
+		fadeOut := int(out * float64(a.wfmt.SampleRate))
+		for i := 0; i < samples; i++ {
+			level := 1.0
+			if i < fadeIn {
+				level = float64(i) / float64(fadeIn)
+			}
+			if left := samples - 1 - i; left < fadeOut {
+				level = math.Min(level, float64(left)/float64(fadeOut))
+			}
+			if level == 1 {
+				continue
+			}
+			for c := 0; c < channels; c++ {
+				a.frames[i*channels+c] *= wave.Frame(level)
+			}
+		}
+		return nil
+	})
+}
+
+// Trim keeps the audio between start and end, in seconds. An end past the audio keeps the
+// rest of it.
+func (a *Audio) Trim(start, end float64) *Audio {
+	return a.step("Trim", func() error {
+		if start < 0 || end < start {
+			return errors.New("Need a start before the end")
+		}
+		channels := a.wfmt.NumChannels
+		samples := len(a.frames) / channels
+		from := int(start * float64(a.wfmt.SampleRate))
+		to := int(end * float64(a.wfmt.SampleRate))
+		if from > samples {
+			from = samples
+		}
+		if to > samples {
+			to = samples
+		}
+		a.frames = a.frames[from*channels : to*channels]
+		return nil
+	})
+}
+
+// Process runs a processor over the audio
+func (a *Audio) Process(p synth.Processor) *Audio {
+	return a.step("Process", func() error {
+		if p == nil {
+			return errors.New("Need a processor")
+		}
+		p.Process(a.frames)
+		return nil
+	})
+}
+
+// Effect runs a registered effect on the audio, with its parameters at their defaults
+// except for those in params
+func (a *Audio) Effect(name string, params map[string]float64) *Audio {
+	return a.step("Effect", func() error {
+		p, err := synth.NewEffect(name, a.wfmt.SampleRate, a.wfmt.NumChannels, params)
+		if err != nil {
+			return err
+		}
+		p.Process(a.frames)
+		return nil
+	})
+}
+
+// Preset runs the chain of effects of a preset on the audio
+func (a *Audio) Preset(p synth.Preset) *Audio {
+	return a.step("Preset", func() error {
+		c, err := p.Chain(a.wfmt.SampleRate, a.wfmt.NumChannels)
+		if err != nil {
+			return err
+		}
+		c.Process(a.frames)
+		return nil
+	})
+}
+
+// Save writes the audio as a .wav file
+func (a *Audio) Save(path string, opts ...wave.WriteOption) error {
+	a.step("Save", func() error {
+		opts = opts[:len(opts):len(opts)]
+		if a.ctx.Done() != nil {
+			// a context which can't be cancelled doesn't need the writing in blocks
+			opts = append(opts, wave.WithContext(a.ctx))
+		}
+		if a.progress != nil {
+			opts = append(opts, wave.WithProgress(a.progress))
+		}
+		return wave.WriteWaveFile(a.frames, a.wfmt, path, opts...)
+	})
+	return a.err
+}
+
+// Frames returns the audio and its format, or the error of the first step that failed
+func (a *Audio) Frames() ([]wave.Frame, wave.WaveFmt, error) {
+	if a.err != nil {
+		return nil, wave.WaveFmt{}, a.err
+	}
+	return a.frames, a.wfmt, nil
+}
+
+// Err returns the error of the first step that failed
+func (a *Audio) Err() error {
+	return a.err
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+	// a second of a quiet stereo sine
+	wfmt := wave.NewWaveFmt(1, 2, 22050, 16, nil)
+	frames := make([]wave.Frame, 2*22050)
+	for i := range frames {
+		frames[i] = wave.Frame(.25 * math.Sin(2*math.Pi*440*float64(i/2)/22050))
+	}
+	if err := wave.WriteWaveFile(frames, wfmt, in); err != nil {
+		t.Fatal(err)
+	}
+
+	var saved int64
+	progress := func(done, total int64) { saved = done }
+	err := Load(in).WithProgress(progress).Resample(44100).Normalize(-6).Trim(.25, .75).Fade(.1, .1).Save(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != 2*22050 {
+		t.Fatalf("Expected saving to report %v frames, got %v", 2*22050, saved)
+	}
+	got, gotFmt, err := Open(os.DirFS(dir), "out.wav").Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotFmt.SampleRate != 44100 || gotFmt.NumChannels != 2 {
+		t.Fatalf("Expected 44100Hz stereo, got %+v", gotFmt)
+	}
+	if len(got) != 2*22050 {
+		t.Fatalf("Expected half a second, got %v frames", len(got))
+	}
+	peak := 0.0
+	for _, f := range got {
+		peak = math.Max(peak, math.Abs(float64(f)))
+	}
+	if want := math.Pow(10, -6./20); math.Abs(peak-want) > .01 {
+		t.Fatalf("Expected a peak of %v, got %v", want, peak)
+	}
+	if got[0] != 0 || got[len(got)-1] != 0 {
+		t.Fatalf("Expected the fades to start and end in silence, got %v and %v", got[0], got[len(got)-1])
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	wfmt := wave.NewWaveFmt(1, 1, 8000, 16, nil)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		audio *Audio
+		err   error // the error wrapped, nil to only check an error is returned
+	}{
+		{Load(filepath.Join(t.TempDir(), "missing.wav")), os.ErrNotExist},
+		{Open(fstest.MapFS{}, "missing.wav"), fs.ErrNotExist},
+		{FromFrames(nil, wave.WaveFmt{}), nil},
+		{FromFrames(nil, wfmt).BitDepth(12).Gain(6), wave.ErrUnsupportedBitDepth{Bits: 12}},
+		{FromFrames(nil, wfmt).Trim(1, 0).Resample(0), nil},
+		{FromFrames(nil, wfmt).Process(nil), nil},
+		{FromFrames(nil, wfmt).Effect("missing", nil), nil},
+		{FromFrames(nil, wfmt).Effect("gain", map[string]float64{"gain": -1}), nil},
+		{FromFrames(nil, wfmt).Preset(synth.Preset{Effects: []synth.PresetEffect{{Effect: "pan"}}}), nil},
+		{FromFrames(nil, wfmt).WithContext(cancelled).Gain(6), context.Canceled},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, _, err := test.audio.Frames()
+			if err == nil || err != test.audio.Err() {
+				t.Fatalf("Expected the error of the failed step, got %v", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Fatalf("Expected %v, got %v", test.err, err)
+			}
+			if test.audio.Save(filepath.Join(t.TempDir(), "out.wav")) != err {
+				t.Fatal("Expected Save to return the first error")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Pipeline is the work done on every file of a batch, starting from the loaded file, such as
+//
+//	func(a *audio.Audio, input string) error {
+//		return a.Normalize(-1).Save(filepath.Join("out", filepath.Base(input)))
+//	}
+type Pipeline func(a *Audio, input string) error
+
+// FileResult is the outcome of a file of a batch
+type FileResult struct {
+	Input    string
+	Err      error
+	Duration float64       // seconds of audio in the file, 0 when it couldn't be loaded
+	Elapsed  time.Duration // time spent on the file
+}
+
+// BatchResult is the outcome of a batch
+type BatchResult struct {
+	Files             []FileResult // in the order of the inputs
+	Succeeded, Failed int
+	Duration          float64       // seconds of audio of all files loaded
+	Elapsed           time.Duration // time the batch took
+}
+
+// Err joins the errors of the files which failed, or returns nil when none did
+func (r BatchResult) Err() error {
+	errs := []error{}
+	for _, f := range r.Files {
+		if f.Err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", f.Input, f.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Batch runs a pipeline on every input, on workers files at a time. A file which fails
+// doesn't stop the others, its error is kept in its result. Less than one worker runs a
+// worker per CPU.
+func Batch(inputs []string, pipeline Pipeline, workers int) BatchResult {
+	return BatchCtx(context.Background(), inputs, pipeline, workers)
+}
+
+// BatchCtx is Batch with a context for the pipelines. Once it is done, the files which
+// haven't started fail with its error.
+func BatchCtx(ctx context.Context, inputs []string, pipeline Pipeline, workers int) BatchResult {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	start := time.Now()
+	result := BatchResult{Files: make([]FileResult, len(inputs))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result.Files[i] = runFile(ctx, inputs[i], pipeline)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, f := range result.Files {
+		if f.Err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+		result.Duration += f.Duration
+	}
+	result.Elapsed = time.Since(start)
+	return result
+}
+
+// runFile loads an input and runs the pipeline on it
+func runFile(ctx context.Context, input string, pipeline Pipeline) FileResult {
+	start := time.Now()
+	r := FileResult{Input: input}
+	if r.Err = ctx.Err(); r.Err != nil {
+		return r
+	}
+	a := Load(input).WithContext(ctx)
+	if r.Err = a.Err(); r.Err == nil {
+		r.Duration = float64(len(a.frames)/a.wfmt.NumChannels) / float64(a.wfmt.SampleRate)
+		r.Err = pipeline(a, input)
+	}
+	r.Elapsed = time.Since(start)
+	return r
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	inputs := []string{}
+	for i := 0; i < 8; i++ {
+		// half a second of a sine, each file at another level
+		frames := make([]wave.Frame, 4000)
+		for j := range frames {
+			frames[j] = wave.Frame(float64(i+1) / 10 * math.Sin(float64(j)/5))
+		}
+		path := filepath.Join(dir, string(rune('a'+i))+".wav")
+		if err := wave.WriteWaveFile(frames, wave.NewWaveFmt(wave.PCM, 1, 8000, 16, nil), path); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, path)
+	}
+	inputs = append(inputs, filepath.Join(dir, "missing.wav"))
+
+	out := filepath.Join(dir, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	normalize := func(a *Audio, input string) error {
+		return a.Normalize(-3).Save(filepath.Join(out, filepath.Base(input)))
+	}
+	result := Batch(inputs, normalize, 3)
+	if result.Succeeded != 8 || result.Failed != 1 || result.Duration != 4 {
+		t.Fatalf("Expected 8 files of 4 seconds and a failure, got %v, %v and %v seconds", result.Succeeded, result.Failed, result.Duration)
+	}
+	for i, f := range result.Files {
+		if f.Input != inputs[i] {
+			t.Fatalf("Expected the results in the order of the inputs, got %v at %v", f.Input, i)
+		}
+	}
+	if err := result.Err(); !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "missing.wav") {
+		t.Fatalf("Expected the missing file to fail, got %v", err)
+	}
+	for _, input := range inputs[:8] {
+		c, err := CompareFiles(filepath.Join(out, filepath.Base(input)), filepath.Join(out, "h.wav"), CompareOptions{Tolerance: .002})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.Equal() {
+			t.Fatalf("Expected every file at the same level, got %v", c)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := BatchCtx(ctx, inputs, normalize, 0); result.Failed != len(inputs) || !errors.Is(result.Err(), context.Canceled) {
+		t.Fatalf("Expected every file to be cancelled, got %v", result.Err())
+	}
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// CompareOptions sets how close two sounds have to be to be equal
+type CompareOptions struct {
+	Tolerance float64 // largest difference of a frame which still counts as equal
+}
+
+// Comparison is how two sounds differ. When their lengths differ, the frames past the end
+// of the shorter one are compared to silence.
+type Comparison struct {
+	LengthA, LengthB int     // frames of both sounds
+	MaxDiff          float64 // largest difference of a frame
+	MaxDiffAt        int     // frame with the largest difference
+	RMS              float64 // root mean square of the differences
+	FirstDiff        int     // first frame which differs more than the tolerance, -1 when none does
+}
+
+// Equal returns true when the sounds have the same length and no frame differs more than
+// the tolerance
+func (c Comparison) Equal() bool {
+	return c.LengthA == c.LengthB && c.FirstDiff < 0
+}
+
+func (c Comparison) String() string {
+	if c.Equal() {
+		return fmt.Sprintf("equal, max difference %.3g at frame %v, RMS %.3g", c.MaxDiff, c.MaxDiffAt, c.RMS)
+	}
+	s := fmt.Sprintf("first difference at frame %v, max difference %.3g at frame %v, RMS %.3g", c.FirstDiff, c.MaxDiff, c.MaxDiffAt, c.RMS)
+	if c.LengthA != c.LengthB {
+		s += fmt.Sprintf(", lengths %v and %v", c.LengthA, c.LengthB)
+	}
+	return s
+}
+
+// Compare compares two sounds frame by frame, such as the output of a processor with that of
+// an earlier version
+func Compare(a, b []wave.Frame, opts CompareOptions) Comparison {
+	c := Comparison{LengthA: len(a), LengthB: len(b), FirstDiff: -1}
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		var fa, fb float64
+		if i < len(a) {
+			fa = float64(a[i])
+		}
+		if i < len(b) {
+			fb = float64(b[i])
+		}
+		diff := math.Abs(fa - fb)
+		sum += diff * diff
+		if diff > c.MaxDiff {
+			c.MaxDiff, c.MaxDiffAt = diff, i
+		}
+		if diff > opts.Tolerance && c.FirstDiff < 0 {
+			c.FirstDiff = i
+		}
+	}
+	if n > 0 {
+		c.RMS = math.Sqrt(sum / float64(n))
+	}
+	if c.FirstDiff < 0 && len(a) != len(b) {
+		// the longer sound goes on in silence
+		c.FirstDiff = n - int(math.Abs(float64(len(a)-len(b))))
+	}
+	return c
+}
+
+// CompareFiles compares two .wav files, which need the same amount of channels and sample
+// rate. Their bit depths can differ.
+func CompareFiles(a, b string, opts CompareOptions) (Comparison, error) {
+	wa, err := wave.ReadWaveFile(a)
+	if err != nil {
+		return Comparison{}, err
+	}
+	wb, err := wave.ReadWaveFile(b)
+	if err != nil {
+		return Comparison{}, err
+	}
+	if wa.NumChannels != wb.NumChannels || wa.SampleRate != wb.SampleRate {
+		return Comparison{}, fmt.Errorf("Can't compare %v channels at %vHz with %v channels at %vHz",
+			wa.NumChannels, wa.SampleRate, wb.NumChannels, wb.SampleRate)
+	}
+	return Compare(wa.Frames, wb.Frames, opts), nil
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b      []wave.Frame
+		tolerance float64
+		equal     bool
+		first     int
+		max       float64
+		maxAt     int
+	}{
+		{[]wave.Frame{0, .5, -.5}, []wave.Frame{0, .5, -.5}, 0, true, -1, 0, 0},
+		{[]wave.Frame{0, .5, -.5}, []wave.Frame{0, .5, -.25}, 0, false, 2, .25, 2},
+		{[]wave.Frame{0, .5, -.5}, []wave.Frame{.125, .5, -.25}, .3, true, -1, .25, 2},
+		{[]wave.Frame{0, .5, -.5}, []wave.Frame{.125, .5, -.25}, .2, false, 2, .25, 2},
+		// a longer sound differs where the shorter one ends
+		{[]wave.Frame{0, .5}, []wave.Frame{0, .5, 0}, 0, false, 2, 0, 0},
+		{[]wave.Frame{0, .5, 1}, []wave.Frame{0, .5}, 0, false, 2, 1, 2},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			c := Compare(test.a, test.b, CompareOptions{Tolerance: test.tolerance})
+			if c.Equal() != test.equal || c.FirstDiff != test.first || c.MaxDiff != test.max || c.MaxDiffAt != test.maxAt {
+				t.Fatalf("Expected equal %v from %v with %v at %v, got %v", test.equal, test.first, test.max, test.maxAt, c)
+			}
+		})
+	}
+	if c := Compare([]wave.Frame{1, 1}, []wave.Frame{0, 0}, CompareOptions{}); c.RMS != 1 {
+		t.Fatalf("Expected an RMS of 1, got %v", c.RMS)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	frames := []wave.Frame{0, .25, .5, -.5}
+	write := func(name string, wfmt wave.WaveFmt, frames []wave.Frame) string {
+		path := filepath.Join(dir, name)
+		if err := wave.WriteWaveFile(frames, wfmt, path); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.wav", wave.NewWaveFmt(wave.PCM, 2, 8000, 16, nil), frames)
+	b := write("b.wav", wave.NewWaveFmt(wave.PCM, 2, 8000, 32, nil), frames)
+	mono := write("mono.wav", wave.NewWaveFmt(wave.PCM, 1, 8000, 16, nil), frames)
+
+	// the rounding of 16 bits is within the tolerance
+	c, err := CompareFiles(a, b, CompareOptions{Tolerance: 1e-4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Equal() || !strings.HasPrefix(c.String(), "equal") {
+		t.Fatalf("Expected the files to be equal, got %v", c)
+	}
+	if _, err := CompareFiles(a, mono, CompareOptions{}); err == nil {
+		t.Fatal("Expected files with other channels to be refused")
+	}
+}
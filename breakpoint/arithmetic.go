@@ -0,0 +1,135 @@
+package breakpoint
+
+// operations combining and transforming envelopes, these all return a new envelope
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// copyEnvelope returns a deep copy of an envelope
+func copyEnvelope(e *Envelope) *Envelope {
+	c := *e
+	c.Points = append(Breakpoints{}, e.Points...)
+	c.Segments = append([]Segment(nil), e.Segments...)
+	return &c
+}
+
+// mapValues returns a copy of the envelope with f applied to every value.
+// f has to be linear for the shape of the segments to be kept.
+func mapValues(e *Envelope, f func(float64) float64) *Envelope {
+	c := copyEnvelope(e)
+	for i := range c.Points {
+		c.Points[i].Value = f(c.Points[i].Value)
+	}
+	return c
+}
+
+// Scale multiplies every value of the envelope by a factor
+func Scale(e *Envelope, factor float64) *Envelope {
+	return mapValues(e, func(v float64) float64 { return v * factor })
+}
+
+// Offset adds an amount to every value of the envelope
+func Offset(e *Envelope, amount float64) *Envelope {
+	return mapValues(e, func(v float64) float64 { return v + amount })
+}
+
+// Invert flips the envelope upside down within the range of its values,
+// so an envelope from 0 to 1 becomes one from 1 to 0
+func Invert(e *Envelope) *Envelope {
+	min, max := MinMaxValue(e.Points)
+	return mapValues(e, func(v float64) float64 { return min + max - v })
+}
+
+// Shift moves the envelope, including its loop, in time
+func Shift(e *Envelope, offset float64) *Envelope {
+	c := copyEnvelope(e)
+	for i := range c.Points {
+		c.Points[i].Time += offset
+	}
+	c.LoopStart += offset
+	c.LoopEnd += offset
+	return c
+}
+
+// Add returns the sum of two envelopes as straight lines within tolerance of the exact sum
+func Add(a, b *Envelope, tolerance float64) (*Envelope, error) {
+	return combine(a, b, tolerance, func(x, y float64) float64 { return x + y })
+}
+
+// Multiply returns the product of two envelopes as straight lines within tolerance of the
+// exact product, e.g. to apply a macro envelope to LFO-derived automation
+func Multiply(a, b *Envelope, tolerance float64) (*Envelope, error) {
+	return combine(a, b, tolerance, func(x, y float64) float64 { return x * y })
+}
+
+// combine evaluates op on two envelopes at every point of both and approximates the
+// result between them with straight lines. Loops are not taken into account.
+func combine(a, b *Envelope, tolerance float64, op func(float64, float64) float64) (*Envelope, error) {
+	if len(a.Points) == 0 || len(b.Points) == 0 {
+		return nil, errors.New("Envelopes need at least one point")
+	}
+	if a.Unit != b.Unit {
+		return nil, errors.New("Envelopes should have the same time unit")
+	}
+	times := make([]float64, 0, len(a.Points)+len(b.Points))
+	for _, p := range a.Points {
+		times = append(times, p.Time)
+	}
+	for _, p := range b.Points {
+		times = append(times, p.Time)
+	}
+	sort.Float64s(times)
+
+	// the value just before and at a time differ when one of the envelopes jumps there
+	before := func(time float64) float64 {
+		return op(a.value(a.locateBefore(time), time), b.value(b.locateBefore(time), time))
+	}
+	at := func(time float64) float64 {
+		return op(a.value(a.locate(time, 0), time), b.value(b.locate(time, 0), time))
+	}
+
+	points := Breakpoints{{times[0], at(times[0])}}
+	for i := 1; i < len(times); i++ {
+		if times[i] == times[i-1] {
+			continue
+		}
+		left, right := points[len(points)-1], Breakpoint{times[i], before(times[i])}
+		points = subdivide(points, left, right, tolerance, 0, at)
+		if v := at(times[i]); v != right.Value {
+			points = append(points, Breakpoint{times[i], v})
+		}
+	}
+
+	env, err := NewEnvelope(points, LINEAR)
+	if err != nil {
+		return nil, err
+	}
+	env.Unit = a.Unit
+	return env, nil
+}
+
+// locateBefore returns the index of the last point strictly before the time
+func (e *Envelope) locateBefore(time float64) int {
+	return sort.Search(len(e.Points), func(i int) bool { return e.Points[i].Time >= time }) - 1
+}
+
+// subdivide appends the points needed to approximate f between left and right with
+// straight lines, the right point itself is appended last
+func subdivide(points Breakpoints, left, right Breakpoint, tolerance float64, depth int, f func(float64) float64) Breakpoints {
+	width := right.Time - left.Time
+	if width > 0 && depth < 16 {
+		for _, frac := range []float64{.25, .5, .75} {
+			line := left.Value + frac*(right.Value-left.Value)
+			if math.Abs(f(left.Time+frac*width)-line) > tolerance {
+				middle := left.Time + width/2
+				mid := Breakpoint{middle, f(middle)}
+				points = subdivide(points, left, mid, tolerance, depth+1, f)
+				return subdivide(points, mid, right, tolerance, depth+1, f)
+			}
+		}
+	}
+	return append(points, right)
+}
@@ -0,0 +1,69 @@
+package breakpoint
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1, 1}, {2, .5}}, LINEAR)
+	env.SetCurve(0, 2)
+	tests := []struct {
+		res *Envelope
+		out []Breakpoint
+	}{
+		{Scale(env, 2), []Breakpoint{{0, 0}, {1, 2}, {2, 1}}},
+		{Offset(env, -.5), []Breakpoint{{0, -.5}, {1, .5}, {2, 0}}},
+		{Invert(env), []Breakpoint{{0, 1}, {1, 0}, {2, .5}}},
+		{Shift(env, 1), []Breakpoint{{1, 0}, {2, 1}, {3, .5}}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if !reflect.DeepEqual([]Breakpoint(test.res.Points), test.out) {
+				t.Fatalf("Expected %v, got %v", test.out, test.res.Points)
+			}
+			if test.res.Segments[0].Curve != 2 {
+				t.Fatalf("Expected the curve to be kept")
+			}
+		})
+	}
+	if env.Points[1].Value != 1 {
+		t.Fatalf("Expected the original envelope to be unchanged")
+	}
+}
+
+func TestCombine(t *testing.T) {
+	a, _ := NewEnvelope([]Breakpoint{{0, 0}, {2, 1}}, LINEAR)
+	b, _ := NewEnvelope([]Breakpoint{{1, 1}, {1, 2}, {3, 0}}, LINEAR)
+	b.SetCurve(1, 3)
+
+	sum, err := Add(a, b, .001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := Multiply(a, b, .001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for time := 0.; time <= 3; time += .01 {
+		if d := math.Abs(sum.ValueAt(time) - (a.ValueAt(time) + b.ValueAt(time))); d > .001 {
+			t.Fatalf("Expected the sum within .001, got an error of %v at %v", d, time)
+		}
+		if d := math.Abs(product.ValueAt(time) - a.ValueAt(time)*b.ValueAt(time)); d > .001 {
+			t.Fatalf("Expected the product within .001, got an error of %v at %v", d, time)
+		}
+	}
+	// the jump of b is kept
+	if v := sum.ValueAt(.999999); math.Abs(v-1.5) > .001 {
+		t.Fatalf("Expected 1.5 just before the jump, got %v", v)
+	}
+	if v := sum.ValueAt(1); v != 2.5 {
+		t.Fatalf("Expected 2.5 at the jump, got %v", v)
+	}
+
+	b.Unit = MILLISECONDS
+	if _, err := Add(a, b, .001); err == nil {
+		t.Fatal("Expected an error for envelopes with different units")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -20,6 +22,8 @@ type Breakpoint struct {
 
 // BreakpointStream can be used to to treat breakpoints as a stream of data
 // Each 'tick' can manipulate the state of the breakpoint stream
+// The stream keeps track of its position, so each tick only looks at the current span
+// rather than searching through all the points.
 type BreakpointStream struct {
 	Breakpoints     Breakpoints
 	Left            Breakpoint
@@ -31,59 +35,120 @@ type BreakpointStream struct {
 	Width           float64
 	Height          float64
 	HasMore         bool
+	Smoothing       float64 // time in seconds of a one-pole filter against zipper noise, 0 disables it
+
+	env      *Envelope // evaluates the spans when the stream was created from an envelope
+	index    int       // last point of the envelope at or before the current position
+	sr       float64
+	smooth   float64 // previous smoothed value
+	smoothed bool    // whether smooth holds a value yet
 }
 
 // Tick returns the next value in the breakpoint stream
 func (b *BreakpointStream) Tick() (out float64) {
-	if !b.HasMore {
+	switch {
+	case b.env != nil:
+		time := b.env.loopTime(b.CurrentPosition)
+		b.index = b.env.locate(time, b.index)
+		out = b.env.value(b.index, time)
+	case !b.HasMore:
 		// permanently the last value
-		return b.Right.Value
-	}
-	if b.Width == 0.0 {
 		out = b.Right.Value
-	} else {
+	case b.CurrentPosition < b.Left.Time:
+		// we have not reached the first point yet
+		out = b.Left.Value
+	case b.Width == 0.0:
+		out = b.Right.Value
+	default:
 		// figure out value from linear interpolation
 		frac := (float64(b.CurrentPosition) - b.Left.Time) / b.Width
 		out = b.Left.Value + (b.Height * frac)
 	}
 
-	// prepare for next frame
-	b.CurrentPosition += b.Increment
-	if b.CurrentPosition > b.Right.Time {
-		// move to next span
-		b.IndexLeft++
-		b.IndexRight++
-		if b.IndexRight < len(b.Breakpoints) {
-			b.Left = b.Breakpoints[b.IndexLeft]
-			b.Right = b.Breakpoints[b.IndexRight]
-			b.Width = b.Right.Time - b.Left.Time
-			b.Height = b.Right.Value - b.Left.Value
-		} else {
-			// no more points
-			b.HasMore = false
+	if b.Smoothing > 0 {
+		if b.smoothed {
+			coef := math.Exp(-1 / (b.Smoothing * b.sr))
+			out = out + coef*(b.smooth-out)
 		}
+		b.smooth, b.smoothed = out, true
+	}
+
+	// prepare for next frame, dense breakpoints can make us skip several spans at once
+	b.CurrentPosition += b.Increment
+	for b.HasMore && b.CurrentPosition > b.Right.Time {
+		b.setSpan(b.IndexRight + 1)
 	}
 	return out
 }
 
+// Seek moves the stream to a time, the next tick returns the value at that time
+// without smoothing from the previous position
+func (b *BreakpointStream) Seek(time float64) {
+	b.CurrentPosition = time
+	b.smoothed = false
+	bs := b.Breakpoints
+	right := sort.Search(len(bs), func(i int) bool { return bs[i].Time >= time })
+	if right == 0 {
+		right = 1
+	}
+	b.setSpan(right)
+	if b.env != nil {
+		time = b.env.loopTime(time)
+		b.index = sort.Search(len(bs), func(i int) bool { return bs[i].Time > time }) - 1
+	}
+}
+
+// Reset moves the stream back to the start
+func (b *BreakpointStream) Reset() {
+	b.Seek(0)
+}
+
+// setSpan makes the span ending at point right the current one
+func (b *BreakpointStream) setSpan(right int) {
+	n := len(b.Breakpoints)
+	if right >= n {
+		// no more points
+		b.IndexLeft, b.IndexRight = n-1, n-1
+		b.Left, b.Right = b.Breakpoints[n-1], b.Breakpoints[n-1]
+		b.Width, b.Height = 0, 0
+		b.HasMore = false
+		return
+	}
+	b.IndexLeft, b.IndexRight = right-1, right
+	b.Left, b.Right = b.Breakpoints[right-1], b.Breakpoints[right]
+	b.Width = b.Right.Time - b.Left.Time
+	b.Height = b.Right.Value - b.Left.Value
+	b.HasMore = true
+}
+
 // NewBreakpointStream represents a slice of breakpoints streamed at a given sample rate
 func NewBreakpointStream(bs []Breakpoint, sr int) (*BreakpointStream, error) {
 	if len(bs) == 0 {
-		return nil, errors.New("Need at least two points to create a stream")
-	}
-	left, right := bs[0], bs[1]
-	return &BreakpointStream{
-		Breakpoints:     Breakpoints(bs),
-		Increment:       1.0 / float64(sr),
-		IndexLeft:       0,
-		IndexRight:      1,
-		CurrentPosition: 0,
-		Left:            left,
-		Right:           right,
-		Width:           right.Time - left.Time,   // first span
-		Height:          right.Value - left.Value, // diff of first span
-		HasMore:         len(bs) > 0,
-	}, nil
+		return nil, errors.New("Need at least one point to create a stream")
+	}
+	b := &BreakpointStream{
+		Breakpoints: Breakpoints(bs),
+		Increment:   1.0 / float64(sr),
+		sr:          float64(sr),
+	}
+	b.setSpan(1)
+	return b, nil
+}
+
+// Stream creates a stream evaluating the envelope at a given sample rate
+func (e *Envelope) Stream(sr int) (*BreakpointStream, error) {
+	scale, err := e.Unit.perSecond()
+	if err != nil {
+		return nil, err
+	}
+	b, err := NewBreakpointStream(e.Points, sr)
+	if err != nil {
+		return nil, err
+	}
+	b.Increment *= scale
+	b.env = e
+	b.index = -1
+	return b, nil
 }
 
 // ParseBreakpoints reads the breakpoints from an io.Reader
@@ -147,6 +212,11 @@ func ValueAt(bs []Breakpoint, time float64, startIndex int) (index int, value fl
 		startSpan++
 	}
 
+	// We have not reached the first point yet
+	if startSpan == 0 {
+		return 0, bs[0].Value
+	}
+
 	// Our span is never-ending (the last point in our breakpoint file was hit)
 	if startSpan == npoints {
 		return startSpan, bs[startSpan-1].Value
@@ -1,6 +1,7 @@
 package breakpoint
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -73,6 +74,20 @@ var (
 			1.5, // linear interpolation should give this result
 			5,
 		},
+		{
+			[]Breakpoint{
+				{
+					Time:  1,
+					Value: 5,
+				},
+				{
+					Time:  2,
+					Value: 10,
+				},
+			},
+			0.5, // before the first point, the value of the first point
+			5,
+		},
 	}
 
 	minMaxTests = []struct {
@@ -197,6 +212,96 @@ func TestBreakpointStream(t *testing.T) {
 	}
 }
 
+// TestDenseBreakpointStream ensures the stream can skip over several spans within a tick
+func TestDenseBreakpointStream(t *testing.T) {
+	bs := []Breakpoint{}
+	for i := 0; i <= 1000; i++ {
+		bs = append(bs, Breakpoint{float64(i) / 1000, float64(i)})
+	}
+	stream, err := NewBreakpointStream(bs, 10)
+	if err != nil {
+		t.Fatalf("Should be able to create breakpoint stream: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		value := stream.Tick()
+		if math.Abs(value-float64(i*100)) > 1e-6 {
+			t.Fatalf("Expected %v at tick %v, got %v", i*100, i, value)
+		}
+	}
+
+	stream.Seek(.25)
+	if value := stream.Tick(); math.Abs(value-250) > 1e-6 {
+		t.Fatalf("Expected 250 after seeking, got %v", value)
+	}
+	stream.Reset()
+	if value := stream.Tick(); value != 0 {
+		t.Fatalf("Expected 0 after a reset, got %v", value)
+	}
+}
+
+func TestSinglePointStream(t *testing.T) {
+	stream, err := NewBreakpointStream([]Breakpoint{{1, 5}}, 10)
+	if err != nil {
+		t.Fatalf("Should be able to stream a single point: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if value := stream.Tick(); value != 5 {
+			t.Fatalf("Expected 5, got %v", value)
+		}
+	}
+	if _, err := NewBreakpointStream(nil, 10); err == nil {
+		t.Fatal("Expected an error for a stream without points")
+	}
+}
+
+func TestEnvelopeStream(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{.5, 0}, {1, 1}, {2, 1}, {3, 0}}, CATMULL_ROM)
+	env.SetCurve(0, 2)
+	stream, err := env.Stream(100)
+	if err != nil {
+		t.Fatalf("Should be able to create stream: %v", err)
+	}
+	for i := 0; i < 400; i++ {
+		expected := env.ValueAt(float64(i) / 100)
+		if value := stream.Tick(); math.Abs(value-expected) > 1e-9 {
+			t.Fatalf("Expected %v at tick %v, got %v", expected, i, value)
+		}
+	}
+	stream.Seek(1.5)
+	if value := stream.Tick(); math.Abs(value-env.ValueAt(1.5)) > 1e-9 {
+		t.Fatalf("Expected %v after seeking, got %v", env.ValueAt(1.5), value)
+	}
+}
+
+// TestSmoothedStream checks a step is turned into an exponential approach
+func TestSmoothedStream(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1, 1}}, STEP)
+	stream, _ := env.Stream(1000)
+	stream.Smoothing = .01
+	stream.Seek(.99)
+	for i := 0; i < 10; i++ {
+		stream.Tick()
+	}
+	// one time constant after the step the value reaches 1-1/e
+	var value float64
+	for i := 0; i < 10; i++ {
+		value = stream.Tick()
+	}
+	if math.Abs(value-(1-1/math.E)) > .01 {
+		t.Fatalf("Expected %v one time constant after the step, got %v", 1-1/math.E, value)
+	}
+	for i := 0; i < 100; i++ {
+		value = stream.Tick()
+	}
+	if math.Abs(value-1) > 1e-3 {
+		t.Fatalf("Expected the value to settle at 1, got %v", value)
+	}
+	stream.Seek(0)
+	if value := stream.Tick(); value != 0 {
+		t.Fatalf("Expected seeking to jump to the new value, got %v", value)
+	}
+}
+
 func TestValueAt(t *testing.T) {
 	for _, test := range valueAtTests {
 		t.Run("", func(t *testing.T) {
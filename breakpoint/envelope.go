@@ -0,0 +1,350 @@
+package breakpoint
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Interpolation selects how the values between breakpoints are calculated
+type Interpolation int
+
+// Interpolation modes of an Envelope
+const (
+	LINEAR         Interpolation = iota
+	MONOTONE_CUBIC               // smooth curve which never overshoots the points
+	CATMULL_ROM                  // smooth curve through the points, which can overshoot them
+	STEP                         // holds the value until the next point, for discrete switches
+)
+
+// TimeUnit is the unit of the times of the points of an envelope
+type TimeUnit int
+
+// Supported time units
+const (
+	SECONDS TimeUnit = iota
+	MILLISECONDS
+	BEATS // quarter notes, converted to seconds with a TempoMap
+)
+
+// perSecond returns how many of the unit fit in a second
+func (u TimeUnit) perSecond() (float64, error) {
+	switch u {
+	case SECONDS:
+		return 1, nil
+	case MILLISECONDS:
+		return 1000, nil
+	}
+	return 0, fmt.Errorf("Time unit %v can't be converted to seconds", u)
+}
+
+// LoopMode selects how an envelope continues once it reaches the end of its loop
+type LoopMode int
+
+// Loop modes of an Envelope
+const (
+	NO_LOOP        LoopMode = iota // play the points once and hold the last value
+	LOOP_FORWARD                   // jump back to the start of the loop
+	LOOP_PING_PONG                 // play the loop backwards, then forwards again
+	LOOP_HOLD                      // play once up to the end of the loop and hold the value there
+)
+
+// Segment describes the shape of the span between two breakpoints
+type Segment struct {
+	// Curve bends a linear span: 0 is a straight line, positive values start slowly and
+	// speed up (exponential), negative values start fast and slow down (logarithmic)
+	Curve float64
+
+	// Bezier makes the span a cubic Bezier curve through the two handles, which are
+	// relative to the span: a time of 0 is at the left point and 1 at the right point,
+	// a value of 0 is the value of the left point and 1 that of the right point.
+	// The curve is used instead of Curve.
+	Bezier  bool
+	Handles [2]Breakpoint
+}
+
+// Envelope is a curve through breakpoints, which have to be sorted by time.
+// Segments[i] shapes the span from Points[i] to Points[i+1], when Segments is nil every
+// span is a straight line. Segments are only used with LINEAR interpolation.
+type Envelope struct {
+	Points        Breakpoints
+	Segments      []Segment
+	Interpolation Interpolation
+	Unit          TimeUnit // unit of the times of the points, seconds by default
+
+	LoopStart float64 // start of the loop, in the unit of the envelope
+	LoopEnd   float64
+	LoopMode  LoopMode
+}
+
+// NewEnvelope creates an envelope from breakpoints sorted by time
+func NewEnvelope(points []Breakpoint, mode Interpolation) (*Envelope, error) {
+	if len(points) == 0 {
+		return nil, errors.New("An envelope needs at least one point")
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Time < points[i-1].Time {
+			return nil, fmt.Errorf("Breakpoint %v is earlier than the one before it", i)
+		}
+	}
+	if mode < LINEAR || mode > STEP {
+		return nil, fmt.Errorf("Interpolation mode %v not supported", mode)
+	}
+	return &Envelope{
+		Points:        Breakpoints(points),
+		Interpolation: mode,
+	}, nil
+}
+
+// SetCurve sets the curvature of the segment starting at point i
+func (e *Envelope) SetCurve(i int, curve float64) error {
+	if i < 0 || i >= len(e.Points)-1 {
+		return fmt.Errorf("Envelope has no segment %v", i)
+	}
+	e.ensureSegments()
+	e.Segments[i].Curve = curve
+	return nil
+}
+
+// SetBezier makes the segment starting at point i a Bezier curve with two handles
+// relative to the segment, their times have to be in the range [0;1]
+func (e *Envelope) SetBezier(i int, h1, h2 Breakpoint) error {
+	if i < 0 || i >= len(e.Points)-1 {
+		return fmt.Errorf("Envelope has no segment %v", i)
+	}
+	if h1.Time < 0 || h1.Time > 1 || h2.Time < 0 || h2.Time > 1 {
+		return errors.New("Handle times should be in the range [0;1]")
+	}
+	e.ensureSegments()
+	e.Segments[i].Bezier = true
+	e.Segments[i].Handles = [2]Breakpoint{h1, h2}
+	return nil
+}
+
+// ensureSegments makes sure there is a segment for every span
+func (e *Envelope) ensureSegments() {
+	if len(e.Segments) != len(e.Points)-1 {
+		segs := make([]Segment, len(e.Points)-1)
+		copy(segs, e.Segments)
+		e.Segments = segs
+	}
+}
+
+// SetLoop sets the loop of the envelope, between two times in the unit of the envelope
+func (e *Envelope) SetLoop(start, end float64, mode LoopMode) error {
+	if mode < NO_LOOP || mode > LOOP_HOLD {
+		return fmt.Errorf("Loop mode %v not supported", mode)
+	}
+	if mode != NO_LOOP && mode != LOOP_HOLD && end <= start {
+		return errors.New("The loop should end after it starts")
+	}
+	e.LoopStart, e.LoopEnd, e.LoopMode = start, end, mode
+	return nil
+}
+
+// loopTime maps a time onto the loop of the envelope
+func (e *Envelope) loopTime(time float64) float64 {
+	length := e.LoopEnd - e.LoopStart
+	switch {
+	case e.LoopMode == NO_LOOP || time <= e.LoopEnd:
+		return time
+	case e.LoopMode == LOOP_HOLD:
+		return e.LoopEnd
+	case length <= 0:
+		return time
+	case e.LoopMode == LOOP_FORWARD:
+		return e.LoopStart + math.Mod(time-e.LoopStart, length)
+	case e.LoopMode == LOOP_PING_PONG:
+		p := math.Mod(time-e.LoopEnd, 2*length)
+		if p < length {
+			return e.LoopEnd - p
+		}
+		return e.LoopStart + p - length
+	}
+	return time
+}
+
+// ValueAt returns the value of the envelope at a time
+func (e *Envelope) ValueAt(time float64) float64 {
+	time = e.loopTime(time)
+	i := sort.Search(len(e.Points), func(i int) bool { return e.Points[i].Time > time }) - 1
+	return e.value(i, time)
+}
+
+// locate returns the index of the last point at or before the time, or -1 when the time is
+// before the first point. The search starts at the hint, so evaluating nearby times is cheap.
+func (e *Envelope) locate(time float64, hint int) int {
+	i := hint
+	if i >= len(e.Points) {
+		i = len(e.Points) - 1
+	}
+	for i >= 0 && e.Points[i].Time > time {
+		i--
+	}
+	for i+1 < len(e.Points) && e.Points[i+1].Time <= time {
+		i++
+	}
+	return i
+}
+
+// value returns the value at a time within the span starting at point i
+func (e *Envelope) value(i int, time float64) float64 {
+	if len(e.Points) == 0 {
+		return 0
+	}
+	if i < 0 {
+		return e.Points[0].Value
+	}
+	if i >= len(e.Points)-1 {
+		return e.Points[len(e.Points)-1].Value
+	}
+	left, right := e.Points[i], e.Points[i+1]
+	width := right.Time - left.Time
+	if width == 0 {
+		return right.Value
+	}
+	x := (time - left.Time) / width
+
+	switch e.Interpolation {
+	case STEP:
+		return left.Value
+	case MONOTONE_CUBIC, CATMULL_ROM:
+		return hermite(left.Value, right.Value, e.tangent(i)*width, e.tangent(i+1)*width, x)
+	default:
+		if i < len(e.Segments) {
+			if seg := e.Segments[i]; seg.Bezier {
+				x = bezier(seg.Handles, x)
+			} else {
+				x = curve(x, seg.Curve)
+			}
+		}
+		return left.Value + (right.Value-left.Value)*x
+	}
+}
+
+// bezier evaluates a cubic Bezier curve from (0, 0) to (1, 1) through the handles at time x.
+// The curve is defined by a parameter rather than by time, so first the parameter at
+// which the curve reaches x is found by bisection. With handle times in [0;1] the time
+// only increases along the curve, so there is exactly one.
+func bezier(h [2]Breakpoint, x float64) float64 {
+	cubic := func(p1, p2, t float64) float64 {
+		u := 1 - t
+		return 3*u*u*t*p1 + 3*u*t*t*p2 + t*t*t
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 50; i++ {
+		mid := (lo + hi) / 2
+		if cubic(h[0].Time, h[1].Time, mid) < x {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return cubic(h[0].Value, h[1].Value, (lo+hi)/2)
+}
+
+// Linearize returns a copy of the envelope made of straight lines, which stays within
+// tolerance of the original. This is useful for exporting curves to formats that only
+// support linear breakpoints.
+func (e *Envelope) Linearize(tolerance float64) *Envelope {
+	points := Breakpoints{}
+	if len(e.Points) > 0 {
+		points = append(points, e.Points[0])
+	}
+	for i := 0; i+1 < len(e.Points); i++ {
+		if e.Interpolation == STEP {
+			// a step is exact as a jump at the time of the next point
+			left, right := e.Points[i], e.Points[i+1]
+			points = append(points, Breakpoint{right.Time, left.Value}, right)
+			continue
+		}
+		points = e.linearizeSpan(points, i, e.Points[i], e.Points[i+1], tolerance, 0)
+	}
+	linear := *e
+	linear.Points = points
+	linear.Segments = nil
+	linear.Interpolation = LINEAR
+	return &linear
+}
+
+// linearizeSpan appends the points needed to approximate the part of span i between the
+// left and right point, subdividing until the straight line is close enough
+func (e *Envelope) linearizeSpan(points Breakpoints, i int, left, right Breakpoint, tolerance float64, depth int) Breakpoints {
+	width := right.Time - left.Time
+	straight := width == 0 || depth >= 16
+	if !straight {
+		straight = true
+		// checking more than the middle catches S-shaped curves
+		for _, f := range []float64{.25, .5, .75} {
+			time := left.Time + f*width
+			line := left.Value + f*(right.Value-left.Value)
+			if math.Abs(e.value(i, time)-line) > tolerance {
+				straight = false
+				break
+			}
+		}
+	}
+	if straight {
+		return append(points, right)
+	}
+	middle := left.Time + width/2
+	mid := Breakpoint{middle, e.value(i, middle)}
+	points = e.linearizeSpan(points, i, left, mid, tolerance, depth+1)
+	return e.linearizeSpan(points, i, mid, right, tolerance, depth+1)
+}
+
+// curve bends x in the range [0;1] exponentially by c
+func curve(x, c float64) float64 {
+	if c == 0 {
+		return x
+	}
+	return (math.Exp(c*x) - 1) / (math.Exp(c) - 1)
+}
+
+// slope returns the slope of the straight line between point i and the next one
+func (e *Envelope) slope(i int) float64 {
+	width := e.Points[i+1].Time - e.Points[i].Time
+	if width == 0 {
+		return 0
+	}
+	return (e.Points[i+1].Value - e.Points[i].Value) / width
+}
+
+// tangent returns the slope of the curve at point i
+func (e *Envelope) tangent(i int) float64 {
+	n := len(e.Points)
+	// the end points follow the straight line to their neighbour
+	if i == 0 {
+		return e.slope(0)
+	}
+	if i == n-1 {
+		return e.slope(n - 2)
+	}
+
+	if e.Interpolation == CATMULL_ROM {
+		width := e.Points[i+1].Time - e.Points[i-1].Time
+		if width == 0 {
+			return 0
+		}
+		return (e.Points[i+1].Value - e.Points[i-1].Value) / width
+	}
+
+	// Fritsch-Butland tangents: flat at local extremes, otherwise a weighted harmonic mean of
+	// the slopes on both sides, which keeps every span monotone
+	d0, d1 := e.slope(i-1), e.slope(i)
+	if d0*d1 <= 0 {
+		return 0
+	}
+	h0 := e.Points[i].Time - e.Points[i-1].Time
+	h1 := e.Points[i+1].Time - e.Points[i].Time
+	w0, w1 := 2*h1+h0, h1+2*h0
+	return (w0 + w1) / (w0/d0 + w1/d1)
+}
+
+// hermite interpolates between two values with the given tangents, x is in the range [0;1]
+func hermite(p0, p1, m0, m1, x float64) float64 {
+	x2 := x * x
+	x3 := x2 * x
+	return (2*x3-3*x2+1)*p0 + (x3-2*x2+x)*m0 + (-2*x3+3*x2)*p1 + (x3-x2)*m1
+}
@@ -0,0 +1,209 @@
+package breakpoint
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEnvelopeInterpolation(t *testing.T) {
+	points := []Breakpoint{{0, 0}, {1, 1}, {2, 1}, {3, 0}}
+	tests := []struct {
+		mode Interpolation
+		time float64
+		out  float64
+	}{
+		{LINEAR, .5, .5},
+		{LINEAR, 2.25, .75},
+		{MONOTONE_CUBIC, 0, 0},
+		{MONOTONE_CUBIC, 1, 1},
+		{MONOTONE_CUBIC, 1.5, 1}, // flat between two equal points, no overshoot
+		{CATMULL_ROM, 1, 1},
+		{CATMULL_ROM, 3, 0},
+		{CATMULL_ROM, -1, 0},
+		{CATMULL_ROM, 4, 0},
+		{STEP, .99, 0},
+		{STEP, 1, 1},
+		{STEP, 2.99, 1},
+		{STEP, 3, 0},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			env, err := NewEnvelope(points, test.mode)
+			if err != nil {
+				t.Fatalf("Should be able to create envelope: %v", err)
+			}
+			if res := env.ValueAt(test.time); math.Abs(res-test.out) > 1e-9 {
+				t.Fatalf("Expected %v at %v, got %v", test.out, test.time, res)
+			}
+		})
+	}
+}
+
+func TestEnvelopeSmoothness(t *testing.T) {
+	points := []Breakpoint{{0, 0}, {1, 5}, {1.5, 6}, {4, 10}, {5, 2}}
+	for _, mode := range []Interpolation{MONOTONE_CUBIC, CATMULL_ROM} {
+		t.Run("", func(t *testing.T) {
+			env, _ := NewEnvelope(points, mode)
+			// no sudden changes of slope at the points
+			for _, p := range points[1 : len(points)-1] {
+				h := 1e-6
+				before := (p.Value - env.ValueAt(p.Time-h)) / h
+				after := (env.ValueAt(p.Time+h) - p.Value) / h
+				if math.Abs(before-after) > 1e-3 {
+					t.Fatalf("Expected a continuous slope at %v, got %v and %v", p.Time, before, after)
+				}
+			}
+		})
+	}
+
+	// the monotone curve never leaves the range of its neighbours
+	env, _ := NewEnvelope(points, MONOTONE_CUBIC)
+	for time := 0.0; time < 4; time += .01 {
+		if v := env.ValueAt(time); v < 0 || v > 10 {
+			t.Fatalf("Expected monotone values, got %v at %v", v, time)
+		}
+	}
+}
+
+func TestNewEnvelope(t *testing.T) {
+	if _, err := NewEnvelope(nil, LINEAR); err == nil {
+		t.Fatal("Expected an error for an envelope without points")
+	}
+	if _, err := NewEnvelope([]Breakpoint{{1, 0}, {0, 1}}, LINEAR); err == nil {
+		t.Fatal("Expected an error for points out of order")
+	}
+}
+
+func TestEnvelopeCurves(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1, 1}, {2, 0}}, LINEAR)
+	if err := env.SetCurve(0, 4); err != nil {
+		t.Fatalf("Should be able to set curve: %v", err)
+	}
+	env.SetCurve(1, -4)
+
+	tests := []struct {
+		time float64
+		out  float64
+	}{
+		{0, 0},
+		{.5, (math.Exp(2) - 1) / (math.Exp(4) - 1)},
+		{1, 1},
+		{1.5, 1 - (math.Exp(-2)-1)/(math.Exp(-4)-1)},
+		{2, 0},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if res := env.ValueAt(test.time); math.Abs(res-test.out) > 1e-9 {
+				t.Fatalf("Expected %v at %v, got %v", test.out, test.time, res)
+			}
+		})
+	}
+
+	// an exponential rise starts slowly, a logarithmic fall moves quickly
+	if env.ValueAt(.25) >= .25 || env.ValueAt(1.25) >= .75 {
+		t.Fatal("Expected curved segments")
+	}
+	if err := env.SetCurve(2, 1); err == nil {
+		t.Fatal("Expected an error for a segment past the last point")
+	}
+}
+
+func TestEnvelopeLoops(t *testing.T) {
+	points := []Breakpoint{{0, 0}, {1, 1}, {2, 0}, {3, 5}}
+	tests := []struct {
+		mode LoopMode
+		time float64
+		out  float64
+	}{
+		{NO_LOOP, 2.5, 2.5},
+		{NO_LOOP, 10, 5},
+		{LOOP_FORWARD, .5, .5},
+		{LOOP_FORWARD, 2.5, .5},     // 2.5 wraps to 1.5
+		{LOOP_FORWARD, 3.25, .75},   // 3.25 wraps to 1.25
+		{LOOP_PING_PONG, 2.25, .25}, // back from 2 to 1.75
+		{LOOP_PING_PONG, 2.75, .75}, // at 1.25
+		{LOOP_PING_PONG, 3.25, .75}, // forwards again at 1.25
+		{LOOP_HOLD, 1.5, .5},
+		{LOOP_HOLD, 10, 0},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			env, _ := NewEnvelope(points, LINEAR)
+			if err := env.SetLoop(1, 2, test.mode); err != nil {
+				t.Fatalf("Should be able to set loop: %v", err)
+			}
+			if res := env.ValueAt(test.time); math.Abs(res-test.out) > 1e-9 {
+				t.Fatalf("Expected %v at %v, got %v", test.out, test.time, res)
+			}
+
+			stream, _ := env.Stream(4)
+			stream.Seek(test.time)
+			if res := stream.Tick(); math.Abs(res-test.out) > 1e-9 {
+				t.Fatalf("Expected the stream to give %v at %v, got %v", test.out, test.time, res)
+			}
+		})
+	}
+
+	env, _ := NewEnvelope(points, LINEAR)
+	if err := env.SetLoop(2, 1, LOOP_FORWARD); err == nil {
+		t.Fatal("Expected an error for a loop ending before it starts")
+	}
+}
+
+func TestEnvelopeBezier(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {2, 10}}, LINEAR)
+	// handles on the straight line give a straight line
+	if err := env.SetBezier(0, Breakpoint{1. / 3, 1. / 3}, Breakpoint{2. / 3, 2. / 3}); err != nil {
+		t.Fatalf("Should be able to set handles: %v", err)
+	}
+	for _, time := range []float64{0, .3, 1, 1.7, 2} {
+		if res := env.ValueAt(time); math.Abs(res-5*time) > 1e-9 {
+			t.Fatalf("Expected %v at %v, got %v", 5*time, time, res)
+		}
+	}
+
+	// an ease in and out is symmetric around the middle and flat at the ends
+	env.SetBezier(0, Breakpoint{.5, 0}, Breakpoint{.5, 1})
+	if res := env.ValueAt(1); math.Abs(res-5) > 1e-9 {
+		t.Fatalf("Expected 5 in the middle, got %v", res)
+	}
+	if a, b := env.ValueAt(.5), env.ValueAt(1.5); math.Abs(a+b-10) > 1e-9 || a >= 2.5 {
+		t.Fatalf("Expected a symmetric ease, got %v and %v", a, b)
+	}
+
+	if err := env.SetBezier(0, Breakpoint{-1, 0}, Breakpoint{.5, 1}); err == nil {
+		t.Fatal("Expected an error for a handle before the segment")
+	}
+}
+
+func TestLinearize(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1, 1}, {2, 0}, {3, 0}}, LINEAR)
+	env.SetBezier(0, Breakpoint{.8, 0}, Breakpoint{.2, 1})
+	env.SetCurve(1, 5)
+
+	linear := env.Linearize(.001)
+	if linear.Segments != nil || linear.Interpolation != LINEAR {
+		t.Fatal("Expected a linear envelope")
+	}
+	if len(linear.Points) < 10 || len(linear.Points) > 500 {
+		t.Fatalf("Expected a reasonable amount of points, got %v", len(linear.Points))
+	}
+	for time := 0.0; time <= 3; time += .001 {
+		if d := math.Abs(linear.ValueAt(time) - env.ValueAt(time)); d > .002 {
+			t.Fatalf("Expected the linear envelope to stay close, got %v off at %v", d, time)
+		}
+	}
+	// steps become jumps at the time of the next point
+	steps, _ := NewEnvelope([]Breakpoint{{0, 0}, {1, 1}, {2, 0}}, STEP)
+	stepped := steps.Linearize(.001)
+	for time := 0.0; time <= 2; time += .001 {
+		if stepped.ValueAt(time) != steps.ValueAt(time) {
+			t.Fatalf("Expected the linearized steps to match at %v", time)
+		}
+	}
+
+	// the flat last segment needs no extra points
+	if p := linear.Points[len(linear.Points)-2]; p.Time != 2 {
+		t.Fatalf("Expected no points within the straight segment, got %v", p)
+	}
+}
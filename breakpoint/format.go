@@ -0,0 +1,263 @@
+package breakpoint
+
+// reading and writing envelopes as CSV and JSON
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	unitNames = map[TimeUnit]string{
+		SECONDS:      "s",
+		MILLISECONDS: "ms",
+		BEATS:        "beats",
+	}
+
+	interpolationNames = map[Interpolation]string{
+		LINEAR:         "linear",
+		MONOTONE_CUBIC: "monotone_cubic",
+		CATMULL_ROM:    "catmull_rom",
+		STEP:           "step",
+	}
+
+	loopNames = map[LoopMode]string{
+		NO_LOOP:        "none",
+		LOOP_FORWARD:   "forward",
+		LOOP_PING_PONG: "ping_pong",
+		LOOP_HOLD:      "hold",
+	}
+)
+
+// String returns the short name of the unit
+func (u TimeUnit) String() string {
+	if name, ok := unitNames[u]; ok {
+		return name
+	}
+	return fmt.Sprintf("TimeUnit(%d)", int(u))
+}
+
+// parseUnit turns the short name of a unit back into the unit
+func parseUnit(name string) (TimeUnit, error) {
+	for u, n := range unitNames {
+		if n == name {
+			return u, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown time unit %q", name)
+}
+
+// parseInterpolation turns the name of an interpolation mode back into the mode
+func parseInterpolation(name string) (Interpolation, error) {
+	for i, n := range interpolationNames {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown interpolation %q", name)
+}
+
+// parseLoopMode turns the name of a loop mode back into the mode
+func parseLoopMode(name string) (LoopMode, error) {
+	for m, n := range loopNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown loop mode %q", name)
+}
+
+// curveAt returns the curvature of the segment starting at point i
+func (e *Envelope) curveAt(i int) float64 {
+	if i < len(e.Segments) {
+		return e.Segments[i].Curve
+	}
+	return 0
+}
+
+// jsonEnvelope is the JSON representation of an envelope
+type jsonEnvelope struct {
+	Unit          string      `json:"unit"`
+	Interpolation string      `json:"interpolation"`
+	Points        []jsonPoint `json:"points"`
+	Loop          *jsonLoop   `json:"loop,omitempty"`
+}
+
+// jsonLoop is the loop of an envelope
+type jsonLoop struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Mode  string  `json:"mode"`
+}
+
+// jsonPoint is a breakpoint with the curvature of the segment starting at it
+type jsonPoint struct {
+	Time   float64     `json:"time"`
+	Value  float64     `json:"value"`
+	Curve  float64     `json:"curve,omitempty"`
+	Bezier *[4]float64 `json:"bezier,omitempty"` // time and value of both handles
+}
+
+// MarshalJSON encodes the envelope with its time unit and interpolation
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	interp, ok := interpolationNames[e.Interpolation]
+	if !ok {
+		return nil, fmt.Errorf("Interpolation mode %v not supported", e.Interpolation)
+	}
+	je := jsonEnvelope{
+		Unit:          e.Unit.String(),
+		Interpolation: interp,
+		Points:        make([]jsonPoint, len(e.Points)),
+	}
+	for i, p := range e.Points {
+		je.Points[i] = jsonPoint{Time: p.Time, Value: p.Value, Curve: e.curveAt(i)}
+		if i < len(e.Segments) && e.Segments[i].Bezier {
+			h := e.Segments[i].Handles
+			je.Points[i].Bezier = &[4]float64{h[0].Time, h[0].Value, h[1].Time, h[1].Value}
+		}
+	}
+	if e.LoopMode != NO_LOOP {
+		mode, ok := loopNames[e.LoopMode]
+		if !ok {
+			return nil, fmt.Errorf("Loop mode %v not supported", e.LoopMode)
+		}
+		je.Loop = &jsonLoop{e.LoopStart, e.LoopEnd, mode}
+	}
+	return json.Marshal(je)
+}
+
+// UnmarshalJSON decodes an envelope, a missing unit or interpolation defaults to
+// seconds and linear
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	je := jsonEnvelope{Unit: "s", Interpolation: "linear"}
+	if err := json.Unmarshal(data, &je); err != nil {
+		return err
+	}
+	unit, err := parseUnit(je.Unit)
+	if err != nil {
+		return err
+	}
+	interp, err := parseInterpolation(je.Interpolation)
+	if err != nil {
+		return err
+	}
+	points := make([]Breakpoint, len(je.Points))
+	for i, p := range je.Points {
+		points[i] = Breakpoint{p.Time, p.Value}
+	}
+	env, err := NewEnvelope(points, interp)
+	if err != nil {
+		return err
+	}
+	env.Unit = unit
+	for i, p := range je.Points {
+		if p.Curve != 0 {
+			if err := env.SetCurve(i, p.Curve); err != nil {
+				return err
+			}
+		}
+		if h := p.Bezier; h != nil {
+			if err := env.SetBezier(i, Breakpoint{h[0], h[1]}, Breakpoint{h[2], h[3]}); err != nil {
+				return err
+			}
+		}
+	}
+	if je.Loop != nil {
+		mode, err := parseLoopMode(je.Loop.Mode)
+		if err != nil {
+			return err
+		}
+		if err := env.SetLoop(je.Loop.Start, je.Loop.End, mode); err != nil {
+			return err
+		}
+	}
+	*e = *env
+	return nil
+}
+
+// WriteCSV writes the envelope as rows of time, value and curve.
+// The header names the time unit, e.g. time_ms.
+// Bezier segments can't be written, use Linearize to turn them into straight lines first.
+func WriteCSV(w io.Writer, e *Envelope) error {
+	for _, seg := range e.Segments {
+		if seg.Bezier {
+			return errors.New("Bezier segments can't be written to CSV, linearize the envelope first")
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time_" + e.Unit.String(), "value", "curve"}); err != nil {
+		return err
+	}
+	for i, p := range e.Points {
+		row := []string{
+			strconv.FormatFloat(p.Time, 'g', -1, 64),
+			strconv.FormatFloat(p.Value, 'g', -1, 64),
+			strconv.FormatFloat(e.curveAt(i), 'g', -1, 64),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads a linear envelope from rows of time, value and an optional curve.
+// A header row is optional, its first column sets the time unit (time_s or time_ms),
+// without one the times are in seconds.
+func ReadCSV(r io.Reader) (*Envelope, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	unit := SECONDS
+	if len(rows) > 0 && strings.HasPrefix(rows[0][0], "time") {
+		if name := strings.TrimPrefix(rows[0][0], "time"); name != "" {
+			if unit, err = parseUnit(strings.TrimPrefix(name, "_")); err != nil {
+				return nil, err
+			}
+		}
+		rows = rows[1:]
+	}
+
+	points := make([]Breakpoint, len(rows))
+	curves := make([]float64, len(rows))
+	for i, row := range rows {
+		if len(row) < 2 || len(row) > 3 {
+			return nil, fmt.Errorf("Row %v should have a time, value and optional curve", i+1)
+		}
+		vals := make([]float64, len(row))
+		for j, field := range row {
+			if vals[j], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				return nil, err
+			}
+		}
+		points[i] = Breakpoint{vals[0], vals[1]}
+		if len(vals) == 3 {
+			curves[i] = vals[2]
+		}
+	}
+
+	env, err := NewEnvelope(points, LINEAR)
+	if err != nil {
+		return nil, err
+	}
+	env.Unit = unit
+	for i, c := range curves {
+		if c != 0 {
+			if err := env.SetCurve(i, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return env, nil
+}
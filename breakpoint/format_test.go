@@ -0,0 +1,109 @@
+package breakpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeJSON(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {250, 1}, {1000, .5}}, MONOTONE_CUBIC)
+	env.Unit = MILLISECONDS
+	env.SetCurve(1, -2)
+	env.SetLoop(250, 1000, LOOP_PING_PONG)
+	env.SetBezier(0, Breakpoint{.25, 0}, Breakpoint{.75, 1})
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("Should be able to marshal envelope: %v", err)
+	}
+	if !strings.Contains(string(data), `"unit":"ms"`) {
+		t.Fatalf("Expected the unit in the JSON, got %s", data)
+	}
+	decoded := &Envelope{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Should be able to unmarshal envelope: %v", err)
+	}
+	if !reflect.DeepEqual(env, decoded) {
+		t.Fatalf("Expected %+v after a round trip, got %+v", env, decoded)
+	}
+
+	invalid := []string{
+		`{"unit":"fortnights","points":[{"time":0,"value":0}]}`,
+		`{"interpolation":"wobbly","points":[{"time":0,"value":0}]}`,
+		`{"points":[]}`,
+	}
+	for _, in := range invalid {
+		t.Run("", func(t *testing.T) {
+			if err := json.Unmarshal([]byte(in), &Envelope{}); err == nil {
+				t.Fatalf("Expected an error for %v", in)
+			}
+		})
+	}
+}
+
+func TestEnvelopeCSV(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {.5, 1}, {2, 0}}, LINEAR)
+	env.SetCurve(0, 3)
+
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, env); err != nil {
+		t.Fatalf("Should be able to write CSV: %v", err)
+	}
+	decoded, err := ReadCSV(buf)
+	if err != nil {
+		t.Fatalf("Should be able to read CSV: %v", err)
+	}
+	if !reflect.DeepEqual(env, decoded) {
+		t.Fatalf("Expected %+v after a round trip, got %+v", env, decoded)
+	}
+
+	env.SetBezier(1, Breakpoint{.5, 0}, Breakpoint{.5, 1})
+	if err := WriteCSV(&bytes.Buffer{}, env); err == nil {
+		t.Fatal("Expected an error writing Bezier segments")
+	}
+	if err := WriteCSV(&bytes.Buffer{}, env.Linearize(.01)); err != nil {
+		t.Fatalf("Should be able to write a linearized envelope: %v", err)
+	}
+
+	tests := []struct {
+		in     string
+		unit   TimeUnit
+		points Breakpoints
+	}{
+		{"0,1\n1,2\n", SECONDS, Breakpoints{{0, 1}, {1, 2}}},
+		{"time_ms, value\n0, 1\n500, 2\n", MILLISECONDS, Breakpoints{{0, 1}, {500, 2}}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			env, err := ReadCSV(strings.NewReader(test.in))
+			if err != nil {
+				t.Fatalf("Should be able to read CSV: %v", err)
+			}
+			if env.Unit != test.unit || !reflect.DeepEqual(env.Points, test.points) {
+				t.Fatalf("Expected %v in %v, got %v in %v", test.points, test.unit, env.Points, env.Unit)
+			}
+		})
+	}
+
+	if _, err := ReadCSV(strings.NewReader("0,1\nx,2\n")); err == nil {
+		t.Fatal("Expected an error for an invalid time")
+	}
+}
+
+func TestMillisecondStream(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1000, 1}}, LINEAR)
+	env.Unit = MILLISECONDS
+	stream, err := env.Stream(10)
+	if err != nil {
+		t.Fatalf("Should be able to stream envelope: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		stream.Tick()
+	}
+	if value := stream.Tick(); value != .5 {
+		t.Fatalf("Expected .5 after half a second, got %v", value)
+	}
+}
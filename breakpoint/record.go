@@ -0,0 +1,50 @@
+package breakpoint
+
+// capturing live control input into envelopes
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Record reads timestamped values from the channel until it is closed and turns them into
+// a linear envelope. Points arriving out of order are sorted by time.
+// When epsilon is larger than 0 the points are thinned with Simplify, 0 keeps every point.
+func Record(in <-chan Breakpoint, epsilon float64) (*Envelope, error) {
+	points := Breakpoints{}
+	for p := range in {
+		points = append(points, p)
+	}
+	if len(points) == 0 {
+		return nil, errors.New("No values were recorded")
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time < points[j].Time })
+	if epsilon > 0 {
+		points = Simplify(points, epsilon)
+	}
+	return NewEnvelope(points, LINEAR)
+}
+
+// RecordValues reads values from the channel until it is closed, such as MIDI CC or OSC input,
+// and stamps them with the time in seconds since the first value arrived.
+// The values are turned into an envelope as with Record.
+func RecordValues(in <-chan float64, epsilon float64) (*Envelope, error) {
+	return recordValues(in, epsilon, time.Now)
+}
+
+func recordValues(in <-chan float64, epsilon float64, now func() time.Time) (*Envelope, error) {
+	stamped := make(chan Breakpoint)
+	go func() {
+		defer close(stamped)
+		var start time.Time
+		for v := range in {
+			t := now()
+			if start.IsZero() {
+				start = t
+			}
+			stamped <- Breakpoint{t.Sub(start).Seconds(), v}
+		}
+	}()
+	return Record(stamped, epsilon)
+}
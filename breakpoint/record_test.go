@@ -0,0 +1,76 @@
+package breakpoint
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		in      []Breakpoint
+		epsilon float64
+		out     []Breakpoint
+	}{
+		{
+			[]Breakpoint{{0, 0}, {.5, .5}, {1, 1}, {1.5, 1}},
+			0,
+			[]Breakpoint{{0, 0}, {.5, .5}, {1, 1}, {1.5, 1}},
+		},
+		{
+			[]Breakpoint{{0, 0}, {.5, .5}, {1, 1}, {1.5, 1}},
+			.01,
+			[]Breakpoint{{0, 0}, {1, 1}, {1.5, 1}},
+		},
+		{
+			[]Breakpoint{{1, 1}, {0, 0}},
+			0,
+			[]Breakpoint{{0, 0}, {1, 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			in := make(chan Breakpoint, len(test.in))
+			for _, p := range test.in {
+				in <- p
+			}
+			close(in)
+			env, err := Record(in, test.epsilon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual([]Breakpoint(env.Points), test.out) {
+				t.Fatalf("Expected %v, got %v", test.out, env.Points)
+			}
+		})
+	}
+
+	in := make(chan Breakpoint)
+	close(in)
+	if _, err := Record(in, 0); err == nil {
+		t.Fatal("Expected an error when nothing was recorded")
+	}
+}
+
+func TestRecordValues(t *testing.T) {
+	clock := time.Unix(100, 0)
+	now := func() time.Time {
+		clock = clock.Add(250 * time.Millisecond)
+		return clock
+	}
+	in := make(chan float64)
+	go func() {
+		for _, v := range []float64{.2, .4, .4} {
+			in <- v
+		}
+		close(in)
+	}()
+	env, err := recordValues(in, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Breakpoint{{0, .2}, {.25, .4}, {.5, .4}}
+	if !reflect.DeepEqual([]Breakpoint(env.Points), expected) {
+		t.Fatalf("Expected %v, got %v", expected, env.Points)
+	}
+}
@@ -0,0 +1,43 @@
+package breakpoint
+
+// rendering envelopes to audio-rate control signals
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// RenderEnvelope evaluates the envelope for every sample of duration seconds
+func RenderEnvelope(env *Envelope, sampleRate int, duration float64) ([]wave.Frame, error) {
+	if sampleRate <= 0 || duration < 0 {
+		return nil, errors.New("Sample rate should be positive and duration not negative")
+	}
+	stream, err := env.Stream(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]wave.Frame, int(duration*float64(sampleRate)))
+	for i := range frames {
+		frames[i] = wave.Frame(stream.Tick())
+	}
+	return frames, nil
+}
+
+// WriteEnvelope renders the envelope and writes it to a mono 16-bit wave file, as a control
+// signal for modular or CV workflows. Values outside of [-1;1] are clipped.
+func WriteEnvelope(env *Envelope, sampleRate int, duration float64, file string) error {
+	frames, err := RenderEnvelope(env, sampleRate, duration)
+	if err != nil {
+		return err
+	}
+	for i, f := range frames {
+		frames[i] = wave.Frame(math.Max(-1, math.Min(float64(f), 1)))
+	}
+	wfmt, err := wave.NewValidWaveFmt(wave.PCM, 1, sampleRate, 16)
+	if err != nil {
+		return err
+	}
+	return wave.WriteFrames(frames, wfmt, file)
+}
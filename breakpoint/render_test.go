@@ -0,0 +1,46 @@
+package breakpoint
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestRenderEnvelope(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {1000, 1}, {2000, 2}}, LINEAR)
+	env.Unit = MILLISECONDS
+	frames, err := RenderEnvelope(env, 100, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 300 {
+		t.Fatalf("Expected 300 frames, got %v", len(frames))
+	}
+	for i, expected := range map[int]float64{0: 0, 50: .5, 150: 1.5, 299: 2} {
+		if math.Abs(float64(frames[i])-expected) > 1e-9 {
+			t.Fatalf("Expected %v at frame %v, got %v", expected, i, frames[i])
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "env.wav")
+	if err := WriteEnvelope(env, 100, 3, file); err != nil {
+		t.Fatal(err)
+	}
+	w, err := wave.ReadWaveFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Frames) != 300 {
+		t.Fatalf("Expected 300 frames in the file, got %v", len(w.Frames))
+	}
+	if math.Abs(float64(w.Frames[50])-.5) > .001 || math.Abs(float64(w.Frames[299])-1) > .001 {
+		t.Fatalf("Expected the rendered values clipped to 1, got %v and %v", w.Frames[50], w.Frames[299])
+	}
+
+	env.Unit = BEATS
+	if _, err := RenderEnvelope(env, 100, 1); err == nil {
+		t.Fatal("Expected an error for an envelope in beats")
+	}
+}
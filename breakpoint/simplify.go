@@ -0,0 +1,46 @@
+package breakpoint
+
+import "math"
+
+// Simplify removes breakpoints using the Ramer-Douglas-Peucker algorithm, keeping the
+// fewest points for which the linear envelope stays within epsilon of the original.
+// The error is measured on the value at the time of every removed point, as time and
+// value usually have different units. The first and last point are always kept.
+func Simplify(points []Breakpoint, epsilon float64) []Breakpoint {
+	if len(points) < 3 {
+		return append([]Breakpoint{}, points...)
+	}
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	// spans still to be checked, as pairs of indices of kept points
+	spans := [][2]int{{0, len(points) - 1}}
+	for len(spans) > 0 {
+		span := spans[len(spans)-1]
+		spans = spans[:len(spans)-1]
+		left, right := points[span[0]], points[span[1]]
+
+		worst, worstIndex := 0.0, -1
+		for i := span[0] + 1; i < span[1]; i++ {
+			expected := right.Value
+			if width := right.Time - left.Time; width != 0 {
+				expected = left.Value + (right.Value-left.Value)*(points[i].Time-left.Time)/width
+			}
+			if d := math.Abs(points[i].Value - expected); d > worst {
+				worst, worstIndex = d, i
+			}
+		}
+		if worstIndex >= 0 && worst > epsilon {
+			keep[worstIndex] = true
+			spans = append(spans, [2]int{span[0], worstIndex}, [2]int{worstIndex, span[1]})
+		}
+	}
+
+	simplified := []Breakpoint{}
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
@@ -0,0 +1,62 @@
+package breakpoint
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		in      []Breakpoint
+		epsilon float64
+		out     []Breakpoint
+	}{
+		{
+			[]Breakpoint{{0, 0}, {1, 1}, {2, 2}, {3, 3}},
+			0,
+			[]Breakpoint{{0, 0}, {3, 3}},
+		},
+		{
+			[]Breakpoint{{0, 0}, {1, 1}, {2, 0}},
+			.5,
+			[]Breakpoint{{0, 0}, {1, 1}, {2, 0}},
+		},
+		{
+			[]Breakpoint{{0, 0}, {1, .1}, {2, 0}},
+			.5,
+			[]Breakpoint{{0, 0}, {2, 0}},
+		},
+		{
+			[]Breakpoint{{0, 0}, {1, 1}},
+			1,
+			[]Breakpoint{{0, 0}, {1, 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if res := Simplify(test.in, test.epsilon); !reflect.DeepEqual(res, test.out) {
+				t.Fatalf("Expected %v, got %v", test.out, res)
+			}
+		})
+	}
+}
+
+// TestSimplifyDense simplifies a densely sampled curve and checks the tolerance holds
+func TestSimplifyDense(t *testing.T) {
+	dense := make([]Breakpoint, 10000)
+	for i := range dense {
+		time := float64(i) / 1000
+		dense[i] = Breakpoint{time, math.Sin(time)}
+	}
+	simplified := Simplify(dense, .001)
+	if len(simplified) > 200 {
+		t.Fatalf("Expected far fewer points, got %v", len(simplified))
+	}
+	env, _ := NewEnvelope(simplified, LINEAR)
+	for _, p := range dense {
+		if d := math.Abs(env.ValueAt(p.Time) - p.Value); d > .001 {
+			t.Fatalf("Expected an error below .001, got %v at %v", d, p.Time)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package breakpoint
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// TempoChange sets the tempo from a beat onwards
+type TempoChange struct {
+	Beat float64
+	BPM  float64
+}
+
+// MeterChange sets the amount of beats per bar from the start of a bar onwards
+type MeterChange struct {
+	Bar         int
+	BeatsPerBar float64
+}
+
+// TempoMap converts between beats and seconds for music with tempo changes
+type TempoMap struct {
+	Changes     []TempoChange // sorted by beat, the first change is at beat 0
+	BeatsPerBar float64       // beats per bar up to the first meter change
+	Meters      []MeterChange // sorted by bar
+}
+
+// NewTempoMap creates a tempo map in 4/4 starting at a tempo in beats per minute
+func NewTempoMap(bpm float64) (*TempoMap, error) {
+	if bpm <= 0 {
+		return nil, errors.New("Tempo should be positive")
+	}
+	return &TempoMap{
+		Changes:     []TempoChange{{0, bpm}},
+		BeatsPerBar: 4,
+	}, nil
+}
+
+// SetTempo changes the tempo from a beat onwards, replacing any change at the same beat
+func (t *TempoMap) SetTempo(beat, bpm float64) error {
+	if bpm <= 0 {
+		return errors.New("Tempo should be positive")
+	}
+	if beat < 0 {
+		return errors.New("Tempo changes can't be before the first beat")
+	}
+	i := sort.Search(len(t.Changes), func(i int) bool { return t.Changes[i].Beat >= beat })
+	if i < len(t.Changes) && t.Changes[i].Beat == beat {
+		t.Changes[i].BPM = bpm
+		return nil
+	}
+	t.Changes = append(t.Changes, TempoChange{})
+	copy(t.Changes[i+1:], t.Changes[i:])
+	t.Changes[i] = TempoChange{beat, bpm}
+	return nil
+}
+
+// SetMeter changes the amount of beats per bar from a bar onwards, replacing any change at the same bar
+func (t *TempoMap) SetMeter(bar int, beatsPerBar float64) error {
+	if beatsPerBar <= 0 {
+		return errors.New("A bar needs a positive amount of beats")
+	}
+	if bar < 0 {
+		return errors.New("Meter changes can't be before the first bar")
+	}
+	i := sort.Search(len(t.Meters), func(i int) bool { return t.Meters[i].Bar >= bar })
+	if i < len(t.Meters) && t.Meters[i].Bar == bar {
+		t.Meters[i].BeatsPerBar = beatsPerBar
+		return nil
+	}
+	t.Meters = append(t.Meters, MeterChange{})
+	copy(t.Meters[i+1:], t.Meters[i:])
+	t.Meters[i] = MeterChange{bar, beatsPerBar}
+	return nil
+}
+
+// Seconds returns the time at which a beat is played
+func (t *TempoMap) Seconds(beat float64) float64 {
+	seconds := 0.0
+	for i, c := range t.Changes {
+		if i > 0 && beat <= c.Beat {
+			break
+		}
+		end := beat
+		if i+1 < len(t.Changes) && t.Changes[i+1].Beat < beat {
+			end = t.Changes[i+1].Beat
+		}
+		seconds += (end - c.Beat) * 60 / c.BPM
+	}
+	return seconds
+}
+
+// Beats returns the beat played at a time in seconds
+func (t *TempoMap) Beats(seconds float64) float64 {
+	elapsed := 0.0
+	for i, c := range t.Changes {
+		if i+1 < len(t.Changes) {
+			length := (t.Changes[i+1].Beat - c.Beat) * 60 / c.BPM
+			if elapsed+length < seconds {
+				elapsed += length
+				continue
+			}
+		}
+		return c.Beat + (seconds-elapsed)*c.BPM/60
+	}
+	return 0
+}
+
+// Bar returns the beat at the start of a bar, counting from bar 0
+func (t *TempoMap) Bar(bar float64) float64 {
+	beat, start, perBar := 0.0, 0.0, t.BeatsPerBar
+	for _, m := range t.Meters {
+		if float64(m.Bar) >= bar {
+			break
+		}
+		beat += (float64(m.Bar) - start) * perBar
+		start, perBar = float64(m.Bar), m.BeatsPerBar
+	}
+	return beat + (bar-start)*perBar
+}
+
+// BarAt returns the bar in which a beat is played, counting from bar 0, and the beat within that bar
+func (t *TempoMap) BarAt(beat float64) (bar int, inBar float64) {
+	first, start, perBar := 0.0, 0, t.BeatsPerBar
+	for _, m := range t.Meters {
+		next := first + float64(m.Bar-start)*perBar
+		if next > beat {
+			break
+		}
+		first, start, perBar = next, m.Bar, m.BeatsPerBar
+	}
+	bars := math.Floor((beat - first) / perBar)
+	return start + int(bars), beat - first - bars*perBar
+}
+
+// Sample returns the frame at which a beat is played at a sample rate
+func (t *TempoMap) Sample(beat float64, sr int) int {
+	return int(t.Seconds(beat)*float64(sr) + .5)
+}
+
+// ToSeconds returns a copy of the envelope with its times converted to seconds.
+// Envelopes in beats are converted with the tempo map, which may be nil for other units.
+func (e *Envelope) ToSeconds(t *TempoMap) (*Envelope, error) {
+	var convert func(float64) float64
+	if e.Unit == BEATS {
+		if t == nil {
+			return nil, errors.New("Need a tempo map to convert beats to seconds")
+		}
+		convert = t.Seconds
+	} else {
+		scale, err := e.Unit.perSecond()
+		if err != nil {
+			return nil, err
+		}
+		convert = func(time float64) float64 { return time / scale }
+	}
+	converted := *e
+	converted.Unit = SECONDS
+	converted.Points = make(Breakpoints, len(e.Points))
+	for i, p := range e.Points {
+		converted.Points[i] = Breakpoint{convert(p.Time), p.Value}
+	}
+	converted.Segments = append([]Segment(nil), e.Segments...)
+	return &converted, nil
+}
@@ -0,0 +1,102 @@
+package breakpoint
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTempoMap(t *testing.T) {
+	tm, err := NewTempoMap(120)
+	if err != nil {
+		t.Fatalf("Should be able to create tempo map: %v", err)
+	}
+	tm.SetTempo(8, 60)
+	tm.SetTempo(4, 240)
+
+	tests := []struct {
+		beat    float64
+		seconds float64
+	}{
+		{0, 0},
+		{2, 1},
+		{4, 2},
+		{6, 2.5},
+		{8, 3},
+		{10, 5},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := tm.Seconds(test.beat); math.Abs(s-test.seconds) > 1e-9 {
+				t.Fatalf("Expected beat %v at %vs, got %v", test.beat, test.seconds, s)
+			}
+			if b := tm.Beats(test.seconds); math.Abs(b-test.beat) > 1e-9 {
+				t.Fatalf("Expected %vs at beat %v, got %v", test.seconds, test.beat, b)
+			}
+		})
+	}
+
+	if s := tm.Sample(tm.Bar(1), 1000); s != 2000 {
+		t.Fatalf("Expected the second bar at sample 2000, got %v", s)
+	}
+	if err := tm.SetTempo(2, 0); err == nil {
+		t.Fatal("Expected an error for a tempo of 0")
+	}
+}
+
+func TestMeters(t *testing.T) {
+	tm, _ := NewTempoMap(120)
+	// two bars of 4/4, a bar of 3/4 and 6/8 counted in eighths from then on
+	tm.SetMeter(3, 6)
+	tm.SetMeter(2, 3)
+	if err := tm.SetMeter(1, 0); err == nil {
+		t.Fatal("Expected an error for a bar without beats")
+	}
+
+	tests := []struct {
+		beat  float64
+		bar   int
+		inBar float64
+	}{
+		{0, 0, 0},
+		{5, 1, 1},
+		{8, 2, 0},
+		{10.5, 2, 2.5},
+		{11, 3, 0},
+		{18, 4, 1},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			bar, inBar := tm.BarAt(test.beat)
+			if bar != test.bar || math.Abs(inBar-test.inBar) > 1e-9 {
+				t.Fatalf("Expected beat %v in bar %v at %v, got bar %v at %v", test.beat, test.bar, test.inBar, bar, inBar)
+			}
+			if b := tm.Bar(float64(bar)) + inBar; math.Abs(b-test.beat) > 1e-9 {
+				t.Fatalf("Expected bar %v to start at beat %v, got %v", bar, test.beat-inBar, b-inBar)
+			}
+		})
+	}
+}
+
+func TestEnvelopeInBeats(t *testing.T) {
+	env, _ := NewEnvelope([]Breakpoint{{0, 0}, {4, 1}, {8, 0}}, LINEAR)
+	env.Unit = BEATS
+	if _, err := env.Stream(10); err == nil {
+		t.Fatal("Expected an error streaming beats without a tempo")
+	}
+
+	tm, _ := NewTempoMap(120)
+	tm.SetTempo(4, 60)
+	seconds, err := env.ToSeconds(tm)
+	if err != nil {
+		t.Fatalf("Should be able to convert to seconds: %v", err)
+	}
+	expected := Breakpoints{{0, 0}, {2, 1}, {6, 0}}
+	for i, p := range seconds.Points {
+		if p != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected[i], p)
+		}
+	}
+	if env.Points[1].Time != 4 {
+		t.Fatal("Expected the original envelope to be unchanged")
+	}
+}
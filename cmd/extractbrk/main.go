@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/DylanMeeus/GoAudio/breakpoint"
 	wav "github.com/DylanMeeus/GoAudio/wave"
 )
 
@@ -15,6 +16,7 @@ var (
 	input  = flag.String("i", "", "input file")
 	output = flag.String("o", "", "output file")
 	window = flag.Int("w", 15, "window of time for capturing breakpoint data")
+	eps    = flag.Float64("e", 0, "remove points which change the envelope by less than this amount")
 )
 
 func main() {
@@ -29,15 +31,22 @@ func main() {
 	ticks := float64(*window) / 1000.0
 	batches := wav.BatchSamples(wave, ticks)
 
-	strout := strings.Builder{}
+	points := []breakpoint.Breakpoint{}
 	elapsed := 0.0
 	for _, b := range batches {
-		maxa := maxAmp(b)
-		es := strconv.FormatFloat(elapsed, 'f', 8, 64)
-		fs := strconv.FormatFloat(maxa, 'f', 8, 64)
-		strout.WriteString(es + ":" + fs + "\n")
+		points = append(points, breakpoint.Breakpoint{Time: elapsed, Value: maxAmp(b)})
 		elapsed += ticks
 	}
+	if *eps > 0 {
+		points = breakpoint.Simplify(points, *eps)
+	}
+
+	strout := strings.Builder{}
+	for _, p := range points {
+		es := strconv.FormatFloat(p.Time, 'f', 8, 64)
+		fs := strconv.FormatFloat(p.Value, 'f', 8, 64)
+		strout.WriteString(es + ":" + fs + "\n")
+	}
 
 	err = ioutil.WriteFile(outfile, []byte(strout.String()), 0644)
 	if err != nil {
@@ -0,0 +1,334 @@
+package main
+
+// goaudio edits, inspects and renders audio files with the packages of GoAudio
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/DylanMeeus/GoAudio/audio"
+	"github.com/DylanMeeus/GoAudio/midi"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+const usage = `Usage: goaudio <command> [flags] <files>
+
+Commands:
+  info [-chunks] <in.wav>                         print the format and levels of a file
+  convert [-rate] [-bits] [-float] <in> <out>     change the sample rate and encoding
+  trim [-start] [-end] <in> <out>                 keep the samples between two times
+  concat <out> <in>...                            join files of the same format
+  normalize [-peak] <in> <out>                    scale the peak to a level in dBFS
+  apply -effect [-param name=value]... <in> <out> run a registered effect
+  apply -preset <preset.json> <in> <out>          run the effects of a preset
+  effects                                         list the effects and their parameters
+  spectrogram [-window] [-hop] <in.wav> <out.png> draw the spectrum over time
+  synth render [-rate] <in.mid> <out.wav>         play a MIDI file on the built-in instruments
+
+Run goaudio <command> -h for the flags of a command.
+`
+
+// errUsage is returned for arguments which don't make a command
+var errUsage = errors.New("Invalid arguments")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if err == errUsage {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "goaudio: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command of the arguments, writing what it prints to out
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	commands := map[string]func(context.Context, []string, io.Writer) error{
+		"info":        info,
+		"convert":     convert,
+		"trim":        trim,
+		"concat":      concat,
+		"normalize":   normalize,
+		"apply":       apply,
+		"effects":     effects,
+		"spectrogram": spectrogram,
+		"synth":       synthesize,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return errUsage
+	}
+	return command(ctx, args[1:], out)
+}
+
+// parse parses the flags of a command, which needs n files after them, or at least n when
+// more is set
+func parse(fs *flag.FlagSet, args []string, n int, more bool) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fs.SetOutput(os.Stderr)
+			fs.PrintDefaults()
+		}
+		return nil, err
+	}
+	files := fs.Args()
+	if len(files) < n || (!more && len(files) > n) {
+		return nil, errUsage
+	}
+	return files, nil
+}
+
+func info(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	chunks := fs.Bool("chunks", false, "print the chunks of the file rather than its levels")
+	files, err := parse(fs, args, 1, false)
+	if err != nil {
+		return err
+	}
+	if *chunks {
+		f, err := os.Open(files[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		root, err := wave.Inspect(f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, root)
+		return nil
+	}
+	w, err := wave.ReadWaveFile(files[0])
+	if err != nil {
+		return err
+	}
+	peak, sum := 0.0, 0.0
+	for _, f := range w.Frames {
+		peak = math.Max(peak, math.Abs(float64(f)))
+		sum += float64(f * f)
+	}
+	rms := 0.0
+	if len(w.Frames) > 0 {
+		rms = math.Sqrt(sum / float64(len(w.Frames)))
+	}
+	samples := len(w.Frames) / w.NumChannels
+	fmt.Fprintf(out, "Format: %v\n", formatName(w.AudioFormat))
+	fmt.Fprintf(out, "Channels: %v\n", w.NumChannels)
+	fmt.Fprintf(out, "SampleRate: %v\n", w.SampleRate)
+	fmt.Fprintf(out, "BitsPerSample: %v\n", w.BitsPerSample)
+	fmt.Fprintf(out, "Samples: %v\n", samples)
+	fmt.Fprintf(out, "Duration: %.3fs\n", float64(samples)/float64(w.SampleRate))
+	fmt.Fprintf(out, "Peak: %.2f dBFS\n", decibels(peak))
+	fmt.Fprintf(out, "RMS: %.2f dBFS\n", decibels(rms))
+	return nil
+}
+
+// formatName names the audio format of the fmt chunk
+func formatName(format int) string {
+	switch format {
+	case wave.PCM:
+		return "PCM"
+	case wave.IEEE_FLOAT:
+		return "IEEE float"
+	case wave.EXTENSIBLE:
+		return "extensible"
+	}
+	return fmt.Sprintf("unknown (%v)", format)
+}
+
+// decibels converts a level to dBFS
+func decibels(level float64) float64 {
+	return 20 * math.Log10(level)
+}
+
+func convert(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	rate := fs.Int("rate", 0, "sample rate, the rate of the input when 0")
+	bits := fs.Int("bits", 0, "bits per sample, 16, 24 or 32, those of the input when 0")
+	float := fs.Bool("float", false, "write 32 bit floats")
+	files, err := parse(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	a := audio.Load(files[0]).WithContext(ctx)
+	if *rate > 0 {
+		a.Resample(*rate)
+	}
+	if *bits > 0 {
+		a.BitDepth(*bits)
+	}
+	opts := []wave.WriteOption{}
+	if *float {
+		opts = append(opts, wave.WithFloat())
+	}
+	return a.Save(files[1], opts...)
+}
+
+func trim(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("trim", flag.ContinueOnError)
+	start := fs.Float64("start", 0, "start in seconds")
+	end := fs.Float64("end", -1, "end in seconds, the end of the input when below 0")
+	files, err := parse(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	w, err := wave.ReadRawWaveFile(files[0])
+	if err != nil {
+		return err
+	}
+	to := w.Length()
+	if *end >= 0 {
+		to = int64(math.Min(*end*float64(w.SampleRate), float64(to)))
+	}
+	// trimming the stored samples keeps them as they are
+	w, err = w.Trim(int64(*start*float64(w.SampleRate)), to)
+	if err != nil {
+		return err
+	}
+	return w.WriteFile(files[1])
+}
+
+func concat(ctx context.Context, args []string, out io.Writer) error {
+	files, err := parse(flag.NewFlagSet("concat", flag.ContinueOnError), args, 3, true)
+	if err != nil {
+		return err
+	}
+	waves := []wave.RawWave{}
+	for _, f := range files[1:] {
+		w, err := wave.ReadRawWaveFile(f)
+		if err != nil {
+			return err
+		}
+		waves = append(waves, w)
+	}
+	joined, err := wave.ConcatRaw(waves...)
+	if err != nil {
+		return err
+	}
+	return joined.WriteFile(files[0])
+}
+
+func normalize(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("normalize", flag.ContinueOnError)
+	peak := fs.Float64("peak", -1, "level of the peak in dBFS")
+	files, err := parse(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	return audio.Load(files[0]).WithContext(ctx).Normalize(*peak).Save(files[1])
+}
+
+// params collects repeated name=value flags
+type params map[string]float64
+
+func (p params) String() string {
+	return fmt.Sprint(map[string]float64(p))
+}
+
+func (p params) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("Expected name=value, got %v", s)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	p[name] = v
+	return nil
+}
+
+func apply(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	effect := fs.String("effect", "", "name of the effect, as listed by goaudio effects")
+	values := params{}
+	fs.Var(values, "param", "parameter of the effect as name=value, can be repeated")
+	preset := fs.String("preset", "", "JSON preset with a chain of effects, instead of -effect")
+	save := fs.String("save", "", "also store the effect with its parameters as a JSON preset")
+	files, err := parse(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	a := audio.Load(files[0]).WithContext(ctx)
+	switch {
+	case *effect != "" && *preset == "":
+		a.Effect(*effect, values)
+		if *save != "" {
+			p := synth.Preset{Effects: []synth.PresetEffect{{Effect: *effect, Params: values}}}
+			if err := p.Validate(); err != nil {
+				return err
+			}
+			if err := synth.WritePresetFile(*save, p); err != nil {
+				return err
+			}
+		}
+	case *preset != "" && *effect == "" && len(values) == 0:
+		p, err := synth.ReadPresetFile(*preset)
+		if err != nil {
+			return err
+		}
+		a.Preset(p)
+	default:
+		return errUsage
+	}
+	return a.Save(files[1])
+}
+
+func effects(ctx context.Context, args []string, out io.Writer) error {
+	if _, err := parse(flag.NewFlagSet("effects", flag.ContinueOnError), args, 0, false); err != nil {
+		return err
+	}
+	for _, name := range synth.Effects() {
+		f, _ := synth.LookupEffect(name)
+		fmt.Fprintf(out, "%v: %v\n", name, f.Description)
+		for _, p := range f.Params {
+			fmt.Fprintf(out, "  %v in [%v;%v]%v, default %v\n", p.Name, p.Min, p.Max, strings.TrimRight(" "+p.Unit, " "), p.Default)
+		}
+	}
+	return nil
+}
+
+func synthesize(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "render" {
+		return errUsage
+	}
+	fs := flag.NewFlagSet("synth render", flag.ContinueOnError)
+	rate := fs.Int("rate", 44100, "sample rate")
+	files, err := parse(fs, args[1:], 2, false)
+	if err != nil {
+		return err
+	}
+	f, err := midi.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+	wfmt, err := wave.NewValidWaveFmt(wave.PCM, 2, *rate, 16)
+	if err != nil {
+		return err
+	}
+	frames, err := midi.RenderCtx(ctx, f, midi.NewGMMap(*rate).Instrument, wfmt)
+	if err != nil {
+		return err
+	}
+	// the instruments are mixed without a limit, so the mix can go past full scale
+	return audio.FromFrames(frames, wfmt).WithContext(ctx).Normalize(-1).Save(files[1])
+}
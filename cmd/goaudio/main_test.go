@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/midi"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// TestCommands runs every command on the files of the one before it
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	// a second of a stereo sine at half scale
+	frames := make([]wave.Frame, 2*8000)
+	for i := range frames {
+		frames[i] = wave.Frame(.5 * math.Sin(2*math.Pi*440*float64(i/2)/8000))
+	}
+	if err := wave.WriteWaveFile(frames, wave.NewWaveFmt(wave.PCM, 2, 8000, 16, nil), path("in.wav")); err != nil {
+		t.Fatal(err)
+	}
+	song := &midi.File{Format: 0, Division: 96, Tracks: []midi.Track{{Events: []midi.Event{
+		{Tick: 0, Type: midi.NOTE_ON, Note: 60, Velocity: 100},
+		{Tick: 96, Type: midi.NOTE_OFF, Note: 60},
+	}}}}
+	if err := midi.WriteFile(path("song.mid"), song); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		output string // a line printed
+		file   string // file written
+		length int    // samples per channel in the file written
+		rate   int
+	}{
+		{args: []string{"info", path("in.wav")}, output: "Peak: -6.02 dBFS"},
+		{args: []string{"convert", "-rate", "16000", path("in.wav"), path("convert.wav")}, file: path("convert.wav"), length: 16000, rate: 16000},
+		{args: []string{"convert", "-float", path("in.wav"), path("float.wav")}, file: path("float.wav"), length: 8000, rate: 8000},
+		{args: []string{"convert", "-bits", "24", path("float.wav"), path("bits24.wav")}, file: path("bits24.wav"), length: 8000, rate: 8000},
+		{args: []string{"info", path("bits24.wav")}, output: "Format: PCM\nChannels: 2\nSampleRate: 8000\nBitsPerSample: 24"},
+		{args: []string{"trim", "-start", ".25", "-end", ".5", path("in.wav"), path("trim.wav")}, file: path("trim.wav"), length: 2000, rate: 8000},
+		{args: []string{"info", "-chunks", path("trim.wav")}, output: `"data" at 36, 8000 bytes: 2000 samples per channel, 0.250s`},
+		{args: []string{"concat", path("concat.wav"), path("in.wav"), path("trim.wav")}, file: path("concat.wav"), length: 10000, rate: 8000},
+		{args: []string{"normalize", "-peak", "0", path("trim.wav"), path("normalize.wav")}, file: path("normalize.wav"), length: 2000, rate: 8000},
+		{args: []string{"info", path("normalize.wav")}, output: "RMS: -3.01 dBFS"},
+		{args: []string{"apply", "-effect", "lowpass", "-param", "cutoff=500", path("trim.wav"), path("apply.wav")}, file: path("apply.wav"), length: 2000, rate: 8000},
+		{args: []string{"apply", "-effect", "gain", "-param", "gain=0.5", "-save", path("half.json"), path("trim.wav"), path("half.wav")}, file: path("half.wav"), length: 2000, rate: 8000},
+		{args: []string{"apply", "-preset", path("half.json"), path("half.wav"), path("quarter.wav")}, file: path("quarter.wav"), length: 2000, rate: 8000},
+		{args: []string{"info", path("quarter.wav")}, output: "RMS: -21.07 dBFS"},
+		{args: []string{"effects"}, output: "cutoff in [20;20000] Hz, default 1000"},
+		{args: []string{"synth", "render", "-rate", "8000", path("song.mid"), path("song.wav")}, file: path("song.wav"), rate: 8000},
+		{args: []string{"spectrogram", "-window", "256", "-hop", "128", path("in.wav"), path("in.png")}},
+	}
+	for _, test := range tests {
+		t.Run(test.args[0], func(t *testing.T) {
+			out := bytes.Buffer{}
+			if err := run(context.Background(), test.args, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), test.output) {
+				t.Fatalf("Expected %q in the output, got %q", test.output, out.String())
+			}
+			if test.file == "" {
+				return
+			}
+			w, err := wave.ReadWaveFile(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.SampleRate != test.rate || (test.length > 0 && len(w.Frames)/w.NumChannels != test.length) {
+				t.Fatalf("Expected %v samples at %vHz, got %v at %vHz", test.length, test.rate, len(w.Frames)/w.NumChannels, w.SampleRate)
+			}
+		})
+	}
+
+	f, err := os.Open(path("in.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 61 transforms of 128 bins, with the sine as the brightest row
+	if b := img.Bounds(); b.Dx() != 61 || b.Dy() != 128 {
+		t.Fatalf("Expected an image of 61x128, got %v", b)
+	}
+	row := 127 - 14 // 440Hz is in bin 14 of 31.25Hz
+	if r, _, _, _ := img.At(30, row).RGBA(); r < 0xF000 {
+		t.Fatalf("Expected the bin of the sine to be about white, got %v", r)
+	}
+
+	for _, args := range [][]string{nil, {"unknown"}, {"info"}, {"synth", "play"}, {"apply", "a.wav", "b.wav"}, {"apply", "-effect", "gain", "-preset", "p.json", "a.wav", "b.wav"}} {
+		if err := run(context.Background(), args, &bytes.Buffer{}); err != errUsage {
+			t.Fatalf("Expected %v to be refused, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// spectrumRange is the range in decibels below the loudest bin drawn from white to black
+const spectrumRange = 90
+
+func spectrogram(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("spectrogram", flag.ContinueOnError)
+	window := fs.Int("window", 1024, "samples per transform, a power of 2")
+	hop := fs.Int("hop", 256, "samples between transforms")
+	files, err := parse(fs, args, 2, false)
+	if err != nil {
+		return err
+	}
+	if *hop < 1 {
+		return errors.New("The hop should be at least one sample")
+	}
+	plan, err := audiomath.NewPlan(*window)
+	if err != nil {
+		return err
+	}
+	w, err := wave.ReadWaveFile(files[0])
+	if err != nil {
+		return err
+	}
+
+	// the channels are mixed to mono
+	mono := make([]wave.Frame, len(w.Frames)/w.NumChannels)
+	for i := range mono {
+		for c := 0; c < w.NumChannels; c++ {
+			mono[i] += w.Frames[i*w.NumChannels+c] / wave.Frame(w.NumChannels)
+		}
+	}
+	hann := make([]wave.Frame, *window)
+	for i := range hann {
+		hann[i] = wave.Frame(.5 - .5*math.Cos(2*math.Pi*float64(i)/float64(*window)))
+	}
+
+	// a column of decibels per hop, from the lowest to the highest bin
+	columns := [][]float64{}
+	loudest := math.Inf(-1)
+	buf := make([]wave.Frame, *window)
+	spectrum := make([]complex128, *window/2+1)
+	for start := 0; start+*window <= len(mono); start += *hop {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for i := range buf {
+			buf[i] = mono[start+i] * hann[i]
+		}
+		plan.Real(spectrum, buf)
+		column := make([]float64, *window/2)
+		for i := range column {
+			column[i] = 20 * math.Log10(cmplx.Abs(spectrum[i])+1e-12)
+			loudest = math.Max(loudest, column[i])
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return errors.New("The file is shorter than a window")
+	}
+
+	img := image.NewGray(image.Rect(0, 0, len(columns), *window/2))
+	for x, column := range columns {
+		for bin, db := range column {
+			level := math.Max(0, math.Min(1, 1-(loudest-db)/spectrumRange))
+			// low frequencies at the bottom
+			img.SetGray(x, *window/2-1-bin, color.Gray{Y: uint8(level * 255)})
+		}
+	}
+	f, err := os.Create(files[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	"github.com/DylanMeeus/GoAudio/playback"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	bpm    = flag.Float64("bpm", 100, "tempo in beats per minute")
+	beats  = flag.Float64("beats", 4, "beats per bar")
+	bars   = flag.Int("bars", 8, "amount of bars")
+	sound  = flag.String("sound", "beep", "click sound: beep, woodblock or hihat")
+	output = flag.String("o", "", "write a practice track to this file instead of playing it")
+)
+
+var sounds = map[string]synth.ClickSound{
+	"beep":      synth.BEEP,
+	"woodblock": synth.WOODBLOCK,
+	"hihat":     synth.HIHAT,
+}
+
+// play a click track, or render it to a wave file
+func main() {
+	flag.Parse()
+
+	sr := 44100
+	tempo, err := breakpoint.NewTempoMap(*bpm)
+	if err != nil {
+		panic(err)
+	}
+	tempo.BeatsPerBar = *beats
+	click, ok := sounds[*sound]
+	if !ok {
+		panic(fmt.Sprintf("unknown sound %v", *sound))
+	}
+	metronome, err := synth.NewMetronome(sr, 1, tempo, click)
+	if err != nil {
+		panic(err)
+	}
+	transport, err := synth.NewTransport(sr, 1, tempo)
+	if err != nil {
+		panic(err)
+	}
+	transport.Tracks = append(transport.Tracks, metronome)
+	duration := tempo.Seconds(tempo.Bar(float64(*bars)))
+
+	if *output != "" {
+		frames := transport.Render(duration, 1024)
+		if err := wave.WriteFrames(frames, wave.NewWaveFmt(1, 1, sr, 16, nil), *output); err != nil {
+			panic(err)
+		}
+		fmt.Printf("done writing to %v\n", *output)
+		return
+	}
+
+	b, err := playback.DefaultBackend()
+	if err != nil {
+		panic(err)
+	}
+	stream, err := playback.NewStream(b, playback.Config{SampleRate: sr, Channels: 1})
+	if err != nil {
+		panic(err)
+	}
+	end := int64(duration * float64(sr))
+	transport.Play()
+	err = stream.Start(func(out []wave.Frame) int {
+		n := transport.Fill(out)
+		if left := int(end - stream.Time()); left < n {
+			return left
+		}
+		return n
+	})
+	if err != nil {
+		panic(err)
+	}
+	if err := stream.Wait(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/DylanMeeus/GoAudio/midi"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	input  = flag.String("i", "", "wave file with a hummed or sung melody")
+	output = flag.String("o", "hum.mid", "MIDI file to write")
+	bend   = flag.Bool("bend", false, "follow the pitch with pitch bend")
+)
+
+// transcribe a melody to a MIDI file
+func main() {
+	flag.Parse()
+	if *input == "" {
+		panic("need a wave file to transcribe")
+	}
+	w, err := wave.ReadWaveFile(*input)
+	if err != nil {
+		panic(err)
+	}
+	t := midi.NewTranscriber()
+	t.PitchBend = *bend
+	notes, bends := t.Transcribe(w.Frames, w.WaveFmt)
+
+	f, err := midi.FromNoteEvents(notes, nil, 480, 0)
+	if err != nil {
+		panic(err)
+	}
+	clock, err := f.Clock()
+	if err != nil {
+		panic(err)
+	}
+	bendEvents, err := midi.PitchBendEvents(bends, clock, 0, t.BendRange)
+	if err != nil {
+		panic(err)
+	}
+	// bends go first, so a note starts at its bend
+	track := &f.Tracks[1]
+	track.Events = append(bendEvents, track.Events...)
+	if err := midi.WriteFile(*output, f); err != nil {
+		panic(err)
+	}
+	fmt.Printf("wrote %v notes to %v\n", len(notes), *output)
+}
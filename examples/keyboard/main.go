@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/DylanMeeus/GoAudio/midi"
+	"github.com/DylanMeeus/GoAudio/playback"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	port = flag.String("port", "", "MIDI input to play from, the first input by default")
+	list = flag.Bool("list", false, "list the MIDI inputs")
+	mpe  = flag.Int("mpe", 0, "members of the lower MPE zone, 0 plays without MPE")
+)
+
+// play a polyphonic synth live from a MIDI keyboard until interrupted
+func main() {
+	flag.Parse()
+	if *list {
+		ports, err := midi.Inputs()
+		if err != nil {
+			panic(err)
+		}
+		for _, p := range ports {
+			fmt.Printf("%v\t%v\n", p.Name, p.Description)
+		}
+		return
+	}
+
+	sr := 44100
+	poly, err := synth.NewPolyphony(16, func() (synth.PolyVoice, error) {
+		v, err := synth.NewVoice(sr, synth.UPWARD_SAWTOOTH)
+		if err != nil {
+			return nil, err
+		}
+		v.Gain = .1
+		return v, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	in, err := midi.OpenInput(*port)
+	if err != nil {
+		panic(err)
+	}
+	// every channel plays the same synth
+	live, err := midi.NewLive(in, func(channel, program int) (synth.Instrument, error) {
+		return poly, nil
+	}, 1)
+	if err != nil {
+		panic(err)
+	}
+	if *mpe > 0 {
+		// the bend, pressure and slide of MPE notes bend, swell and open the filter of their voice
+		for _, e := range []struct {
+			d           synth.Dimension
+			param       string
+			base, depth float64
+		}{
+			{synth.BEND, "pitch", 0, 1},
+			{synth.PRESSURE, "amp", .05, .1},
+			{synth.TIMBRE, "cutoff", 500, 4000},
+		} {
+			if err := poly.Express(e.d, e.param, e.base, e.depth); err != nil {
+				panic(err)
+			}
+		}
+		config, err := midi.MPEConfiguration(midi.MPE_LOWER, *mpe)
+		if err != nil {
+			panic(err)
+		}
+		live.Queue(config...)
+	}
+
+	b, err := playback.DefaultBackend()
+	if err != nil {
+		panic(err)
+	}
+	stream, err := playback.NewStream(b, playback.Config{SampleRate: sr, Channels: 1, BlockSize: 256})
+	if err != nil {
+		panic(err)
+	}
+	if err := stream.Start(live.Render); err != nil {
+		panic(err)
+	}
+	fmt.Println("playing, press ctrl-c to stop")
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	if err := live.Close(); err != nil {
+		panic(err)
+	}
+	if err := stream.Stop(); err != nil {
+		panic(err)
+	}
+	if err := live.Err(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/DylanMeeus/GoAudio/midi"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	input  = flag.String("i", "", "MIDI file to render")
+	output = flag.String("o", "midi.wav", "wave file to write")
+)
+
+// render a MIDI file with the General MIDI patches of the synthesizer
+func main() {
+	flag.Parse()
+	if *input == "" {
+		panic("need a MIDI file to render")
+	}
+	f, err := midi.ReadFile(*input)
+	if err != nil {
+		panic(err)
+	}
+	sr := 44100
+	// every program plays a patch of its General MIDI family, channel 10 plays drums
+	instruments := midi.NewGMMap(sr).Instrument
+	wfmt, err := wave.NewValidWaveFmt(wave.PCM, 1, sr, 16)
+	if err != nil {
+		panic(err)
+	}
+	frames, err := midi.Render(f, instruments, wfmt)
+	if err != nil {
+		panic(err)
+	}
+	if err := wave.WriteFrames(frames, wfmt, *output); err != nil {
+		panic(err)
+	}
+	fmt.Printf("rendered %v seconds to %v\n", len(frames)/sr, *output)
+}
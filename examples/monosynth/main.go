@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	note     = flag.String("n", "A2", "note to play")
+	gate     = flag.Float64("g", 1, "time the note is held in seconds")
+	duration = flag.Float64("d", 2, "duration of the output in seconds")
+	output   = flag.String("o", "output.wav", "output file")
+)
+
+// render a single note of a detuned two-oscillator sawtooth voice
+func main() {
+	flag.Parse()
+
+	sr := 44100
+	voice, err := synth.NewVoice(sr, synth.UPWARD_SAWTOOTH, synth.UPWARD_SAWTOOTH)
+	if err != nil {
+		panic(err)
+	}
+	voice.Oscillators[1].Detune = 7
+	voice.Cutoff = 400
+	voice.Resonance = .6
+	voice.LFOCutoff = .5
+
+	freq, err := synth.ParseNoteToFrequency(*note)
+	if err != nil {
+		panic(err)
+	}
+
+	frames := voice.Render(freq, .8, *gate, *duration)
+	if err := wave.WriteFrames(frames, wave.NewWaveFmt(1, 1, sr, 16, nil), *output); err != nil {
+		panic(err)
+	}
+	fmt.Printf("done writing to %v\n", *output)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/DylanMeeus/GoAudio/osc"
+	"github.com/DylanMeeus/GoAudio/playback"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var address = flag.String("address", ":8000", "UDP address to receive OSC on")
+
+// play a drone of which the filter is controlled by /synth/cutoff and /synth/resonance
+func main() {
+	flag.Parse()
+	sr := 44100
+	voice, err := synth.NewVoice(sr, synth.UPWARD_SAWTOOTH, synth.UPWARD_SAWTOOTH)
+	if err != nil {
+		panic(err)
+	}
+	voice.Oscillators[1].Detune = 7
+	voice.Gain = .2
+	voice.EnvAmount = 0
+
+	cutoff, err := osc.NewControl(sr, 100, 8000, .3)
+	if err != nil {
+		panic(err)
+	}
+	cutoff.Exponential = true
+	resonance, err := osc.NewControl(sr, 0, .9, .2)
+	if err != nil {
+		panic(err)
+	}
+	matrix := synth.NewModMatrix()
+	if err := matrix.Connect(cutoff, voice, "cutoff", 1); err != nil {
+		panic(err)
+	}
+	if err := matrix.Connect(resonance, voice, "resonance", 1); err != nil {
+		panic(err)
+	}
+
+	server := osc.NewServer()
+	if err := server.Bind("/synth/cutoff", cutoff); err != nil {
+		panic(err)
+	}
+	if err := server.Bind("/synth/resonance", resonance); err != nil {
+		panic(err)
+	}
+	go func() {
+		if err := server.ListenAndServe(*address); err != nil {
+			panic(err)
+		}
+	}()
+
+	b, err := playback.DefaultBackend()
+	if err != nil {
+		panic(err)
+	}
+	stream, err := playback.NewStream(b, playback.Config{SampleRate: sr, Channels: 1, BlockSize: 256})
+	if err != nil {
+		panic(err)
+	}
+	voice.NoteOn(synth.MidiToFrequency(45), 1)
+	if err := stream.Start(func(out []wave.Frame) int {
+		for i := range out {
+			matrix.Tick()
+			out[i] = wave.Frame(voice.Tick())
+		}
+		return len(out)
+	}); err != nil {
+		panic(err)
+	}
+	fmt.Printf("listening for OSC on %v, press ctrl-c to stop\n", *address)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	if err := stream.Stop(); err != nil {
+		panic(err)
+	}
+}
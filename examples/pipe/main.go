@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"math"
+	"os"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var gain = flag.Float64("gain", 0, "gain in dB")
+
+// change the volume of a wave stream from stdin and write it to stdout, e.g.
+//
+//	sox in.flac -t wav - | pipe -gain -6 | ffmpeg -i - out.mp3
+func main() {
+	flag.Parse()
+
+	dec, err := wave.NewDecoder(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	// the writer supports every format the decoder reads
+	wfmt := dec.Format()
+	out, err := wave.NewWaveWriter(os.Stdout, wfmt)
+	if err != nil {
+		panic(err)
+	}
+	amp := wave.Frame(math.Pow(10, *gain/20))
+	block := make([]wave.Frame, 4096*wfmt.NumChannels)
+	for {
+		n, err := dec.Read(block)
+		for i := range block[:n] {
+			block[i] *= amp
+		}
+		if werr := out.Write(block[:n]); werr != nil {
+			panic(werr)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		panic(err)
+	}
+}
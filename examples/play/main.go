@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/DylanMeeus/GoAudio/playback"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	input = flag.String("i", "", "wave file to play, plays a note when empty")
+	note  = flag.String("n", "A3", "note to play")
+)
+
+// play a wave file or a synthesized note on the default output device
+// build with -tags portaudio to enable the PortAudio backend
+func main() {
+	flag.Parse()
+
+	player, err := playback.NewPlayer()
+	if err != nil {
+		panic(err)
+	}
+
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		dec, err := wave.NewDecoder(f)
+		if err != nil {
+			panic(err)
+		}
+		if err := player.PlayDecoder(dec); err != nil {
+			panic(err)
+		}
+		fmt.Printf("done playing %v\n", *input)
+		return
+	}
+
+	sr := 44100
+	voice, err := synth.NewVoice(sr, synth.UPWARD_SAWTOOTH, synth.SQUARE)
+	if err != nil {
+		panic(err)
+	}
+	freq, err := synth.ParseNoteToFrequency(*note)
+	if err != nil {
+		panic(err)
+	}
+	frames := voice.Render(freq, .8, 1, 1.5)
+	if err := player.Play(frames, wave.NewWaveFmt(1, 1, sr, 16, nil)); err != nil {
+		panic(err)
+	}
+	fmt.Printf("done playing %v\n", *note)
+}
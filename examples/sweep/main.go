@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	start    = flag.Float64("s", 20, "start frequency in Hz")
+	end      = flag.Float64("e", 20000, "end frequency in Hz")
+	duration = flag.Float64("d", 10, "duration of the sweep in seconds")
+	linear   = flag.Bool("l", false, "sweep linearly instead of exponentially")
+	output   = flag.String("o", "sweep.wav", "output file")
+	inverse  = flag.String("i", "", "also write the inverse filter of an exponential sweep to this file")
+)
+
+// write a sine sweep for measuring the response of a room or device
+func main() {
+	flag.Parse()
+
+	sr := 48000
+	sweep := synth.ExponentialSweep
+	if *linear {
+		sweep = synth.LinearSweep
+	}
+	frames, err := sweep(sr, *start, *end, *duration, .9)
+	if err != nil {
+		panic(err)
+	}
+	wfmt := wave.NewWaveFmt(1, 1, sr, 16, nil)
+	if err := wave.WriteFrames(frames, wfmt, *output); err != nil {
+		panic(err)
+	}
+	fmt.Printf("done writing to %v\n", *output)
+
+	if *inverse != "" {
+		frames, err := synth.InverseSweep(sr, *start, *end, *duration)
+		if err != nil {
+			panic(err)
+		}
+		if err := wave.WriteFrames(frames, wfmt, *inverse); err != nil {
+			panic(err)
+		}
+		fmt.Printf("done writing to %v\n", *inverse)
+	}
+}
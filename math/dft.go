@@ -50,15 +50,24 @@ func HFFT(input []wave.Frame, freqs []complex128, n, step int) {
 	HFFT(input[step:], freqs[h:], h, 2*step)
 
 	for k := 0; k < h; k++ {
-		a := -2 * math.Pi * float64(k) * float64(n)
+		a := -2 * math.Pi * float64(k) / float64(n)
 		e := cmplx.Rect(1, a) * freqs[k+h]
 		freqs[k], freqs[k+h] = freqs[k]+e, freqs[k]-e
 	}
 }
 
-// FFT (Fast Fourier Transform) implementation
+// FFT (Fast Fourier Transform) implementation. Sizes which are a power of two use a cached
+// Plan, a Plan of its own avoids allocating the result.
 func FFT(input []wave.Frame) []complex128 {
 	freqs := make([]complex128, len(input))
-	HFFT(input, freqs, len(input), 1)
+	p, err := cachedPlan(len(input))
+	if err != nil {
+		HFFT(input, freqs, len(input), 1)
+		return freqs
+	}
+	for i, f := range input {
+		freqs[i] = complex(float64(f), 0)
+	}
+	p.Transform(freqs)
 	return freqs
 }
@@ -0,0 +1,93 @@
+package math
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// TestFFT checks the energy of a cosine ends up in its bin and the mirrored one
+func TestFFT(t *testing.T) {
+	n, bin := 16, 3
+	input := make([]wave.Frame, n)
+	for i := range input {
+		input[i] = wave.Frame(math.Cos(tau * float64(bin*i) / float64(n)))
+	}
+	for k, f := range FFT(input) {
+		want := 0.
+		if k == bin || k == n-bin {
+			want = float64(n) / 2
+		}
+		if got := cmplx.Abs(f); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("Expected a magnitude of %v in bin %v, got %v", want, k, got)
+		}
+	}
+}
+
+// naiveDFT is the definition of the transform, which the plans should agree with
+func naiveDFT(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := range out {
+		for i, c := range x {
+			out[k] += c * cmplx.Rect(1, -tau*float64(k*i%n)/float64(n))
+		}
+	}
+	return out
+}
+
+// TestPlan compares the transforms of a plan with the naive DFT
+func TestPlan(t *testing.T) {
+	for _, n := range []int{1, 2, 4, 8, 64, 256} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			p, err := NewPlan(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Len() != n {
+				t.Fatalf("Expected a length of %v, got %v", n, p.Len())
+			}
+			input := make([]wave.Frame, n)
+			signal := make([]complex128, n)
+			for i := range input {
+				input[i] = wave.Frame(math.Sin(float64(i*i)/7) + .5*math.Cos(float64(i)/3))
+				signal[i] = complex(float64(input[i]), math.Sin(float64(i)))
+			}
+			compare := func(name string, got, want []complex128) {
+				for k := range want {
+					if cmplx.Abs(got[k]-want[k]) > 1e-9*float64(n) {
+						t.Fatalf("%v: Expected %v in bin %v, got %v", name, want[k], k, got[k])
+					}
+				}
+			}
+
+			want := naiveDFT(signal)
+			data := append([]complex128{}, signal...)
+			p.Transform(data)
+			compare("Transform", data, want)
+			p.Inverse(data)
+			compare("Inverse", data, signal)
+
+			reals := make([]complex128, n)
+			for i, f := range input {
+				reals[i] = complex(float64(f), 0)
+			}
+			want = naiveDFT(reals)
+			got := make([]complex128, n/2+1)
+			p.Real(got, input)
+			compare("Real", got, want[:n/2+1])
+			compare("FFT", FFT(input), want)
+		})
+	}
+}
+
+func TestPlanSize(t *testing.T) {
+	for _, n := range []int{-4, 0, 3, 12, 100} {
+		if _, err := NewPlan(n); err == nil {
+			t.Fatalf("Expected an error for a size of %v", n)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package math
+
+import (
+	"errors"
+	"math/bits"
+	"math/cmplx"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Plan is a fast fourier transform of one size, a power of two, of which the twiddle factors
+// and bit reversal table are computed up front. Transforming many blocks with one plan, as a
+// spectrogram does, allocates nothing. A plan can be used by several goroutines at once.
+type Plan struct {
+	n        int
+	twiddles []complex128 // e^(-2πik/n) for k < n/2
+	reverse  []int        // bit reversed index of every element
+	half     *Plan        // the plan of n/2 which transforms real input
+}
+
+// plans caches the plans FFT uses by size
+var plans sync.Map
+
+// NewPlan creates the plan of an FFT of n elements
+func NewPlan(n int) (*Plan, error) {
+	if n < 1 || n&(n-1) != 0 {
+		return nil, errors.New("FFT size should be a power of 2")
+	}
+	p := newPlan(n)
+	if n > 1 {
+		p.half = newPlan(n / 2)
+	}
+	return p, nil
+}
+
+func newPlan(n int) *Plan {
+	p := &Plan{
+		n:        n,
+		twiddles: make([]complex128, n/2),
+		reverse:  make([]int, n),
+	}
+	for k := range p.twiddles {
+		p.twiddles[k] = cmplx.Rect(1, -tau*float64(k)/float64(n))
+	}
+	shift := bits.UintSize - bits.Len(uint(n-1))
+	for i := range p.reverse {
+		p.reverse[i] = int(bits.Reverse(uint(i)) >> shift)
+	}
+	return p
+}
+
+// cachedPlan returns the plan of a size, creating it the first time
+func cachedPlan(n int) (*Plan, error) {
+	if p, ok := plans.Load(n); ok {
+		return p.(*Plan), nil
+	}
+	p, err := NewPlan(n)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := plans.LoadOrStore(n, p)
+	return actual.(*Plan), nil
+}
+
+// Len returns the size of the transform
+func (p *Plan) Len() int {
+	return p.n
+}
+
+// Transform replaces the n elements of data with their spectrum
+func (p *Plan) Transform(data []complex128) {
+	data = data[:p.n]
+	for i, j := range p.reverse {
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	for size := 2; size <= p.n; size <<= 1 {
+		half, step := size/2, p.n/size
+		for start := 0; start < p.n; start += size {
+			lo, hi := data[start:start+half], data[start+half:start+size]
+			for k := range lo {
+				e := hi[k] * p.twiddles[k*step]
+				lo[k], hi[k] = lo[k]+e, lo[k]-e
+			}
+		}
+	}
+}
+
+// Inverse replaces a spectrum of n elements in data with the signal it was transformed from
+func (p *Plan) Inverse(data []complex128) {
+	data = data[:p.n]
+	for i, c := range data {
+		data[i] = cmplx.Conj(c)
+	}
+	p.Transform(data)
+	scale := 1 / float64(p.n)
+	for i, c := range data {
+		data[i] = complex(real(c)*scale, -imag(c)*scale)
+	}
+}
+
+// Real writes the first n/2+1 bins of the spectrum of n real frames into dst, the others
+// mirror them. The frames are packed into a transform of half the size, which makes it about
+// twice as fast as transforming them as complex numbers.
+func (p *Plan) Real(dst []complex128, input []wave.Frame) {
+	if p.n == 1 {
+		dst[0] = complex(float64(input[0]), 0)
+		return
+	}
+	m := p.n / 2
+	dst = dst[:m+1]
+	input = input[:p.n]
+	// pack the even frames in the real and the odd frames in the imaginary parts
+	for k := 0; k < m; k++ {
+		dst[k] = complex(float64(input[2*k]), float64(input[2*k+1]))
+	}
+	p.half.Transform(dst)
+
+	// split the spectrum of the packed frames into that of the even and odd frames, and
+	// combine those into the spectrum of all frames, two bins at a time so it works in place
+	z0 := dst[0]
+	dst[0] = complex(real(z0)+imag(z0), 0)
+	dst[m] = complex(real(z0)-imag(z0), 0)
+	for k := 1; k <= m/2; k++ {
+		j := m - k
+		zk, zj := dst[k], dst[j]
+		dst[k] = unpack(zk, zj, p.twiddles[k])
+		dst[j] = unpack(zj, zk, p.twiddles[j])
+	}
+}
+
+// unpack returns bin k of a real spectrum from bins k and n/2-k of its packed transform
+func unpack(zk, zj, twiddle complex128) complex128 {
+	even := (zk + cmplx.Conj(zj)) / 2
+	odd := (zk - cmplx.Conj(zj)) / 2
+	// odd is multiplied by -i to take it out of the imaginary parts
+	return even + twiddle*complex(imag(odd), -real(odd))
+}
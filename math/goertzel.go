@@ -0,0 +1,27 @@
+package math
+
+import (
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Goertzel returns the amplitude of a single frequency in the input frames.
+// This is cheaper than an FFT when only a few frequencies are of interest.
+// For a sine at the given frequency, the result is its amplitude.
+func Goertzel(input []wave.Frame, sr int, freq float64) float64 {
+	n := len(input)
+	if n == 0 {
+		return 0
+	}
+	w := tau * freq / float64(sr)
+	coeff := 2 * math.Cos(w)
+	var s1, s2 float64
+	for _, x := range input {
+		s := float64(x) + coeff*s1 - s2
+		s2, s1 = s1, s
+	}
+	re := s1 - s2*math.Cos(w)
+	im := s2 * math.Sin(w)
+	return 2 * math.Sqrt(re*re+im*im) / float64(n)
+}
@@ -0,0 +1,99 @@
+package math
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// onsetWindow and onsetHop are the size and step of the spectra of Onsets, onsetThreshold
+// is the least flux of an onset, about a sine starting at -20dB
+const (
+	onsetWindow    = 1024
+	onsetHop       = 256
+	onsetThreshold = 4
+)
+
+// SpectralFlux returns the increase in log magnitude of the spectrum of mono frames for
+// every hop, in windows of a power of two. Notes starting show up as peaks.
+func SpectralFlux(input []wave.Frame, window, hop int) []float64 {
+	flux := []float64{}
+	hann := make([]float64, window)
+	for i := range hann {
+		hann[i] = .5 - .5*math.Cos(tau*float64(i)/float64(window))
+	}
+	plan, err := cachedPlan(window)
+	if err != nil {
+		return flux
+	}
+	buf := make([]wave.Frame, window)
+	spectrum := make([]complex128, window/2+1)
+	prev := make([]float64, window/2)
+	for start := 0; start+window <= len(input); start += hop {
+		for i := range buf {
+			buf[i] = input[start+i] * wave.Frame(hann[i])
+		}
+		plan.Real(spectrum, buf)
+		f := 0.0
+		for k := range prev {
+			// log compression makes quiet notes count as well
+			// a sine of amplitude 1 peaks at window/4 in a hann window
+			mag := math.Log1p(100 * cmplx.Abs(spectrum[k]) * 4 / float64(window))
+			if mag > prev[k] {
+				f += mag - prev[k]
+			}
+			prev[k] = mag
+		}
+		flux = append(flux, f)
+	}
+	return flux
+}
+
+// Onsets returns the samples at which notes start in mono frames. They are the peaks of the
+// spectral flux which stand out from its median around them.
+func Onsets(input []wave.Frame, sr int) []int {
+	flux := SpectralFlux(input, onsetWindow, onsetHop)
+	if len(flux) == 0 {
+		return nil
+	}
+	// the first spectrum rises from nothing
+	flux[0] = 0
+
+	minGap := int(.05 * float64(sr) / onsetHop) // 50ms between onsets
+	onsets := []int{}
+	last := -minGap - 1
+	for i, f := range flux {
+		if f < onsetThreshold || i-last <= minGap {
+			continue
+		}
+		lo, hi := i-3, i+3
+		peak := true
+		for j := lo; j <= hi && peak; j++ {
+			if j >= 0 && j < len(flux) && flux[j] > f {
+				peak = false
+			}
+		}
+		if !peak || f < 1.5*localMedian(flux, i, 8) {
+			continue
+		}
+		onsets = append(onsets, i*onsetHop+onsetWindow/2)
+		last = i
+	}
+	return onsets
+}
+
+// localMedian returns the median of the values within distance of an index
+func localMedian(values []float64, i, distance int) float64 {
+	lo, hi := i-distance, i+distance+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(values) {
+		hi = len(values)
+	}
+	window := append([]float64{}, values[lo:hi]...)
+	sort.Float64s(window)
+	return window[len(window)/2]
+}
@@ -0,0 +1,91 @@
+package math
+
+import (
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// yinThreshold is the largest normalized difference at which YIN accepts a period
+const yinThreshold = .15
+
+// Pitch estimates the fundamental frequency of mono frames between minFreq and maxFreq
+// with the YIN algorithm. The frames should span at least two periods of minFreq. It also
+// returns the periodicity, from 0 for noise to 1 for a periodic signal, and a frequency of
+// 0 when the frames aren't periodic enough.
+func Pitch(input []wave.Frame, sr int, minFreq, maxFreq float64) (freq, periodicity float64) {
+	if minFreq <= 0 || maxFreq <= minFreq {
+		return 0, 0
+	}
+	minLag := int(float64(sr) / maxFreq)
+	if minLag < 2 {
+		minLag = 2
+	}
+	maxLag := int(float64(sr)/minFreq) + 1
+	if maxLag > len(input)/2 {
+		maxLag = len(input) / 2
+	}
+	if maxLag <= minLag {
+		return 0, 0
+	}
+	window := len(input) - maxLag
+
+	// cumulative mean normalized difference
+	d := make([]float64, maxLag+1)
+	d[0] = 1
+	sum := 0.0
+	for lag := 1; lag <= maxLag; lag++ {
+		diff := 0.0
+		for i := 0; i < window; i++ {
+			delta := float64(input[i] - input[i+lag])
+			diff += delta * delta
+		}
+		sum += diff
+		if sum == 0 {
+			d[lag] = 1
+		} else {
+			d[lag] = diff * float64(lag) / sum
+		}
+	}
+
+	// the first dip below the threshold, else the global minimum
+	best := -1
+	for lag := minLag; lag < maxLag; lag++ {
+		if d[lag] < yinThreshold {
+			for lag+1 < maxLag && d[lag+1] < d[lag] {
+				lag++
+			}
+			best = lag
+			break
+		}
+	}
+	if best < 0 {
+		best = minLag
+		for lag := minLag; lag < maxLag; lag++ {
+			if d[lag] < d[best] {
+				best = lag
+			}
+		}
+		if d[best] >= yinThreshold*2 {
+			return 0, clamp(1 - d[best])
+		}
+	}
+
+	// parabolic interpolation between the neighbouring lags
+	period := float64(best)
+	if best > 1 && best < maxLag {
+		a, b, c := d[best-1], d[best], d[best+1]
+		if div := a - 2*b + c; div > 0 {
+			period += (a - c) / (2 * div)
+		}
+	}
+	return float64(sr) / period, clamp(1 - d[best])
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+package midi
+
+import (
+	"math"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+)
+
+// Clock converts between ticks and time with the tempo and time signatures of a file
+type Clock struct {
+	Tempo    *breakpoint.TempoMap // in quarter notes
+	Division int                  // ticks per quarter note
+}
+
+// Clock collects the tempo and time signature events of all tracks into a tempo map.
+// Files without tempo events play at 120 beats per minute, as the standard prescribes.
+// SMPTE files get a clock at a fixed tempo.
+func (f *File) Clock() (*Clock, error) {
+	tempo, err := breakpoint.NewTempoMap(120)
+	if err != nil {
+		return nil, err
+	}
+	if f.FramesPerSecond > 0 {
+		// at 120 beats per minute a quarter note lasts half a second
+		return &Clock{Tempo: tempo, Division: f.FramesPerSecond * f.TicksPerFrame / 2}, nil
+	}
+	c := &Clock{Tempo: tempo, Division: f.Division}
+
+	events := []Event{}
+	for _, t := range f.Tracks {
+		for _, e := range t.Events {
+			if e.Type == TEMPO || e.Type == TIME_SIGNATURE {
+				events = append(events, e)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Tick < events[j].Tick })
+	for _, e := range events {
+		beat := c.Beat(e.Tick)
+		switch e.Type {
+		case TEMPO:
+			if e.Value > 0 {
+				if err := tempo.SetTempo(beat, 60e6/float64(e.Value)); err != nil {
+					return nil, err
+				}
+			}
+		case TIME_SIGNATURE:
+			num, den := e.TimeSignature()
+			perBar := float64(num) * 4 / float64(den)
+			bar, inBar := tempo.BarAt(beat)
+			if inBar > 1e-9 {
+				// a meter change in the middle of a bar starts a new bar
+				bar++
+			}
+			if bar == 0 {
+				tempo.BeatsPerBar = perBar
+			} else if err := tempo.SetMeter(bar, perBar); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// Beat returns the quarter note at which a tick is played
+func (c *Clock) Beat(tick int64) float64 {
+	return float64(tick) / float64(c.Division)
+}
+
+// Seconds returns the time at which a tick is played
+func (c *Clock) Seconds(tick int64) float64 {
+	return c.Tempo.Seconds(c.Beat(tick))
+}
+
+// Sample returns the frame at which a tick is played at a sample rate
+func (c *Clock) Sample(tick int64, sr int) int {
+	return c.Tempo.Sample(c.Beat(tick), sr)
+}
+
+// Tick returns the tick played at a time in seconds, rounded to the nearest tick
+func (c *Clock) Tick(seconds float64) int64 {
+	return int64(math.Round(c.Tempo.Beats(seconds) * float64(c.Division)))
+}
+
+// TickAtSample returns the tick played at a frame at a sample rate
+func (c *Clock) TickAtSample(sample, sr int) int64 {
+	return c.Tick(float64(sample) / float64(sr))
+}
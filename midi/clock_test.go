@@ -0,0 +1,56 @@
+package midi
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestClock(t *testing.T) {
+	conductor := []byte{
+		0x00, 0xFF, 0x58, 0x04, 0x03, 0x02, 0x18, 0x08, // 3/4
+		0x00, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20, // 120 BPM
+		0x8B, 0x20, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40, // 60 BPM after 4 beats (1440 ticks)
+		0x00, 0xFF, 0x58, 0x04, 0x06, 0x03, 0x18, 0x08, // 6/8 from bar 2, the meter change is halfway bar 1
+	}
+	f, err := Parse(bytes.NewReader(smf(1, 360, conductor)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := f.Clock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tick    int64
+		seconds float64
+	}{
+		{0, 0},
+		{360, .5},
+		{1440, 2},
+		{1800, 3},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if s := c.Seconds(test.tick); math.Abs(s-test.seconds) > 1e-9 {
+				t.Fatalf("Expected tick %v at %vs, got %v", test.tick, test.seconds, s)
+			}
+			if tick := c.Tick(test.seconds); tick != test.tick {
+				t.Fatalf("Expected %vs at tick %v, got %v", test.seconds, test.tick, tick)
+			}
+			if s := c.Sample(test.tick, 1000); s != int(test.seconds*1000) {
+				t.Fatalf("Expected tick %v at sample %v, got %v", test.tick, test.seconds*1000, s)
+			}
+		})
+	}
+	if c.Tempo.BeatsPerBar != 3 || len(c.Tempo.Meters) != 1 || c.Tempo.Meters[0].Bar != 2 || c.Tempo.Meters[0].BeatsPerBar != 3 {
+		t.Fatalf("Expected 3/4 followed by 6/8 from bar 2, got %v and %+v", c.Tempo.BeatsPerBar, c.Tempo.Meters)
+	}
+
+	// SMPTE files count ticks in real time
+	f = &File{FramesPerSecond: 25, TicksPerFrame: 40}
+	c, _ = f.Clock()
+	if s := c.Seconds(1500); math.Abs(s-1.5) > 1e-9 {
+		t.Fatalf("Expected 1000 ticks per second, got tick 1500 at %v", s)
+	}
+}
@@ -0,0 +1,134 @@
+package midi
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+)
+
+// controlStep is the time in seconds between the events of a sloping segment
+const controlStep = .01
+
+// ControllerEnvelope collects the CONTROL_CHANGE events of a controller on a channel into an
+// envelope in seconds, scaling the values from 0-127 to min-max. The envelope steps like the
+// controller did, the Smoothing of its stream takes the edges off.
+func ControllerEnvelope(events []Event, clock *Clock, channel, controller int, min, max float64) (*breakpoint.Envelope, error) {
+	return controlEnvelope(events, clock, func(e Event) (float64, bool) {
+		if e.Type != CONTROL_CHANGE || e.Channel != channel || e.Controller != controller {
+			return 0, false
+		}
+		return min + (max-min)*float64(e.Value)/127, true
+	})
+}
+
+// PitchBendEnvelope collects the PITCH_BEND events of a channel into an envelope in seconds
+// of the bend in semitones, for an instrument which bends bendRange semitones at a full bend
+func PitchBendEnvelope(events []Event, clock *Clock, channel int, bendRange float64) (*breakpoint.Envelope, error) {
+	return controlEnvelope(events, clock, func(e Event) (float64, bool) {
+		if e.Type != PITCH_BEND || e.Channel != channel {
+			return 0, false
+		}
+		return float64(e.Value) / 8192 * bendRange, true
+	})
+}
+
+func controlEnvelope(events []Event, clock *Clock, value func(Event) (float64, bool)) (*breakpoint.Envelope, error) {
+	points := breakpoint.Breakpoints{}
+	for _, e := range sortedEvents(events) {
+		v, ok := value(e)
+		if !ok {
+			continue
+		}
+		p := breakpoint.Breakpoint{Time: clock.Seconds(e.Tick), Value: v}
+		if n := len(points); n > 0 && points[n-1].Time == p.Time {
+			// the last of the events at the same time wins
+			points[n-1] = p
+			continue
+		}
+		points = append(points, p)
+	}
+	if len(points) == 0 {
+		return nil, errors.New("No events of the controller")
+	}
+	return breakpoint.NewEnvelope(points, breakpoint.STEP)
+}
+
+// ControllerEvents converts an envelope to the CONTROL_CHANGE events of a controller on a
+// channel, scaling the values from min-max to 0-127
+func ControllerEvents(env *breakpoint.Envelope, clock *Clock, channel, controller int, min, max float64) ([]Event, error) {
+	return controlEvents(env, clock, func(v float64) Event {
+		cc := int(math.Round((v - min) / (max - min) * 127))
+		if cc < 0 {
+			cc = 0
+		} else if cc > 127 {
+			cc = 127
+		}
+		return Event{Type: CONTROL_CHANGE, Channel: channel, Controller: controller, Value: cc}
+	})
+}
+
+// PitchBendEvents converts an envelope of a bend in semitones to the PITCH_BEND events of a
+// channel, for an instrument which bends bendRange semitones at a full bend
+func PitchBendEvents(bend *breakpoint.Envelope, clock *Clock, channel int, bendRange float64) ([]Event, error) {
+	return controlEvents(bend, clock, func(semitones float64) Event {
+		v := int(math.Round(semitones / bendRange * 8192))
+		if v < -8192 {
+			v = -8192
+		} else if v > 8191 {
+			v = 8191
+		}
+		return Event{Type: PITCH_BEND, Channel: channel, Value: v}
+	})
+}
+
+// controlEvents samples an envelope from its first to its last point. The events can only
+// step, so sloping and curved spans are followed every 10ms. Events which wouldn't change
+// the value are left out.
+func controlEvents(env *breakpoint.Envelope, clock *Clock, event func(float64) Event) ([]Event, error) {
+	if env == nil || len(env.Points) == 0 {
+		return nil, nil
+	}
+	var tick func(t float64) int64
+	step := controlStep
+	switch env.Unit {
+	case breakpoint.SECONDS:
+		tick = clock.Tick
+	case breakpoint.MILLISECONDS:
+		tick = func(t float64) int64 { return clock.Tick(t / 1000) }
+		step *= 1000
+	case breakpoint.BEATS:
+		tick = func(t float64) int64 { return int64(math.Round(t * float64(clock.Division))) }
+		step = clock.Tempo.Beats(controlStep)
+	default:
+		return nil, errors.New("Unsupported time unit of the envelope")
+	}
+
+	events := []Event{}
+	add := func(t float64) {
+		e := event(env.ValueAt(t))
+		e.Tick = tick(t)
+		if n := len(events); n > 0 && events[n-1].Value == e.Value {
+			return
+		}
+		events = append(events, e)
+	}
+	for i, p := range env.Points {
+		if i > 0 && env.Interpolation != breakpoint.STEP {
+			prev := env.Points[i-1]
+			for t := prev.Time + step; t < p.Time; t += step {
+				add(t)
+			}
+		}
+		add(p.Time)
+	}
+	return events, nil
+}
+
+// sortedEvents returns a copy of events sorted by tick
+func sortedEvents(events []Event) []Event {
+	sorted := append([]Event{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Tick < sorted[j].Tick })
+	return sorted
+}
@@ -0,0 +1,119 @@
+package midi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+)
+
+func TestControllerEnvelope(t *testing.T) {
+	tempo, _ := breakpoint.NewTempoMap(120)
+	clock := &Clock{Tempo: tempo, Division: 100}
+	events := []Event{
+		{Tick: 200, Type: CONTROL_CHANGE, Channel: 0, Controller: 74, Value: 127},
+		{Tick: 0, Type: CONTROL_CHANGE, Channel: 0, Controller: 74, Value: 0},
+		{Tick: 100, Type: CONTROL_CHANGE, Channel: 0, Controller: 74, Value: 64},
+		{Tick: 100, Type: CONTROL_CHANGE, Channel: 0, Controller: 74, Value: 32}, // replaces 64
+		{Tick: 150, Type: CONTROL_CHANGE, Channel: 1, Controller: 74, Value: 5},
+		{Tick: 150, Type: CONTROL_CHANGE, Channel: 0, Controller: 7, Value: 5},
+		{Tick: 150, Type: NOTE_ON, Channel: 0, Note: 74, Velocity: 5},
+	}
+	env, err := ControllerEnvelope(events, clock, 0, 74, 100, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := breakpoint.Breakpoints{{Time: 0, Value: 100}, {Time: .5, Value: 100 + 900*32./127}, {Time: 1, Value: 1000}}
+	if len(env.Points) != len(expected) || env.Interpolation != breakpoint.STEP {
+		t.Fatalf("Expected a step envelope through %v, got %+v", expected, env)
+	}
+	for i := range expected {
+		if math.Abs(env.Points[i].Time-expected[i].Time) > 1e-9 || math.Abs(env.Points[i].Value-expected[i].Value) > 1e-9 {
+			t.Fatalf("Expected %v, got %v", expected, env.Points)
+		}
+	}
+	if _, err := ControllerEnvelope(events, clock, 2, 74, 0, 1); err == nil {
+		t.Fatal("Expected an error without events of the controller")
+	}
+
+	// and back
+	back, err := ControllerEvents(env, clock, 0, 74, 100, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{events[1], events[3], events[0]}
+	if len(back) != len(want) {
+		t.Fatalf("Expected %+v, got %+v", want, back)
+	}
+	for i := range want {
+		if back[i].Tick != want[i].Tick || back[i].Value != want[i].Value || back[i].Controller != 74 || back[i].Type != CONTROL_CHANGE {
+			t.Fatalf("Expected %+v, got %+v", want, back)
+		}
+	}
+}
+
+func TestControllerEvents(t *testing.T) {
+	tempo, _ := breakpoint.NewTempoMap(60)
+	clock := &Clock{Tempo: tempo, Division: 1000}
+	tests := []struct {
+		unit  breakpoint.TimeUnit
+		end   float64
+		ticks int64 // of the last event
+	}{
+		{breakpoint.SECONDS, .1, 100},
+		{breakpoint.MILLISECONDS, 100, 100},
+		{breakpoint.BEATS, .1, 100},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			// a ramp over the range of the controller
+			env, err := breakpoint.NewEnvelope(breakpoint.Breakpoints{{Time: 0, Value: 0}, {Time: test.end, Value: 1}}, breakpoint.LINEAR)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.Unit = test.unit
+			events, err := ControllerEvents(env, clock, 3, 1, 0, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// every 10ms
+			if len(events) != 11 {
+				t.Fatalf("Expected 11 events, got %+v", events)
+			}
+			for i, e := range events {
+				if e.Tick != int64(i)*10 || math.Abs(float64(e.Value)-12.7*float64(i)) > .5 || e.Channel != 3 {
+					t.Fatalf("Unexpected events %+v", events)
+				}
+			}
+			if last := events[len(events)-1]; last.Tick != test.ticks || last.Value != 127 {
+				t.Fatalf("Expected the ramp to end at %v, got %+v", test.ticks, last)
+			}
+		})
+	}
+}
+
+func TestPitchBendEnvelope(t *testing.T) {
+	tempo, _ := breakpoint.NewTempoMap(60)
+	clock := &Clock{Tempo: tempo, Division: 1000}
+	events := []Event{
+		{Tick: 0, Type: PITCH_BEND, Value: -8192},
+		{Tick: 500, Type: PITCH_BEND, Value: 4096},
+		{Tick: 1000, Type: PITCH_BEND, Value: 8191},
+	}
+	env, err := PitchBendEnvelope(events, clock, 0, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := env.ValueAt(.75); v != 6 {
+		t.Fatalf("Expected the bend to hold 6 semitones, got %v", v)
+	}
+	back, err := PitchBendEvents(env, clock, 0, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range events {
+		if back[i].Tick != events[i].Tick || back[i].Value != events[i].Value {
+			t.Fatalf("Expected %+v, got %+v", events, back)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package midi
+
+import (
+	"fmt"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// GM_DRUMS is the channel on which General MIDI plays drums, channel 10 counting from 1
+const GM_DRUMS = 9
+
+// Family is one of the 16 groups of 8 programs of General MIDI
+type Family int
+
+// Families of General MIDI programs
+const (
+	PIANO Family = iota
+	CHROMATIC_PERCUSSION
+	ORGAN
+	GUITAR
+	BASS
+	STRINGS
+	ENSEMBLE
+	BRASS
+	REED
+	PIPE
+	SYNTH_LEAD
+	SYNTH_PAD
+	SYNTH_EFFECTS
+	ETHNIC
+	PERCUSSIVE
+	SOUND_EFFECTS
+)
+
+// gmVoices is the polyphony and gmGain the level of every voice of the default patches
+const (
+	gmVoices = 16
+	gmGain   = .1
+)
+
+var programNames = [128]string{
+	"Acoustic Grand Piano", "Bright Acoustic Piano", "Electric Grand Piano", "Honky-tonk Piano",
+	"Electric Piano 1", "Electric Piano 2", "Harpsichord", "Clavinet",
+	"Celesta", "Glockenspiel", "Music Box", "Vibraphone",
+	"Marimba", "Xylophone", "Tubular Bells", "Dulcimer",
+	"Drawbar Organ", "Percussive Organ", "Rock Organ", "Church Organ",
+	"Reed Organ", "Accordion", "Harmonica", "Tango Accordion",
+	"Acoustic Guitar (nylon)", "Acoustic Guitar (steel)", "Electric Guitar (jazz)", "Electric Guitar (clean)",
+	"Electric Guitar (muted)", "Overdriven Guitar", "Distortion Guitar", "Guitar Harmonics",
+	"Acoustic Bass", "Electric Bass (finger)", "Electric Bass (pick)", "Fretless Bass",
+	"Slap Bass 1", "Slap Bass 2", "Synth Bass 1", "Synth Bass 2",
+	"Violin", "Viola", "Cello", "Contrabass",
+	"Tremolo Strings", "Pizzicato Strings", "Orchestral Harp", "Timpani",
+	"String Ensemble 1", "String Ensemble 2", "Synth Strings 1", "Synth Strings 2",
+	"Choir Aahs", "Voice Oohs", "Synth Voice", "Orchestra Hit",
+	"Trumpet", "Trombone", "Tuba", "Muted Trumpet",
+	"French Horn", "Brass Section", "Synth Brass 1", "Synth Brass 2",
+	"Soprano Sax", "Alto Sax", "Tenor Sax", "Baritone Sax",
+	"Oboe", "English Horn", "Bassoon", "Clarinet",
+	"Piccolo", "Flute", "Recorder", "Pan Flute",
+	"Blown Bottle", "Shakuhachi", "Whistle", "Ocarina",
+	"Lead 1 (square)", "Lead 2 (sawtooth)", "Lead 3 (calliope)", "Lead 4 (chiff)",
+	"Lead 5 (charang)", "Lead 6 (voice)", "Lead 7 (fifths)", "Lead 8 (bass + lead)",
+	"Pad 1 (new age)", "Pad 2 (warm)", "Pad 3 (polysynth)", "Pad 4 (choir)",
+	"Pad 5 (bowed)", "Pad 6 (metallic)", "Pad 7 (halo)", "Pad 8 (sweep)",
+	"FX 1 (rain)", "FX 2 (soundtrack)", "FX 3 (crystal)", "FX 4 (atmosphere)",
+	"FX 5 (brightness)", "FX 6 (goblins)", "FX 7 (echoes)", "FX 8 (sci-fi)",
+	"Sitar", "Banjo", "Shamisen", "Koto",
+	"Kalimba", "Bagpipe", "Fiddle", "Shanai",
+	"Tinkle Bell", "Agogo", "Steel Drums", "Woodblock",
+	"Taiko Drum", "Melodic Tom", "Synth Drum", "Reverse Cymbal",
+	"Guitar Fret Noise", "Breath Noise", "Seashore", "Bird Tweet",
+	"Telephone Ring", "Helicopter", "Applause", "Gunshot",
+}
+
+// drumNames are the notes of the General MIDI percussion map, from note 35
+var drumNames = []string{
+	"Acoustic Bass Drum", "Bass Drum 1", "Side Stick", "Acoustic Snare",
+	"Hand Clap", "Electric Snare", "Low Floor Tom", "Closed Hi-Hat",
+	"High Floor Tom", "Pedal Hi-Hat", "Low Tom", "Open Hi-Hat",
+	"Low-Mid Tom", "Hi-Mid Tom", "Crash Cymbal 1", "High Tom",
+	"Ride Cymbal 1", "Chinese Cymbal", "Ride Bell", "Tambourine",
+	"Splash Cymbal", "Cowbell", "Crash Cymbal 2", "Vibraslap",
+	"Ride Cymbal 2", "Hi Bongo", "Low Bongo", "Mute Hi Conga",
+	"Open Hi Conga", "Low Conga", "High Timbale", "Low Timbale",
+	"High Agogo", "Low Agogo", "Cabasa", "Maracas",
+	"Short Whistle", "Long Whistle", "Short Guiro", "Long Guiro",
+	"Claves", "Hi Wood Block", "Low Wood Block", "Mute Cuica",
+	"Open Cuica", "Mute Triangle", "Open Triangle",
+}
+
+// ProgramName returns the General MIDI name of a program, or "" outside 0-127
+func ProgramName(program int) string {
+	if program < 0 || program >= len(programNames) {
+		return ""
+	}
+	return programNames[program]
+}
+
+// ProgramFamily returns the family of a program
+func ProgramFamily(program int) Family {
+	return Family(program / 8)
+}
+
+// DrumName returns the General MIDI name of the drum of a note, or "" for notes without one
+func DrumName(note int) string {
+	if note < 35 || note >= 35+len(drumNames) {
+		return ""
+	}
+	return drumNames[note-35]
+}
+
+// Patch creates an instrument
+type Patch func() (synth.Instrument, error)
+
+// GMMap maps General MIDI programs to instruments, so files play with sensible sounds without
+// wiring every program. A program plays the patch set for it in Programs, or otherwise that
+// of its family, and the drum channel plays Drums whatever its program. Its Instrument method
+// is an InstrumentMap.
+type GMMap struct {
+	Families [16]Patch
+	Programs map[int]Patch
+	Drums    Patch
+}
+
+// scaledVoice plays a voice at a lower level, so that the voices of a patch can be mixed
+type scaledVoice struct {
+	synth.PolyVoice
+	gain float64
+}
+
+func (s scaledVoice) Tick() float64 {
+	return s.PolyVoice.Tick() * s.gain
+}
+
+// polyPatch plays a patch with a polyphony of voices
+func polyPatch(newVoice func() (synth.PolyVoice, error)) Patch {
+	return func() (synth.Instrument, error) {
+		p, err := synth.NewPolyphony(gmVoices, newVoice)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// voicePatch plays subtractive voices of the shapes, set up by a function
+func voicePatch(sr int, setup func(v *synth.Voice), shapes ...synth.Shape) Patch {
+	return polyPatch(func() (synth.PolyVoice, error) {
+		v, err := synth.NewVoice(sr, shapes...)
+		if err != nil {
+			return nil, err
+		}
+		v.Gain = gmGain
+		setup(v)
+		return v, nil
+	})
+}
+
+// modalPatch plays struck objects of a material
+func modalPatch(sr int, m synth.Material) Patch {
+	seed := int64(0)
+	return polyPatch(func() (synth.PolyVoice, error) {
+		seed++
+		v, err := synth.NewModal(sr, m, seed)
+		if err != nil {
+			return nil, err
+		}
+		return scaledVoice{v, gmGain * 3}, nil
+	})
+}
+
+// pluckPatch plays plucked strings
+func pluckPatch(sr int, decay float64) Patch {
+	seed := int64(0)
+	return polyPatch(func() (synth.PolyVoice, error) {
+		seed++
+		v := synth.NewPluck(sr, seed)
+		v.Decay = decay
+		return scaledVoice{v, gmGain * 2}, nil
+	})
+}
+
+// NewGMMap creates a map with a patch made from the instruments of the synthesizer for every
+// family, struck bars and bells for the mallets, and the General MIDI drum kit
+func NewGMMap(sr int) *GMMap {
+	envelope := func(attack, decay, sustain, release float64) func(v *synth.Voice) {
+		return func(v *synth.Voice) {
+			v.AmpEnv = synth.NewDAHDSR(sr, 0, attack, 0, decay, sustain, release)
+		}
+	}
+	filter := func(cutoff, amount float64, then func(v *synth.Voice)) func(v *synth.Voice) {
+		return func(v *synth.Voice) {
+			v.Cutoff, v.EnvAmount = cutoff, amount
+			then(v)
+		}
+	}
+	detuned := func(cents float64, then func(v *synth.Voice)) func(v *synth.Voice) {
+		return func(v *synth.Voice) {
+			v.Oscillators[0].Detune, v.Oscillators[1].Detune = -cents, cents
+			then(v)
+		}
+	}
+	saw, square := synth.UPWARD_SAWTOOTH, synth.SQUARE
+
+	g := &GMMap{Programs: map[int]Patch{}}
+	g.Families = [16]Patch{
+		PIANO:                voicePatch(sr, filter(3000, 1, envelope(.005, 1.5, 0, .4)), synth.TRIANGLE, saw),
+		CHROMATIC_PERCUSSION: modalPatch(sr, synth.GLASS),
+		ORGAN:                voicePatch(sr, filter(4000, 0, envelope(.01, .1, 1, .05)), synth.SINE, square),
+		GUITAR:               pluckPatch(sr, 2),
+		BASS:                 voicePatch(sr, filter(600, 2, envelope(.005, .8, .3, .1)), saw),
+		STRINGS:              voicePatch(sr, detuned(8, filter(2500, 0, envelope(.2, .1, 1, .4))), saw, saw),
+		ENSEMBLE:             voicePatch(sr, detuned(10, filter(2000, 0, envelope(.25, .1, 1, .5))), saw, saw),
+		BRASS:                voicePatch(sr, filter(800, 2.5, envelope(.05, .2, .8, .1)), saw),
+		REED:                 voicePatch(sr, filter(1500, 1, envelope(.03, .1, .9, .1)), square),
+		PIPE:                 voicePatch(sr, filter(3000, 0, envelope(.08, .1, .9, .15)), synth.SINE, synth.TRIANGLE),
+		SYNTH_LEAD:           voicePatch(sr, filter(3000, 1, envelope(.005, .2, .8, .1)), square, saw),
+		SYNTH_PAD:            voicePatch(sr, detuned(12, filter(1500, 1, envelope(.6, .5, .8, 1.2))), saw, saw),
+		SYNTH_EFFECTS:        voicePatch(sr, filter(1000, 3, envelope(.3, 1, .5, 1)), synth.TRIANGLE, square),
+		ETHNIC:               pluckPatch(sr, 3),
+		PERCUSSIVE:           modalPatch(sr, synth.WOOD),
+		SOUND_EFFECTS:        voicePatch(sr, filter(2000, 2, envelope(.01, .5, .3, .5)), square),
+	}
+	// marimba, xylophone and tubular bells
+	g.Programs[12] = modalPatch(sr, synth.WOOD)
+	g.Programs[13] = modalPatch(sr, synth.WOOD)
+	g.Programs[14] = modalPatch(sr, synth.METAL)
+	// pizzicato strings and harp are plucked
+	g.Programs[45] = pluckPatch(sr, .8)
+	g.Programs[46] = pluckPatch(sr, 3)
+
+	seed := int64(0)
+	g.Drums = func() (synth.Instrument, error) {
+		seed += 100
+		return synth.NewGMDrumKit(sr, seed), nil
+	}
+	return g
+}
+
+// Instrument creates the instrument of a program on a channel
+func (g *GMMap) Instrument(channel, program int) (synth.Instrument, error) {
+	if program < 0 || program > 127 {
+		return nil, fmt.Errorf("Program %v is not a General MIDI program", program)
+	}
+	patch := g.Drums
+	if channel != GM_DRUMS {
+		var ok bool
+		if patch, ok = g.Programs[program]; !ok {
+			patch = g.Families[ProgramFamily(program)]
+		}
+	}
+	if patch == nil {
+		return nil, fmt.Errorf("No patch for program %v on channel %v", program, channel)
+	}
+	return patch()
+}
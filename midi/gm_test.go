@@ -0,0 +1,95 @@
+package midi
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestGMNames(t *testing.T) {
+	tests := []struct {
+		program int
+		name    string
+		family  Family
+	}{
+		{0, "Acoustic Grand Piano", PIANO},
+		{24, "Acoustic Guitar (nylon)", GUITAR},
+		{73, "Flute", PIPE},
+		{127, "Gunshot", SOUND_EFFECTS},
+		{128, "", 16},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if name := ProgramName(test.program); name != test.name {
+				t.Fatalf("Expected %v, got %v", test.name, name)
+			}
+			if test.name != "" && ProgramFamily(test.program) != test.family {
+				t.Fatalf("Expected family %v, got %v", test.family, ProgramFamily(test.program))
+			}
+		})
+	}
+	if DrumName(35) != "Acoustic Bass Drum" || DrumName(42) != "Closed Hi-Hat" || DrumName(81) != "Open Triangle" || DrumName(82) != "" {
+		t.Fatal("Expected the General MIDI percussion map")
+	}
+}
+
+func TestGMMap(t *testing.T) {
+	sr := 8000
+	g := NewGMMap(sr)
+	for program := 0; program < 128; program++ {
+		inst, err := g.Instrument(0, program)
+		if err != nil || inst == nil {
+			t.Fatalf("Expected an instrument for program %v, got %v", program, err)
+		}
+	}
+	if inst, _ := g.Instrument(GM_DRUMS, 5); inst == nil {
+		t.Fatal("Expected the drum kit on the drum channel")
+	} else if _, ok := inst.(*synth.DrumKit); !ok {
+		t.Fatalf("Expected the drum kit on the drum channel, got %T", inst)
+	}
+	if _, err := g.Instrument(0, 128); err == nil {
+		t.Fatal("Expected an error for a program outside General MIDI")
+	}
+	g.Families[ORGAN] = nil
+	if _, err := g.Instrument(0, 16); err == nil {
+		t.Fatal("Expected an error for a family without a patch")
+	}
+
+	// a program of its own takes precedence over the family
+	played := false
+	g.Programs[16] = func() (synth.Instrument, error) {
+		played = true
+		return &logInstrument{notes: map[int]bool{}}, nil
+	}
+	if _, err := g.Instrument(0, 16); err != nil || !played {
+		t.Fatalf("Expected the patch of the program, got %v", err)
+	}
+}
+
+func TestRenderGM(t *testing.T) {
+	track := []byte{
+		0x00, 0xC0, 0x18, // guitar on channel 1
+		0x00, 0x90, 0x3C, 0x64,
+		0x00, 0x99, 0x24, 0x64, // kick on the drum channel
+		0x60, 0x80, 0x3C, 0x00,
+		0x00, 0x89, 0x24, 0x00,
+	}
+	f, err := Parse(bytes.NewReader(smf(0, 96, track)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := Render(f, NewGMMap(8000).Instrument, wave.NewWaveFmt(1, 1, 8000, 16, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	peak := 0.0
+	for _, v := range frames {
+		peak = math.Max(peak, math.Abs(float64(v)))
+	}
+	if peak < .05 || peak > 1 {
+		t.Fatalf("Expected the guitar and kick to be audible without clipping, got %v", peak)
+	}
+}
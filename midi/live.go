@@ -0,0 +1,251 @@
+package midi
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Port describes a MIDI input or output of the system
+type Port struct {
+	Name        string // name to open the port with
+	Description string // human readable name
+}
+
+// Input is an open MIDI input
+type Input interface {
+	// Read blocks until the next event arrives, the Tick of live events is not set
+	Read() (Event, error)
+	Close() error
+}
+
+// rawInput parses the byte stream of a MIDI port
+type rawInput struct {
+	r       io.ReadCloser
+	p       parser
+	buf     []byte
+	pending []Event
+}
+
+// NewRawInput reads events from a stream of MIDI bytes, such as a raw MIDI device, a
+// serial port or a pipe. Closing the input closes the stream.
+func NewRawInput(r io.ReadCloser) Input {
+	return &rawInput{r: r, buf: make([]byte, 256)}
+}
+
+func (in *rawInput) Read() (Event, error) {
+	for len(in.pending) == 0 {
+		n, err := in.r.Read(in.buf)
+		for _, b := range in.buf[:n] {
+			if e, ok := in.p.feed(b); ok {
+				in.pending = append(in.pending, e)
+			}
+		}
+		if len(in.pending) == 0 && err != nil {
+			return Event{}, err
+		}
+	}
+	e := in.pending[0]
+	in.pending = in.pending[1:]
+	return e, nil
+}
+
+func (in *rawInput) Close() error {
+	return in.r.Close()
+}
+
+// Inputs returns the MIDI inputs of the system
+func Inputs() ([]Port, error) {
+	return inputs()
+}
+
+// OpenInput opens a MIDI input by the name of its port, or the first input for ""
+func OpenInput(name string) (Input, error) {
+	if name == "" {
+		ports, err := inputs()
+		if err != nil {
+			return nil, err
+		}
+		if len(ports) == 0 {
+			return nil, errors.New("No MIDI inputs")
+		}
+		name = ports[0].Name
+	}
+	return openInput(name)
+}
+
+// Output is an open MIDI output
+type Output interface {
+	Write(e Event) error
+	Close() error
+}
+
+// rawOutput writes the messages of events to a stream of MIDI bytes
+type rawOutput struct {
+	w io.WriteCloser
+}
+
+// NewRawOutput writes events to a stream of MIDI bytes, without running status. Closing
+// the output closes the stream.
+func NewRawOutput(w io.WriteCloser) Output {
+	return &rawOutput{w: w}
+}
+
+func (out *rawOutput) Write(e Event) error {
+	m, err := e.Message()
+	if err != nil {
+		return err
+	}
+	_, err = out.w.Write(m)
+	return err
+}
+
+func (out *rawOutput) Close() error {
+	return out.w.Close()
+}
+
+// Outputs returns the MIDI outputs of the system
+func Outputs() ([]Port, error) {
+	return outputs()
+}
+
+// OpenOutput opens a MIDI output by the name of its port, or the first output for ""
+func OpenOutput(name string) (Output, error) {
+	if name == "" {
+		ports, err := outputs()
+		if err != nil {
+			return nil, err
+		}
+		if len(ports) == 0 {
+			return nil, errors.New("No MIDI outputs")
+		}
+		name = ports[0].Name
+	}
+	return openOutput(name)
+}
+
+// Live plays the instruments of a map from a MIDI input in real time. Events are read on a
+// separate goroutine and played at the start of the next block, so they are late by up to
+// a block on top of the latency of the output.
+type Live struct {
+	Channels int
+
+	in      Input
+	p       *player
+	mu      sync.Mutex
+	pending []Event
+	err     error
+	closed  bool
+	done    chan struct{}
+	forward func(Event)
+}
+
+// NewLive starts reading events from the input, which are played by Render. The instruments
+// are mono, their mix is copied to every channel.
+func NewLive(in Input, instruments InstrumentMap, channels int) (*Live, error) {
+	if in == nil || instruments == nil {
+		return nil, errors.New("Need an input and an instrument map")
+	}
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	l := &Live{
+		Channels: channels,
+		in:       in,
+		p:        newPlayer(instruments),
+		done:     make(chan struct{}),
+	}
+	go l.read()
+	return l, nil
+}
+
+func (l *Live) read() {
+	defer close(l.done)
+	for {
+		e, err := l.in.Read()
+		l.mu.Lock()
+		if err != nil {
+			if !l.closed && err != io.EOF {
+				l.err = err
+			}
+			l.mu.Unlock()
+			return
+		}
+		forward := l.forward
+		if e.Type <= PITCH_BEND {
+			l.pending = append(l.pending, e)
+		}
+		l.mu.Unlock()
+		if forward != nil && e.Type > PITCH_BEND {
+			forward(e)
+		}
+	}
+}
+
+// Forward passes the events of the input which aren't played, such as clock and time code,
+// to a handler on the goroutine reading the input. The handler of a ClockFollower lets a
+// transport follow the input along with the instruments.
+func (l *Live) Forward(handle func(Event)) {
+	l.mu.Lock()
+	l.forward = handle
+	l.mu.Unlock()
+}
+
+// Queue plays events at the start of the next block along with those of the input, such as
+// the MPE configuration of a controller which doesn't send it
+func (l *Live) Queue(events ...Event) {
+	l.mu.Lock()
+	l.pending = append(l.pending, events...)
+	l.mu.Unlock()
+}
+
+// Render plays the events which arrived since the last block and fills out with the
+// instruments, it can be used as the callback of a playback stream
+func (l *Live) Render(out []wave.Frame) int {
+	l.mu.Lock()
+	events := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	for _, e := range events {
+		if err := l.p.handle(e); err != nil {
+			l.mu.Lock()
+			if l.err == nil {
+				l.err = err
+			}
+			l.mu.Unlock()
+		}
+	}
+	n := len(out) / l.Channels * l.Channels
+	for i := 0; i < n; i += l.Channels {
+		v := wave.Frame(l.p.tick())
+		for c := 0; c < l.Channels; c++ {
+			out[i+c] = v
+		}
+	}
+	return len(out)
+}
+
+// Err returns the error which stopped reading the input, or the first error of the
+// instrument map
+func (l *Live) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close closes the input and releases the notes still held by the next Render
+func (l *Live) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+	err := l.in.Close()
+	<-l.done
+	l.mu.Lock()
+	for ch := 0; ch < 16; ch++ {
+		l.pending = append(l.pending, Event{Type: CONTROL_CHANGE, Channel: ch, Controller: CC_ALL_NOTES_OFF})
+	}
+	l.mu.Unlock()
+	return err
+}
@@ -0,0 +1,111 @@
+package midi
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestRawInput(t *testing.T) {
+	data := []byte{
+		0x90, 0x3C, 0xF8, 0x64, // note on with a clock in between
+		0x3E, 0x00, // running status, note off
+		0xFE,             // active sensing
+		0xC2, 0x05, 0x06, // program changes with running status
+		0xF0, 0x7E, 0x01, 0xF7, // sysex
+		0x45,             // data without a status after sysex is dropped
+		0xE0, 0x00, 0x40, // pitch bend center
+		0xF2, 0x01, 0x02, // song position ends running status
+		0x10, 0xF1, // data without a status is dropped, as is a truncated quarter frame
+		0xB1, 0x40, // truncated
+	}
+	in := NewRawInput(ioutil.NopCloser(bytes.NewReader(data)))
+	expected := []Event{
+		{Type: CLOCK},
+		{Type: NOTE_ON, Note: 0x3C, Velocity: 0x64},
+		{Type: NOTE_OFF, Note: 0x3E},
+		{Type: PROGRAM_CHANGE, Channel: 2, Value: 5},
+		{Type: PROGRAM_CHANGE, Channel: 2, Value: 6},
+		{Type: SYSEX, Data: []byte{0x7E, 0x01, 0xF7}},
+		{Type: PITCH_BEND},
+		{Type: SONG_POSITION, Value: 2<<7 | 1},
+	}
+	for i, e := range expected {
+		got, err := in.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != e.Type || got.Channel != e.Channel || got.Note != e.Note ||
+			got.Velocity != e.Velocity || got.Value != e.Value || !bytes.Equal(got.Data, e.Data) {
+			t.Fatalf("Event %v: expected %+v, got %+v", i, e, got)
+		}
+	}
+	if e, err := in.Read(); err != io.EOF {
+		t.Fatalf("Expected EOF, got %+v, %v", e, err)
+	}
+}
+
+func TestLive(t *testing.T) {
+	r, w := io.Pipe()
+	inst := &logInstrument{notes: map[int]bool{}}
+	shared := func(channel, program int) (synth.Instrument, error) {
+		return inst, nil
+	}
+	l, err := NewLive(NewRawInput(r), shared, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := make([]wave.Frame, 8)
+
+	// events are played at the start of the next block
+	w.Write([]byte{0x90, 0x3C, 0x7F, 0x91, 0x40, 0x40})
+	waitPending(l, 2)
+	l.Render(block)
+	expected := []wave.Frame{2, 2, 2, 2, 2, 2, 2, 2}
+	for i := range expected {
+		if block[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, block)
+		}
+	}
+	if len(inst.on) != 2 || inst.ticks != 4 {
+		// the instrument is shared by both channels and ticked once per frame
+		t.Fatalf("Expected 2 notes and 4 ticks, got %v and %v", inst.on, inst.ticks)
+	}
+
+	w.Write([]byte{0xB0, 0x40, 0x7F, 0x80, 0x3C, 0x00})
+	waitPending(l, 2)
+	l.Render(block)
+	if len(inst.notes) != 2 {
+		t.Fatalf("Expected the pedal to sustain the note, got %v", inst.notes)
+	}
+
+	// closing releases the held notes
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l.Render(block)
+	if len(inst.notes) != 0 || block[0] != 0 {
+		t.Fatalf("Expected the notes to be released, got %v", inst.notes)
+	}
+	if err := l.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitPending waits until events have been read from the input of a live player
+func waitPending(l *Live, n int) {
+	for {
+		l.mu.Lock()
+		pending := len(l.pending)
+		l.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,145 @@
+package midi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// parser turns the bytes of a MIDI port into events, as they arrive
+type parser struct {
+	status  byte // for running status
+	common  byte // system common message waiting for its data
+	data    []byte
+	inSysex bool
+	sysex   []byte
+}
+
+// realTime are the events of the real time messages, by status byte
+var realTime = map[byte]EventType{0xF8: CLOCK, 0xFA: START, 0xFB: CONTINUE, 0xFC: STOP}
+
+// feed adds a byte, returning an event when the byte completes a message
+func (p *parser) feed(b byte) (Event, bool) {
+	switch {
+	case b >= 0xF8:
+		// real time messages may appear anywhere, even within other messages
+		t, ok := realTime[b]
+		return Event{Type: t}, ok
+	case b == 0xF0:
+		p.status, p.common, p.inSysex, p.sysex = 0, 0, true, p.sysex[:0]
+		return Event{}, false
+	case b == 0xF7:
+		if !p.inSysex {
+			return Event{}, false
+		}
+		p.inSysex = false
+		return Event{Type: SYSEX, Data: append(append([]byte{}, p.sysex...), 0xF7)}, true
+	case b&0x80 != 0:
+		// system common messages end running status
+		p.status, p.common, p.inSysex, p.data = 0, 0, false, p.data[:0]
+		if b < 0xF0 {
+			p.status = b
+		} else if b == 0xF1 || b == 0xF2 || b == 0xF3 {
+			p.common = b
+		}
+		return Event{}, false
+	case p.inSysex:
+		p.sysex = append(p.sysex, b)
+		return Event{}, false
+	case p.common != 0:
+		p.data = append(p.data, b)
+		switch {
+		case p.common == 0xF1:
+			p.common = 0
+			return Event{Type: QUARTER_FRAME, Value: int(b)}, true
+		case p.common == 0xF2 && len(p.data) == 2:
+			p.common = 0
+			return Event{Type: SONG_POSITION, Value: int(p.data[1])<<7 | int(p.data[0])}, true
+		case p.common == 0xF3:
+			// song select is skipped
+			p.common = 0
+		}
+		return Event{}, false
+	case p.status == 0:
+		return Event{}, false
+	}
+	p.data = append(p.data, b)
+	size := 2
+	if kind := p.status >> 4; kind == 0xC || kind == 0xD {
+		size = 1
+	}
+	if len(p.data) < size {
+		return Event{}, false
+	}
+	d2 := byte(0)
+	if size == 2 {
+		d2 = p.data[1]
+	}
+	e, _ := channelEvent(p.status, p.data[0], d2)
+	p.data = p.data[:0]
+	return e, true
+}
+
+// channelMessage encodes a channel event to its status and data bytes
+func channelMessage(e Event) ([]byte, error) {
+	var s, d1, d2 byte
+	size := 2
+	switch e.Type {
+	case NOTE_OFF:
+		s, d1, d2 = 0x80, byte(e.Note), byte(e.Velocity)
+	case NOTE_ON:
+		s, d1, d2 = 0x90, byte(e.Note), byte(e.Velocity)
+	case KEY_PRESSURE:
+		s, d1, d2 = 0xA0, byte(e.Note), byte(e.Velocity)
+	case CONTROL_CHANGE:
+		s, d1, d2 = 0xB0, byte(e.Controller), byte(e.Value)
+	case PROGRAM_CHANGE:
+		s, d1, size = 0xC0, byte(e.Value), 1
+	case CHANNEL_PRESSURE:
+		s, d1, size = 0xD0, byte(e.Value), 1
+	case PITCH_BEND:
+		v := e.Value + 8192
+		if v < 0 || v > 0x3FFF {
+			return nil, errors.New("Pitch bend out of range")
+		}
+		s, d1, d2 = 0xE0, byte(v&0x7F), byte(v>>7)
+	default:
+		return nil, fmt.Errorf("Event type %v is not a channel event", e.Type)
+	}
+	if e.Channel < 0 || e.Channel > 15 || d1 > 0x7F || d2 > 0x7F {
+		return nil, errors.New("Channel event out of range")
+	}
+	if size == 1 {
+		return []byte{s | byte(e.Channel), d1}, nil
+	}
+	return []byte{s | byte(e.Channel), d1, d2}, nil
+}
+
+// Message encodes an event as it is sent to a MIDI port, without running status.
+// System exclusive messages get their leading 0xF0, meta events can't be sent.
+func (e Event) Message() ([]byte, error) {
+	switch e.Type {
+	case SYSEX:
+		return append([]byte{0xF0}, e.Data...), nil
+	case CLOCK:
+		return []byte{0xF8}, nil
+	case START:
+		return []byte{0xFA}, nil
+	case CONTINUE:
+		return []byte{0xFB}, nil
+	case STOP:
+		return []byte{0xFC}, nil
+	case SONG_POSITION:
+		if e.Value < 0 || e.Value > 0x3FFF {
+			return nil, errors.New("Song position out of range")
+		}
+		return []byte{0xF2, byte(e.Value & 0x7F), byte(e.Value >> 7)}, nil
+	case QUARTER_FRAME:
+		if e.Value < 0 || e.Value > 0x7F {
+			return nil, errors.New("Quarter frame out of range")
+		}
+		return []byte{0xF1, byte(e.Value)}, nil
+	case TEMPO, TIME_SIGNATURE, META:
+		return nil, errors.New("Meta events only exist in files")
+	}
+	return channelMessage(e)
+}
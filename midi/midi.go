@@ -0,0 +1,78 @@
+// Package midi reads and writes Standard MIDI Files, plays them or live MIDI input on the
+// instruments of the synthesizer, and converts between MIDI and the note events and tempo
+// maps of the synthesizer and breakpoint packages.
+package midi
+
+// EventType is the kind of a MIDI event
+type EventType int
+
+const (
+	NOTE_OFF         EventType = iota
+	NOTE_ON                    // a note on with velocity 0 is read as a NOTE_OFF
+	KEY_PRESSURE               // polyphonic aftertouch
+	CONTROL_CHANGE             // Controller and Value
+	PROGRAM_CHANGE             // the program in Value
+	CHANNEL_PRESSURE           // the pressure in Value
+	PITCH_BEND                 // the bend in Value, from -8192 to 8191
+	SYSEX                      // Data holds the message without the leading 0xF0
+	TEMPO                      // microseconds per quarter note in Value
+	TIME_SIGNATURE             // Data holds numerator, log2 of the denominator, clocks per click and 32nds per quarter
+	META                       // other meta events, of type Meta with Data
+	CLOCK                      // real time, 24 clocks per quarter note
+	START                      // real time, play from the start
+	CONTINUE                   // real time, play on from the position
+	STOP                       // real time
+	SONG_POSITION              // the position in 16th notes in Value
+	QUARTER_FRAME              // a piece of MIDI time code, the data byte in Value
+)
+
+// Common meta event types
+const (
+	META_TEXT           = 0x01
+	META_TRACK_NAME     = 0x03
+	META_INSTRUMENT     = 0x04
+	META_LYRIC          = 0x05
+	META_MARKER         = 0x06
+	META_END_OF_TRACK   = 0x2F
+	META_TEMPO          = 0x51
+	META_TIME_SIGNATURE = 0x58
+	META_KEY_SIGNATURE  = 0x59
+)
+
+// Event is an event of a track at an absolute time in ticks
+type Event struct {
+	Tick       int64
+	Type       EventType
+	Channel    int // 0-15 for channel events
+	Note       int // for NOTE_ON, NOTE_OFF and KEY_PRESSURE
+	Velocity   int // for NOTE_ON and NOTE_OFF, the pressure for KEY_PRESSURE
+	Controller int // for CONTROL_CHANGE
+	Value      int
+	Meta       int // type of META, TEMPO and TIME_SIGNATURE events
+	Data       []byte
+}
+
+// TimeSignature returns the numerator and denominator of a TIME_SIGNATURE event
+func (e Event) TimeSignature() (numerator, denominator int) {
+	if len(e.Data) < 2 {
+		return 4, 4
+	}
+	return int(e.Data[0]), 1 << e.Data[1]
+}
+
+// Track is a sequence of events sorted by tick
+type Track struct {
+	Name   string
+	Events []Event
+}
+
+// File is a Standard MIDI File
+type File struct {
+	Format   int // 0 for a single track, 1 for simultaneous tracks, 2 for independent patterns
+	Division int // ticks per quarter note
+	// SMPTE timing, when set the ticks are FramesPerSecond*TicksPerFrame per second
+	// regardless of the tempo, and Division is ignored
+	FramesPerSecond int
+	TicksPerFrame   int
+	Tracks          []Track
+}
@@ -0,0 +1,154 @@
+package midi
+
+import (
+	"errors"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// Manager channels of the MPE zones
+const (
+	MPE_LOWER = 0  // members from channel 1 up
+	MPE_UPPER = 15 // members from channel 14 down
+)
+
+// Registered parameters
+const (
+	rpnBendRange = 0
+	rpnMPE       = 6
+	rpnNull      = 0x3FFF
+)
+
+// mpeBendRange is the bend range in semitones of member channels after configuration
+const mpeBendRange = 48
+
+// ExpressiveInstrument is an instrument with per-note expression, such as a Polyphony. In an
+// MPE zone the bend, pressure and timbre of each member channel go to the notes it plays.
+type ExpressiveInstrument interface {
+	synth.Instrument
+	NoteExpression(note int, d synth.Dimension, value float64)
+}
+
+// MPEConfiguration returns the MPE configuration message of a zone, which gives the zone of a
+// manager channel a number of member channels, 0 turns the zone off. Render and Live play
+// the zones as they are configured by the events, the configuration can be queued on a Live
+// for controllers which don't send it.
+func MPEConfiguration(manager, members int) ([]Event, error) {
+	if manager != MPE_LOWER && manager != MPE_UPPER {
+		return nil, errors.New("The manager of a zone should be channel 0 or 15")
+	}
+	if members < 0 || members > 15 {
+		return nil, errors.New("A zone has up to 15 members")
+	}
+	cc := func(controller, value int) Event {
+		return Event{Type: CONTROL_CHANGE, Channel: manager, Controller: controller, Value: value}
+	}
+	return []Event{
+		cc(CC_RPN_MSB, rpnMPE>>7),
+		cc(CC_RPN_LSB, rpnMPE&0x7F),
+		cc(CC_DATA_ENTRY, members),
+		cc(CC_RPN_MSB, rpnNull>>7),
+		cc(CC_RPN_LSB, rpnNull&0x7F),
+	}, nil
+}
+
+// dataEntry sets the registered parameter of a channel, the bend range or an MPE zone
+func (p *player) dataEntry(c *renderChannel, value int) {
+	switch {
+	case c.rpn == rpnBendRange && c.manager != nil:
+		// the bend range of a member applies to the whole zone
+		for _, m := range c.manager.members {
+			m.bendRange = float64(value)
+		}
+	case c.rpn == rpnBendRange:
+		c.bendRange = float64(value)
+	case c.rpn == rpnMPE && (c.number == MPE_LOWER || c.number == MPE_UPPER):
+		p.configure(c, value)
+	}
+}
+
+// configure gives the zone of a manager channel its members, taking them from the other zone
+func (p *player) configure(manager *renderChannel, members int) {
+	p.dissolve(manager)
+	manager.bendRange = 2
+	for i := 1; i <= members && i < 15; i++ {
+		ch := i
+		if manager.number == MPE_UPPER {
+			ch = 15 - i
+		}
+		m := p.channels[ch]
+		if m.manager != nil {
+			p.leave(m)
+		}
+		p.dissolve(m)
+		m.manager = manager
+		m.bendRange = mpeBendRange
+		m.bend, m.pressure, m.timbre = 0, 0, 64./127
+		manager.members = append(manager.members, m)
+	}
+}
+
+// dissolve turns off the zone of a manager
+func (p *player) dissolve(manager *renderChannel) {
+	for _, m := range manager.members {
+		p.release(m)
+		m.manager = nil
+		m.bendRange = 2
+	}
+	manager.members = nil
+}
+
+// leave removes a member from its zone
+func (p *player) leave(m *renderChannel) {
+	p.release(m)
+	manager := m.manager
+	for i, other := range manager.members {
+		if other == m {
+			manager.members = append(manager.members[:i], manager.members[i+1:]...)
+			break
+		}
+	}
+	m.manager = nil
+	m.bendRange = 2
+}
+
+// express sends dimensions of the expression of a member channel to one of its notes, the
+// bend of the manager adds to that of the member
+func (p *player) express(c *renderChannel, note int, dimensions ...synth.Dimension) {
+	for _, d := range dimensions {
+		var v float64
+		switch d {
+		case synth.BEND:
+			v = c.bend*c.bendRange + c.manager.bend*c.manager.bendRange
+		case synth.PRESSURE:
+			v = c.pressure
+		case synth.TIMBRE:
+			v = c.timbre
+		}
+		for _, inst := range c.manager.instruments {
+			if e, ok := inst.(ExpressiveInstrument); ok {
+				e.NoteExpression(note, d, v)
+			}
+		}
+	}
+}
+
+// expressZone sends a dimension of a member channel to its notes, or the bend of a manager
+// to the notes of every member
+func (p *player) expressZone(c *renderChannel, d synth.Dimension) {
+	members := []*renderChannel{c}
+	if c.manager == nil {
+		if d != synth.BEND {
+			return
+		}
+		members = c.members
+	}
+	for _, m := range members {
+		for note := range m.held {
+			p.express(m, note, d)
+		}
+		for note := range m.sustained {
+			p.express(m, note, d)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package midi
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// expressionInstrument records the last expression of every note
+type expressionInstrument struct {
+	logInstrument
+	expression map[int]map[synth.Dimension]float64
+}
+
+func (e *expressionInstrument) NoteExpression(note int, d synth.Dimension, value float64) {
+	if e.expression[note] == nil {
+		e.expression[note] = map[synth.Dimension]float64{}
+	}
+	e.expression[note][d] = value
+}
+
+func TestMPE(t *testing.T) {
+	inst := &expressionInstrument{
+		logInstrument: logInstrument{notes: map[int]bool{}},
+		expression:    map[int]map[synth.Dimension]float64{},
+	}
+	channels := []int{}
+	p := newPlayer(func(channel, program int) (synth.Instrument, error) {
+		channels = append(channels, channel)
+		return inst, nil
+	})
+	play := func(events ...Event) {
+		for _, e := range events {
+			if err := p.handle(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	config, err := MPEConfiguration(MPE_LOWER, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	play(config...)
+	play(
+		Event{Type: PITCH_BEND, Channel: 1, Value: 4096},
+		Event{Type: NOTE_ON, Channel: 1, Note: 60, Velocity: 100},
+		Event{Type: NOTE_ON, Channel: 2, Note: 64, Velocity: 100},
+		Event{Type: CHANNEL_PRESSURE, Channel: 2, Value: 127},
+		Event{Type: CONTROL_CHANGE, Channel: 1, Controller: CC_TIMBRE, Value: 127},
+	)
+	if len(channels) != 1 || channels[0] != MPE_LOWER {
+		t.Fatalf("Expected the members to play the instrument of the manager, got %v", channels)
+	}
+	c, e := inst.expression[60], inst.expression[64]
+	if c[synth.BEND] != 24 || c[synth.PRESSURE] != 0 || c[synth.TIMBRE] != 1 {
+		t.Fatalf("Expected C4 bent by 24 semitones with full timbre, got %v", c)
+	}
+	if e[synth.BEND] != 0 || e[synth.PRESSURE] != 1 || math.Abs(e[synth.TIMBRE]-.5) > .01 {
+		t.Fatalf("Expected E4 with full pressure, got %v", e)
+	}
+
+	// the bend of the manager adds to all notes, the bend range of a member to all members
+	play(
+		Event{Type: PITCH_BEND, Channel: MPE_LOWER, Value: -8192},
+		Event{Type: CONTROL_CHANGE, Channel: 2, Controller: CC_RPN_MSB, Value: 0},
+		Event{Type: CONTROL_CHANGE, Channel: 2, Controller: CC_RPN_LSB, Value: 0},
+		Event{Type: CONTROL_CHANGE, Channel: 2, Controller: CC_DATA_ENTRY, Value: 12},
+		Event{Type: PITCH_BEND, Channel: 1, Value: 4096},
+	)
+	if c[synth.BEND] != 4 || e[synth.BEND] != -2 {
+		t.Fatalf("Expected the bends of the manager and member to add up, got %v and %v", c[synth.BEND], e[synth.BEND])
+	}
+
+	// the pedal of the manager holds the notes of the members
+	play(
+		Event{Type: CONTROL_CHANGE, Channel: MPE_LOWER, Controller: CC_SUSTAIN, Value: 127},
+		Event{Type: NOTE_OFF, Channel: 1, Note: 60},
+	)
+	if !inst.notes[60] {
+		t.Fatal("Expected the note to be sustained")
+	}
+	play(Event{Type: CONTROL_CHANGE, Channel: MPE_LOWER, Controller: CC_SUSTAIN, Value: 0})
+	if inst.notes[60] || !inst.notes[64] {
+		t.Fatalf("Expected the sustained note to be released, got %v", inst.notes)
+	}
+
+	// turning the zone off makes the members ordinary channels
+	config, _ = MPEConfiguration(MPE_LOWER, 0)
+	play(config...)
+	play(Event{Type: NOTE_ON, Channel: 3, Note: 67, Velocity: 100})
+	if len(channels) != 2 || channels[1] != 3 || inst.expression[67] != nil {
+		t.Fatalf("Expected channel 3 to play without expression, got %v", channels)
+	}
+
+	if _, err := MPEConfiguration(3, 4); err == nil {
+		t.Fatal("Expected an error for a zone without a manager channel")
+	}
+	if _, err := MPEConfiguration(MPE_UPPER, 16); err == nil {
+		t.Fatal("Expected an error for too many members")
+	}
+}
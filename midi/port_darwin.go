@@ -0,0 +1,280 @@
+package midi
+
+// MIDI input and output through CoreMIDI. The read procedure runs on a thread of CoreMIDI,
+// it copies the bytes into a buffer in C which is read from Go, so Go is never called back.
+
+/*
+#cgo LDFLAGS: -framework CoreMIDI -framework CoreFoundation
+#include <CoreMIDI/CoreMIDI.h>
+#include <dispatch/dispatch.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define GM_BUFFER 65536
+
+typedef struct {
+	MIDIClientRef client;
+	MIDIPortRef port;
+	MIDIEndpointRef source;
+	unsigned char data[GM_BUFFER];
+	int start, size;
+	int closed;
+	dispatch_semaphore_t available; // signaled when bytes arrive or the input is closed
+	pthread_mutex_t lock;
+} gmInput;
+
+static void gmRead(const MIDIPacketList *list, void *user, void *src) {
+	gmInput *in = user;
+	pthread_mutex_lock(&in->lock);
+	const MIDIPacket *p = &list->packet[0];
+	for (UInt32 i = 0; i < list->numPackets; i++) {
+		for (int j = 0; j < p->length && in->size < GM_BUFFER; j++) {
+			in->data[(in->start + in->size++) % GM_BUFFER] = p->data[j];
+		}
+		p = MIDIPacketNext(p);
+	}
+	pthread_mutex_unlock(&in->lock);
+	dispatch_semaphore_signal(in->available);
+}
+
+static OSStatus gmOpen(gmInput *in, int index) {
+	if (index < 0 || index >= (int)MIDIGetNumberOfSources()) {
+		return kMIDIInvalidPort;
+	}
+	in->source = MIDIGetSource(index);
+	in->available = dispatch_semaphore_create(0);
+	pthread_mutex_init(&in->lock, NULL);
+	OSStatus err = MIDIClientCreate(CFSTR("GoAudio"), NULL, NULL, &in->client);
+	if (err == 0) {
+		err = MIDIInputPortCreate(in->client, CFSTR("input"), gmRead, in, &in->port);
+	}
+	if (err == 0) {
+		err = MIDIPortConnectSource(in->port, in->source, NULL);
+	}
+	if (err) {
+		if (in->client) {
+			MIDIClientDispose(in->client);
+		}
+		dispatch_release(in->available);
+		pthread_mutex_destroy(&in->lock);
+	}
+	return err;
+}
+
+// gmReadBytes waits for bytes and copies up to size of them, it returns -1 once closed
+static int gmReadBytes(gmInput *in, unsigned char *buf, int size) {
+	for (;;) {
+		pthread_mutex_lock(&in->lock);
+		if (in->size > 0) {
+			int n = in->size < size ? in->size : size;
+			for (int i = 0; i < n; i++) {
+				buf[i] = in->data[(in->start + i) % GM_BUFFER];
+			}
+			in->start = (in->start + n) % GM_BUFFER;
+			in->size -= n;
+			pthread_mutex_unlock(&in->lock);
+			return n;
+		}
+		int closed = in->closed;
+		pthread_mutex_unlock(&in->lock);
+		if (closed) {
+			return -1;
+		}
+		dispatch_semaphore_wait(in->available, DISPATCH_TIME_FOREVER);
+	}
+}
+
+static void gmClose(gmInput *in) {
+	MIDIPortDisconnectSource(in->port, in->source);
+	MIDIClientDispose(in->client);
+	pthread_mutex_lock(&in->lock);
+	in->closed = 1;
+	pthread_mutex_unlock(&in->lock);
+	dispatch_semaphore_signal(in->available);
+}
+
+static void gmFree(gmInput *in) {
+	dispatch_release(in->available);
+	pthread_mutex_destroy(&in->lock);
+	free(in);
+}
+
+#define GM_PACKET 1024
+
+typedef struct {
+	MIDIClientRef client;
+	MIDIPortRef port;
+	MIDIEndpointRef destination;
+} gmOutput;
+
+static OSStatus gmOpenOutput(gmOutput *out, int index) {
+	if (index < 0 || index >= (int)MIDIGetNumberOfDestinations()) {
+		return kMIDIInvalidPort;
+	}
+	out->destination = MIDIGetDestination(index);
+	OSStatus err = MIDIClientCreate(CFSTR("GoAudio"), NULL, NULL, &out->client);
+	if (err == 0) {
+		err = MIDIOutputPortCreate(out->client, CFSTR("output"), &out->port);
+	}
+	if (err && out->client) {
+		MIDIClientDispose(out->client);
+	}
+	return err;
+}
+
+// gmSend sends a message right away
+static OSStatus gmSend(gmOutput *out, const unsigned char *data, int size) {
+	Byte buf[sizeof(MIDIPacketList) + GM_PACKET];
+	MIDIPacketList *list = (MIDIPacketList *)buf;
+	MIDIPacket *p = MIDIPacketListInit(list);
+	p = MIDIPacketListAdd(list, sizeof(buf), p, 0, size, data);
+	if (p == NULL) {
+		return kMIDIMessageSendErr;
+	}
+	return MIDISend(out->port, out->destination, list);
+}
+
+static void gmCloseOutput(gmOutput *out) {
+	MIDIClientDispose(out->client);
+}
+
+// gmEndpointName copies the display name of a source or destination into buf
+static void gmEndpointName(MIDIEndpointRef endpoint, char *buf, int size) {
+	buf[0] = 0;
+	CFStringRef name = NULL;
+	MIDIObjectGetStringProperty(endpoint, kMIDIPropertyDisplayName, &name);
+	if (name) {
+		CFStringGetCString(name, buf, size, kCFStringEncodingUTF8);
+		CFRelease(name);
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+func inputs() ([]Port, error) {
+	return endpoints(int(C.MIDIGetNumberOfSources()), func(i int) C.MIDIEndpointRef {
+		return C.MIDIGetSource(C.ItemCount(i))
+	}), nil
+}
+
+func outputs() ([]Port, error) {
+	return endpoints(int(C.MIDIGetNumberOfDestinations()), func(i int) C.MIDIEndpointRef {
+		return C.MIDIGetDestination(C.ItemCount(i))
+	}), nil
+}
+
+// endpoints returns the sources or destinations as ports named by their index
+func endpoints(n int, endpoint func(int) C.MIDIEndpointRef) []Port {
+	ports := []Port{}
+	buf := (*C.char)(C.malloc(256))
+	defer C.free(unsafe.Pointer(buf))
+	for i := 0; i < n; i++ {
+		C.gmEndpointName(endpoint(i), buf, 256)
+		ports = append(ports, Port{Name: strconv.Itoa(i), Description: C.GoString(buf)})
+	}
+	return ports
+}
+
+// openInput opens a source by its index
+func openInput(name string) (Input, error) {
+	index, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, fmt.Errorf("MIDI input %v should be the index of a source", name)
+	}
+	// the input is referenced from the thread of CoreMIDI, so it lives in C memory
+	in := (*C.gmInput)(C.calloc(1, C.sizeof_gmInput))
+	if status := C.gmOpen(in, C.int(index)); status != 0 {
+		C.free(unsafe.Pointer(in))
+		return nil, fmt.Errorf("CoreMIDI: OSStatus %v", status)
+	}
+	return NewRawInput(&coreMIDIReader{in: in}), nil
+}
+
+// coreMIDIReader reads the bytes received by a CoreMIDI input port
+type coreMIDIReader struct {
+	in      *C.gmInput
+	mu      sync.Mutex
+	readers sync.WaitGroup
+	closed  bool
+}
+
+func (r *coreMIDIReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return 0, io.EOF
+	}
+	r.readers.Add(1)
+	r.mu.Unlock()
+	defer r.readers.Done()
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := C.gmReadBytes(r.in, (*C.uchar)(unsafe.Pointer(&p[0])), C.int(len(p)))
+	if n < 0 {
+		return 0, io.EOF
+	}
+	return int(n), nil
+}
+
+// Close disposes of the client and frees the input once a blocked Read has returned
+func (r *coreMIDIReader) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+	C.gmClose(r.in)
+	r.readers.Wait()
+	C.gmFree(r.in)
+	return nil
+}
+
+// openOutput opens a destination by its index
+func openOutput(name string) (Output, error) {
+	index, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, fmt.Errorf("MIDI output %v should be the index of a destination", name)
+	}
+	out := &coreMIDIOutput{}
+	if status := C.gmOpenOutput(&out.out, C.int(index)); status != 0 {
+		return nil, fmt.Errorf("CoreMIDI: OSStatus %v", status)
+	}
+	return out, nil
+}
+
+// coreMIDIOutput sends events to a destination
+type coreMIDIOutput struct {
+	out C.gmOutput
+}
+
+func (o *coreMIDIOutput) Write(e Event) error {
+	m, err := e.Message()
+	if err != nil {
+		return err
+	}
+	if len(m) > C.GM_PACKET {
+		return errors.New("CoreMIDI: message is too long")
+	}
+	if status := C.gmSend(&o.out, (*C.uchar)(unsafe.Pointer(&m[0])), C.int(len(m))); status != 0 {
+		return fmt.Errorf("CoreMIDI: OSStatus %v", status)
+	}
+	return nil
+}
+
+func (o *coreMIDIOutput) Close() error {
+	C.gmCloseOutput(&o.out)
+	return nil
+}
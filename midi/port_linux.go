@@ -0,0 +1,132 @@
+package midi
+
+// MIDI input and output through the raw MIDI devices of the ALSA kernel driver and through the
+// ALSA sequencer, so it works without cgo or alsa-lib like the ALSA playback backend. Hardware
+// ports are raw MIDI devices named hw:card,device. The ports of the sequencer, which include
+// those of other programs, are named client:port like aconnect lists them.
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func inputs() ([]Port, error) {
+	return ports("Input", true)
+}
+
+func outputs() ([]Port, error) {
+	return ports("Output", false)
+}
+
+// ports returns the raw MIDI devices followed by the ports of the sequencer
+func ports(direction string, input bool) ([]Port, error) {
+	raw, err := rawMIDIPorts(direction)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := seqPorts(input)
+	if err != nil {
+		return nil, err
+	}
+	return append(raw, seq...), nil
+}
+
+// rawMIDIPorts returns the devices with an Input or Output
+func rawMIDIPorts(direction string) ([]Port, error) {
+	paths, err := filepath.Glob("/dev/snd/midiC*D*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	ports := []Port{}
+	for _, path := range paths {
+		var card, dev int
+		if n, _ := fmt.Sscanf(filepath.Base(path), "midiC%dD%d", &card, &dev); n != 2 {
+			continue
+		}
+		p := Port{Name: fmt.Sprintf("hw:%d,%d", card, dev)}
+		name, directions := rawMIDIInfo(card, dev)
+		if directions != nil && !directions[direction] {
+			continue
+		}
+		p.Description = name
+		if p.Description == "" {
+			p.Description = p.Name
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// rawMIDIInfo reads /proc/asound/cardN/midiN, of which the first line is the name of the
+// device, followed by a section for every Input and Output
+func rawMIDIInfo(card, dev int) (string, map[string]bool) {
+	f, err := os.Open(fmt.Sprintf("/proc/asound/card%d/midi%d", card, dev))
+	if err != nil {
+		return "", nil
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	if !s.Scan() {
+		return "", nil
+	}
+	name := strings.TrimSpace(s.Text())
+	directions := map[string]bool{}
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) == 2 {
+			directions[fields[0]] = true
+		}
+	}
+	return name, directions
+}
+
+// rawMIDIDevice returns the path of a device named hw:card,device
+func rawMIDIDevice(name string) (string, error) {
+	var card, dev int
+	if n, _ := fmt.Sscanf(name, "hw:%d,%d", &card, &dev); n != 2 {
+		return "", fmt.Errorf("MIDI port %v should be named hw:card,device or client:port", name)
+	}
+	return fmt.Sprintf("/dev/snd/midiC%dD%d", card, dev), nil
+}
+
+// seqAddress parses the name of a port of the sequencer, client:port
+func seqAddress(name string) (client, port int, ok bool) {
+	if n, _ := fmt.Sscanf(name, "%d:%d", &client, &port); n != 2 {
+		return 0, 0, false
+	}
+	return client, port, client >= 0 && client < 256 && port >= 0 && port < 256
+}
+
+func openInput(name string) (Input, error) {
+	if client, port, ok := seqAddress(name); ok {
+		return openSeqInput(client, port)
+	}
+	path, err := rawMIDIDevice(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRawInput(f), nil
+}
+
+func openOutput(name string) (Output, error) {
+	if client, port, ok := seqAddress(name); ok {
+		return openSeqOutput(client, port)
+	}
+	path, err := rawMIDIDevice(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewRawOutput(f), nil
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !windows && !(darwin && cgo)
+// +build !linux
+// +build !windows
+// +build !darwin !cgo
+
+package midi
+
+import (
+	"errors"
+)
+
+func inputs() ([]Port, error) {
+	return nil, nil
+}
+
+func openInput(name string) (Input, error) {
+	return nil, errors.New("MIDI input is not supported on this platform")
+}
+
+func outputs() ([]Port, error) {
+	return nil, nil
+}
+
+func openOutput(name string) (Output, error) {
+	return nil, errors.New("MIDI output is not supported on this platform")
+}
@@ -0,0 +1,234 @@
+package midi
+
+// MIDI input and output through WinMM, calling winmm.dll directly so it works without cgo.
+// Only short messages are received and sent, system exclusive messages are not.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	winmm            = syscall.NewLazyDLL("winmm.dll")
+	midiInGetNumDevs = winmm.NewProc("midiInGetNumDevs")
+	midiInGetDevCaps = winmm.NewProc("midiInGetDevCapsW")
+	midiInOpen       = winmm.NewProc("midiInOpen")
+	midiInStart      = winmm.NewProc("midiInStart")
+	midiInStop       = winmm.NewProc("midiInStop")
+	midiInReset      = winmm.NewProc("midiInReset")
+	midiInClose      = winmm.NewProc("midiInClose")
+
+	midiOutGetNumDevs = winmm.NewProc("midiOutGetNumDevs")
+	midiOutGetDevCaps = winmm.NewProc("midiOutGetDevCapsW")
+	midiOutOpen       = winmm.NewProc("midiOutOpen")
+	midiOutShortMsg   = winmm.NewProc("midiOutShortMsg")
+	midiOutReset      = winmm.NewProc("midiOutReset")
+	midiOutClose      = winmm.NewProc("midiOutClose")
+
+	// a single callback serves every input, as callbacks can't be freed
+	winmmCallback     uintptr
+	winmmCallbackOnce sync.Once
+	winmmInputs       = map[uintptr]*winmmReader{}
+	winmmInputsMu     sync.Mutex
+	winmmNextInput    uintptr
+)
+
+// constants of the Windows SDK
+const (
+	callbackFunction = 0x30000
+	mimData          = 0x3C3
+	maxPNameLen      = 32
+)
+
+// midiOutCaps is MIDIOUTCAPSW
+type midiOutCaps struct {
+	Mid           uint16
+	Pid           uint16
+	DriverVersion uint32
+	Pname         [maxPNameLen]uint16
+	Technology    uint16
+	Voices        uint16
+	Notes         uint16
+	ChannelMask   uint16
+	Support       uint32
+}
+
+// midiInCaps is MIDIINCAPSW
+type midiInCaps struct {
+	Mid           uint16
+	Pid           uint16
+	DriverVersion uint32
+	Pname         [maxPNameLen]uint16
+	Support       uint32
+}
+
+func inputs() ([]Port, error) {
+	n, _, _ := midiInGetNumDevs.Call()
+	ports := []Port{}
+	for i := uintptr(0); i < n; i++ {
+		caps := midiInCaps{}
+		if r, _, _ := midiInGetDevCaps.Call(i, uintptr(unsafe.Pointer(&caps)), unsafe.Sizeof(caps)); r != 0 {
+			return nil, fmt.Errorf("WinMM: midiInGetDevCaps error %v", r)
+		}
+		ports = append(ports, Port{Name: strconv.Itoa(int(i)), Description: syscall.UTF16ToString(caps.Pname[:])})
+	}
+	return ports, nil
+}
+
+// winmmReader receives the short messages of an input as bytes
+type winmmReader struct {
+	handle   uintptr
+	id       uintptr
+	messages chan []byte
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// messageSize returns the length of a short message by its status byte
+func messageSize(status byte) int {
+	switch {
+	case status >= 0xF8, status == 0xF6:
+		return 1
+	case status == 0xF1, status == 0xF3, status&0xF0 == 0xC0, status&0xF0 == 0xD0:
+		return 2
+	}
+	return 3
+}
+
+func winmmReceive(handle, msg, instance, param1, param2 uintptr) uintptr {
+	if msg != mimData {
+		return 0
+	}
+	winmmInputsMu.Lock()
+	r := winmmInputs[instance]
+	winmmInputsMu.Unlock()
+	if r == nil {
+		return 0
+	}
+	b := []byte{byte(param1), byte(param1 >> 8), byte(param1 >> 16)}
+	select {
+	case r.messages <- b[:messageSize(b[0])]:
+	default:
+		// the reader has fallen behind, the callback must not block
+	}
+	return 0
+}
+
+// openInput opens a device by its index
+func openInput(name string) (Input, error) {
+	index, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, fmt.Errorf("MIDI input %v should be the index of a device", name)
+	}
+	winmmCallbackOnce.Do(func() {
+		winmmCallback = syscall.NewCallback(winmmReceive)
+	})
+	r := &winmmReader{messages: make(chan []byte, 1024), closed: make(chan struct{})}
+	winmmInputsMu.Lock()
+	winmmNextInput++
+	r.id = winmmNextInput
+	winmmInputs[r.id] = r
+	winmmInputsMu.Unlock()
+
+	if res, _, _ := midiInOpen.Call(uintptr(unsafe.Pointer(&r.handle)), uintptr(index), winmmCallback, r.id, callbackFunction); res != 0 {
+		r.forget()
+		return nil, fmt.Errorf("WinMM: midiInOpen error %v", res)
+	}
+	if res, _, _ := midiInStart.Call(r.handle); res != 0 {
+		midiInClose.Call(r.handle)
+		r.forget()
+		return nil, fmt.Errorf("WinMM: midiInStart error %v", res)
+	}
+	return NewRawInput(r), nil
+}
+
+func (r *winmmReader) forget() {
+	winmmInputsMu.Lock()
+	delete(winmmInputs, r.id)
+	winmmInputsMu.Unlock()
+}
+
+func (r *winmmReader) Read(p []byte) (int, error) {
+	select {
+	case m := <-r.messages:
+		if len(p) < len(m) {
+			return 0, errors.New("Read buffer is too small for a MIDI message")
+		}
+		return copy(p, m), nil
+	case <-r.closed:
+		return 0, io.EOF
+	}
+}
+
+func (r *winmmReader) Close() error {
+	r.once.Do(func() {
+		midiInStop.Call(r.handle)
+		midiInReset.Call(r.handle)
+		midiInClose.Call(r.handle)
+		r.forget()
+		close(r.closed)
+	})
+	return nil
+}
+
+func outputs() ([]Port, error) {
+	n, _, _ := midiOutGetNumDevs.Call()
+	ports := []Port{}
+	for i := uintptr(0); i < n; i++ {
+		caps := midiOutCaps{}
+		if r, _, _ := midiOutGetDevCaps.Call(i, uintptr(unsafe.Pointer(&caps)), unsafe.Sizeof(caps)); r != 0 {
+			return nil, fmt.Errorf("WinMM: midiOutGetDevCaps error %v", r)
+		}
+		ports = append(ports, Port{Name: strconv.Itoa(int(i)), Description: syscall.UTF16ToString(caps.Pname[:])})
+	}
+	return ports, nil
+}
+
+// winmmOutput sends short messages to a device
+type winmmOutput struct {
+	handle uintptr
+}
+
+// openOutput opens a device by its index
+func openOutput(name string) (Output, error) {
+	index, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, fmt.Errorf("MIDI output %v should be the index of a device", name)
+	}
+	out := &winmmOutput{}
+	if res, _, _ := midiOutOpen.Call(uintptr(unsafe.Pointer(&out.handle)), uintptr(index), 0, 0, 0); res != 0 {
+		return nil, fmt.Errorf("WinMM: midiOutOpen error %v", res)
+	}
+	return out, nil
+}
+
+func (out *winmmOutput) Write(e Event) error {
+	m, err := e.Message()
+	if err != nil {
+		return err
+	}
+	if m[0] == 0xF0 {
+		return errors.New("WinMM output does not send system exclusive messages")
+	}
+	msg := uintptr(0)
+	for i, b := range m {
+		msg |= uintptr(b) << (8 * uint(i))
+	}
+	if res, _, _ := midiOutShortMsg.Call(out.handle, msg); res != 0 {
+		return fmt.Errorf("WinMM: midiOutShortMsg error %v", res)
+	}
+	return nil
+}
+
+func (out *winmmOutput) Close() error {
+	midiOutReset.Call(out.handle)
+	if res, _, _ := midiOutClose.Call(out.handle); res != 0 {
+		return fmt.Errorf("WinMM: midiOutClose error %v", res)
+	}
+	return nil
+}
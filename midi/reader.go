@@ -0,0 +1,213 @@
+package midi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// maxChunk is the largest track chunk that is read, to fail early on corrupt lengths
+const maxChunk = 64 << 20
+
+// ReadFile parses a .mid file
+func ReadFile(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(bufio.NewReader(f))
+}
+
+// Parse reads a Standard MIDI File. Chunks other than the header and tracks are skipped.
+func Parse(r io.Reader) (*File, error) {
+	id, body, err := readChunk(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != "MThd" || len(body) < 6 {
+		return nil, errors.New("Not a Standard MIDI File")
+	}
+	f := &File{Format: int(binary.BigEndian.Uint16(body[0:2]))}
+	tracks := int(binary.BigEndian.Uint16(body[2:4]))
+	division := binary.BigEndian.Uint16(body[4:6])
+	if division&0x8000 != 0 {
+		f.FramesPerSecond = int(-int8(division >> 8))
+		f.TicksPerFrame = int(division & 0xFF)
+		if f.FramesPerSecond <= 0 || f.TicksPerFrame == 0 {
+			return nil, errors.New("Invalid SMPTE division")
+		}
+	} else {
+		f.Division = int(division)
+		if f.Division == 0 {
+			return nil, errors.New("Division can't be zero")
+		}
+	}
+
+	for len(f.Tracks) < tracks {
+		id, body, err := readChunk(r)
+		if err == io.EOF {
+			// a file ending early keeps the tracks read so far
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id != "MTrk" {
+			continue
+		}
+		t, err := parseTrack(body)
+		if err != nil {
+			return nil, fmt.Errorf("Track %v: %v", len(f.Tracks), err)
+		}
+		f.Tracks = append(f.Tracks, t)
+	}
+	return f, nil
+}
+
+func readChunk(r io.Reader) (string, []byte, error) {
+	hdr := make([]byte, 8)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return "", nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[4:8])
+	if size > maxChunk {
+		return "", nil, errors.New("Chunk is too large")
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(hdr[0:4]), body, nil
+}
+
+// readVarint reads a variable length quantity, returning the value and its size in bytes
+func readVarint(b []byte) (int, int, error) {
+	v := 0
+	for i := 0; i < 4 && i < len(b); i++ {
+		v = v<<7 | int(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("Invalid variable length quantity")
+}
+
+// parseTrack reads the events of a track chunk
+func parseTrack(b []byte) (Track, error) {
+	t := Track{}
+	tick := int64(0)
+	status := byte(0) // for running status
+	for len(b) > 0 {
+		delta, n, err := readVarint(b)
+		if err != nil {
+			return t, err
+		}
+		b = b[n:]
+		tick += int64(delta)
+		if len(b) == 0 {
+			return t, errors.New("Event missing after its delta time")
+		}
+
+		e := Event{Tick: tick}
+		switch {
+		case b[0] == 0xFF:
+			if len(b) < 2 {
+				return t, errors.New("Truncated meta event")
+			}
+			e.Meta = int(b[1])
+			size, n, err := readVarint(b[2:])
+			if err != nil {
+				return t, err
+			}
+			if len(b) < 2+n+size {
+				return t, errors.New("Truncated meta event")
+			}
+			e.Data = b[2+n : 2+n+size]
+			b = b[2+n+size:]
+			switch {
+			case e.Meta == META_END_OF_TRACK:
+				return t, nil
+			case e.Meta == META_TEMPO && len(e.Data) == 3:
+				e.Type, e.Value = TEMPO, int(e.Data[0])<<16|int(e.Data[1])<<8|int(e.Data[2])
+			case e.Meta == META_TIME_SIGNATURE && len(e.Data) >= 2:
+				e.Type = TIME_SIGNATURE
+			case e.Meta == META_TRACK_NAME && t.Name == "":
+				t.Name = string(e.Data)
+				e.Type = META
+			default:
+				e.Type = META
+			}
+			t.Events = append(t.Events, e)
+			continue
+		case b[0] == 0xF0 || b[0] == 0xF7:
+			size, n, err := readVarint(b[1:])
+			if err != nil {
+				return t, err
+			}
+			if len(b) < 1+n+size {
+				return t, errors.New("Truncated system exclusive event")
+			}
+			e.Type, e.Data = SYSEX, b[1+n:1+n+size]
+			b = b[1+n+size:]
+			status = 0
+			t.Events = append(t.Events, e)
+			continue
+		case b[0]&0x80 != 0:
+			status = b[0]
+			b = b[1:]
+		case status == 0:
+			return t, errors.New("Running status without a previous status")
+		}
+
+		size := 2
+		if kind := status >> 4; kind == 0xC || kind == 0xD {
+			size = 1
+		}
+		if len(b) < size {
+			return t, errors.New("Truncated channel event")
+		}
+		d1, d2 := b[0], byte(0)
+		if size == 2 {
+			d2 = b[1]
+		}
+		b = b[size:]
+		if e, err = channelEvent(status, d1, d2); err != nil {
+			return t, err
+		}
+		e.Tick = tick
+		t.Events = append(t.Events, e)
+	}
+	return t, nil
+}
+
+// channelEvent decodes a channel message, a note on with velocity 0 is a NOTE_OFF
+func channelEvent(status, d1, d2 byte) (Event, error) {
+	e := Event{Channel: int(status & 0x0F)}
+	switch status >> 4 {
+	case 0x8:
+		e.Type, e.Note, e.Velocity = NOTE_OFF, int(d1), int(d2)
+	case 0x9:
+		e.Type, e.Note, e.Velocity = NOTE_ON, int(d1), int(d2)
+		if d2 == 0 {
+			e.Type = NOTE_OFF
+		}
+	case 0xA:
+		e.Type, e.Note, e.Velocity = KEY_PRESSURE, int(d1), int(d2)
+	case 0xB:
+		e.Type, e.Controller, e.Value = CONTROL_CHANGE, int(d1), int(d2)
+	case 0xC:
+		e.Type, e.Value = PROGRAM_CHANGE, int(d1)
+	case 0xD:
+		e.Type, e.Value = CHANNEL_PRESSURE, int(d1)
+	case 0xE:
+		e.Type, e.Value = PITCH_BEND, (int(d2)<<7|int(d1))-8192
+	default:
+		return e, fmt.Errorf("Unexpected status byte %#x", status)
+	}
+	return e, nil
+}
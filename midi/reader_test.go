@@ -0,0 +1,102 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// smf builds a file from the bodies of its track chunks
+func smf(format, division int, tracks ...[]byte) []byte {
+	b := []byte("MThd")
+	b = binary.BigEndian.AppendUint32(b, 6)
+	b = binary.BigEndian.AppendUint16(b, uint16(format))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(tracks)))
+	b = binary.BigEndian.AppendUint16(b, uint16(division))
+	for _, t := range tracks {
+		b = append(b, "MTrk"...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(t)))
+		b = append(b, t...)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	conductor := []byte{
+		0x00, 0xFF, 0x03, 0x04, 'S', 'o', 'n', 'g',
+		0x00, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20, // 500000us per quarter
+		0x00, 0xFF, 0x58, 0x04, 0x03, 0x02, 0x18, 0x08, // 3/4
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	notes := []byte{
+		0x00, 0x90, 0x3C, 0x64, // note on C4
+		0x81, 0x70, 0x3E, 0x50, // running status, D4 after 240 ticks
+		0x60, 0x3C, 0x00, // note on with velocity 0 after 96 ticks
+		0x00, 0xB1, 0x40, 0x7F, // sustain on channel 2
+		0x00, 0xE0, 0x00, 0x40, // pitch bend center
+		0x00, 0xC0, 0x05, // program change
+		0x00, 0xF0, 0x02, 0x7E, 0xF7, // sysex
+		0x83, 0x60, 0x80, 0x3E, 0x00, // note off after 480 ticks
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	data := smf(1, 480, conductor, notes)
+	// unknown chunks are skipped
+	data = append(data[:14], append([]byte{'X', 'Y', 'Z', 'W', 0, 0, 0, 1, 0}, data[14:]...)...)
+
+	f, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Format != 1 || f.Division != 480 || len(f.Tracks) != 2 || f.Tracks[0].Name != "Song" {
+		t.Fatalf("Unexpected file %+v", f)
+	}
+	tempo := f.Tracks[0].Events[1]
+	if tempo.Type != TEMPO || tempo.Value != 500000 {
+		t.Fatalf("Expected a tempo of 500000us, got %+v", tempo)
+	}
+	if num, den := f.Tracks[0].Events[2].TimeSignature(); num != 3 || den != 4 {
+		t.Fatalf("Expected 3/4, got %v/%v", num, den)
+	}
+
+	tests := []Event{
+		{Tick: 0, Type: NOTE_ON, Note: 60, Velocity: 100},
+		{Tick: 240, Type: NOTE_ON, Note: 62, Velocity: 80},
+		{Tick: 336, Type: NOTE_OFF, Note: 60},
+		{Tick: 336, Type: CONTROL_CHANGE, Channel: 1, Controller: 64, Value: 127},
+		{Tick: 336, Type: PITCH_BEND, Value: 0},
+		{Tick: 336, Type: PROGRAM_CHANGE, Value: 5},
+		{Tick: 336, Type: SYSEX, Data: []byte{0x7E, 0xF7}},
+		{Tick: 816, Type: NOTE_OFF, Note: 62},
+	}
+	events := f.Tracks[1].Events
+	if len(events) != len(tests) {
+		t.Fatalf("Expected %v events, got %v", len(tests), len(events))
+	}
+	for i, want := range tests {
+		t.Run("", func(t *testing.T) {
+			got := events[i]
+			if got.Tick != want.Tick || got.Type != want.Type || got.Channel != want.Channel || got.Note != want.Note ||
+				got.Velocity != want.Velocity || got.Controller != want.Controller || got.Value != want.Value ||
+				!bytes.Equal(got.Data, want.Data) {
+				t.Fatalf("Expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := [][]byte{
+		[]byte("RIFF0000WAVE"),
+		smf(0, 0),
+		smf(0, 96, []byte{0x00, 0x3C, 0x64}),       // running status without status
+		smf(0, 96, []byte{0x00, 0x90, 0x3C}),       // truncated note
+		smf(0, 96, []byte{0xFF, 0xFF, 0xFF, 0xFF}), // invalid delta
+	}
+	for _, data := range tests {
+		t.Run("", func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(data)); err == nil {
+				t.Fatal("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package midi
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Controllers with a meaning during rendering
+const (
+	CC_DATA_ENTRY    = 6
+	CC_SUSTAIN       = 64
+	CC_TIMBRE        = 74 // the slide of MPE controllers
+	CC_RPN_LSB       = 100
+	CC_RPN_MSB       = 101
+	CC_ALL_SOUND_OFF = 120
+	CC_ALL_NOTES_OFF = 123
+)
+
+// renderTail is the longest time in seconds rendering goes on after the last event
+const renderTail = 3.0
+
+// InstrumentMap creates the instrument playing a program on a channel. Render and Live ask
+// for an instrument whenever a channel plays a program for the first time, starting at
+// program 0. The same instrument may be returned for several channels or programs. The member
+// channels of an MPE zone play the instrument of its manager channel.
+type InstrumentMap func(channel, program int) (synth.Instrument, error)
+
+// activeInstrument is implemented by instruments which know when they have gone silent
+type activeInstrument interface {
+	Active() bool
+}
+
+// renderChannel is the state of a MIDI channel during rendering
+type renderChannel struct {
+	number      int
+	program     int
+	instruments map[int]synth.Instrument // by program
+	held        map[int]bool             // keys which are down
+	sustained   map[int]bool             // keys released while the pedal is down
+	pedal       bool
+
+	rpn       int     // registered parameter of data entry
+	bendRange float64 // in semitones
+	bend      float64 // in the range [-1;1]
+	pressure  float64
+	timbre    float64
+
+	// MPE, the manager of a member channel plays its notes, members are set on the manager
+	manager *renderChannel
+	members []*renderChannel
+}
+
+// player sends channel events to the instruments of a map and mixes them
+type player struct {
+	instruments InstrumentMap
+	channels    []*renderChannel
+	playing     []synth.Instrument
+}
+
+func newPlayer(instruments InstrumentMap) *player {
+	p := &player{instruments: instruments, channels: make([]*renderChannel, 16)}
+	for i := range p.channels {
+		p.channels[i] = &renderChannel{
+			number:      i,
+			instruments: map[int]synth.Instrument{},
+			held:        map[int]bool{},
+			sustained:   map[int]bool{},
+			rpn:         rpnNull,
+			bendRange:   2,
+			timbre:      64. / 127,
+		}
+	}
+	return p
+}
+
+// instrument returns the instrument of the current program of a channel, or of the manager
+// of an MPE member channel
+func (p *player) instrument(c *renderChannel) (synth.Instrument, error) {
+	if c.manager != nil {
+		c = c.manager
+	}
+	if inst, ok := c.instruments[c.program]; ok {
+		return inst, nil
+	}
+	inst, err := p.instruments(c.number, c.program)
+	if err != nil {
+		return nil, err
+	}
+	if inst == nil {
+		return nil, errors.New("Instrument map returned no instrument")
+	}
+	c.instruments[c.program] = inst
+	for _, playing := range p.playing {
+		if playing == inst {
+			// the map may share an instrument between channels, it is mixed once
+			return inst, nil
+		}
+	}
+	p.playing = append(p.playing, inst)
+	return inst, nil
+}
+
+func (p *player) noteOff(c *renderChannel, note int) {
+	if c.manager != nil {
+		c = c.manager
+	}
+	for _, inst := range c.instruments {
+		inst.NoteOff(note)
+	}
+}
+
+// handle plays an event, events which aren't channel events are ignored
+func (p *player) handle(e Event) error {
+	if e.Type > PITCH_BEND || e.Channel < 0 || e.Channel >= len(p.channels) {
+		return nil
+	}
+	c := p.channels[e.Channel]
+	switch e.Type {
+	case NOTE_ON:
+		inst, err := p.instrument(c)
+		if err != nil {
+			return err
+		}
+		delete(c.sustained, e.Note)
+		c.held[e.Note] = true
+		inst.NoteOn(e.Note, float64(e.Velocity)/127)
+		if c.manager != nil {
+			p.express(c, e.Note, synth.BEND, synth.PRESSURE, synth.TIMBRE)
+		}
+	case NOTE_OFF:
+		delete(c.held, e.Note)
+		if c.pedal || c.manager != nil && c.manager.pedal {
+			c.sustained[e.Note] = true
+		} else {
+			p.noteOff(c, e.Note)
+		}
+	case PROGRAM_CHANGE:
+		c.program = e.Value
+	case PITCH_BEND:
+		c.bend = float64(e.Value) / 8192
+		p.expressZone(c, synth.BEND)
+	case CHANNEL_PRESSURE:
+		c.pressure = float64(e.Value) / 127
+		p.expressZone(c, synth.PRESSURE)
+	case CONTROL_CHANGE:
+		switch e.Controller {
+		case CC_SUSTAIN:
+			c.pedal = e.Value >= 64
+			p.releaseSustained(c)
+			for _, m := range c.members {
+				p.releaseSustained(m)
+			}
+		case CC_TIMBRE:
+			c.timbre = float64(e.Value) / 127
+			p.expressZone(c, synth.TIMBRE)
+		case CC_RPN_MSB:
+			c.rpn = c.rpn&0x7F | e.Value<<7
+		case CC_RPN_LSB:
+			c.rpn = c.rpn&^0x7F | e.Value
+		case CC_DATA_ENTRY:
+			p.dataEntry(c, e.Value)
+		case CC_ALL_NOTES_OFF, CC_ALL_SOUND_OFF:
+			p.release(c)
+			for _, m := range c.members {
+				p.release(m)
+			}
+		}
+	}
+	return nil
+}
+
+// releaseSustained lets go of the sustained notes of a channel once its pedal and that of
+// its manager are up
+func (p *player) releaseSustained(c *renderChannel) {
+	if c.pedal || c.manager != nil && c.manager.pedal {
+		return
+	}
+	for note := range c.sustained {
+		p.noteOff(c, note)
+	}
+	c.sustained = map[int]bool{}
+}
+
+// release lets go of the held and sustained notes of a channel
+func (p *player) release(c *renderChannel) {
+	for note := range c.held {
+		p.noteOff(c, note)
+	}
+	for note := range c.sustained {
+		p.noteOff(c, note)
+	}
+	c.held, c.sustained = map[int]bool{}, map[int]bool{}
+}
+
+// tick returns the next sample of the mix of the instruments
+func (p *player) tick() float64 {
+	v := 0.0
+	for _, inst := range p.playing {
+		v += inst.Tick()
+	}
+	return v
+}
+
+// Render plays a file on the instruments of the map, with tempo changes, velocities and the
+// sustain pedal, and returns the frames at the sample rate and channel count of the format.
+// The instruments are mono, their mix is copied to every channel. Rendering goes on after
+// the last event until the instruments are silent, for at most 3 seconds.
+func Render(f *File, instruments InstrumentMap, wfmt wave.WaveFmt) ([]wave.Frame, error) {
+	return RenderCtx(context.Background(), f, instruments, wfmt)
+}
+
+// renderBlock is the amount of samples per channel RenderCtx mixes between checks of the
+// context
+const renderBlock = 4096
+
+// RenderCtx is Render which stops with the error of the context once it is done
+func RenderCtx(ctx context.Context, f *File, instruments InstrumentMap, wfmt wave.WaveFmt) ([]wave.Frame, error) {
+	return RenderWithProgress(ctx, f, instruments, wfmt, nil)
+}
+
+// RenderWithProgress is RenderCtx which reports the frames rendered when progress isn't nil.
+// The total counts the whole tail after the last event, which stops early once the
+// instruments are silent, so the last report has the frames rendered as the total.
+func RenderWithProgress(ctx context.Context, f *File, instruments InstrumentMap, wfmt wave.WaveFmt, progress wave.Progress) ([]wave.Frame, error) {
+	if instruments == nil {
+		return nil, errors.New("Need an instrument map")
+	}
+	if wfmt.NumChannels < 1 || wfmt.SampleRate < 1 {
+		return nil, errors.New("Format needs channels and a sample rate")
+	}
+	clock, err := f.Clock()
+	if err != nil {
+		return nil, err
+	}
+	sr := wfmt.SampleRate
+
+	events := []Event{}
+	for _, t := range f.Tracks {
+		for _, e := range t.Events {
+			if e.Type <= PITCH_BEND {
+				events = append(events, e)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Tick < events[j].Tick })
+	total := int64(renderTail*float64(sr)) * int64(wfmt.NumChannels)
+	if len(events) > 0 {
+		total += int64(clock.Sample(events[len(events)-1].Tick, sr)) * int64(wfmt.NumChannels)
+	}
+
+	p := newPlayer(instruments)
+	frames := []wave.Frame{}
+	mix := func(until int) error {
+		for len(frames)/wfmt.NumChannels < until {
+			if len(frames)/wfmt.NumChannels%renderBlock == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if progress != nil && len(frames) > 0 {
+					progress(int64(len(frames)), total)
+				}
+			}
+			v := p.tick()
+			for c := 0; c < wfmt.NumChannels; c++ {
+				frames = append(frames, wave.Frame(v))
+			}
+		}
+		return nil
+	}
+
+	for _, e := range events {
+		if err := mix(clock.Sample(e.Tick, sr)); err != nil {
+			return nil, err
+		}
+		if err := p.handle(e); err != nil {
+			return nil, err
+		}
+	}
+
+	// release the notes still sounding and let them ring out
+	for _, c := range p.channels {
+		p.release(c)
+	}
+	end := len(frames)/wfmt.NumChannels + int(renderTail*float64(sr))
+	for len(frames)/wfmt.NumChannels < end && !silent(p.playing) {
+		if err := mix(len(frames)/wfmt.NumChannels + 1); err != nil {
+			return nil, err
+		}
+	}
+	if progress != nil {
+		progress(int64(len(frames)), int64(len(frames)))
+	}
+	return frames, nil
+}
+
+// silent returns true when every instrument reports that it has stopped playing
+func silent(instruments []synth.Instrument) bool {
+	for _, inst := range instruments {
+		switch i := inst.(type) {
+		case *synth.Polyphony:
+			if i.ActiveVoices() > 0 {
+				return false
+			}
+		case activeInstrument:
+			if i.Active() {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package midi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// logInstrument records at which tick its notes start and stop, and outputs the held notes
+type logInstrument struct {
+	ticks  int
+	notes  map[int]bool
+	on     []int
+	off    []int
+	vel    []float64
+	silent bool
+}
+
+func (l *logInstrument) NoteOn(note int, velocity float64) {
+	l.notes[note] = true
+	l.on = append(l.on, l.ticks)
+	l.vel = append(l.vel, velocity)
+}
+
+func (l *logInstrument) NoteOff(note int) {
+	if l.notes[note] {
+		delete(l.notes, note)
+		l.off = append(l.off, l.ticks)
+	}
+}
+
+func (l *logInstrument) Tick() float64 {
+	l.ticks++
+	return float64(len(l.notes))
+}
+
+func (l *logInstrument) Active() bool {
+	return len(l.notes) > 0
+}
+
+func TestRender(t *testing.T) {
+	conductor := []byte{
+		0x00, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40, // 60 BPM
+		0x60, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20, // 120 BPM from beat 1
+	}
+	track := []byte{
+		0x00, 0x90, 0x3C, 0x7F, // C4 at 0s
+		0x30, 0xB0, 0x40, 0x7F, // sustain on at .5s
+		0x30, 0x80, 0x3C, 0x00, // released at 1s, sustained
+		0x00, 0xC1, 0x05, // channel 2 to program 5
+		0x00, 0x91, 0x40, 0x40, // E4 on channel 2 at 1s
+		0x60, 0xB0, 0x40, 0x00, // pedal up at 1.5s
+		0x00, 0x81, 0x40, 0x00,
+	}
+	f, err := Parse(bytes.NewReader(smf(1, 96, conductor, track)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := map[[2]int]*logInstrument{}
+	instruments := func(channel, program int) (synth.Instrument, error) {
+		l := &logInstrument{notes: map[int]bool{}}
+		created[[2]int{channel, program}] = l
+		return l, nil
+	}
+	frames, err := Render(f, instruments, wave.NewWaveFmt(1, 2, 100, 16, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 || created[[2]int{0, 0}] == nil || created[[2]int{1, 5}] == nil {
+		t.Fatalf("Expected program 0 on channel 1 and program 5 on channel 2, got %v", created)
+	}
+	piano, other := created[[2]int{0, 0}], created[[2]int{1, 5}]
+	if len(piano.on) != 1 || piano.on[0] != 0 || len(piano.off) != 1 || piano.off[0] != 150 || piano.vel[0] != 1 {
+		t.Fatalf("Expected C4 held by the pedal from 0 to 1.5s, got %v-%v", piano.on, piano.off)
+	}
+	// the instrument of channel 2 is created at 1s, when it plays its first note
+	if len(other.on) != 1 || other.on[0] != 0 || other.off[0] != 50 {
+		t.Fatalf("Expected E4 from 1 to 1.5s, got %v-%v", other.on, other.off)
+	}
+	// both channels hold the mono mix, which stops once every instrument is silent
+	if len(frames) != 300 || frames[0] != 1 || frames[1] != 1 || frames[2*120] != 2 {
+		t.Fatalf("Expected 150 frames of the mix on both channels, got %v", len(frames))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RenderCtx(ctx, f, instruments, wave.NewWaveFmt(1, 2, 100, 16, nil)); err != context.Canceled {
+		t.Fatalf("Expected rendering to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,436 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64
+
+package midi
+
+// MIDI input and output through the ALSA sequencer, talking to /dev/snd/seq directly so it
+// works without cgo or alsa-lib. A port of our own is subscribed to the port which is opened,
+// and the events of the sequencer are converted to and from MIDI bytes.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// constants of <sound/asequencer.h>
+const (
+	seqEventSize = 28
+
+	seqEventNoteOn       = 6
+	seqEventNoteOff      = 7
+	seqEventKeyPress     = 8
+	seqEventController   = 10
+	seqEventPgmChange    = 11
+	seqEventChanPress    = 12
+	seqEventPitchBend    = 13
+	seqEventControl14    = 14
+	seqEventNonRegParam  = 15
+	seqEventRegParam     = 16
+	seqEventSongPos      = 20
+	seqEventSongSel      = 21
+	seqEventQFrame       = 22
+	seqEventStart        = 30
+	seqEventContinue     = 31
+	seqEventStop         = 32
+	seqEventClock        = 36
+	seqEventTuneRequest  = 40
+	seqEventReset        = 41
+	seqEventSensing      = 42
+	seqEventSysex        = 130
+	seqEventLengthMask   = 3 << 2
+	seqEventLengthVarLen = 1 << 2
+	seqExtMask           = 0xC0000000
+
+	seqCapRead      = 1 << 0
+	seqCapWrite     = 1 << 1
+	seqCapSubsRead  = 1 << 5
+	seqCapSubsWrite = 1 << 6
+	seqCapNoExport  = 1 << 7
+
+	seqPortTypeMIDIGeneric = 1 << 1
+	seqPortTypeApplication = 1 << 20
+
+	seqClientSystem       = 0
+	seqQueueDirect        = 253
+	seqAddressUnknown     = 253
+	seqAddressSubscribers = 254
+)
+
+// the size of struct snd_seq_port_info depends on the size of a pointer
+const seqWordSize = int(unsafe.Sizeof(uintptr(0)))
+
+var (
+	seqClientInfoSize = 188
+	seqPortInfoSize   = 96 + seqWordSize + 64
+	seqSubscribeSize  = 80
+
+	ioctlSeqClientID        = seqIoctl(2, 0x01, 4)
+	ioctlSeqGetClientInfo   = seqIoctl(3, 0x10, seqClientInfoSize)
+	ioctlSeqSetClientInfo   = seqIoctl(1, 0x11, seqClientInfoSize)
+	ioctlSeqCreatePort      = seqIoctl(3, 0x20, seqPortInfoSize)
+	ioctlSeqSubscribePort   = seqIoctl(1, 0x30, seqSubscribeSize)
+	ioctlSeqQueryNextClient = seqIoctl(3, 0x51, seqClientInfoSize)
+	ioctlSeqQueryNextPort   = seqIoctl(3, 0x52, seqPortInfoSize)
+)
+
+// seqIoctl encodes an ioctl request of the sequencer, dir 1 writes and 2 reads
+func seqIoctl(dir, nr uintptr, size int) uintptr {
+	return dir<<30 | uintptr(size)<<16 | 'S'<<8 | nr
+}
+
+// seqSystem maps the sequencer events without data to their status byte
+var seqSystem = map[byte]byte{
+	seqEventTuneRequest: 0xF6,
+	seqEventClock:       0xF8,
+	seqEventStart:       0xFA,
+	seqEventContinue:    0xFB,
+	seqEventStop:        0xFC,
+	seqEventSensing:     0xFE,
+	seqEventReset:       0xFF,
+}
+
+// seqChannel maps the channel events of the sequencer to the high nibble of their status
+var seqChannel = map[byte]byte{
+	seqEventNoteOff:    0x80,
+	seqEventNoteOn:     0x90,
+	seqEventKeyPress:   0xA0,
+	seqEventController: 0xB0,
+	seqEventPgmChange:  0xC0,
+	seqEventChanPress:  0xD0,
+	seqEventPitchBend:  0xE0,
+}
+
+// seqClient is a client of the sequencer with one port
+type seqClient struct {
+	f    *os.File
+	id   int
+	port int
+}
+
+// openSeq connects to the sequencer as a new client
+func openSeq(flag int) (*seqClient, error) {
+	f, err := os.OpenFile("/dev/snd/seq", flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	c := &seqClient{f: f}
+	id := make([]byte, 4)
+	if err := c.ioctl(ioctlSeqClientID, id); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("ALSA sequencer: %w", err)
+	}
+	c.id = int(int32(binary.NativeEndian.Uint32(id)))
+	return c, nil
+}
+
+// ioctl runs a request on the sequencer. Fd would put the file in blocking mode, after which
+// Close no longer stops a Read.
+func (c *seqClient) ioctl(req uintptr, arg []byte) error {
+	rc, err := c.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(&arg[0])))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// createPort names the client and creates its port, the capabilities tell which way the
+// events go
+func (c *seqClient) createPort(name string, capability uint32) error {
+	info := make([]byte, seqClientInfoSize)
+	binary.NativeEndian.PutUint32(info[0:4], uint32(c.id))
+	if err := c.ioctl(ioctlSeqGetClientInfo, info); err != nil {
+		return err
+	}
+	clear(info[8:72])
+	copy(info[8:71], "GoAudio")
+	if err := c.ioctl(ioctlSeqSetClientInfo, info); err != nil {
+		return err
+	}
+
+	port := make([]byte, seqPortInfoSize)
+	port[0] = byte(c.id)
+	copy(port[2:65], name)
+	binary.NativeEndian.PutUint32(port[68:72], capability)
+	binary.NativeEndian.PutUint32(port[72:76], seqPortTypeMIDIGeneric|seqPortTypeApplication)
+	if err := c.ioctl(ioctlSeqCreatePort, port); err != nil {
+		return err
+	}
+	c.port = int(port[1])
+	return nil
+}
+
+// subscribe connects the port of a sender to that of a destination
+func (c *seqClient) subscribe(sender, dest [2]byte) error {
+	subs := make([]byte, seqSubscribeSize)
+	copy(subs[0:2], sender[:])
+	copy(subs[2:4], dest[:])
+	return c.ioctl(ioctlSeqSubscribePort, subs)
+}
+
+// seqPorts returns the ports of the other clients of the sequencer which events can be read
+// from, or written to. Without the sequencer there are no ports.
+func seqPorts(input bool) ([]Port, error) {
+	c, err := openSeq(os.O_RDONLY)
+	if err != nil {
+		return nil, nil
+	}
+	defer c.f.Close()
+	capability := uint32(seqCapWrite | seqCapSubsWrite)
+	if input {
+		capability = seqCapRead | seqCapSubsRead
+	}
+
+	ports := []Port{}
+	client := make([]byte, seqClientInfoSize)
+	binary.NativeEndian.PutUint32(client[0:4], ^uint32(0))
+	for c.ioctl(ioctlSeqQueryNextClient, client) == nil {
+		id := int(int32(binary.NativeEndian.Uint32(client[0:4])))
+		if id == seqClientSystem || id == c.id {
+			continue
+		}
+		port := make([]byte, seqPortInfoSize)
+		// the next port after 255 is the first one
+		port[0], port[1] = byte(id), 255
+		for c.ioctl(ioctlSeqQueryNextPort, port) == nil {
+			caps := binary.NativeEndian.Uint32(port[68:72])
+			if caps&capability != capability || caps&seqCapNoExport != 0 {
+				continue
+			}
+			ports = append(ports, Port{
+				Name:        fmt.Sprintf("%d:%d", id, port[1]),
+				Description: cString(client[8:72]) + ": " + cString(port[2:66]),
+			})
+		}
+	}
+	return ports, nil
+}
+
+// cString returns the text of a C string up to its terminating zero
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// openSeqInput subscribes a port of its own to a port which events are read from
+func openSeqInput(client, port int) (Input, error) {
+	c, err := openSeq(os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.createPort("GoAudio input", seqCapWrite|seqCapSubsWrite); err != nil {
+		c.f.Close()
+		return nil, fmt.Errorf("ALSA sequencer: %w", err)
+	}
+	if err := c.subscribe([2]byte{byte(client), byte(port)}, [2]byte{byte(c.id), byte(c.port)}); err != nil {
+		c.f.Close()
+		return nil, fmt.Errorf("ALSA sequencer: subscribing to %v:%v: %w", client, port, err)
+	}
+	return NewRawInput(&seqReader{c: c, buf: make([]byte, 1<<16)}), nil
+}
+
+// seqReader converts the events the sequencer delivers to MIDI bytes
+type seqReader struct {
+	c       *seqClient
+	buf     []byte // events as they are read, large enough for system exclusive messages
+	pending []byte // converted bytes which didn't fit in the last Read
+}
+
+func (r *seqReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		n, err := r.c.f.Read(r.buf)
+		if err != nil {
+			return 0, err
+		}
+		r.pending = seqMessages(nil, r.buf[:n])
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *seqReader) Close() error {
+	return r.c.f.Close()
+}
+
+// seqMessages appends the MIDI bytes of the events in b to dst. The data of an event of
+// variable length follows it, padded to the size of an event.
+func seqMessages(dst, b []byte) []byte {
+	for len(b) >= seqEventSize {
+		e := b[:seqEventSize]
+		b = b[seqEventSize:]
+		if e[1]&seqEventLengthMask != seqEventLengthVarLen {
+			dst = appendSeqMessage(dst, e)
+			continue
+		}
+		n := int(binary.NativeEndian.Uint32(e[16:20]) &^ seqExtMask)
+		padded := (n + seqEventSize - 1) / seqEventSize * seqEventSize
+		if n > len(b) {
+			break
+		}
+		if e[0] == seqEventSysex {
+			dst = append(dst, b[:n]...)
+		}
+		b = b[min(padded, len(b)):]
+	}
+	return dst
+}
+
+// appendSeqMessage appends the MIDI bytes of an event of fixed length, events which aren't
+// MIDI messages are skipped
+func appendSeqMessage(dst, e []byte) []byte {
+	ch := e[16] & 0x0F
+	param := int(binary.NativeEndian.Uint32(e[20:24]))
+	value := int(int32(binary.NativeEndian.Uint32(e[24:28])))
+	cc := func(dst []byte, controller, value int) []byte {
+		return append(dst, 0xB0|ch, byte(controller&0x7F), byte(value&0x7F))
+	}
+	switch t := e[0]; t {
+	case seqEventNoteOff, seqEventNoteOn, seqEventKeyPress:
+		return append(dst, seqChannel[t]|ch, e[17]&0x7F, e[18]&0x7F)
+	case seqEventController:
+		return cc(dst, param, value)
+	case seqEventPgmChange, seqEventChanPress:
+		return append(dst, seqChannel[t]|ch, byte(value&0x7F))
+	case seqEventPitchBend:
+		v := value + 8192
+		return append(dst, 0xE0|ch, byte(v&0x7F), byte(v>>7&0x7F))
+	case seqEventControl14:
+		// the most significant byte goes to the controller, the least to the one 32 above
+		if param < 32 {
+			return cc(cc(dst, param, value>>7), param+32, value)
+		}
+		return cc(dst, param, value)
+	case seqEventNonRegParam, seqEventRegParam:
+		msb, lsb := 99, 98
+		if t == seqEventRegParam {
+			msb, lsb = 101, 100
+		}
+		dst = cc(cc(dst, msb, param>>7), lsb, param)
+		return cc(cc(dst, 6, value>>7), 38, value)
+	case seqEventSongPos:
+		return append(dst, 0xF2, byte(value&0x7F), byte(value>>7&0x7F))
+	case seqEventSongSel:
+		return append(dst, 0xF3, byte(value&0x7F))
+	case seqEventQFrame:
+		return append(dst, 0xF1, byte(value&0x7F))
+	}
+	if s, ok := seqSystem[e[0]]; ok {
+		return append(dst, s)
+	}
+	return dst
+}
+
+// openSeqOutput subscribes a port which events are written to to a port of its own
+func openSeqOutput(client, port int) (Output, error) {
+	c, err := openSeq(os.O_WRONLY)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.createPort("GoAudio output", seqCapRead|seqCapSubsRead); err != nil {
+		c.f.Close()
+		return nil, fmt.Errorf("ALSA sequencer: %w", err)
+	}
+	if err := c.subscribe([2]byte{byte(c.id), byte(c.port)}, [2]byte{byte(client), byte(port)}); err != nil {
+		c.f.Close()
+		return nil, fmt.Errorf("ALSA sequencer: subscribing %v:%v: %w", client, port, err)
+	}
+	return &seqOutput{c: c}, nil
+}
+
+// seqOutput sends events to the subscribers of its port
+type seqOutput struct {
+	c   *seqClient
+	buf []byte
+}
+
+func (out *seqOutput) Write(e Event) error {
+	m, err := e.Message()
+	if err != nil {
+		return err
+	}
+	out.buf, err = seqEvent(out.buf[:0], m, byte(out.c.port))
+	if err != nil {
+		return err
+	}
+	_, err = out.c.f.Write(out.buf)
+	return err
+}
+
+func (out *seqOutput) Close() error {
+	return out.c.f.Close()
+}
+
+// seqEvent appends the event of the sequencer for a MIDI message, sent right away from a port
+// to its subscribers. The sequencer fills in the client of the source.
+func seqEvent(dst, m []byte, port byte) ([]byte, error) {
+	start := len(dst)
+	dst = append(dst, make([]byte, seqEventSize)...)
+	e := dst[start:]
+	e[3] = seqQueueDirect
+	e[13] = port
+	e[14], e[15] = seqAddressSubscribers, seqAddressUnknown
+	putValue := func(v int) {
+		binary.NativeEndian.PutUint32(e[24:28], uint32(int32(v)))
+	}
+
+	status := m[0]
+	switch {
+	case status == 0xF0:
+		// the data follows the event
+		e[0], e[1] = seqEventSysex, seqEventLengthVarLen
+		binary.NativeEndian.PutUint32(e[16:20], uint32(len(m)))
+		return append(dst, m...), nil
+	case status < 0xF0:
+		for t, s := range seqChannel {
+			if s == status&0xF0 {
+				e[0] = t
+			}
+		}
+		e[16] = status & 0x0F
+		switch e[0] {
+		case seqEventNoteOff, seqEventNoteOn, seqEventKeyPress:
+			e[17], e[18] = m[1], m[2]
+		case seqEventController:
+			binary.NativeEndian.PutUint32(e[20:24], uint32(m[1]))
+			putValue(int(m[2]))
+		case seqEventPgmChange, seqEventChanPress:
+			putValue(int(m[1]))
+		case seqEventPitchBend:
+			putValue((int(m[2])<<7 | int(m[1])) - 8192)
+		}
+		return dst, nil
+	case status == 0xF1:
+		e[0] = seqEventQFrame
+		putValue(int(m[1]))
+		return dst, nil
+	case status == 0xF2:
+		e[0] = seqEventSongPos
+		putValue(int(m[2])<<7 | int(m[1]))
+		return dst, nil
+	}
+	for t, s := range seqSystem {
+		if s == status {
+			e[0] = t
+			return dst, nil
+		}
+	}
+	return nil, fmt.Errorf("Message % x can't be sent to the ALSA sequencer", m)
+}
@@ -0,0 +1,109 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64
+
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestSeqEvents converts messages to events of the sequencer and back
+func TestSeqEvents(t *testing.T) {
+	messages := [][]byte{
+		{0x90, 0x3C, 0x64},
+		{0x81, 0x3E, 0x20},
+		{0xA2, 0x3C, 0x10},
+		{0xB1, 0x40, 0x7F},
+		{0xC0, 0x05},
+		{0xD3, 0x60},
+		{0xE0, 0x00, 0x40},
+		{0xE0, 0x7F, 0x7F},
+		{0xF1, 0x35},
+		{0xF2, 0x10, 0x02},
+		{0xF8},
+		{0xFA},
+		{0xFC},
+		{0xF0, 0x7E, 0x7F, 0x09, 0x01, 0xF7},
+		{0xF0, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0x43, 0x12,
+			0x00, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0x43, 0x12, 0x00, 0xF7},
+	}
+	for _, m := range messages {
+		t.Run("", func(t *testing.T) {
+			e, err := seqEvent(nil, m, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e[13] != 1 || e[14] != seqAddressSubscribers {
+				t.Fatalf("Expected an event from port 1 to the subscribers, got % x", e[12:16])
+			}
+			if m[0] == 0xF0 {
+				// the kernel pads the data it reads out to the size of an event
+				e = append(e, make([]byte, (seqEventSize-len(m)%seqEventSize)%seqEventSize)...)
+			}
+			// followed by a note off, to check the events are split right
+			next, _ := seqEvent(nil, []byte{0x80, 0x40, 0x00}, 1)
+			got := seqMessages(nil, append(e, next...))
+			want := append(append([]byte{}, m...), 0x80, 0x40, 0x00)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Expected % x, got % x", want, got)
+			}
+		})
+	}
+	if _, err := seqEvent(nil, []byte{0xF4}, 1); err == nil {
+		t.Fatal("Expected an error for an undefined message")
+	}
+}
+
+// TestSeqParameters checks the events of 14 bit controllers and parameters become control
+// changes
+func TestSeqParameters(t *testing.T) {
+	event := func(t byte, param, value int) []byte {
+		e := make([]byte, seqEventSize)
+		e[0], e[16] = t, 2
+		binary.NativeEndian.PutUint32(e[20:24], uint32(param))
+		binary.NativeEndian.PutUint32(e[24:28], uint32(value))
+		return e
+	}
+	tests := []struct {
+		event []byte
+		want  []byte
+	}{
+		{event(seqEventControl14, 7, 0x1234), []byte{0xB2, 7, 0x24, 0xB2, 39, 0x34}},
+		{event(seqEventControl14, 64, 0x7F), []byte{0xB2, 64, 0x7F}},
+		{event(seqEventRegParam, 0, 0x100), []byte{0xB2, 101, 0, 0xB2, 100, 0, 0xB2, 6, 2, 0xB2, 38, 0}},
+		{event(seqEventNonRegParam, 0x81, 5), []byte{0xB2, 99, 1, 0xB2, 98, 1, 0xB2, 6, 0, 0xB2, 38, 5}},
+		{event(99, 0, 0), nil},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if got := seqMessages(nil, test.event); !bytes.Equal(got, test.want) {
+				t.Fatalf("Expected % x, got % x", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSeqAddress(t *testing.T) {
+	tests := []struct {
+		name         string
+		client, port int
+		ok           bool
+	}{
+		{"20:0", 20, 0, true},
+		{"128:1", 128, 1, true},
+		{"hw:1,0", 0, 0, false},
+		{"256:0", 256, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, port, ok := seqAddress(test.name)
+			if ok != test.ok || (ok && (client != test.client || port != test.port)) {
+				t.Fatalf("Expected %v:%v %v, got %v:%v %v", test.client, test.port, test.ok, client, port, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux && !(386 || amd64 || arm || arm64 || riscv64)
+// +build linux,!386,!amd64,!arm,!arm64,!riscv64
+
+package midi
+
+import (
+	"errors"
+)
+
+func seqPorts(input bool) ([]Port, error) {
+	return nil, nil
+}
+
+func openSeqInput(client, port int) (Input, error) {
+	return nil, errors.New("The ALSA sequencer is not supported on this architecture")
+}
+
+func openSeqOutput(client, port int) (Output, error) {
+	return nil, errors.New("The ALSA sequencer is not supported on this architecture")
+}
@@ -0,0 +1,385 @@
+package midi
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// clocksPerBeat is the resolution of MIDI clock, in clocks per quarter note
+const clocksPerBeat = 24
+
+// mtcRates are the frame rates of MIDI time code, by their code
+var mtcRates = [4]float64{24, 25, 30000. / 1001, 30}
+
+// stampedEvent is an event with the time it arrived
+type stampedEvent struct {
+	Event
+	at time.Time
+}
+
+// ClockFollower plays a transport in time with the MIDI clock or MIDI time code of another
+// device. Events are passed to Handle as they arrive, and Fill is used as the callback of
+// the stream playing the transport, so the transport only changes between blocks.
+//
+// With MIDI clock the transport starts, stops and seeks with the master, and the tempo map
+// of the transport follows the tempo of the master, adjusted on every beat. Only the parts of
+// the song which are timed in beats, such as the metronome, follow a tempo which differs from
+// the song. With MIDI time code the transport follows the time of the master, in seconds.
+type ClockFollower struct {
+	Transport *synth.Transport
+	// ClockTolerance is the difference in beats with the clock, and CodeTolerance that in
+	// seconds with the time code, above which the transport moves to the master
+	ClockTolerance float64
+	CodeTolerance  float64
+	// Timeout stops the transport once time code has not arrived for that long
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending []stampedEvent
+	now     func() time.Time
+
+	// MIDI clock
+	clocks  []time.Time // arrival of the last clocks, for the tempo
+	base    float64     // beat of the last start or song position
+	clock   int         // clocks since base, the first clock after a start is clock 0
+	running bool
+	waiting bool // for the first clock after a start or continue, which starts the transport
+
+	// MIDI time code
+	pieces   [8]int
+	received int // pieces received in order since piece 0
+	lastCode time.Time
+	coded    bool // whether the transport is playing from time code
+}
+
+// NewClockFollower creates a follower for a transport
+func NewClockFollower(t *synth.Transport) (*ClockFollower, error) {
+	if t == nil {
+		return nil, errors.New("Need a transport to follow the clock")
+	}
+	return &ClockFollower{
+		Transport:      t,
+		ClockTolerance: 1,
+		CodeTolerance:  .1,
+		Timeout:        250 * time.Millisecond,
+		now:            time.Now,
+	}, nil
+}
+
+// Handle queues a clock, transport, song position or time code event, it is safe to call
+// while the transport plays. Other events are ignored.
+func (f *ClockFollower) Handle(e Event) {
+	switch e.Type {
+	case CLOCK, START, CONTINUE, STOP, SONG_POSITION, QUARTER_FRAME, SYSEX:
+	default:
+		return
+	}
+	f.mu.Lock()
+	f.pending = append(f.pending, stampedEvent{e, f.now()})
+	f.mu.Unlock()
+}
+
+// Run handles the events of an input until it is closed
+func (f *ClockFollower) Run(in Input) error {
+	for {
+		e, err := in.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f.Handle(e)
+	}
+}
+
+// Tempo returns the tempo of the master in beats per minute from the last clocks, or 0
+// before enough clocks have arrived
+func (f *ClockFollower) Tempo() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tempo()
+}
+
+func (f *ClockFollower) tempo() float64 {
+	n := len(f.clocks)
+	if n < 2 {
+		return 0
+	}
+	interval := f.clocks[n-1].Sub(f.clocks[0]).Seconds() / float64(n-1)
+	if interval <= 0 {
+		return 0
+	}
+	return 60 / (interval * clocksPerBeat)
+}
+
+// Fill applies the events which arrived since the last block and fills out with the
+// transport
+func (f *ClockFollower) Fill(out []wave.Frame) int {
+	f.mu.Lock()
+	events := f.pending
+	f.pending = nil
+	for _, e := range events {
+		f.apply(e)
+	}
+	if f.coded && f.now().Sub(f.lastCode) > f.Timeout {
+		f.coded = false
+		f.Transport.Stop()
+	}
+	f.mu.Unlock()
+	return f.Transport.Fill(out)
+}
+
+func (f *ClockFollower) apply(e stampedEvent) {
+	t := f.Transport
+	switch e.Type {
+	case START:
+		f.base, f.clock = 0, -1
+		f.running, f.waiting = true, true
+		t.SeekSample(0)
+	case CONTINUE:
+		f.running, f.waiting = true, true
+	case STOP:
+		f.running = false
+		t.Stop()
+	case SONG_POSITION:
+		// in 16th notes
+		f.base, f.clock = float64(e.Value)/4, -1
+		t.SeekBeat(f.base)
+	case CLOCK:
+		f.clocks = append(f.clocks, e.at)
+		if len(f.clocks) > clocksPerBeat+1 {
+			f.clocks = f.clocks[1:]
+		}
+		if !f.running {
+			return
+		}
+		f.clock++
+		if f.waiting {
+			f.waiting = false
+			t.Play()
+		}
+		if f.clock > 0 && f.clock%clocksPerBeat == 0 {
+			f.follow()
+		}
+	case QUARTER_FRAME:
+		f.quarterFrame(e.Value, e.at)
+	case SYSEX:
+		// a full time code message locates the transport
+		d := e.Data
+		if len(d) >= 8 && d[0] == 0x7F && d[2] == 0x01 && d[3] == 0x01 {
+			seconds, _ := timeCode(int(d[4]), int(d[5]), int(d[6]), int(d[7]))
+			t.SeekSample(int64(seconds * float64(t.SampleRate())))
+		}
+	}
+}
+
+// follow adjusts the transport on every beat of the master
+func (f *ClockFollower) follow() {
+	t := f.Transport
+	bpm := f.tempo()
+	if bpm <= 0 {
+		return
+	}
+	beat := f.base + float64(f.clock)/clocksPerBeat
+	at := t.Beat()
+	drift := beat - at
+	if math.Abs(drift) > f.ClockTolerance {
+		t.SeekBeat(beat)
+		return
+	}
+	// catch up with the master over the next beat
+	target := bpm * (1 + drift)
+	current := 60 / (t.Tempo.Seconds(at+1) - t.Tempo.Seconds(at))
+	if math.Abs(target/current-1) > .005 {
+		t.Tempo.SetTempo(at, target)
+	}
+}
+
+// quarterFrame collects the eight pieces of the time code, the last piece completes it
+func (f *ClockFollower) quarterFrame(value int, at time.Time) {
+	piece := value >> 4
+	if piece != f.received {
+		f.received = 0
+		if piece != 0 {
+			return
+		}
+	}
+	f.pieces[piece] = value & 0x0F
+	f.received++
+	if f.received < 8 {
+		return
+	}
+	f.received = 0
+	p := f.pieces
+	seconds, rate := timeCode(p[6]|p[7]<<4, p[4]|p[5]<<4, p[2]|p[3]<<4, p[0]|p[1]<<4)
+	// the time code was that of the first piece, two frames ago
+	seconds += 2 / rate
+
+	t := f.Transport
+	f.lastCode = at
+	if !f.coded || !t.Playing() || math.Abs(t.Seconds()-seconds) > f.CodeTolerance {
+		t.SeekSample(int64(seconds * float64(t.SampleRate())))
+	}
+	f.coded = true
+	t.Play()
+}
+
+// timeCode returns the time in seconds and the frame rate of a time code, of which the
+// hours carry the frame rate in bits 5 and 6
+func timeCode(hours, minutes, seconds, frames int) (float64, float64) {
+	rate := mtcRates[(hours>>5)&3]
+	return float64((hours&0x1F)*3600+minutes*60+seconds) + float64(frames)/rate, rate
+}
+
+// scheduledEvent is an event to be sent at a time
+type scheduledEvent struct {
+	Event
+	at time.Time
+}
+
+// ClockSender sends MIDI clock for a transport, so other devices play in time with it.
+// Fill is used as the callback of the stream playing the transport. The clocks of a block are
+// sent at their time within the block from a separate goroutine, delayed by Latency so they
+// line up with the output of the stream.
+type ClockSender struct {
+	Transport *synth.Transport
+	Latency   time.Duration
+
+	out     Output
+	playing bool
+	end     int64   // position at the end of the last block
+	resume  float64 // first clock after a song position
+	queue   chan scheduledEvent
+	done    chan struct{}
+	mu      sync.Mutex
+	err     error
+	now     func() time.Time
+}
+
+// NewClockSender starts sending clock for a transport to an output
+func NewClockSender(t *synth.Transport, out Output) (*ClockSender, error) {
+	if t == nil || out == nil {
+		return nil, errors.New("Need a transport and an output to send clock")
+	}
+	c := &ClockSender{
+		Transport: t,
+		out:       out,
+		queue:     make(chan scheduledEvent, 1024),
+		done:      make(chan struct{}),
+		now:       time.Now,
+	}
+	go c.send()
+	return c, nil
+}
+
+func (c *ClockSender) send() {
+	defer close(c.done)
+	for e := range c.queue {
+		if wait := e.at.Sub(c.now()); wait > 0 {
+			time.Sleep(wait)
+		}
+		if err := c.out.Write(e.Event); err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = err
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// Fill fills out with the transport and schedules the clocks of the block. Start, stop and
+// continue are sent when the transport starts and stops, a jump such as the end of a loop
+// is sent as a stop, the new song position and a continue.
+func (c *ClockSender) Fill(out []wave.Frame) int {
+	t := c.Transport
+	sr := float64(t.SampleRate())
+	start := t.Position()
+	playing := t.Playing()
+	n := t.Fill(out)
+	end := t.Position()
+	now := c.now().Add(c.Latency)
+
+	switch {
+	case playing && !c.playing:
+		if start == 0 {
+			c.resume = 0
+			c.schedule(Event{Type: START}, now)
+		} else {
+			c.locate(start, now)
+		}
+	case !playing && c.playing:
+		c.schedule(Event{Type: STOP}, now)
+	case playing && start != c.end:
+		c.schedule(Event{Type: STOP}, now)
+		c.locate(start, now)
+	}
+	c.playing = playing
+	c.end = end
+	if !playing {
+		return n
+	}
+
+	length := int64(len(out) / t.Channels)
+	if end != start+length {
+		// the transport jumped within the block, the clocks go on after the jump
+		c.schedule(Event{Type: STOP}, now)
+		c.locate(end, now)
+		return n
+	}
+	from, to := float64(start)/sr, float64(end)/sr
+	first := math.Ceil(t.Tempo.Beats(from)*clocksPerBeat - 1e-9)
+	last := t.Tempo.Beats(to) * clocksPerBeat
+	for clock := math.Max(first, c.resume); clock < last-1e-9; clock++ {
+		offset := t.Tempo.Seconds(clock/clocksPerBeat) - from
+		c.schedule(Event{Type: CLOCK}, now.Add(time.Duration(offset*float64(time.Second))))
+	}
+	return n
+}
+
+// locate sends the song position of a sample followed by continue. The song position is
+// in 16th notes, a position between them continues with the clock of the next.
+func (c *ClockSender) locate(sample int64, at time.Time) {
+	t := c.Transport
+	beat := t.Tempo.Beats(float64(sample) / float64(t.SampleRate()))
+	position := int(math.Ceil(beat*4 - 1e-9))
+	if position > 0x3FFF {
+		position = 0x3FFF
+	}
+	c.resume = float64(position * clocksPerBeat / 4)
+	c.schedule(Event{Type: SONG_POSITION, Value: position}, at)
+	c.schedule(Event{Type: CONTINUE}, at)
+}
+
+func (c *ClockSender) schedule(e Event, at time.Time) {
+	select {
+	case c.queue <- scheduledEvent{e, at}:
+	default:
+		// the output has fallen behind, dropping an event rather than the audio
+	}
+}
+
+// Err returns the first error writing to the output
+func (c *ClockSender) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close sends a stop when the transport was playing, and waits for the scheduled events
+// to be sent. The output is not closed.
+func (c *ClockSender) Close() error {
+	if c.playing {
+		c.schedule(Event{Type: STOP}, c.now())
+	}
+	close(c.queue)
+	<-c.done
+	return c.Err()
+}
@@ -0,0 +1,216 @@
+package midi
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func newSyncTransport(t *testing.T, sr int) *synth.Transport {
+	tempo, err := breakpoint.NewTempoMap(120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := synth.NewTransport(sr, 1, tempo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr
+}
+
+func TestClockFollower(t *testing.T) {
+	sr := 1000
+	tr := newSyncTransport(t, sr)
+	f, err := NewClockFollower(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	f.now = func() time.Time { return now }
+
+	// the master plays at 100 BPM, a clock every 25ms
+	interval := 25 * time.Millisecond
+	block := make([]wave.Frame, 25)
+	clocks := func(n int) {
+		for i := 0; i < n; i++ {
+			f.Handle(Event{Type: CLOCK})
+			f.Fill(block)
+			now = now.Add(interval)
+		}
+	}
+	clocks(30) // the tempo is known before the start
+	f.Handle(Event{Type: START})
+	f.Fill(block)
+	if tr.Playing() {
+		t.Fatal("Expected the transport to wait for the first clock")
+	}
+	clocks(24 * 8)
+	if !tr.Playing() {
+		t.Fatal("Expected the transport to play")
+	}
+	if bpm := f.Tempo(); math.Abs(bpm-100) > 1e-6 {
+		t.Fatalf("Expected a tempo of 100 BPM, got %v", bpm)
+	}
+	// after 8 beats of the master the transport is close to beat 8
+	if beat := tr.Beat(); math.Abs(beat-8) > .1 {
+		t.Fatalf("Expected the transport at beat 8, got %v", beat)
+	}
+	if bpm := 60 / (tr.Tempo.Seconds(10) - tr.Tempo.Seconds(9)); math.Abs(bpm-100) > 2 {
+		t.Fatalf("Expected the tempo map to follow at 100 BPM, got %v", bpm)
+	}
+
+	f.Handle(Event{Type: STOP})
+	f.Handle(Event{Type: SONG_POSITION, Value: 64})
+	f.Fill(block)
+	if tr.Playing() || tr.Beat() != 16 {
+		t.Fatalf("Expected the transport stopped at beat 16, got %v", tr.Beat())
+	}
+	f.Handle(Event{Type: CONTINUE})
+	clocks(1)
+	if !tr.Playing() {
+		t.Fatal("Expected the transport to continue")
+	}
+}
+
+// quarterFrames returns the quarter frames of a time code
+func quarterFrames(hours, minutes, seconds, frames, rate int) []Event {
+	hours |= rate << 5
+	nibbles := []int{frames, frames >> 4, seconds, seconds >> 4, minutes, minutes >> 4, hours, hours >> 4}
+	events := []Event{}
+	for piece, n := range nibbles {
+		events = append(events, Event{Type: QUARTER_FRAME, Value: piece<<4 | n&0x0F})
+	}
+	return events
+}
+
+func TestTimeCodeFollower(t *testing.T) {
+	sr := 1000
+	tr := newSyncTransport(t, sr)
+	f, err := NewClockFollower(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(0, 0)
+	f.now = func() time.Time { return now }
+	block := make([]wave.Frame, 10)
+
+	// 25 frames per second, a quarter frame every 10ms
+	for _, e := range quarterFrames(0, 1, 0, 0, 1) {
+		f.Handle(e)
+		f.Fill(block)
+		now = now.Add(10 * time.Millisecond)
+	}
+	if !tr.Playing() || math.Abs(tr.Seconds()-60.09) > .02 {
+		t.Fatalf("Expected the transport to play from 60.08s, got %v", tr.Seconds())
+	}
+	// time code which stays close doesn't move the transport
+	position := tr.Position()
+	for _, e := range quarterFrames(0, 1, 0, 2, 1) {
+		f.Handle(e)
+		f.Fill(block)
+		now = now.Add(10 * time.Millisecond)
+	}
+	if tr.Position() != position+80 {
+		t.Fatalf("Expected the transport to play on, got %v", tr.Position()-position)
+	}
+
+	// the time code stops
+	now = now.Add(time.Second)
+	f.Fill(block)
+	if tr.Playing() {
+		t.Fatal("Expected the transport to stop without time code")
+	}
+
+	// a full frame locates
+	f.Handle(Event{Type: SYSEX, Data: []byte{0x7F, 0x7F, 0x01, 0x01, 1<<5 | 1, 0, 0, 5, 0xF7}})
+	f.Fill(block)
+	if math.Abs(tr.Seconds()-3600.2) > 1e-3 {
+		t.Fatalf("Expected the transport at 1 hour and 5 frames, got %v", tr.Seconds())
+	}
+}
+
+// eventOutput records the events written to it
+type eventOutput struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (o *eventOutput) Write(e Event) error {
+	o.mu.Lock()
+	o.events = append(o.events, e)
+	o.mu.Unlock()
+	return nil
+}
+
+func (o *eventOutput) Close() error {
+	return nil
+}
+
+func TestClockSender(t *testing.T) {
+	sr := 2400
+	tr := newSyncTransport(t, sr)
+	out := &eventOutput{}
+	c, err := NewClockSender(tr, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every event is due right away
+	c.Latency = -time.Hour
+
+	// at 120 BPM a clock every 50 samples
+	block := make([]wave.Frame, 120)
+	render := func(blocks int) {
+		for i := 0; i < blocks; i++ {
+			c.Fill(block)
+		}
+	}
+	render(2) // stopped
+	tr.Play()
+	render(10) // a beat
+	tr.Stop()
+	render(1)
+	if err := tr.SeekBeat(2.1); err != nil {
+		t.Fatal(err)
+	}
+	tr.Play()
+	render(1)
+	// a loop jumps back
+	if err := tr.SetLoopBeats(2, 2.5); err != nil {
+		t.Fatal(err)
+	}
+	render(5)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(events []Event) map[EventType]int {
+		counts := map[EventType]int{}
+		for _, e := range events {
+			counts[e.Type]++
+		}
+		return counts
+	}
+	events := out.events
+	if events[0].Type != START || events[25].Type != STOP {
+		t.Fatalf("Expected a start, a beat of clock and a stop, got %+v", events[:26])
+	}
+	if counts := count(events[:25]); counts[CLOCK] != 24 {
+		t.Fatalf("Expected 24 clocks in a beat, got %v", counts)
+	}
+	// from beat 2.1 the song position is the next 16th, beat 2.25
+	if events[26].Type != SONG_POSITION || events[26].Value != 9 || events[27].Type != CONTINUE {
+		t.Fatalf("Expected a song position and continue, got %+v", events[26:28])
+	}
+	// the block from 2.1 plays up to beat 2.2, before the first clock at 2.25
+	if events[28].Type != CLOCK && events[28].Type != STOP {
+		t.Fatalf("Unexpected %+v", events[28:])
+	}
+	if counts := count(events[28:]); counts[STOP] < 2 || counts[SONG_POSITION] < 1 {
+		t.Fatalf("Expected the loop to send a stop and a song position, got %v in %+v", counts, events[28:])
+	}
+}
@@ -0,0 +1,220 @@
+package midi
+
+import (
+	"math"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	gmath "github.com/DylanMeeus/GoAudio/math"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Transcriber turns a monophonic recording, such as a hummed melody, into notes
+type Transcriber struct {
+	MinFrequency float64 // lowest pitch, in Hz
+	MaxFrequency float64 // highest pitch, in Hz
+	Periodicity  float64 // least periodicity of a frame with a pitch, from 0 to 1
+	Silence      float64 // RMS below which a frame is silent
+	MinDuration  float64 // shortest note in seconds
+	// PitchBend follows glides and vibrato within BendRange semitones of a note with pitch
+	// bend, rather than starting new notes when the pitch moves to the next semitone
+	PitchBend bool
+	BendRange float64
+}
+
+// NewTranscriber creates a transcriber for voices and most melodic instruments
+func NewTranscriber() *Transcriber {
+	return &Transcriber{
+		MinFrequency: 60,
+		MaxFrequency: 1500,
+		Periodicity:  .8,
+		Silence:      .01,
+		MinDuration:  .06,
+		BendRange:    2,
+	}
+}
+
+// TranscribeMonophonic finds the notes of a monophonic recording with the defaults of
+// NewTranscriber
+func TranscribeMonophonic(frames []wave.Frame, wfmt wave.WaveFmt) []synth.NoteEvent {
+	notes, _ := NewTranscriber().Transcribe(frames, wfmt)
+	return notes
+}
+
+// transcribeHop is the time in seconds between the frames which are analysed
+const transcribeHop = .01
+
+// analysis is the pitch and loudness of the recording around a hop
+type analysis struct {
+	pitch  float64 // fractional MIDI note
+	rms    float64
+	onset  bool
+	voiced bool
+}
+
+// Transcribe finds the notes of a recording, which starts at time 0. Notes start when the
+// recording gets loud enough, at onsets and when the pitch settles on another note. With
+// PitchBend it also returns an envelope of the pitch relative to the sounding note in
+// semitones, which PitchBendEvents converts to MIDI.
+func (t *Transcriber) Transcribe(frames []wave.Frame, wfmt wave.WaveFmt) ([]synth.NoteEvent, *breakpoint.Envelope) {
+	if wfmt.NumChannels < 1 || wfmt.SampleRate < 1 || t.MinFrequency <= 0 {
+		return nil, nil
+	}
+	sr := wfmt.SampleRate
+	mono := wave.AcquireFrames(len(frames) / wfmt.NumChannels)
+	defer wave.ReleaseFrames(mono)
+	for i := range mono {
+		sum := wave.Frame(0)
+		for c := 0; c < wfmt.NumChannels; c++ {
+			sum += frames[i*wfmt.NumChannels+c]
+		}
+		mono[i] = sum / wave.Frame(wfmt.NumChannels)
+	}
+
+	// analyse the pitch and loudness around every hop
+	hop := int(transcribeHop * float64(sr))
+	if hop < 1 {
+		hop = 1
+	}
+	window := 2 * (int(float64(sr)/t.MinFrequency) + 1)
+	onsets := map[int]bool{}
+	for _, o := range gmath.Onsets(mono, sr) {
+		onsets[(o+hop/2)/hop] = true
+	}
+	analysed := []analysis{}
+	for center := 0; center < len(mono); center += hop {
+		lo, hi := center-window/2, center+window/2
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(mono) {
+			hi = len(mono)
+		}
+		a := analysis{rms: rms(mono[lo:hi]), onset: onsets[len(analysed)]}
+		if a.rms >= t.Silence {
+			freq, periodicity := gmath.Pitch(mono[lo:hi], sr, t.MinFrequency, t.MaxFrequency)
+			if freq > 0 && periodicity >= t.Periodicity {
+				a.pitch, a.voiced = synth.FrequencyToMidi(freq), true
+			}
+		}
+		analysed = append(analysed, a)
+	}
+
+	// split the voiced frames into segments of a single note
+	minFrames := int(math.Ceil(t.MinDuration / transcribeHop))
+	if minFrames < 1 {
+		minFrames = 1
+	}
+	type segment struct{ start, end int }
+	segments := []segment{}
+	start := -1
+	for i := 0; i <= len(analysed); i++ {
+		if i == len(analysed) || !analysed[i].voiced {
+			if start >= 0 {
+				segments = append(segments, segment{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		if analysed[i].onset && i-start >= minFrames {
+			segments = append(segments, segment{start, i})
+			start = i
+			continue
+		}
+		// a new note once the pitch has left the note for long enough
+		note := math.Round(medianPitch(analysed[start:i]))
+		moved := i+minFrames <= len(analysed) && i-start >= minFrames
+		for j := i; moved && j < i+minFrames; j++ {
+			a := analysed[j]
+			if !a.voiced || (t.PitchBend && math.Abs(a.pitch-note) <= t.BendRange) ||
+				(!t.PitchBend && math.Round(a.pitch) == note) {
+				moved = false
+			}
+		}
+		if moved {
+			segments = append(segments, segment{start, i})
+			start = i
+		}
+	}
+
+	notes := []synth.NoteEvent{}
+	bend := breakpoint.Breakpoints{}
+	loudest := 0.0
+	levels := []float64{}
+	for _, s := range segments {
+		if s.end-s.start < minFrames {
+			continue
+		}
+		part := analysed[s.start:s.end]
+		note := int(math.Round(medianPitch(part)))
+		level := medianLevel(part)
+		loudest = math.Max(loudest, level)
+		levels = append(levels, level)
+		notes = append(notes, synth.NoteEvent{
+			Time:     float64(s.start) * transcribeHop,
+			Duration: float64(s.end-s.start) * transcribeHop,
+			Note:     note,
+		})
+		if t.PitchBend {
+			points := breakpoint.Breakpoints{}
+			for i, a := range part {
+				points = append(points, breakpoint.Breakpoint{
+					Time:  float64(s.start+i) * transcribeHop,
+					Value: math.Max(-t.BendRange, math.Min(t.BendRange, a.pitch-float64(note))),
+				})
+			}
+			// a hundredth of a semitone is well below what can be heard
+			bend = append(bend, breakpoint.Simplify(points, .01)...)
+		}
+	}
+	for i := range notes {
+		notes[i].Velocity = levels[i] / loudest
+	}
+	if len(bend) == 0 {
+		return notes, nil
+	}
+	env, err := breakpoint.NewEnvelope(bend, breakpoint.LINEAR)
+	if err != nil {
+		return notes, nil
+	}
+	return notes, env
+}
+
+// medianPitch returns the median pitch of analysed hops
+func medianPitch(hops []analysis) float64 {
+	values := make([]float64, len(hops))
+	for i, a := range hops {
+		values[i] = a.pitch
+	}
+	return median(values)
+}
+
+// medianLevel returns the median RMS of analysed hops
+func medianLevel(hops []analysis) float64 {
+	values := make([]float64, len(hops))
+	for i, a := range hops {
+		values[i] = a.rms
+	}
+	return median(values)
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	return values[len(values)/2]
+}
+
+func rms(frames []wave.Frame) float64 {
+	if len(frames) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, f := range frames {
+		sum += float64(f * f)
+	}
+	return math.Sqrt(sum / float64(len(frames)))
+}
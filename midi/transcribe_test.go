@@ -0,0 +1,109 @@
+package midi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// part of a melody, a note of 0 is silence
+type hum struct {
+	note, amplitude, duration float64
+}
+
+// sing renders a melody with continuous phase, so notes without a rest are legato
+func sing(sr int, melody []hum) []wave.Frame {
+	frames := []wave.Frame{}
+	phase := 0.0
+	for _, h := range melody {
+		n := int(h.duration * float64(sr))
+		for i := 0; i < n; i++ {
+			v := 0.0
+			if h.note > 0 {
+				v = h.amplitude * math.Sin(phase)
+				phase += 2 * math.Pi * synth.MidiToFrequency(0) * math.Pow(2, h.note/12) / float64(sr)
+			}
+			frames = append(frames, wave.Frame(v))
+		}
+	}
+	return frames
+}
+
+func TestTranscribeMonophonic(t *testing.T) {
+	sr := 16000
+	frames := sing(sr, []hum{
+		{69, .5, .4},
+		{0, 0, .1},
+		{72, .25, .4},
+		{74, .5, .4}, // legato
+		{0, 0, .2},
+		{67, .1, .3},
+		{67, .5, .3}, // accent on the same note
+	})
+	notes := TranscribeMonophonic(frames, wave.NewWaveFmt(1, 1, sr, 16, nil))
+	expected := []synth.NoteEvent{
+		{Time: 0, Duration: .4, Note: 69, Velocity: 1},
+		{Time: .5, Duration: .4, Note: 72, Velocity: .5},
+		{Time: .9, Duration: .4, Note: 74, Velocity: 1},
+		{Time: 1.5, Duration: .3, Note: 67, Velocity: .2},
+		{Time: 1.8, Duration: .3, Note: 67, Velocity: 1},
+	}
+	if len(notes) != len(expected) {
+		t.Fatalf("Expected %v notes, got %+v", len(expected), notes)
+	}
+	for i, e := range expected {
+		n := notes[i]
+		if n.Note != e.Note || math.Abs(n.Time-e.Time) > .04 || math.Abs(n.Duration-e.Duration) > .06 ||
+			math.Abs(n.Velocity-e.Velocity) > .1 {
+			t.Fatalf("Note %v: expected %+v, got %+v", i, e, n)
+		}
+	}
+}
+
+func TestTranscribePitchBend(t *testing.T) {
+	sr := 16000
+	// a glide up by .8 semitones
+	melody := []hum{}
+	for i := 0; i < 50; i++ {
+		melody = append(melody, hum{69 + .8*float64(i)/50, .5, .01})
+	}
+	melody = append(melody, hum{69.8, .5, .3})
+	frames := sing(sr, melody)
+	wfmt := wave.NewWaveFmt(1, 1, sr, 16, nil)
+
+	tr := NewTranscriber()
+	notes, bend := tr.Transcribe(frames, wfmt)
+	if len(notes) != 2 || notes[0].Note != 69 || notes[1].Note != 70 || bend != nil {
+		t.Fatalf("Expected the glide to step to the next note, got %+v", notes)
+	}
+
+	tr.PitchBend = true
+	notes, bend = tr.Transcribe(frames, wfmt)
+	if len(notes) != 1 || notes[0].Note != 70 {
+		t.Fatalf("Expected a single note bending to 70, got %+v", notes)
+	}
+	points := bend.Points
+	if first, last := points[0].Value, points[len(points)-1].Value; math.Abs(first+1) > .05 || math.Abs(last+.2) > .05 {
+		t.Fatalf("Expected a bend from -1 to -.2, got %v", bend)
+	}
+
+	// the bend is exported with the notes
+	tempo, _ := breakpoint.NewTempoMap(120)
+	clock := &Clock{Tempo: tempo, Division: 480}
+	events, err := PitchBendEvents(bend, clock, 0, tr.BendRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := events[0].Value, events[len(events)-1].Value
+	if len(events) < 10 || math.Abs(float64(first)+4096) > 200 || math.Abs(float64(last)+820) > 200 {
+		t.Fatalf("Unexpected pitch bend events %+v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Tick < events[i-1].Tick || events[i].Value < events[i-1].Value {
+			t.Fatalf("Expected a rising bend, got %+v", events)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package midi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// WriteFile writes a .mid file
+func WriteFile(name string, f *File) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err := Write(w, f); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Write encodes a Standard MIDI File. The events of every track are written in the order of
+// their ticks, with running status, and each track is ended with an end of track event.
+func Write(w io.Writer, f *File) error {
+	hdr := []byte("MThd")
+	hdr = binary.BigEndian.AppendUint32(hdr, 6)
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(f.Format))
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(len(f.Tracks)))
+	if f.FramesPerSecond > 0 {
+		hdr = append(hdr, byte(-int8(f.FramesPerSecond)), byte(f.TicksPerFrame))
+	} else {
+		if f.Division < 1 || f.Division > 0x7FFF {
+			return errors.New("Division should be between 1 and 32767 ticks per quarter note")
+		}
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(f.Division))
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	for i, t := range f.Tracks {
+		body, err := encodeTrack(t)
+		if err != nil {
+			return fmt.Errorf("Track %v: %v", i, err)
+		}
+		chunk := append([]byte("MTrk"), binary.BigEndian.AppendUint32(nil, uint32(len(body)))...)
+		if _, err := w.Write(append(chunk, body...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarint appends a variable length quantity
+func appendVarint(b []byte, v int) []byte {
+	buf := []byte{byte(v & 0x7F)}
+	for v >>= 7; v > 0; v >>= 7 {
+		buf = append([]byte{byte(v&0x7F | 0x80)}, buf...)
+	}
+	return append(b, buf...)
+}
+
+func appendMeta(b []byte, meta int, data []byte) []byte {
+	b = append(b, 0xFF, byte(meta))
+	b = appendVarint(b, len(data))
+	return append(b, data...)
+}
+
+func encodeTrack(t Track) ([]byte, error) {
+	events := append([]Event{}, t.Events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Tick < events[j].Tick })
+
+	b := []byte{}
+	hasName := false
+	for _, e := range events {
+		if e.Type == META && e.Meta == META_TRACK_NAME {
+			hasName = true
+		}
+	}
+	if t.Name != "" && !hasName {
+		b = appendMeta(append(b, 0), META_TRACK_NAME, []byte(t.Name))
+	}
+	tick := int64(0)
+	status := byte(0)
+	for _, e := range events {
+		if e.Tick < 0 {
+			return nil, errors.New("Event before the start of the track")
+		}
+		if e.Type == META && e.Meta == META_END_OF_TRACK {
+			continue
+		}
+		b = appendVarint(b, int(e.Tick-tick))
+		tick = e.Tick
+
+		switch e.Type {
+		case SYSEX:
+			b = append(b, 0xF0)
+			b = appendVarint(b, len(e.Data))
+			b = append(b, e.Data...)
+			status = 0
+			continue
+		case TEMPO:
+			v := e.Value
+			b = appendMeta(b, META_TEMPO, []byte{byte(v >> 16), byte(v >> 8), byte(v)})
+			continue
+		case TIME_SIGNATURE:
+			b = appendMeta(b, META_TIME_SIGNATURE, e.Data)
+			continue
+		case META:
+			b = appendMeta(b, e.Meta, e.Data)
+			continue
+		}
+		m, err := channelMessage(e)
+		if err != nil {
+			return nil, err
+		}
+		if m[0] == status {
+			b = append(b, m[1:]...)
+		} else {
+			status = m[0]
+			b = append(b, m...)
+		}
+	}
+	return appendMeta(append(b, 0), META_END_OF_TRACK, nil), nil
+}
+
+// TimeSignatureEvent creates a time signature event with 24 MIDI clocks per click
+func TimeSignatureEvent(tick int64, numerator, denominator int) Event {
+	log2 := 0
+	for d := denominator; d > 1; d >>= 1 {
+		log2++
+	}
+	return Event{
+		Tick: tick,
+		Type: TIME_SIGNATURE,
+		Meta: META_TIME_SIGNATURE,
+		Data: []byte{byte(numerator), byte(log2), 24, 8},
+	}
+}
+
+// meter turns beats per bar in quarter notes into a time signature
+func meter(beatsPerBar float64) (numerator, denominator int) {
+	for denominator = 4; denominator <= 64; denominator *= 2 {
+		n := beatsPerBar * float64(denominator) / 4
+		if math.Abs(n-math.Round(n)) < 1e-9 {
+			return int(math.Round(n)), denominator
+		}
+	}
+	return int(math.Round(beatsPerBar)), 4
+}
+
+// ConductorTrack returns a track with the tempo changes and meters of a tempo map
+func ConductorTrack(tempo *breakpoint.TempoMap, division int) Track {
+	t := Track{Name: "Conductor"}
+	num, den := meter(tempo.BeatsPerBar)
+	t.Events = append(t.Events, TimeSignatureEvent(0, num, den))
+	for _, m := range tempo.Meters {
+		num, den := meter(m.BeatsPerBar)
+		tick := int64(math.Round(tempo.Bar(float64(m.Bar)) * float64(division)))
+		t.Events = append(t.Events, TimeSignatureEvent(tick, num, den))
+	}
+	for _, c := range tempo.Changes {
+		t.Events = append(t.Events, Event{
+			Tick:  int64(math.Round(c.Beat * float64(division))),
+			Type:  TEMPO,
+			Meta:  META_TEMPO,
+			Value: int(math.Round(60e6 / c.BPM)),
+		})
+	}
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].Tick < t.Events[j].Tick })
+	return t
+}
+
+// NoteTrack converts note events, timed in seconds, to the notes of a track on a channel
+func NoteTrack(name string, events []synth.NoteEvent, clock *Clock, channel int) Track {
+	t := Track{Name: name}
+	for _, e := range events {
+		velocity := int(math.Round(e.Velocity * 127))
+		if velocity < 1 {
+			velocity = 1
+		} else if velocity > 127 {
+			velocity = 127
+		}
+		start, end := clock.Tick(e.Time), clock.Tick(e.Time+e.Duration)
+		t.Events = append(t.Events,
+			Event{Tick: start, Type: NOTE_ON, Channel: channel, Note: e.Note, Velocity: velocity},
+			Event{Tick: end, Type: NOTE_OFF, Channel: channel, Note: e.Note, Velocity: 64})
+	}
+	// at the same tick, note-offs go first so that repeated notes are retriggered
+	sort.SliceStable(t.Events, func(i, j int) bool {
+		if t.Events[i].Tick != t.Events[j].Tick {
+			return t.Events[i].Tick < t.Events[j].Tick
+		}
+		return t.Events[i].Type == NOTE_OFF && t.Events[j].Type == NOTE_ON
+	})
+	return t
+}
+
+// FromNoteEvents creates a format 1 file with a conductor track for the tempo map and a track
+// with the notes, such as the events of a sequencer. A nil tempo map plays at 120 BPM in 4/4.
+func FromNoteEvents(events []synth.NoteEvent, tempo *breakpoint.TempoMap, division, channel int) (*File, error) {
+	if division < 1 || division > 0x7FFF {
+		return nil, errors.New("Division should be between 1 and 32767 ticks per quarter note")
+	}
+	if channel < 0 || channel > 15 {
+		return nil, errors.New("Channel should be between 0 and 15")
+	}
+	if tempo == nil {
+		var err error
+		if tempo, err = breakpoint.NewTempoMap(120); err != nil {
+			return nil, err
+		}
+	}
+	clock := &Clock{Tempo: tempo, Division: division}
+	return &File{
+		Format:   1,
+		Division: division,
+		Tracks:   []Track{ConductorTrack(tempo, division), NoteTrack("Notes", events, clock, channel)},
+	}, nil
+}
@@ -0,0 +1,134 @@
+package midi
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	tempo, err := breakpoint.NewTempoMap(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tempo.SetTempo(8, 150); err != nil {
+		t.Fatal(err)
+	}
+	if err := tempo.SetMeter(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := tempo.SetMeter(4, 3.5); err != nil {
+		t.Fatal(err)
+	}
+	events := []synth.NoteEvent{
+		{Time: 0, Duration: tempo.Seconds(1), Note: 60, Velocity: 1},
+		{Time: tempo.Seconds(1), Duration: tempo.Seconds(2) - tempo.Seconds(1), Note: 60, Velocity: .5},
+		{Time: tempo.Seconds(9), Duration: tempo.Seconds(10) - tempo.Seconds(9), Note: 67, Velocity: .25},
+	}
+	f, err := FromNoteEvents(events, tempo, 480, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := Write(buf, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != 1 || got.Division != 480 || len(got.Tracks) != 2 || got.Tracks[1].Name != "Notes" {
+		t.Fatalf("Unexpected file %+v", got)
+	}
+
+	clock, err := got.Clock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.Tempo.Changes) != 2 || clock.Tempo.Changes[1] != (breakpoint.TempoChange{Beat: 8, BPM: 150}) {
+		t.Fatalf("Unexpected tempo changes %+v", clock.Tempo.Changes)
+	}
+	meters := []breakpoint.MeterChange{{Bar: 2, BeatsPerBar: 3}, {Bar: 4, BeatsPerBar: 3.5}}
+	if len(clock.Tempo.Meters) != len(meters) {
+		t.Fatalf("Expected meters %+v, got %+v", meters, clock.Tempo.Meters)
+	}
+	for i := range meters {
+		if clock.Tempo.Meters[i] != meters[i] {
+			t.Fatalf("Expected meters %+v, got %+v", meters, clock.Tempo.Meters)
+		}
+	}
+
+	notes := got.Tracks[1].Events[1:] // after the track name
+	expected := []struct {
+		tick     int64
+		typ      EventType
+		note     int
+		velocity int
+	}{
+		{0, NOTE_ON, 60, 127},
+		{480, NOTE_OFF, 60, 64},
+		{480, NOTE_ON, 60, 64},
+		{960, NOTE_OFF, 60, 64},
+		{4320, NOTE_ON, 67, 32},
+		{4800, NOTE_OFF, 67, 64},
+	}
+	if len(notes) != len(expected) {
+		t.Fatalf("Expected %v events, got %+v", len(expected), notes)
+	}
+	for i, e := range expected {
+		n := notes[i]
+		if n.Tick != e.tick || n.Type != e.typ || n.Note != e.note || n.Velocity != e.velocity || n.Channel != 3 {
+			t.Fatalf("Event %v: expected %+v, got %+v", i, e, n)
+		}
+		if i == 4 && math.Abs(clock.Seconds(n.Tick)-events[2].Time) > 1e-6 {
+			t.Fatalf("Expected the note at %v, got %v", events[2].Time, clock.Seconds(n.Tick))
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	track := Track{Events: []Event{
+		{Tick: 0, Type: PROGRAM_CHANGE, Channel: 1, Value: 5},
+		{Tick: 0, Type: CONTROL_CHANGE, Channel: 1, Controller: CC_SUSTAIN, Value: 127},
+		{Tick: 10, Type: PITCH_BEND, Channel: 1, Value: -8192},
+		{Tick: 20, Type: PITCH_BEND, Channel: 1, Value: 8191},
+		{Tick: 30, Type: SYSEX, Data: []byte{0x7E, 0xF7}},
+		{Tick: 40, Type: META, Meta: META_MARKER, Data: []byte("A")},
+	}}
+	buf := &bytes.Buffer{}
+	if err := Write(buf, &File{Division: 96, Tracks: []Track{track}}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := f.Tracks[0].Events
+	if len(got) != len(track.Events) {
+		t.Fatalf("Expected %v events, got %+v", len(track.Events), got)
+	}
+	for i, e := range track.Events {
+		g := got[i]
+		if g.Tick != e.Tick || g.Type != e.Type || g.Channel != e.Channel || g.Value != e.Value ||
+			g.Controller != e.Controller || !bytes.Equal(g.Data, e.Data) {
+			t.Fatalf("Event %v: expected %+v, got %+v", i, e, g)
+		}
+	}
+
+	for _, e := range []Event{
+		{Type: PITCH_BEND, Value: 8192},
+		{Type: NOTE_ON, Channel: 16},
+		{Type: NOTE_ON, Note: 128},
+		{Tick: -1, Type: NOTE_ON},
+	} {
+		t.Run("", func(t *testing.T) {
+			err := Write(&bytes.Buffer{}, &File{Division: 96, Tracks: []Track{{Events: []Event{e}}}})
+			if err == nil {
+				t.Fatalf("Expected an error writing %+v", e)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Package osc sends and receives Open Sound Control messages, and maps their addresses to the
+// parameters of processors and synths, so they can be controlled from apps such as TouchOSC,
+// Max or SuperCollider.
+package osc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Message is an OSC message. The arguments are int32, float32, string, []byte, int64,
+// float64 or bool values.
+type Message struct {
+	Address string
+	Args    []interface{}
+}
+
+// Float returns an argument as a number, for the numeric and boolean types
+func (m Message) Float(i int) (float64, error) {
+	if i < 0 || i >= len(m.Args) {
+		return 0, fmt.Errorf("%v has no argument %v", m.Address, i)
+	}
+	switch v := m.Args[i].(type) {
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("Argument %v of %v is not a number", i, m.Address)
+}
+
+// appendString appends a string padded with zeros to a multiple of 4 bytes
+func appendString(b []byte, s string) []byte {
+	b = append(b, s...)
+	return append(b, make([]byte, 4-len(s)%4)...)
+}
+
+// Bytes encodes the message as an OSC packet
+func (m Message) Bytes() ([]byte, error) {
+	if !strings.HasPrefix(m.Address, "/") {
+		return nil, errors.New("An address should start with /")
+	}
+	tags := []byte{','}
+	args := []byte{}
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags = append(tags, 'i')
+			args = binary.BigEndian.AppendUint32(args, uint32(v))
+		case float32:
+			tags = append(tags, 'f')
+			args = binary.BigEndian.AppendUint32(args, math.Float32bits(v))
+		case string:
+			tags = append(tags, 's')
+			args = appendString(args, v)
+		case []byte:
+			tags = append(tags, 'b')
+			args = binary.BigEndian.AppendUint32(args, uint32(len(v)))
+			args = append(args, v...)
+			args = append(args, make([]byte, (4-len(v)%4)%4)...)
+		case int64:
+			tags = append(tags, 'h')
+			args = binary.BigEndian.AppendUint64(args, uint64(v))
+		case float64:
+			tags = append(tags, 'd')
+			args = binary.BigEndian.AppendUint64(args, math.Float64bits(v))
+		case bool:
+			if v {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		default:
+			return nil, fmt.Errorf("Can't send an argument of type %T", arg)
+		}
+	}
+	b := appendString(nil, m.Address)
+	b = appendString(b, string(tags))
+	return append(b, args...), nil
+}
+
+// Bundle encodes messages as an OSC bundle to be handled immediately
+func Bundle(messages ...Message) ([]byte, error) {
+	b := appendString(nil, "#bundle")
+	b = binary.BigEndian.AppendUint64(b, 1)
+	for _, m := range messages {
+		e, err := m.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(e)))
+		b = append(b, e...)
+	}
+	return b, nil
+}
+
+// readString reads a padded string
+func readString(b []byte) (string, []byte, error) {
+	end := strings.IndexByte(string(b), 0)
+	if end < 0 {
+		return "", nil, errors.New("String without an end")
+	}
+	size := end + 4 - end%4
+	if size > len(b) {
+		return "", nil, errors.New("String without padding")
+	}
+	return string(b[:end]), b[size:], nil
+}
+
+// Parse decodes an OSC packet into its messages. The messages of bundles are returned in
+// order, their time tags are ignored.
+func Parse(packet []byte) ([]Message, error) {
+	if len(packet)%4 != 0 {
+		return nil, errors.New("OSC packets are a multiple of 4 bytes")
+	}
+	if strings.HasPrefix(string(packet), "#bundle\x00") {
+		if len(packet) < 16 {
+			return nil, errors.New("Bundle without a time tag")
+		}
+		messages := []Message{}
+		for b := packet[16:]; len(b) > 0; {
+			if len(b) < 4 {
+				return nil, errors.New("Bundle element without a size")
+			}
+			size := int(binary.BigEndian.Uint32(b))
+			if size > len(b)-4 {
+				return nil, errors.New("Bundle element larger than the bundle")
+			}
+			inner, err := Parse(b[4 : 4+size])
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, inner...)
+			b = b[4+size:]
+		}
+		return messages, nil
+	}
+	m, err := parseMessage(packet)
+	if err != nil {
+		return nil, err
+	}
+	return []Message{m}, nil
+}
+
+func parseMessage(b []byte) (Message, error) {
+	address, b, err := readString(b)
+	if err != nil {
+		return Message{}, err
+	}
+	if !strings.HasPrefix(address, "/") {
+		return Message{}, errors.New("An address should start with /")
+	}
+	m := Message{Address: address}
+	if len(b) == 0 {
+		// old implementations leave out the type tags of messages without arguments
+		return m, nil
+	}
+	tags, b, err := readString(b)
+	if err != nil {
+		return Message{}, err
+	}
+	if !strings.HasPrefix(tags, ",") {
+		return Message{}, errors.New("Message without type tags")
+	}
+	need := func(n int) error {
+		if len(b) < n {
+			return fmt.Errorf("%v is missing arguments", address)
+		}
+		return nil
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i', 'f', 'b':
+			if err := need(4); err != nil {
+				return Message{}, err
+			}
+			v := binary.BigEndian.Uint32(b)
+			b = b[4:]
+			switch tag {
+			case 'i':
+				m.Args = append(m.Args, int32(v))
+			case 'f':
+				m.Args = append(m.Args, math.Float32frombits(v))
+			case 'b':
+				size := int(v) + (4-int(v)%4)%4
+				if err := need(size); err != nil {
+					return Message{}, err
+				}
+				m.Args = append(m.Args, append([]byte{}, b[:v]...))
+				b = b[size:]
+			}
+		case 'h', 'd':
+			if err := need(8); err != nil {
+				return Message{}, err
+			}
+			v := binary.BigEndian.Uint64(b)
+			b = b[8:]
+			if tag == 'h' {
+				m.Args = append(m.Args, int64(v))
+			} else {
+				m.Args = append(m.Args, math.Float64frombits(v))
+			}
+		case 's':
+			var s string
+			if s, b, err = readString(b); err != nil {
+				return Message{}, err
+			}
+			m.Args = append(m.Args, s)
+		case 'T', 'F':
+			m.Args = append(m.Args, tag == 'T')
+		default:
+			return Message{}, fmt.Errorf("Unsupported argument type %c", tag)
+		}
+	}
+	return m, nil
+}
+
+// Match returns true when an address matches an OSC address pattern, in which ? matches a
+// character, * any characters, [a-c] and [!a-c] a set of characters and {foo,bar} one of
+// several strings. Wildcards don't match the / between the parts of an address.
+func Match(pattern, address string) bool {
+	p := strings.Split(pattern, "/")
+	a := strings.Split(address, "/")
+	if len(p) != len(a) {
+		return false
+	}
+	for i := range p {
+		if !matchPart(p[i], a[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPart matches a part of an address
+func matchPart(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchPart(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			end := strings.IndexByte(pattern, ']')
+			if end < 0 || len(s) == 0 || !matchSet(pattern[1:end], s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+		case '{':
+			end := strings.IndexByte(pattern, '}')
+			if end < 0 {
+				return false
+			}
+			for _, alt := range strings.Split(pattern[1:end], ",") {
+				if strings.HasPrefix(s, alt) && matchPart(pattern[end+1:], s[len(alt):]) {
+					return true
+				}
+			}
+			return false
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchSet matches a character against the inside of brackets
+func matchSet(set string, c byte) bool {
+	negate := strings.HasPrefix(set, "!")
+	if negate {
+		set = set[1:]
+	}
+	for i := 0; i < len(set); i++ {
+		if i+2 < len(set) && set[i+1] == '-' {
+			if set[i] <= c && c <= set[i+2] {
+				return !negate
+			}
+			i += 2
+		} else if set[i] == c {
+			return !negate
+		}
+	}
+	return negate
+}
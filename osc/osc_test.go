@@ -0,0 +1,101 @@
+package osc
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMessageBytes(t *testing.T) {
+	// the example of the OSC 1.0 specification
+	b, err := Message{Address: "/oscillator/4/frequency", Args: []interface{}{float32(440)}}.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte("/oscillator/4/frequency\x00,f\x00\x00\x43\xdc\x00\x00")
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("Expected %q, got %q", expected, b)
+	}
+	if _, err := (Message{Address: "foo"}).Bytes(); err == nil {
+		t.Fatal("Expected an error for an address without /")
+	}
+	if _, err := (Message{Address: "/foo", Args: []interface{}{1}}).Bytes(); err == nil {
+		t.Fatal("Expected an error for an int, which has no OSC type")
+	}
+}
+
+func TestParse(t *testing.T) {
+	messages := []Message{
+		{Address: "/a", Args: []interface{}{int32(-3), float32(.5), "hello", []byte{1, 2, 3, 4, 5}}},
+		{Address: "/b/c", Args: []interface{}{int64(1) << 40, 2.5, true, false}},
+		{Address: "/none"},
+	}
+	for _, m := range messages {
+		t.Run("", func(t *testing.T) {
+			b, err := m.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := Parse(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed) != 1 || !reflect.DeepEqual(parsed[0], m) {
+				t.Fatalf("Expected %+v, got %+v", m, parsed)
+			}
+		})
+	}
+
+	bundle, err := Bundle(messages...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, messages) {
+		t.Fatalf("Expected the messages of the bundle, got %+v", parsed)
+	}
+
+	invalid := [][]byte{
+		[]byte("/a\x00"),
+		[]byte("/abc"),
+		[]byte("abc\x00"),
+		[]byte("/a\x00\x00,i\x00\x00"),
+		[]byte("/a\x00\x00,x\x00\x00"),
+		[]byte("#bundle\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x08"),
+	}
+	for _, b := range invalid {
+		if _, err := Parse(b); err == nil {
+			t.Fatalf("Expected an error for %q", b)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, address string
+		match            bool
+	}{
+		{"/synth/cutoff", "/synth/cutoff", true},
+		{"/synth/cutoff", "/synth/cutof", false},
+		{"/synth/*", "/synth/cutoff", true},
+		{"/*", "/synth/cutoff", false},
+		{"/synth/c*f", "/synth/cutoff", true},
+		{"/fader?", "/fader1", true},
+		{"/fader?", "/fader12", false},
+		{"/fader[1-3]", "/fader2", true},
+		{"/fader[!1-3]", "/fader2", false},
+		{"/fader[!1-3]", "/fader4", true},
+		{"/{synth,drums}/level", "/drums/level", true},
+		{"/{synth,drums}/level", "/bass/level", false},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if Match(test.pattern, test.address) != test.match {
+				t.Fatalf("Expected %v matching %v to be %v", test.pattern, test.address, test.match)
+			}
+		})
+	}
+}
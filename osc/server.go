@@ -0,0 +1,206 @@
+package osc
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPacket is the largest UDP packet a server reads
+const maxPacket = 65536
+
+// Handler handles a message sent to an address
+type Handler func(m Message)
+
+// route is a handler of an address
+type route struct {
+	address string
+	handler Handler
+}
+
+// Server dispatches the messages it receives to the handlers of their addresses. The address
+// of a message is a pattern, it is handled by every route with an address which matches it.
+type Server struct {
+	mu     sync.Mutex
+	routes []route
+}
+
+// NewServer creates a server without routes
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Handle adds a handler for the messages to an address. Handlers are called on the goroutine
+// serving the connection, they should be quick and safe to run alongside the audio.
+func (s *Server) Handle(address string, h Handler) error {
+	if !strings.HasPrefix(address, "/") || strings.ContainsAny(address, "*?[]{}, #") {
+		return errors.New("An address should start with / and have no pattern characters")
+	}
+	if h == nil {
+		return errors.New("Need a handler")
+	}
+	s.mu.Lock()
+	s.routes = append(s.routes, route{address, h})
+	s.mu.Unlock()
+	return nil
+}
+
+// Bind lets a control follow the first argument of the messages to an address
+func (s *Server) Bind(address string, c *Control) error {
+	if c == nil {
+		return errors.New("Need a control")
+	}
+	return s.Handle(address, func(m Message) {
+		if v, err := m.Float(0); err == nil {
+			c.Set(v)
+		}
+	})
+}
+
+// Dispatch handles the messages of a packet
+func (s *Server) Dispatch(packet []byte) error {
+	messages, err := Parse(packet)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	routes := s.routes
+	s.mu.Unlock()
+	for _, m := range messages {
+		for _, r := range routes {
+			if Match(m.Address, r.address) {
+				r.handler(m)
+			}
+		}
+	}
+	return nil
+}
+
+// Serve handles the packets read from a connection until reading fails. Invalid packets
+// are skipped.
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, maxPacket)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		s.Dispatch(buf[:n])
+	}
+}
+
+// ListenAndServe serves the packets sent to a UDP address, such as ":8000"
+func (s *Server) ListenAndServe(address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return s.Serve(conn)
+}
+
+// Client sends messages, usually over a connected UDP socket
+type Client struct {
+	w io.Writer
+}
+
+// NewClient creates a client writing a packet per call to w
+func NewClient(w io.Writer) *Client {
+	return &Client{w: w}
+}
+
+// Dial creates a client sending to a UDP address, such as "localhost:9000"
+func Dial(address string) (*Client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// Send sends a message to an address
+func (c *Client) Send(address string, args ...interface{}) error {
+	b, err := Message{Address: address, Args: args}.Bytes()
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(b)
+	return err
+}
+
+// SendBundle sends messages in a bundle, so they are handled together
+func (c *Client) SendBundle(messages ...Message) error {
+	b, err := Bundle(messages...)
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(b)
+	return err
+}
+
+// Close closes the writer of the client when it can be closed
+func (c *Client) Close() error {
+	if closer, ok := c.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Control is a modulation source following a value sent over OSC. It can be bound to a
+// parameter of an Automated processor or connected to those of voices in a ModMatrix.
+// Values are sent in the range [0;1], like the faders of OSC apps, and mapped to [Min;Max].
+type Control struct {
+	Min, Max    float64
+	Exponential bool    // map along a logarithmic scale, for frequencies, Min and Max should be above 0
+	Smoothing   float64 // time in seconds to follow a change, so changes don't click
+
+	sr     float64
+	target uint64 // bits of the value set, which may be set on another goroutine
+	value  float64
+	coef   float64
+	from   float64 // Smoothing of the coefficient
+}
+
+// NewControl creates a control for a sample rate, starting at a value in the range [0;1]
+func NewControl(sr int, min, max, initial float64) (*Control, error) {
+	if sr < 1 {
+		return nil, errors.New("Need a sample rate")
+	}
+	c := &Control{Min: min, Max: max, Smoothing: .02, sr: float64(sr)}
+	c.Set(initial)
+	c.value = c.Value()
+	return c, nil
+}
+
+// Set sets the value in the range [0;1], it is safe to call while the control is ticked
+func (c *Control) Set(v float64) {
+	v = math.Max(0, math.Min(1, v))
+	atomic.StoreUint64(&c.target, math.Float64bits(v))
+}
+
+// Value returns the value last set, in the range [0;1]
+func (c *Control) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.target))
+}
+
+// Tick moves towards the value set and returns it mapped to [Min;Max]
+func (c *Control) Tick() float64 {
+	target := c.Value()
+	if c.Smoothing <= 0 {
+		c.value = target
+	} else {
+		if c.from != c.Smoothing {
+			c.from = c.Smoothing
+			c.coef = math.Exp(-1 / (c.Smoothing * c.sr))
+		}
+		c.value = target + (c.value-target)*c.coef
+	}
+	if c.Exponential {
+		return c.Min * math.Pow(c.Max/c.Min, c.value)
+	}
+	return c.Min + (c.Max-c.Min)*c.value
+}
@@ -0,0 +1,104 @@
+package osc
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestDispatch(t *testing.T) {
+	s := NewServer()
+	got := map[string]float64{}
+	for _, address := range []string{"/mixer/1/level", "/mixer/2/level", "/mixer/1/pan"} {
+		address := address
+		if err := s.Handle(address, func(m Message) {
+			got[address], _ = m.Float(0)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, _ := Message{Address: "/mixer/*/level", Args: []interface{}{int32(1)}}.Bytes()
+	if err := s.Dispatch(b); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["/mixer/1/level"] != 1 || got["/mixer/2/level"] != 1 {
+		t.Fatalf("Expected the pattern to reach both levels, got %v", got)
+	}
+	if err := s.Handle("/mixer/*", func(Message) {}); err == nil {
+		t.Fatal("Expected an error for a route with a pattern")
+	}
+	if err := s.Dispatch([]byte("/bad")); err == nil {
+		t.Fatal("Expected an error for an invalid packet")
+	}
+}
+
+func TestControl(t *testing.T) {
+	c, err := NewControl(1000, 100, 10000, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Exponential = true
+	if v := c.Tick(); v != 100 {
+		t.Fatalf("Expected the minimum, got %v", v)
+	}
+	c.Set(.5)
+	if v := c.Tick(); v <= 100 || v >= 1000 {
+		t.Fatalf("Expected the control to move smoothly, got %v", v)
+	}
+	for i := 0; i < 200; i++ {
+		c.Tick()
+	}
+	if v := c.Tick(); math.Abs(v-1000) > 1 {
+		t.Fatalf("Expected the middle of the logarithmic scale, got %v", v)
+	}
+	c.Smoothing = 0
+	c.Set(2)
+	if v := c.Tick(); v != 10000 {
+		t.Fatalf("Expected the value to be clamped to the maximum, got %v", v)
+	}
+}
+
+func TestServe(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("No UDP on the loopback: %v", err)
+	}
+	defer conn.Close()
+
+	voice, err := synth.NewVoice(1000, synth.SINE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cutoff, _ := NewControl(1000, 0, 4000, 0)
+	cutoff.Smoothing = 0
+	matrix := synth.NewModMatrix()
+	if err := matrix.Connect(cutoff, voice, "cutoff", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer()
+	if err := s.Bind("/synth/cutoff", cutoff); err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(conn)
+
+	c, err := Dial(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Send("/synth/cutoff", float32(.5)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for cutoff.Value() != .5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	matrix.Tick()
+	if voice.Cutoff != 2000 {
+		t.Fatalf("Expected the message to set the cutoff, got %v", voice.Cutoff)
+	}
+}
@@ -0,0 +1,424 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64
+
+package playback
+
+// ALSA backend talking to the kernel directly, so it works without cgo or alsa-lib.
+// Without alsa-lib there are no plugins: the device has to support 16-bit samples at
+// the requested rate and channels, and it can't be shared with a running sound server.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(alsa{})
+}
+
+// constants of <sound/asound.h>
+const (
+	alsaAccessRWInterleaved = 3
+	alsaFormatS16LE         = 2
+	alsaSubformatStd        = 0
+
+	alsaParamAccess     = 0
+	alsaParamFormat     = 1
+	alsaParamSubformat  = 2
+	alsaParamFirstRange = 8 // parameters from here on are intervals rather than masks
+	alsaParamChannels   = 10
+	alsaParamRate       = 11
+	alsaParamPeriodSize = 13
+	alsaParamPeriods    = 15
+	alsaParamBufferSize = 17
+	alsaParamLast       = 19
+
+	alsaIntervalInteger = 1 << 2
+
+	alsaPeriods = 4 // periods in the buffer of the device
+)
+
+// the sizes of the kernel structures depend on the size of a long
+const wordSize = int(unsafe.Sizeof(uintptr(0)))
+
+var (
+	hwParamsSize = 536 + wordSize + 64
+	swAvailMin   = 12 + (wordSize-12%wordSize)%wordSize // offset of the first long
+	swParamsSize = swAvailMin + 7*wordSize + 8 + 56
+
+	ioctlHWRefine = alsaIoctl(3, 0x10, hwParamsSize)
+	ioctlHWParams = alsaIoctl(3, 0x11, hwParamsSize)
+	ioctlSWParams = alsaIoctl(3, 0x13, swParamsSize)
+	ioctlPrepare  = alsaIoctl(0, 0x40, 0)
+	ioctlDrop     = alsaIoctl(0, 0x43, 0)
+	ioctlDrain    = alsaIoctl(0, 0x44, 0)
+	ioctlWriteI   = alsaIoctl(1, 0x50, 3*wordSize)
+	ioctlReadI    = alsaIoctl(2, 0x51, 3*wordSize)
+)
+
+// alsaIoctl encodes an ioctl request of the sound subsystem, dir 1 writes and 2 reads
+func alsaIoctl(dir, nr uintptr, size int) uintptr {
+	return dir<<30 | uintptr(size)<<16 | 'A'<<8 | nr
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// alsaXfer is struct snd_xferi, which transfers interleaved frames
+type alsaXfer struct {
+	result int
+	buf    unsafe.Pointer
+	frames uintptr
+}
+
+// hwParams is struct snd_pcm_hw_params
+type hwParams []byte
+
+// newHWParams allows any configuration, which is narrowed down by setting parameters
+func newHWParams() hwParams {
+	p := make(hwParams, hwParamsSize)
+	for i := 4; i < 4+(alsaParamSubformat+1)*32; i++ {
+		p[i] = 0xff
+	}
+	for param := alsaParamFirstRange; param <= alsaParamLast; param++ {
+		binary.LittleEndian.PutUint32(p[p.interval(param)+4:], math.MaxUint32)
+	}
+	binary.LittleEndian.PutUint32(p[512:], math.MaxUint32) // rmask
+	binary.LittleEndian.PutUint32(p[520:], math.MaxUint32) // info
+	return p
+}
+
+// setMask allows only a single value of a mask parameter
+func (p hwParams) setMask(param, value int) {
+	off := 4 + param*32
+	for i := 0; i < 32; i++ {
+		p[off+i] = 0
+	}
+	p[off+value/8] = 1 << (value % 8)
+}
+
+// interval returns the offset of an interval parameter
+func (p hwParams) interval(param int) int {
+	return 4 + 8*32 + (param-alsaParamFirstRange)*12
+}
+
+// setInterval restricts an interval parameter to integers in [min;max]
+func (p hwParams) setInterval(param int, min, max uint32) {
+	off := p.interval(param)
+	binary.LittleEndian.PutUint32(p[off:], min)
+	binary.LittleEndian.PutUint32(p[off+4:], max)
+	binary.LittleEndian.PutUint32(p[off+8:], alsaIntervalInteger)
+}
+
+// value returns the lower bound of an interval, which is the value once the parameters are set
+func (p hwParams) value(param int) uint32 {
+	return binary.LittleEndian.Uint32(p[p.interval(param):])
+}
+
+// max returns the upper bound of an interval
+func (p hwParams) max(param int) uint32 {
+	return binary.LittleEndian.Uint32(p[p.interval(param)+4:])
+}
+
+// swParams returns struct snd_pcm_sw_params for a stream with the given buffer size
+func swParams(period, buffer uint64, capture bool) []byte {
+	p := make([]byte, swParamsSize)
+	long := func(i int, v uint64) {
+		off := swAvailMin + i*wordSize
+		if wordSize == 8 {
+			binary.LittleEndian.PutUint64(p[off:], v)
+		} else {
+			binary.LittleEndian.PutUint32(p[off:], uint32(v))
+		}
+	}
+	start := buffer
+	if capture {
+		start = 1
+	}
+	// the boundary is the largest multiple of the buffer which fits in a long
+	boundary := buffer
+	max := uint64(1)<<(8*wordSize-1) - 1
+	for boundary*2 <= max-buffer {
+		boundary *= 2
+	}
+
+	binary.LittleEndian.PutUint32(p[4:], 1) // period_step
+	long(0, period)                         // avail_min
+	long(1, 1)                              // xfer_align
+	long(2, start)                          // start_threshold
+	long(3, buffer)                         // stop_threshold
+	long(6, boundary)                       // boundary
+	return p
+}
+
+// alsaDevice returns the path of a device named hw:card,device, the default is hw:0,0
+func alsaDevice(name string, capture bool) (string, error) {
+	card, dev := 0, 0
+	if name != "" {
+		n, _ := fmt.Sscanf(name, "hw:%d,%d", &card, &dev)
+		if n == 0 {
+			return "", fmt.Errorf("Device %v should be named hw:card,device", name)
+		}
+	}
+	kind := 'p'
+	if capture {
+		kind = 'c'
+	}
+	return fmt.Sprintf("/dev/snd/pcmC%dD%d%c", card, dev, kind), nil
+}
+
+// alsa plays and records through the ALSA devices of the kernel
+type alsa struct{}
+
+func (alsa) Name() string {
+	return "alsa"
+}
+
+func (alsa) OpenOutput(cfg Config) (Output, error) {
+	return openALSA(cfg, false)
+}
+
+func (alsa) OpenInput(cfg Config) (Input, error) {
+	return openALSA(cfg, true)
+}
+
+// Devices lists the PCM devices of all sound cards, devices which are in use are listed
+// without their channels and sample rates
+func (alsa) Devices() ([]Device, error) {
+	data, err := ioutil.ReadFile("/proc/asound/pcm")
+	if os.IsNotExist(err) {
+		// no sound cards
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	devices := []Device{}
+	for _, pcm := range parseALSAPCMs(string(data)) {
+		d := pcm.Device
+		if pcm.playback {
+			d.OutputChannels, d.SampleRates = alsaRefine(d.Name, false)
+		}
+		if pcm.capture {
+			var rates []int
+			d.InputChannels, rates = alsaRefine(d.Name, true)
+			if len(d.SampleRates) == 0 {
+				d.SampleRates = rates
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// alsaPCM is a line of /proc/asound/pcm
+type alsaPCM struct {
+	Device
+	playback, capture bool
+}
+
+// parseALSAPCMs parses /proc/asound/pcm, which lists a device per line as
+// "00-00: id : name : playback 1 : capture 1"
+func parseALSAPCMs(list string) []alsaPCM {
+	pcms := []alsaPCM{}
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+		var card, dev int
+		if n, _ := fmt.Sscanf(fields[0], "%d-%d", &card, &dev); n != 2 {
+			continue
+		}
+		pcm := alsaPCM{Device: Device{
+			Backend:     "alsa",
+			Name:        fmt.Sprintf("hw:%d,%d", card, dev),
+			Description: strings.TrimSpace(fields[2]),
+		}}
+		for _, f := range fields[3:] {
+			f = strings.TrimSpace(f)
+			pcm.playback = pcm.playback || strings.HasPrefix(f, "playback")
+			pcm.capture = pcm.capture || strings.HasPrefix(f, "capture")
+		}
+		pcm.DefaultOutput = card == 0 && dev == 0 && pcm.playback
+		pcm.DefaultInput = card == 0 && dev == 0 && pcm.capture
+		pcms = append(pcms, pcm)
+	}
+	return pcms
+}
+
+// alsaRefine asks a device for its maximum amount of channels and the sample rates it supports
+func alsaRefine(name string, capture bool) (int, []int) {
+	path, err := alsaDevice(name, capture)
+	if err != nil {
+		return 0, nil
+	}
+	// don't wait for devices which are in use
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return 0, nil
+	}
+	defer f.Close()
+	hw := newHWParams()
+	hw.setMask(alsaParamFormat, alsaFormatS16LE)
+	if err := ioctl(f.Fd(), ioctlHWRefine, unsafe.Pointer(&hw[0])); err != nil {
+		return 0, nil
+	}
+	return int(hw.max(alsaParamChannels)), ratesBetween(int(hw.value(alsaParamRate)), int(hw.max(alsaParamRate)))
+}
+
+// alsaStream is an open ALSA device
+type alsaStream struct {
+	xruns    uint64 // accessed atomically, kept first for alignment on 32-bit systems
+	f        *os.File
+	channels int
+	capture  bool
+	latency  time.Duration
+	buf      []int16
+}
+
+func openALSA(cfg Config, capture bool) (*alsaStream, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	path, err := alsaDevice(cfg.Device, capture)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	block := cfg.BlockSize
+	if block == 0 {
+		block = defaultBlockSize
+	}
+
+	hw := newHWParams()
+	hw.setMask(alsaParamAccess, alsaAccessRWInterleaved)
+	hw.setMask(alsaParamFormat, alsaFormatS16LE)
+	hw.setMask(alsaParamSubformat, alsaSubformatStd)
+	hw.setInterval(alsaParamChannels, uint32(cfg.Channels), uint32(cfg.Channels))
+	hw.setInterval(alsaParamRate, uint32(cfg.SampleRate), uint32(cfg.SampleRate))
+	hw.setInterval(alsaParamPeriodSize, uint32(block), uint32(block))
+	hw.setInterval(alsaParamPeriods, alsaPeriods, alsaPeriods)
+	if err := ioctl(f.Fd(), ioctlHWParams, unsafe.Pointer(&hw[0])); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Device does not support %v channels of 16-bit samples at %vHz with blocks of %v: %v",
+			cfg.Channels, cfg.SampleRate, block, err)
+	}
+
+	period, buffer := uint64(hw.value(alsaParamPeriodSize)), uint64(hw.value(alsaParamBufferSize))
+	sw := swParams(period, buffer, capture)
+	if err := ioctl(f.Fd(), ioctlSWParams, unsafe.Pointer(&sw[0])); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := ioctl(f.Fd(), ioctlPrepare, nil); err != nil {
+		f.Close()
+		return nil, err
+	}
+	// an output plays its whole buffer before a new frame, an input hands over every period
+	latency := time.Duration(buffer) * time.Second / time.Duration(cfg.SampleRate)
+	if capture {
+		latency = time.Duration(period) * time.Second / time.Duration(cfg.SampleRate)
+	}
+	return &alsaStream{f: f, channels: cfg.Channels, capture: capture, latency: latency}, nil
+}
+
+// transfer moves the frames in buf to or from the device, recovering from xruns
+func (s *alsaStream) transfer(req uintptr) error {
+	total := len(s.buf) / s.channels
+	for done := 0; done < total; {
+		x := alsaXfer{
+			buf:    unsafe.Pointer(&s.buf[done*s.channels]),
+			frames: uintptr(total - done),
+		}
+		err := ioctl(s.f.Fd(), req, unsafe.Pointer(&x))
+		runtime.KeepAlive(s.buf)
+		switch err {
+		case nil:
+			done += x.result
+		case syscall.EINTR:
+			// interrupted by a signal, try again
+		case syscall.EPIPE:
+			// an underrun or overrun stops the device until it is prepared again
+			atomic.AddUint64(&s.xruns, 1)
+			if err := ioctl(s.f.Fd(), ioctlPrepare, nil); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *alsaStream) Write(frames []wave.Frame) error {
+	n := len(frames) / s.channels * s.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(s.buf) < n {
+		s.buf = make([]int16, n)
+	}
+	s.buf = s.buf[:n]
+	wave.FramesToInt16(s.buf, frames)
+	return s.transfer(ioctlWriteI)
+}
+
+func (s *alsaStream) Read(frames []wave.Frame) error {
+	n := len(frames) / s.channels * s.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(s.buf) < n {
+		s.buf = make([]int16, n)
+	}
+	s.buf = s.buf[:n]
+	if err := s.transfer(ioctlReadI); err != nil {
+		return err
+	}
+	wave.Int16ToFrames(frames, s.buf)
+	return nil
+}
+
+// Latency returns the time the frames spend in the buffer of the device
+func (s *alsaStream) Latency() time.Duration {
+	return s.latency
+}
+
+// Xruns returns how often the device stopped on an underrun or overrun
+func (s *alsaStream) Xruns() uint64 {
+	return atomic.LoadUint64(&s.xruns)
+}
+
+// Close plays the frames still in the buffer of an output and closes the device
+func (s *alsaStream) Close() error {
+	req := ioctlDrain
+	if s.capture {
+		req = ioctlDrop
+	}
+	err := ioctl(s.f.Fd(), req, nil)
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
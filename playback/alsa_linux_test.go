@@ -0,0 +1,91 @@
+//go:build linux && (386 || amd64 || arm || arm64 || riscv64)
+// +build linux
+// +build 386 amd64 arm arm64 riscv64
+
+package playback
+
+import (
+	"testing"
+)
+
+func TestALSAIoctls(t *testing.T) {
+	// the requests as defined by <sound/asound.h> on 64-bit systems
+	if wordSize != 8 {
+		t.Skip("Request numbers differ on 32-bit systems")
+	}
+	tests := []struct {
+		req, expected uintptr
+	}{
+		{ioctlHWRefine, 0xC2604110},
+		{ioctlHWParams, 0xC2604111},
+		{ioctlSWParams, 0xC0884113},
+		{ioctlPrepare, 0x4140},
+		{ioctlDrop, 0x4143},
+		{ioctlDrain, 0x4144},
+		{ioctlWriteI, 0x40184150},
+		{ioctlReadI, 0x80184151},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if test.req != test.expected {
+				t.Fatalf("Expected request %#x, got %#x", test.expected, test.req)
+			}
+		})
+	}
+}
+
+func TestALSAParams(t *testing.T) {
+	hw := newHWParams()
+	hw.setMask(alsaParamFormat, alsaFormatS16LE)
+	hw.setInterval(alsaParamRate, 44100, 48000)
+	if hw[4+32] != 1<<alsaFormatS16LE || hw[4+33] != 0 || hw[4] != 0xff {
+		t.Fatalf("Expected only the 16-bit format to be allowed, got %v", hw[4+32:4+64])
+	}
+	if v := hw.value(alsaParamRate); v != 44100 {
+		t.Fatalf("Expected a rate of 44100, got %v", v)
+	}
+	if v := hw.value(alsaParamChannels); v != 0 {
+		t.Fatalf("Expected any amount of channels, got a minimum of %v", v)
+	}
+}
+
+func TestALSADevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture bool
+		path    string
+	}{
+		{"", false, "/dev/snd/pcmC0D0p"},
+		{"hw:1,2", true, "/dev/snd/pcmC1D2c"},
+		{"hw:1", false, "/dev/snd/pcmC1D0p"},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			path, err := alsaDevice(test.name, test.capture)
+			if err != nil || path != test.path {
+				t.Fatalf("Expected %v, got %v (%v)", test.path, path, err)
+			}
+		})
+	}
+	if _, err := alsaDevice("default", false); err == nil {
+		t.Fatal("Expected an error for a device name alsa-lib would resolve")
+	}
+}
+
+func TestParseALSAPCMs(t *testing.T) {
+	list := `00-00: ALC892 Analog : ALC892 Analog : playback 1 : capture 1
+00-01: ALC892 Digital : ALC892 Digital : playback 1
+01-03: HDMI 0 : HDMI 0 : playback 1
+`
+	pcms := parseALSAPCMs(list)
+	if len(pcms) != 3 {
+		t.Fatalf("Expected 3 devices, got %v", len(pcms))
+	}
+	if p := pcms[0]; p.Name != "hw:0,0" || p.Description != "ALC892 Analog" || !p.playback || !p.capture ||
+		!p.DefaultOutput || !p.DefaultInput {
+		t.Fatalf("Unexpected first device %+v", p)
+	}
+	if p := pcms[2]; p.Name != "hw:1,3" || p.capture || p.DefaultOutput {
+		t.Fatalf("Unexpected last device %+v", p)
+	}
+}
@@ -0,0 +1,147 @@
+package playback
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Capture records blocks of a fixed size from an input device
+type Capture struct {
+	Config
+	// OnXrun, when set, is called from the capture goroutine with the total amount of xruns
+	// after every block during which the input had an xrun
+	OnXrun func(total uint64)
+
+	in    Input
+	clock int64 // samples per channel recorded
+	worker
+	xrunWatch
+}
+
+// NewCapture opens an input on the backend, which records blocks of cfg.BlockSize
+// frames per channel
+func NewCapture(b Backend, cfg Config) (*Capture, error) {
+	ib, ok := b.(InputBackend)
+	if !ok {
+		return nil, errors.New("Backend " + b.Name() + " does not support recording")
+	}
+	if cfg.BlockSize == 0 {
+		cfg.BlockSize = defaultBlockSize
+	}
+	in, err := ib.OpenInput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Capture{
+		Config: cfg,
+		in:     in,
+	}, nil
+}
+
+// Start passes every recorded block of interleaved frames to the callback on a separate
+// goroutine, until the callback returns false or Stop is called.
+// The block is reused, so the callback has to copy the frames it wants to keep.
+func (c *Capture) Start(cb func(in []wave.Frame) bool) error {
+	return c.start(func() error {
+		block := make([]wave.Frame, c.BlockSize*c.Channels)
+		for !c.stopping() {
+			if err := c.in.Read(block); err != nil {
+				return err
+			}
+			atomic.AddInt64(&c.clock, int64(c.BlockSize))
+			c.check(c.OnXrun, c.in)
+			if !cb(block) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Channel starts recording and sends a copy of every block on the returned channel, which
+// is closed when the capture stops. Blocks are dropped when the channel is full rather
+// than delaying the recording.
+func (c *Capture) Channel(buffer int) (<-chan []wave.Frame, error) {
+	ch := make(chan []wave.Frame, buffer)
+	err := c.Start(func(in []wave.Frame) bool {
+		select {
+		case ch <- append([]wave.Frame(nil), in...):
+		default:
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		<-c.done
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// Time returns the amount of samples per channel recorded
+func (c *Capture) Time() int64 {
+	return atomic.LoadInt64(&c.clock)
+}
+
+// Xruns returns the amount of xruns counted by the input
+func (c *Capture) Xruns() uint64 {
+	return StreamXruns(c.in)
+}
+
+// Latency returns the latency reported by the input, 0 when the backend doesn't know
+func (c *Capture) Latency() time.Duration {
+	return StreamLatency(c.in)
+}
+
+// Wait blocks until the callback ends the capture and closes it
+func (c *Capture) Wait() error {
+	return c.wait(c.in.Close)
+}
+
+// Stop ends the capture after the current block and closes it
+func (c *Capture) Stop() error {
+	return c.halt(c.in.Close)
+}
+
+// Record records duration seconds from the default input device of the preferred backend
+func Record(duration float64, wfmt wave.WaveFmt) ([]wave.Frame, error) {
+	b, err := DefaultBackend()
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCapture(b, Config{
+		SampleRate: wfmt.SampleRate,
+		Channels:   wfmt.NumChannels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	total := int(duration*float64(wfmt.SampleRate)) * wfmt.NumChannels
+	frames := make([]wave.Frame, 0, total)
+	c.Start(func(in []wave.Frame) bool {
+		n := total - len(frames)
+		if n > len(in) {
+			n = len(in)
+		}
+		frames = append(frames, in[:n]...)
+		return len(frames) < total
+	})
+	if err := c.Wait(); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// RecordToFile records duration seconds from the default input device to a wave file
+func RecordToFile(path string, duration float64, wfmt wave.WaveFmt) error {
+	frames, err := Record(duration, wfmt)
+	if err != nil {
+		return err
+	}
+	return wave.WriteFrames(frames, wfmt, path)
+}
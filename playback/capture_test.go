@@ -0,0 +1,86 @@
+package playback
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// fakeInputBackend records a ramp, counting up by one every frame
+type fakeInputBackend struct {
+	fakeBackend
+	next wave.Frame
+}
+
+func (f *fakeInputBackend) OpenInput(cfg Config) (Input, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	f.cfg = cfg
+	return f, nil
+}
+
+func (f *fakeInputBackend) Read(frames []wave.Frame) error {
+	for i := range frames {
+		frames[i] = f.next
+		f.next++
+	}
+	return nil
+}
+
+func TestCapture(t *testing.T) {
+	fake := &fakeInputBackend{}
+	c, err := NewCapture(fake, Config{SampleRate: 100, Channels: 2, BlockSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := c.Channel(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := <-ch
+	if len(block) != 20 || block[0] != 0 || block[19] != 19 {
+		t.Fatalf("Expected the first block of the ramp, got %v", block)
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+		// the channel is closed once the capture stopped
+	}
+	if !fake.closed || c.Time()%10 != 0 || c.Time() == 0 {
+		t.Fatalf("Expected whole blocks recorded and the input closed, got %v", c.Time())
+	}
+
+	if _, err := NewCapture(&fakeBackend{}, Config{SampleRate: 100, Channels: 1}); err == nil {
+		t.Fatal("Expected an error for a backend which can't record")
+	}
+}
+
+func TestRecordToFile(t *testing.T) {
+	saved := backends
+	defer func() { backends = saved }()
+	backends = []Backend{&fakeInputBackend{}}
+
+	wfmt := wave.NewWaveFmt(1, 1, 8000, 16, nil)
+	frames, err := Record(.5, wfmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 4000 || frames[3999] != 3999 {
+		t.Fatalf("Expected half a second of frames, got %v", len(frames))
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.wav")
+	if err := RecordToFile(path, .25, wfmt); err != nil {
+		t.Fatal(err)
+	}
+	w, err := wave.ReadWaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Frames) != 2000 {
+		t.Fatalf("Expected 2000 frames in the file, got %v", len(w.Frames))
+	}
+}
@@ -0,0 +1,204 @@
+package playback
+
+// CoreAudio backend using an AudioQueue. The queue calls back on its own thread when a
+// buffer has been played, the buffers are handed around in C so Go is never called back.
+
+/*
+#cgo LDFLAGS: -framework AudioToolbox -framework CoreFoundation
+#include <AudioToolbox/AudioToolbox.h>
+#include <dispatch/dispatch.h>
+#include <pthread.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define GQ_BUFFERS 3
+
+typedef struct {
+	AudioQueueRef queue;
+	AudioQueueBufferRef buffers[GQ_BUFFERS];
+	AudioQueueBufferRef free[GQ_BUFFERS];
+	int nfree;
+	int size;
+	int started;
+	int xruns;              // writes which found every buffer played
+	dispatch_semaphore_t available; // counts the free buffers
+	pthread_mutex_t lock;
+} gqOutput;
+
+static void gqCallback(void *user, AudioQueueRef q, AudioQueueBufferRef buf) {
+	gqOutput *o = user;
+	pthread_mutex_lock(&o->lock);
+	o->free[o->nfree++] = buf;
+	pthread_mutex_unlock(&o->lock);
+	dispatch_semaphore_signal(o->available);
+}
+
+static OSStatus gqOpen(gqOutput *o, double rate, int channels, int frames) {
+	AudioStreamBasicDescription f;
+	memset(&f, 0, sizeof(f));
+	f.mSampleRate = rate;
+	f.mFormatID = kAudioFormatLinearPCM;
+	f.mFormatFlags = kLinearPCMFormatFlagIsFloat | kLinearPCMFormatFlagIsPacked;
+	f.mBytesPerPacket = 4 * channels;
+	f.mFramesPerPacket = 1;
+	f.mBytesPerFrame = 4 * channels;
+	f.mChannelsPerFrame = channels;
+	f.mBitsPerChannel = 32;
+
+	OSStatus err = AudioQueueNewOutput(&f, gqCallback, o, NULL, NULL, 0, &o->queue);
+	if (err) {
+		return err;
+	}
+	o->size = frames * 4 * channels;
+	for (int i = 0; i < GQ_BUFFERS; i++) {
+		err = AudioQueueAllocateBuffer(o->queue, o->size, &o->buffers[i]);
+		if (err) {
+			AudioQueueDispose(o->queue, true);
+			return err;
+		}
+		o->free[i] = o->buffers[i];
+	}
+	o->nfree = GQ_BUFFERS;
+	o->available = dispatch_semaphore_create(GQ_BUFFERS);
+	pthread_mutex_init(&o->lock, NULL);
+	return 0;
+}
+
+// gqWrite waits for a free buffer, fills it and queues it
+static OSStatus gqWrite(gqOutput *o, const float *data, int bytes) {
+	dispatch_semaphore_wait(o->available, DISPATCH_TIME_FOREVER);
+	pthread_mutex_lock(&o->lock);
+	if (o->started && o->nfree == GQ_BUFFERS) {
+		o->xruns++;
+	}
+	AudioQueueBufferRef buf = o->free[--o->nfree];
+	pthread_mutex_unlock(&o->lock);
+
+	memcpy(buf->mAudioData, data, bytes);
+	buf->mAudioDataByteSize = bytes;
+	OSStatus err = AudioQueueEnqueueBuffer(o->queue, buf, 0, NULL);
+	if (err || o->started) {
+		return err;
+	}
+	o->started = 1;
+	return AudioQueueStart(o->queue, NULL);
+}
+
+static int gqXruns(gqOutput *o) {
+	pthread_mutex_lock(&o->lock);
+	int n = o->xruns;
+	pthread_mutex_unlock(&o->lock);
+	return n;
+}
+
+// gqClose waits until every buffer has been played and disposes of the queue
+static OSStatus gqClose(gqOutput *o) {
+	for (int i = 0; i < GQ_BUFFERS; i++) {
+		dispatch_semaphore_wait(o->available, DISPATCH_TIME_FOREVER);
+	}
+	if (o->started) {
+		AudioQueueStop(o->queue, true);
+	}
+	OSStatus err = AudioQueueDispose(o->queue, true);
+	dispatch_release(o->available);
+	pthread_mutex_destroy(&o->lock);
+	return err;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(coreAudio{})
+}
+
+// coreAudio plays through the default output device of macOS
+type coreAudio struct{}
+
+func (coreAudio) Name() string {
+	return "coreaudio"
+}
+
+func (coreAudio) OpenOutput(cfg Config) (Output, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Device != "" {
+		return nil, errors.New("CoreAudio only supports the default device")
+	}
+	block := cfg.BlockSize
+	if block == 0 {
+		block = defaultBlockSize
+	}
+	// the queue is referenced from its callback thread, so it lives in C memory
+	q := (*C.gqOutput)(C.calloc(1, C.sizeof_gqOutput))
+	if status := C.gqOpen(q, C.double(cfg.SampleRate), C.int(cfg.Channels), C.int(block)); status != 0 {
+		C.free(unsafe.Pointer(q))
+		return nil, fmt.Errorf("CoreAudio: OSStatus %v", status)
+	}
+	return &coreAudioOutput{queue: q, channels: cfg.Channels, block: block, rate: cfg.SampleRate}, nil
+}
+
+// coreAudioOutput is an open AudioQueue
+type coreAudioOutput struct {
+	queue    *C.gqOutput
+	channels int
+	block    int // frames per channel in a buffer of the queue
+	rate     int
+	buf      []float32
+}
+
+// Latency returns the duration of the buffers of the queue, which are all filled ahead
+func (o *coreAudioOutput) Latency() time.Duration {
+	return time.Duration(C.GQ_BUFFERS*o.block) * time.Second / time.Duration(o.rate)
+}
+
+func (o *coreAudioOutput) Write(frames []wave.Frame) error {
+	size := o.block * o.channels
+	if cap(o.buf) < size {
+		o.buf = make([]float32, size)
+	}
+	total := len(frames) / o.channels * o.channels
+	for done := 0; done < total; done += size {
+		n := total - done
+		if n > size {
+			n = size
+		}
+		buf := o.buf[:n]
+		wave.FramesToFloat32(buf, frames[done:])
+		if status := C.gqWrite(o.queue, (*C.float)(unsafe.Pointer(&buf[0])), C.int(4*n)); status != 0 {
+			return fmt.Errorf("CoreAudio: OSStatus %v", status)
+		}
+	}
+	return nil
+}
+
+// Xruns returns how often the queue had played every buffer before the next one was written
+func (o *coreAudioOutput) Xruns() uint64 {
+	if o.queue == nil {
+		return 0
+	}
+	return uint64(C.gqXruns(o.queue))
+}
+
+// Close waits for the queued buffers to be played and disposes of the queue
+func (o *coreAudioOutput) Close() error {
+	if o.queue == nil {
+		return nil
+	}
+	status := C.gqClose(o.queue)
+	C.free(unsafe.Pointer(o.queue))
+	o.queue = nil
+	if status != 0 {
+		return fmt.Errorf("CoreAudio: OSStatus %v", status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package playback
+
+// Device describes an audio device of a backend
+type Device struct {
+	Backend        string
+	Name           string // name to open the device with, as Config.Device
+	Description    string // human readable name
+	InputChannels  int    // maximum amount of input channels, 0 when unknown or output-only
+	OutputChannels int    // maximum amount of output channels, 0 when unknown or input-only
+	SampleRates    []int  // empty when unknown
+	DefaultInput   bool
+	DefaultOutput  bool
+}
+
+// DeviceLister is implemented by backends which can list their devices
+type DeviceLister interface {
+	Devices() ([]Device, error)
+}
+
+// commonRates are the sample rates tried on devices which only report a range
+var commonRates = []int{8000, 11025, 16000, 22050, 32000, 44100, 48000, 88200, 96000, 176400, 192000}
+
+// Devices returns the devices of every backend which can list them.
+// The devices of the other backends are still returned when a backend fails.
+func Devices() ([]Device, error) {
+	devices := []Device{}
+	var first error
+	for _, b := range backends {
+		lister, ok := b.(DeviceLister)
+		if !ok {
+			continue
+		}
+		ds, err := lister.Devices()
+		if err != nil && first == nil {
+			first = err
+		}
+		devices = append(devices, ds...)
+	}
+	return devices, first
+}
+
+// ratesBetween returns the common sample rates within [min;max]
+func ratesBetween(min, max int) []int {
+	rates := []int{}
+	for _, r := range commonRates {
+		if r >= min && r <= max {
+			rates = append(rates, r)
+		}
+	}
+	return rates
+}
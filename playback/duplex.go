@@ -0,0 +1,143 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Duplex runs the frames of an input device through a chain of processors to an output
+// device, for live effects. Every block is recorded, processed and played before the next
+// one is recorded, so the latency stays within the buffers of the devices.
+//
+// For overdubs, a transport is played along with the processed input and every recorded
+// block is handed to OnRecord with the sample of the transport it was played against.
+type Duplex struct {
+	Config
+	Processors []synth.Processor // applied in order to every block of interleaved frames
+
+	// Transport, when set, is mixed into every block played, e.g. the backing track of an overdub
+	Transport *synth.Transport
+	// OnRecord receives every recorded block, before it is processed, with the sample of the
+	// transport at which its first frame was heard. The block is reused afterwards.
+	OnRecord func(sample int64, in []wave.Frame)
+	// Compensation is the round trip from output to input, which is subtracted from the
+	// transport position of recorded blocks. It starts at Latency() and can be adjusted.
+	Compensation time.Duration
+	// OnXrun, when set, is called with the total amount of xruns of both devices after
+	// every block during which one of them had an xrun
+	OnXrun func(total uint64)
+
+	in    Input
+	out   Output
+	clock int64
+	worker
+	xrunWatch
+}
+
+// NewDuplex opens an input and an output with the same config on the backend
+func NewDuplex(b Backend, cfg Config, processors ...synth.Processor) (*Duplex, error) {
+	ib, ok := b.(InputBackend)
+	if !ok {
+		return nil, errors.New("Backend " + b.Name() + " does not support recording")
+	}
+	if cfg.BlockSize == 0 {
+		cfg.BlockSize = defaultBlockSize
+	}
+	in, err := ib.OpenInput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	out, err := b.OpenOutput(cfg)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	d := &Duplex{
+		Config:     cfg,
+		Processors: processors,
+		in:         in,
+		out:        out,
+	}
+	d.Compensation = d.Latency()
+	return d, nil
+}
+
+// Start processes blocks on a separate goroutine until Stop is called
+func (d *Duplex) Start() error {
+	if d.Transport != nil && d.Transport.Channels != d.Channels {
+		return fmt.Errorf("Transport has %v channels instead of %v", d.Transport.Channels, d.Channels)
+	}
+	return d.start(func() error {
+		block := make([]wave.Frame, d.BlockSize*d.Channels)
+		var backing []wave.Frame
+		if d.Transport != nil {
+			backing = make([]wave.Frame, len(block))
+		}
+		compensation := int64(d.Compensation.Seconds()*float64(d.SampleRate) + .5)
+		for !d.stopping() {
+			if err := d.in.Read(block); err != nil {
+				return err
+			}
+			position := atomic.LoadInt64(&d.clock)
+			if d.Transport != nil {
+				position = d.Transport.Position()
+			}
+			if d.OnRecord != nil {
+				d.OnRecord(position-compensation, block)
+			}
+			for _, p := range d.Processors {
+				p.Process(block)
+			}
+			if d.Transport != nil {
+				d.Transport.Process(backing)
+				wave.MixInto(block, backing, 1)
+			}
+			if err := d.out.Write(block); err != nil {
+				return err
+			}
+			atomic.AddInt64(&d.clock, int64(d.BlockSize))
+			d.check(d.OnXrun, d.in, d.out)
+		}
+		return nil
+	})
+}
+
+// Time returns the amount of samples per channel processed
+func (d *Duplex) Time() int64 {
+	return atomic.LoadInt64(&d.clock)
+}
+
+// BlockLatency returns the latency added by processing in blocks, a block has to be
+// recorded completely before it can be processed
+func (d *Duplex) BlockLatency() time.Duration {
+	return time.Duration(d.BlockSize) * time.Second / time.Duration(d.SampleRate)
+}
+
+// Xruns returns the amount of xruns counted by both devices
+func (d *Duplex) Xruns() uint64 {
+	return StreamXruns(d.in) + StreamXruns(d.out)
+}
+
+// Latency returns the round trip from a sound at the input to hearing it at the output:
+// the latencies reported by both devices and the block being processed
+func (d *Duplex) Latency() time.Duration {
+	return StreamLatency(d.in) + d.BlockLatency() + StreamLatency(d.out)
+}
+
+// Stop ends processing after the current block and closes both devices
+func (d *Duplex) Stop() error {
+	return d.halt(d.close)
+}
+
+func (d *Duplex) close() error {
+	err := d.in.Close()
+	if oerr := d.out.Close(); err == nil {
+		err = oerr
+	}
+	return err
+}
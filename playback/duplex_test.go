@@ -0,0 +1,99 @@
+package playback
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestDuplex(t *testing.T) {
+	fake := &fakeInputBackend{}
+	d, err := NewDuplex(fake, Config{SampleRate: 1000, Channels: 1, BlockSize: 10}, synth.NewGain(0), &synth.Gain{Level: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.BlockLatency().Milliseconds() != 10 {
+		t.Fatalf("Expected a block latency of 10ms, got %v", d.BlockLatency())
+	}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for d.Time() < 100 {
+		runtime.Gosched()
+	}
+	if err := d.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.written) != int(d.Time()) || len(fake.written) < 100 {
+		t.Fatalf("Expected every processed block to be played, got %v of %v", len(fake.written), d.Time())
+	}
+	for i, f := range fake.written {
+		if f != wave.Frame(2*i) {
+			t.Fatalf("Expected the ramp doubled, got %v at %v", f, i)
+		}
+	}
+}
+
+// constantTrack plays the same value on every frame
+type constantTrack struct{ value wave.Frame }
+
+func (c constantTrack) Seek(time float64) {}
+
+func (c constantTrack) Process(frames []wave.Frame) {
+	for i := range frames {
+		frames[i] = c.value
+	}
+}
+
+func TestDuplexOverdub(t *testing.T) {
+	fake := &fakeInputBackend{}
+	fake.latency = 5 * time.Millisecond
+	d, err := NewDuplex(fake, Config{SampleRate: 1000, Channels: 1, BlockSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both devices report 5ms on top of the block
+	if d.Latency() != 20*time.Millisecond || d.Compensation != d.Latency() {
+		t.Fatalf("Expected a round trip of 20ms, got %v", d.Latency())
+	}
+
+	tempo, _ := breakpoint.NewTempoMap(120)
+	d.Transport, _ = synth.NewTransport(1000, 1, tempo)
+	d.Transport.Tracks = append(d.Transport.Tracks, constantTrack{1000})
+	d.Transport.SeekSample(100)
+	d.Transport.Play()
+
+	recorded := []int64{}
+	d.OnRecord = func(sample int64, in []wave.Frame) {
+		if len(recorded) < 3 {
+			recorded = append(recorded, sample)
+		}
+	}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for d.Time() < 30 {
+		runtime.Gosched()
+	}
+	if err := d.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 3 || recorded[0] != 80 || recorded[2] != 100 {
+		t.Fatalf("Expected blocks lined up 20 samples before the transport, got %v", recorded)
+	}
+	for i, f := range fake.written {
+		if f != wave.Frame(1000+i) {
+			t.Fatalf("Expected the transport mixed with the input, got %v at %v", f, i)
+		}
+	}
+
+	d, _ = NewDuplex(fake, Config{SampleRate: 1000, Channels: 1, BlockSize: 10})
+	d.Transport, _ = synth.NewTransport(1000, 2, tempo)
+	if err := d.Start(); err == nil {
+		t.Fatal("Expected an error for a transport with another amount of channels")
+	}
+}
@@ -0,0 +1,348 @@
+//go:build jack
+// +build jack
+
+package playback
+
+// JACK backend. Every stream is a JACK client with a port per channel, the process
+// callback runs in C on the realtime thread of JACK and exchanges interleaved frames
+// with Go through a lock-free ring buffer.
+
+/*
+#cgo pkg-config: jack
+#include <jack/jack.h>
+#include <jack/ringbuffer.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#define GJ_CHUNK 256
+
+typedef struct {
+	jack_client_t *client;
+	jack_port_t **ports;
+	int channels;
+	int input;
+	jack_ringbuffer_t *ring; // interleaved floats
+	float chunk[GJ_CHUNK * 8];
+	int xruns;               // blocks for which the ring buffer ran empty or full
+} gjStream;
+
+static int gjProcess(jack_nframes_t nframes, void *arg) {
+	gjStream *s = arg;
+	size_t frame = sizeof(float) * s->channels;
+	int per = GJ_CHUNK * 8 / s->channels;
+	for (jack_nframes_t done = 0; done < nframes;) {
+		jack_nframes_t n = nframes - done;
+		if (n > per) {
+			n = per;
+		}
+		if (s->input) {
+			for (int c = 0; c < s->channels; c++) {
+				float *buf = jack_port_get_buffer(s->ports[c], nframes);
+				for (jack_nframes_t i = 0; i < n; i++) {
+					s->chunk[i*s->channels+c] = buf[done+i];
+				}
+			}
+			if (jack_ringbuffer_write_space(s->ring) < n*frame) {
+				__atomic_add_fetch(&s->xruns, 1, __ATOMIC_RELAXED);
+			} else {
+				jack_ringbuffer_write(s->ring, (const char *)s->chunk, n*frame);
+			}
+		} else {
+			size_t avail = jack_ringbuffer_read_space(s->ring) / frame;
+			if (avail > n) {
+				avail = n;
+			}
+			jack_ringbuffer_read(s->ring, (char *)s->chunk, avail*frame);
+			if (avail < n) {
+				memset(s->chunk + avail*s->channels, 0, (n-avail)*frame);
+				__atomic_add_fetch(&s->xruns, 1, __ATOMIC_RELAXED);
+			}
+			for (int c = 0; c < s->channels; c++) {
+				float *buf = jack_port_get_buffer(s->ports[c], nframes);
+				for (jack_nframes_t i = 0; i < n; i++) {
+					buf[done+i] = s->chunk[i*s->channels+c];
+				}
+			}
+		}
+		done += n;
+	}
+	return 0;
+}
+
+// gjOpen creates a client with a port per channel and activates it
+static int gjOpen(gjStream *s, const char *name, int channels, int input, size_t ringSize) {
+	jack_status_t status;
+	s->client = jack_client_open(name, JackNoStartServer, &status);
+	if (!s->client) {
+		return -1;
+	}
+	s->channels = channels;
+	s->input = input;
+	s->ports = calloc(channels, sizeof(jack_port_t *));
+	s->ring = jack_ringbuffer_create(ringSize);
+	for (int c = 0; c < channels; c++) {
+		char port[32];
+		snprintf(port, sizeof(port), input ? "in_%d" : "out_%d", c+1);
+		s->ports[c] = jack_port_register(s->client, port, JACK_DEFAULT_AUDIO_TYPE,
+			input ? JackPortIsInput : JackPortIsOutput, 0);
+		if (!s->ports[c]) {
+			return -2;
+		}
+	}
+	jack_set_process_callback(s->client, gjProcess, s);
+	return jack_activate(s->client) ? -3 : 0;
+}
+
+// gjConnect connects the ports to the physical ports of the system
+static void gjConnect(gjStream *s) {
+	const char **physical = jack_get_ports(s->client, NULL, JACK_DEFAULT_AUDIO_TYPE,
+		JackPortIsPhysical | (s->input ? JackPortIsOutput : JackPortIsInput));
+	if (!physical) {
+		return;
+	}
+	for (int c = 0; c < s->channels && physical[c]; c++) {
+		if (s->input) {
+			jack_connect(s->client, physical[c], jack_port_name(s->ports[c]));
+		} else {
+			jack_connect(s->client, jack_port_name(s->ports[c]), physical[c]);
+		}
+	}
+	jack_free(physical);
+}
+
+static void gjClose(gjStream *s) {
+	if (s->client) {
+		jack_deactivate(s->client);
+		jack_client_close(s->client);
+	}
+	if (s->ring) {
+		jack_ringbuffer_free(s->ring);
+	}
+	free(s->ports);
+}
+
+// gjLatency returns the largest latency of the ports in frames, including the ring buffer
+static int gjLatency(gjStream *s) {
+	jack_latency_range_t range;
+	jack_nframes_t max = 0;
+	for (int c = 0; c < s->channels; c++) {
+		jack_port_get_latency_range(s->ports[c], s->input ? JackCaptureLatency : JackPlaybackLatency, &range);
+		if (range.max > max) {
+			max = range.max;
+		}
+	}
+	return max + jack_ringbuffer_read_space(s->ring) / (sizeof(float) * s->channels);
+}
+
+static int gjXruns(gjStream *s) {
+	return __atomic_load_n(&s->xruns, __ATOMIC_RELAXED);
+}
+
+// gjProbe connects to the server without starting one, jack_client_open can't be called from Go
+static jack_client_t *gjProbe(const char *name) {
+	jack_status_t status;
+	return jack_client_open(name, JackNoStartServer, &status);
+}
+
+// gjCountPorts counts the physical ports with the given flags
+static int gjCountPorts(jack_client_t *client, unsigned long flags) {
+	const char **ports = jack_get_ports(client, NULL, JACK_DEFAULT_AUDIO_TYPE, JackPortIsPhysical | flags);
+	int n = 0;
+	if (ports) {
+		while (ports[n]) {
+			n++;
+		}
+		jack_free(ports);
+	}
+	return n;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(jack{})
+}
+
+// jackMaxChannels is the amount of channels that fit in the chunk of the process callback
+const jackMaxChannels = 8
+
+// jack runs every stream as a client of a running JACK server. The device of the config
+// names the client: without a name the client is called goaudio and connected to the
+// physical ports, with a name its ports are left for the user to patch.
+type jack struct{}
+
+func (jack) Name() string {
+	return "jack"
+}
+
+// Devices returns the JACK server with its physical ports, at the rate it runs at
+func (jack) Devices() ([]Device, error) {
+	name := C.CString("goaudio-probe")
+	defer C.free(unsafe.Pointer(name))
+	client := C.gjProbe(name)
+	if client == nil {
+		return nil, errors.New("JACK server not running")
+	}
+	defer C.jack_client_close(client)
+	return []Device{{
+		Backend:     "jack",
+		Name:        "jack",
+		Description: "JACK server",
+		// a physical output port of the system is where JACK clients are captured from
+		InputChannels:  int(C.gjCountPorts(client, C.JackPortIsOutput)),
+		OutputChannels: int(C.gjCountPorts(client, C.JackPortIsInput)),
+		SampleRates:    []int{int(C.jack_get_sample_rate(client))},
+		DefaultInput:   true,
+		DefaultOutput:  true,
+	}}, nil
+}
+
+func (jack) OpenOutput(cfg Config) (Output, error) {
+	return openJACK(cfg, false)
+}
+
+func (jack) OpenInput(cfg Config) (Input, error) {
+	return openJACK(cfg, true)
+}
+
+func openJACK(cfg Config, input bool) (*jackStream, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Channels > jackMaxChannels {
+		return nil, fmt.Errorf("JACK supports up to %v channels", jackMaxChannels)
+	}
+	block := cfg.BlockSize
+	if block == 0 {
+		block = defaultBlockSize
+	}
+	name := cfg.Device
+	if name == "" {
+		name = "goaudio"
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	in := C.int(0)
+	if input {
+		in = 1
+	}
+	// the process callback holds on to the stream, so it lives in C memory
+	s := (*C.gjStream)(C.calloc(1, C.sizeof_gjStream))
+	ring := C.size_t(4 * block * cfg.Channels * 4)
+	if code := C.gjOpen(s, cname, C.int(cfg.Channels), in, ring); code != 0 {
+		C.gjClose(s)
+		C.free(unsafe.Pointer(s))
+		return nil, fmt.Errorf("JACK: can't open client %v (%v)", name, code)
+	}
+	if rate := int(C.jack_get_sample_rate(s.client)); rate != cfg.SampleRate {
+		C.gjClose(s)
+		C.free(unsafe.Pointer(s))
+		return nil, fmt.Errorf("JACK runs at %vHz, not %vHz", rate, cfg.SampleRate)
+	}
+	if cfg.Device == "" {
+		C.gjConnect(s)
+	}
+	period := time.Duration(C.jack_get_buffer_size(s.client)) * time.Second / time.Duration(cfg.SampleRate)
+	return &jackStream{stream: s, channels: cfg.Channels, rate: cfg.SampleRate, input: input, wait: period / 2}, nil
+}
+
+// jackStream is an active JACK client
+type jackStream struct {
+	stream   *C.gjStream
+	channels int
+	rate     int
+	input    bool
+	wait     time.Duration // time to wait for the process callback to make room or data
+	buf      []float32
+}
+
+// Latency returns the latency of the connections of the ports and the frames in the ring buffer
+func (j *jackStream) Latency() time.Duration {
+	return time.Duration(C.gjLatency(j.stream)) * time.Second / time.Duration(j.rate)
+}
+
+// Xruns returns how often the process callback found the ring buffer empty or full
+func (j *jackStream) Xruns() uint64 {
+	return uint64(C.gjXruns(j.stream))
+}
+
+func (j *jackStream) Write(frames []wave.Frame) error {
+	n := len(frames) / j.channels * j.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(j.buf) < n {
+		j.buf = make([]float32, n)
+	}
+	buf := j.buf[:n]
+	wave.FramesToFloat32(buf, frames)
+	frame := 4 * j.channels
+	for done := 0; done < n; {
+		space := int(C.jack_ringbuffer_write_space(j.stream.ring)) / frame * j.channels
+		if space == 0 {
+			time.Sleep(j.wait)
+			continue
+		}
+		if space > n-done {
+			space = n - done
+		}
+		C.jack_ringbuffer_write(j.stream.ring, (*C.char)(unsafe.Pointer(&buf[done])), C.size_t(4*space))
+		done += space
+	}
+	return nil
+}
+
+func (j *jackStream) Read(frames []wave.Frame) error {
+	n := len(frames) / j.channels * j.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(j.buf) < n {
+		j.buf = make([]float32, n)
+	}
+	buf := j.buf[:n]
+	frame := 4 * j.channels
+	for done := 0; done < n; {
+		avail := int(C.jack_ringbuffer_read_space(j.stream.ring)) / frame * j.channels
+		if avail == 0 {
+			time.Sleep(j.wait)
+			continue
+		}
+		if avail > n-done {
+			avail = n - done
+		}
+		C.jack_ringbuffer_read(j.stream.ring, (*C.char)(unsafe.Pointer(&buf[done])), C.size_t(4*avail))
+		done += avail
+	}
+	wave.Float32ToFrames(frames, buf)
+	return nil
+}
+
+// Close waits for the written frames to be played and closes the client
+func (j *jackStream) Close() error {
+	if j.stream == nil {
+		return nil
+	}
+	if !j.input {
+		// give up on draining when the server stops calling back
+		deadline := time.Now().Add(time.Second)
+		for C.jack_ringbuffer_read_space(j.stream.ring) > 0 && time.Now().Before(deadline) {
+			time.Sleep(j.wait)
+		}
+	}
+	C.gjClose(j.stream)
+	C.free(unsafe.Pointer(j.stream))
+	j.stream = nil
+	return nil
+}
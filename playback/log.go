@@ -0,0 +1,25 @@
+package playback
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// logger is the logger set with SetLogger
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets a logger for the xruns of streams, captures and duplex streams, which are
+// logged as warnings from their goroutines. Nothing is logged by default, nil turns the
+// logging off again. Ring buffers don't log as they can run on real-time callbacks, use
+// their OnXrun instead.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// warn logs an event to the logger of the package, if one is set
+func warn(msg string, args ...any) {
+	if l := logger.Load(); l != nil {
+		l.Log(context.Background(), slog.LevelWarn, msg, args...)
+	}
+}
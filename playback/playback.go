@@ -0,0 +1,144 @@
+// Package playback sends frames to the audio devices of the system.
+//
+// Audio systems are available as backends, which register themselves when they are
+// compiled in. The native backend of the system is available by default: ALSA on Linux,
+// WASAPI on Windows, CoreAudio on macOS (which needs cgo) and Web Audio when compiled to
+// WebAssembly for the browser. Other backends are enabled with build tags:
+// `go build -tags portaudio` or `go build -tags jack`.
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Config describes a stream to open on a device
+type Config struct {
+	SampleRate int
+	Channels   int
+	BlockSize  int    // frames per channel in each buffer, 0 lets the backend choose
+	Device     string // name of the device, empty for the default device
+}
+
+// Output is an open stream to an output device
+type Output interface {
+	// Write plays interleaved frames, blocking until the device accepted them
+	Write(frames []wave.Frame) error
+	Close() error
+}
+
+// Input is an open stream from an input device
+type Input interface {
+	// Read fills frames with interleaved frames from the device, blocking until they are recorded
+	Read(frames []wave.Frame) error
+	Close() error
+}
+
+// LatencyReporter is implemented by outputs and inputs which know their latency: the time
+// between writing a frame and hearing it, or between a sound and reading its frame
+type LatencyReporter interface {
+	Latency() time.Duration
+}
+
+// StreamLatency returns the latency reported by an output or input, 0 when it doesn't know
+func StreamLatency(stream interface{}) time.Duration {
+	if l, ok := stream.(LatencyReporter); ok {
+		return l.Latency()
+	}
+	return 0
+}
+
+// XrunCounter is implemented by outputs and inputs which count their xruns: the times an
+// output ran out of frames to play, or an input lost frames because it wasn't read in time
+type XrunCounter interface {
+	Xruns() uint64
+}
+
+// StreamXruns returns the xruns counted by an output or input, 0 when it doesn't count them
+func StreamXruns(stream interface{}) uint64 {
+	if x, ok := stream.(XrunCounter); ok {
+		return x.Xruns()
+	}
+	return 0
+}
+
+// xrunWatch reports new xruns of devices to a callback
+type xrunWatch struct {
+	seen uint64
+}
+
+// check calls cb with the total amount of xruns of the devices when it went up since the last check
+func (x *xrunWatch) check(cb func(total uint64), devices ...interface{}) {
+	total := uint64(0)
+	for _, d := range devices {
+		total += StreamXruns(d)
+	}
+	if total > x.seen {
+		warn("xrun", "new", total-x.seen, "total", total)
+		x.seen = total
+		if cb != nil {
+			cb(total)
+		}
+	}
+}
+
+// Backend is an audio system through which devices can be opened
+type Backend interface {
+	Name() string
+	OpenOutput(cfg Config) (Output, error)
+}
+
+// InputBackend is implemented by backends which can also record from input devices
+type InputBackend interface {
+	Backend
+	OpenInput(cfg Config) (Input, error)
+}
+
+// backends in order of preference
+var backends []Backend
+
+// Register makes a backend available, backends registered first are preferred.
+// The built-in backends register themselves in the order of their file names.
+func Register(b Backend) {
+	backends = append(backends, b)
+}
+
+// Backends returns the available backends
+func Backends() []Backend {
+	return append([]Backend(nil), backends...)
+}
+
+// GetBackend returns the backend with the given name
+func GetBackend(name string) (Backend, error) {
+	for _, b := range backends {
+		if b.Name() == name {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("Backend %v not available", name)
+}
+
+// DefaultBackend returns the preferred backend
+func DefaultBackend() (Backend, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("No audio backend available, build with e.g. -tags portaudio")
+	}
+	return backends[0], nil
+}
+
+// validate checks the config can be opened
+func (c Config) validate() error {
+	if c.SampleRate <= 0 {
+		return errors.New("Sample rate should be positive")
+	}
+	if c.Channels <= 0 {
+		return errors.New("Need at least one channel")
+	}
+	if c.BlockSize < 0 {
+		return errors.New("Block size can't be negative")
+	}
+	return nil
+}
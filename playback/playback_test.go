@@ -0,0 +1,122 @@
+package playback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// fakeBackend records everything written to its outputs
+type fakeBackend struct {
+	cfg     Config
+	written []wave.Frame
+	writes  int
+	closed  bool
+	latency time.Duration
+	xruns   uint64
+}
+
+func (f *fakeBackend) Name() string {
+	return "fake"
+}
+
+func (f *fakeBackend) OpenOutput(cfg Config) (Output, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	f.cfg = cfg
+	return f, nil
+}
+
+func (f *fakeBackend) Write(frames []wave.Frame) error {
+	f.written = append(f.written, frames...)
+	f.writes++
+	return nil
+}
+
+func (f *fakeBackend) Latency() time.Duration {
+	return f.latency
+}
+
+func (f *fakeBackend) Xruns() uint64 {
+	return f.xruns
+}
+
+func (f *fakeBackend) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestPlayer(t *testing.T) {
+	fake := &fakeBackend{}
+	p := &Player{Backend: fake, BlockSize: 100}
+	frames := make([]wave.Frame, 1000)
+	for i := range frames {
+		frames[i] = wave.Frame(i)
+	}
+	if err := p.Play(frames, wave.NewWaveFmt(1, 2, 44100, 16, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if fake.cfg.SampleRate != 44100 || fake.cfg.Channels != 2 {
+		t.Fatalf("Expected a stereo stream at 44100Hz, got %+v", fake.cfg)
+	}
+	if fake.writes != 5 || len(fake.written) != 1000 || fake.written[999] != 999 {
+		t.Fatalf("Expected all frames written in blocks of 200, got %v writes", fake.writes)
+	}
+	if !fake.closed {
+		t.Fatal("Expected the output to be closed")
+	}
+
+	if err := p.Play(frames, wave.NewWaveFmt(1, 0, 44100, 16, nil)); err == nil {
+		t.Fatal("Expected an error for a format without channels")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	saved := backends
+	defer func() { backends = saved }()
+
+	backends = nil
+	if _, err := DefaultBackend(); err == nil {
+		t.Fatal("Expected an error without backends")
+	}
+	Register(&fakeBackend{})
+	if b, err := GetBackend("fake"); err != nil || b.Name() != "fake" {
+		t.Fatalf("Expected to find the fake backend, got %v", err)
+	}
+	if _, err := GetBackend("missing"); err == nil {
+		t.Fatal("Expected an error for a missing backend")
+	}
+}
+
+// listingBackend is a fake backend which also lists devices
+type listingBackend struct {
+	fakeBackend
+	devices []Device
+}
+
+func (l *listingBackend) Devices() ([]Device, error) {
+	return l.devices, nil
+}
+
+func TestDevices(t *testing.T) {
+	saved := backends
+	defer func() { backends = saved }()
+
+	backends = []Backend{
+		&fakeBackend{},
+		&listingBackend{devices: []Device{{Name: "a", OutputChannels: 2, DefaultOutput: true}}},
+		&listingBackend{devices: []Device{{Name: "b", InputChannels: 1}}},
+	}
+	devices, err := Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[0].Name != "a" || devices[1].Name != "b" {
+		t.Fatalf("Expected the devices of both listing backends, got %v", devices)
+	}
+	if rates := ratesBetween(40000, 50000); len(rates) != 2 || rates[0] != 44100 {
+		t.Fatalf("Expected 44100 and 48000, got %v", rates)
+	}
+}
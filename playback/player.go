@@ -0,0 +1,123 @@
+package playback
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// defaultBlockSize is the amount of frames per channel written at once unless set otherwise
+const defaultBlockSize = 1024
+
+// Player plays frames or decoders on an output device.
+// Play and PlayDecoder block until they are done, while a queue of decoders is played in
+// the background and can be paused, seeked and skipped through.
+type Player struct {
+	Backend   Backend
+	Device    string // empty for the default device
+	BlockSize int    // frames per channel written at once
+	// OnPosition is called from the playing goroutine after every block with the index of the
+	// track in the queue and the sample, counted per channel, played up to in that track
+	OnPosition func(track int, sample int64)
+
+	queue    []QueueItem
+	current  int   // index in the queue of the track being played
+	position int64 // samples per channel read from the current track
+	volume   float64
+	paused   bool
+	w        *worker
+	mu       sync.Mutex
+}
+
+// NewPlayer creates a player on the default device of the preferred backend
+func NewPlayer() (*Player, error) {
+	b, err := DefaultBackend()
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		Backend:   b,
+		BlockSize: defaultBlockSize,
+	}, nil
+}
+
+// Play plays interleaved frames in the given format and returns when they are done
+func (p *Player) Play(frames []wave.Frame, wfmt wave.WaveFmt) error {
+	return p.PlayDecoder(NewFrameDecoder(frames, wfmt))
+}
+
+// PlayDecoder plays a decoder until it is exhausted
+func (p *Player) PlayDecoder(d wave.Decoder) error {
+	wfmt := d.Format()
+	if p.BlockSize <= 0 {
+		p.BlockSize = defaultBlockSize
+	}
+	out, err := p.Backend.OpenOutput(Config{
+		SampleRate: wfmt.SampleRate,
+		Channels:   wfmt.NumChannels,
+		BlockSize:  p.BlockSize,
+		Device:     p.Device,
+	})
+	if err != nil {
+		return err
+	}
+	block := make([]wave.Frame, p.BlockSize*wfmt.NumChannels)
+	for {
+		n, err := d.Read(block)
+		if n > 0 {
+			if werr := out.Write(block[:n]); werr != nil {
+				out.Close()
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// frameDecoder serves frames from memory
+type frameDecoder struct {
+	wfmt   wave.WaveFmt
+	frames []wave.Frame
+	pos    int
+}
+
+// NewFrameDecoder creates a decoder for interleaved frames in memory, which can seek
+func NewFrameDecoder(frames []wave.Frame, wfmt wave.WaveFmt) wave.Decoder {
+	return &frameDecoder{wfmt: wfmt, frames: frames}
+}
+
+func (f *frameDecoder) Format() wave.WaveFmt {
+	return f.wfmt
+}
+
+func (f *frameDecoder) Read(frames []wave.Frame) (int, error) {
+	if f.pos >= len(f.frames) {
+		return 0, io.EOF
+	}
+	n := copy(frames, f.frames[f.pos:])
+	f.pos += n
+	return n, nil
+}
+
+// Clone returns a decoder of the same frames from the start, which reads them at the same time
+func (f *frameDecoder) Clone() (wave.Decoder, error) {
+	return &frameDecoder{wfmt: f.wfmt, frames: f.frames}, nil
+}
+
+func (f *frameDecoder) SeekSample(sample int64) error {
+	pos := sample * int64(f.wfmt.NumChannels)
+	if pos < 0 || pos > int64(len(f.frames)) {
+		return fmt.Errorf("Sample %v out of range", sample)
+	}
+	f.pos = int(pos)
+	return nil
+}
@@ -0,0 +1,254 @@
+//go:build portaudio
+// +build portaudio
+
+package playback
+
+/*
+#cgo pkg-config: portaudio-2.0
+#include <portaudio.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(portAudio{})
+}
+
+var (
+	paOnce sync.Once
+	paErr  error
+)
+
+// initPortAudio initializes PortAudio once, it stays initialized for the life of the program
+func initPortAudio() error {
+	paOnce.Do(func() {
+		if code := C.Pa_Initialize(); code != C.paNoError {
+			paErr = paError(code)
+		}
+	})
+	return paErr
+}
+
+func paError(code C.PaError) error {
+	return fmt.Errorf("PortAudio: %v", C.GoString(C.Pa_GetErrorText(code)))
+}
+
+// portAudio plays through PortAudio, using its blocking API
+type portAudio struct{}
+
+func (portAudio) Name() string {
+	return "portaudio"
+}
+
+// paDevice returns the index of the named device, or the default device
+func paDevice(name string, input bool) (C.PaDeviceIndex, error) {
+	if name == "" {
+		dev := C.Pa_GetDefaultOutputDevice()
+		if input {
+			dev = C.Pa_GetDefaultInputDevice()
+		}
+		if dev == C.paNoDevice {
+			return 0, errors.New("No default device")
+		}
+		return dev, nil
+	}
+	for i := C.PaDeviceIndex(0); i < C.Pa_GetDeviceCount(); i++ {
+		info := C.Pa_GetDeviceInfo(i)
+		if info == nil || C.GoString(info.name) != name {
+			continue
+		}
+		if (input && info.maxInputChannels > 0) || (!input && info.maxOutputChannels > 0) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Device %v not found", name)
+}
+
+// Devices lists the devices of all host APIs, the sample rates are checked for 32-bit floats
+func (portAudio) Devices() ([]Device, error) {
+	if err := initPortAudio(); err != nil {
+		return nil, err
+	}
+	count := C.Pa_GetDeviceCount()
+	if count < 0 {
+		return nil, paError(C.PaError(count))
+	}
+	defIn, defOut := C.Pa_GetDefaultInputDevice(), C.Pa_GetDefaultOutputDevice()
+	devices := []Device{}
+	for i := C.PaDeviceIndex(0); i < count; i++ {
+		info := C.Pa_GetDeviceInfo(i)
+		if info == nil {
+			continue
+		}
+		d := Device{
+			Backend:        "portaudio",
+			Name:           C.GoString(info.name),
+			Description:    C.GoString(info.name),
+			InputChannels:  int(info.maxInputChannels),
+			OutputChannels: int(info.maxOutputChannels),
+			DefaultInput:   i == defIn,
+			DefaultOutput:  i == defOut,
+		}
+		if host := C.Pa_GetHostApiInfo(info.hostApi); host != nil {
+			d.Description = fmt.Sprintf("%v (%v)", d.Name, C.GoString(host.name))
+		}
+		params := C.PaStreamParameters{
+			device:       i,
+			channelCount: 1,
+			sampleFormat: C.paFloat32,
+		}
+		for _, rate := range commonRates {
+			var code C.PaError
+			if d.OutputChannels > 0 {
+				code = C.Pa_IsFormatSupported(nil, &params, C.double(rate))
+			} else {
+				code = C.Pa_IsFormatSupported(&params, nil, C.double(rate))
+			}
+			if code == C.paNoError {
+				d.SampleRates = append(d.SampleRates, rate)
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func (portAudio) OpenOutput(cfg Config) (Output, error) {
+	return openPortAudio(cfg, false)
+}
+
+func (portAudio) OpenInput(cfg Config) (Input, error) {
+	return openPortAudio(cfg, true)
+}
+
+func openPortAudio(cfg Config, input bool) (*paStream, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if err := initPortAudio(); err != nil {
+		return nil, err
+	}
+	dev, err := paDevice(cfg.Device, input)
+	if err != nil {
+		return nil, err
+	}
+	info := C.Pa_GetDeviceInfo(dev)
+	params := C.PaStreamParameters{
+		device:           dev,
+		channelCount:     C.int(cfg.Channels),
+		sampleFormat:     C.paFloat32,
+		suggestedLatency: info.defaultHighOutputLatency,
+	}
+	var stream unsafe.Pointer
+	var code C.PaError
+	if input {
+		params.suggestedLatency = info.defaultHighInputLatency
+		code = C.Pa_OpenStream(&stream, &params, nil, C.double(cfg.SampleRate),
+			C.ulong(cfg.BlockSize), C.paNoFlag, nil, nil)
+	} else {
+		code = C.Pa_OpenStream(&stream, nil, &params, C.double(cfg.SampleRate),
+			C.ulong(cfg.BlockSize), C.paNoFlag, nil, nil)
+	}
+	if code != C.paNoError {
+		return nil, paError(code)
+	}
+	if code := C.Pa_StartStream(stream); code != C.paNoError {
+		C.Pa_CloseStream(stream)
+		return nil, paError(code)
+	}
+	return &paStream{stream: stream, channels: cfg.Channels, input: input}, nil
+}
+
+// paStream is an open PortAudio stream
+type paStream struct {
+	xruns    uint64 // accessed atomically, kept first for alignment on 32-bit systems
+	stream   unsafe.Pointer
+	channels int
+	input    bool
+	buf      []float32
+}
+
+// Xruns returns how often PortAudio reported an underflow or overflow
+func (o *paStream) Xruns() uint64 {
+	return atomic.LoadUint64(&o.xruns)
+}
+
+// Latency returns the latency PortAudio reports for the stream
+func (o *paStream) Latency() time.Duration {
+	info := C.Pa_GetStreamInfo(o.stream)
+	if info == nil {
+		return 0
+	}
+	seconds := float64(info.outputLatency)
+	if o.input {
+		seconds = float64(info.inputLatency)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (o *paStream) Write(frames []wave.Frame) error {
+	n := len(frames) / o.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(o.buf) < len(frames) {
+		o.buf = make([]float32, len(frames))
+	}
+	buf := o.buf[:n*o.channels]
+	wave.FramesToFloat32(buf, frames)
+	code := C.Pa_WriteStream(o.stream, unsafe.Pointer(&buf[0]), C.ulong(n))
+	// an underflow means we were late, the samples are still played
+	if code == C.paOutputUnderflowed {
+		atomic.AddUint64(&o.xruns, 1)
+	} else if code != C.paNoError {
+		return paError(code)
+	}
+	return nil
+}
+
+func (o *paStream) Read(frames []wave.Frame) error {
+	n := len(frames) / o.channels
+	if n == 0 {
+		return nil
+	}
+	if cap(o.buf) < len(frames) {
+		o.buf = make([]float32, len(frames))
+	}
+	buf := o.buf[:n*o.channels]
+	code := C.Pa_ReadStream(o.stream, unsafe.Pointer(&buf[0]), C.ulong(n))
+	// an overflow means frames were lost before these, the frames read are still valid
+	if code == C.paInputOverflowed {
+		atomic.AddUint64(&o.xruns, 1)
+	} else if code != C.paNoError {
+		return paError(code)
+	}
+	wave.Float32ToFrames(frames, buf)
+	return nil
+}
+
+// Close waits for the written frames to be played and closes the stream
+func (o *paStream) Close() error {
+	if o.stream == nil {
+		return nil
+	}
+	stop := C.Pa_StopStream(o.stream)
+	code := C.Pa_CloseStream(o.stream)
+	o.stream = nil
+	if stop != C.paNoError {
+		return paError(stop)
+	}
+	if code != C.paNoError {
+		return paError(code)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package playback
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// QueueItem is a track waiting to be played by a player
+type QueueItem struct {
+	Decoder    wave.Decoder
+	ReplayGain float64 // gain of the track in dB, 0 when unknown
+	Peak       float64 // largest absolute sample of the track, 0 when unknown
+}
+
+// Enqueue adds tracks to the end of the queue. Tracks following each other with the same
+// sample rate and amount of channels are played without a gap.
+func (p *Player) Enqueue(items ...QueueItem) error {
+	for _, item := range items {
+		if item.Decoder == nil {
+			return errors.New("A queued track needs a decoder")
+		}
+	}
+	p.mu.Lock()
+	p.queue = append(p.queue, items...)
+	p.mu.Unlock()
+	return nil
+}
+
+// Start plays the queue on a separate goroutine until it is exhausted or Stop is called
+func (p *Player) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w != nil && !p.w.finished() {
+		return errors.New("Already started")
+	}
+	if p.BlockSize <= 0 {
+		p.BlockSize = defaultBlockSize
+	}
+	w := &worker{}
+	p.w = w
+	return w.start(func() error { return p.run(w) })
+}
+
+// Wait blocks until the queue has been played
+func (p *Player) Wait() error {
+	p.mu.Lock()
+	w := p.w
+	p.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.wait(func() error { return nil })
+}
+
+// Stop ends playback after the current block, the queue is kept so Start resumes from there
+func (p *Player) Stop() error {
+	p.mu.Lock()
+	w := p.w
+	p.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.halt(func() error { return nil })
+}
+
+// Pause plays silence until Resume is called, keeping the device open
+func (p *Player) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+}
+
+// Resume continues playback where it was paused
+func (p *Player) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+}
+
+// Paused reports whether the player is paused
+func (p *Player) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// Skip moves on to the next track in the queue
+func (p *Player) Skip() {
+	p.mu.Lock()
+	if p.current < len(p.queue) {
+		p.current++
+		p.position = 0
+	}
+	p.mu.Unlock()
+}
+
+// SeekSample moves to a sample, counted per channel, of the current track.
+// The next block played starts at exactly that sample.
+func (p *Player) SeekSample(sample int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current >= len(p.queue) {
+		return errors.New("No track to seek in")
+	}
+	s, ok := p.queue[p.current].Decoder.(wave.Seeker)
+	if !ok {
+		return errors.New("Track does not support seeking")
+	}
+	if err := s.SeekSample(sample); err != nil {
+		return err
+	}
+	p.position = sample
+	return nil
+}
+
+// Position returns the index in the queue of the current track and the sample of that
+// track up to which has been played
+func (p *Player) Position() (track int, sample int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current, p.position
+}
+
+// SetVolume sets the volume in dB, which is added to the replay gain of every track
+func (p *Player) SetVolume(db float64) {
+	p.mu.Lock()
+	p.volume = db
+	p.mu.Unlock()
+}
+
+// Volume returns the volume in dB
+func (p *Player) Volume() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume
+}
+
+// gain returns the linear gain of a track, lowered when its peak would clip
+func (p *Player) gain(item QueueItem) float64 {
+	g := math.Pow(10, (p.volume+item.ReplayGain)/20)
+	if item.Peak > 0 && g*item.Peak > 1 {
+		g = 1 / item.Peak
+	}
+	return g
+}
+
+// fill reads the next block from the queue, continuing with the next track when one ends
+// so there is no gap. The block ends early when the next track has another format.
+func (p *Player) fill(block []wave.Frame, cfg Config) (int, error) {
+	n := 0
+	for n < len(block) && p.current < len(p.queue) {
+		item := p.queue[p.current]
+		wfmt := item.Decoder.Format()
+		if wfmt.SampleRate != cfg.SampleRate || wfmt.NumChannels != cfg.Channels {
+			break
+		}
+		read, err := item.Decoder.Read(block[n:])
+		wave.ApplyGain(block[n:n+read], wave.Frame(p.gain(item)))
+		n += read
+		p.position += int64(read / cfg.Channels)
+		if err == io.EOF {
+			p.current++
+			p.position = 0
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// run plays the queue, opening the output again whenever the format changes
+func (p *Player) run(w *worker) error {
+	var (
+		out   Output
+		cfg   Config
+		block []wave.Frame
+	)
+	closeOutput := func() error {
+		if out == nil {
+			return nil
+		}
+		err := out.Close()
+		out = nil
+		return err
+	}
+	for !w.stopping() {
+		p.mu.Lock()
+		if p.current >= len(p.queue) {
+			p.mu.Unlock()
+			break
+		}
+		wfmt := p.queue[p.current].Decoder.Format()
+		paused := p.paused
+		p.mu.Unlock()
+
+		if out == nil || wfmt.SampleRate != cfg.SampleRate || wfmt.NumChannels != cfg.Channels {
+			if err := closeOutput(); err != nil {
+				return err
+			}
+			cfg = Config{
+				SampleRate: wfmt.SampleRate,
+				Channels:   wfmt.NumChannels,
+				BlockSize:  p.BlockSize,
+				Device:     p.Device,
+			}
+			o, err := p.Backend.OpenOutput(cfg)
+			if err != nil {
+				return err
+			}
+			out = o
+			block = make([]wave.Frame, cfg.BlockSize*cfg.Channels)
+		}
+
+		if paused {
+			for i := range block {
+				block[i] = 0
+			}
+			if err := out.Write(block); err != nil {
+				closeOutput()
+				return err
+			}
+			continue
+		}
+
+		p.mu.Lock()
+		n, err := p.fill(block, cfg)
+		track, sample := p.current, p.position
+		p.mu.Unlock()
+		if n > 0 {
+			if werr := out.Write(block[:n]); werr != nil {
+				closeOutput()
+				return werr
+			}
+		}
+		if err != nil {
+			closeOutput()
+			return err
+		}
+		if p.OnPosition != nil {
+			p.OnPosition(track, sample)
+		}
+	}
+	return closeOutput()
+}
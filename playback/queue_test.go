@@ -0,0 +1,111 @@
+package playback
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestPlayerQueue(t *testing.T) {
+	fake := &fakeBackend{}
+	p := &Player{Backend: fake, BlockSize: 3}
+	stereo := wave.NewWaveFmt(1, 2, 44100, 16, nil)
+	ones := []wave.Frame{1, 1, 1, 1, 1, 1, 1, 1}
+	positions := [][2]int64{}
+	p.OnPosition = func(track int, sample int64) {
+		positions = append(positions, [2]int64{int64(track), sample})
+	}
+
+	p.SetVolume(-20 * math.Log10(2))
+	err := p.Enqueue(
+		QueueItem{Decoder: NewFrameDecoder(ones, stereo)},
+		// the replay gain would clip the peak of the track
+		QueueItem{Decoder: NewFrameDecoder(ones[:4], stereo), ReplayGain: 12, Peak: .8},
+		QueueItem{Decoder: NewFrameDecoder(ones[:2], wave.NewWaveFmt(1, 1, 22050, 16, nil))},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []wave.Frame{.5, .5, .5, .5, .5, .5, .5, .5, 1.25, 1.25, 1.25, 1.25, .5, .5}
+	if len(fake.written) != len(expected) {
+		t.Fatalf("Expected %v frames, got %v", len(expected), fake.written)
+	}
+	for i := range expected {
+		if math.Abs(float64(fake.written[i]-expected[i])) > 1e-9 {
+			t.Fatalf("Expected %v, got %v", expected, fake.written)
+		}
+	}
+	// the first two tracks share a block, the third one reopens the output in mono
+	if fake.writes != 3 || fake.cfg.Channels != 1 || fake.cfg.SampleRate != 22050 {
+		t.Fatalf("Expected the last track on its own mono output after 3 writes, got %v writes and %+v",
+			fake.writes, fake.cfg)
+	}
+	if len(positions) != 4 || positions[1] != [2]int64{1, 2} || positions[3] != [2]int64{3, 0} {
+		t.Fatalf("Expected positions through the queue, got %v", positions)
+	}
+	if track, _ := p.Position(); track != 3 {
+		t.Fatalf("Expected the queue to be done, got track %v", track)
+	}
+}
+
+func TestPlayerPauseSeek(t *testing.T) {
+	fake := &fakeBackend{}
+	p := &Player{Backend: fake, BlockSize: 2}
+	frames := []wave.Frame{1, 2, 3, 4, 5, 6}
+	p.Enqueue(QueueItem{Decoder: NewFrameDecoder(frames, wave.NewWaveFmt(1, 1, 44100, 16, nil))})
+
+	seeked := false
+	p.OnPosition = func(track int, sample int64) {
+		if !seeked {
+			seeked = true
+			if err := p.SeekSample(1); err != nil {
+				t.Error(err)
+			}
+			p.Pause()
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				p.Resume()
+			}()
+		}
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(); err == nil {
+		t.Fatal("Expected an error when starting twice")
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	played := []wave.Frame{}
+	silent := 0
+	for _, f := range fake.written {
+		if f == 0 {
+			silent++
+			continue
+		}
+		played = append(played, f)
+	}
+	expected := []wave.Frame{1, 2, 2, 3, 4, 5, 6}
+	if len(played) != len(expected) || silent == 0 {
+		t.Fatalf("Expected %v with silence while paused, got %v", expected, fake.written)
+	}
+	for i := range expected {
+		if played[i] != expected[i] {
+			t.Fatalf("Expected %v after seeking back, got %v", expected, played)
+		}
+	}
+	if err := p.SeekSample(0); err == nil {
+		t.Fatal("Expected an error when seeking past the end of the queue")
+	}
+}
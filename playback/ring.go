@@ -0,0 +1,103 @@
+package playback
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// RingBuffer is a queue of frames for a single producer and a single consumer, which never
+// locks so a real-time callback doesn't have to wait on the goroutine filling it
+type RingBuffer struct {
+	// accessed atomically, kept first for alignment on 32-bit systems
+	read      uint64 // total frames popped
+	write     uint64 // total frames pushed
+	overruns  uint64
+	underruns uint64
+
+	buf  []wave.Frame
+	mask uint64
+
+	// OnXrun, when set, is called by Push on an overrun and by Pop on an underrun, with the
+	// amount of frames which didn't fit or were missing. It runs on the goroutine of the
+	// caller, so it should return quickly.
+	OnXrun func(overrun bool, missed int)
+}
+
+// NewRingBuffer creates a ring buffer holding at least size frames, the size is rounded
+// up to a power of two
+func NewRingBuffer(size int) (*RingBuffer, error) {
+	if size <= 0 {
+		return nil, errors.New("Ring buffer size should be positive")
+	}
+	n := 1
+	for n < size {
+		n *= 2
+	}
+	return &RingBuffer{
+		buf:  make([]wave.Frame, n),
+		mask: uint64(n - 1),
+	}, nil
+}
+
+// Push adds as many frames as fit and returns how many were added.
+// Not adding all the frames counts as an overrun.
+func (r *RingBuffer) Push(frames []wave.Frame) int {
+	write := atomic.LoadUint64(&r.write)
+	free := uint64(len(r.buf)) - (write - atomic.LoadUint64(&r.read))
+	n := uint64(len(frames))
+	if n > free {
+		n = free
+		atomic.AddUint64(&r.overruns, 1)
+		if r.OnXrun != nil {
+			defer r.OnXrun(true, len(frames)-int(n))
+		}
+	}
+	for i := uint64(0); i < n; i++ {
+		r.buf[(write+i)&r.mask] = frames[i]
+	}
+	atomic.StoreUint64(&r.write, write+n)
+	return int(n)
+}
+
+// Pop takes as many frames as available, up to len(frames), and returns how many were taken.
+// Not filling all the frames counts as an underrun, the remaining frames are left untouched.
+func (r *RingBuffer) Pop(frames []wave.Frame) int {
+	read := atomic.LoadUint64(&r.read)
+	available := atomic.LoadUint64(&r.write) - read
+	n := uint64(len(frames))
+	if n > available {
+		n = available
+		atomic.AddUint64(&r.underruns, 1)
+		if r.OnXrun != nil {
+			defer r.OnXrun(false, len(frames)-int(n))
+		}
+	}
+	for i := uint64(0); i < n; i++ {
+		frames[i] = r.buf[(read+i)&r.mask]
+	}
+	atomic.StoreUint64(&r.read, read+n)
+	return int(n)
+}
+
+// Len returns the amount of frames waiting to be popped
+func (r *RingBuffer) Len() int {
+	read := atomic.LoadUint64(&r.read)
+	return int(atomic.LoadUint64(&r.write) - read)
+}
+
+// Cap returns the amount of frames the buffer can hold
+func (r *RingBuffer) Cap() int {
+	return len(r.buf)
+}
+
+// Overruns returns how often frames were pushed which didn't fit
+func (r *RingBuffer) Overruns() uint64 {
+	return atomic.LoadUint64(&r.overruns)
+}
+
+// Underruns returns how often fewer frames than requested could be popped
+func (r *RingBuffer) Underruns() uint64 {
+	return atomic.LoadUint64(&r.underruns)
+}
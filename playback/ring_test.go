@@ -0,0 +1,79 @@
+package playback
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestRingBuffer(t *testing.T) {
+	r, err := NewRingBuffer(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Cap() != 8 {
+		t.Fatalf("Expected the capacity rounded up to 8, got %v", r.Cap())
+	}
+	out := make([]wave.Frame, 8)
+	for round := 0; round < 3; round++ {
+		if n := r.Push([]wave.Frame{1, 2, 3, 4, 5, 6}); n != 6 || r.Len() != 6 {
+			t.Fatalf("Expected 6 frames pushed, got %v", n)
+		}
+		if n := r.Pop(out[:4]); n != 4 || out[0] != 1 || out[3] != 4 {
+			t.Fatalf("Expected 1 to 4, got %v", out[:n])
+		}
+		if n := r.Pop(out[:2]); n != 2 || out[1] != 6 {
+			t.Fatalf("Expected 5 and 6 after wrapping around, got %v", out[:n])
+		}
+	}
+	if r.Overruns() != 0 || r.Underruns() != 0 {
+		t.Fatal("Expected no overruns or underruns")
+	}
+
+	missed := map[bool]int{}
+	r.OnXrun = func(overrun bool, n int) {
+		missed[overrun] += n
+	}
+	if n := r.Push(make([]wave.Frame, 10)); n != 8 || r.Overruns() != 1 || missed[true] != 2 {
+		t.Fatalf("Expected 8 frames to fit and an overrun of 2, got %v and %v", n, missed)
+	}
+	if n := r.Pop(make([]wave.Frame, 11)); n != 8 || r.Underruns() != 1 || missed[false] != 3 {
+		t.Fatalf("Expected 8 frames and an underrun of 3, got %v and %v", n, missed)
+	}
+}
+
+func TestRingBufferConcurrent(t *testing.T) {
+	r, _ := NewRingBuffer(64)
+	total := 100000
+	go func() {
+		block := make([]wave.Frame, 16)
+		for i := 0; i < total; {
+			for j := range block {
+				block[j] = wave.Frame(i + j)
+			}
+			n := len(block)
+			if total-i < n {
+				n = total - i
+			}
+			pushed := r.Push(block[:n])
+			if pushed == 0 {
+				runtime.Gosched()
+			}
+			i += pushed
+		}
+	}()
+	block := make([]wave.Frame, 10)
+	for i := 0; i < total; {
+		n := r.Pop(block)
+		if n == 0 {
+			runtime.Gosched()
+		}
+		for j := 0; j < n; j++ {
+			if block[j] != wave.Frame(i+j) {
+				t.Fatalf("Expected %v, got %v", i+j, block[j])
+			}
+		}
+		i += n
+	}
+}
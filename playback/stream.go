@@ -0,0 +1,94 @@
+package playback
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Callback fills out with interleaved frames and returns how many it has written.
+// Returning less than len(out) ends the stream once those frames are played.
+type Callback func(out []wave.Frame) int
+
+// Stream renders audio in real time by pulling blocks of a fixed size from a callback
+type Stream struct {
+	Config
+	// OnXrun, when set, is called from the stream goroutine with the total amount of xruns
+	// after every block during which the output had an xrun
+	OnXrun func(total uint64)
+
+	out   Output
+	clock int64 // samples per channel handed to the output
+	worker
+	xrunWatch
+}
+
+// NewStream opens an output on the backend, of which the callback will be asked for
+// blocks of cfg.BlockSize frames per channel
+func NewStream(b Backend, cfg Config) (*Stream, error) {
+	if cfg.BlockSize == 0 {
+		cfg.BlockSize = defaultBlockSize
+	}
+	out, err := b.OpenOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{
+		Config: cfg,
+		out:    out,
+	}, nil
+}
+
+// Start calls the callback for every block on a separate goroutine until the callback
+// ends the stream or Stop is called
+func (s *Stream) Start(cb Callback) error {
+	return s.start(func() error {
+		block := make([]wave.Frame, s.BlockSize*s.Channels)
+		for !s.stopping() {
+			n := cb(block)
+			if n > len(block) {
+				n = len(block)
+			}
+			if err := s.out.Write(block[:n]); err != nil {
+				return err
+			}
+			atomic.AddInt64(&s.clock, int64(n/s.Channels))
+			s.check(s.OnXrun, s.out)
+			if n < len(block) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Time returns the sample clock of the stream, the amount of samples per channel rendered
+func (s *Stream) Time() int64 {
+	return atomic.LoadInt64(&s.clock)
+}
+
+// Seconds returns the sample clock in seconds
+func (s *Stream) Seconds() float64 {
+	return float64(s.Time()) / float64(s.SampleRate)
+}
+
+// Xruns returns the amount of xruns counted by the output
+func (s *Stream) Xruns() uint64 {
+	return StreamXruns(s.out)
+}
+
+// Latency returns the latency reported by the output, 0 when the backend doesn't know
+func (s *Stream) Latency() time.Duration {
+	return StreamLatency(s.out)
+}
+
+// Wait blocks until the callback ends the stream and closes it
+func (s *Stream) Wait() error {
+	return s.wait(s.out.Close)
+}
+
+// Stop ends the stream after the current block and closes it
+func (s *Stream) Stop() error {
+	return s.halt(s.out.Close)
+}
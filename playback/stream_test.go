@@ -0,0 +1,100 @@
+package playback
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestStream(t *testing.T) {
+	fake := &fakeBackend{}
+	s, err := NewStream(fake, Config{SampleRate: 100, Channels: 2, BlockSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	err = s.Start(func(out []wave.Frame) int {
+		calls++
+		if len(out) != 20 {
+			t.Errorf("Expected blocks of 20 frames, got %v", len(out))
+		}
+		for i := range out {
+			out[i] = wave.Frame(calls)
+		}
+		if calls == 4 {
+			return 10
+		}
+		return len(out)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.written) != 70 || fake.written[69] != 4 || !fake.closed {
+		t.Fatalf("Expected 70 frames written and the output closed, got %v", len(fake.written))
+	}
+	if s.Time() != 35 || s.Seconds() != .35 {
+		t.Fatalf("Expected the clock at 35 samples, got %v", s.Time())
+	}
+	if err := s.Start(func(out []wave.Frame) int { return 0 }); err == nil {
+		t.Fatal("Expected an error when starting a stream twice")
+	}
+}
+
+func TestStreamStop(t *testing.T) {
+	fake := &fakeBackend{}
+	s, _ := NewStream(fake, Config{SampleRate: 100, Channels: 1})
+	started := make(chan bool)
+	s.Start(func(out []wave.Frame) int {
+		select {
+		case started <- true:
+		default:
+		}
+		return len(out)
+	})
+	<-started
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if !fake.closed || s.Time()%defaultBlockSize != 0 {
+		t.Fatalf("Expected whole blocks written before stopping, got %v", s.Time())
+	}
+}
+
+func TestStreamXruns(t *testing.T) {
+	fake := &fakeBackend{}
+	s, _ := NewStream(fake, Config{SampleRate: 100, Channels: 1, BlockSize: 10})
+	reported := []uint64{}
+	s.OnXrun = func(total uint64) {
+		reported = append(reported, total)
+	}
+	log := bytes.Buffer{}
+	SetLogger(slog.New(slog.NewTextHandler(&log, nil)))
+	defer SetLogger(nil)
+	blocks := 0
+	s.Start(func(out []wave.Frame) int {
+		// the output had an xrun during the second and third block
+		blocks++
+		if blocks == 2 || blocks == 3 {
+			fake.xruns++
+		}
+		if blocks == 5 {
+			return 0
+		}
+		return len(out)
+	})
+	if err := s.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != 2 || reported[1] != 2 || s.Xruns() != 2 {
+		t.Fatalf("Expected two xruns reported, got %v", reported)
+	}
+	if n := strings.Count(log.String(), "level=WARN msg=xrun"); n != 2 {
+		t.Fatalf("Expected two xruns logged, got %q", log.String())
+	}
+}
@@ -0,0 +1,322 @@
+//go:build windows && (amd64 || arm64)
+// +build windows
+// +build amd64 arm64
+
+package playback
+
+// WASAPI backend calling the COM interfaces directly, so it works without cgo.
+// The stream is opened in shared mode and Windows converts the sample rate when it
+// differs from the rate of the mixer.
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(wasapi{})
+}
+
+var (
+	ole32            = syscall.NewLazyDLL("ole32.dll")
+	coInitializeEx   = ole32.NewProc("CoInitializeEx")
+	coCreateInstance = ole32.NewProc("CoCreateInstance")
+	coTaskMemFree    = ole32.NewProc("CoTaskMemFree")
+
+	clsidMMDeviceEnumerator = guid{0xBCDE0395, 0xE52F, 0x467C, [8]byte{0x8E, 0x3D, 0xC4, 0x57, 0x92, 0x91, 0x69, 0x2E}}
+	iidIMMDeviceEnumerator  = guid{0xA95664D2, 0x9614, 0x4F35, [8]byte{0xA7, 0x46, 0xDE, 0x8D, 0xB6, 0x36, 0x17, 0xE6}}
+	iidIAudioClient         = guid{0x1CB9AD4C, 0xDBFA, 0x4C32, [8]byte{0xB1, 0x78, 0xC2, 0xF5, 0x68, 0xA7, 0x03, 0xB2}}
+	iidIAudioRenderClient   = guid{0xF294ACFC, 0x3146, 0x4483, [8]byte{0xA7, 0xBF, 0xAD, 0xDC, 0xA7, 0xC2, 0x60, 0xE2}}
+	subtypeIEEEFloat        = guid{0x00000003, 0x0000, 0x0010, [8]byte{0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}}
+
+	comOnce sync.Once
+)
+
+// constants of the Windows SDK
+const (
+	clsctxAll              = 0x17
+	coinitMultithreaded    = 0
+	eRender                = 0
+	eConsole               = 0
+	audclntSharemodeShared = 0
+	audclntAutoConvertPCM  = 0x80000000
+	audclntSRCDefault      = 0x08000000
+	waveFormatExtensible   = 0xFFFE
+
+	// vtable indices of the methods, which follow the three methods of IUnknown
+	methodRelease                 = 2  // IUnknown
+	methodGetDefaultAudioEndpoint = 4  // IMMDeviceEnumerator
+	methodActivate                = 3  // IMMDevice
+	methodInitialize              = 3  // IAudioClient
+	methodGetBufferSize           = 4  // IAudioClient
+	methodGetStreamLatency        = 5  // IAudioClient
+	methodGetCurrentPadding       = 6  // IAudioClient
+	methodGetMixFormat            = 8  // IAudioClient
+	methodStart                   = 10 // IAudioClient
+	methodStop                    = 11 // IAudioClient
+	methodGetService              = 14 // IAudioClient
+	methodGetBuffer               = 3  // IAudioRenderClient
+	methodReleaseBuffer           = 4  // IAudioRenderClient
+)
+
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// waveFormatExt is WAVEFORMATEXTENSIBLE
+type waveFormatExt struct {
+	FormatTag      uint16
+	Channels       uint16
+	SamplesPerSec  uint32
+	AvgBytesPerSec uint32
+	BlockAlign     uint16
+	BitsPerSample  uint16
+	Size           uint16
+	ValidBits      uint16
+	ChannelMask    uint32
+	SubFormat      guid
+}
+
+// comObject is a COM interface, of which the first field points to the vtable
+type comObject struct {
+	vtable *[16]uintptr
+}
+
+// call invokes method i of the vtable of the object
+func (o *comObject) call(i int, args ...uintptr) error {
+	args = append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)
+	n := len(args)
+	for len(args) < 9 {
+		args = append(args, 0)
+	}
+	hr, _, _ := syscall.Syscall9(o.vtable[i], uintptr(n),
+		args[0], args[1], args[2], args[3], args[4], args[5], args[6], args[7], args[8])
+	if int32(hr) < 0 {
+		return fmt.Errorf("WASAPI: HRESULT %#x", uint32(hr))
+	}
+	return nil
+}
+
+func (o *comObject) release() {
+	if o != nil {
+		o.call(methodRelease)
+	}
+}
+
+// initCOM joins the multithreaded apartment, so COM objects can be used from any goroutine
+func initCOM() {
+	comOnce.Do(func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		coInitializeEx.Call(0, coinitMultithreaded)
+	})
+}
+
+// wasapi plays through the default endpoint of the Windows audio session API
+type wasapi struct{}
+
+func (wasapi) Name() string {
+	return "wasapi"
+}
+
+// Devices returns the default output device in the format of the mixer
+func (wasapi) Devices() ([]Device, error) {
+	client, err := defaultAudioClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.release()
+	var format *waveFormatExt
+	if err := client.call(methodGetMixFormat, uintptr(unsafe.Pointer(&format))); err != nil {
+		return nil, err
+	}
+	defer coTaskMemFree.Call(uintptr(unsafe.Pointer(format)))
+	return []Device{{
+		Backend:        "wasapi",
+		Description:    "Default output device",
+		OutputChannels: int(format.Channels),
+		// other rates are converted by Windows
+		SampleRates:   []int{int(format.SamplesPerSec)},
+		DefaultOutput: true,
+	}}, nil
+}
+
+// defaultAudioClient activates an audio client on the default output device
+func defaultAudioClient() (*comObject, error) {
+	initCOM()
+
+	var enum *comObject
+	hr, _, _ := coCreateInstance.Call(uintptr(unsafe.Pointer(&clsidMMDeviceEnumerator)), 0, clsctxAll,
+		uintptr(unsafe.Pointer(&iidIMMDeviceEnumerator)), uintptr(unsafe.Pointer(&enum)))
+	if int32(hr) < 0 {
+		return nil, fmt.Errorf("WASAPI: can't create device enumerator: HRESULT %#x", uint32(hr))
+	}
+	defer enum.release()
+
+	var device *comObject
+	if err := enum.call(methodGetDefaultAudioEndpoint, eRender, eConsole, uintptr(unsafe.Pointer(&device))); err != nil {
+		return nil, err
+	}
+	defer device.release()
+
+	var client *comObject
+	if err := device.call(methodActivate, uintptr(unsafe.Pointer(&iidIAudioClient)), clsctxAll, 0,
+		uintptr(unsafe.Pointer(&client))); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (wasapi) OpenOutput(cfg Config) (Output, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Device != "" {
+		return nil, errors.New("WASAPI only supports the default device")
+	}
+	client, err := defaultAudioClient()
+	if err != nil {
+		return nil, err
+	}
+	o := &wasapiOutput{client: client, channels: cfg.Channels, rate: cfg.SampleRate}
+
+	format := waveFormatExt{
+		FormatTag:      waveFormatExtensible,
+		Channels:       uint16(cfg.Channels),
+		SamplesPerSec:  uint32(cfg.SampleRate),
+		AvgBytesPerSec: uint32(cfg.SampleRate * cfg.Channels * 4),
+		BlockAlign:     uint16(cfg.Channels * 4),
+		BitsPerSample:  32,
+		Size:           22,
+		ValidBits:      32,
+		SubFormat:      subtypeIEEEFloat,
+	}
+	block := cfg.BlockSize
+	if block == 0 {
+		block = defaultBlockSize
+	}
+	// the buffer holds a few blocks, in units of 100ns
+	duration := uintptr(int64(4*block) * 1e7 / int64(cfg.SampleRate))
+	if err := o.client.call(methodInitialize, audclntSharemodeShared, audclntAutoConvertPCM|audclntSRCDefault,
+		duration, 0, uintptr(unsafe.Pointer(&format)), 0); err != nil {
+		o.client.release()
+		return nil, err
+	}
+	if err := o.client.call(methodGetBufferSize, uintptr(unsafe.Pointer(&o.size))); err != nil {
+		o.client.release()
+		return nil, err
+	}
+	if err := o.client.call(methodGetService, uintptr(unsafe.Pointer(&iidIAudioRenderClient)),
+		uintptr(unsafe.Pointer(&o.render))); err != nil {
+		o.client.release()
+		return nil, err
+	}
+	o.wait = time.Duration(o.size) * time.Second / time.Duration(4*cfg.SampleRate)
+	return o, nil
+}
+
+// wasapiOutput is an open shared-mode render stream
+type wasapiOutput struct {
+	client   *comObject
+	render   *comObject
+	channels int
+	rate     int
+	size     uint32 // frames in the buffer of the endpoint
+	wait     time.Duration
+	started  bool
+	xruns    uint64
+}
+
+// Xruns returns how often the buffer of the endpoint had been played completely before a write
+func (o *wasapiOutput) Xruns() uint64 {
+	return atomic.LoadUint64(&o.xruns)
+}
+
+// Latency returns the latency of the stream reported by Windows, plus the buffer of the endpoint
+func (o *wasapiOutput) Latency() time.Duration {
+	var stream int64 // in units of 100ns
+	if err := o.client.call(methodGetStreamLatency, uintptr(unsafe.Pointer(&stream))); err != nil {
+		stream = 0
+	}
+	return time.Duration(stream)*100 + time.Duration(o.size)*time.Second/time.Duration(o.rate)
+}
+
+// available returns the amount of frames which can be written to the buffer
+func (o *wasapiOutput) available() (int, error) {
+	var padding uint32
+	if err := o.client.call(methodGetCurrentPadding, uintptr(unsafe.Pointer(&padding))); err != nil {
+		return 0, err
+	}
+	return int(o.size - padding), nil
+}
+
+func (o *wasapiOutput) Write(frames []wave.Frame) error {
+	total := len(frames) / o.channels
+	for done := 0; done < total; {
+		n, err := o.available()
+		if err != nil {
+			return err
+		}
+		if o.started && done == 0 && n == int(o.size) {
+			atomic.AddUint64(&o.xruns, 1)
+		}
+		if n == 0 {
+			time.Sleep(o.wait)
+			continue
+		}
+		if n > total-done {
+			n = total - done
+		}
+		var data *float32
+		if err := o.render.call(methodGetBuffer, uintptr(n), uintptr(unsafe.Pointer(&data))); err != nil {
+			return err
+		}
+		buf := (*[1 << 28]float32)(unsafe.Pointer(data))[: n*o.channels : n*o.channels]
+		wave.FramesToFloat32(buf, frames[done*o.channels:])
+		if err := o.render.call(methodReleaseBuffer, uintptr(n), 0); err != nil {
+			return err
+		}
+		done += n
+		if !o.started {
+			if err := o.client.call(methodStart); err != nil {
+				return err
+			}
+			o.started = true
+		}
+	}
+	return nil
+}
+
+// Close waits for the buffer to be played and releases the stream
+func (o *wasapiOutput) Close() error {
+	if o.client == nil {
+		return nil
+	}
+	var err error
+	for o.started {
+		n, aerr := o.available()
+		if aerr != nil || n == int(o.size) {
+			err = aerr
+			break
+		}
+		time.Sleep(o.wait)
+	}
+	if o.started {
+		o.client.call(methodStop)
+	}
+	o.render.release()
+	o.client.release()
+	o.client, o.render = nil, nil
+	return err
+}
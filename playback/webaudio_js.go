@@ -0,0 +1,241 @@
+//go:build js && wasm
+// +build js,wasm
+
+package playback
+
+// Web Audio backend for programs compiled to WebAssembly. The frames are posted to an
+// AudioWorklet, which plays them on the audio thread of the browser.
+// The output has to be opened from a goroutine rather than from a JavaScript callback,
+// as it waits for the browser, and browsers only start audio after a user gesture.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func init() {
+	Register(webAudio{})
+}
+
+// workletSource is the processor running in the AudioWorkletGlobalScope. It plays the blocks
+// it receives in order and reports back how many samples per channel it played.
+const workletSource = `
+class GoAudioProcessor extends AudioWorkletProcessor {
+	constructor(options) {
+		super();
+		this.channels = options.processorOptions.channels;
+		this.queue = [];
+		this.offset = 0;
+		this.port.onmessage = (e) => this.queue.push(e.data);
+	}
+	process(inputs, outputs) {
+		const out = outputs[0];
+		let played = 0;
+		for (let i = 0; i < out[0].length && this.queue.length > 0; i++) {
+			const block = this.queue[0];
+			for (let c = 0; c < out.length && c < this.channels; c++) {
+				out[c][i] = block[this.offset + c];
+			}
+			this.offset += this.channels;
+			played++;
+			if (this.offset >= block.length) {
+				this.queue.shift();
+				this.offset = 0;
+			}
+		}
+		if (played > 0) {
+			this.port.postMessage(played);
+		}
+		return true;
+	}
+}
+registerProcessor("goaudio-output", GoAudioProcessor);
+`
+
+// webAudio plays through an AudioContext of the browser
+type webAudio struct{}
+
+func (webAudio) Name() string {
+	return "webaudio"
+}
+
+// await blocks until a promise settles and returns its value
+func await(promise js.Value) (js.Value, error) {
+	done := make(chan struct{})
+	var (
+		result js.Value
+		err    error
+	)
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			result = args[0]
+		}
+		close(done)
+		return nil
+	})
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = fmt.Errorf("Web Audio: %v", args[0].Call("toString").String())
+		close(done)
+		return nil
+	})
+	defer then.Release()
+	defer catch.Release()
+	promise.Call("then", then, catch)
+	<-done
+	return result, err
+}
+
+func (webAudio) OpenOutput(cfg Config) (Output, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Device != "" {
+		return nil, errors.New("Web Audio only supports the default device")
+	}
+	ctor := js.Global().Get("AudioContext")
+	if ctor.IsUndefined() {
+		ctor = js.Global().Get("webkitAudioContext")
+	}
+	if ctor.IsUndefined() {
+		return nil, errors.New("Web Audio is not available")
+	}
+	ctx := ctor.New(map[string]interface{}{"sampleRate": cfg.SampleRate})
+	if ctx.Get("audioWorklet").IsUndefined() {
+		ctx.Call("close")
+		return nil, errors.New("AudioWorklet is not available, the page needs a secure context")
+	}
+
+	blob := js.Global().Get("Blob").New([]interface{}{workletSource},
+		map[string]interface{}{"type": "application/javascript"})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	_, err := await(ctx.Get("audioWorklet").Call("addModule", url))
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+	if err != nil {
+		ctx.Call("close")
+		return nil, err
+	}
+
+	node := js.Global().Get("AudioWorkletNode").New(ctx, "goaudio-output", map[string]interface{}{
+		"numberOfInputs":     0,
+		"outputChannelCount": []interface{}{cfg.Channels},
+		"processorOptions":   map[string]interface{}{"channels": cfg.Channels},
+	})
+	node.Call("connect", ctx.Get("destination"))
+
+	block := cfg.BlockSize
+	if block == 0 {
+		block = defaultBlockSize
+	}
+	o := &webAudioOutput{
+		ctx:      ctx,
+		node:     node,
+		channels: cfg.Channels,
+		rate:     cfg.SampleRate,
+		limit:    4 * block,
+		played:   make(chan struct{}, 1),
+	}
+	o.onMessage = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		o.mu.Lock()
+		o.queued -= args[0].Get("data").Int()
+		o.mu.Unlock()
+		select {
+		case o.played <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	node.Get("port").Set("onmessage", o.onMessage)
+	// browsers suspend contexts created without a user gesture
+	ctx.Call("resume")
+	return o, nil
+}
+
+// webAudioOutput is an AudioWorkletNode connected to the speakers
+type webAudioOutput struct {
+	ctx       js.Value
+	node      js.Value
+	onMessage js.Func
+	channels  int
+	rate      int
+	limit     int           // samples per channel posted ahead of the worklet
+	queued    int           // samples per channel posted but not yet played
+	played    chan struct{} // signalled when the worklet has played samples
+	started   bool
+	xruns     uint64
+	mu        sync.Mutex
+}
+
+// Xruns returns how often the worklet had played everything before the next write
+func (o *webAudioOutput) Xruns() uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.xruns
+}
+
+// Latency returns the latency of the context and the frames waiting in the worklet
+func (o *webAudioOutput) Latency() time.Duration {
+	seconds := 0.0
+	for _, prop := range []string{"baseLatency", "outputLatency"} {
+		if v := o.ctx.Get(prop); v.Type() == js.TypeNumber {
+			seconds += v.Float()
+		}
+	}
+	return time.Duration(seconds*float64(time.Second)) + time.Duration(o.pending())*time.Second/time.Duration(o.rate)
+}
+
+// pending returns the amount of samples per channel waiting in the worklet
+func (o *webAudioOutput) pending() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.queued
+}
+
+func (o *webAudioOutput) Write(frames []wave.Frame) error {
+	n := len(frames) / o.channels
+	if n == 0 {
+		return nil
+	}
+	for o.pending() > o.limit {
+		<-o.played
+	}
+	buf := make([]byte, 4*n*o.channels)
+	for i, f := range frames[:n*o.channels] {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(f)))
+	}
+	bytes := js.Global().Get("Uint8Array").New(len(buf))
+	js.CopyBytesToJS(bytes, buf)
+	block := js.Global().Get("Float32Array").New(bytes.Get("buffer"))
+
+	o.mu.Lock()
+	if o.started && o.queued == 0 {
+		o.xruns++
+	}
+	o.started = true
+	o.queued += n
+	o.mu.Unlock()
+	o.node.Get("port").Call("postMessage", block, []interface{}{bytes.Get("buffer")})
+	return nil
+}
+
+// Close waits for the posted frames to be played and closes the context
+func (o *webAudioOutput) Close() error {
+	if o.ctx.IsUndefined() {
+		return nil
+	}
+	for o.pending() > 0 {
+		<-o.played
+	}
+	o.node.Call("disconnect")
+	o.node.Get("port").Set("onmessage", js.Null())
+	o.onMessage.Release()
+	_, err := await(o.ctx.Call("close"))
+	o.ctx = js.Undefined()
+	return err
+}
@@ -0,0 +1,79 @@
+package playback
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// worker runs the loop of a stream on its own goroutine until it ends or is stopped
+type worker struct {
+	stop    int32
+	started bool
+	closed  bool
+	done    chan struct{}
+	err     error
+	mu      sync.Mutex
+}
+
+// start runs the loop, which should check stopping between blocks
+func (w *worker) start(loop func() error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started {
+		return errors.New("Already started")
+	}
+	w.started = true
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		w.err = loop()
+	}()
+	return nil
+}
+
+// stopping returns true once the loop is asked to stop
+func (w *worker) stopping() bool {
+	return atomic.LoadInt32(&w.stop) != 0
+}
+
+// wait waits for the loop to end and closes the devices once
+func (w *worker) wait(close func() error) error {
+	w.mu.Lock()
+	done := w.done
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		if err := close(); w.err == nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+// halt asks the loop to stop, waits for it and closes the devices
+func (w *worker) halt(close func() error) error {
+	atomic.StoreInt32(&w.stop, 1)
+	return w.wait(close)
+}
+
+// finished returns true once the loop has ended
+func (w *worker) finished() bool {
+	w.mu.Lock()
+	done := w.done
+	w.mu.Unlock()
+	if done == nil {
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
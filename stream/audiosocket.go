@@ -0,0 +1,209 @@
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// SampleEncoding is how the samples of an audio message are stored
+type SampleEncoding uint8
+
+const (
+	FLOAT32 SampleEncoding = iota // little endian floats, a Float32Array in the browser
+	INT16                         // little endian 16 bit integers, an Int16Array in the browser
+	CODEC                         // compressed by the codec of the socket, e.g. Opus
+)
+
+// audioHeaderSize is the size of the header in front of the samples of an audio message
+const audioHeaderSize = 20
+
+// AudioHeader describes the block of an audio message. In the message it is stored little endian as
+//
+//	seq         uint32
+//	timestamp   uint64, in samples per channel
+//	sample rate uint32
+//	channels    uint8
+//	encoding    uint8
+//	reserved    uint16
+type AudioHeader struct {
+	Seq        uint32
+	Timestamp  uint64
+	SampleRate int
+	Channels   int
+	Encoding   SampleEncoding
+}
+
+// EncodeAudioMessage stores a header and its samples in a message
+func EncodeAudioMessage(h AudioHeader, samples []byte) []byte {
+	msg := make([]byte, audioHeaderSize+len(samples))
+	binary.LittleEndian.PutUint32(msg[0:], h.Seq)
+	binary.LittleEndian.PutUint64(msg[4:], h.Timestamp)
+	binary.LittleEndian.PutUint32(msg[12:], uint32(h.SampleRate))
+	msg[16] = byte(h.Channels)
+	msg[17] = byte(h.Encoding)
+	copy(msg[audioHeaderSize:], samples)
+	return msg
+}
+
+// DecodeAudioMessage splits a message into its header and samples
+func DecodeAudioMessage(msg []byte) (AudioHeader, []byte, error) {
+	if len(msg) < audioHeaderSize {
+		return AudioHeader{}, nil, errors.New("Message is too short for an audio header")
+	}
+	h := AudioHeader{
+		Seq:        binary.LittleEndian.Uint32(msg[0:]),
+		Timestamp:  binary.LittleEndian.Uint64(msg[4:]),
+		SampleRate: int(binary.LittleEndian.Uint32(msg[12:])),
+		Channels:   int(msg[16]),
+		Encoding:   SampleEncoding(msg[17]),
+	}
+	if h.Channels < 1 {
+		return AudioHeader{}, nil, errors.New("Audio message without channels")
+	}
+	return h, msg[audioHeaderSize:], nil
+}
+
+// AudioSocket streams blocks of frames over a WebSocket in both directions, a message per block
+type AudioSocket struct {
+	*WebSocket
+	SampleRate int
+	Channels   int
+	Encoding   SampleEncoding
+	Codec      PayloadCodec // used for the CODEC encoding
+
+	seq       uint32
+	timestamp uint64
+	buf       []wave.Frame // decoded frames, reused by Read
+	pending   []wave.Frame // the rest of the message being read by Read
+}
+
+// NewAudioSocket sends frames at a sample rate and channel count over a WebSocket as floats
+func NewAudioSocket(ws *WebSocket, sr, channels int) (*AudioSocket, error) {
+	if channels < 1 || channels > 255 {
+		return nil, errors.New("Need between 1 and 255 channels")
+	}
+	return &AudioSocket{
+		WebSocket:  ws,
+		SampleRate: sr,
+		Channels:   channels,
+		Encoding:   FLOAT32,
+	}, nil
+}
+
+// WriteFrames sends a block of frames in a single message
+func (s *AudioSocket) WriteFrames(frames []wave.Frame) error {
+	frames = frames[:len(frames)/s.Channels*s.Channels]
+	var samples []byte
+	switch s.Encoding {
+	case FLOAT32:
+		samples = make([]byte, 4*len(frames))
+		for i, f := range frames {
+			binary.LittleEndian.PutUint32(samples[4*i:], math.Float32bits(float32(f)))
+		}
+	case INT16:
+		samples = make([]byte, 2*len(frames))
+		for i, f := range frames {
+			v := math.Max(-1, math.Min(1, float64(f)))
+			binary.LittleEndian.PutUint16(samples[2*i:], uint16(int16(v*math.MaxInt16)))
+		}
+	case CODEC:
+		if s.Codec == nil {
+			return errors.New("Need a codec for the CODEC encoding")
+		}
+		var err error
+		if samples, err = s.Codec.Encode(frames); err != nil {
+			return err
+		}
+	default:
+		return errors.New("Unknown sample encoding")
+	}
+	msg := EncodeAudioMessage(AudioHeader{
+		Seq:        s.seq,
+		Timestamp:  s.timestamp,
+		SampleRate: s.SampleRate,
+		Channels:   s.Channels,
+		Encoding:   s.Encoding,
+	}, samples)
+	s.seq++
+	s.timestamp += uint64(len(frames) / s.Channels)
+	return s.WriteMessage(true, msg)
+}
+
+// ReadFrames waits for the next audio message and decodes its frames. Text messages are
+// skipped, they are left to the application for control.
+func (s *AudioSocket) ReadFrames() (AudioHeader, []wave.Frame, error) {
+	h, samples, err := s.readAudio()
+	if err != nil {
+		return h, nil, err
+	}
+	frames, err := s.decode(nil, h, samples)
+	return h, frames, err
+}
+
+// Format returns a format of 32 bit floats at the rate and channels of the socket, which the
+// messages of the other side are expected to match
+func (s *AudioSocket) Format() wave.WaveFmt {
+	return wave.NewWaveFmt(wave.IEEE_FLOAT, s.Channels, s.SampleRate, 32, nil)
+}
+
+// Read decodes up to len(dst) frames of the incoming messages into dst, so the socket is a
+// wave.Decoder and a stream can be read with one buffer. The rest of a message is returned by
+// the next call, a close of the other side by io.EOF. Messages in the CODEC encoding are
+// decoded by the codec, which may allocate.
+func (s *AudioSocket) Read(dst []wave.Frame) (int, error) {
+	if len(s.pending) == 0 {
+		h, samples, err := s.readAudio()
+		if err != nil {
+			return 0, err
+		}
+		if s.buf, err = s.decode(s.buf[:0], h, samples); err != nil {
+			return 0, err
+		}
+		s.pending = s.buf
+	}
+	n := copy(dst, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// readAudio waits for the next binary message and splits it into its header and samples
+func (s *AudioSocket) readAudio() (AudioHeader, []byte, error) {
+	for {
+		isBinary, msg, err := s.ReadMessage()
+		if err != nil {
+			return AudioHeader{}, nil, err
+		}
+		if isBinary {
+			return DecodeAudioMessage(msg)
+		}
+	}
+}
+
+// decode appends the frames of the samples of a message to dst
+func (s *AudioSocket) decode(dst []wave.Frame, h AudioHeader, samples []byte) ([]wave.Frame, error) {
+	switch h.Encoding {
+	case FLOAT32:
+		for i := 0; i+4 <= len(samples); i += 4 {
+			dst = append(dst, wave.Frame(math.Float32frombits(binary.LittleEndian.Uint32(samples[i:]))))
+		}
+	case INT16:
+		for i := 0; i+2 <= len(samples); i += 2 {
+			dst = append(dst, wave.Frame(float64(int16(binary.LittleEndian.Uint16(samples[i:])))/math.MaxInt16))
+		}
+	case CODEC:
+		if s.Codec == nil {
+			return dst, errors.New("Need a codec for the CODEC encoding")
+		}
+		frames, err := s.Codec.Decode(samples)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, frames...)
+	default:
+		return dst, errors.New("Unknown sample encoding")
+	}
+	return dst, nil
+}
@@ -0,0 +1,134 @@
+package stream
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestAudioSocket(t *testing.T) {
+	// the server sends back every block it receives as 16 bit integers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		s, _ := NewAudioSocket(ws, 8000, 2)
+		s.Encoding = INT16
+		for {
+			_, frames, err := s.ReadFrames()
+			if err != nil {
+				return
+			}
+			if err := s.WriteFrames(frames); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	ws, err := DialWebSocket("ws" + strings.TrimPrefix(server.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewAudioSocket(ws, 8000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []int{2, 100, 40000} // the sizes of the frames of the messages use all three length encodings
+	var timestamp uint64
+	for i, size := range tests {
+		t.Run("", func(t *testing.T) {
+			frames := make([]wave.Frame, size)
+			for j := range frames {
+				frames[j] = wave.Frame(math.Sin(float64(j)))
+			}
+			// a text message in between is skipped
+			if err := ws.WriteMessage(false, []byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.WriteFrames(frames); err != nil {
+				t.Fatal(err)
+			}
+			h, got, err := s.ReadFrames()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if h.Seq != uint32(i) || h.Timestamp != timestamp || h.Channels != 2 || h.SampleRate != 8000 || h.Encoding != INT16 {
+				t.Fatalf("Expected block %v at %v, got %+v", i, timestamp, h)
+			}
+			if len(got) != len(frames) {
+				t.Fatalf("Expected %v frames, got %v", len(frames), len(got))
+			}
+			for j := range got {
+				if math.Abs(float64(got[j]-frames[j])) > 1e-4 {
+					t.Fatalf("Expected %v at %v, got %v", frames[j], j, got[j])
+				}
+			}
+			timestamp += uint64(size / 2)
+		})
+	}
+
+	// a block read in pieces into one buffer
+	frames := make([]wave.Frame, 1000)
+	for j := range frames {
+		frames[j] = wave.Frame(math.Cos(float64(j)))
+	}
+	if err := s.WriteFrames(frames); err != nil {
+		t.Fatal(err)
+	}
+	var d wave.Decoder = s
+	if f := d.Format(); f.SampleRate != s.SampleRate || f.NumChannels != 2 {
+		t.Fatalf("Expected the format of the socket, got %+v", f)
+	}
+	buf := make([]wave.Frame, 300)
+	for read := 0; read < len(frames); {
+		n, err := d.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := len(frames) - read; n != len(buf) && n != want {
+			t.Fatalf("Expected %v frames, got %v", want, n)
+		}
+		for j, f := range buf[:n] {
+			if math.Abs(float64(f-frames[read+j])) > 1e-4 {
+				t.Fatalf("Expected %v at %v, got %v", frames[read+j], read+j, f)
+			}
+		}
+		read += n
+	}
+
+	// the server answers pings while it waits for audio
+	if err := ws.writeFrame(wsPing, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.writeFrame(wsClose, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ws.ReadMessage(); err != io.EOF {
+		t.Fatalf("Expected the close to end the stream, got %v", err)
+	}
+	ws.conn.Close()
+}
+
+func TestUpgradeWebSocketRejects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		UpgradeWebSocket(w, r)
+	}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected a plain request to be refused, got %v", resp.Status)
+	}
+}
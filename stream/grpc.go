@@ -0,0 +1,296 @@
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Adapters between the AudioChunk messages of audio.proto and the Decoder, Encoder and
+// Processor interfaces. They depend on the getters of the generated message rather than on
+// gRPC itself, so a service wires them to its streams with small closures, e.g.
+//
+//	func (s *server) Process(srv audiopb.Audio_ProcessServer) error {
+//		return stream.ProcessChunks(
+//			func() (stream.Chunk, error) { return srv.Recv() },
+//			func(c *stream.AudioChunk) error {
+//				return srv.Send(&audiopb.AudioChunk{Seq: c.Seq, Timestamp: c.Timestamp,
+//					SampleRate: c.SampleRate, Channels: c.Channels, Samples: c.Samples})
+//			},
+//			reverb)
+//	}
+
+// Chunk is implemented by the AudioChunk message generated from audio.proto, and by AudioChunk
+type Chunk interface {
+	GetSeq() uint32
+	GetTimestamp() uint64
+	GetSampleRate() uint32
+	GetChannels() uint32
+	GetSamples() []float32
+}
+
+// AudioChunk is a block of interleaved frames with the fields of the AudioChunk message.
+// Marshal and UnmarshalAudioChunk use the protobuf wire format, so chunks can also be
+// exchanged with generated code over other transports.
+type AudioChunk struct {
+	Seq        uint32
+	Timestamp  uint64
+	SampleRate uint32
+	Channels   uint32
+	Samples    []float32
+}
+
+func (c *AudioChunk) GetSeq() uint32        { return c.Seq }
+func (c *AudioChunk) GetTimestamp() uint64  { return c.Timestamp }
+func (c *AudioChunk) GetSampleRate() uint32 { return c.SampleRate }
+func (c *AudioChunk) GetChannels() uint32   { return c.Channels }
+func (c *AudioChunk) GetSamples() []float32 { return c.Samples }
+
+// Marshal encodes the chunk in the protobuf wire format, with the samples packed
+func (c *AudioChunk) Marshal() []byte {
+	b := make([]byte, 0, 32+4*len(c.Samples))
+	for _, f := range []struct {
+		num int
+		v   uint64
+	}{{1, uint64(c.Seq)}, {2, c.Timestamp}, {3, uint64(c.SampleRate)}, {4, uint64(c.Channels)}} {
+		if f.v != 0 {
+			b = binary.AppendUvarint(b, uint64(f.num<<3))
+			b = binary.AppendUvarint(b, f.v)
+		}
+	}
+	if len(c.Samples) > 0 {
+		b = binary.AppendUvarint(b, 5<<3|2)
+		b = binary.AppendUvarint(b, uint64(4*len(c.Samples)))
+		for _, s := range c.Samples {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(s))
+		}
+	}
+	return b
+}
+
+// UnmarshalAudioChunk decodes a chunk in the protobuf wire format, skipping unknown fields
+func UnmarshalAudioChunk(b []byte) (*AudioChunk, error) {
+	c := &AudioChunk{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("Invalid field key")
+		}
+		b = b[n:]
+		num, wireType := key>>3, key&7
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errors.New("Invalid varint")
+			}
+			b = b[n:]
+			switch num {
+			case 1:
+				c.Seq = uint32(v)
+			case 2:
+				c.Timestamp = v
+			case 3:
+				c.SampleRate = uint32(v)
+			case 4:
+				c.Channels = uint32(v)
+			}
+		case 1:
+			if len(b) < 8 {
+				return nil, errors.New("Truncated fixed64")
+			}
+			b = b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return nil, errors.New("Truncated length delimited field")
+			}
+			field := b[n : n+int(size)]
+			b = b[n+int(size):]
+			if num == 5 {
+				if len(field)%4 != 0 {
+					return nil, errors.New("Packed samples are not a whole amount of floats")
+				}
+				for i := 0; i < len(field); i += 4 {
+					c.Samples = append(c.Samples, math.Float32frombits(binary.LittleEndian.Uint32(field[i:])))
+				}
+			}
+		case 5:
+			if len(b) < 4 {
+				return nil, errors.New("Truncated fixed32")
+			}
+			if num == 5 {
+				// samples written unpacked
+				c.Samples = append(c.Samples, math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			}
+			b = b[4:]
+		default:
+			return nil, errors.New("Unsupported wire type")
+		}
+	}
+	return c, nil
+}
+
+// chunkFrames converts the samples of a chunk to frames
+func chunkFrames(c Chunk) []wave.Frame {
+	return appendChunkFrames(nil, c)
+}
+
+// appendChunkFrames appends the samples of a chunk to dst as frames
+func appendChunkFrames(dst []wave.Frame, c Chunk) []wave.Frame {
+	for _, s := range c.GetSamples() {
+		dst = append(dst, wave.Frame(s))
+	}
+	return dst
+}
+
+// ChunkWriter sends frames as chunks, a chunk per write. It is a wave.Encoder.
+type ChunkWriter struct {
+	SampleRate int
+	Channels   int
+
+	send      func(c *AudioChunk) error
+	seq       uint32
+	timestamp uint64
+}
+
+// NewChunkWriter creates a writer sending chunks through send, e.g. the Send of a gRPC stream
+func NewChunkWriter(send func(c *AudioChunk) error, sr, channels int) (*ChunkWriter, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	return &ChunkWriter{SampleRate: sr, Channels: channels, send: send}, nil
+}
+
+// Write sends the frames in a chunk
+func (w *ChunkWriter) Write(frames []wave.Frame) error {
+	samples := make([]float32, len(frames)/w.Channels*w.Channels)
+	for i := range samples {
+		samples[i] = float32(frames[i])
+	}
+	c := &AudioChunk{
+		Seq:        w.seq,
+		Timestamp:  w.timestamp,
+		SampleRate: uint32(w.SampleRate),
+		Channels:   uint32(w.Channels),
+		Samples:    samples,
+	}
+	w.seq++
+	w.timestamp += uint64(len(samples) / w.Channels)
+	return w.send(c)
+}
+
+// Close does nothing, ending the stream is up to the transport
+func (w *ChunkWriter) Close() error {
+	return nil
+}
+
+// SendDecoder sends all frames of a decoder in chunks of block samples per channel
+func SendDecoder(d wave.Decoder, send func(c *AudioChunk) error, block int) error {
+	wfmt := d.Format()
+	w, err := NewChunkWriter(send, wfmt.SampleRate, wfmt.NumChannels)
+	if err != nil {
+		return err
+	}
+	frames := make([]wave.Frame, block*wfmt.NumChannels)
+	for {
+		n, err := d.Read(frames)
+		if n > 0 {
+			if err := w.Write(frames[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ChunkDecoder decodes the frames of received chunks, e.g. from the Recv of a gRPC stream
+type ChunkDecoder struct {
+	recv    func() (Chunk, error)
+	wfmt    wave.WaveFmt
+	buf     []wave.Frame // frames of the last chunk, reused for the next
+	pending []wave.Frame
+}
+
+// NewChunkDecoder waits for the first chunk to learn the format of the stream
+func NewChunkDecoder(recv func() (Chunk, error)) (*ChunkDecoder, error) {
+	c, err := recv()
+	if err != nil {
+		return nil, err
+	}
+	if c.GetChannels() < 1 {
+		return nil, errors.New("Chunk without channels")
+	}
+	wfmt, err := wave.NewValidWaveFmt(wave.PCM, int(c.GetChannels()), int(c.GetSampleRate()), 32)
+	if err != nil {
+		return nil, err
+	}
+	frames := chunkFrames(c)
+	return &ChunkDecoder{
+		recv:    recv,
+		wfmt:    wfmt,
+		buf:     frames,
+		pending: frames,
+	}, nil
+}
+
+// Format returns a 32 bit format at the rate and channels of the first chunk
+func (d *ChunkDecoder) Format() wave.WaveFmt {
+	return d.wfmt
+}
+
+// Read decodes the frames of the chunks in order into frames, reusing a buffer between
+// chunks, and returns io.EOF once the stream has ended
+func (d *ChunkDecoder) Read(frames []wave.Frame) (int, error) {
+	for len(d.pending) == 0 {
+		c, err := d.recv()
+		if err != nil {
+			return 0, err
+		}
+		d.buf = appendChunkFrames(d.buf[:0], c)
+		d.pending = d.buf
+	}
+	n := copy(frames, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// ProcessChunks runs every received chunk through a processor and sends it back with the
+// same sequence number and timestamp, until receiving ends. The end of the stream (io.EOF)
+// is not an error.
+func ProcessChunks(recv func() (Chunk, error), send func(c *AudioChunk) error, p synth.Processor) error {
+	for {
+		c, err := recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		frames := chunkFrames(c)
+		p.Process(frames)
+		samples := make([]float32, len(frames))
+		for i, f := range frames {
+			samples[i] = float32(f)
+		}
+		out := &AudioChunk{
+			Seq:        c.GetSeq(),
+			Timestamp:  c.GetTimestamp(),
+			SampleRate: c.GetSampleRate(),
+			Channels:   c.GetChannels(),
+			Samples:    samples,
+		}
+		if err := send(out); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// halve is a processor halving the frames
+type halve struct{}
+
+func (halve) Process(frames []wave.Frame) {
+	for i := range frames {
+		frames[i] /= 2
+	}
+}
+
+func TestAudioChunkWireFormat(t *testing.T) {
+	c := &AudioChunk{Seq: 300, Timestamp: 1 << 40, SampleRate: 48000, Channels: 2, Samples: []float32{.5, -.25}}
+	b := c.Marshal()
+	// field 1 varint 300, field 5 packed with 8 bytes, as protoc encodes it
+	if !bytes.HasPrefix(b, []byte{0x08, 0xAC, 0x02}) || !bytes.Contains(b, []byte{0x2A, 0x08, 0, 0, 0, 0x3F}) {
+		t.Fatalf("Unexpected encoding % x", b)
+	}
+	// unknown fields and unpacked samples are accepted
+	b = append(b, 0x32, 0x01, 0xFF, 0x2D, 0, 0, 0x80, 0x3F)
+	got, err := UnmarshalAudioChunk(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Seq != 300 || got.Timestamp != 1<<40 || got.SampleRate != 48000 || got.Channels != 2 || len(got.Samples) != 3 || got.Samples[2] != 1 {
+		t.Fatalf("Expected the chunk back, got %+v", got)
+	}
+	if _, err := UnmarshalAudioChunk(b[:len(b)-12]); err == nil {
+		t.Fatal("Expected an error for a truncated chunk")
+	}
+}
+
+func TestChunkAdapters(t *testing.T) {
+	data, frames := waveBytes(t, 1000)
+	d, err := NewChunkDecoder(func() (Chunk, error) { return nil, io.EOF })
+	if err == nil || d != nil {
+		t.Fatal("Expected an error for a stream without chunks")
+	}
+	d, err = NewChunkDecoder(func() (Chunk, error) { return &AudioChunk{Channels: 2}, nil })
+	if err == nil || d != nil {
+		t.Fatal("Expected an error for a chunk without a sample rate")
+	}
+
+	// client -> processing service -> client, through channels in place of gRPC streams
+	toServer, toClient := make(chan *AudioChunk, 100), make(chan *AudioChunk, 100)
+	recv := func(ch chan *AudioChunk) func() (Chunk, error) {
+		return func() (Chunk, error) {
+			c, ok := <-ch
+			if !ok {
+				return nil, io.EOF
+			}
+			return c, nil
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- ProcessChunks(recv(toServer), func(c *AudioChunk) error { toClient <- c; return nil }, halve{})
+		close(toClient)
+	}()
+	src, err := wave.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SendDecoder(src, func(c *AudioChunk) error { toServer <- c; return nil }, 64); err != nil {
+		t.Fatal(err)
+	}
+	close(toServer)
+
+	d, err = NewChunkDecoder(recv(toClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Format().NumChannels != 1 || d.Format().SampleRate != 8000 {
+		t.Fatalf("Expected the format of the source, got %+v", d.Format())
+	}
+	got := []wave.Frame{}
+	block := make([]wave.Frame, 100)
+	for {
+		n, err := d.Read(block)
+		got = append(got, block[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(frames) {
+		t.Fatalf("Expected %v frames, got %v", len(frames), len(got))
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-frames[i]/2)) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[i]/2, i, got[i])
+		}
+	}
+}
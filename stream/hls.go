@@ -0,0 +1,246 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// hlsSegment is a finished segment of a live stream
+type hlsSegment struct {
+	seq      int
+	name     string
+	duration float64
+	data     []byte
+}
+
+// Segmenter cuts a live stream into segments of a fixed duration for HTTP Live Streaming.
+// Every segment is encoded on its own, so it can be played without the ones before it.
+// The playlist and the segments are served by the segmenter as an http.Handler, and are
+// also written to Dir when it is set.
+type Segmenter struct {
+	Target      float64 // duration of the segments in seconds
+	Window      int     // segments in the playlist, a live playlist slides over the latest ones
+	Name        string  // the playlist is Name.m3u8 and the segments Name0, Name1, ... with the extension
+	Extension   string  // e.g. .mp3 or .aac
+	ContentType string  // of the segments, e.g. audio/mpeg
+	Dir         string
+
+	sr         int
+	channels   int
+	newEncoder func(w io.Writer) (wave.Encoder, error)
+	enc        wave.Encoder
+	buf        bytes.Buffer // the segment being encoded
+	samples    int          // samples per channel in the segment being encoded
+	segments   []hlsSegment // the latest segments, one more than the window so listeners can finish it
+	next       int          // sequence number of the segment being encoded
+	ended      bool
+	mu         sync.Mutex // guards the state of the stream, which ServeHTTP reads while writing
+}
+
+// NewSegmenter creates a segmenter for 6 second segments with 5 of them in the playlist
+func NewSegmenter(sr, channels int, ext, contentType string, newEncoder func(w io.Writer) (wave.Encoder, error)) (*Segmenter, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if newEncoder == nil {
+		return nil, errors.New("Need an encoder for the segments")
+	}
+	return &Segmenter{
+		Target:      6,
+		Window:      5,
+		Name:        "live",
+		Extension:   ext,
+		ContentType: contentType,
+		sr:          sr,
+		channels:    channels,
+		newEncoder:  newEncoder,
+	}, nil
+}
+
+// Write encodes frames, finishing a segment whenever it reaches the target duration
+func (s *Segmenter) Write(frames []wave.Frame) error {
+	if s.closed() {
+		return errors.New("Segmenter is closed")
+	}
+	size := int(math.Round(s.Target * float64(s.sr)))
+	if size < 1 {
+		return errors.New("Target duration is too short")
+	}
+	frames = frames[:len(frames)/s.channels*s.channels]
+	for len(frames) > 0 {
+		if s.enc == nil {
+			enc, err := s.newEncoder(&s.buf)
+			if err != nil {
+				return err
+			}
+			s.enc = enc
+		}
+		n := size - s.samples
+		if n > len(frames)/s.channels {
+			n = len(frames) / s.channels
+		}
+		if err := s.enc.Write(frames[:n*s.channels]); err != nil {
+			return err
+		}
+		s.samples += n
+		frames = frames[n*s.channels:]
+		if s.samples == size {
+			if err := s.finish(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finish closes the segment being encoded and adds it to the playlist
+func (s *Segmenter) finish() error {
+	if s.enc == nil {
+		return nil
+	}
+	if err := s.enc.Close(); err != nil {
+		return err
+	}
+	seg := hlsSegment{
+		seq:      s.next,
+		name:     fmt.Sprintf("%v%v%v", s.Name, s.next, s.Extension),
+		duration: float64(s.samples) / float64(s.sr),
+		data:     append([]byte{}, s.buf.Bytes()...),
+	}
+
+	s.mu.Lock()
+	s.enc = nil
+	s.buf.Reset()
+	s.samples = 0
+	s.next++
+	s.segments = append(s.segments, seg)
+	var removed []hlsSegment
+	if s.Window > 0 && len(s.segments) > s.Window+1 {
+		removed = append(removed, s.segments[:len(s.segments)-s.Window-1]...)
+		s.segments = append([]hlsSegment{}, s.segments[len(s.segments)-s.Window-1:]...)
+	}
+	playlist := s.playlist()
+	s.mu.Unlock()
+
+	if s.Dir == "" {
+		return nil
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.Dir, seg.name), seg.data, 0644); err != nil {
+		return err
+	}
+	if err := s.writePlaylist(playlist); err != nil {
+		return err
+	}
+	for _, old := range removed {
+		os.Remove(filepath.Join(s.Dir, old.name))
+	}
+	return nil
+}
+
+// writePlaylist replaces the playlist on disk, so readers never see half of it
+func (s *Segmenter) writePlaylist(playlist string) error {
+	name := filepath.Join(s.Dir, s.Name+".m3u8")
+	if err := ioutil.WriteFile(name+".tmp", []byte(playlist), 0644); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
+// playlist returns the media playlist of the segments in the window
+func (s *Segmenter) playlist() string {
+	segments := s.segments
+	if s.Window > 0 && len(segments) > s.Window {
+		segments = segments[len(segments)-s.Window:]
+	}
+	target := math.Ceil(s.Target)
+	for _, seg := range segments {
+		target = math.Max(target, math.Ceil(seg.duration))
+	}
+	b := &strings.Builder{}
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(b, "#EXT-X-TARGETDURATION:%v\n", int(target))
+	seq := s.next
+	if len(segments) > 0 {
+		seq = segments[0].seq
+	}
+	fmt.Fprintf(b, "#EXT-X-MEDIA-SEQUENCE:%v\n", seq)
+	if s.Window == 0 {
+		b.WriteString("#EXT-X-PLAYLIST-TYPE:EVENT\n")
+	}
+	for _, seg := range segments {
+		fmt.Fprintf(b, "#EXTINF:%.3f,\n%v\n", seg.duration, seg.name)
+	}
+	if s.ended {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	}
+	return b.String()
+}
+
+// Playlist returns the current media playlist
+func (s *Segmenter) Playlist() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.playlist()
+}
+
+// closed tells whether Close was called
+func (s *Segmenter) closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ended
+}
+
+// Close finishes the last segment and ends the playlist
+func (s *Segmenter) Close() error {
+	if s.closed() {
+		return nil
+	}
+	if err := s.finish(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.ended = true
+	playlist := s.playlist()
+	s.mu.Unlock()
+	if s.Dir != "" {
+		return s.writePlaylist(playlist)
+	}
+	return nil
+}
+
+// ServeHTTP serves the playlist and the segments still in memory, by the last element of the path
+func (s *Segmenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	s.mu.Lock()
+	if name == s.Name+".m3u8" {
+		playlist := s.playlist()
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+		io.WriteString(w, playlist)
+		return
+	}
+	for _, seg := range s.segments {
+		if seg.name == name {
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", s.ContentType)
+			http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(seg.data))
+			return
+		}
+	}
+	s.mu.Unlock()
+	http.NotFound(w, r)
+}
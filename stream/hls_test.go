@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestSegmenter(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSegmenter(10, 1, ".raw", "application/octet-stream", func(w io.Writer) (wave.Encoder, error) {
+		return &byteEncoder{w}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Target, s.Window, s.Dir = 1, 2, dir
+
+	frames := make([]wave.Frame, 35)
+	for i := range frames {
+		frames[i] = wave.Frame(i) / 100
+	}
+	for i := 0; i < len(frames); i += 7 {
+		if err := s.Write(frames[i : i+7]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:1\n#EXT-X-MEDIA-SEQUENCE:1\n" +
+		"#EXTINF:1.000,\nlive1.raw\n#EXTINF:1.000,\nlive2.raw\n"
+	if got := s.Playlist(); got != want {
+		t.Fatalf("Expected playlist\n%v\ngot\n%v", want, got)
+	}
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/live2.raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(data) != 11 || data[0] != 20 || data[10] != 0xFF {
+		t.Fatalf("Expected the frames 20 to 29 and the flush of the encoder, got %v", data)
+	}
+	if resp, _ := http.Get(server.URL + "/live9.raw"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a missing segment to be not found, got %v", resp.Status)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	playlist, err := ioutil.ReadFile(filepath.Join(dir, "live.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(playlist), "#EXTINF:0.500,\nlive3.raw\n#EXT-X-ENDLIST\n") {
+		t.Fatalf("Expected the short last segment and the end of the playlist, got\n%v", string(playlist))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "live0.raw")); !os.IsNotExist(err) {
+		t.Fatal("Expected segments out of the window to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "live2.raw")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSegmenterServeWhileWriting serves the playlist while writing, run it with -race
+func TestSegmenterServeWhileWriting(t *testing.T) {
+	s, err := NewSegmenter(10, 1, ".raw", "application/octet-stream", func(w io.Writer) (wave.Encoder, error) {
+		return &byteEncoder{w}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Target, s.Window = 1, 2
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest("GET", "/live.m3u8", nil))
+		}
+	}()
+	frames := make([]wave.Frame, 5)
+	for i := 0; i < 100; i++ {
+		if err := s.Write(frames); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	wg.Wait()
+	if !strings.HasSuffix(s.Playlist(), "#EXT-X-ENDLIST\n") {
+		t.Fatalf("Expected the playlist to end, got\n%v", s.Playlist())
+	}
+}
@@ -0,0 +1,189 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// skipLimit is how far a seek forward is done by reading on, rather than with a new request
+const skipLimit = 64 * 1024
+
+// HTTPReader reads a file served over HTTP. Seeking starts a new range request from the
+// new offset, so only the parts which are read are downloaded.
+type HTTPReader struct {
+	URL    string
+	Client *http.Client
+
+	size   int64 // -1 when the server doesn't tell
+	ranges bool  // whether the server supports range requests
+	offset int64 // offset of the next Read
+	body   io.ReadCloser
+	at     int64 // offset of the next byte of the body
+}
+
+// NewHTTPReader requests the start of the file to learn its size and whether the server
+// supports range requests. A nil client uses http.DefaultClient.
+func NewHTTPReader(url string, client *http.Client) (*HTTPReader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &HTTPReader{URL: url, Client: client, size: -1}
+	if err := r.open(0); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open starts a request for the file from an offset
+func (r *HTTPReader) open(offset int64) error {
+	r.closeBody()
+	req, err := http.NewRequest(http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%v-", offset))
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		r.ranges = true
+		if size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok {
+			r.size = size
+		}
+		r.body, r.at = resp.Body, offset
+	case http.StatusOK:
+		// the server sends the whole file, skip up to the offset
+		r.ranges = false
+		r.size = resp.ContentLength
+		r.body, r.at = resp.Body, 0
+		if err := r.skip(offset); err != nil {
+			r.closeBody()
+			return err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		r.body, r.at = ioutil.NopCloser(strings.NewReader("")), offset
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("Request for %v failed: %v", r.URL, resp.Status)
+	}
+	return nil
+}
+
+// parseContentRange returns the total size from a header like "bytes 0-99/1000"
+func parseContentRange(header string) (int64, bool) {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(header[i+1:], 10, 64)
+	return size, err == nil
+}
+
+// skip reads on in the body up to an offset
+func (r *HTTPReader) skip(offset int64) error {
+	n, err := io.CopyN(ioutil.Discard, r.body, offset-r.at)
+	r.at += n
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (r *HTTPReader) closeBody() {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+}
+
+// Size returns the size of the file in bytes, or -1 when the server doesn't report it
+func (r *HTTPReader) Size() int64 {
+	return r.size
+}
+
+// Read reads from the current offset, requesting the file from there when needed
+func (r *HTTPReader) Read(p []byte) (int, error) {
+	if r.body == nil || r.at > r.offset || r.offset-r.at > skipLimit {
+		if err := r.open(r.offset); err != nil {
+			return 0, err
+		}
+	}
+	if r.at < r.offset {
+		if err := r.skip(r.offset); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.body.Read(p)
+	r.at += int64(n)
+	r.offset = r.at
+	return n, err
+}
+
+// Seek moves the offset of the next Read. Seeking relative to the end needs the size of the file.
+func (r *HTTPReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		if r.size < 0 {
+			return 0, errors.New("Size of the file is unknown")
+		}
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("Can't seek before the start of the file")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Close ends the current request
+func (r *HTTPReader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+// RemoteDecoder decodes a file served over HTTP
+type RemoteDecoder struct {
+	wave.Decoder
+	reader *HTTPReader
+}
+
+// OpenURL detects the format of a file served over HTTP and opens a decoder for it.
+// The decoder seeks with range requests when the server supports them.
+func OpenURL(url string) (*RemoteDecoder, error) {
+	r, err := NewHTTPReader(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDecoder(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &RemoteDecoder{Decoder: d, reader: r}, nil
+}
+
+// SeekSample jumps to a sample, counted per channel, when the format supports seeking
+func (d *RemoteDecoder) SeekSample(sample int64) error {
+	s, ok := d.Decoder.(wave.Seeker)
+	if !ok {
+		return errors.New("Format does not support seeking")
+	}
+	return s.SeekSample(sample)
+}
+
+// Close ends the connection to the server
+func (d *RemoteDecoder) Close() error {
+	return d.reader.Close()
+}
@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// waveBytes returns a mono .wav file of a ramp
+func waveBytes(t *testing.T, n int) ([]byte, []wave.Frame) {
+	frames := make([]wave.Frame, n)
+	for i := range frames {
+		frames[i] = wave.Frame(float64(i)/float64(n)*2 - 1)
+	}
+	buf := bytes.Buffer{}
+	if err := wave.WriteWaveToWriter(frames, wave.NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), frames
+}
+
+func TestOpenURL(t *testing.T) {
+	data, frames := waveBytes(t, 100000)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeContent(w, r, "ramp.wav", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	d, err := OpenURL(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.Format().SampleRate != 8000 || d.reader.Size() != int64(len(data)) {
+		t.Fatalf("Expected a file of %v bytes at 8000Hz, got %v", len(data), d.reader.Size())
+	}
+
+	block := make([]wave.Frame, 10)
+	if err := d.SeekSample(90000); err != nil {
+		t.Fatal(err)
+	}
+	before := requests
+	if n, err := d.Read(block); n != 10 || err != nil || math.Abs(float64(block[0]-frames[90000])) > 1e-4 {
+		t.Fatalf("Expected to read from sample 90000, got %v frames: %v", n, err)
+	}
+	if requests != before+1 {
+		t.Fatalf("Expected a single range request for the seek, got %v", requests-before)
+	}
+	// a short seek forward reads on in the same response
+	d.SeekSample(90100)
+	if n, _ := d.Read(block); n != 10 || requests != before+1 || math.Abs(float64(block[0]-frames[90100])) > 1e-4 {
+		t.Fatalf("Expected to skip ahead without a request, got %v requests", requests-before)
+	}
+	d.SeekSample(99995)
+	total := 0
+	for {
+		n, err := d.Read(block)
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if total != 5 {
+		t.Fatalf("Expected the last 5 frames, got %v", total)
+	}
+}
+
+func TestHTTPReaderWithoutRanges(t *testing.T) {
+	data := []byte("0123456789")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	r, err := NewHTTPReader(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	buf := make([]byte, 3)
+	r.Seek(6, io.SeekStart)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "678" {
+		t.Fatalf("Expected 678, got %q: %v", buf, err)
+	}
+	// going back requests the whole file again
+	r.Seek(-8, io.SeekCurrent)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "123" {
+		t.Fatalf("Expected 123, got %q: %v", buf, err)
+	}
+
+	server.Config.Handler = http.NotFoundHandler()
+	if _, err := OpenURL(server.URL); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
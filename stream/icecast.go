@@ -0,0 +1,264 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// ServerProtocol is the protocol a source uses to connect to a streaming server
+type ServerProtocol int
+
+const (
+	ICECAST   ServerProtocol = iota // HTTP PUT, Icecast 2.4 and later
+	SHOUTCAST                       // the SHOUTcast v1 protocol, on the port after the one of the listeners
+)
+
+// dialTimeout limits how long connecting to a server may take
+const dialTimeout = 10 * time.Second
+
+// Source streams encoded audio to a mount of an Icecast or SHOUTcast server, making a
+// GoAudio pipeline an internet radio station. Servers expect audio at the speed it is
+// played, so the frames should be written as they are rendered or paced to real time.
+type Source struct {
+	Host     string // host:port of the server
+	Mount    string // e.g. /live.mp3, not used by SHOUTcast
+	User     string // source by default
+	Password string
+	Protocol ServerProtocol
+
+	ContentType string // of the encoded stream, e.g. audio/mpeg or audio/ogg
+	Name        string
+	Description string
+	Genre       string
+	URL         string // of the website of the station
+	Public      bool   // whether the server lists the stream in directories
+
+	conn net.Conn
+	enc  wave.Encoder
+}
+
+// NewSource creates a source for an Icecast mount
+func NewSource(host, mount, password, contentType string) *Source {
+	return &Source{
+		Host:        host,
+		Mount:       mount,
+		User:        "source",
+		Password:    password,
+		ContentType: contentType,
+	}
+}
+
+// Connect logs in to the server and creates the encoder on the connection
+func (s *Source) Connect(newEncoder func(w io.Writer) (wave.Encoder, error)) error {
+	if s.conn != nil {
+		return errors.New("Already connected")
+	}
+	if err := s.checkFields(); err != nil {
+		return err
+	}
+	var err error
+	switch s.Protocol {
+	case ICECAST:
+		err = s.connectIcecast()
+	case SHOUTCAST:
+		err = s.connectShoutcast()
+	default:
+		err = errors.New("Unknown server protocol")
+	}
+	if err != nil {
+		return err
+	}
+	enc, err := newEncoder(s.conn)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	s.enc = enc
+	return nil
+}
+
+// checkFields rejects the fields which would break the lines they are sent in, a space in the
+// mount would also end the path of the request line
+func (s *Source) checkFields() error {
+	if strings.ContainsAny(s.Mount, " \r\n") {
+		return fmt.Errorf("Mount %q should not contain spaces or line breaks", s.Mount)
+	}
+	fields := [][2]string{
+		{"Host", s.Host}, {"User", s.User}, {"Password", s.Password}, {"ContentType", s.ContentType},
+		{"Name", s.Name}, {"Description", s.Description}, {"Genre", s.Genre}, {"URL", s.URL},
+	}
+	for _, f := range fields {
+		if strings.ContainsAny(f[1], "\r\n") {
+			return fmt.Errorf("%v of the source should not contain line breaks", f[0])
+		}
+	}
+	return nil
+}
+
+func (s *Source) connectIcecast() error {
+	conn, err := net.DialTimeout("tcp", s.Host, dialTimeout)
+	if err != nil {
+		return err
+	}
+	req := &strings.Builder{}
+	fmt.Fprintf(req, "PUT %v HTTP/1.1\r\n", s.Mount)
+	fmt.Fprintf(req, "Host: %v\r\n", s.Host)
+	fmt.Fprintf(req, "Authorization: Basic %v\r\n", s.auth())
+	fmt.Fprintf(req, "User-Agent: GoAudio\r\n")
+	fmt.Fprintf(req, "Content-Type: %v\r\n", s.ContentType)
+	fmt.Fprintf(req, "Ice-Public: %v\r\n", boolInt(s.Public))
+	for _, h := range [][2]string{{"Ice-Name", s.Name}, {"Ice-Description", s.Description}, {"Ice-Genre", s.Genre}, {"Ice-Url", s.URL}} {
+		if h[1] != "" {
+			fmt.Fprintf(req, "%v: %v\r\n", h[0], h[1])
+		}
+	}
+	req.WriteString("Expect: 100-continue\r\n\r\n")
+	if _, err := io.WriteString(conn, req.String()); err != nil {
+		conn.Close()
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	r := bufio.NewReader(conn)
+	for {
+		resp, err := http.ReadResponse(r, nil)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		if resp.StatusCode == http.StatusContinue {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			conn.Close()
+			return fmt.Errorf("Server refused the source: %v", resp.Status)
+		}
+		break
+	}
+	conn.SetReadDeadline(time.Time{})
+	s.conn = conn
+	return nil
+}
+
+func (s *Source) connectShoutcast() error {
+	host, port, err := net.SplitHostPort(s.Host)
+	if err != nil {
+		return err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(p+1)), dialTimeout)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(conn, s.Password+"\r\n"); err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "OK") {
+		conn.Close()
+		return fmt.Errorf("Server refused the source: %v", strings.TrimSpace(line))
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	hdr := &strings.Builder{}
+	fmt.Fprintf(hdr, "content-type:%v\r\n", s.ContentType)
+	fmt.Fprintf(hdr, "icy-pub:%v\r\n", boolInt(s.Public))
+	for _, h := range [][2]string{{"icy-name", s.Name}, {"icy-genre", s.Genre}, {"icy-url", s.URL}} {
+		if h[1] != "" {
+			fmt.Fprintf(hdr, "%v:%v\r\n", h[0], h[1])
+		}
+	}
+	hdr.WriteString("\r\n")
+	if _, err := io.WriteString(conn, hdr.String()); err != nil {
+		conn.Close()
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *Source) auth() string {
+	return base64.StdEncoding.EncodeToString([]byte(s.User + ":" + s.Password))
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Write encodes frames and sends them to the server
+func (s *Source) Write(frames []wave.Frame) error {
+	if s.enc == nil {
+		return errors.New("Not connected")
+	}
+	return s.enc.Write(frames)
+}
+
+// SetMetadata changes the title of the song the server shows to listeners
+func (s *Source) SetMetadata(title string) error {
+	q := url.Values{}
+	q.Set("mode", "updinfo")
+	q.Set("song", title)
+	var req *http.Request
+	var err error
+	if s.Protocol == SHOUTCAST {
+		q.Set("pass", s.Password)
+		req, err = http.NewRequest(http.MethodGet, "http://"+s.Host+"/admin.cgi?"+q.Encode(), nil)
+	} else {
+		q.Set("mount", s.Mount)
+		req, err = http.NewRequest(http.MethodGet, "http://"+s.Host+"/admin/metadata?"+q.Encode(), nil)
+		if err == nil {
+			req.Header.Set("Authorization", "Basic "+s.auth())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "GoAudio")
+	client := http.Client{Timeout: dialTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Server refused the metadata: %v", resp.Status)
+	}
+	return nil
+}
+
+// Close flushes the encoder and disconnects from the server
+func (s *Source) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.enc.Close()
+	if cerr := s.conn.Close(); err == nil {
+		err = cerr
+	}
+	s.conn, s.enc = nil, nil
+	return err
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// byteEncoder writes every frame as a single byte
+type byteEncoder struct {
+	w io.Writer
+}
+
+func (e *byteEncoder) Write(frames []wave.Frame) error {
+	buf := make([]byte, len(frames))
+	for i, f := range frames {
+		buf[i] = byte(f * 100)
+	}
+	_, err := e.w.Write(buf)
+	return err
+}
+
+func (e *byteEncoder) Close() error {
+	_, err := e.w.Write([]byte{0xFF})
+	return err
+}
+
+// fakeIcecast accepts a source on a mount and metadata updates on the same port
+func fakeIcecast(t *testing.T) (net.Listener, chan *http.Request, chan []byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, streams := make(chan *http.Request, 4), make(chan []byte, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			req, err := http.ReadRequest(r)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			requests <- req
+			if req.Method == http.MethodPut {
+				io.WriteString(conn, "HTTP/1.1 100 Continue\r\n\r\nHTTP/1.0 200 OK\r\n\r\n")
+				go func() {
+					data, _ := ioutil.ReadAll(r)
+					streams <- data
+					conn.Close()
+				}()
+				continue
+			}
+			io.WriteString(conn, "HTTP/1.0 200 OK\r\nContent-Length: 0\r\n\r\n")
+			conn.Close()
+		}
+	}()
+	return l, requests, streams
+}
+
+func TestIcecastSource(t *testing.T) {
+	l, requests, streams := fakeIcecast(t)
+	defer l.Close()
+
+	s := NewSource(l.Addr().String(), "/live.mp3", "hackme", "audio/mpeg")
+	s.Name = "GoAudio radio"
+	if err := s.Write([]wave.Frame{0}); err == nil {
+		t.Fatal("Expected an error writing before connecting")
+	}
+	if err := s.Connect(func(w io.Writer) (wave.Encoder, error) { return &byteEncoder{w}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	put := <-requests
+	if user, pass, _ := put.BasicAuth(); put.URL.Path != "/live.mp3" || user != "source" || pass != "hackme" {
+		t.Fatalf("Expected a source login on /live.mp3, got %v as %v:%v", put.URL.Path, user, pass)
+	}
+	if put.Header.Get("Content-Type") != "audio/mpeg" || put.Header.Get("Ice-Name") != "GoAudio radio" {
+		t.Fatalf("Expected the stream headers, got %v", put.Header)
+	}
+
+	if err := s.Write([]wave.Frame{.01, .02, .03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMetadata("Artist - Title"); err != nil {
+		t.Fatal(err)
+	}
+	meta := <-requests
+	q := meta.URL.Query()
+	if meta.URL.Path != "/admin/metadata" || q.Get("mount") != "/live.mp3" || q.Get("song") != "Artist - Title" || q.Get("mode") != "updinfo" {
+		t.Fatalf("Expected a metadata update for the mount, got %v", meta.URL)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data := <-streams; !bytes.Equal(data, []byte{1, 2, 3, 0xFF}) {
+		t.Fatalf("Expected the encoded frames and the flush, got %v", data)
+	}
+}
+
+func TestIcecastRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		http.ReadRequest(bufio.NewReader(conn))
+		io.WriteString(conn, "HTTP/1.0 401 Unauthorized\r\n\r\n")
+		conn.Close()
+	}()
+	s := NewSource(l.Addr().String(), "/live", "wrong", "audio/ogg")
+	if err := s.Connect(func(w io.Writer) (wave.Encoder, error) { return &byteEncoder{w}, nil }); err == nil {
+		t.Fatal("Expected the server to refuse the source")
+	}
+}
+
+func TestSourceLineBreaks(t *testing.T) {
+	sources := []func(s *Source){
+		func(s *Source) { s.Name = "Radio\r\nIce-Public: 1" },
+		func(s *Source) { s.Description = "a\nb" },
+		func(s *Source) { s.Genre = "jazz\r" },
+		func(s *Source) { s.URL = "http://example.com\r\n\r\nPUT" },
+		func(s *Source) { s.ContentType = "audio/ogg\r\nX: y" },
+		func(s *Source) { s.Mount = "/live HTTP/1.1\r\nX: y\r\n" },
+		func(s *Source) { s.Mount = "/live mp3" },
+		func(s *Source) { s.Protocol, s.Password = SHOUTCAST, "pass\r\nicy-pub:1" },
+	}
+	for _, set := range sources {
+		t.Run("", func(t *testing.T) {
+			// nothing listens on the port, the source should fail before dialing
+			s := NewSource("127.0.0.1:1", "/live", "hackme", "audio/ogg")
+			set(s)
+			err := s.Connect(func(w io.Writer) (wave.Encoder, error) { return &byteEncoder{w}, nil })
+			if err == nil || !strings.Contains(err.Error(), "line breaks") {
+				t.Fatalf("Expected the line break to be rejected, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package stream
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// LiveEncoder encodes a never ending stream of frames in chunks of a fixed size, for
+// recording services which run for days. The chunks come from a fixed set of buffers, so
+// memory stays flat: once all of them wait to be encoded, LiveEncoder stops receiving and
+// the producers block until the encoder catches up.
+type LiveEncoder struct {
+	Encoder  wave.Encoder
+	Channels int
+	Chunk    int          // samples per channel in a chunk
+	Queue    int          // chunks which may wait to be encoded
+	Flush    func() error // called after every chunk when set, e.g. to sync the file
+
+	encoded int64 // samples per channel, accessed atomically
+	pending int32 // chunks waiting, accessed atomically
+	done    chan struct{}
+}
+
+// NewLiveEncoder creates an encoder for chunks of a second with up to 4 of them waiting
+func NewLiveEncoder(enc wave.Encoder, sr, channels int) (*LiveEncoder, error) {
+	if enc == nil {
+		return nil, errors.New("Need an encoder")
+	}
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	return &LiveEncoder{
+		Encoder:  enc,
+		Channels: channels,
+		Chunk:    sr,
+		Queue:    4,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Run encodes the frames received from in until it is closed, then encodes the last partial
+// chunk and closes the encoder. When encoding fails Run returns the error without waiting for
+// the channel to close, producers can select on Done to stop sending. The slices sent on the
+// channel may not be changed afterwards, as they are copied after they are received.
+func (e *LiveEncoder) Run(in <-chan []wave.Frame) error {
+	defer close(e.done)
+	if e.Chunk < 1 || e.Queue < 1 {
+		return errors.New("Chunk and queue need to hold at least one sample")
+	}
+	size := e.Chunk * e.Channels
+	// the waiting chunks, one being encoded and one being filled
+	free := make(chan []wave.Frame, e.Queue+2)
+	for i := 0; i < e.Queue+2; i++ {
+		free <- make([]wave.Frame, 0, size)
+	}
+	full := make(chan []wave.Frame, e.Queue)
+	failed := make(chan error, 1)
+	go func() {
+		defer close(failed)
+		for chunk := range full {
+			atomic.AddInt32(&e.pending, -1)
+			err := e.Encoder.Write(chunk)
+			if err == nil && e.Flush != nil {
+				err = e.Flush()
+			}
+			if err != nil {
+				failed <- err
+				// let Run see the failure rather than block on a full queue
+				for range full {
+				}
+				return
+			}
+			atomic.AddInt64(&e.encoded, int64(len(chunk)/e.Channels))
+			free <- chunk[:0]
+		}
+	}()
+
+	var chunk []wave.Frame
+	for frames := range in {
+		for len(frames) > 0 {
+			if chunk == nil {
+				select {
+				case chunk = <-free:
+				case err := <-failed:
+					close(full)
+					return err
+				}
+			}
+			n := copy(chunk[len(chunk):size], frames)
+			chunk, frames = chunk[:len(chunk)+n], frames[n:]
+			if len(chunk) == size {
+				atomic.AddInt32(&e.pending, 1)
+				select {
+				case full <- chunk:
+				case err := <-failed:
+					close(full)
+					return err
+				}
+				chunk = nil
+			}
+		}
+	}
+	if len(chunk) >= e.Channels {
+		atomic.AddInt32(&e.pending, 1)
+		full <- chunk[:len(chunk)/e.Channels*e.Channels]
+	}
+	close(full)
+	if err := <-failed; err != nil {
+		return err
+	}
+	return e.Encoder.Close()
+}
+
+// Done is closed when Run has returned
+func (e *LiveEncoder) Done() <-chan struct{} {
+	return e.done
+}
+
+// Encoded returns the amount of samples per channel which have been encoded
+func (e *LiveEncoder) Encoded() int64 {
+	return atomic.LoadInt64(&e.encoded)
+}
+
+// Pending returns the amount of full chunks waiting to be encoded
+func (e *LiveEncoder) Pending() int {
+	return int(atomic.LoadInt32(&e.pending))
+}
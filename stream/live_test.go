@@ -0,0 +1,112 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// gatedEncoder keeps the chunks it is given, each write waits for the gate
+type gatedEncoder struct {
+	gate   chan struct{}
+	chunks [][]wave.Frame
+	closed bool
+	fail   bool
+}
+
+func (e *gatedEncoder) Write(frames []wave.Frame) error {
+	<-e.gate
+	if e.fail {
+		return errors.New("disk full")
+	}
+	e.chunks = append(e.chunks, append([]wave.Frame{}, frames...))
+	return nil
+}
+
+func (e *gatedEncoder) Close() error {
+	e.closed = true
+	return nil
+}
+
+func TestLiveEncoder(t *testing.T) {
+	enc := &gatedEncoder{gate: make(chan struct{})}
+	l, err := NewLiveEncoder(enc, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Queue = 2
+	in := make(chan []wave.Frame)
+	result := make(chan error, 1)
+	go func() { result <- l.Run(in) }()
+
+	// a chunk is 20 frames; one is encoding, two wait and the fourth waits for room
+	var block []wave.Frame
+	sent := 0
+	for ; sent < 10; sent++ {
+		block = make([]wave.Frame, 20)
+		for i := range block {
+			block[i] = wave.Frame(sent)
+		}
+		select {
+		case in <- block:
+			continue
+		case <-time.After(50 * time.Millisecond):
+		}
+		break
+	}
+	if sent != 4 {
+		t.Fatalf("Expected the encoder to stop receiving after 4 chunks, got %v", sent)
+	}
+	if l.Pending() != 3 {
+		t.Fatalf("Expected 3 chunks waiting, got %v", l.Pending())
+	}
+
+	close(enc.gate)
+	in <- block
+	for sent++; sent < 10; sent++ {
+		block = make([]wave.Frame, 20)
+		for i := range block {
+			block[i] = wave.Frame(sent)
+		}
+		in <- block
+	}
+	in <- block[:7] // a partial chunk of 3 samples per channel
+	close(in)
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if len(enc.chunks) != 11 || !enc.closed || l.Encoded() != 103 {
+		t.Fatalf("Expected 11 chunks and the encoder closed, got %v chunks and %v samples", len(enc.chunks), l.Encoded())
+	}
+	for i, chunk := range enc.chunks[:10] {
+		if chunk[0] != wave.Frame(i) || chunk[19] != wave.Frame(i) {
+			t.Fatalf("Expected chunk %v in order, got %v", i, chunk)
+		}
+	}
+	if len(enc.chunks[10]) != 6 {
+		t.Fatalf("Expected the partial chunk cut to whole samples, got %v frames", len(enc.chunks[10]))
+	}
+}
+
+func TestLiveEncoderFails(t *testing.T) {
+	enc := &gatedEncoder{gate: make(chan struct{}), fail: true}
+	close(enc.gate)
+	l, _ := NewLiveEncoder(enc, 10, 1)
+	in := make(chan []wave.Frame)
+	result := make(chan error, 1)
+	go func() { result <- l.Run(in) }()
+	block := make([]wave.Frame, 10)
+	for {
+		select {
+		case in <- block:
+			continue
+		case <-l.Done():
+		}
+		break
+	}
+	if err := <-result; err == nil {
+		t.Fatal("Expected the error of the encoder")
+	}
+}
@@ -0,0 +1,365 @@
+package stream
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// rtpHeaderSize is the size of an RTP header without contributing sources or extensions
+const rtpHeaderSize = 12
+
+// maxDatagram is the largest UDP payload a receiver reads
+const maxDatagram = 65536
+
+// PayloadCodec converts frames to the payload of RTP packets and back. L16 is built in,
+// an Opus codec wrapping an Opus library plugs in here with its dynamic payload type.
+// Timestamps count samples per channel, so the clock rate is the sample rate of the frames.
+type PayloadCodec interface {
+	PayloadType() uint8
+	Encode(frames []wave.Frame) ([]byte, error)
+	Decode(payload []byte) ([]wave.Frame, error)
+}
+
+// L16 is uncompressed 16 bit PCM in network byte order, as described in RFC 3551
+type L16 struct {
+	Channels int
+	Type     uint8 // 10 for stereo and 11 for mono at 44100Hz, dynamic (96-127) for other rates
+}
+
+// NewL16 creates the codec for a channel count, with the static payload types at 44100Hz
+func NewL16(sr, channels int) *L16 {
+	c := &L16{Channels: channels, Type: 96}
+	if sr == 44100 && channels == 1 {
+		c.Type = 11
+	} else if sr == 44100 && channels == 2 {
+		c.Type = 10
+	}
+	return c
+}
+
+func (c *L16) PayloadType() uint8 {
+	return c.Type
+}
+
+func (c *L16) Encode(frames []wave.Frame) ([]byte, error) {
+	buf := make([]byte, 2*len(frames))
+	for i, f := range frames {
+		v := math.Max(-1, math.Min(1, float64(f)))
+		binary.BigEndian.PutUint16(buf[2*i:], uint16(int16(v*math.MaxInt16)))
+	}
+	return buf, nil
+}
+
+func (c *L16) Decode(payload []byte) ([]wave.Frame, error) {
+	if len(payload)%(2*c.Channels) != 0 {
+		return nil, errors.New("Payload is not a whole amount of frames")
+	}
+	frames := make([]wave.Frame, len(payload)/2)
+	for i := range frames {
+		frames[i] = wave.Frame(float64(int16(binary.BigEndian.Uint16(payload[2*i:]))) / math.MaxInt16)
+	}
+	return frames, nil
+}
+
+// RTPSender cuts frames into RTP packets of a fixed duration
+type RTPSender struct {
+	Codec    PayloadCodec
+	Channels int
+	Packet   int    // samples per channel in a packet
+	SSRC     uint32 // identifies the stream, random by default
+
+	seq       uint16
+	timestamp uint32
+	buf       []wave.Frame // frames waiting for a full packet
+	w         io.Writer
+}
+
+// NewRTPSender creates a sender writing a packet per call to w, usually a connected UDP socket.
+// The packets hold 20ms of audio.
+func NewRTPSender(w io.Writer, codec PayloadCodec, sr, channels int) (*RTPSender, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if codec == nil {
+		return nil, errors.New("Need a payload codec")
+	}
+	return &RTPSender{
+		Codec:     codec,
+		Channels:  channels,
+		Packet:    sr / 50,
+		SSRC:      rand.Uint32(),
+		seq:       uint16(rand.Uint32()),
+		timestamp: rand.Uint32(),
+		w:         w,
+	}, nil
+}
+
+// Write sends the frames in as many full packets as they make, the rest waits for the next write
+func (s *RTPSender) Write(frames []wave.Frame) error {
+	s.buf = append(s.buf, frames...)
+	size := s.Packet * s.Channels
+	sent := 0
+	for ; len(s.buf)-sent >= size; sent += size {
+		if err := s.send(s.buf[sent : sent+size]); err != nil {
+			s.buf = append(s.buf[:0], s.buf[sent:]...)
+			return err
+		}
+	}
+	s.buf = append(s.buf[:0], s.buf[sent:]...)
+	return nil
+}
+
+// Flush sends the waiting frames in a short packet
+func (s *RTPSender) Flush() error {
+	if len(s.buf) < s.Channels {
+		return nil
+	}
+	err := s.send(s.buf[:len(s.buf)/s.Channels*s.Channels])
+	s.buf = s.buf[:0]
+	return err
+}
+
+func (s *RTPSender) send(frames []wave.Frame) error {
+	payload, err := s.Codec.Encode(frames)
+	if err != nil {
+		return err
+	}
+	packet := make([]byte, rtpHeaderSize+len(payload))
+	packet[0] = 2 << 6
+	packet[1] = s.Codec.PayloadType() & 0x7F
+	binary.BigEndian.PutUint16(packet[2:], s.seq)
+	binary.BigEndian.PutUint32(packet[4:], s.timestamp)
+	binary.BigEndian.PutUint32(packet[8:], s.SSRC)
+	copy(packet[rtpHeaderSize:], payload)
+	s.seq++
+	s.timestamp += uint32(len(frames) / s.Channels)
+	_, err = s.w.Write(packet)
+	return err
+}
+
+// RTPPacket is a parsed RTP packet
+type RTPPacket struct {
+	PayloadType uint8
+	Marker      bool
+	Sequence    uint16
+	Timestamp   uint32
+	SSRC        uint32
+	Payload     []byte
+}
+
+// ParseRTP parses the header of an RTP packet, skipping contributing sources, extensions and padding
+func ParseRTP(data []byte) (RTPPacket, error) {
+	if len(data) < rtpHeaderSize {
+		return RTPPacket{}, errors.New("Packet is too short for an RTP header")
+	}
+	if data[0]>>6 != 2 {
+		return RTPPacket{}, errors.New("Not an RTP version 2 packet")
+	}
+	p := RTPPacket{
+		PayloadType: data[1] & 0x7F,
+		Marker:      data[1]&0x80 != 0,
+		Sequence:    binary.BigEndian.Uint16(data[2:]),
+		Timestamp:   binary.BigEndian.Uint32(data[4:]),
+		SSRC:        binary.BigEndian.Uint32(data[8:]),
+	}
+	start := rtpHeaderSize + 4*int(data[0]&0x0F)
+	if data[0]&0x10 != 0 {
+		if len(data) < start+4 {
+			return RTPPacket{}, errors.New("Packet is too short for its header extension")
+		}
+		start += 4 + 4*int(binary.BigEndian.Uint16(data[start+2:]))
+	}
+	end := len(data)
+	if data[0]&0x20 != 0 && end > 0 {
+		end -= int(data[end-1])
+	}
+	if start > end {
+		return RTPPacket{}, errors.New("Packet is too short for its header")
+	}
+	p.Payload = data[start:end]
+	return p, nil
+}
+
+// jitterPacket is a decoded packet waiting in the jitter buffer
+type jitterPacket struct {
+	seq       uint64 // sequence number extended past wrapping
+	timestamp uint32
+	frames    []wave.Frame
+}
+
+// RTPReceiver puts received packets back in order in a jitter buffer and plays them out
+// at a steady rate. Missing packets are played as silence once later packets have waited
+// for the depth of the buffer, packets arriving after their turn are dropped.
+type RTPReceiver struct {
+	Codec    PayloadCodec
+	Channels int
+	Depth    int // samples per channel buffered before playing starts
+
+	packets   []jitterPacket // sorted by sequence number
+	buffered  int            // samples per channel in packets
+	buffering bool
+	started   bool
+	ssrc      uint32
+	lastSeq   uint64 // extended sequence number of the newest packet
+	nextSeq   uint64
+	nextTS    uint32
+	pending   []wave.Frame // the rest of the packet being played
+	silence   int          // frames of silence left to play for missing packets
+	lost      uint64
+	late      uint64
+	mu        sync.Mutex
+}
+
+// NewRTPReceiver creates a receiver buffering 60ms before playing
+func NewRTPReceiver(codec PayloadCodec, sr, channels int) (*RTPReceiver, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if codec == nil {
+		return nil, errors.New("Need a payload codec")
+	}
+	return &RTPReceiver{
+		Codec:     codec,
+		Channels:  channels,
+		Depth:     sr * 60 / 1000,
+		buffering: true,
+	}, nil
+}
+
+// Push adds a received packet to the jitter buffer. The receiver follows the first source
+// it hears from and restarts when another source takes over.
+func (r *RTPReceiver) Push(data []byte) error {
+	p, err := ParseRTP(data)
+	if err != nil {
+		return err
+	}
+	if p.PayloadType != r.Codec.PayloadType() {
+		return errors.New("Unexpected payload type")
+	}
+	frames, err := r.Codec.Decode(p.Payload)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started || p.SSRC != r.ssrc {
+		r.reset()
+		r.started, r.ssrc = true, p.SSRC
+		// start a cycle in, so packets from before the first one don't wrap below zero
+		r.lastSeq = 1<<16 + uint64(p.Sequence)
+		r.nextSeq, r.nextTS = r.lastSeq, p.Timestamp
+	}
+	// extend the sequence number to the one closest to the newest packet
+	seq := uint64(int64(r.lastSeq) + int64(int16(p.Sequence-uint16(r.lastSeq))))
+	if seq > r.lastSeq {
+		r.lastSeq = seq
+	}
+	if seq < r.nextSeq {
+		r.late++
+		return nil
+	}
+	i := sort.Search(len(r.packets), func(i int) bool { return r.packets[i].seq >= seq })
+	if i < len(r.packets) && r.packets[i].seq == seq {
+		return nil
+	}
+	r.packets = append(r.packets, jitterPacket{})
+	copy(r.packets[i+1:], r.packets[i:])
+	r.packets[i] = jitterPacket{seq: seq, timestamp: p.Timestamp, frames: frames}
+	r.buffered += len(frames) / r.Channels
+	return nil
+}
+
+func (r *RTPReceiver) reset() {
+	r.packets = r.packets[:0]
+	r.buffered = 0
+	r.buffering = true
+	r.pending = nil
+	r.silence = 0
+}
+
+// Receive pushes the packets read from a connection until reading fails. Invalid packets are skipped.
+func (r *RTPReceiver) Receive(conn io.Reader) error {
+	buf := make([]byte, maxDatagram)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return err
+		}
+		r.Push(buf[:n])
+	}
+}
+
+// Fill plays out the buffered audio into out, with silence while the buffer fills up.
+// It always fills the whole block, so it can be the callback of a playback stream.
+func (r *RTPReceiver) Fill(out []wave.Frame) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range out {
+		out[i] = 0
+	}
+	if r.buffering {
+		if r.buffered < r.Depth {
+			return len(out)
+		}
+		r.buffering = false
+	}
+	for done := 0; done < len(out); {
+		if r.silence > 0 {
+			// out is silent already
+			n := min(r.silence, len(out)-done)
+			r.silence -= n
+			done += n
+			continue
+		}
+		if len(r.pending) > 0 {
+			n := copy(out[done:], r.pending)
+			r.pending = r.pending[n:]
+			done += n
+			continue
+		}
+		if len(r.packets) == 0 {
+			// the buffer ran dry, wait for it to fill up again
+			r.buffering = true
+			return len(out)
+		}
+		p := r.packets[0]
+		if p.seq != r.nextSeq {
+			// a packet is missing, play silence up to the timestamp of the next one
+			r.lost += p.seq - r.nextSeq
+			// a jump of the timestamp, such as a sender pausing, is played for no longer
+			// than the depth of the buffer
+			gap := int(int32(p.timestamp - r.nextTS))
+			gap = max(min(gap, r.Depth), 0)
+			r.silence = gap * r.Channels
+			r.nextSeq, r.nextTS = p.seq, p.timestamp
+			continue
+		}
+		r.packets = r.packets[1:]
+		r.buffered -= len(p.frames) / r.Channels
+		r.pending = p.frames
+		r.nextSeq++
+		r.nextTS = p.timestamp + uint32(len(p.frames)/r.Channels)
+	}
+	return len(out)
+}
+
+// Lost returns the amount of packets which hadn't arrived when it was their turn to be played
+func (r *RTPReceiver) Lost() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lost
+}
+
+// Late returns the amount of packets which arrived after their turn to be played
+func (r *RTPReceiver) Late() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.late
+}
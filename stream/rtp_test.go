@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// packetWriter keeps every write as a packet
+type packetWriter struct {
+	packets [][]byte
+}
+
+func (w *packetWriter) Write(p []byte) (int, error) {
+	w.packets = append(w.packets, append([]byte{}, p...))
+	return len(p), nil
+}
+
+func TestRTP(t *testing.T) {
+	const sr, channels = 8000, 2
+	codec := NewL16(sr, channels)
+	w := &packetWriter{}
+	s, err := NewRTPSender(w, codec, sr, channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.seq = 65530 // wraps during the test
+
+	frames := make([]wave.Frame, sr/5*channels) // 10 packets of 20ms
+	for i := range frames {
+		frames[i] = wave.Frame(float64(i%100)/100 - .5)
+	}
+	for i := 0; i < len(frames); i += 70 {
+		end := i + 70
+		if end > len(frames) {
+			end = len(frames)
+		}
+		if err := s.Write(frames[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(w.packets) != 10 {
+		t.Fatalf("Expected 10 packets, got %v", len(w.packets))
+	}
+
+	tests := []struct {
+		order []int
+		lost  int // packet played as silence
+	}{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, -1},
+		{[]int{0, 2, 1, 3, 5, 4, 6, 8, 7, 9}, -1},
+		{[]int{0, 1, 2, 3, 4, 6, 7, 8, 9}, 5},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			r, err := NewRTPReceiver(codec, sr, channels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, i := range test.order {
+				if err := r.Push(w.packets[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := make([]wave.Frame, len(frames))
+			if n := r.Fill(out); n != len(out) {
+				t.Fatalf("Expected a full block, got %v frames", n)
+			}
+			for i := range out {
+				want := frames[i]
+				if packet := i / (sr / 50 * channels); packet == test.lost {
+					want = 0
+				}
+				if math.Abs(float64(out[i]-want)) > 1e-4 {
+					t.Fatalf("Expected %v at %v, got %v", want, i, out[i])
+				}
+			}
+			if lost := r.Lost(); (test.lost >= 0) != (lost == 1) {
+				t.Fatalf("Expected the lost packets to be counted, got %v", lost)
+			}
+		})
+	}
+}
+
+func TestRTPJitterBuffer(t *testing.T) {
+	const sr = 8000
+	codec := NewL16(sr, 1)
+	w := &packetWriter{}
+	s, _ := NewRTPSender(w, codec, sr, 1)
+	frames := make([]wave.Frame, sr/5)
+	for i := range frames {
+		frames[i] = .5
+	}
+	s.Write(frames)
+
+	r, _ := NewRTPReceiver(codec, sr, 1)
+	out := make([]wave.Frame, 160)
+	r.Push(w.packets[0])
+	r.Push(w.packets[1])
+	r.Fill(out)
+	if out[0] != 0 {
+		t.Fatal("Expected silence before the buffer reached its depth")
+	}
+	r.Push(w.packets[2])
+	r.Fill(out)
+	if math.Abs(float64(out[0])-.5) > 1e-4 {
+		t.Fatal("Expected to play once the buffer reached its depth")
+	}
+	r.Push(w.packets[0])
+	if r.Late() != 1 {
+		t.Fatalf("Expected a packet arriving after its turn to be late, got %v", r.Late())
+	}
+}
+
+func TestRTPTimestampJump(t *testing.T) {
+	const sr = 8000
+	codec := NewL16(sr, 1)
+	w := &packetWriter{}
+	s, _ := NewRTPSender(w, codec, sr, 1)
+	frames := make([]wave.Frame, sr/10)
+	for i := range frames {
+		frames[i] = .5
+	}
+	s.Write(frames)
+
+	// the packet after the missing one claims to be a day later
+	jumped := append([]byte{}, w.packets[4]...)
+	binary.BigEndian.PutUint32(jumped[4:8], binary.BigEndian.Uint32(jumped[4:8])+sr*86400)
+	r, _ := NewRTPReceiver(codec, sr, 1)
+	for _, p := range [][]byte{w.packets[0], w.packets[1], w.packets[2], jumped} {
+		if err := r.Push(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := make([]wave.Frame, 3*160+r.Depth+160)
+	r.Fill(out)
+	for i, f := range out {
+		want := .5
+		if i >= 3*160 && i < 3*160+r.Depth {
+			want = 0
+		}
+		if math.Abs(float64(f)-want) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", want, i, f)
+		}
+	}
+	if r.Lost() != 1 {
+		t.Fatalf("Expected a lost packet, got %v", r.Lost())
+	}
+}
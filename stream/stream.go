@@ -0,0 +1,54 @@
+// Package stream moves audio over networks and pipes, so GoAudio can work on audio that
+// isn't in a local file.
+package stream
+
+import (
+	"errors"
+	"io"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Format opens decoders for the files of which the first bytes match
+type Format struct {
+	Name  string
+	Match func(header []byte) bool
+	Open  func(r io.ReadSeeker) (wave.Decoder, error)
+}
+
+// headerSize is the amount of bytes read to detect a format
+const headerSize = 12
+
+var formats = []Format{
+	{
+		Name: "wav",
+		Match: func(header []byte) bool {
+			return (string(header[0:4]) == "RIFF" || string(header[0:4]) == "RF64") && string(header[8:12]) == "WAVE"
+		},
+		Open: func(r io.ReadSeeker) (wave.Decoder, error) {
+			return wave.NewDecoder(r)
+		},
+	},
+}
+
+// RegisterFormat adds a format to detect, later formats are tried first
+func RegisterFormat(f Format) {
+	formats = append([]Format{f}, formats...)
+}
+
+// NewDecoder detects the format of a file from its first bytes and opens a decoder for it
+func NewDecoder(r io.ReadSeeker) (wave.Decoder, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for _, f := range formats {
+		if f.Match(header) {
+			return f.Open(r)
+		}
+	}
+	return nil, errors.New("Unknown audio format")
+}
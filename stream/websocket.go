@@ -0,0 +1,286 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A minimal WebSocket (RFC 6455) implementation, enough to exchange binary messages with browsers
+
+const (
+	wsContinuation = 0
+	wsText         = 1
+	wsBinary       = 2
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+)
+
+// wsGUID is appended to the key of a handshake to compute the accept header
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessage is the largest message a WebSocket accepts
+const maxMessage = 16 << 20
+
+// WebSocket is a connection upgraded to the WebSocket protocol
+type WebSocket struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool // clients mask the frames they send
+	wmu    sync.Mutex
+	closed bool
+}
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerContains checks whether a comma separated header holds a token
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UpgradeWebSocket answers a WebSocket handshake from an HTTP handler and takes over the connection
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "Expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("Not a WebSocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("Unsupported WebSocket version")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Can't upgrade the connection", http.StatusInternalServerError)
+		return nil, errors.New("Response does not support taking over the connection")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAccept(key) + "\r\n\r\n"
+	if _, err := io.WriteString(conn, resp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &WebSocket{conn: conn, r: rw.Reader}, nil
+}
+
+// DialWebSocket connects to a ws:// or wss:// URL
+func DialWebSocket(rawurl string) (*WebSocket, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		conn, err = net.DialTimeout("tcp", host, dialTimeout)
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", host, nil)
+	default:
+		return nil, fmt.Errorf("Unsupported scheme %v", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req := fmt.Sprintf("GET %v HTTP/1.1\r\nHost: %v\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %v\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+	if _, err := io.WriteString(conn, req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, fmt.Errorf("Server refused the WebSocket: %v", resp.Status)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return &WebSocket{conn: conn, r: r, client: true}, nil
+}
+
+// writeFrame sends a single unfragmented frame
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closed {
+		return errors.New("WebSocket is closed")
+	}
+	hdr := make([]byte, 2, 14)
+	hdr[0] = 0x80 | opcode
+	switch n := len(payload); {
+	case n < 126:
+		hdr[1] = byte(n)
+	case n <= 0xFFFF:
+		hdr[1] = 126
+		hdr = append(hdr, byte(n>>8), byte(n))
+	default:
+		hdr[1] = 127
+		hdr = hdr[:10]
+		binary.BigEndian.PutUint64(hdr[2:], uint64(n))
+	}
+	if ws.client {
+		hdr[1] |= 0x80
+		mask := make([]byte, 4)
+		rand.Read(mask)
+		hdr = append(hdr, mask...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	if _, err := ws.conn.Write(append(hdr, payload...)); err != nil {
+		return err
+	}
+	if opcode == wsClose {
+		ws.closed = true
+	}
+	return nil
+}
+
+// readFrame reads the next frame and unmasks its payload
+func (ws *WebSocket) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(ws.r, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = hdr[0]&0x80 != 0, hdr[0]&0x0F
+	n := uint64(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessage {
+		return false, 0, nil, errors.New("WebSocket frame is too large")
+	}
+	var mask [4]byte
+	masked := hdr[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(ws.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered while reading,
+// and a close from the other side is answered and returned as io.EOF.
+func (ws *WebSocket) ReadMessage() (isBinary bool, data []byte, err error) {
+	started := false
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return false, nil, err
+		}
+		switch opcode {
+		case wsPing:
+			if err := ws.writeFrame(wsPong, payload); err != nil {
+				return false, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			ws.writeFrame(wsClose, payload)
+			return false, nil, io.EOF
+		case wsText, wsBinary:
+			if started {
+				return false, nil, errors.New("WebSocket message started before the previous one ended")
+			}
+			started, isBinary, data = true, opcode == wsBinary, payload
+		case wsContinuation:
+			if !started {
+				return false, nil, errors.New("WebSocket continuation without a message")
+			}
+			if len(data)+len(payload) > maxMessage {
+				return false, nil, errors.New("WebSocket message is too large")
+			}
+			data = append(data, payload...)
+		default:
+			return false, nil, fmt.Errorf("Unknown WebSocket opcode %v", opcode)
+		}
+		if fin {
+			return isBinary, data, nil
+		}
+	}
+}
+
+// WriteMessage sends a binary or text message
+func (ws *WebSocket) WriteMessage(isBinary bool, data []byte) error {
+	if isBinary {
+		return ws.writeFrame(wsBinary, data)
+	}
+	return ws.writeFrame(wsText, data)
+}
+
+// Close sends a normal close to the other side and closes the connection
+func (ws *WebSocket) Close() error {
+	ws.writeFrame(wsClose, []byte{0x03, 0xE8})
+	return ws.conn.Close()
+}
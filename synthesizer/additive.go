@@ -0,0 +1,181 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Partial is a single sine component of an additive sound
+type Partial struct {
+	Freq  []breakpoint.Breakpoint // frequency envelope in Hz
+	Amp   []breakpoint.Breakpoint // amplitude envelope
+	Phase float64                 // initial phase as a fraction of a cycle
+}
+
+// AdditiveSynth generates sound by summing sine partials, each following its own
+// frequency and amplitude envelope
+type AdditiveSynth struct {
+	Partials []Partial
+	Pitch    float64 // multiplier applied to all partial frequencies
+
+	sr      float64
+	time    float64
+	phases  []float64
+	freqIdx []int
+	ampIdx  []int
+}
+
+// NewAdditiveSynth creates an additive synthesizer from the partials
+func NewAdditiveSynth(sr int, partials []Partial) *AdditiveSynth {
+	a := &AdditiveSynth{
+		Partials: partials,
+		Pitch:    1,
+		sr:       float64(sr),
+	}
+	a.Reset()
+	return a
+}
+
+// Reset restarts all partials from the beginning of their envelopes
+func (a *AdditiveSynth) Reset() {
+	a.time = 0
+	a.phases = make([]float64, len(a.Partials))
+	a.freqIdx = make([]int, len(a.Partials))
+	a.ampIdx = make([]int, len(a.Partials))
+	for i, p := range a.Partials {
+		a.phases[i] = p.Phase
+	}
+}
+
+// Tick returns the next sample, the sum of all partials
+func (a *AdditiveSynth) Tick() float64 {
+	out := 0.0
+	for i, p := range a.Partials {
+		var freq, amp float64
+		a.freqIdx[i], freq = breakpoint.ValueAt(p.Freq, a.time, spanStart(a.freqIdx[i]))
+		a.ampIdx[i], amp = breakpoint.ValueAt(p.Amp, a.time, spanStart(a.ampIdx[i]))
+
+		out += amp * math.Sin(tau*a.phases[i])
+		a.phases[i] += freq * a.Pitch / a.sr
+		a.phases[i] -= math.Floor(a.phases[i])
+	}
+	a.time += 1 / a.sr
+	return out
+}
+
+// Render returns duration seconds of audio
+func (a *AdditiveSynth) Render(duration float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*a.sr))
+	for i := range frames {
+		frames[i] = wave.Frame(a.Tick())
+	}
+	return frames
+}
+
+// spanStart turns the index returned by breakpoint.ValueAt into the index to continue searching
+func spanStart(i int) int {
+	if i > 0 {
+		return i - 1
+	}
+	return 0
+}
+
+// AnalyzePartials derives the strongest n partials of a mono sample using a short-time
+// fourier transform. The window size has to be a power of 2, the resulting partials
+// can be passed to NewAdditiveSynth to resynthesize the sample.
+func AnalyzePartials(frames []wave.Frame, sr, windowSize, n int) ([]Partial, error) {
+	if windowSize < 4 || windowSize&(windowSize-1) != 0 {
+		return nil, errors.New("Window size should be a power of 2")
+	}
+	if len(frames) < windowSize {
+		return nil, errors.New("Need at least one window of frames to analyze")
+	}
+
+	window := make([]float64, windowSize)
+	windowSum := 0.0
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(tau*float64(i)/float64(windowSize))
+		windowSum += window[i]
+	}
+
+	// magnitude spectrum of every (half-overlapping) window
+	hop := windowSize / 2
+	bins := windowSize / 2
+	plan, err := audiomath.NewPlan(windowSize)
+	if err != nil {
+		return nil, err
+	}
+	fft := make([]complex128, bins+1)
+	spectra := [][]float64{}
+	buf := wave.AcquireFrames(windowSize)
+	defer wave.ReleaseFrames(buf)
+	for start := 0; start+windowSize <= len(frames); start += hop {
+		for i := range buf {
+			buf[i] = frames[start+i] * wave.Frame(window[i])
+		}
+		plan.Real(fft, buf)
+		mags := make([]float64, bins)
+		for i := range mags {
+			mags[i] = cmplx.Abs(fft[i]) * 2 / windowSum
+		}
+		spectra = append(spectra, mags)
+	}
+
+	// pick the strongest peaks of the average spectrum
+	avg := make([]float64, bins)
+	for _, mags := range spectra {
+		for i, m := range mags {
+			avg[i] += m / float64(len(spectra))
+		}
+	}
+	peaks := []int{}
+	for i := 1; i < bins-1; i++ {
+		if avg[i] > avg[i-1] && avg[i] >= avg[i+1] {
+			peaks = append(peaks, i)
+		}
+	}
+	sort.Slice(peaks, func(i, j int) bool { return avg[peaks[i]] > avg[peaks[j]] })
+	if len(peaks) > n {
+		peaks = peaks[:n]
+	}
+
+	// follow each peak through the windows
+	binWidth := float64(sr) / float64(windowSize)
+	partials := make([]Partial, len(peaks))
+	for p, bin := range peaks {
+		for w, mags := range spectra {
+			// the peak may drift a little between windows
+			b := bin
+			for _, c := range []int{bin - 1, bin + 1} {
+				if c > 0 && c < bins-1 && mags[c] > mags[b] {
+					b = c
+				}
+			}
+			offset, amp := parabolicPeak(mags, b)
+			time := float64(w*hop+windowSize/2) / float64(sr)
+			partials[p].Freq = append(partials[p].Freq, breakpoint.Breakpoint{Time: time, Value: (float64(b) + offset) * binWidth})
+			partials[p].Amp = append(partials[p].Amp, breakpoint.Breakpoint{Time: time, Value: amp})
+		}
+	}
+	return partials, nil
+}
+
+// parabolicPeak refines the position and height of a spectral peak by fitting a parabola
+func parabolicPeak(mags []float64, i int) (offset, height float64) {
+	if i <= 0 || i >= len(mags)-1 {
+		return 0, mags[i]
+	}
+	a, b, c := mags[i-1], mags[i], mags[i+1]
+	denom := a - 2*b + c
+	if denom == 0 {
+		return 0, b
+	}
+	offset = 0.5 * (a - c) / denom
+	return offset, b - 0.25*(a-c)*offset
+}
@@ -0,0 +1,61 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestAdditiveSynth(t *testing.T) {
+	a := synth.NewAdditiveSynth(8, []synth.Partial{
+		{
+			Freq: []breakpoint.Breakpoint{{Time: 0, Value: 1}},
+			Amp:  []breakpoint.Breakpoint{{Time: 0, Value: 1}, {Time: 1, Value: 0}},
+		},
+	})
+	out := a.Render(1)
+	for i, v := range out {
+		amp := 1 - float64(i)/8
+		expected := amp * math.Sin(2*math.Pi*float64(i)/8)
+		if !floatFuzzyEquals(float64(v), expected) {
+			t.Fatalf("Expected %v at %v but got %v", expected, i, v)
+		}
+	}
+}
+
+// TestAnalyzePartials analyzes a two-tone signal and ensures both tones are found
+func TestAnalyzePartials(t *testing.T) {
+	sr := 8000
+	frames := make([]wave.Frame, sr/2)
+	for i := range frames {
+		time := float64(i) / float64(sr)
+		frames[i] = wave.Frame(.5*math.Sin(2*math.Pi*440*time) + .25*math.Sin(2*math.Pi*1000*time))
+	}
+
+	partials, err := synth.AnalyzePartials(frames, sr, 512, 2)
+	if err != nil {
+		t.Fatalf("Should be able to analyze frames: %v", err)
+	}
+	if len(partials) != 2 {
+		t.Fatalf("Expected 2 partials but got %v", len(partials))
+	}
+
+	expected := []struct{ freq, amp float64 }{{440, .5}, {1000, .25}}
+	for i, e := range expected {
+		_, freq := breakpoint.ValueAt(partials[i].Freq, .25, 0)
+		_, amp := breakpoint.ValueAt(partials[i].Amp, .25, 0)
+		if math.Abs(freq-e.freq) > 5 {
+			t.Fatalf("Expected partial at %vHz but got %v", e.freq, freq)
+		}
+		if math.Abs(amp-e.amp) > .05 {
+			t.Fatalf("Expected amplitude %v but got %v", e.amp, amp)
+		}
+	}
+
+	if _, err := synth.AnalyzePartials(frames, sr, 500, 2); err == nil {
+		t.Fatal("Expected an error for a window size which is not a power of 2")
+	}
+}
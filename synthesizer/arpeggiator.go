@@ -0,0 +1,156 @@
+package synthesizer
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// ArpMode is the order in which an arpeggiator plays the held notes
+type ArpMode int
+
+// Arpeggiator modes
+const (
+	ARP_UP ArpMode = iota
+	ARP_DOWN
+	ARP_UP_DOWN
+	ARP_RANDOM
+	ARP_AS_PLAYED
+)
+
+// heldNote is a note held on the arpeggiator
+type heldNote struct {
+	note     int
+	velocity float64
+}
+
+// Arpeggiator turns the notes of a held chord into a sequence of single notes
+type Arpeggiator struct {
+	Mode    ArpMode
+	Rate    NoteDivision // time between two notes
+	Octaves int          // amount of octaves the arpeggio spans
+	Gate    float64      // length of each note as a fraction of the rate
+
+	held []heldNote // in the order they were played
+	pos  int
+	rnd  *rand.Rand
+}
+
+// NewArpeggiator creates an arpeggiator, the seed is used by the random mode
+func NewArpeggiator(mode ArpMode, rate NoteDivision, octaves int, seed int64) *Arpeggiator {
+	if octaves < 1 {
+		octaves = 1
+	}
+	return &Arpeggiator{
+		Mode:    mode,
+		Rate:    rate,
+		Octaves: octaves,
+		Gate:    .5,
+		rnd:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NoteOn adds a note to the held chord
+func (a *Arpeggiator) NoteOn(note int, velocity float64) {
+	for i, h := range a.held {
+		if h.note == note {
+			a.held[i].velocity = velocity
+			return
+		}
+	}
+	a.held = append(a.held, heldNote{note, velocity})
+}
+
+// NoteOff removes a note from the held chord
+func (a *Arpeggiator) NoteOff(note int) {
+	for i, h := range a.held {
+		if h.note == note {
+			a.held = append(a.held[:i], a.held[i+1:]...)
+			return
+		}
+	}
+}
+
+// Reset restarts the arpeggio from its first note
+func (a *Arpeggiator) Reset() {
+	a.pos = 0
+}
+
+// sequence returns one cycle of the arpeggio
+func (a *Arpeggiator) sequence() []heldNote {
+	notes := make([]heldNote, len(a.held))
+	copy(notes, a.held)
+	if a.Mode != ARP_AS_PLAYED {
+		sort.Slice(notes, func(i, j int) bool { return notes[i].note < notes[j].note })
+	}
+
+	seq := make([]heldNote, 0, len(notes)*a.Octaves)
+	for o := 0; o < a.Octaves; o++ {
+		for _, n := range notes {
+			seq = append(seq, heldNote{n.note + 12*o, n.velocity})
+		}
+	}
+
+	switch a.Mode {
+	case ARP_DOWN:
+		for i, j := 0, len(seq)-1; i < j; i, j = i+1, j-1 {
+			seq[i], seq[j] = seq[j], seq[i]
+		}
+	case ARP_UP_DOWN:
+		// don't repeat the highest and lowest notes when turning around
+		for i := len(seq) - 2; i > 0; i-- {
+			seq = append(seq, seq[i])
+		}
+	}
+	return seq
+}
+
+// Next returns the next note of the arpeggio, ok is false when no notes are held
+func (a *Arpeggiator) Next() (note int, velocity float64, ok bool) {
+	seq := a.sequence()
+	if len(seq) == 0 {
+		return 0, 0, false
+	}
+	var n heldNote
+	if a.Mode == ARP_RANDOM {
+		n = seq[a.rnd.Intn(len(seq))]
+	} else {
+		n = seq[a.pos%len(seq)]
+		a.pos++
+	}
+	return n.note, n.velocity, true
+}
+
+// Events returns the arpeggiated notes of the held chord for duration seconds at a tempo
+func (a *Arpeggiator) Events(bpm, duration float64) []NoteEvent {
+	events := []NoteEvent{}
+	step := a.Rate.Seconds(bpm)
+	for time := 0.0; time < duration; time += step {
+		note, velocity, ok := a.Next()
+		if !ok {
+			break
+		}
+		events = append(events, NoteEvent{
+			Time:     time,
+			Duration: step * a.Gate,
+			Note:     note,
+			Velocity: velocity,
+		})
+	}
+	return events
+}
+
+// Pattern returns the next n notes of the arpeggio as a sequencer pattern
+func (a *Arpeggiator) Pattern(n int) Pattern {
+	p := Pattern{Division: a.Rate}
+	for i := 0; i < n; i++ {
+		note, velocity, ok := a.Next()
+		if !ok {
+			p.Steps = append(p.Steps, RestStep())
+			continue
+		}
+		step := NewStep(note, velocity)
+		step.Gate = a.Gate
+		p.Steps = append(p.Steps, step)
+	}
+	return p
+}
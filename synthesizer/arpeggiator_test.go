@@ -0,0 +1,62 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	arpTests = []struct {
+		mode    synth.ArpMode
+		octaves int
+		out     []int
+	}{
+		{synth.ARP_UP, 1, []int{60, 64, 67, 60, 64}},
+		{synth.ARP_DOWN, 1, []int{67, 64, 60, 67}},
+		{synth.ARP_UP_DOWN, 1, []int{60, 64, 67, 64, 60, 64}},
+		{synth.ARP_UP, 2, []int{60, 64, 67, 72, 76, 79, 60}},
+		{synth.ARP_AS_PLAYED, 1, []int{64, 60, 67, 64}},
+	}
+)
+
+func TestArpeggiator(t *testing.T) {
+	for _, test := range arpTests {
+		t.Run("", func(t *testing.T) {
+			arp := synth.NewArpeggiator(test.mode, synth.SIXTEENTH, test.octaves, 1)
+			arp.NoteOn(64, 1)
+			arp.NoteOn(60, 1)
+			arp.NoteOn(67, 1)
+			for i, expected := range test.out {
+				note, _, ok := arp.Next()
+				if !ok || note != expected {
+					t.Fatalf("Expected note %v at %v but got %v", expected, i, note)
+				}
+			}
+		})
+	}
+}
+
+func TestArpeggiatorEvents(t *testing.T) {
+	arp := synth.NewArpeggiator(synth.ARP_RANDOM, synth.EIGHTH, 1, 1)
+	if events := arp.Events(120, 1); len(events) != 0 {
+		t.Fatal("Expected no events without held notes")
+	}
+	arp.NoteOn(60, .5)
+	arp.NoteOn(62, .5)
+	events := arp.Events(120, 1)
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 eighth notes in a second at 120bpm, got %v", len(events))
+	}
+	for _, e := range events {
+		if e.Note != 60 && e.Note != 62 {
+			t.Fatalf("Unexpected note %v", e.Note)
+		}
+	}
+
+	arp.NoteOff(60)
+	pattern := arp.Pattern(3)
+	if len(pattern.Steps) != 3 || pattern.Steps[2].Note != 62 {
+		t.Fatalf("Expected pattern of the remaining note, got %v", pattern.Steps)
+	}
+}
@@ -0,0 +1,90 @@
+package synthesizer
+
+import (
+	"errors"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// AutomatableProcessor is a processor with parameters that can be automated
+type AutomatableProcessor interface {
+	Processor
+	Parameterized
+}
+
+// binding connects a source to a parameter
+type binding struct {
+	param  string
+	source ModSource
+	value  float64 // value of the source at the start of the next block
+}
+
+// Automated is a processor whose parameters follow sources, such as breakpoint streams
+// or LFOs, over the frames it processes
+type Automated struct {
+	Target    AutomatableProcessor
+	BlockSize int // the parameters are updated at the start of every block of samples
+	Channels  int // amount of interleaved channels, the sources advance once per sample of all channels
+
+	bindings []binding
+}
+
+// NewAutomated wraps a processor so its parameters can be automated, updating them every sample
+func NewAutomated(target AutomatableProcessor, channels int) (*Automated, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	return &Automated{
+		Target:    target,
+		BlockSize: 1,
+		Channels:  channels,
+	}, nil
+}
+
+// Bind lets a parameter follow a source, the first value of the source is applied immediately
+func (a *Automated) Bind(param string, source ModSource) error {
+	if source == nil {
+		return errors.New("A binding needs a source")
+	}
+	v := source.Tick()
+	if err := a.Target.SetParam(param, v); err != nil {
+		return err
+	}
+	a.bindings = append(a.bindings, binding{param, source, v})
+	return nil
+}
+
+// Seek moves the sources which support seeking, such as breakpoint streams, to a time in seconds.
+// Other sources, such as LFOs, carry on where they were.
+func (a *Automated) Seek(time float64) {
+	for i := range a.bindings {
+		b := &a.bindings[i]
+		if s, ok := b.source.(Seeker); ok {
+			s.Seek(time)
+			b.value = b.source.Tick()
+		}
+	}
+}
+
+// Process runs the target on the frames, updating the bound parameters as it goes
+func (a *Automated) Process(frames []wave.Frame) {
+	block := a.BlockSize
+	if block < 1 {
+		block = 1
+	}
+	step := block * a.Channels
+	for start := 0; start < len(frames); start += step {
+		end := start + step
+		if end > len(frames) {
+			end = len(frames)
+		}
+		for i := range a.bindings {
+			b := &a.bindings[i]
+			a.Target.SetParam(b.param, b.value)
+			for s := 0; s < block; s++ {
+				b.value = b.source.Tick()
+			}
+		}
+		a.Target.Process(frames[start:end])
+	}
+}
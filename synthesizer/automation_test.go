@@ -0,0 +1,76 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestAutomatedGain(t *testing.T) {
+	fade, _ := breakpoint.NewBreakpointStream([]breakpoint.Breakpoint{{Time: 0, Value: 1}, {Time: 1, Value: 0}}, 4)
+	a, err := synth.NewAutomated(&synth.Gain{Level: 1}, 2)
+	if err != nil {
+		t.Fatalf("Should be able to automate: %v", err)
+	}
+	if err := a.Bind("gain", fade); err != nil {
+		t.Fatalf("Should be able to bind gain: %v", err)
+	}
+
+	frames := make([]wave.Frame, 10) // 5 stereo samples
+	for i := range frames {
+		frames[i] = 1
+	}
+	// process in two calls to make sure the automation continues where it left off
+	a.Process(frames[:4])
+	a.Process(frames[4:])
+	expected := []wave.Frame{1, 1, .75, .75, .5, .5, .25, .25, 0, 0}
+	for i := range expected {
+		if !floatFuzzyEquals(float64(frames[i]), float64(expected[i])) {
+			t.Fatalf("Expected %v at %v, got %v", expected[i], i, frames[i])
+		}
+	}
+
+	if err := a.Bind("cutoff", fade); err == nil {
+		t.Fatal("Expected an error for a parameter the gain doesn't have")
+	}
+}
+
+func TestAutomatedBlocks(t *testing.T) {
+	lfo, _ := synth.NewLFO(8, synth.SQUARE, 1)
+	lfo.Unipolar = true
+	a, _ := synth.NewAutomated(&synth.Gain{}, 1)
+	a.BlockSize = 4
+	a.Bind("gain", lfo)
+
+	frames := make([]wave.Frame, 16)
+	for i := range frames {
+		frames[i] = 1
+	}
+	a.Process(frames)
+	// every block has the gain of the lfo at its first sample
+	reference, _ := synth.NewLFO(8, synth.SQUARE, 1)
+	reference.Unipolar = true
+	values := make([]float64, len(frames))
+	for i := range values {
+		values[i] = reference.Tick()
+	}
+	for i, f := range frames {
+		if expected := wave.Frame(values[i-i%4]); f != expected {
+			t.Fatalf("Expected %v at %v, got %v", expected, i, f)
+		}
+	}
+}
+
+func TestPan(t *testing.T) {
+	p := &synth.Pan{}
+	if err := p.SetParam("pan", -1); err != nil {
+		t.Fatalf("Should be able to pan: %v", err)
+	}
+	frames := []wave.Frame{1, 1}
+	p.Process(frames)
+	if frames[1] > 1e-9 || frames[0] < 1 {
+		t.Fatalf("Expected the signal hard left, got %v", frames)
+	}
+}
@@ -0,0 +1,108 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// BeatMode selects how a BeatGenerator produces its beat
+type BeatMode int
+
+const (
+	BINAURAL   BeatMode = iota // each ear gets a carrier offset by half the beat frequency
+	ISOCHRONIC                 // both ears get the carrier, pulsed on and off at the beat frequency
+)
+
+// Ramp is a parameter moving from Start to End over the duration of a render.
+// The change follows a raised cosine so it eases in and out.
+type Ramp struct {
+	Start float64
+	End   float64
+}
+
+// ConstantRamp returns a ramp which does not change
+func ConstantRamp(v float64) Ramp {
+	return Ramp{v, v}
+}
+
+// At returns the value of the ramp at x in the range [0;1]
+func (r Ramp) At(x float64) float64 {
+	x = math.Max(0, math.Min(x, 1))
+	return r.Start + (r.End-r.Start)*(.5-.5*math.Cos(math.Pi*x))
+}
+
+// BeatGenerator renders stereo binaural beats or isochronic tones
+type BeatGenerator struct {
+	Mode    BeatMode
+	Carrier Ramp    // frequency of the tone in Hz
+	Beat    Ramp    // frequency of the beat in Hz
+	Duty    float64 // fraction of an isochronic pulse during which the tone is on
+}
+
+// NewBeatGenerator creates a generator with a constant carrier and beat frequency
+func NewBeatGenerator(mode BeatMode, carrier, beat float64) *BeatGenerator {
+	return &BeatGenerator{
+		Mode:    mode,
+		Carrier: ConstantRamp(carrier),
+		Beat:    ConstantRamp(beat),
+		Duty:    .5,
+	}
+}
+
+// pulse returns the gain of an isochronic pulse at a phase in the range [0;1),
+// the edges are smoothed to prevent clicks
+func (b *BeatGenerator) pulse(phase float64) float64 {
+	edge := math.Min(.1, b.Duty/2)
+	switch {
+	case phase < edge:
+		return .5 - .5*math.Cos(math.Pi*phase/edge)
+	case phase < b.Duty-edge:
+		return 1
+	case phase < b.Duty:
+		return .5 - .5*math.Cos(math.Pi*(b.Duty-phase)/edge)
+	}
+	return 0
+}
+
+// Render returns duration seconds of interleaved stereo frames
+func (b *BeatGenerator) Render(sr int, duration, amp float64) ([]wave.Frame, error) {
+	if b.Mode != BINAURAL && b.Mode != ISOCHRONIC {
+		return nil, errors.New("Unknown beat mode")
+	}
+	if duration <= 0 {
+		return nil, errors.New("Duration should be positive")
+	}
+	if b.Duty <= 0 || b.Duty > 1 {
+		return nil, errors.New("Duty should be in the range (0;1]")
+	}
+	n := int(duration * float64(sr))
+	frames := make([]wave.Frame, 2*n)
+	var left, right, beat float64 // phases in cycles
+	for i := 0; i < n; i++ {
+		x := float64(i) / float64(n)
+		carrier, bf := b.Carrier.At(x), b.Beat.At(x)
+
+		l, r := math.Sin(tau*left), math.Sin(tau*right)
+		if b.Mode == ISOCHRONIC {
+			g := b.pulse(beat)
+			l, r = l*g, l*g
+		}
+		frames[2*i] = wave.Frame(amp * l)
+		frames[2*i+1] = wave.Frame(amp * r)
+
+		// phases are accumulated so the frequencies can change without discontinuities
+		if b.Mode == BINAURAL {
+			left += (carrier - bf/2) / float64(sr)
+			right += (carrier + bf/2) / float64(sr)
+		} else {
+			left += carrier / float64(sr)
+		}
+		beat += bf / float64(sr)
+		left -= math.Floor(left)
+		right -= math.Floor(right)
+		beat -= math.Floor(beat)
+	}
+	return frames, nil
+}
@@ -0,0 +1,81 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// channel extracts one channel of interleaved stereo frames
+func channel(frames []wave.Frame, c int) []wave.Frame {
+	out := make([]wave.Frame, len(frames)/2)
+	for i := range out {
+		out[i] = frames[2*i+c]
+	}
+	return out
+}
+
+func TestBinauralBeat(t *testing.T) {
+	sr := 8000
+	b := synth.NewBeatGenerator(synth.BINAURAL, 200, 10)
+	frames, err := b.Render(sr, 1, 1)
+	if err != nil {
+		t.Fatalf("Should be able to render: %v", err)
+	}
+	if len(frames) != 2*sr {
+		t.Fatalf("Expected %v frames, got %v", 2*sr, len(frames))
+	}
+	left, right := channel(frames, 0), channel(frames, 1)
+	if f := zeroCrossingFreq(left, sr, sr/2, sr/2); math.Abs(f-195) > 2 {
+		t.Fatalf("Expected the left channel at 195Hz, got %v", f)
+	}
+	if f := zeroCrossingFreq(right, sr, sr/2, sr/2); math.Abs(f-205) > 2 {
+		t.Fatalf("Expected the right channel at 205Hz, got %v", f)
+	}
+}
+
+func TestBeatRamp(t *testing.T) {
+	sr := 8000
+	b := synth.NewBeatGenerator(synth.BINAURAL, 200, 0)
+	b.Carrier = synth.Ramp{Start: 100, End: 400}
+	frames, err := b.Render(sr, 4, 1)
+	if err != nil {
+		t.Fatalf("Should be able to render: %v", err)
+	}
+	left := channel(frames, 0)
+	for _, test := range []struct {
+		at   int
+		freq float64
+	}{{sr / 10, 100}, {2 * sr, 250}, {4*sr - sr/10, 400}} {
+		if f := zeroCrossingFreq(left, sr, test.at, sr/10); math.Abs(f-test.freq)/test.freq > .05 {
+			t.Fatalf("Expected %vHz at frame %v, got %v", test.freq, test.at, f)
+		}
+	}
+}
+
+func TestIsochronicTone(t *testing.T) {
+	sr := 8000
+	b := synth.NewBeatGenerator(synth.ISOCHRONIC, 200, 4)
+	frames, err := b.Render(sr, 1, 1)
+	if err != nil {
+		t.Fatalf("Should be able to render: %v", err)
+	}
+	left := channel(frames, 0)
+	// every pulse lasts a quarter second, on for the first half
+	for p := 0; p < 4; p++ {
+		start := p * sr / 4
+		if peak(left[start+sr/32:start+sr/10]) < .9 {
+			t.Fatalf("Expected pulse %v to be on", p)
+		}
+		if peak(left[start+sr/8+1:start+sr/4]) != 0 {
+			t.Fatalf("Expected pulse %v to be off", p)
+		}
+	}
+
+	b.Duty = 0
+	if _, err := b.Render(sr, 1, 1); err == nil {
+		t.Fatal("Expected an error for a duty of 0")
+	}
+}
@@ -0,0 +1,386 @@
+package synthesizer
+
+import (
+	"math"
+)
+
+// General MIDI notes of the drums in a DrumKit
+const (
+	gmKick       = 36
+	gmSnare      = 38
+	gmClap       = 39
+	gmClosedHat  = 42
+	gmOpenHat    = 46
+	clapBursts   = 3
+	clapInterval = .01 // seconds between the bursts of a clap
+)
+
+// hihatRatios are the frequencies of the square waves making up the metallic hihat sound
+var hihatRatios = []float64{205.3, 304.4, 369.6, 522.7, 540, 800}
+
+// Drum is a percussion sound which is triggered rather than held
+type Drum interface {
+	Trigger(velocity float64)
+	Tick() float64
+	Active() bool
+}
+
+// decay returns the multiplier which fades a level by 60dB over the time in seconds
+func decay(time, sr float64) float64 {
+	if time <= 0 {
+		return 0
+	}
+	return math.Pow(10, -3/(time*sr))
+}
+
+// Kick is a kick drum made from a sine wave with a falling pitch
+type Kick struct {
+	StartFreq float64 // frequency at the start of the hit
+	EndFreq   float64 // frequency the pitch falls to
+	Sweep     float64 // time constant of the pitch sweep in seconds
+	Decay     float64 // time in seconds to fade by 60dB
+	Click     float64 // level of the noise click at the start
+
+	sr    float64
+	noise *Noise
+	time  float64
+	phase float64
+	amp   float64
+	click float64
+}
+
+// NewKick creates a kick drum with a classic electronic sound
+func NewKick(sr int, seed int64) *Kick {
+	noise, _ := NewNoise(WHITE, seed)
+	return &Kick{
+		StartFreq: 150,
+		EndFreq:   45,
+		Sweep:     .04,
+		Decay:     .5,
+		Click:     .3,
+		sr:        float64(sr),
+		noise:     noise,
+	}
+}
+
+// Trigger starts a new hit
+func (k *Kick) Trigger(velocity float64) {
+	k.time, k.phase = 0, 0
+	k.amp = velocity
+	k.click = velocity * k.Click
+}
+
+// Choke silences the kick
+func (k *Kick) Choke() {
+	k.amp, k.click = 0, 0
+}
+
+// Active returns true while the kick is audible
+func (k *Kick) Active() bool {
+	return k.amp > silence
+}
+
+// Tick returns the next sample of the kick
+func (k *Kick) Tick() float64 {
+	if !k.Active() {
+		return 0
+	}
+	freq := k.EndFreq + (k.StartFreq-k.EndFreq)*math.Exp(-k.time/k.Sweep)
+	out := k.amp*math.Sin(tau*k.phase) + k.click*k.noise.Tick(0)
+
+	k.phase += freq / k.sr
+	k.phase -= math.Floor(k.phase)
+	k.time += 1 / k.sr
+	k.amp *= decay(k.Decay, k.sr)
+	k.click *= decay(.005, k.sr)
+	return out
+}
+
+// Snare is a snare drum mixing a short tone with filtered noise
+type Snare struct {
+	Freq       float64 // frequency of the tone
+	ToneDecay  float64 // in seconds
+	NoiseDecay float64 // in seconds
+	Snappy     float64 // mix between tone (0) and noise (1)
+
+	sr     float64
+	noise  *Noise
+	filter *SVF
+	phase  float64
+	tone   float64
+	rattle float64
+}
+
+// NewSnare creates a snare drum
+func NewSnare(sr int, seed int64) *Snare {
+	noise, _ := NewNoise(WHITE, seed)
+	return &Snare{
+		Freq:       185,
+		ToneDecay:  .15,
+		NoiseDecay: .25,
+		Snappy:     .6,
+		sr:         float64(sr),
+		noise:      noise,
+		filter:     NewSVF(sr, HIGHPASS, 1500, .1),
+	}
+}
+
+// Trigger starts a new hit
+func (s *Snare) Trigger(velocity float64) {
+	s.phase = 0
+	s.tone = velocity * (1 - s.Snappy)
+	s.rattle = velocity * s.Snappy
+}
+
+// Choke silences the snare
+func (s *Snare) Choke() {
+	s.tone, s.rattle = 0, 0
+}
+
+// Active returns true while the snare is audible
+func (s *Snare) Active() bool {
+	return s.tone > silence || s.rattle > silence
+}
+
+// Tick returns the next sample of the snare
+func (s *Snare) Tick() float64 {
+	if !s.Active() {
+		return 0
+	}
+	out := s.tone*math.Sin(tau*s.phase) + s.rattle*s.filter.Tick(s.noise.Tick(0))
+	s.phase += s.Freq / s.sr
+	s.phase -= math.Floor(s.phase)
+	s.tone *= decay(s.ToneDecay, s.sr)
+	s.rattle *= decay(s.NoiseDecay, s.sr)
+	return out
+}
+
+// HiHat is a hihat made from metallic square waves and noise through a highpass filter
+type HiHat struct {
+	Decay  float64 // in seconds, short for a closed hihat and long for an open one
+	Cutoff float64 // cutoff of the highpass filter in Hz
+
+	sr     float64
+	noise  *Noise
+	filter *SVF
+	phases []float64
+	amp    float64
+}
+
+// NewHiHat creates a hihat with the given decay time
+func NewHiHat(sr int, decay float64, seed int64) *HiHat {
+	noise, _ := NewNoise(WHITE, seed)
+	return &HiHat{
+		Decay:  decay,
+		Cutoff: 7000,
+		sr:     float64(sr),
+		noise:  noise,
+		filter: NewSVF(sr, HIGHPASS, 7000, .2),
+		phases: make([]float64, len(hihatRatios)),
+	}
+}
+
+// Trigger starts a new hit
+func (h *HiHat) Trigger(velocity float64) {
+	h.filter.Set(h.Cutoff, .2)
+	h.amp = velocity
+}
+
+// Choke silences the hihat, used when a closed hihat cuts off an open one
+func (h *HiHat) Choke() {
+	h.amp = 0
+}
+
+// Active returns true while the hihat is audible
+func (h *HiHat) Active() bool {
+	return h.amp > silence
+}
+
+// Tick returns the next sample of the hihat
+func (h *HiHat) Tick() float64 {
+	if !h.Active() {
+		return 0
+	}
+	metal := 0.0
+	for i, f := range hihatRatios {
+		metal += squareCalc(tau * h.phases[i])
+		h.phases[i] += f / h.sr
+		h.phases[i] -= math.Floor(h.phases[i])
+	}
+	metal /= float64(len(hihatRatios))
+
+	out := h.amp * h.filter.Tick(.5*metal+.5*h.noise.Tick(0))
+	h.amp *= decay(h.Decay, h.sr)
+	return out
+}
+
+// Clap is a handclap made from several quick bursts of bandpassed noise
+type Clap struct {
+	Decay float64 // decay of the tail in seconds
+
+	sr     float64
+	noise  *Noise
+	filter *SVF
+	time   float64
+	amp    float64
+	tail   float64
+}
+
+// NewClap creates a handclap
+func NewClap(sr int, seed int64) *Clap {
+	noise, _ := NewNoise(WHITE, seed)
+	return &Clap{
+		Decay:  .3,
+		sr:     float64(sr),
+		noise:  noise,
+		filter: NewSVF(sr, BANDPASS, 1200, .5),
+	}
+}
+
+// Trigger starts a new hit
+func (c *Clap) Trigger(velocity float64) {
+	c.time = 0
+	c.amp = velocity
+	c.tail = velocity
+}
+
+// Active returns true while the clap is audible
+func (c *Clap) Active() bool {
+	return c.tail > silence
+}
+
+// Tick returns the next sample of the clap
+func (c *Clap) Tick() float64 {
+	if !c.Active() {
+		return 0
+	}
+	level := c.tail
+	if c.time < clapBursts*clapInterval {
+		// each burst decays quickly before the next one starts
+		since := math.Mod(c.time, clapInterval)
+		level = c.amp * math.Pow(10, -3*since/clapInterval)
+	} else {
+		c.tail *= decay(c.Decay, c.sr)
+	}
+	c.time += 1 / c.sr
+	return level * c.filter.Tick(c.noise.Tick(0)) * 2
+}
+
+// DrumKit is an Instrument playing drums mapped to MIDI notes.
+// NewDrumKit maps the drums to their General MIDI notes.
+type DrumKit struct {
+	Drums map[int]Drum
+	Choke map[int][]int // notes which cut off the drums of other notes, such as an open hihat
+	Gain  float64
+}
+
+// NewDrumKit creates a kit with a kick (36), snare (38), clap (39), closed hihat (42) and open hihat (46)
+func NewDrumKit(sr int, seed int64) *DrumKit {
+	return &DrumKit{
+		Drums: map[int]Drum{
+			gmKick:      NewKick(sr, seed),
+			gmSnare:     NewSnare(sr, seed+1),
+			gmClap:      NewClap(sr, seed+2),
+			gmClosedHat: NewHiHat(sr, .05, seed+3),
+			gmOpenHat:   NewHiHat(sr, .5, seed+4),
+		},
+		Choke: map[int][]int{gmClosedHat: {gmOpenHat}},
+		Gain:  .5,
+	}
+}
+
+// tom is a tuned drum with a short fall in pitch, for toms, bongos and congas
+func tom(sr int, freq, decay float64, seed int64) *Kick {
+	k := NewKick(sr, seed)
+	k.StartFreq, k.EndFreq = freq*1.5, freq
+	k.Sweep, k.Decay, k.Click = .05, decay, .1
+	return k
+}
+
+// block is a short pitched hit, for wood blocks, cowbells and triangles
+func block(sr int, freq, decay float64, seed int64) *Snare {
+	s := NewSnare(sr, seed)
+	s.Freq, s.ToneDecay, s.Snappy = freq, decay, 0
+	return s
+}
+
+// NewGMDrumKit creates a kit with a drum for every note of the General MIDI percussion map,
+// from the acoustic bass drum (35) to the open triangle (81). Cymbals and shakers are
+// made like hihats, toms and hand drums like kicks, and the other percussion like blocks.
+func NewGMDrumKit(sr int, seed int64) *DrumKit {
+	kit := NewDrumKit(sr, seed)
+	n := func(note int) int64 { return seed + int64(note) }
+	rim, electric := NewSnare(sr, n(37)), NewSnare(sr, n(40))
+	rim.Freq, rim.ToneDecay, rim.Snappy = 400, .05, .2
+	electric.Snappy = .8
+	acoustic := NewKick(sr, n(35))
+	acoustic.StartFreq, acoustic.EndFreq = 120, 40
+
+	drums := map[int]Drum{
+		35: acoustic, 37: rim, 40: electric,
+		// toms
+		41: tom(sr, 65, .5, n(41)), 43: tom(sr, 75, .5, n(43)), 45: tom(sr, 90, .45, n(45)),
+		47: tom(sr, 105, .45, n(47)), 48: tom(sr, 120, .4, n(48)), 50: tom(sr, 140, .4, n(50)),
+		// cymbals
+		44: NewHiHat(sr, .08, n(44)), 49: NewHiHat(sr, 1.5, n(49)), 51: NewHiHat(sr, .9, n(51)),
+		52: NewHiHat(sr, 1.2, n(52)), 53: NewHiHat(sr, .6, n(53)), 55: NewHiHat(sr, .6, n(55)),
+		57: NewHiHat(sr, 1.5, n(57)), 59: NewHiHat(sr, .9, n(59)),
+		// percussion
+		54: NewHiHat(sr, .15, n(54)), 56: block(sr, 560, .15, n(56)), 58: NewHiHat(sr, .4, n(58)),
+		60: tom(sr, 400, .15, n(60)), 61: tom(sr, 300, .2, n(61)), 62: tom(sr, 330, .08, n(62)),
+		63: tom(sr, 330, .3, n(63)), 64: tom(sr, 220, .35, n(64)), 65: tom(sr, 260, .25, n(65)),
+		66: tom(sr, 180, .3, n(66)), 67: block(sr, 900, .12, n(67)), 68: block(sr, 680, .12, n(68)),
+		69: NewHiHat(sr, .06, n(69)), 70: NewHiHat(sr, .04, n(70)), 71: block(sr, 2500, .2, n(71)),
+		72: block(sr, 2200, .5, n(72)), 73: NewHiHat(sr, .05, n(73)), 74: NewHiHat(sr, .2, n(74)),
+		75: block(sr, 2500, .03, n(75)), 76: block(sr, 900, .05, n(76)), 77: block(sr, 700, .05, n(77)),
+		78: tom(sr, 600, .1, n(78)), 79: tom(sr, 500, .3, n(79)), 80: block(sr, 4000, .1, n(80)),
+		81: block(sr, 4000, 1, n(81)),
+	}
+	for note, drum := range drums {
+		kit.Drums[note] = drum
+	}
+	// the closed and pedal hihats, and the mute triangle and cuica, cut off the open ones
+	kit.Choke = map[int][]int{gmClosedHat: {gmOpenHat}, 44: {gmOpenHat}, 80: {81}, 78: {79}}
+	return kit
+}
+
+// choker is implemented by drums which can be cut off
+type choker interface {
+	Choke()
+}
+
+// NoteOn triggers the drum mapped to the note, after cutting off the drums it chokes
+func (d *DrumKit) NoteOn(note int, velocity float64) {
+	drum, ok := d.Drums[note]
+	if !ok {
+		return
+	}
+	for _, other := range d.Choke[note] {
+		if c, ok := d.Drums[other].(choker); ok {
+			c.Choke()
+		}
+	}
+	drum.Trigger(velocity)
+}
+
+// NoteOff is a no-op, drums play out by themselves
+func (d *DrumKit) NoteOff(note int) {}
+
+// Active returns true while any of the drums is audible
+func (d *DrumKit) Active() bool {
+	for _, drum := range d.Drums {
+		if drum.Active() {
+			return true
+		}
+	}
+	return false
+}
+
+// Tick returns the mix of all drums
+func (d *DrumKit) Tick() float64 {
+	out := 0.0
+	for _, drum := range d.Drums {
+		out += drum.Tick()
+	}
+	return out * d.Gain
+}
@@ -0,0 +1,79 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestDrums(t *testing.T) {
+	sr := 22050
+	drums := []synth.Drum{
+		synth.NewKick(sr, 1),
+		synth.NewSnare(sr, 1),
+		synth.NewHiHat(sr, .05, 1),
+		synth.NewClap(sr, 1),
+	}
+	for _, drum := range drums {
+		t.Run("", func(t *testing.T) {
+			if drum.Active() {
+				t.Fatal("Drum should be silent before it is triggered")
+			}
+			drum.Trigger(1)
+			frames := make([]wave.Frame, 2*sr)
+			for i := range frames {
+				frames[i] = wave.Frame(drum.Tick())
+			}
+			if p := peak(frames[:sr/10]); p < .05 || p > 2 {
+				t.Fatalf("Expected an audible hit, got peak %v", p)
+			}
+			if drum.Active() || peak(frames[sr:]) > .001 {
+				t.Fatal("Expected the drum to have died out")
+			}
+		})
+	}
+}
+
+func TestDrumKit(t *testing.T) {
+	kit := synth.NewDrumKit(8000, 1)
+	seq := synth.NewSequencer(120, 1, synth.Pattern{
+		Division: synth.QUARTER,
+		Steps:    []synth.Step{synth.NewStep(36, 1), synth.NewStep(38, 1), synth.NewStep(36, 1), synth.NewStep(38, 1)},
+	})
+	frames := seq.Render(kit, 8000, 1, .5)
+	if p := peak(frames); p == 0 {
+		t.Fatal("Expected the kit to play the pattern")
+	}
+
+	open := kit.Drums[46]
+	kit.NoteOn(46, 1)
+	kit.NoteOn(42, 1)
+	if open.Active() {
+		t.Fatal("Expected the closed hihat to choke the open hihat")
+	}
+}
+
+func TestGMDrumKit(t *testing.T) {
+	sr := 22050
+	kit := synth.NewGMDrumKit(sr, 1)
+	for note := 35; note <= 81; note++ {
+		drum, ok := kit.Drums[note]
+		if !ok {
+			t.Fatalf("Expected a drum for note %v", note)
+		}
+		kit.NoteOn(note, 1)
+		frames := make([]wave.Frame, sr/10)
+		for i := range frames {
+			frames[i] = wave.Frame(drum.Tick())
+		}
+		if p := peak(frames); p < .01 {
+			t.Fatalf("Expected note %v to be audible, got peak %v", note, p)
+		}
+	}
+	open := kit.Drums[81]
+	kit.NoteOn(80, 1)
+	if open.Active() {
+		t.Fatal("Expected the mute triangle to choke the open triangle")
+	}
+}
@@ -0,0 +1,171 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	// dtmfRows and dtmfCols are the low and high group frequencies of the DTMF keypad
+	dtmfRows = []float64{697, 770, 852, 941}
+	dtmfCols = []float64{1209, 1336, 1477, 1633}
+	dtmfKeys = []string{"123A", "456B", "789C", "*0#D"}
+)
+
+// TelephonyTone is a call progress tone as used in North America
+type TelephonyTone int
+
+const (
+	DIAL_TONE TelephonyTone = iota
+	RINGBACK_TONE
+	BUSY_TONE
+	REORDER_TONE
+)
+
+// telephonyTones lists the frequencies and on/off cadence in seconds of each tone,
+// a tone without an off time is continuous
+var telephonyTones = map[TelephonyTone]struct {
+	freqs   [2]float64
+	on, off float64
+}{
+	DIAL_TONE:     {[2]float64{350, 440}, 1, 0},
+	RINGBACK_TONE: {[2]float64{440, 480}, 2, 4},
+	BUSY_TONE:     {[2]float64{480, 620}, .5, .5},
+	REORDER_TONE:  {[2]float64{480, 620}, .25, .25},
+}
+
+// dtmfFrequencies returns the row and column frequency of a key
+func dtmfFrequencies(key rune) (float64, float64, error) {
+	key = []rune(strings.ToUpper(string(key)))[0]
+	for r, row := range dtmfKeys {
+		if c := strings.IndexRune(row, key); c >= 0 {
+			return dtmfRows[r], dtmfCols[c], nil
+		}
+	}
+	return 0, 0, fmt.Errorf("%q is not a DTMF key", key)
+}
+
+// dualTone generates two summed sines of equal level
+func dualTone(sr int, f1, f2, duration, amp float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	for i := range frames {
+		t := float64(i) / float64(sr)
+		frames[i] = wave.Frame(amp / 2 * (math.Sin(tau*f1*t) + math.Sin(tau*f2*t)))
+	}
+	return frames
+}
+
+// DTMFTone generates the tone of a single key (0-9, A-D, * or #)
+func DTMFTone(sr int, key rune, duration, amp float64) ([]wave.Frame, error) {
+	row, col, err := dtmfFrequencies(key)
+	if err != nil {
+		return nil, err
+	}
+	return dualTone(sr, row, col, duration, amp), nil
+}
+
+// DTMFSequence dials the keys, each lasting toneDuration seconds followed by gap seconds of silence
+func DTMFSequence(sr int, keys string, toneDuration, gap, amp float64) ([]wave.Frame, error) {
+	frames := []wave.Frame{}
+	silence := make([]wave.Frame, int(gap*float64(sr)))
+	for _, key := range keys {
+		tone, err := DTMFTone(sr, key, toneDuration, amp)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, tone...)
+		frames = append(frames, silence...)
+	}
+	return frames, nil
+}
+
+// CallProgressTone generates duration seconds of a call progress tone, starting with its on period
+func CallProgressTone(sr int, tone TelephonyTone, duration, amp float64) ([]wave.Frame, error) {
+	t, ok := telephonyTones[tone]
+	if !ok {
+		return nil, fmt.Errorf("Unknown telephony tone %v", tone)
+	}
+	frames := dualTone(sr, t.freqs[0], t.freqs[1], duration, amp)
+	if t.off == 0 {
+		return frames, nil
+	}
+	for i := range frames {
+		if math.Mod(float64(i)/float64(sr), t.on+t.off) >= t.on {
+			frames[i] = 0
+		}
+	}
+	return frames, nil
+}
+
+// DTMFDetector decodes DTMF keys from audio
+type DTMFDetector struct {
+	BlockSize int     // frames analyzed at once, about 10ms is a good size
+	Threshold float64 // minimum amplitude of both tones
+	Twist     float64 // maximum ratio between the amplitudes of the two tones
+
+	sr int
+}
+
+// NewDTMFDetector creates a detector with settings suitable for telephone audio
+func NewDTMFDetector(sr int) *DTMFDetector {
+	return &DTMFDetector{
+		BlockSize: sr / 100,
+		Threshold: .05,
+		Twist:     2.5,
+		sr:        sr,
+	}
+}
+
+// strongest returns the index of the strongest frequency in a group, or -1 when it does not
+// stand out clearly from the others
+func (d *DTMFDetector) strongest(frames []wave.Frame, freqs []float64) (int, float64) {
+	best, second, index := 0.0, 0.0, -1
+	for i, f := range freqs {
+		amp := audiomath.Goertzel(frames, d.sr, f)
+		if amp > best {
+			best, second, index = amp, best, i
+		} else if amp > second {
+			second = amp
+		}
+	}
+	if best < d.Threshold || second > best/2 {
+		return -1, 0
+	}
+	return index, best
+}
+
+// detect returns the key present in a block of frames, or 0 when there is none
+func (d *DTMFDetector) detect(frames []wave.Frame) rune {
+	row, rowAmp := d.strongest(frames, dtmfRows)
+	col, colAmp := d.strongest(frames, dtmfCols)
+	if row < 0 || col < 0 {
+		return 0
+	}
+	if rowAmp > colAmp*d.Twist || colAmp > rowAmp*d.Twist {
+		return 0
+	}
+	return rune(dtmfKeys[row][col])
+}
+
+// Decode returns the keys dialed in the frames. A key has to be present in at least two
+// consecutive blocks to be detected, and is only reported again after a pause.
+func (d *DTMFDetector) Decode(frames []wave.Frame) string {
+	keys := []rune{}
+	var last, reported rune
+	for i := 0; i+d.BlockSize <= len(frames); i += d.BlockSize {
+		key := d.detect(frames[i : i+d.BlockSize])
+		if key != 0 && key == last && key != reported {
+			keys = append(keys, key)
+			reported = key
+		}
+		if key == 0 {
+			reported = 0
+		}
+		last = key
+	}
+	return string(keys)
+}
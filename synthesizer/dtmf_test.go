@@ -0,0 +1,55 @@
+package synthesizer_test
+
+import (
+	"math/rand"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestDTMF(t *testing.T) {
+	sr := 8000
+	tests := []struct {
+		keys  string
+		noise float64
+	}{
+		{"0123456789", 0},
+		{"*#ABCD", 0},
+		{"5551234", .05},
+		{"11", 0},
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			frames, err := synth.DTMFSequence(sr, test.keys, .07, .05, .5)
+			if err != nil {
+				t.Fatalf("Should be able to dial: %v", err)
+			}
+			for i := range frames {
+				frames[i] += wave.Frame(test.noise * (rnd.Float64()*2 - 1))
+			}
+			if keys := synth.NewDTMFDetector(sr).Decode(frames); keys != test.keys {
+				t.Fatalf("Expected %q, got %q", test.keys, keys)
+			}
+		})
+	}
+
+	if _, err := synth.DTMFTone(sr, 'x', 1, 1); err == nil {
+		t.Fatal("Expected an error for an invalid key")
+	}
+}
+
+func TestCallProgressTone(t *testing.T) {
+	sr := 8000
+	frames, err := synth.CallProgressTone(sr, synth.BUSY_TONE, 2, 1)
+	if err != nil {
+		t.Fatalf("Should be able to create tone: %v", err)
+	}
+	if peak(frames[:sr/2]) < .5 || peak(frames[sr/2:sr]) != 0 || peak(frames[sr:3*sr/2]) < .5 {
+		t.Fatal("Expected a busy tone to be on and off for half a second")
+	}
+	if keys := synth.NewDTMFDetector(sr).Decode(frames); keys != "" {
+		t.Fatalf("Expected no keys in a busy tone, got %q", keys)
+	}
+}
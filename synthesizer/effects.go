@@ -0,0 +1,58 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Gain is a processor changing the level of the frames
+type Gain struct {
+	Level float64 // linear gain
+}
+
+// NewGain creates a gain processor from a level in decibels
+func NewGain(db float64) *Gain {
+	return &Gain{Level: math.Pow(10, db/20)}
+}
+
+// SetParam sets the linear "gain" so it can be automated
+func (g *Gain) SetParam(name string, value float64) error {
+	if name != "gain" {
+		return fmt.Errorf("Gain has no parameter %v", name)
+	}
+	g.Level = value
+	return nil
+}
+
+// Process scales the frames in place
+func (g *Gain) Process(frames []wave.Frame) {
+	wave.ApplyGain(frames, wave.Frame(g.Level))
+}
+
+// Pan is a processor positioning interleaved stereo frames between the speakers
+type Pan struct {
+	Position float64 // in the range [-1;1], -1 is hard left
+}
+
+// SetParam sets the "pan" position so it can be automated
+func (p *Pan) SetParam(name string, value float64) error {
+	if name != "pan" {
+		return fmt.Errorf("Pan has no parameter %v", name)
+	}
+	p.Position = math.Max(-1, math.Min(value, 1))
+	return nil
+}
+
+// Process pans interleaved stereo frames in place, using an equal power law
+func (p *Pan) Process(frames []wave.Frame) {
+	angle := (p.Position + 1) * math.Pi / 4
+	// the center keeps the original level
+	left := wave.Frame(math.Cos(angle) * math.Sqrt2)
+	right := wave.Frame(math.Sin(angle) * math.Sqrt2)
+	for i := 0; i+1 < len(frames); i += 2 {
+		frames[i] *= left
+		frames[i+1] *= right
+	}
+}
@@ -0,0 +1,243 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+)
+
+// curveResolution is the amount of points used when a curved segment is turned into breakpoints
+const curveResolution = 16
+
+// Segment is a single timed stage of an Envelope
+type Segment struct {
+	Duration float64 // in seconds
+	Level    float64 // value reached at the end of the segment
+	Curve    float64 // 0 is linear, positive values start slow, negative values start fast
+}
+
+// Envelope is a multi-stage envelope generator built from arbitrary segments.
+// While the gate is open the segments before Release are played, looping from LoopEnd back to
+// LoopStart if a loop is set, or holding the level of the last segment (sustain) otherwise.
+// Closing the gate jumps straight to the release segments.
+type Envelope struct {
+	Start     float64 // value before the first segment
+	Segments  []Segment
+	Release   int // index of the first release segment, -1 plays all segments as a one-shot
+	LoopStart int // first segment of the loop, -1 when not looping
+	LoopEnd   int // last segment of the loop
+
+	sr         float64
+	active     bool
+	gate       bool
+	sustaining bool
+	segment    int
+	pos        float64 // position in samples within the current segment
+	from       float64 // value at the start of the current segment
+	value      float64
+}
+
+// NewEnvelope creates a one-shot envelope from the segments for the given sample rate
+func NewEnvelope(sr int, start float64, segments []Segment) *Envelope {
+	return &Envelope{
+		Start:     start,
+		Segments:  segments,
+		Release:   -1,
+		LoopStart: -1,
+		LoopEnd:   -1,
+		sr:        float64(sr),
+		value:     start,
+	}
+}
+
+// NewDAHDSR creates a delay -> attack -> hold -> decay -> sustain -> release envelope
+// peaking at 1, time durations are passed as seconds.
+func NewDAHDSR(sr int, delay, attack, hold, decay, sustain, release float64) *Envelope {
+	e := NewEnvelope(sr, 0, []Segment{
+		{Duration: delay, Level: 0},
+		{Duration: attack, Level: 1},
+		{Duration: hold, Level: 1},
+		{Duration: decay, Level: sustain},
+		{Duration: release, Level: 0},
+	})
+	e.Release = 4
+	return e
+}
+
+// SetRelease marks segment i as the first release segment.
+// The segments before it are sustained at their final level for as long as the gate is open.
+func (e *Envelope) SetRelease(i int) error {
+	if i < 1 || i > len(e.Segments) {
+		return errors.New("Release point should be within the envelope segments")
+	}
+	if e.LoopStart >= 0 && e.LoopEnd >= i {
+		return errors.New("Release point can not be inside the loop")
+	}
+	e.Release = i
+	return nil
+}
+
+// SetLoop loops the segments start..end (inclusive) while the gate is open
+func (e *Envelope) SetLoop(start, end int) error {
+	if start < 0 || end < start || end >= len(e.Segments) {
+		return errors.New("Invalid loop points for envelope")
+	}
+	if e.Release >= 0 && end >= e.Release {
+		return errors.New("Loop has to end before the release point")
+	}
+	e.LoopStart, e.LoopEnd = start, end
+	return nil
+}
+
+// ClearLoop removes the loop points from the envelope
+func (e *Envelope) ClearLoop() {
+	e.LoopStart, e.LoopEnd = -1, -1
+}
+
+// NoteOn opens the gate and restarts the envelope from the start value
+func (e *Envelope) NoteOn() {
+	e.active = len(e.Segments) > 0
+	e.gate = true
+	e.sustaining = false
+	e.segment = 0
+	e.pos = 0
+	e.from = e.Start
+	e.value = e.Start
+}
+
+// NoteOff closes the gate, moving on to the release segments from the current value
+func (e *Envelope) NoteOff() {
+	e.gate = false
+	if !e.active || e.Release < 0 || (e.segment >= e.Release && !e.sustaining) {
+		return
+	}
+	e.sustaining = false
+	e.from = e.value
+	e.pos = 0
+	if e.Release >= len(e.Segments) {
+		e.active = false
+		return
+	}
+	e.segment = e.Release
+}
+
+// Done returns true once the envelope has played all of its segments
+func (e *Envelope) Done() bool {
+	return !e.active
+}
+
+// Value returns the current value of the envelope without advancing it
+func (e *Envelope) Value() float64 {
+	return e.value
+}
+
+// Tick returns the next value of the envelope
+func (e *Envelope) Tick() float64 {
+	if !e.active || e.sustaining {
+		return e.value
+	}
+
+	// skip over segments without duration, bounded in case a loop contains only those
+	for i := 0; i <= len(e.Segments); i++ {
+		if e.Segments[e.segment].Duration*e.sr >= 1 {
+			break
+		}
+		e.value = e.Segments[e.segment].Level
+		if !e.next() {
+			return e.value
+		}
+	}
+
+	seg := e.Segments[e.segment]
+	length := seg.Duration * e.sr
+	if length < 1 {
+		return e.value
+	}
+	e.value = e.from + (seg.Level-e.from)*curve(e.pos/length, seg.Curve)
+	e.pos++
+	if e.pos >= length {
+		e.next()
+	}
+	return e.value
+}
+
+// next moves to the following segment, returns false if the envelope stopped moving
+func (e *Envelope) next() bool {
+	level := e.Segments[e.segment].Level
+	e.from = level
+	e.pos = 0
+
+	following := e.segment + 1
+	if e.gate && e.LoopStart >= 0 && e.segment == e.LoopEnd {
+		following = e.LoopStart
+	} else if e.gate && e.Release >= 0 && following == e.Release {
+		e.sustaining = true
+		return false
+	}
+	if following >= len(e.Segments) {
+		e.value = level
+		e.active = false
+		return false
+	}
+	e.segment = following
+	return true
+}
+
+// ToBreakpoints turns the envelope into breakpoints, holding the sustain level for the given
+// amount of seconds. Loops are played once and curved segments are approximated by
+// several linear segments.
+func (e *Envelope) ToBreakpoints(sustain float64) []breakpoint.Breakpoint {
+	bs := []breakpoint.Breakpoint{{Time: 0, Value: e.Start}}
+	time, from := 0.0, e.Start
+	for i, seg := range e.Segments {
+		if i == e.Release {
+			time += sustain
+			bs = append(bs, breakpoint.Breakpoint{Time: time, Value: from})
+		}
+		steps := 1
+		if seg.Curve != 0 {
+			steps = curveResolution
+		}
+		for s := 1; s <= steps; s++ {
+			frac := float64(s) / float64(steps)
+			bs = append(bs, breakpoint.Breakpoint{
+				Time:  time + frac*seg.Duration,
+				Value: from + (seg.Level-from)*curve(frac, seg.Curve),
+			})
+		}
+		time += seg.Duration
+		from = seg.Level
+	}
+	return bs
+}
+
+// EnvelopeFromBreakpoints creates a one-shot envelope with a linear segment between each
+// pair of breakpoints
+func EnvelopeFromBreakpoints(sr int, bs []breakpoint.Breakpoint) (*Envelope, error) {
+	if len(bs) == 0 {
+		return nil, errors.New("Need at least one breakpoint to create an envelope")
+	}
+	segments := make([]Segment, 0, len(bs)-1)
+	for i := 1; i < len(bs); i++ {
+		duration := bs[i].Time - bs[i-1].Time
+		if duration < 0 {
+			return nil, errors.New("Breakpoint times should be increasing")
+		}
+		segments = append(segments, Segment{Duration: duration, Level: bs[i].Value})
+	}
+	e := NewEnvelope(sr, bs[0].Value, segments)
+	if bs[0].Time > 0 {
+		// hold the first value until the first breakpoint is reached
+		e.Segments = append([]Segment{{Duration: bs[0].Time, Level: bs[0].Value}}, e.Segments...)
+	}
+	return e, nil
+}
+
+// curve shapes a linear fraction in the range [0;1], c = 0 leaves it linear
+func curve(x, c float64) float64 {
+	if c == 0 {
+		return x
+	}
+	return (math.Exp(c*x) - 1) / (math.Exp(c) - 1)
+}
@@ -0,0 +1,125 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	// at 10 samples per second each tick advances the envelope by 0.1 seconds
+	dahdsrTests = []struct {
+		tick  int
+		value float64
+	}{
+		{0, 0},   // delay
+		{9, 0},   // still delayed
+		{15, .5}, // halfway through the attack
+		{20, 1},  // hold
+		{35, .75},
+		{45, .5}, // sustain
+		{80, .5}, // still sustained
+	}
+)
+
+func TestDAHDSR(t *testing.T) {
+	env := synth.NewDAHDSR(10, 1, 1, 1, 1, .5, 1)
+	env.NoteOn()
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = env.Tick()
+	}
+	for _, test := range dahdsrTests {
+		t.Run("", func(t *testing.T) {
+			if !floatFuzzyEquals(values[test.tick], test.value) {
+				t.Fatalf("Expected %v at tick %v but got %v", test.value, test.tick, values[test.tick])
+			}
+		})
+	}
+
+	env.NoteOff()
+	for i := 0; i < 5; i++ {
+		env.Tick()
+	}
+	if !floatFuzzyEquals(env.Value(), .25) {
+		t.Fatalf("Expected to be halfway through the release, got %v", env.Value())
+	}
+	for i := 0; i < 10; i++ {
+		env.Tick()
+	}
+	if !env.Done() || env.Value() != 0 {
+		t.Fatalf("Expected envelope to be done at 0, got %v", env.Value())
+	}
+}
+
+func TestEnvelopeLoop(t *testing.T) {
+	env := synth.NewEnvelope(10, 0, []synth.Segment{
+		{Duration: 1, Level: 1},
+		{Duration: 1, Level: 0},
+		{Duration: 1, Level: -1},
+	})
+	if err := env.SetRelease(2); err != nil {
+		t.Fatalf("Should be able to set release: %v", err)
+	}
+	if err := env.SetLoop(0, 1); err != nil {
+		t.Fatalf("Should be able to set loop: %v", err)
+	}
+	if err := env.SetLoop(1, 2); err == nil {
+		t.Fatal("Loop should not be allowed to contain the release point")
+	}
+
+	env.NoteOn()
+	for i := 0; i < 25; i++ {
+		env.Tick()
+	}
+	// third pass through the attack segment
+	if !floatFuzzyEquals(env.Tick(), .5) {
+		t.Fatalf("Expected loop to restart the attack, got %v", env.Value())
+	}
+	env.NoteOff()
+	for i := 0; i < 15; i++ {
+		env.Tick()
+	}
+	if !env.Done() || env.Value() != -1 {
+		t.Fatalf("Expected release to end at -1, got %v", env.Value())
+	}
+}
+
+func TestEnvelopeBreakpoints(t *testing.T) {
+	env := synth.NewDAHDSR(10, 0, 1, 0, 1, .5, 2)
+	bs := env.ToBreakpoints(3)
+	expected := []breakpoint.Breakpoint{
+		{Time: 0, Value: 0},
+		{Time: 0, Value: 0},
+		{Time: 1, Value: 1},
+		{Time: 1, Value: 1},
+		{Time: 2, Value: .5},
+		{Time: 5, Value: .5},
+		{Time: 7, Value: 0},
+	}
+	if len(bs) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, bs)
+	}
+	for i := range bs {
+		if bs[i] != expected[i] {
+			t.Fatalf("Expected %v but got %v", expected, bs)
+		}
+	}
+
+	back, err := synth.EnvelopeFromBreakpoints(10, bs)
+	if err != nil {
+		t.Fatalf("Should be able to create envelope from breakpoints: %v", err)
+	}
+	back.NoteOn()
+	for i := 0; i < 40; i++ {
+		back.Tick()
+	}
+	if !floatFuzzyEquals(back.Value(), .5) {
+		t.Fatalf("Expected .5 during the sustain, got %v", back.Value())
+	}
+
+	if _, err := synth.EnvelopeFromBreakpoints(10, nil); err == nil {
+		t.Fatal("Expected an error for empty breakpoints")
+	}
+}
@@ -0,0 +1,72 @@
+package synthesizer
+
+import "errors"
+
+// Euclid returns a rhythm of pulses spread as evenly as possible over the steps, using
+// Bjorklund's algorithm. The rhythm is rotated to the left by rotation steps.
+// For example Euclid(8, 3, 0) gives the tresillo x..x..x.
+func Euclid(steps, pulses, rotation int) ([]bool, error) {
+	if steps < 1 {
+		return nil, errors.New("A rhythm needs at least one step")
+	}
+	if pulses < 0 || pulses > steps {
+		return nil, errors.New("Pulses should be in the range [0;steps]")
+	}
+
+	// repeatedly pair the groups of pulses with the remaining groups until at most one
+	// remainder is left
+	a := make([][]bool, pulses)
+	for i := range a {
+		a[i] = []bool{true}
+	}
+	b := make([][]bool, steps-pulses)
+	for i := range b {
+		b[i] = []bool{false}
+	}
+	for len(b) > 1 && len(a) > 0 {
+		n := len(a)
+		if len(b) < n {
+			n = len(b)
+		}
+		merged := make([][]bool, n)
+		for i := range merged {
+			merged[i] = append(append([]bool{}, a[i]...), b[i]...)
+		}
+		if len(a) > n {
+			b = a[n:]
+		} else {
+			b = b[n:]
+		}
+		a = merged
+	}
+
+	rhythm := make([]bool, 0, steps)
+	for _, g := range append(a, b...) {
+		rhythm = append(rhythm, g...)
+	}
+	rotated := make([]bool, steps)
+	for i := range rotated {
+		rotated[i] = rhythm[((i+rotation)%steps+steps)%steps]
+	}
+	return rotated, nil
+}
+
+// EuclidPattern creates a sequencer pattern playing the note on the pulses of a Euclidean rhythm
+func EuclidPattern(steps, pulses, rotation, note int, velocity float64, d NoteDivision) (Pattern, error) {
+	rhythm, err := Euclid(steps, pulses, rotation)
+	if err != nil {
+		return Pattern{}, err
+	}
+	p := Pattern{
+		Steps:    make([]Step, steps),
+		Division: d,
+	}
+	for i, pulse := range rhythm {
+		if pulse {
+			p.Steps[i] = NewStep(note, velocity)
+		} else {
+			p.Steps[i] = RestStep()
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,65 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestEuclid(t *testing.T) {
+	tests := []struct {
+		steps, pulses, rotation int
+		expected                string
+	}{
+		{8, 3, 0, "x..x..x."},
+		{8, 5, 0, "x.xx.xx."},
+		{16, 4, 0, "x...x...x...x..."},
+		{13, 5, 0, "x..x.x..x.x.."},
+		{12, 7, 0, "x.xx.x.xx.x."},
+		{8, 3, 1, "..x..x.x"},
+		{8, 3, -1, ".x..x..x"},
+		{4, 0, 0, "...."},
+		{4, 4, 0, "xxxx"},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			rhythm, err := synth.Euclid(test.steps, test.pulses, test.rotation)
+			if err != nil {
+				t.Fatalf("Should be able to create rhythm: %v", err)
+			}
+			got := ""
+			for _, p := range rhythm {
+				if p {
+					got += "x"
+				} else {
+					got += "."
+				}
+			}
+			if got != test.expected {
+				t.Fatalf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+
+	if _, err := synth.Euclid(4, 5, 0); err == nil {
+		t.Fatal("Expected an error for more pulses than steps")
+	}
+}
+
+func TestEuclidPattern(t *testing.T) {
+	kick, err := synth.EuclidPattern(8, 3, 0, 36, 1, synth.EIGHTH)
+	if err != nil {
+		t.Fatalf("Should be able to create pattern: %v", err)
+	}
+	hats, _ := synth.EuclidPattern(8, 5, 0, 42, .5, synth.EIGHTH)
+
+	// layer both rhythms on a drum kit
+	events := append(synth.NewSequencer(120, 1, kick).Events(1), synth.NewSequencer(120, 1, hats).Events(1)...)
+	if len(events) != 8 {
+		t.Fatalf("Expected 8 notes, got %v", len(events))
+	}
+	frames := synth.RenderEvents(events, synth.NewDrumKit(8000, 1), 8000, 2.5)
+	if peak(frames) == 0 {
+		t.Fatal("Expected the kit to play the rhythms")
+	}
+}
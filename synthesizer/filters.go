@@ -1,7 +1,10 @@
 package synthesizer
 
 import (
+	"fmt"
 	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
 )
 
 // Lowpass applies a low-pass filter to the frames
@@ -65,3 +68,94 @@ func Balance(signal, comparator, delay []float64, frequency, samplerate float64)
 	}
 	return c
 }
+
+// FilterMode selects the response of a state-variable filter
+type FilterMode int
+
+// Responses supported by the state-variable filter
+const (
+	LOWPASS FilterMode = iota
+	BANDPASS
+	HIGHPASS
+	NOTCH
+)
+
+// SVF is a resonant state-variable filter which keeps its state between samples, so the
+// cutoff and resonance can be changed while filtering.
+type SVF struct {
+	Mode      FilterMode
+	Cutoff    float64 // in Hz
+	Resonance float64 // in the range [0;1], 1 is at the edge of self-oscillation
+
+	sr         float64
+	g, k       float64
+	a1, a2, a3 float64
+	ic1, ic2   float64 // integrator states
+}
+
+// NewSVF creates a state-variable filter
+func NewSVF(sr int, mode FilterMode, cutoff, resonance float64) *SVF {
+	f := &SVF{
+		Mode: mode,
+		sr:   float64(sr),
+	}
+	f.Set(cutoff, resonance)
+	return f
+}
+
+// Set changes the cutoff frequency and resonance of the filter
+func (f *SVF) Set(cutoff, resonance float64) {
+	f.Cutoff = math.Max(1, math.Min(cutoff, f.sr*0.49))
+	f.Resonance = math.Max(0, math.Min(resonance, 1))
+
+	f.g = math.Tan(math.Pi * f.Cutoff / f.sr)
+	f.k = 2 - 1.98*f.Resonance
+	f.a1 = 1 / (1 + f.g*(f.g+f.k))
+	f.a2 = f.g * f.a1
+	f.a3 = f.g * f.a2
+}
+
+// SetParam sets the "cutoff" or "resonance" of the filter so they can be modulated
+func (f *SVF) SetParam(name string, value float64) error {
+	switch name {
+	case "cutoff":
+		f.Set(value, f.Resonance)
+	case "resonance":
+		f.Set(f.Cutoff, value)
+	default:
+		return fmt.Errorf("Filter has no parameter %v", name)
+	}
+	return nil
+}
+
+// Reset clears the state of the filter
+func (f *SVF) Reset() {
+	f.ic1, f.ic2 = 0, 0
+}
+
+// Tick filters a single sample
+func (f *SVF) Tick(x float64) float64 {
+	v3 := x - f.ic2
+	v1 := f.a1*f.ic1 + f.a2*v3
+	v2 := f.ic2 + f.a2*f.ic1 + f.a3*v3
+	f.ic1 = 2*v1 - f.ic1
+	f.ic2 = 2*v2 - f.ic2
+
+	switch f.Mode {
+	case BANDPASS:
+		return v1
+	case HIGHPASS:
+		return x - f.k*v1 - v2
+	case NOTCH:
+		return x - f.k*v1
+	default:
+		return v2
+	}
+}
+
+// Process filters the frames in place
+func (f *SVF) Process(frames []wave.Frame) {
+	for i, x := range frames {
+		frames[i] = wave.Frame(f.Tick(float64(x)))
+	}
+}
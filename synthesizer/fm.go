@@ -0,0 +1,209 @@
+package synthesizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Operator is a single sine oscillator in an FM patch
+type Operator struct {
+	Ratio    float64   // frequency as a multiple of the note frequency
+	Fixed    float64   // fixed frequency in Hz, used instead of the ratio when set
+	Detune   float64   // in cents
+	Level    float64   // output amplitude, for modulators this is the modulation index in radians
+	Envelope *Envelope // amplitude envelope, the operator is not enveloped when nil
+}
+
+// Algorithm describes how the operators of an FM patch are routed.
+// Mod[i][j] is the amount by which operator j modulates operator i, a modulator with an
+// index lower than or equal to the carrier (such as Mod[i][i]) is a feedback path.
+// Output[i] is the amount of operator i that is mixed into the output.
+type Algorithm struct {
+	Mod    [][]float64
+	Output []float64
+}
+
+// NewAlgorithm creates an algorithm for n operators without any routing
+func NewAlgorithm(n int) Algorithm {
+	mod := make([][]float64, n)
+	for i := range mod {
+		mod[i] = make([]float64, n)
+	}
+	return Algorithm{
+		Mod:    mod,
+		Output: make([]float64, n),
+	}
+}
+
+// dx7Routing lists the modulator -> carrier connections, carriers and feedback operator
+// of the DX7 algorithms, operators are numbered 1-6 as on the synthesizer.
+var dx7Routing = map[int]struct {
+	connections [][2]int
+	carriers    []int
+	feedback    int
+}{
+	1:  {[][2]int{{6, 5}, {5, 4}, {4, 3}, {2, 1}}, []int{1, 3}, 6},
+	2:  {[][2]int{{6, 5}, {5, 4}, {4, 3}, {2, 1}}, []int{1, 3}, 2},
+	5:  {[][2]int{{6, 5}, {4, 3}, {2, 1}}, []int{1, 3, 5}, 6},
+	31: {[][2]int{{6, 5}}, []int{1, 2, 3, 4, 5}, 6},
+	32: {nil, []int{1, 2, 3, 4, 5, 6}, 6},
+}
+
+// DX7Algorithm returns the routing of one of the six-operator DX7 algorithms, with the given
+// amount of feedback in radians. Operator 1 of the DX7 is operator 0 of the algorithm.
+// Currently algorithms 1, 2, 5, 31 and 32 are supported.
+func DX7Algorithm(n int, feedback float64) (Algorithm, error) {
+	routing, ok := dx7Routing[n]
+	if !ok {
+		return Algorithm{}, fmt.Errorf("DX7 algorithm %v not supported", n)
+	}
+	alg := NewAlgorithm(6)
+	for _, c := range routing.connections {
+		alg.Mod[c[1]-1][c[0]-1] = 1
+	}
+	for _, c := range routing.carriers {
+		alg.Output[c-1] = 1 / float64(len(routing.carriers))
+	}
+	fb := routing.feedback - 1
+	alg.Mod[fb][fb] = feedback
+	return alg, nil
+}
+
+// FMSynth renders notes using phase modulation between a set of operators
+type FMSynth struct {
+	Operators []Operator
+	Algorithm Algorithm
+
+	sr       float64
+	freq     float64
+	velocity float64
+	phases   []float64 // in cycles
+	outs     []float64 // output of each operator for the current sample
+	prevs    []float64 // output of each operator one sample ago, for feedback smoothing
+}
+
+// NewFMSynth creates an FM synthesizer from operators and the algorithm connecting them
+func NewFMSynth(sr int, ops []Operator, alg Algorithm) (*FMSynth, error) {
+	n := len(ops)
+	if n == 0 {
+		return nil, errors.New("An FM synth needs at least one operator")
+	}
+	if len(alg.Mod) != n || len(alg.Output) != n {
+		return nil, errors.New("Algorithm does not match the amount of operators")
+	}
+	for _, row := range alg.Mod {
+		if len(row) != n {
+			return nil, errors.New("Algorithm does not match the amount of operators")
+		}
+	}
+	return &FMSynth{
+		Operators: ops,
+		Algorithm: alg,
+		sr:        float64(sr),
+		phases:    make([]float64, n),
+		outs:      make([]float64, n),
+		prevs:     make([]float64, n),
+	}, nil
+}
+
+// NoteOn starts a note at the given frequency and velocity in the range [0;1]
+func (f *FMSynth) NoteOn(freq, velocity float64) {
+	f.freq = freq
+	f.velocity = velocity
+	for i, op := range f.Operators {
+		f.phases[i] = 0
+		f.outs[i] = 0
+		f.prevs[i] = 0
+		if op.Envelope != nil {
+			op.Envelope.NoteOn()
+		}
+	}
+}
+
+// NoteOff releases the envelopes of all operators
+func (f *FMSynth) NoteOff() {
+	for _, op := range f.Operators {
+		if op.Envelope != nil {
+			op.Envelope.NoteOff()
+		}
+	}
+}
+
+// Done returns true once the envelopes of all carriers have finished.
+// Carriers without an envelope never finish.
+func (f *FMSynth) Done() bool {
+	for i, op := range f.Operators {
+		if f.Algorithm.Output[i] == 0 {
+			continue
+		}
+		if op.Envelope == nil || !op.Envelope.Done() {
+			return false
+		}
+	}
+	return true
+}
+
+// Active returns true as long as the synth is producing sound
+func (f *FMSynth) Active() bool {
+	return !f.Done()
+}
+
+// Tick returns the next sample of the current note
+func (f *FMSynth) Tick() float64 {
+	n := len(f.Operators)
+	// higher operators are calculated first (as on the DX7), anything modulating from an
+	// operator that has not been calculated yet for this sample is a feedback path.
+	for i := n - 1; i >= 0; i-- {
+		op := f.Operators[i]
+		mod := 0.0
+		for j, amount := range f.Algorithm.Mod[i] {
+			if amount == 0 {
+				continue
+			}
+			if j > i {
+				mod += amount * f.outs[j]
+			} else {
+				// average the last two samples to tame feedback
+				mod += amount * (f.outs[j] + f.prevs[j]) / 2
+			}
+		}
+
+		amp := op.Level
+		if op.Envelope != nil {
+			amp *= op.Envelope.Tick()
+		}
+		f.prevs[i] = f.outs[i]
+		f.outs[i] = amp * math.Sin(tau*f.phases[i]+mod)
+
+		freq := op.Fixed
+		if freq == 0 {
+			freq = f.freq * op.Ratio
+		}
+		freq *= math.Pow(2, op.Detune/1200)
+		f.phases[i] += freq / f.sr
+		f.phases[i] -= math.Floor(f.phases[i])
+	}
+
+	out := 0.0
+	for i, amount := range f.Algorithm.Output {
+		out += amount * f.outs[i]
+	}
+	return out * f.velocity
+}
+
+// Render plays a note held for gate seconds and returns duration seconds of audio
+func (f *FMSynth) Render(freq, velocity, gate, duration float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*f.sr))
+	gateframes := int(gate * f.sr)
+	f.NoteOn(freq, velocity)
+	for i := range frames {
+		if i == gateframes {
+			f.NoteOff()
+		}
+		frames[i] = wave.Frame(f.Tick())
+	}
+	return frames
+}
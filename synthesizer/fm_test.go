@@ -0,0 +1,97 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	dx7AlgorithmTests = []struct {
+		algorithm int
+		carriers  int
+		valid     bool
+	}{
+		{1, 2, true},
+		{5, 3, true},
+		{32, 6, true},
+		{33, 0, false},
+	}
+)
+
+// TestFMCarrier ensures an unmodulated carrier produces a plain sine wave
+func TestFMCarrier(t *testing.T) {
+	alg := synth.NewAlgorithm(2)
+	alg.Output[0] = 1
+	fm, err := synth.NewFMSynth(8, []synth.Operator{
+		{Ratio: 1, Level: 1},
+		{Ratio: 2, Level: 1},
+	}, alg)
+	if err != nil {
+		t.Fatalf("Should be able to create FM synth: %v", err)
+	}
+	out := fm.Render(1, 1, 1, 1)
+	for i, v := range out {
+		expected := math.Sin(2 * math.Pi * float64(i) / 8)
+		if !floatFuzzyEquals(float64(v), expected) {
+			t.Fatalf("Expected %v at %v but got %v", expected, i, v)
+		}
+	}
+
+	// routing the second operator into the first changes the waveform
+	alg.Mod[0][1] = 2
+	out = fm.Render(1, 1, 1, 1)
+	if floatFuzzyEquals(float64(out[1]), math.Sin(2*math.Pi/8)) {
+		t.Fatal("Expected the carrier to be modulated")
+	}
+}
+
+func TestFMEnvelopes(t *testing.T) {
+	alg, err := synth.DX7Algorithm(32, 0)
+	if err != nil {
+		t.Fatalf("Should be able to create algorithm: %v", err)
+	}
+	ops := make([]synth.Operator, 6)
+	for i := range ops {
+		ops[i] = synth.Operator{
+			Ratio:    float64(i + 1),
+			Level:    1,
+			Envelope: synth.NewDAHDSR(100, 0, .1, 0, .1, .5, .1),
+		}
+	}
+	fm, err := synth.NewFMSynth(100, ops, alg)
+	if err != nil {
+		t.Fatalf("Should be able to create FM synth: %v", err)
+	}
+	fm.Render(110, 1, .5, 1)
+	if !fm.Done() {
+		t.Fatal("Expected all carriers to have finished after their release")
+	}
+}
+
+func TestDX7Algorithm(t *testing.T) {
+	for _, test := range dx7AlgorithmTests {
+		t.Run("", func(t *testing.T) {
+			alg, err := synth.DX7Algorithm(test.algorithm, 1)
+			if (err == nil) != test.valid {
+				t.Fatalf("Expected valid: %v, got error %v", test.valid, err)
+			}
+			carriers := 0
+			for _, o := range alg.Output {
+				if o != 0 {
+					carriers++
+				}
+			}
+			if carriers != test.carriers {
+				t.Fatalf("Expected %v carriers but got %v", test.carriers, carriers)
+			}
+		})
+	}
+}
+
+func TestFMInvalid(t *testing.T) {
+	if _, err := synth.NewFMSynth(44100, []synth.Operator{{}}, synth.NewAlgorithm(2)); err == nil {
+		t.Fatal("Expected an error for mismatched algorithm")
+	}
+}
@@ -0,0 +1,175 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Vowel selects a set of formants
+type Vowel int
+
+// Vowels with formant presets, the order is used when morphing through them
+const (
+	VOWEL_A Vowel = iota
+	VOWEL_E
+	VOWEL_I
+	VOWEL_O
+	VOWEL_U
+)
+
+// Formant is a resonance of the vocal tract
+type Formant struct {
+	Freq      float64 // center frequency in Hz
+	Bandwidth float64 // in Hz
+	Gain      float64 // linear gain at the center frequency
+}
+
+// vowelFormants lists the formant frequencies, gains in dB and bandwidths of a tenor voice
+var vowelFormants = map[Vowel][3][5]float64{
+	VOWEL_A: {{650, 1080, 2650, 2900, 3250}, {0, -6, -7, -8, -22}, {80, 90, 120, 130, 140}},
+	VOWEL_E: {{400, 1700, 2600, 3200, 3580}, {0, -14, -12, -14, -20}, {70, 80, 100, 120, 120}},
+	VOWEL_I: {{290, 1870, 2800, 3250, 3540}, {0, -15, -18, -20, -30}, {40, 90, 100, 120, 120}},
+	VOWEL_O: {{400, 800, 2600, 2800, 3000}, {0, -10, -12, -12, -26}, {40, 80, 100, 120, 120}},
+	VOWEL_U: {{350, 600, 2700, 2900, 3300}, {0, -20, -17, -14, -26}, {40, 60, 100, 120, 120}},
+}
+
+// VowelFormants returns the formants of a vowel
+func VowelFormants(v Vowel) ([]Formant, error) {
+	preset, ok := vowelFormants[v]
+	if !ok {
+		return nil, fmt.Errorf("Vowel %v not supported", v)
+	}
+	formants := make([]Formant, len(preset[0]))
+	for i := range formants {
+		formants[i] = Formant{
+			Freq:      preset[0][i],
+			Gain:      math.Pow(10, preset[1][i]/20),
+			Bandwidth: preset[2][i],
+		}
+	}
+	return formants, nil
+}
+
+// FormantFilter is a bank of parallel bandpass filters, one for every formant.
+// It can shape the timbre of an oscillator or be used as an effect.
+type FormantFilter struct {
+	Formants []Formant
+
+	sr      float64
+	filters []*SVF
+}
+
+// NewFormantFilter creates a filter bank set to a vowel
+func NewFormantFilter(sr int, v Vowel) (*FormantFilter, error) {
+	f := &FormantFilter{sr: float64(sr)}
+	if err := f.SetVowel(v); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetFormants changes the formants, keeping the state of the filters when the amount is the same
+func (f *FormantFilter) SetFormants(formants []Formant) {
+	f.Formants = formants
+	if len(f.filters) != len(formants) {
+		f.filters = make([]*SVF, len(formants))
+		for i := range f.filters {
+			f.filters[i] = NewSVF(int(f.sr), BANDPASS, 1000, 0)
+		}
+	}
+	for i, fm := range formants {
+		// the resonance follows from Q = freq / bandwidth = 1 / k
+		res := (2 - fm.Bandwidth/fm.Freq) / 1.98
+		f.filters[i].Set(fm.Freq, res)
+	}
+}
+
+// SetVowel sets the formants to those of a vowel
+func (f *FormantFilter) SetVowel(v Vowel) error {
+	return f.Morph(v, v, 0)
+}
+
+// Morph sets the formants between two vowels, x in the range [0;1] moves from one to the other
+func (f *FormantFilter) Morph(from, to Vowel, x float64) error {
+	a, err := VowelFormants(from)
+	if err != nil {
+		return err
+	}
+	b, err := VowelFormants(to)
+	if err != nil {
+		return err
+	}
+	x = math.Max(0, math.Min(x, 1))
+	formants := make([]Formant, len(a))
+	for i := range formants {
+		// frequencies and bandwidths are interpolated logarithmically, as we hear them
+		formants[i] = Formant{
+			Freq:      a[i].Freq * math.Pow(b[i].Freq/a[i].Freq, x),
+			Bandwidth: a[i].Bandwidth * math.Pow(b[i].Bandwidth/a[i].Bandwidth, x),
+			Gain:      a[i].Gain + (b[i].Gain-a[i].Gain)*x,
+		}
+	}
+	f.SetFormants(formants)
+	return nil
+}
+
+// SetParam sets the "vowel" parameter, a position in the range [0;4] morphing through
+// the vowels a, e, i, o and u
+func (f *FormantFilter) SetParam(name string, value float64) error {
+	if name != "vowel" {
+		return fmt.Errorf("Formant filter has no parameter %v", name)
+	}
+	value = math.Max(0, math.Min(value, float64(VOWEL_U)))
+	from := Vowel(math.Floor(value))
+	if from == VOWEL_U {
+		return f.SetVowel(VOWEL_U)
+	}
+	return f.Morph(from, from+1, value-float64(from))
+}
+
+// Tick filters a single sample
+func (f *FormantFilter) Tick(x float64) float64 {
+	out := 0.0
+	for i, filter := range f.filters {
+		// scale by k so every band has the gain of its formant at the center
+		out += filter.Tick(x) * filter.k * f.Formants[i].Gain
+	}
+	return out
+}
+
+// Process filters the frames in place
+func (f *FormantFilter) Process(frames []wave.Frame) {
+	for i, x := range frames {
+		frames[i] = wave.Frame(f.Tick(float64(x)))
+	}
+}
+
+// FormantOscillator runs a generator through a formant filter, giving it a vocal timbre.
+// It is a Generator itself so it can be used in a Voice.
+type FormantOscillator struct {
+	Source Generator
+	Filter *FormantFilter
+}
+
+// NewFormantOscillator creates a sawtooth singing a vowel
+func NewFormantOscillator(sr int, v Vowel) (*FormantOscillator, error) {
+	osc, err := NewOscillator(sr, UPWARD_SAWTOOTH)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := NewFormantFilter(sr, v)
+	if err != nil {
+		return nil, err
+	}
+	return &FormantOscillator{
+		Source: osc,
+		Filter: filter,
+	}, nil
+}
+
+// Tick returns the next sample at the given frequency
+func (f *FormantOscillator) Tick(freq float64) float64 {
+	return f.Filter.Tick(f.Source.Tick(freq))
+}
@@ -0,0 +1,66 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// gainAt measures the gain of a processor for a sine at the given frequency
+func gainAt(p synth.Processor, sr int, freq float64) float64 {
+	frames := make([]wave.Frame, sr/2)
+	for i := range frames {
+		frames[i] = wave.Frame(math.Sin(2 * math.Pi * freq * float64(i) / float64(sr)))
+	}
+	p.Process(frames)
+	return audiomath.Goertzel(frames[sr/4:], sr, freq)
+}
+
+func TestFormantFilter(t *testing.T) {
+	sr := 16000
+	tests := []struct {
+		vowel synth.Vowel
+		peak  float64 // first formant
+		dip   float64 // frequency between the first two formants
+	}{
+		{synth.VOWEL_A, 650, 850},
+		{synth.VOWEL_I, 290, 1000},
+		{synth.VOWEL_U, 350, 460},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := synth.NewFormantFilter(sr, test.vowel)
+			if err != nil {
+				t.Fatalf("Should be able to create filter: %v", err)
+			}
+			if g := gainAt(f, sr, test.peak); math.Abs(g-1) > .2 {
+				t.Fatalf("Expected unity gain at the first formant, got %v", g)
+			}
+			f, _ = synth.NewFormantFilter(sr, test.vowel)
+			if g := gainAt(f, sr, test.dip); g > .5 {
+				t.Fatalf("Expected a dip between the formants, got %v", g)
+			}
+		})
+	}
+
+	if _, err := synth.NewFormantFilter(sr, synth.Vowel(10)); err == nil {
+		t.Fatal("Expected an error for an unknown vowel")
+	}
+}
+
+func TestFormantMorph(t *testing.T) {
+	f, _ := synth.NewFormantFilter(16000, synth.VOWEL_A)
+	if err := f.SetParam("vowel", 1.5); err != nil {
+		t.Fatalf("Should be able to morph: %v", err)
+	}
+	// halfway between e (400Hz) and i (290Hz)
+	if expected := math.Sqrt(400 * 290); !floatFuzzyEquals(f.Formants[0].Freq, expected) {
+		t.Fatalf("Expected first formant at %v, got %v", expected, f.Formants[0].Freq)
+	}
+	if err := f.SetParam("vowel", 4); err != nil || f.Formants[0].Freq != 350 {
+		t.Fatalf("Expected the last vowel to be u, got %v (%v)", f.Formants[0].Freq, err)
+	}
+}
@@ -0,0 +1,73 @@
+package synthesizer
+
+import "math"
+
+// GlideMode decides how long a glide between two notes takes
+type GlideMode int
+
+// Glide modes
+const (
+	CONSTANT_TIME GlideMode = iota // every glide takes the same time
+	CONSTANT_RATE                  // glides take longer the further apart the notes are
+)
+
+// Glide slides a frequency towards a target, linearly in pitch (portamento)
+type Glide struct {
+	Mode GlideMode
+	Time float64 // duration of a glide in seconds, or seconds per octave for CONSTANT_RATE
+
+	sr      float64
+	current float64 // in octaves (log2 of the frequency)
+	target  float64
+	step    float64 // octaves per sample
+}
+
+// NewGlide creates a glide for the given mode and time
+func NewGlide(sr int, mode GlideMode, time float64) *Glide {
+	return &Glide{
+		Mode: mode,
+		Time: time,
+		sr:   float64(sr),
+	}
+}
+
+// Set jumps to a frequency without gliding
+func (g *Glide) Set(freq float64) {
+	g.current = math.Log2(freq)
+	g.target = g.current
+	g.step = 0
+}
+
+// Target starts gliding from the current frequency to the new one
+func (g *Glide) Target(freq float64) {
+	g.target = math.Log2(freq)
+	distance := math.Abs(g.target - g.current)
+
+	duration := g.Time
+	if g.Mode == CONSTANT_RATE {
+		duration *= distance
+	}
+	samples := duration * g.sr
+	if samples < 1 {
+		g.current = g.target
+		g.step = 0
+		return
+	}
+	g.step = distance / samples
+}
+
+// Gliding returns true while the target has not been reached
+func (g *Glide) Gliding() bool {
+	return g.current != g.target
+}
+
+// Tick returns the current frequency and moves it towards the target
+func (g *Glide) Tick() float64 {
+	freq := math.Exp2(g.current)
+	if g.current < g.target {
+		g.current = math.Min(g.current+g.step, g.target)
+	} else if g.current > g.target {
+		g.current = math.Max(g.current-g.step, g.target)
+	}
+	return freq
+}
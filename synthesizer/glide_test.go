@@ -0,0 +1,63 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	// gliding two octaves up at 10 samples per second
+	glideTests = []struct {
+		mode  synth.GlideMode
+		time  float64
+		ticks int
+		freq  float64
+	}{
+		{synth.CONSTANT_TIME, 1, 5, 200},
+		{synth.CONSTANT_TIME, 1, 10, 400},
+		{synth.CONSTANT_TIME, 2, 10, 200},
+		{synth.CONSTANT_RATE, .5, 5, 200},
+		{synth.CONSTANT_RATE, 1, 10, 200},
+		{synth.CONSTANT_TIME, 0, 0, 400},
+	}
+)
+
+func TestGlide(t *testing.T) {
+	for _, test := range glideTests {
+		t.Run("", func(t *testing.T) {
+			g := synth.NewGlide(10, test.mode, test.time)
+			g.Set(100)
+			g.Target(400)
+			for i := 0; i < test.ticks; i++ {
+				g.Tick()
+			}
+			if f := g.Tick(); !floatFuzzyEquals(f, test.freq) {
+				t.Fatalf("Expected %v after %v ticks, got %v", test.freq, test.ticks, f)
+			}
+		})
+	}
+}
+
+func TestVoiceLegato(t *testing.T) {
+	v, err := synth.NewVoice(100, synth.SINE)
+	if err != nil {
+		t.Fatalf("Should be able to create voice: %v", err)
+	}
+	v.Glide = synth.NewGlide(100, synth.CONSTANT_TIME, .1)
+	v.Legato = true
+
+	v.NoteOn(100, 1)
+	for i := 0; i < 50; i++ {
+		v.Tick()
+	}
+	level := v.Level()
+	v.NoteOn(200, 1)
+	v.Tick()
+	if !floatFuzzyEquals(v.Level(), level) {
+		t.Fatalf("Expected legato note not to retrigger the envelope, got %v and %v", level, v.Level())
+	}
+	if !v.Glide.Gliding() {
+		t.Fatal("Expected the voice to glide to the next note")
+	}
+}
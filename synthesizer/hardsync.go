@@ -0,0 +1,97 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// HardSync is a pair of oscillators where the master resets the phase of the slave at the
+// start of every cycle. Only the slave is heard, at the pitch of the master, and sweeping
+// the Ratio gives the classic sync sound. Discontinuities are smoothed with PolyBLEPs
+// to keep aliasing down.
+type HardSync struct {
+	Shape Shape   // shape of the slave
+	Ratio float64 // frequency of the slave as a multiple of the master
+
+	sr     float64
+	master float64 // phases as a fraction of a cycle
+	slave  float64
+	prev   float64 // previous sample, output with a delay of one sample to correct it
+	cur    float64
+}
+
+// syncEdges lists the discontinuities of each shape within a cycle, as phase and jump
+var syncEdges = map[Shape][][2]float64{
+	SINE:              nil,
+	TRIANGLE:          nil,
+	UPWARD_SAWTOOTH:   {{0, -2}},
+	DOWNWARD_SAWTOOTH: {{0, 2}},
+	SQUARE:            {{0, 2}, {.5, -2}},
+}
+
+// NewHardSync creates a synced oscillator pair
+func NewHardSync(sr int, shape Shape, ratio float64) (*HardSync, error) {
+	if _, ok := syncEdges[shape]; !ok {
+		return nil, fmt.Errorf("Shape type %v not supported", shape)
+	}
+	return &HardSync{
+		Shape: shape,
+		Ratio: ratio,
+		sr:    float64(sr),
+	}, nil
+}
+
+// value returns the naive value of the slave at a phase in the range [0;1)
+func (h *HardSync) value(phase float64) float64 {
+	return shapeCalcFunc[h.Shape](phase * tau)
+}
+
+// blep adds a band-limited step of size jump which happened d samples before the current one
+func (h *HardSync) blep(d, jump float64) {
+	h.cur -= jump / 2 * (1 - d) * (1 - d)
+	h.prev += jump / 2 * d * d
+}
+
+// edges corrects the discontinuities of the shape when the slave moves from one phase to
+// another (both unwrapped), end is the time in samples before the current one at which
+// the movement stops
+func (h *HardSync) edges(from, to, end, inc float64) {
+	for _, e := range syncEdges[h.Shape] {
+		for pos := math.Floor(from) + e[0]; pos <= to; pos++ {
+			if pos > from {
+				h.blep(end+(to-pos)/inc, e[1])
+			}
+		}
+	}
+}
+
+// Tick returns the next sample with the master running at the given frequency in Hz.
+// The output is delayed by one sample.
+func (h *HardSync) Tick(freq float64) float64 {
+	out := h.prev
+	h.prev = h.cur
+	h.cur = 0 // collects the corrections before the naive value is added
+
+	minc := freq / h.sr
+	sinc := minc * h.Ratio
+	h.master += minc
+	if h.master >= 1 && minc > 0 {
+		h.master -= math.Floor(h.master)
+		d := h.master / minc // samples since the reset
+		end := h.slave + sinc*(1-d)
+		h.edges(h.slave, end, d, sinc)
+
+		// the jump from where the slave was to the start of its cycle
+		before := h.value(end - math.Floor(end))
+		h.slave = sinc * d
+		h.edges(0, h.slave, 0, sinc)
+		h.blep(d, h.value(0)-before)
+	} else {
+		end := h.slave + sinc
+		h.edges(h.slave, end, 0, sinc)
+		h.slave = end
+	}
+	h.slave -= math.Floor(h.slave)
+	h.cur += h.value(h.slave)
+	return out
+}
@@ -0,0 +1,95 @@
+package synthesizer_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// inharmonicEnergy returns the fraction of the spectrum of the frames which is not close
+// to a harmonic of the frequency, for measuring aliasing
+func inharmonicEnergy(frames []wave.Frame, sr int, freq float64) float64 {
+	n := len(frames)
+	windowed := make([]wave.Frame, n)
+	for i, f := range frames {
+		windowed[i] = f * wave.Frame(.5-.5*math.Cos(2*math.Pi*float64(i)/float64(n)))
+	}
+	spectrum := audiomath.FFT(windowed)
+	total, inharmonic := 0.0, 0.0
+	for bin := 1; bin < n/2; bin++ {
+		f := float64(bin) * float64(sr) / float64(n)
+		p := math.Pow(cmplx.Abs(spectrum[bin]), 2)
+		total += p
+		h := f / freq
+		if math.Abs(h-math.Round(h))*freq > 20 {
+			inharmonic += p
+		}
+	}
+	return inharmonic / total
+}
+
+func TestHardSyncAliasing(t *testing.T) {
+	sr, freq, ratio := 44100, 1234.5, 2.7
+	n := 1 << 14
+
+	h, err := synth.NewHardSync(sr, synth.UPWARD_SAWTOOTH, ratio)
+	if err != nil {
+		t.Fatalf("Should be able to create oscillator: %v", err)
+	}
+	smooth := make([]wave.Frame, n)
+	for i := range smooth {
+		smooth[i] = wave.Frame(h.Tick(freq))
+	}
+
+	// naive sync without any correction
+	naive := make([]wave.Frame, n)
+	master, slave := 0.0, 0.0
+	for i := range naive {
+		naive[i] = wave.Frame(2*slave - 1)
+		master += freq / float64(sr)
+		slave += freq * ratio / float64(sr)
+		if master >= 1 {
+			master--
+			slave = master * ratio
+		}
+		slave -= math.Floor(slave)
+	}
+
+	a, b := inharmonicEnergy(smooth, sr, freq), inharmonicEnergy(naive, sr, freq)
+	if a > b/10 {
+		t.Fatalf("Expected much less aliasing than naive sync, got %v vs %v", a, b)
+	}
+}
+
+func TestHardSyncPitch(t *testing.T) {
+	sr := 8000
+	for _, shape := range []synth.Shape{synth.SINE, synth.SQUARE, synth.UPWARD_SAWTOOTH, synth.DOWNWARD_SAWTOOTH} {
+		t.Run("", func(t *testing.T) {
+			h, err := synth.NewHardSync(sr, shape, 1.6)
+			if err != nil {
+				t.Fatalf("Should be able to create oscillator: %v", err)
+			}
+			frames := make([]wave.Frame, sr/2)
+			for i := range frames {
+				frames[i] = wave.Frame(h.Tick(100))
+			}
+			// the output repeats at the frequency of the master
+			best, bestLag := 0.0, 0
+			for lag := 50; lag < 100; lag++ {
+				if c := autocorrelation(frames, lag); c > best {
+					best, bestLag = c, lag
+				}
+			}
+			if bestLag != 80 {
+				t.Fatalf("Expected a period of 80 samples, got %v", bestLag)
+			}
+			if peak(frames) > 1.5 {
+				t.Fatalf("Expected the corrections to stay small, got a peak of %v", peak(frames))
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package synthesizer
+
+import (
+	"iter"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Frames returns an endless iterator over the samples of a source, such as an LFO, an
+// envelope or a drum, which ticks the source once per sample. Break out of the loop to stop.
+func Frames(src ModSource) iter.Seq[wave.Frame] {
+	return func(yield func(wave.Frame) bool) {
+		for yield(wave.Frame(src.Tick())) {
+		}
+	}
+}
+
+// Blocks returns an endless iterator over blocks of size samples of a source. The slice is
+// reused by the next block, it has to be copied to be kept.
+func Blocks(src ModSource, size int) iter.Seq[[]wave.Frame] {
+	return func(yield func([]wave.Frame) bool) {
+		if size < 1 {
+			size = 1
+		}
+		block := wave.AcquireFrames(size)
+		defer wave.ReleaseFrames(block)
+		for {
+			for i := range block {
+				block[i] = wave.Frame(src.Tick())
+			}
+			if !yield(block) {
+				return
+			}
+		}
+	}
+}
+
+// Frames returns an endless iterator over the waveform of the oscillator at a frequency in Hz
+func (o *Oscillator) Frames(freq float64) iter.Seq[wave.Frame] {
+	return func(yield func(wave.Frame) bool) {
+		for yield(wave.Frame(o.Tick(freq))) {
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestIterators(t *testing.T) {
+	lfo, _ := synth.NewLFO(100, synth.SINE, 3)
+	reference, _ := synth.NewLFO(100, synth.SINE, 3)
+
+	got := []wave.Frame{}
+	for f := range synth.Frames(lfo) {
+		if len(got) == 10 {
+			break
+		}
+		got = append(got, f)
+	}
+	blocks := 0
+	for block := range synth.Blocks(lfo, 4) {
+		if len(block) != 4 {
+			t.Fatalf("Expected blocks of 4 samples, got %v", len(block))
+		}
+		got = append(got, block...)
+		if blocks++; blocks == 3 {
+			break
+		}
+	}
+	if len(got) != 22 {
+		t.Fatalf("Expected 22 samples, got %v", len(got))
+	}
+	for i, f := range got {
+		if i == 10 {
+			// the sample ticked before the break is skipped
+			reference.Tick()
+		}
+		if want := wave.Frame(reference.Tick()); f != want {
+			t.Fatalf("Expected %v at %v, got %v", want, i, f)
+		}
+	}
+
+	osc, _ := synth.NewOscillator(100, synth.SQUARE)
+	reference2, _ := synth.NewOscillator(100, synth.SQUARE)
+	i := 0
+	for f := range osc.Frames(10) {
+		if want := wave.Frame(reference2.Tick(10)); f != want {
+			t.Fatalf("Expected %v at %v, got %v", want, i, f)
+		}
+		if i++; i == 50 {
+			break
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Random shapes, these are only supported by the LFO
+const (
+	SAMPLE_AND_HOLD Shape = iota + TRIANGLE + 1
+	SMOOTH_RANDOM
+)
+
+// NoteDivision is a note length expressed in quarter notes (beats)
+type NoteDivision float64
+
+// Common note divisions for tempo-synced modulation
+const (
+	WHOLE         NoteDivision = 4
+	HALF          NoteDivision = 2
+	QUARTER       NoteDivision = 1
+	EIGHTH        NoteDivision = 0.5
+	SIXTEENTH     NoteDivision = 0.25
+	THIRTY_SECOND NoteDivision = 0.125
+)
+
+// Dotted returns the division lengthened by half
+func Dotted(d NoteDivision) NoteDivision {
+	return d * 1.5
+}
+
+// Triplet returns the division so that three of them fit in two of the original
+func Triplet(d NoteDivision) NoteDivision {
+	return d * 2 / 3
+}
+
+// Seconds returns the duration of the division at a tempo in beats per minute
+func (d NoteDivision) Seconds(bpm float64) float64 {
+	return float64(d) * 60 / bpm
+}
+
+// LFO is a low-frequency oscillator meant for modulating parameters.
+// The output is in the range [-1;1], or [0;1] when Unipolar is set.
+type LFO struct {
+	Shape    Shape
+	Rate     float64 // in Hz
+	Offset   float64 // phase offset as a fraction of a cycle
+	Unipolar bool
+
+	sr    float64
+	phase float64 // current phase as a fraction of a cycle
+	rnd   *rand.Rand
+	prev  float64 // random value at the start of the cycle
+	next  float64 // random value at the start of the next cycle
+}
+
+// NewLFO creates a free-running LFO of the given shape and rate in Hz
+func NewLFO(sr int, shape Shape, rate float64) (*LFO, error) {
+	if _, ok := shapeCalcFunc[shape]; !ok && shape != SAMPLE_AND_HOLD && shape != SMOOTH_RANDOM {
+		return nil, fmt.Errorf("Shape type %v not supported", shape)
+	}
+	l := &LFO{
+		Shape: shape,
+		Rate:  rate,
+		sr:    float64(sr),
+	}
+	l.Seed(1)
+	return l, nil
+}
+
+// NewSyncedLFO creates an LFO completing one cycle per note division at the given tempo
+func NewSyncedLFO(sr int, shape Shape, bpm float64, d NoteDivision) (*LFO, error) {
+	l, err := NewLFO(sr, shape, 0)
+	if err != nil {
+		return nil, err
+	}
+	l.Sync(bpm, d)
+	return l, nil
+}
+
+// Sync sets the rate so that one cycle lasts exactly one note division at the given tempo
+func (l *LFO) Sync(bpm float64, d NoteDivision) {
+	l.Rate = 1 / d.Seconds(bpm)
+}
+
+// Seed resets the random generator used by the random shapes, for reproducible renders
+func (l *LFO) Seed(seed int64) {
+	l.rnd = rand.New(rand.NewSource(seed))
+	l.prev = l.random()
+	l.next = l.random()
+}
+
+// Reset restarts the LFO at the start of its cycle (e.g for retriggering on a new note)
+func (l *LFO) Reset() {
+	l.phase = 0
+}
+
+// SetParam sets the "rate" or "offset" of the LFO so they can be modulated
+func (l *LFO) SetParam(name string, value float64) error {
+	switch name {
+	case "rate":
+		l.Rate = value
+	case "offset":
+		l.Offset = value
+	default:
+		return fmt.Errorf("LFO has no parameter %v", name)
+	}
+	return nil
+}
+
+// Tick returns the next value of the LFO
+func (l *LFO) Tick() float64 {
+	phase := l.phase + l.Offset
+	phase -= math.Floor(phase)
+
+	var val float64
+	switch l.Shape {
+	case SAMPLE_AND_HOLD:
+		val = l.prev
+	case SMOOTH_RANDOM:
+		// cosine interpolation between the random values
+		frac := (1 - math.Cos(math.Pi*l.phase)) / 2
+		val = l.prev + (l.next-l.prev)*frac
+	default:
+		val = shapeCalcFunc[l.Shape](phase * tau)
+	}
+
+	l.phase += l.Rate / l.sr
+	if l.phase >= 1 {
+		l.phase -= math.Floor(l.phase)
+		l.prev = l.next
+		l.next = l.random()
+	}
+
+	if l.Unipolar {
+		return (val + 1) / 2
+	}
+	return val
+}
+
+// random returns a new value in the range [-1;1]
+func (l *LFO) random() float64 {
+	return l.rnd.Float64()*2 - 1
+}
@@ -0,0 +1,77 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	// LFOs at a quarter of the sample rate, so each tick is a quarter cycle
+	lfoTests = []struct {
+		shape    synth.Shape
+		offset   float64
+		unipolar bool
+		out      []float64
+	}{
+		{synth.SINE, 0, false, []float64{0, 1, 0, -1}},
+		{synth.SINE, 0.25, false, []float64{1, 0, -1, 0}},
+		{synth.SINE, 0, true, []float64{.5, 1, .5, 0}},
+		{synth.UPWARD_SAWTOOTH, 0, false, []float64{-1, -.5, 0, .5}},
+		{synth.TRIANGLE, 0, false, []float64{1, 0, -1, 0}},
+	}
+)
+
+func TestLFOShapes(t *testing.T) {
+	for _, test := range lfoTests {
+		t.Run("", func(t *testing.T) {
+			lfo, err := synth.NewLFO(4, test.shape, 1)
+			if err != nil {
+				t.Fatalf("Should be able to create LFO: %v", err)
+			}
+			lfo.Offset = test.offset
+			lfo.Unipolar = test.unipolar
+			for i, expected := range test.out {
+				if v := lfo.Tick(); !floatFuzzyEquals(v, expected) {
+					t.Fatalf("Expected %v at tick %v but got %v", expected, i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestLFOSampleAndHold(t *testing.T) {
+	lfo, err := synth.NewLFO(8, synth.SAMPLE_AND_HOLD, 1)
+	if err != nil {
+		t.Fatalf("Should be able to create LFO: %v", err)
+	}
+	first := lfo.Tick()
+	for i := 1; i < 8; i++ {
+		if v := lfo.Tick(); v != first {
+			t.Fatalf("Expected value to be held for the entire cycle, got %v and %v", first, v)
+		}
+	}
+	if v := lfo.Tick(); v == first {
+		t.Fatal("Expected a new random value for the next cycle")
+	}
+
+	// the same seed should give the same values
+	other, _ := synth.NewLFO(8, synth.SAMPLE_AND_HOLD, 1)
+	if v := other.Tick(); v != first {
+		t.Fatalf("Expected seeded LFOs to be reproducible, got %v and %v", first, v)
+	}
+}
+
+func TestLFOSync(t *testing.T) {
+	lfo, err := synth.NewSyncedLFO(44100, synth.SINE, 120, synth.EIGHTH)
+	if err != nil {
+		t.Fatalf("Should be able to create LFO: %v", err)
+	}
+	// an eighth note at 120 bpm lasts a quarter of a second
+	if !floatFuzzyEquals(lfo.Rate, 4) {
+		t.Fatalf("Expected rate of 4Hz, got %v", lfo.Rate)
+	}
+	if d := synth.Triplet(synth.QUARTER).Seconds(60); !floatFuzzyEquals(d, 2./3) {
+		t.Fatalf("Expected triplet quarter to last 2/3 of a second, got %v", d)
+	}
+}
@@ -0,0 +1,151 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// ClickSound selects the sounds of a metronome
+type ClickSound int
+
+const (
+	BEEP      ClickSound = iota // sine bursts, the accent an octave higher
+	WOODBLOCK                   // short knocks with the inharmonic overtone of a wooden bar
+	HIHAT                       // a closed hihat, with an open one as the accent
+)
+
+// woodRatio is the frequency of the second mode of a wooden bar relative to the first
+const woodRatio = 2.76
+
+// Click is a short decaying tone, the sound of an electronic metronome
+type Click struct {
+	Freq     float64
+	Overtone float64 // level of an overtone at 2.76 times the frequency, for a wooden sound
+	Decay    float64 // time in seconds to fade by 60dB
+
+	sr    float64
+	phase float64
+	amp   float64
+}
+
+// NewClick creates a click at a frequency
+func NewClick(sr int, freq, decay float64) *Click {
+	return &Click{
+		Freq:  freq,
+		Decay: decay,
+		sr:    float64(sr),
+	}
+}
+
+// Trigger starts a new click
+func (c *Click) Trigger(velocity float64) {
+	c.amp = velocity
+	c.phase = 0
+}
+
+// Active returns true while the click is audible
+func (c *Click) Active() bool {
+	return c.amp > silence
+}
+
+// Tick returns the next sample of the click
+func (c *Click) Tick() float64 {
+	if !c.Active() {
+		return 0
+	}
+	out := math.Sin(tau*c.phase) + c.Overtone*math.Sin(tau*woodRatio*c.phase)
+	out *= c.amp / (1 + c.Overtone)
+	c.phase += c.Freq / c.sr
+	c.phase -= math.Floor(c.phase)
+	c.amp *= decay(c.Decay, c.sr)
+	return out
+}
+
+// Metronome clicks on every beat of a tempo map, with an accent on the first beat of every bar.
+// It is a Track, so it follows a Transport both live and when rendering.
+type Metronome struct {
+	Tempo    *breakpoint.TempoMap
+	Accent   Drum // played on the first beat of a bar
+	Beat     Drum // played on the other beats
+	Level    float64
+	Channels int
+
+	sr         int
+	position   int64
+	next       float64 // the next beat to click on
+	nextSample int64
+}
+
+// NewMetronome creates a metronome at the start of the tempo map
+func NewMetronome(sr, channels int, tempo *breakpoint.TempoMap, sound ClickSound) (*Metronome, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if tempo == nil {
+		return nil, errors.New("Need a tempo map")
+	}
+	m := &Metronome{
+		Tempo:    tempo,
+		Level:    .5,
+		Channels: channels,
+		sr:       sr,
+	}
+	if err := m.SetSound(sound); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetSound replaces the accent and beat with one of the built-in sounds
+func (m *Metronome) SetSound(sound ClickSound) error {
+	switch sound {
+	case BEEP:
+		m.Accent, m.Beat = NewClick(m.sr, 1760, .05), NewClick(m.sr, 880, .05)
+	case WOODBLOCK:
+		accent, beat := NewClick(m.sr, 1200, .03), NewClick(m.sr, 800, .03)
+		accent.Overtone, beat.Overtone = .5, .5
+		m.Accent, m.Beat = accent, beat
+	case HIHAT:
+		m.Accent, m.Beat = NewHiHat(m.sr, .3, 1), NewHiHat(m.sr, .05, 2)
+	default:
+		return errors.New("Unknown click sound")
+	}
+	return nil
+}
+
+// Seek moves the metronome to a time in seconds, the next click is on the first beat from there
+func (m *Metronome) Seek(time float64) {
+	m.position = int64(math.Round(time * float64(m.sr)))
+	m.next = math.Floor(m.Tempo.Beats(time))
+	m.nextSample = int64(m.Tempo.Sample(m.next, m.sr))
+	if m.nextSample < m.position {
+		m.next++
+		m.nextSample = int64(m.Tempo.Sample(m.next, m.sr))
+	}
+}
+
+// Process writes the clicks to every channel of the frames
+func (m *Metronome) Process(frames []wave.Frame) {
+	for i := 0; i+m.Channels <= len(frames); i += m.Channels {
+		if m.position == m.nextSample {
+			if _, inBar := m.Tempo.BarAt(m.next); inBar < 1e-9 {
+				m.Accent.Trigger(1)
+			} else {
+				m.Beat.Trigger(1)
+			}
+			m.next++
+			m.nextSample = int64(m.Tempo.Sample(m.next, m.sr))
+		}
+		v := m.Accent.Tick()
+		if m.Beat != m.Accent {
+			v += m.Beat.Tick()
+		}
+		for c := 0; c < m.Channels; c++ {
+			frames[i+c] = wave.Frame(m.Level * v)
+		}
+		m.position++
+	}
+}
@@ -0,0 +1,78 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// countingDrum logs the frames at which it is triggered
+type countingDrum struct {
+	frame    int
+	triggers []int
+}
+
+func (c *countingDrum) Trigger(velocity float64) { c.triggers = append(c.triggers, c.frame) }
+func (c *countingDrum) Active() bool             { return false }
+func (c *countingDrum) Tick() float64            { c.frame++; return 0 }
+
+func TestMetronome(t *testing.T) {
+	tempo, _ := breakpoint.NewTempoMap(120)
+	tempo.BeatsPerBar = 3
+	tr, _ := synth.NewTransport(100, 2, tempo)
+	m, err := synth.NewMetronome(100, 2, tempo, synth.WOODBLOCK)
+	if err != nil {
+		t.Fatalf("Should be able to create metronome: %v", err)
+	}
+	accent, beat := &countingDrum{}, &countingDrum{}
+	m.Accent, m.Beat = accent, beat
+	tr.Tracks = append(tr.Tracks, m)
+
+	// start halfway through the first beat, which lasts 50 samples
+	tr.SeekSample(30)
+	tr.Render(3.5, 64)
+	expectedAccents, expectedBeats := []int{120, 270}, []int{20, 70, 170, 220, 320}
+	if len(accent.triggers) != len(expectedAccents) || accent.triggers[1] != expectedAccents[1] {
+		t.Fatalf("Expected accents at %v, got %v", expectedAccents, accent.triggers)
+	}
+	if len(beat.triggers) != len(expectedBeats) {
+		t.Fatalf("Expected beats at %v, got %v", expectedBeats, beat.triggers)
+	}
+	for i, e := range expectedBeats {
+		if beat.triggers[i] != e {
+			t.Fatalf("Expected beats at %v, got %v", expectedBeats, beat.triggers)
+		}
+	}
+
+	if err := m.SetSound(synth.ClickSound(-1)); err == nil {
+		t.Fatal("Expected an error for an unknown sound")
+	}
+}
+
+func TestMetronomeSounds(t *testing.T) {
+	tempo, _ := breakpoint.NewTempoMap(60)
+	for _, sound := range []synth.ClickSound{synth.BEEP, synth.WOODBLOCK, synth.HIHAT} {
+		t.Run("", func(t *testing.T) {
+			m, err := synth.NewMetronome(44100, 1, tempo, sound)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr, _ := synth.NewTransport(44100, 1, tempo)
+			tr.Tracks = append(tr.Tracks, m)
+			frames := tr.Render(1, 512)
+			peak := 0.0
+			for _, f := range frames {
+				if float64(f) > peak {
+					peak = float64(f)
+				}
+			}
+			if peak < .1 || peak > 1 {
+				t.Fatalf("Expected an audible click, got a peak of %v", peak)
+			}
+			if frames[len(frames)-1] != 0 && frames[len(frames)/2] != 0 {
+				t.Fatal("Expected the click to fade before the next beat")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Material selects a set of modes for modal synthesis
+type Material int
+
+// Materials with mode presets
+const (
+	WOOD Material = iota
+	METAL
+	GLASS
+)
+
+// Mode is a resonance of a struck object
+type Mode struct {
+	Ratio float64 // frequency as a multiple of the note frequency
+	Decay float64 // time in seconds to fade by 60dB
+	Gain  float64
+}
+
+// materialModes lists the ratios, decays and gains of the modes of each material
+var materialModes = map[Material][3][]float64{
+	// a tuned marimba bar
+	WOOD: {{1, 3.99, 10.65}, {.6, .2, .06}, {1, .4, .2}},
+	// a church bell, with the hum note an octave below and the minor third above the strike note
+	METAL: {{.5, 1, 1.183, 1.506, 2, 2.514, 2.662, 3.011}, {6, 4, 3, 2.5, 2, 1.5, 1.2, 1}, {.5, 1, .6, .4, .5, .25, .2, .15}},
+	// a struck glass, with the inharmonic partials of a free bar
+	GLASS: {{1, 2.756, 5.404, 8.933}, {2.5, 1.5, .8, .5}, {1, .5, .3, .15}},
+}
+
+// MaterialModes returns the modes of a material
+func MaterialModes(m Material) ([]Mode, error) {
+	preset, ok := materialModes[m]
+	if !ok {
+		return nil, fmt.Errorf("Material %v not supported", m)
+	}
+	modes := make([]Mode, len(preset[0]))
+	for i := range modes {
+		modes[i] = Mode{
+			Ratio: preset[0][i],
+			Decay: preset[1][i],
+			Gain:  preset[2][i],
+		}
+	}
+	return modes, nil
+}
+
+// resonator is a two-pole filter ringing at a single mode
+type resonator struct {
+	b1, b2 float64
+	gain   float64
+	y1, y2 float64
+}
+
+// Modal synthesizes struck objects as a bank of decaying resonators excited by a short noise burst
+type Modal struct {
+	Modes    []Mode
+	Hardness float64 // in the range [0;1], a harder mallet gives a shorter and brighter strike
+
+	sr         float64
+	rnd        *rand.Rand
+	resonators []resonator
+	burst      []float64 // excitation still to be played
+	level      float64   // envelope of the slowest mode
+	fade       float64
+}
+
+// NewModal creates a modal synthesizer for a material, the seed makes the excitation reproducible
+func NewModal(sr int, m Material, seed int64) (*Modal, error) {
+	modes, err := MaterialModes(m)
+	if err != nil {
+		return nil, err
+	}
+	return &Modal{
+		Modes:    modes,
+		Hardness: .7,
+		sr:       float64(sr),
+		rnd:      rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// NoteOn strikes the object at the given frequency and velocity in the range [0;1]
+func (m *Modal) NoteOn(freq, velocity float64) {
+	total := 0.0
+	for _, mode := range m.Modes {
+		total += mode.Gain
+	}
+	m.resonators = m.resonators[:0]
+	m.fade = 0
+	for _, mode := range m.Modes {
+		f := freq * mode.Ratio
+		if f >= m.sr/2 || mode.Decay <= 0 {
+			continue
+		}
+		w := tau * f / m.sr
+		r := decay(mode.Decay, m.sr)
+		m.resonators = append(m.resonators, resonator{
+			b1: 2 * r * math.Cos(w),
+			b2: -r * r,
+			// scaled so an impulse rings at the gain of the mode
+			gain: math.Sin(w) * mode.Gain / total,
+		})
+		m.fade = math.Max(m.fade, r)
+	}
+
+	// a Hann windowed noise burst, normalized so the output can't exceed the velocity
+	n := int((.0003 + (1-m.Hardness)*.004) * m.sr)
+	if n < 1 {
+		n = 1
+	}
+	m.burst = make([]float64, n)
+	sum := 0.0
+	for i := range m.burst {
+		w := .5 - .5*math.Cos(tau*float64(i+1)/float64(n+1))
+		m.burst[i] = w * (m.rnd.Float64()*2 - 1)
+		sum += math.Abs(m.burst[i])
+	}
+	for i := range m.burst {
+		m.burst[i] *= velocity / sum
+	}
+	m.level = velocity
+}
+
+// NoteOff is a no-op, a struck object rings out by itself
+func (m *Modal) NoteOff() {}
+
+// Active returns true while the object is still ringing
+func (m *Modal) Active() bool {
+	return m.level > silence
+}
+
+// Tick returns the next sample
+func (m *Modal) Tick() float64 {
+	x := 0.0
+	if len(m.burst) > 0 {
+		x = m.burst[0]
+		m.burst = m.burst[1:]
+	}
+	out := 0.0
+	for i := range m.resonators {
+		r := &m.resonators[i]
+		y := r.gain*x + r.b1*r.y1 + r.b2*r.y2
+		r.y2, r.y1 = r.y1, y
+		out += y
+	}
+	m.level *= m.fade
+	return out
+}
+
+// Render strikes a note and returns duration seconds of audio
+func (m *Modal) Render(freq, velocity, duration float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*m.sr))
+	m.NoteOn(freq, velocity)
+	for i := range frames {
+		frames[i] = wave.Frame(m.Tick())
+	}
+	return frames
+}
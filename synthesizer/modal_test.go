@@ -0,0 +1,49 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestModal(t *testing.T) {
+	sr := 16000
+	for _, material := range []synth.Material{synth.WOOD, synth.METAL, synth.GLASS} {
+		t.Run("", func(t *testing.T) {
+			m, err := synth.NewModal(sr, material, 1)
+			if err != nil {
+				t.Fatalf("Should be able to create modal synth: %v", err)
+			}
+			frames := m.Render(440, 1, .5)
+			if p := peak(frames); p == 0 || p > 1 {
+				t.Fatalf("Expected a strike within range, got peak %v", p)
+			}
+			// every mode below Nyquist should be ringing
+			for _, mode := range m.Modes {
+				f := 440 * mode.Ratio
+				if f >= float64(sr)/2 {
+					continue
+				}
+				if a := audiomath.Goertzel(frames[:sr/20], sr, f); a < 1e-4 {
+					t.Fatalf("Expected a mode at %vHz, got amplitude %v", f, a)
+				}
+			}
+		})
+	}
+}
+
+func TestModalDecay(t *testing.T) {
+	m, _ := synth.NewModal(8000, synth.WOOD, 1)
+	frames := m.Render(220, 1, 1)
+	if m.Active() {
+		t.Fatal("Expected a wooden bar to have died out within a second")
+	}
+	if p := peak(frames[7000:]); p > .001 {
+		t.Fatalf("Expected silence at the end, got %v", p)
+	}
+
+	if _, err := synth.NewModal(8000, synth.Material(9), 1); err == nil {
+		t.Fatal("Expected an error for an unknown material")
+	}
+}
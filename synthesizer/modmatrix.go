@@ -0,0 +1,161 @@
+package synthesizer
+
+import (
+	"errors"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+)
+
+// ModSource produces a modulation value once per sample, LFOs and envelopes are sources
+type ModSource interface {
+	Tick() float64
+}
+
+// Parameterized is anything with named parameters that can be modulated
+type Parameterized interface {
+	SetParam(name string, value float64) error
+}
+
+// ModValue is a source holding a value set from outside, such as the velocity of a note
+type ModValue struct {
+	Value float64
+}
+
+// Tick returns the current value
+func (m *ModValue) Tick() float64 {
+	return m.Value
+}
+
+// BreakpointSource is a source following breakpoints over time
+type BreakpointSource struct {
+	Points []breakpoint.Breakpoint
+
+	sr    float64
+	time  float64
+	index int
+}
+
+// NewBreakpointSource creates a source from breakpoints with their time in seconds
+func NewBreakpointSource(sr int, points []breakpoint.Breakpoint) (*BreakpointSource, error) {
+	if len(points) == 0 {
+		return nil, errors.New("Need at least one breakpoint")
+	}
+	return &BreakpointSource{
+		Points: points,
+		sr:     float64(sr),
+	}, nil
+}
+
+// Reset restarts the source at time 0
+func (b *BreakpointSource) Reset() {
+	b.time, b.index = 0, 0
+}
+
+// Seek moves the source to a time in seconds
+func (b *BreakpointSource) Seek(time float64) {
+	b.time, b.index = time, 0
+}
+
+// Tick returns the value at the current time and advances it by one sample
+func (b *BreakpointSource) Tick() float64 {
+	var v float64
+	b.index, v = breakpoint.ValueAt(b.Points, b.time, b.index)
+	b.time += 1 / b.sr
+	return v
+}
+
+// ModRoute connects a source to a parameter of a destination
+type ModRoute struct {
+	Source ModSource
+	Target Parameterized
+	Param  string
+	Depth  float64 // the source is multiplied by the depth and added to the base value
+}
+
+// destination is a modulated parameter and its unmodulated value
+type destination struct {
+	target Parameterized
+	param  string
+	base   float64
+}
+
+// ModMatrix routes modulation sources to parameters.
+// Every tick, each parameter is set to its base value plus the sum of all routes to it.
+type ModMatrix struct {
+	Routes []ModRoute
+
+	destinations []destination
+	values       map[ModSource]float64
+}
+
+// NewModMatrix creates an empty modulation matrix
+func NewModMatrix() *ModMatrix {
+	return &ModMatrix{
+		values: map[ModSource]float64{},
+	}
+}
+
+// SetBase sets the unmodulated value of a parameter
+func (m *ModMatrix) SetBase(target Parameterized, param string, base float64) error {
+	if err := target.SetParam(param, base); err != nil {
+		return err
+	}
+	for i, d := range m.destinations {
+		if d.target == target && d.param == param {
+			m.destinations[i].base = base
+			return nil
+		}
+	}
+	m.destinations = append(m.destinations, destination{target, param, base})
+	return nil
+}
+
+// Connect adds a route from a source to a parameter, the base value of the parameter
+// should be set with SetBase and defaults to 0
+func (m *ModMatrix) Connect(source ModSource, target Parameterized, param string, depth float64) error {
+	if source == nil || target == nil {
+		return errors.New("A route needs a source and a target")
+	}
+	found := false
+	for _, d := range m.destinations {
+		if d.target == target && d.param == param {
+			found = true
+		}
+	}
+	if !found {
+		if err := m.SetBase(target, param, 0); err != nil {
+			return err
+		}
+	}
+	m.Routes = append(m.Routes, ModRoute{
+		Source: source,
+		Target: target,
+		Param:  param,
+		Depth:  depth,
+	})
+	return nil
+}
+
+// Tick advances every source by one sample and updates all modulated parameters.
+// Call it once before ticking the modulated voices and effects, sources which are
+// also ticked elsewhere (such as the LFO of a Voice) should not be routed.
+func (m *ModMatrix) Tick() {
+	// a source routed to several parameters only advances once
+	for s := range m.values {
+		delete(m.values, s)
+	}
+	for _, r := range m.Routes {
+		if _, ok := m.values[r.Source]; !ok {
+			m.values[r.Source] = r.Source.Tick()
+		}
+	}
+	for _, d := range m.destinations {
+		v := d.base
+		for _, r := range m.Routes {
+			if r.Target == d.target && r.Param == d.param {
+				v += r.Depth * m.values[r.Source]
+			}
+		}
+		d.target.SetParam(d.param, v)
+	}
+}
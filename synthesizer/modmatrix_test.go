@@ -0,0 +1,67 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// param records the values a parameter is set to
+type param struct {
+	values map[string]float64
+}
+
+func (p *param) SetParam(name string, value float64) error {
+	p.values[name] = value
+	return nil
+}
+
+func TestModMatrix(t *testing.T) {
+	m := synth.NewModMatrix()
+	target := &param{map[string]float64{}}
+	velocity := &synth.ModValue{Value: .5}
+	ramp, err := synth.NewBreakpointSource(4, []breakpoint.Breakpoint{{Time: 0, Value: 0}, {Time: 1, Value: 1}})
+	if err != nil {
+		t.Fatalf("Should be able to create source: %v", err)
+	}
+
+	if err := m.SetBase(target, "cutoff", 1000); err != nil {
+		t.Fatalf("Should be able to set base: %v", err)
+	}
+	m.Connect(velocity, target, "cutoff", 200)
+	m.Connect(ramp, target, "cutoff", 400)
+	m.Connect(ramp, target, "amp", 1)
+
+	expected := []struct{ cutoff, amp float64 }{{1100, 0}, {1200, .25}, {1300, .5}, {1400, .75}}
+	for _, e := range expected {
+		m.Tick()
+		if !floatFuzzyEquals(target.values["cutoff"], e.cutoff) {
+			t.Fatalf("Expected cutoff %v, got %v", e.cutoff, target.values["cutoff"])
+		}
+		// the ramp is routed twice but should only advance once per tick
+		if !floatFuzzyEquals(target.values["amp"], e.amp) {
+			t.Fatalf("Expected amp %v, got %v", e.amp, target.values["amp"])
+		}
+	}
+}
+
+func TestModMatrixVoice(t *testing.T) {
+	v, err := synth.NewVoice(8000, synth.SINE)
+	if err != nil {
+		t.Fatalf("Should be able to create voice: %v", err)
+	}
+	lfo, _ := synth.NewLFO(8000, synth.SQUARE, 1)
+	m := synth.NewModMatrix()
+	m.SetBase(v, "cutoff", 500)
+	if err := m.Connect(lfo, v, "cutoff", 100); err != nil {
+		t.Fatalf("Should be able to route to the voice: %v", err)
+	}
+	m.Tick()
+	if v.Cutoff != 600 && v.Cutoff != 400 {
+		t.Fatalf("Expected the cutoff to be modulated, got %v", v.Cutoff)
+	}
+	if err := m.Connect(lfo, v, "wobble", 1); err == nil {
+		t.Fatal("Expected an error for an unknown parameter")
+	}
+}
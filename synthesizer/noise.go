@@ -0,0 +1,87 @@
+package synthesizer
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// NoiseColor describes the spectral slope of a noise generator
+type NoiseColor int
+
+// Supported noise colors
+const (
+	WHITE NoiseColor = iota // flat spectrum
+	PINK                    // -3dB per octave
+	BROWN                   // -6dB per octave
+	BLUE                    // +3dB per octave
+)
+
+// Noise generates colored noise in (roughly) the range [-1;1] from a seeded random source
+type Noise struct {
+	Color NoiseColor
+
+	rnd   *rand.Rand
+	pink  [7]float64 // filter state of the pink noise
+	brown float64
+	last  float64 // previous pink sample, for blue noise
+}
+
+// NewNoise creates a noise generator, generators with the same seed produce the same noise
+func NewNoise(color NoiseColor, seed int64) (*Noise, error) {
+	if color < WHITE || color > BLUE {
+		return nil, fmt.Errorf("Noise color %v not supported", color)
+	}
+	return &Noise{
+		Color: color,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+// Tick returns the next noise sample. The frequency is ignored, it is only accepted so that
+// noise can be used as a Generator (e.g as one of the oscillators of a Voice).
+func (n *Noise) Tick(freq float64) float64 {
+	switch n.Color {
+	case PINK:
+		return n.nextPink()
+	case BROWN:
+		// leaky integration of white noise
+		n.brown = (n.brown + 0.02*n.white()) / 1.02
+		return n.brown * 3.5
+	case BLUE:
+		// differentiated pink noise
+		p := n.nextPink()
+		out := (p - n.last) * .5
+		n.last = p
+		return out
+	default:
+		return n.white()
+	}
+}
+
+// Process overwrites the frames with noise
+func (n *Noise) Process(frames []wave.Frame) {
+	for i := range frames {
+		frames[i] = wave.Frame(n.Tick(0))
+	}
+}
+
+func (n *Noise) white() float64 {
+	return n.rnd.Float64()*2 - 1
+}
+
+// nextPink filters white noise using Paul Kellet's refined method
+func (n *Noise) nextPink() float64 {
+	w := n.white()
+	b := &n.pink
+	b[0] = 0.99886*b[0] + w*0.0555179
+	b[1] = 0.99332*b[1] + w*0.0750759
+	b[2] = 0.96900*b[2] + w*0.1538520
+	b[3] = 0.86650*b[3] + w*0.3104856
+	b[4] = 0.55000*b[4] + w*0.5329522
+	b[5] = -0.7616*b[5] - w*0.0168980
+	out := b[0] + b[1] + b[2] + b[3] + b[4] + b[5] + b[6] + w*0.5362
+	b[6] = w * 0.115926
+	return out * 0.11
+}
@@ -0,0 +1,54 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+var (
+	// the correlation between neighbouring samples reveals the slope of the spectrum
+	noiseColorTests = []struct {
+		color    synth.NoiseColor
+		min, max float64
+	}{
+		{synth.WHITE, -.05, .05},
+		{synth.PINK, .3, 1},
+		{synth.BROWN, .9, 1},
+		{synth.BLUE, -1, -.05},
+	}
+)
+
+func TestNoiseColors(t *testing.T) {
+	for _, test := range noiseColorTests {
+		t.Run("", func(t *testing.T) {
+			noise, err := synth.NewNoise(test.color, 1)
+			if err != nil {
+				t.Fatalf("Should be able to create noise: %v", err)
+			}
+			frames := make([]wave.Frame, 44100)
+			noise.Process(frames)
+			corr := autocorrelation(frames, 1) / autocorrelation(frames, 0)
+			if corr < test.min || corr > test.max {
+				t.Fatalf("Expected lag-1 correlation in [%v;%v], got %v", test.min, test.max, corr)
+			}
+			if p := peak(frames); p > 1.5 {
+				t.Fatalf("Expected noise to stay roughly within [-1;1], got peak %v", p)
+			}
+		})
+	}
+}
+
+func TestNoiseSeed(t *testing.T) {
+	a, _ := synth.NewNoise(synth.PINK, 42)
+	b, _ := synth.NewNoise(synth.PINK, 42)
+	for i := 0; i < 100; i++ {
+		if a.Tick(0) != b.Tick(0) {
+			t.Fatal("Expected noise with the same seed to be identical")
+		}
+	}
+	if _, err := synth.NewNoise(synth.NoiseColor(42), 1); err == nil {
+		t.Fatal("Expected an error for an unknown noise color")
+	}
+}
@@ -0,0 +1,120 @@
+package synthesizer
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// parallelWarmup is the time in seconds every block is preceded by, so the state of its
+// processor has settled, and parallelCrossfade the time over which blocks are joined
+const (
+	parallelWarmup    = .5
+	parallelCrossfade = .01
+)
+
+// ParallelProcess runs a processor over the frames in place, splitting them into a block per
+// worker which are processed at the same time. Every block gets its own processor, which
+// first runs over the half second before the block so filters and envelope followers reach
+// the state they would have had, and neighbouring blocks are crossfaded over 10ms. Processors
+// which remember more than half a second, such as long delays and reverbs, don't sound the
+// same as when they process the frames in one go.
+func ParallelProcess(frames []wave.Frame, wfmt wave.WaveFmt, newProcessor func() (Processor, error), workers int) error {
+	if newProcessor == nil {
+		return errors.New("Need a processor")
+	}
+	channels := wfmt.NumChannels
+	if channels < 1 || wfmt.SampleRate < 1 {
+		return errors.New("Format needs channels and a sample rate")
+	}
+	if workers < 1 {
+		return errors.New("Need at least one worker")
+	}
+	samples := len(frames) / channels
+	warmup := int(parallelWarmup * float64(wfmt.SampleRate))
+	fade := int(parallelCrossfade * float64(wfmt.SampleRate))
+
+	// blocks much shorter than the warmup aren't worth it
+	blocks := workers
+	if max := samples / (4 * warmup); blocks > max {
+		blocks = max
+	}
+	if blocks <= 1 {
+		p, err := newProcessor()
+		if err != nil {
+			return err
+		}
+		p.Process(frames)
+		return nil
+	}
+
+	// block i covers [starts[i];starts[i+1]), it is processed from the warmup before its start
+	// to the crossfade after its end
+	starts := make([]int, blocks+1)
+	for i := range starts {
+		starts[i] = samples * i / blocks
+	}
+	outputs := make([][]wave.Frame, blocks)
+	buffers := make([][]wave.Frame, blocks)
+	errs := make([]error, blocks)
+	var wg sync.WaitGroup
+	for i := 0; i < blocks; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p, err := newProcessor()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			lo, hi := starts[i]-warmup, starts[i+1]+fade
+			if lo < 0 {
+				lo = 0
+			}
+			if hi > samples {
+				hi = samples
+			}
+			out := wave.AcquireFrames((hi - lo) * channels)
+			buffers[i] = out
+			copy(out, frames[lo*channels:hi*channels])
+			p.Process(out)
+			// keep the output from the start of the block
+			skip := (starts[i] - lo) * channels
+			outputs[i] = out[skip:]
+		}(i)
+	}
+	wg.Wait()
+	defer func() {
+		for _, buf := range buffers {
+			wave.ReleaseFrames(buf)
+		}
+	}()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, out := range outputs {
+		start, end := starts[i], starts[i+1]
+		copy(frames[start*channels:end*channels], out)
+		if i == 0 {
+			continue
+		}
+		// fade from the tail of the previous block into this one
+		prev := outputs[i-1][(start-starts[i-1])*channels:]
+		n := fade
+		if n > end-start {
+			n = end - start
+		}
+		for s := 0; s < n; s++ {
+			x := wave.Frame(s+1) / wave.Frame(n+1)
+			for c := 0; c < channels; c++ {
+				j := s*channels + c
+				frames[start*channels+j] = prev[j]*(1-x) + out[j]*x
+			}
+		}
+	}
+	return nil
+}
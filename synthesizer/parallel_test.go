@@ -0,0 +1,58 @@
+package synthesizer_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestParallelProcess(t *testing.T) {
+	sr := 1000
+	tests := []struct {
+		channels     int
+		newProcessor func() (synth.Processor, error)
+	}{
+		{2, func() (synth.Processor, error) { return synth.NewGain(-6), nil }},
+		{1, func() (synth.Processor, error) { return synth.NewSVF(sr, synth.LOWPASS, 100, .3), nil }},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+			input := make([]wave.Frame, 20*sr*test.channels)
+			for i := range input {
+				input[i] = wave.Frame(rnd.Float64()*2 - 1)
+			}
+			serial := append([]wave.Frame{}, input...)
+			p, _ := test.newProcessor()
+			p.Process(serial)
+
+			parallel := append([]wave.Frame{}, input...)
+			wfmt := wave.NewWaveFmt(1, test.channels, sr, 16, nil)
+			if err := synth.ParallelProcess(parallel, wfmt, test.newProcessor, 4); err != nil {
+				t.Fatal(err)
+			}
+			for i := range serial {
+				if math.Abs(float64(serial[i]-parallel[i])) > 1e-6 {
+					t.Fatalf("Expected frame %v to be %v, got %v", i, serial[i], parallel[i])
+				}
+			}
+		})
+	}
+
+	frames := make([]wave.Frame, 100)
+	wfmt := wave.NewWaveFmt(1, 1, sr, 16, nil)
+	failing := func() (synth.Processor, error) { return nil, errors.New("failed") }
+	if err := synth.ParallelProcess(frames, wfmt, failing, 4); err == nil {
+		t.Fatal("Expected the error of the processor")
+	}
+	if err := synth.ParallelProcess(frames, wfmt, nil, 4); err == nil {
+		t.Fatal("Expected an error without a processor")
+	}
+	if err := synth.ParallelProcess(frames, wfmt, func() (synth.Processor, error) { return synth.NewGain(0), nil }, 0); err == nil {
+		t.Fatal("Expected an error without workers")
+	}
+}
@@ -0,0 +1,129 @@
+package synthesizer
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// silence is the level below which a decaying sound is considered finished
+const silence = 1e-4
+
+// Pluck generates plucked-string notes using the Karplus-Strong algorithm
+type Pluck struct {
+	Decay        float64 // time in seconds for a note to fade by 60dB
+	Damping      float64 // in the range [0;1], higher values lose high frequencies faster
+	PickPosition float64 // in the range (0;1), position along the string where it is plucked
+
+	sr     float64
+	rnd    *rand.Rand
+	line   []float64 // delay line holding one period of the string
+	pos    int
+	gain   float64 // loop gain applied every period
+	smooth float64 // damping filter coefficient
+	coef   float64 // allpass coefficient for fine tuning
+	last   float64 // previous output, for the damping filter
+	apIn   float64 // previous allpass input
+	apOut  float64 // previous allpass output
+	peak   float64 // peak level of the current period
+	active bool
+}
+
+// NewPluck creates a plucked string, the seed makes the excitation noise reproducible
+func NewPluck(sr int, seed int64) *Pluck {
+	return &Pluck{
+		Decay:        2,
+		Damping:      1,
+		PickPosition: .3,
+		sr:           float64(sr),
+		rnd:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NoteOn plucks the string at the given frequency and velocity in the range [0;1]
+func (p *Pluck) NoteOn(freq, velocity float64) {
+	period := p.sr / freq
+	p.smooth = p.Damping / 2
+
+	// the damping filter delays the loop by its coefficient, the allpass makes up the fraction
+	delay := period - p.smooth
+	n := int(delay)
+	frac := delay - float64(n)
+	if frac < .1 && n > 1 {
+		n--
+		frac++
+	}
+	if n < 1 {
+		n = 1
+	}
+	p.coef = (1 - frac) / (1 + frac)
+	p.gain = math.Pow(10, -3/(p.Decay*freq))
+
+	// noise burst, comb filtered to mimic the position at which the string is picked
+	noise := make([]float64, n)
+	for i := range noise {
+		noise[i] = p.rnd.Float64()*2 - 1
+	}
+	offset := int(math.Round(p.PickPosition * float64(n)))
+	p.line = make([]float64, n)
+	mean, peak := 0.0, 0.0
+	for i := range p.line {
+		p.line[i] = noise[i] - noise[((i-offset)%n+n)%n]
+		mean += p.line[i] / float64(n)
+	}
+	for i := range p.line {
+		p.line[i] -= mean
+		peak = math.Max(peak, math.Abs(p.line[i]))
+	}
+	if peak > 0 {
+		for i := range p.line {
+			p.line[i] *= velocity / peak
+		}
+	}
+
+	p.pos = 0
+	p.last, p.apIn, p.apOut, p.peak = 0, 0, 0, 0
+	p.active = velocity > 0
+}
+
+// NoteOff is a no-op, a plucked string rings out by itself
+func (p *Pluck) NoteOff() {}
+
+// Active returns true while the string is still audible
+func (p *Pluck) Active() bool {
+	return p.active
+}
+
+// Tick returns the next sample of the string
+func (p *Pluck) Tick() float64 {
+	if len(p.line) == 0 {
+		return 0
+	}
+	out := p.line[p.pos]
+
+	lp := (1-p.smooth)*out + p.smooth*p.last
+	p.last = out
+	ap := p.coef*lp + p.apIn - p.coef*p.apOut
+	p.apIn, p.apOut = lp, ap
+	p.line[p.pos] = p.gain * ap
+
+	p.peak = math.Max(p.peak, math.Abs(out))
+	p.pos++
+	if p.pos == len(p.line) {
+		p.pos = 0
+		p.active = p.peak > silence
+		p.peak = 0
+	}
+	return out
+}
+
+// Render plucks a note and returns duration seconds of audio
+func (p *Pluck) Render(freq, velocity, duration float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*p.sr))
+	p.NoteOn(freq, velocity)
+	for i := range frames {
+		frames[i] = wave.Frame(p.Tick())
+	}
+	return frames
+}
@@ -0,0 +1,65 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestPluckPitch(t *testing.T) {
+	sr := 44100
+	freq := 220.
+	frames := synth.NewPluck(sr, 1).Render(freq, 1, .5)
+
+	// the autocorrelation should peak at the period of the note rather than around it
+	period := float64(sr) / freq
+	best, bestLag := 0.0, 0
+	for lag := int(period) - 20; lag <= int(period)+20; lag++ {
+		c := autocorrelation(frames, lag)
+		if c > best {
+			best, bestLag = c, lag
+		}
+	}
+	if math.Abs(float64(bestLag)-period) > 1 {
+		t.Fatalf("Expected a period of %v samples, got %v", period, bestLag)
+	}
+}
+
+func TestPluckDecay(t *testing.T) {
+	p := synth.NewPluck(8000, 1)
+	p.Decay = .5
+	frames := p.Render(440, 1, 1)
+	if p.Active() {
+		t.Fatal("Expected string to have died out")
+	}
+	if peak(frames[4000:]) > 0.01 {
+		t.Fatalf("Expected the string to be 60dB down after its decay time, got %v", peak(frames[4000:]))
+	}
+
+	again := synth.NewPluck(8000, 1)
+	again.Decay = .5
+	other := again.Render(440, 1, 1)
+	for i := range frames {
+		if frames[i] != other[i] {
+			t.Fatal("Expected plucks with the same seed to be identical")
+		}
+	}
+}
+
+func autocorrelation(frames []wave.Frame, lag int) float64 {
+	sum := 0.0
+	for i := 0; i+lag < len(frames); i++ {
+		sum += float64(frames[i] * frames[i+lag])
+	}
+	return sum
+}
+
+func peak(frames []wave.Frame) float64 {
+	p := 0.0
+	for _, f := range frames {
+		p = math.Max(p, math.Abs(float64(f)))
+	}
+	return p
+}
@@ -0,0 +1,231 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+)
+
+// StealPolicy decides which voice is reused when all voices are playing
+type StealPolicy int
+
+// Policies for stealing a voice
+const (
+	OLDEST    StealPolicy = iota // steal the voice that started first
+	QUIETEST                     // steal the voice with the lowest output level
+	SAME_NOTE                    // retrigger a voice playing the same note, otherwise the oldest
+)
+
+// Dimension is an expression of a single note, such as those of MPE controllers
+type Dimension int
+
+// Dimensions of per-note expression
+const (
+	BEND     Dimension = iota // in semitones
+	PRESSURE                  // in the range [0;1]
+	TIMBRE                    // in the range [0;1], the slide of MPE controllers
+)
+
+// expressionRoute sets a parameter of the voices from a dimension of their note
+type expressionRoute struct {
+	dimension   Dimension
+	param       string
+	base, depth float64
+}
+
+// levelRelease is the amount by which the tracked level of a voice falls each tick
+const levelRelease = 0.999
+
+// PolyVoice is a sound source which can be played by Polyphony
+type PolyVoice interface {
+	NoteOn(freq, velocity float64)
+	NoteOff()
+	Active() bool
+	Tick() float64
+}
+
+// polyVoice keeps track of the note a voice is playing
+type polyVoice struct {
+	PolyVoice
+	note    int
+	held    bool
+	started uint64
+	level   float64
+	express [3]float64 // by dimension
+}
+
+// Polyphony allocates notes to a fixed set of voices and mixes their output
+type Polyphony struct {
+	Policy StealPolicy
+	Tuning func(note int) float64 // turns a MIDI note into a frequency, e.g the Frequency of a Tuning
+
+	voices     []*polyVoice
+	clock      uint64
+	expression []expressionRoute
+}
+
+// NewPolyphony creates n voices using the constructor
+func NewPolyphony(n int, newVoice func() (PolyVoice, error)) (*Polyphony, error) {
+	if n < 1 {
+		return nil, errors.New("Polyphony needs at least one voice")
+	}
+	p := &Polyphony{
+		Policy: OLDEST,
+		Tuning: MidiToFrequency,
+		voices: make([]*polyVoice, n),
+	}
+	for i := range p.voices {
+		v, err := newVoice()
+		if err != nil {
+			return nil, err
+		}
+		p.voices[i] = &polyVoice{PolyVoice: v, note: -1}
+	}
+	return p, nil
+}
+
+// NoteOn plays a MIDI note with a velocity in the range [0;1] on a free or stolen voice
+func (p *Polyphony) NoteOn(note int, velocity float64) {
+	v := p.allocate(note)
+	p.clock++
+	v.note = note
+	v.held = true
+	v.started = p.clock
+	v.express = [3]float64{}
+	p.applyExpression(v)
+	v.NoteOn(p.Tuning(note), velocity)
+}
+
+// Express routes a dimension of per-note expression to a parameter of the voices, which is
+// set to base + depth * the expression of the note they play. The voices should implement
+// Parameterized, for a Voice BEND is routed to "pitch" with a depth of 1. Routes to the
+// same parameter add up on top of the base of the first.
+func (p *Polyphony) Express(d Dimension, param string, base, depth float64) error {
+	if d < BEND || d > TIMBRE {
+		return errors.New("Unknown dimension of expression")
+	}
+	for _, v := range p.voices {
+		target, ok := v.PolyVoice.(Parameterized)
+		if !ok {
+			return errors.New("Voices need parameters for expression")
+		}
+		if err := target.SetParam(param, base); err != nil {
+			return err
+		}
+	}
+	p.expression = append(p.expression, expressionRoute{d, param, base, depth})
+	return nil
+}
+
+// NoteExpression sets a dimension of the expression of a note. It applies to the voices
+// holding the note, or when it is released to those still sounding, and is reset to 0
+// when a voice starts a note.
+func (p *Polyphony) NoteExpression(note int, d Dimension, value float64) {
+	if d < BEND || d > TIMBRE {
+		return
+	}
+	held := false
+	for _, v := range p.voices {
+		if v.held && v.note == note {
+			held = true
+		}
+	}
+	for _, v := range p.voices {
+		if v.note == note && v.held == held && (held || v.Active()) {
+			v.express[d] = value
+			p.applyExpression(v)
+		}
+	}
+}
+
+// applyExpression sets the parameters of a voice from the expression of its note
+func (p *Polyphony) applyExpression(v *polyVoice) {
+	if len(p.expression) == 0 {
+		return
+	}
+	target := v.PolyVoice.(Parameterized)
+	values := map[string]float64{}
+	for _, r := range p.expression {
+		if _, ok := values[r.param]; !ok {
+			values[r.param] = r.base
+		}
+		values[r.param] += r.depth * v.express[r.dimension]
+	}
+	for param, value := range values {
+		// the parameters were checked by Express
+		target.SetParam(param, value)
+	}
+}
+
+// NoteOff releases all voices playing the note
+func (p *Polyphony) NoteOff(note int) {
+	for _, v := range p.voices {
+		if v.held && v.note == note {
+			v.held = false
+			v.NoteOff()
+		}
+	}
+}
+
+// AllNotesOff releases every voice
+func (p *Polyphony) AllNotesOff() {
+	for _, v := range p.voices {
+		if v.held {
+			v.held = false
+			v.NoteOff()
+		}
+	}
+}
+
+// ActiveVoices returns the amount of voices that are producing sound
+func (p *Polyphony) ActiveVoices() int {
+	n := 0
+	for _, v := range p.voices {
+		if v.Active() {
+			n++
+		}
+	}
+	return n
+}
+
+// Tick returns the mix of all active voices
+func (p *Polyphony) Tick() float64 {
+	out := 0.0
+	for _, v := range p.voices {
+		if !v.Active() {
+			v.level = 0
+			continue
+		}
+		s := v.Tick()
+		v.level = math.Max(math.Abs(s), v.level*levelRelease)
+		out += s
+	}
+	return out
+}
+
+// allocate finds the voice to play the note on
+func (p *Polyphony) allocate(note int) *polyVoice {
+	if p.Policy == SAME_NOTE {
+		for _, v := range p.voices {
+			if v.note == note && v.Active() {
+				return v
+			}
+		}
+	}
+	for _, v := range p.voices {
+		if !v.Active() {
+			return v
+		}
+	}
+
+	steal := p.voices[0]
+	for _, v := range p.voices[1:] {
+		if p.Policy == QUIETEST {
+			if v.level < steal.level {
+				steal = v
+			}
+		} else if v.started < steal.started {
+			steal = v
+		}
+	}
+	return steal
+}
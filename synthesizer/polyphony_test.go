@@ -0,0 +1,134 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// fakeVoice outputs its velocity for as long as the note is held
+type fakeVoice struct {
+	freq, velocity float64
+	on             bool
+}
+
+func (f *fakeVoice) NoteOn(freq, velocity float64) { f.freq, f.velocity, f.on = freq, velocity, true }
+func (f *fakeVoice) NoteOff()                      { f.on = false }
+func (f *fakeVoice) Active() bool                  { return f.on }
+func (f *fakeVoice) Tick() float64                 { return f.velocity }
+
+var (
+	stealPolicyTests = []struct {
+		policy synth.StealPolicy
+		notes  []int
+		vels   []float64
+		stolen int // note that should no longer be playing
+	}{
+		{synth.OLDEST, []int{60, 62, 64}, []float64{.5, .1, .9}, 60},
+		{synth.QUIETEST, []int{60, 62, 64}, []float64{.5, .1, .9}, 62},
+		{synth.SAME_NOTE, []int{60, 62, 62}, []float64{.5, .1, .9}, -1},
+	}
+)
+
+func newFakePolyphony(t *testing.T, n int) (*synth.Polyphony, []*fakeVoice) {
+	voices := []*fakeVoice{}
+	p, err := synth.NewPolyphony(n, func() (synth.PolyVoice, error) {
+		v := &fakeVoice{}
+		voices = append(voices, v)
+		return v, nil
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create polyphony: %v", err)
+	}
+	return p, voices
+}
+
+func TestPolyphonyStealing(t *testing.T) {
+	for _, test := range stealPolicyTests {
+		t.Run("", func(t *testing.T) {
+			p, voices := newFakePolyphony(t, 2)
+			p.Policy = test.policy
+			p.Tuning = func(note int) float64 { return float64(note) }
+			for i, note := range test.notes {
+				p.NoteOn(note, test.vels[i])
+				p.Tick()
+			}
+			for _, v := range voices {
+				if v.freq == float64(test.stolen) {
+					t.Fatalf("Expected note %v to be stolen", test.stolen)
+				}
+			}
+			if test.stolen < 0 && p.ActiveVoices() != 2 {
+				t.Fatalf("Expected the same note to be retriggered, got %v active voices", p.ActiveVoices())
+			}
+		})
+	}
+}
+
+func TestPolyphonyMix(t *testing.T) {
+	p, _ := newFakePolyphony(t, 4)
+	p.NoteOn(60, .25)
+	p.NoteOn(64, .5)
+	if out := p.Tick(); out != .75 {
+		t.Fatalf("Expected voices to be mixed, got %v", out)
+	}
+	p.NoteOff(60)
+	if out := p.Tick(); out != .5 {
+		t.Fatalf("Expected released voice to stop, got %v", out)
+	}
+	p.AllNotesOff()
+	if p.ActiveVoices() != 0 {
+		t.Fatal("Expected all voices to be released")
+	}
+
+	if _, err := synth.NewPolyphony(0, nil); err == nil {
+		t.Fatal("Expected an error for polyphony without voices")
+	}
+}
+
+func TestPolyphonyExpression(t *testing.T) {
+	voices := []*synth.Voice{}
+	p, err := synth.NewPolyphony(2, func() (synth.PolyVoice, error) {
+		v, err := synth.NewVoice(100, synth.SINE)
+		voices = append(voices, v)
+		return v, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Express(synth.BEND, "pitch", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Express(synth.PRESSURE, "amp", .5, .5); err != nil {
+		t.Fatal(err)
+	}
+	p.NoteOn(60, 1)
+	p.NoteOn(64, 1)
+	p.NoteExpression(60, synth.BEND, 2)
+	p.NoteExpression(64, synth.PRESSURE, 1)
+	if voices[0].Transpose != 2 || voices[0].Gain != .5 || voices[1].Transpose != 0 || voices[1].Gain != 1 {
+		t.Fatalf("Expected the expression of each note on its voice, got %+v %+v", voices[0], voices[1])
+	}
+	// a released note is still bent while it sounds, a new note starts without expression
+	p.NoteOff(60)
+	p.NoteExpression(60, synth.BEND, -1)
+	if voices[0].Transpose != -1 {
+		t.Fatalf("Expected the released note to be bent, got %v", voices[0].Transpose)
+	}
+	p.AllNotesOff()
+	for p.ActiveVoices() > 0 {
+		p.Tick()
+	}
+	p.NoteOn(67, 1)
+	if voices[0].Transpose != 0 || voices[0].Gain != .5 {
+		t.Fatalf("Expected the expression to be reset, got %+v", voices[0])
+	}
+
+	fake, _ := newFakePolyphony(t, 1)
+	if err := fake.Express(synth.BEND, "pitch", 0, 1); err == nil {
+		t.Fatal("Expected an error for voices without parameters")
+	}
+	if err := p.Express(synth.TIMBRE, "nothing", 0, 1); err == nil {
+		t.Fatal("Expected an error for an unknown parameter")
+	}
+}
@@ -0,0 +1,104 @@
+package synthesizer_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestEffectParams(t *testing.T) {
+	e, err := synth.NewEffect("lowpass", 44100, 1, map[string]float64{"cutoff": 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetParam("resonance", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetParam("vowel", 1); err == nil {
+		t.Fatal("Expected an unknown parameter to fail")
+	}
+	want := []synth.ParamValue{
+		{Param: synth.Param{Name: "cutoff", Unit: "Hz", Min: 20, Max: 20000, Default: 1000}, Value: 500},
+		{Param: synth.Param{Name: "resonance", Min: 0, Max: 1, Default: 0}, Value: 1},
+	}
+	if got := e.Params(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestPreset(t *testing.T) {
+	highpass, err := synth.NewEffect("highpass", 44100, 2, map[string]float64{"cutoff": 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gain, err := synth.NewEffect("gain", 44100, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gain.SetParam("gain", .5)
+	chain := synth.Chain{highpass, gain}
+
+	path := filepath.Join(t.TempDir(), "voice.json")
+	if err := synth.WritePresetFile(path, chain.Preset("voice")); err != nil {
+		t.Fatal(err)
+	}
+	p, err := synth.ReadPresetFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p, chain.Preset("voice")) {
+		t.Fatalf("Expected %+v, got %+v", chain.Preset("voice"), p)
+	}
+
+	// the chain of the preset processes like the chain it was stored from
+	loaded, err := p.Chain(44100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := make([]wave.Frame, 2000), make([]wave.Frame, 2000)
+	for i := range a {
+		a[i] = wave.Frame(i%50)/50 - .5
+		b[i] = a[i]
+	}
+	chain.Process(a)
+	loaded.Process(b)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("Expected the loaded chain to process the same")
+	}
+}
+
+func TestReadPreset(t *testing.T) {
+	tests := []struct {
+		json string
+		err  bool
+	}{
+		{`{"effects": [{"effect": "gain"}]}`, false},
+		{`{"name": "dark", "effects": [{"effect": "lowpass", "params": {"cutoff": 300}}]}`, false},
+		{`{"effects": [{"effect": "missing"}]}`, true},
+		{`{"effects": [{"effect": "gain", "params": {"cutoff": 300}}]}`, true},
+		{`{"effects": [{"effect": "gain", "params": {"gain": -1}}]}`, true},
+		{`{"effects": [], "volume": 1}`, true},
+		{`{"effects": [`, true},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := synth.ReadPreset(strings.NewReader(test.json))
+			if (err != nil) != test.err {
+				t.Fatalf("Expected an error: %v, got %v", test.err, err)
+			}
+		})
+	}
+
+	b := bytes.Buffer{}
+	if err := synth.WritePreset(&b, synth.Preset{Effects: []synth.PresetEffect{{Effect: "gain"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"effects\": [\n    {\n      \"effect\": \"gain\"\n    }\n  ]\n}\n"; b.String() != want {
+		t.Fatalf("Expected %q, got %q", want, b.String())
+	}
+}
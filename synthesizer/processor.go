@@ -0,0 +1,27 @@
+package synthesizer
+
+import "github.com/DylanMeeus/GoAudio/wave"
+
+// Processor works on a block of frames in place.
+// Effects transform the frames, generators overwrite them with their output.
+type Processor interface {
+	Process(frames []wave.Frame)
+}
+
+// processBlock is the amount of samples per channel ProcessSamples converts at a time
+const processBlock = 1024
+
+// ProcessSamples runs a processor over samples of any type, such as wave.Frame32, converting
+// them to frames a block at a time. The blocks keep the interleaved channels together.
+func ProcessSamples[T wave.Sample](p Processor, samples []T, channels int) {
+	if channels < 1 {
+		channels = 1
+	}
+	block := wave.AcquireFrames(processBlock * channels)
+	defer wave.ReleaseFrames(block)
+	for start := 0; start < len(samples); start += len(block) {
+		n := wave.ConvertSamples(block, samples[start:])
+		p.Process(block[:n])
+		wave.ConvertSamples(samples[start:start+n], block[:n])
+	}
+}
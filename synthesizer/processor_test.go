@@ -0,0 +1,28 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestProcessSamples(t *testing.T) {
+	for _, channels := range []int{1, 2, 3} {
+		t.Run("", func(t *testing.T) {
+			frames := make([]wave.Frame, 5000*channels)
+			samples := make([]wave.Frame32, len(frames))
+			for i := range frames {
+				frames[i] = wave.Frame(i%100)/100 - .5
+				samples[i] = wave.Frame32(frames[i])
+			}
+			synth.NewSVF(44100, synth.LOWPASS, 2000, .5).Process(frames)
+			synth.ProcessSamples(synth.NewSVF(44100, synth.LOWPASS, 2000, .5), samples, channels)
+			for i := range frames {
+				if d := float64(samples[i]) - float64(frames[i]); d > 1e-5 || d < -1e-5 {
+					t.Fatalf("Expected sample %v to be %v, got %v", i, frames[i], samples[i])
+				}
+			}
+		})
+	}
+}
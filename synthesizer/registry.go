@@ -0,0 +1,381 @@
+package synthesizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Param describes a parameter of a registered effect or generator, which is set with SetParam
+type Param struct {
+	Name     string
+	Unit     string // such as "Hz", "dB" or "s", empty for a plain number
+	Min, Max float64
+	Default  float64
+}
+
+// EffectFactory creates the effects of a kind for a sample rate and amount of interleaved
+// channels. Effects with parameters implement Parameterized.
+type EffectFactory struct {
+	Description string
+	Params      []Param
+	New         func(sr, channels int) (Processor, error)
+}
+
+// GeneratorFactory creates the generators of a kind for a sample rate. Generators with
+// parameters implement Parameterized.
+type GeneratorFactory struct {
+	Description string
+	Params      []Param
+	New         func(sr int) (Generator, error)
+}
+
+// registry holds the effects and generators which can be created by name
+var registry = struct {
+	sync.RWMutex
+	effects    map[string]EffectFactory
+	generators map[string]GeneratorFactory
+}{
+	effects:    map[string]EffectFactory{},
+	generators: map[string]GeneratorFactory{},
+}
+
+// RegisterEffect makes an effect available by name, such as to presets and the goaudio
+// tool. Packages usually register their effects in an init function. A name can only be
+// registered once.
+func RegisterEffect(name string, factory EffectFactory) error {
+	if err := checkFactory(name, factory.Params, factory.New == nil); err != nil {
+		return err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.effects[name]; ok {
+		return fmt.Errorf("Effect %v is already registered", name)
+	}
+	registry.effects[name] = factory
+	return nil
+}
+
+// RegisterGenerator makes a generator available by name, like RegisterEffect
+func RegisterGenerator(name string, factory GeneratorFactory) error {
+	if err := checkFactory(name, factory.Params, factory.New == nil); err != nil {
+		return err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.generators[name]; ok {
+		return fmt.Errorf("Generator %v is already registered", name)
+	}
+	registry.generators[name] = factory
+	return nil
+}
+
+// checkFactory checks the name and parameters of a factory
+func checkFactory(name string, params []Param, noNew bool) error {
+	if name == "" {
+		return errors.New("Need a name")
+	}
+	if noNew {
+		return errors.New("Need a function creating the processors")
+	}
+	seen := map[string]bool{}
+	for _, p := range params {
+		if p.Name == "" || seen[p.Name] {
+			return fmt.Errorf("Parameter %q is unnamed or listed twice", p.Name)
+		}
+		if p.Min > p.Max || p.Default < p.Min || p.Default > p.Max {
+			return fmt.Errorf("Default of %v is outside [%v;%v]", p.Name, p.Min, p.Max)
+		}
+		seen[p.Name] = true
+	}
+	return nil
+}
+
+// Effects returns the names of the registered effects in alphabetical order
+func Effects() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.effects))
+	for name := range registry.effects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generators returns the names of the registered generators in alphabetical order
+func Generators() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.generators))
+	for name := range registry.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupEffect returns the factory of a registered effect
+func LookupEffect(name string) (EffectFactory, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.effects[name]
+	return f, ok
+}
+
+// LookupGenerator returns the factory of a registered generator
+func LookupGenerator(name string) (GeneratorFactory, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	f, ok := registry.generators[name]
+	return f, ok
+}
+
+// NewEffect creates a registered effect with its parameters at their defaults, except for
+// those set by params. Values outside the range of a parameter are refused.
+func NewEffect(name string, sr, channels int, params map[string]float64) (*Effect, error) {
+	f, ok := LookupEffect(name)
+	if !ok {
+		return nil, fmt.Errorf("No effect named %v", name)
+	}
+	p, err := f.New(sr, channels)
+	if err != nil {
+		return nil, err
+	}
+	if err := setParams(p, name, f.Params, params); err != nil {
+		return nil, err
+	}
+	e := &Effect{name: name, processor: p, params: f.Params, values: map[string]float64{}}
+	for _, d := range f.Params {
+		e.values[d.Name] = d.Default
+		if v, ok := params[d.Name]; ok {
+			e.values[d.Name] = v
+		}
+	}
+	return e, nil
+}
+
+// ParamValue is a parameter of an effect with its current value
+type ParamValue struct {
+	Param
+	Value float64
+}
+
+// Effect is an effect created by NewEffect. It keeps the values of its parameters, so they
+// can be listed and stored in presets.
+type Effect struct {
+	name      string
+	processor Processor
+	params    []Param
+	values    map[string]float64
+}
+
+// Name returns the name the effect is registered with
+func (e *Effect) Name() string {
+	return e.name
+}
+
+// Processor returns the processor created by the factory of the effect
+func (e *Effect) Processor() Processor {
+	return e.processor
+}
+
+// Params returns the parameters of the effect with their values, in the order of the factory
+func (e *Effect) Params() []ParamValue {
+	values := make([]ParamValue, len(e.params))
+	for i, d := range e.params {
+		values[i] = ParamValue{Param: d, Value: e.values[d.Name]}
+	}
+	return values
+}
+
+// SetParam sets a parameter, clamping the value to its range so the effect can be automated
+// by sources going past it
+func (e *Effect) SetParam(name string, value float64) error {
+	for _, d := range e.params {
+		if d.Name == name {
+			value = math.Max(d.Min, math.Min(value, d.Max))
+			if err := e.processor.(Parameterized).SetParam(name, value); err != nil {
+				return err
+			}
+			e.values[name] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("%v has no parameter %v", e.name, name)
+}
+
+func (e *Effect) Process(frames []wave.Frame) {
+	e.processor.Process(frames)
+}
+
+// NewGenerator creates a registered generator like NewEffect
+func NewGenerator(name string, sr int, params map[string]float64) (Generator, error) {
+	f, ok := LookupGenerator(name)
+	if !ok {
+		return nil, fmt.Errorf("No generator named %v", name)
+	}
+	g, err := f.New(sr)
+	if err != nil {
+		return nil, err
+	}
+	if err := setParams(g, name, f.Params, params); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// setParams sets the parameters described by descriptors to their values, or their defaults
+func setParams(target interface{}, name string, descriptors []Param, values map[string]float64) error {
+	known := map[string]bool{}
+	for _, d := range descriptors {
+		known[d.Name] = true
+	}
+	for param := range values {
+		if !known[param] {
+			return fmt.Errorf("%v has no parameter %v", name, param)
+		}
+	}
+	if len(descriptors) == 0 {
+		return nil
+	}
+	p, ok := target.(Parameterized)
+	if !ok {
+		return fmt.Errorf("%v has parameters but can't set them", name)
+	}
+	for _, d := range descriptors {
+		v, ok := values[d.Name]
+		if !ok {
+			v = d.Default
+		}
+		if v < d.Min || v > d.Max {
+			return fmt.Errorf("%v of %v should be in [%v;%v], got %v", d.Name, name, d.Min, d.Max, v)
+		}
+		if err := p.SetParam(d.Name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// perChannel runs a processor per channel of interleaved frames, for processors keeping a
+// state between samples such as filters
+type perChannel struct {
+	processors []AutomatableProcessor
+	buffers    [][]wave.Frame
+}
+
+// newPerChannel creates a processor per channel
+func newPerChannel(channels int, newProcessor func() (AutomatableProcessor, error)) (*perChannel, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	p := &perChannel{buffers: make([][]wave.Frame, channels)}
+	for c := 0; c < channels; c++ {
+		proc, err := newProcessor()
+		if err != nil {
+			return nil, err
+		}
+		p.processors = append(p.processors, proc)
+	}
+	return p, nil
+}
+
+// SetParam sets the parameter of the processors of every channel
+func (p *perChannel) SetParam(name string, value float64) error {
+	for _, proc := range p.processors {
+		if err := proc.SetParam(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *perChannel) Process(frames []wave.Frame) {
+	n := len(frames) / len(p.processors)
+	for c := range p.buffers {
+		if cap(p.buffers[c]) < n {
+			p.buffers[c] = make([]wave.Frame, n)
+		}
+		p.buffers[c] = p.buffers[c][:n]
+	}
+	wave.Deinterleave(p.buffers, frames)
+	for c, proc := range p.processors {
+		proc.Process(p.buffers[c])
+	}
+	wave.Interleave(frames, p.buffers)
+}
+
+func init() {
+	filter := func(mode FilterMode, description string) EffectFactory {
+		return EffectFactory{
+			Description: description,
+			Params: []Param{
+				{Name: "cutoff", Unit: "Hz", Min: 20, Max: 20000, Default: 1000},
+				{Name: "resonance", Min: 0, Max: 1, Default: 0},
+			},
+			New: func(sr, channels int) (Processor, error) {
+				return newPerChannel(channels, func() (AutomatableProcessor, error) {
+					return NewSVF(sr, mode, 1000, 0), nil
+				})
+			},
+		}
+	}
+	effects := map[string]EffectFactory{
+		"gain": {
+			Description: "changes the level",
+			Params:      []Param{{Name: "gain", Min: 0, Max: 16, Default: 1}},
+			New: func(sr, channels int) (Processor, error) {
+				return NewGain(0), nil
+			},
+		},
+		"pan": {
+			Description: "positions stereo frames between the speakers",
+			Params:      []Param{{Name: "pan", Min: -1, Max: 1, Default: 0}},
+			New: func(sr, channels int) (Processor, error) {
+				if channels != 2 {
+					return nil, errors.New("Panning needs stereo frames")
+				}
+				return &Pan{}, nil
+			},
+		},
+		"lowpass":  filter(LOWPASS, "resonant low-pass filter"),
+		"highpass": filter(HIGHPASS, "resonant high-pass filter"),
+		"bandpass": filter(BANDPASS, "resonant band-pass filter"),
+		"notch":    filter(NOTCH, "resonant notch filter"),
+		"formant": {
+			Description: "gives a vocal timbre, morphing through the vowels a, e, i, o and u",
+			Params:      []Param{{Name: "vowel", Min: 0, Max: float64(VOWEL_U), Default: 0}},
+			New: func(sr, channels int) (Processor, error) {
+				return newPerChannel(channels, func() (AutomatableProcessor, error) {
+					return NewFormantFilter(sr, VOWEL_A)
+				})
+			},
+		},
+	}
+	for name, f := range effects {
+		RegisterEffect(name, f)
+	}
+
+	oscillator := func(shape Shape, description string) GeneratorFactory {
+		return GeneratorFactory{
+			Description: description,
+			New: func(sr int) (Generator, error) {
+				return NewOscillator(sr, shape)
+			},
+		}
+	}
+	generators := map[string]GeneratorFactory{
+		"sine":     oscillator(SINE, "sine oscillator"),
+		"square":   oscillator(SQUARE, "square oscillator"),
+		"triangle": oscillator(TRIANGLE, "triangle oscillator"),
+		"saw":      oscillator(UPWARD_SAWTOOTH, "sawtooth oscillator"),
+	}
+	for name, f := range generators {
+		RegisterGenerator(name, f)
+	}
+}
@@ -0,0 +1,147 @@
+package synthesizer_test
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// invert is an effect as a third-party package would register it
+type invert struct {
+	amount float64
+}
+
+func (i *invert) SetParam(name string, value float64) error {
+	if name != "amount" {
+		return errors.New("No such parameter")
+	}
+	i.amount = value
+	return nil
+}
+
+func (i *invert) Process(frames []wave.Frame) {
+	for j := range frames {
+		frames[j] *= wave.Frame(1 - 2*i.amount)
+	}
+}
+
+func TestRegisterEffect(t *testing.T) {
+	factory := synth.EffectFactory{
+		Description: "inverts the polarity",
+		Params:      []synth.Param{{Name: "amount", Min: 0, Max: 1, Default: 1}},
+		New: func(sr, channels int) (synth.Processor, error) {
+			return &invert{}, nil
+		},
+	}
+	if err := synth.RegisterEffect("test-invert", factory); err != nil {
+		t.Fatal(err)
+	}
+	if err := synth.RegisterEffect("test-invert", factory); err == nil {
+		t.Fatal("Expected registering a name twice to fail")
+	}
+	if !slices.Contains(synth.Effects(), "test-invert") {
+		t.Fatalf("Expected the effect to be listed, got %v", synth.Effects())
+	}
+	if f, ok := synth.LookupEffect("test-invert"); !ok || f.Description != factory.Description {
+		t.Fatal("Expected to look up the effect")
+	}
+
+	p, err := synth.NewEffect("test-invert", 44100, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []wave.Frame{.5}
+	p.Process(frames)
+	if frames[0] != -.5 {
+		t.Fatalf("Expected the default amount to invert, got %v", frames[0])
+	}
+
+	invalid := []synth.EffectFactory{
+		{},
+		{New: factory.New, Params: []synth.Param{{Name: "a"}, {Name: "a"}}},
+		{New: factory.New, Params: []synth.Param{{Name: "a", Min: 0, Max: 1, Default: 2}}},
+	}
+	for _, f := range invalid {
+		if err := synth.RegisterEffect("test-invalid", f); err == nil {
+			t.Fatalf("Expected %+v to be refused", f)
+		}
+	}
+	if err := synth.RegisterEffect("", factory); err == nil {
+		t.Fatal("Expected an empty name to be refused")
+	}
+}
+
+func TestNewEffect(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels int
+		params   map[string]float64
+		err      bool
+	}{
+		{"gain", 1, map[string]float64{"gain": 2}, false},
+		{"lowpass", 2, map[string]float64{"cutoff": 500}, false},
+		{"formant", 1, map[string]float64{"vowel": 2.5}, false},
+		{"pan", 2, nil, false},
+		{"pan", 1, nil, true},
+		{"missing", 1, nil, true},
+		{"gain", 1, map[string]float64{"cutoff": 500}, true},
+		{"lowpass", 1, map[string]float64{"cutoff": 1e6}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := synth.NewEffect(test.name, 44100, test.channels, test.params)
+			if (err != nil) != test.err {
+				t.Fatalf("Expected an error: %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestEffectChannels(t *testing.T) {
+	// a filter on stereo frames keeps a state per channel, so it filters each like mono
+	mono := make([]wave.Frame, 1000)
+	stereo := make([]wave.Frame, 2*len(mono))
+	for i := range mono {
+		mono[i] = wave.Frame(math.Sin(float64(i)))
+		stereo[2*i], stereo[2*i+1] = mono[i], -mono[i]
+	}
+	params := map[string]float64{"cutoff": 2000, "resonance": .3}
+	p, err := synth.NewEffect("lowpass", 44100, 1, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Process(mono)
+	p, err = synth.NewEffect("lowpass", 44100, 2, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Process(stereo)
+	for i := range mono {
+		if stereo[2*i] != mono[i] || stereo[2*i+1] != -mono[i] {
+			t.Fatalf("Expected frame %v to be %v, got %v and %v", i, mono[i], stereo[2*i], stereo[2*i+1])
+		}
+	}
+}
+
+func TestNewGenerator(t *testing.T) {
+	for _, name := range synth.Generators() {
+		t.Run(name, func(t *testing.T) {
+			g, err := synth.NewGenerator(name, 44100, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 100; i++ {
+				if v := g.Tick(440); v < -1 || v > 1 {
+					t.Fatalf("Expected samples in [-1;1], got %v", v)
+				}
+			}
+		})
+	}
+	if _, err := synth.NewGenerator("missing", 44100, nil); err == nil {
+		t.Fatal("Expected an unknown generator to fail")
+	}
+}
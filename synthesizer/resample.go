@@ -0,0 +1,96 @@
+package synthesizer
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// resampleZeros is the amount of zero crossings of the sinc on either side of a sample,
+// which sets how steep the filter of Resample is
+const resampleZeros = 16
+
+// resampleBlock is the amount of samples per channel ResampleCtx makes between checks of the
+// context
+const resampleBlock = 4096
+
+// Resample converts interleaved frames from one sample rate to another. Every new sample is
+// interpolated with a windowed sinc, which also filters out the frequencies above half of
+// the lower rate so they don't fold back when lowering the rate.
+func Resample(frames []wave.Frame, channels, from, to int) ([]wave.Frame, error) {
+	return ResampleCtx(context.Background(), frames, channels, from, to)
+}
+
+// ResampleCtx is Resample which stops with the error of the context once it is done
+func ResampleCtx(ctx context.Context, frames []wave.Frame, channels, from, to int) ([]wave.Frame, error) {
+	return ResampleWithProgress(ctx, frames, channels, from, to, nil)
+}
+
+// ResampleWithProgress is ResampleCtx which reports the frames made out of all of them after
+// every block, when progress isn't nil
+func ResampleWithProgress(ctx context.Context, frames []wave.Frame, channels, from, to int, progress wave.Progress) ([]wave.Frame, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if from <= 0 || to <= 0 {
+		return nil, errors.New("Samplerate should be positive")
+	}
+	samples := len(frames) / channels
+	out := make([]wave.Frame, int(int64(samples)*int64(to)/int64(from))*channels)
+	if from == to {
+		copy(out, frames)
+		if progress != nil {
+			progress(int64(len(out)), int64(len(out)))
+		}
+		return out, nil
+	}
+	// the cutoff relative to the Nyquist frequency of the input, and the reach of the filter
+	// in input samples
+	cutoff := math.Min(1, float64(to)/float64(from))
+	half := resampleZeros / cutoff
+	weights := make([]float64, 2*int(half)+2)
+	for i := 0; i < len(out)/channels; i++ {
+		if i%resampleBlock == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if progress != nil && i > 0 {
+				progress(int64(i*channels), int64(len(out)))
+			}
+		}
+		t := float64(int64(i)*int64(from)) / float64(to)
+		lo, hi := int(math.Ceil(t-half)), int(math.Floor(t+half))
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= samples {
+			hi = samples - 1
+		}
+		w := weights[:0]
+		for j := lo; j <= hi; j++ {
+			x := t - float64(j)
+			w = append(w, cutoff*sinc(cutoff*x)*(.5+.5*math.Cos(math.Pi*x/half)))
+		}
+		for c := 0; c < channels; c++ {
+			sum := 0.0
+			for k, weight := range w {
+				sum += weight * float64(frames[(lo+k)*channels+c])
+			}
+			out[i*channels+c] = wave.Frame(sum)
+		}
+	}
+	if progress != nil {
+		progress(int64(len(out)), int64(len(out)))
+	}
+	return out, nil
+}
+
+// sinc is the normalized sinc function, sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
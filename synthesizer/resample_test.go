@@ -0,0 +1,72 @@
+package synthesizer_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	audiomath "github.com/DylanMeeus/GoAudio/math"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		from, to int
+		freq     float64
+		amp      float64 // expected amplitude of the sine
+	}{
+		{44100, 48000, 1000, 1},
+		{48000, 44100, 1000, 1},
+		{48000, 8000, 440, 1},
+		// above the Nyquist frequency of the new rate
+		{48000, 8000, 6000, 0},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			// a second of a stereo sine, the right channel at half the level
+			frames := make([]wave.Frame, 2*test.from)
+			for i := 0; i < test.from; i++ {
+				v := wave.Frame(math.Sin(2 * math.Pi * test.freq * float64(i) / float64(test.from)))
+				frames[2*i], frames[2*i+1] = v, v/2
+			}
+			out, err := synth.Resample(frames, 2, test.from, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) != 2*test.to {
+				t.Fatalf("Expected %v frames, got %v", 2*test.to, len(out))
+			}
+			left, right := make([]wave.Frame, test.to/2), make([]wave.Frame, test.to/2)
+			for i := range left {
+				// away from the edges
+				left[i], right[i] = out[2*(i+test.to/4)], out[2*(i+test.to/4)+1]
+			}
+			if amp := audiomath.Goertzel(left, test.to, test.freq); math.Abs(amp-test.amp) > .01 {
+				t.Fatalf("Expected an amplitude of %v, got %v", test.amp, amp)
+			}
+			if amp := audiomath.Goertzel(right, test.to, test.freq); math.Abs(amp-test.amp/2) > .01 {
+				t.Fatalf("Expected an amplitude of %v on the right, got %v", test.amp/2, amp)
+			}
+		})
+	}
+	if _, err := synth.Resample(nil, 0, 44100, 48000); err == nil {
+		t.Fatal("Expected an error without channels")
+	}
+}
+
+func TestResampleProgress(t *testing.T) {
+	frames := make([]wave.Frame, 2*44100)
+	var done, total int64
+	reports := 0
+	out, err := synth.ResampleWithProgress(context.Background(), frames, 2, 44100, 48000, func(d, t int64) {
+		done, total = d, t
+		reports++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports < 2 || done != int64(len(out)) || total != int64(len(out)) {
+		t.Fatalf("Expected reports up to %v, got %v reports ending at %v of %v", len(out), reports, done, total)
+	}
+}
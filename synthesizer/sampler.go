@@ -0,0 +1,208 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Sample is a mono recording played back by a Sampler
+type Sample struct {
+	Data       []float64
+	SampleRate int
+	RootNote   int     // MIDI note at which the sample plays at its original pitch
+	Tune       float64 // in cents, added to the root note
+	LoopStart  int     // first frame of the loop
+	LoopEnd    int     // last frame of the loop (inclusive), looping is disabled when not after LoopStart
+}
+
+// NewSample creates a sample from (interleaved) frames, mixing multiple channels down to mono
+func NewSample(frames []wave.Frame, channels, sr, root int) (*Sample, error) {
+	if channels < 1 {
+		return nil, errors.New("A sample needs at least one channel")
+	}
+	data := make([]float64, len(frames)/channels)
+	for i := range data {
+		for c := 0; c < channels; c++ {
+			data[i] += float64(frames[i*channels+c]) / float64(channels)
+		}
+	}
+	return &Sample{
+		Data:       data,
+		SampleRate: sr,
+		RootNote:   root,
+		LoopStart:  -1,
+		LoopEnd:    -1,
+	}, nil
+}
+
+// LoadSample reads a sample from a wave file, the root note and loop are taken from the
+// smpl chunk when the file has one, otherwise the root note is middle C (60)
+func LoadSample(file string) (*Sample, error) {
+	wav, err := wave.ReadWaveFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSample(wav.Frames, wav.NumChannels, wav.SampleRate, 60)
+	if err != nil {
+		return nil, err
+	}
+	if wav.Sampler != nil {
+		s.RootNote = wav.Sampler.UnityNote
+		s.Tune = float64(uint32(wav.Sampler.PitchFraction)) / (1 << 32) * 100
+		if len(wav.Sampler.Loops) > 0 {
+			s.LoopStart = wav.Sampler.Loops[0].Start
+			s.LoopEnd = wav.Sampler.Loops[0].End
+		}
+	}
+	return s, nil
+}
+
+// looped returns true when the sample has a valid loop
+func (s *Sample) looped() bool {
+	return s.LoopStart >= 0 && s.LoopEnd > s.LoopStart && s.LoopEnd < len(s.Data)
+}
+
+// Zone maps a sample to a range of keys and velocities, both ranges are inclusive
+type Zone struct {
+	Sample       *Sample
+	LowKey       int
+	HighKey      int
+	LowVelocity  float64
+	HighVelocity float64
+}
+
+// Sampler is an Instrument playing samples repitched to the notes it receives.
+// All zones containing a note and its velocity are played together.
+type Sampler struct {
+	Zones   []Zone
+	Release float64 // fade out time in seconds after a note off
+	Tuning  func(note int) float64
+
+	sr     float64
+	voices []*sampleVoice
+}
+
+// sampleVoice is a single sample being played
+type sampleVoice struct {
+	sample   *Sample
+	note     int
+	pos      float64
+	rate     float64 // frames of the sample to advance per output frame
+	velocity float64
+	amp      float64
+	fade     float64 // amplitude decrease per frame once released
+	released bool
+}
+
+// NewSampler creates a sampler from a set of zones
+func NewSampler(sr int, zones ...Zone) (*Sampler, error) {
+	for _, z := range zones {
+		if z.Sample == nil || len(z.Sample.Data) == 0 {
+			return nil, errors.New("Every zone needs a sample")
+		}
+		if z.LowKey > z.HighKey || z.LowVelocity > z.HighVelocity {
+			return nil, errors.New("Zone ranges should not be reversed")
+		}
+	}
+	return &Sampler{
+		Zones:   zones,
+		Release: .05,
+		Tuning:  MidiToFrequency,
+		sr:      float64(sr),
+	}, nil
+}
+
+// AddZone maps a sample to all velocities of a key range
+func (s *Sampler) AddZone(sample *Sample, low, high int) {
+	s.Zones = append(s.Zones, Zone{
+		Sample:       sample,
+		LowKey:       low,
+		HighKey:      high,
+		HighVelocity: 1,
+	})
+}
+
+// NoteOn starts every zone matching the note and velocity in the range [0;1]
+func (s *Sampler) NoteOn(note int, velocity float64) {
+	for _, z := range s.Zones {
+		if note < z.LowKey || note > z.HighKey || velocity < z.LowVelocity || velocity > z.HighVelocity {
+			continue
+		}
+		root := s.Tuning(z.Sample.RootNote) * math.Pow(2, z.Sample.Tune/1200)
+		s.voices = append(s.voices, &sampleVoice{
+			sample:   z.Sample,
+			note:     note,
+			rate:     s.Tuning(note) / root * float64(z.Sample.SampleRate) / s.sr,
+			velocity: velocity,
+			amp:      1,
+		})
+	}
+}
+
+// NoteOff releases all samples playing the note
+func (s *Sampler) NoteOff(note int) {
+	fade := 1.0
+	if s.Release > 0 {
+		fade = 1 / (s.Release * s.sr)
+	}
+	for _, v := range s.voices {
+		if v.note == note && !v.released {
+			v.released = true
+			v.fade = fade
+		}
+	}
+}
+
+// Active returns true while any sample is playing
+func (s *Sampler) Active() bool {
+	return len(s.voices) > 0
+}
+
+// Tick returns the next sample of all playing notes mixed together
+func (s *Sampler) Tick() float64 {
+	out := 0.0
+	playing := s.voices[:0]
+	for _, v := range s.voices {
+		out += v.tick()
+		if v.amp > 0 && (v.sample.looped() || v.pos < float64(len(v.sample.Data)-1)) {
+			playing = append(playing, v)
+		}
+	}
+	for i := len(playing); i < len(s.voices); i++ {
+		s.voices[i] = nil
+	}
+	s.voices = playing
+	return out
+}
+
+// tick reads the sample at the current position using linear interpolation
+func (v *sampleVoice) tick() float64 {
+	data := v.sample.Data
+	i := int(v.pos)
+	if i >= len(data) {
+		return 0
+	}
+	next := 0.0
+	if i+1 < len(data) {
+		next = data[i+1]
+	}
+	if v.sample.looped() && i == v.sample.LoopEnd {
+		next = data[v.sample.LoopStart]
+	}
+	frac := v.pos - float64(i)
+	out := (data[i] + frac*(next-data[i])) * v.amp * v.velocity
+
+	v.pos += v.rate
+	if v.sample.looped() {
+		length := float64(v.sample.LoopEnd - v.sample.LoopStart + 1)
+		for v.pos >= float64(v.sample.LoopEnd+1) {
+			v.pos -= length
+		}
+	}
+	if v.released {
+		v.amp = math.Max(0, v.amp-v.fade)
+	}
+	return out
+}
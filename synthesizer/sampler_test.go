@@ -0,0 +1,117 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// sineSample creates a sample of a sine wave at the frequency of its root note
+func sineSample(sr, root int, duration float64) *synth.Sample {
+	freq := synth.MidiToFrequency(root)
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	for i := range frames {
+		frames[i] = wave.Frame(math.Sin(2 * math.Pi * freq * float64(i) / float64(sr)))
+	}
+	s, _ := synth.NewSample(frames, 1, sr, root)
+	return s
+}
+
+func TestSamplerPitch(t *testing.T) {
+	sr := 8000
+	sample := sineSample(sr, 69, 1)
+	s, err := synth.NewSampler(sr)
+	if err != nil {
+		t.Fatalf("Should be able to create sampler: %v", err)
+	}
+	s.AddZone(sample, 0, 127)
+
+	// an octave up should play twice as fast
+	s.NoteOn(81, 1)
+	frames := make([]wave.Frame, sr/4)
+	for i := range frames {
+		frames[i] = wave.Frame(s.Tick())
+	}
+	period := float64(sr) / 880
+	best, bestLag := 0.0, 0
+	for lag := 4; lag < 20; lag++ {
+		if c := autocorrelation(frames, lag); c > best {
+			best, bestLag = c, lag
+		}
+	}
+	if math.Abs(float64(bestLag)-period) > 1 {
+		t.Fatalf("Expected a period of %v samples, got %v", period, bestLag)
+	}
+
+	// the sample is 1 second at the root, so half a second an octave up
+	for i := 0; i < sr/2; i++ {
+		s.Tick()
+	}
+	if s.Active() {
+		t.Fatal("Expected the sample to have ended")
+	}
+}
+
+func TestSamplerLoop(t *testing.T) {
+	sr := 8000
+	sample := sineSample(sr, 69, .1)
+	sample.LoopStart, sample.LoopEnd = 400, 799
+	s, _ := synth.NewSampler(sr)
+	s.AddZone(sample, 0, 127)
+
+	s.NoteOn(69, 1)
+	for i := 0; i < sr; i++ {
+		s.Tick()
+	}
+	if !s.Active() {
+		t.Fatal("Expected a looped sample to keep playing while held")
+	}
+	s.NoteOff(69)
+	for i := 0; i < sr; i++ {
+		s.Tick()
+	}
+	if s.Active() {
+		t.Fatal("Expected the sample to stop after its release")
+	}
+}
+
+func TestSamplerZones(t *testing.T) {
+	sr := 8000
+	soft, loud := sineSample(sr, 60, .1), sineSample(sr, 60, .1)
+	s, err := synth.NewSampler(sr,
+		synth.Zone{Sample: soft, LowKey: 48, HighKey: 72, LowVelocity: 0, HighVelocity: .5},
+		synth.Zone{Sample: loud, LowKey: 48, HighKey: 72, LowVelocity: .5, HighVelocity: 1},
+	)
+	if err != nil {
+		t.Fatalf("Should be able to create sampler: %v", err)
+	}
+
+	tests := []struct {
+		note     int
+		velocity float64
+		active   bool
+	}{
+		{60, .2, true},
+		{60, .9, true},
+		{40, .9, false},
+		{80, .2, false},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			s.NoteOn(test.note, test.velocity)
+			if s.Active() != test.active {
+				t.Fatalf("Expected active %v for note %v", test.active, test.note)
+			}
+			s.NoteOff(test.note)
+			for s.Active() {
+				s.Tick()
+			}
+		})
+	}
+
+	if _, err := synth.NewSampler(sr, synth.Zone{}); err == nil {
+		t.Fatal("Expected an error for a zone without a sample")
+	}
+}
@@ -0,0 +1,168 @@
+package synthesizer
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// NoteEvent is a note played at a point in time
+type NoteEvent struct {
+	Time     float64 // start of the note in seconds
+	Duration float64 // in seconds
+	Note     int     // MIDI note
+	Velocity float64 // in the range [0;1]
+}
+
+// Instrument is anything that can play MIDI notes, such as Polyphony
+type Instrument interface {
+	NoteOn(note int, velocity float64)
+	NoteOff(note int)
+	Tick() float64
+}
+
+// noteTrigger starts or stops the note of an event at a frame
+type noteTrigger struct {
+	frame int
+	on    bool
+	event NoteEvent
+}
+
+// eventTriggers returns the note-ons and note-offs of the events in the order they are played
+func eventTriggers(events []NoteEvent, sr int) []noteTrigger {
+	triggers := make([]noteTrigger, 0, 2*len(events))
+	for _, e := range events {
+		start := int(math.Round(e.Time * float64(sr)))
+		end := int(math.Round((e.Time + e.Duration) * float64(sr)))
+		triggers = append(triggers, noteTrigger{start, true, e}, noteTrigger{end, false, e})
+	}
+	// at the same frame, note-offs go first so that repeated notes are retriggered
+	sort.SliceStable(triggers, func(i, j int) bool {
+		if triggers[i].frame != triggers[j].frame {
+			return triggers[i].frame < triggers[j].frame
+		}
+		return !triggers[i].on && triggers[j].on
+	})
+	return triggers
+}
+
+// RenderEvents plays the note events on the instrument and returns duration seconds of audio
+func RenderEvents(events []NoteEvent, inst Instrument, sr int, duration float64) []wave.Frame {
+	triggers := eventTriggers(events, sr)
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	next := 0
+	for i := range frames {
+		for ; next < len(triggers) && triggers[next].frame <= i; next++ {
+			t := triggers[next]
+			if t.on {
+				inst.NoteOn(t.event.Note, t.event.Velocity)
+			} else {
+				inst.NoteOff(t.event.Note)
+			}
+		}
+		frames[i] = wave.Frame(inst.Tick())
+	}
+	return frames
+}
+
+// Step is a single step of a sequencer pattern
+type Step struct {
+	Note        int
+	Velocity    float64
+	Gate        float64 // length of the note as a fraction of the step
+	Probability float64 // chance of the step being played, in the range [0;1]
+	Rest        bool
+}
+
+// NewStep creates a step which is always played, with the note lasting half a step
+func NewStep(note int, velocity float64) Step {
+	return Step{
+		Note:        note,
+		Velocity:    velocity,
+		Gate:        .5,
+		Probability: 1,
+	}
+}
+
+// RestStep creates a step without a note
+func RestStep() Step {
+	return Step{Rest: true}
+}
+
+// Pattern is a sequence of steps on a grid
+type Pattern struct {
+	Steps    []Step
+	Division NoteDivision // length of every step
+}
+
+// Length returns the duration of the pattern in beats
+func (p Pattern) Length() float64 {
+	return float64(len(p.Steps)) * float64(p.Division)
+}
+
+// Sequencer plays a chain of patterns at a tempo
+type Sequencer struct {
+	BPM      float64
+	Swing    float64 // fraction of a step by which every second step is delayed
+	Patterns []Pattern
+
+	rnd *rand.Rand
+}
+
+// NewSequencer creates a sequencer playing the patterns one after the other.
+// The seed is used to decide which steps are played when they have a probability below 1.
+func NewSequencer(bpm float64, seed int64, patterns ...Pattern) *Sequencer {
+	return &Sequencer{
+		BPM:      bpm,
+		Patterns: patterns,
+		rnd:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Length returns the duration of the chain of patterns in seconds
+func (s *Sequencer) Length() float64 {
+	beats := 0.0
+	for _, p := range s.Patterns {
+		beats += p.Length()
+	}
+	return beats * 60 / s.BPM
+}
+
+// Events returns the notes of the chain of patterns, repeated loops times
+func (s *Sequencer) Events(loops int) []NoteEvent {
+	events := []NoteEvent{}
+	beat := 0.0
+	step := 0
+	for l := 0; l < loops; l++ {
+		for _, p := range s.Patterns {
+			length := float64(p.Division)
+			for _, st := range p.Steps {
+				start := beat
+				if step%2 == 1 {
+					start += s.Swing * length
+				}
+				beat += length
+				step++
+
+				if st.Rest || s.rnd.Float64() >= st.Probability {
+					continue
+				}
+				events = append(events, NoteEvent{
+					Time:     start * 60 / s.BPM,
+					Duration: st.Gate * length * 60 / s.BPM,
+					Note:     st.Note,
+					Velocity: st.Velocity,
+				})
+			}
+		}
+	}
+	return events
+}
+
+// Render plays the patterns loops times on the instrument, followed by tail seconds
+// to let the last notes ring out
+func (s *Sequencer) Render(inst Instrument, sr, loops int, tail float64) []wave.Frame {
+	return RenderEvents(s.Events(loops), inst, sr, float64(loops)*s.Length()+tail)
+}
@@ -0,0 +1,78 @@
+package synthesizer_test
+
+import (
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+// recorder is an instrument logging the frames at which notes start and stop
+type recorder struct {
+	frame int
+	ons   map[int][]int
+	offs  map[int][]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{ons: map[int][]int{}, offs: map[int][]int{}}
+}
+
+func (r *recorder) NoteOn(note int, velocity float64) { r.ons[note] = append(r.ons[note], r.frame) }
+func (r *recorder) NoteOff(note int)                  { r.offs[note] = append(r.offs[note], r.frame) }
+func (r *recorder) Tick() float64                     { r.frame++; return 0 }
+
+func TestSequencerEvents(t *testing.T) {
+	pattern := synth.Pattern{
+		Division: synth.EIGHTH,
+		Steps:    []synth.Step{synth.NewStep(60, 1), synth.RestStep(), synth.NewStep(62, .5), synth.NewStep(64, .5)},
+	}
+	seq := synth.NewSequencer(120, 1, pattern)
+	seq.Swing = .5
+
+	// an eighth note at 120 bpm lasts a quarter of a second
+	if !floatFuzzyEquals(seq.Length(), 1) {
+		t.Fatalf("Expected pattern to last a second, got %v", seq.Length())
+	}
+	events := seq.Events(2)
+	if len(events) != 6 {
+		t.Fatalf("Expected 6 events, got %v", len(events))
+	}
+	expected := []float64{0, .5, .875, 1, 1.5, 1.875}
+	for i, e := range events {
+		if !floatFuzzyEquals(e.Time, expected[i]) {
+			t.Fatalf("Expected event %v at %v but got %v", i, expected[i], e.Time)
+		}
+		if !floatFuzzyEquals(e.Duration, .125) {
+			t.Fatalf("Expected notes to last half a step, got %v", e.Duration)
+		}
+	}
+}
+
+func TestSequencerProbability(t *testing.T) {
+	step := synth.NewStep(60, 1)
+	step.Probability = .5
+	steps := make([]synth.Step, 1000)
+	for i := range steps {
+		steps[i] = step
+	}
+	seq := synth.NewSequencer(120, 1, synth.Pattern{Division: synth.SIXTEENTH, Steps: steps})
+	n := len(seq.Events(1))
+	if n < 400 || n > 600 {
+		t.Fatalf("Expected about half of the steps to be played, got %v", n)
+	}
+}
+
+func TestRenderEvents(t *testing.T) {
+	r := newRecorder()
+	events := []synth.NoteEvent{
+		{Time: 0, Duration: .5, Note: 60, Velocity: 1},
+		{Time: .5, Duration: .5, Note: 60, Velocity: 1},
+	}
+	frames := synth.RenderEvents(events, r, 10, 2)
+	if len(frames) != 20 {
+		t.Fatalf("Expected 20 frames, got %v", len(frames))
+	}
+	if len(r.ons[60]) != 2 || r.ons[60][1] != 5 || r.offs[60][0] != 5 || r.offs[60][1] != 10 {
+		t.Fatalf("Unexpected note triggers: on %v off %v", r.ons[60], r.offs[60])
+	}
+}
@@ -0,0 +1,124 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// validateSweep checks the parameters shared by the measurement signals
+func validateSweep(sr int, start, end, duration float64) error {
+	if sr <= 0 {
+		return errors.New("Samplerate should be positive")
+	}
+	if duration <= 0 {
+		return errors.New("Duration should be positive")
+	}
+	if start <= 0 || end <= 0 {
+		return errors.New("Frequencies should be positive")
+	}
+	if start >= float64(sr)/2 || end >= float64(sr)/2 {
+		return errors.New("Frequencies should be below the Nyquist frequency")
+	}
+	return nil
+}
+
+// LinearSweep generates a sine whose frequency rises (or falls) linearly from start to end
+// over the duration in seconds
+func LinearSweep(sr int, start, end, duration, amp float64) ([]wave.Frame, error) {
+	if err := validateSweep(sr, start, end, duration); err != nil {
+		return nil, err
+	}
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	for i := range frames {
+		t := float64(i) / float64(sr)
+		// the phase is the integral of the instantaneous frequency
+		phase := start*t + (end-start)*t*t/(2*duration)
+		frames[i] = wave.Frame(amp * math.Sin(tau*phase))
+	}
+	return frames, nil
+}
+
+// ExponentialSweep generates a sine whose frequency changes exponentially from start to end
+// over the duration in seconds, spending the same time on every octave
+func ExponentialSweep(sr int, start, end, duration, amp float64) ([]wave.Frame, error) {
+	if err := validateSweep(sr, start, end, duration); err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, errors.New("Start and end frequency of an exponential sweep should differ")
+	}
+	l := duration / math.Log(end/start)
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	for i := range frames {
+		t := float64(i) / float64(sr)
+		frames[i] = wave.Frame(amp * math.Sin(tau*start*l*(math.Exp(t/l)-1)))
+	}
+	return frames, nil
+}
+
+// InverseSweep returns the inverse filter of an exponential sweep: convolving a recorded
+// response to the sweep with it gives the impulse response of the system.
+// The sweep is reversed and attenuated by 6dB per octave to compensate for its pink spectrum.
+func InverseSweep(sr int, start, end, duration float64) ([]wave.Frame, error) {
+	sweep, err := ExponentialSweep(sr, start, end, duration, 1)
+	if err != nil {
+		return nil, err
+	}
+	l := duration / math.Log(end/start)
+	n := len(sweep)
+	inverse := make([]wave.Frame, n)
+	for i := range inverse {
+		t := float64(i) / float64(sr)
+		inverse[i] = sweep[n-1-i] * wave.Frame(math.Exp(-t/l))
+	}
+	return inverse, nil
+}
+
+// Impulse generates a single sample of the given amplitude followed by silence
+func Impulse(sr int, duration, amp float64) ([]wave.Frame, error) {
+	if duration <= 0 {
+		return nil, errors.New("Duration should be positive")
+	}
+	frames := make([]wave.Frame, int(duration*float64(sr)))
+	if len(frames) == 0 {
+		return nil, errors.New("Duration should be at least one sample")
+	}
+	frames[0] = wave.Frame(amp)
+	return frames, nil
+}
+
+// SteppedTones generates a sequence of sine tones from start to end spaced evenly on a
+// logarithmic scale, each lasting stepDuration seconds. Every tone fades in and out over
+// fade seconds to avoid clicks between the steps.
+func SteppedTones(sr int, start, end float64, steps int, stepDuration, fade, amp float64) ([]wave.Frame, error) {
+	if err := validateSweep(sr, start, end, stepDuration); err != nil {
+		return nil, err
+	}
+	if steps < 1 {
+		return nil, errors.New("Need at least one step")
+	}
+	if fade < 0 || 2*fade > stepDuration {
+		return nil, errors.New("Fade should fit twice within a step")
+	}
+	n := int(stepDuration * float64(sr))
+	nfade := int(fade * float64(sr))
+	frames := make([]wave.Frame, 0, n*steps)
+	for s := 0; s < steps; s++ {
+		freq := start
+		if steps > 1 {
+			freq = start * math.Pow(end/start, float64(s)/float64(steps-1))
+		}
+		for i := 0; i < n; i++ {
+			gain := 1.0
+			if i < nfade {
+				gain = .5 - .5*math.Cos(math.Pi*float64(i)/float64(nfade))
+			} else if i >= n-nfade {
+				gain = .5 - .5*math.Cos(math.Pi*float64(n-1-i)/float64(nfade))
+			}
+			frames = append(frames, wave.Frame(amp*gain*math.Sin(tau*freq*float64(i)/float64(sr))))
+		}
+	}
+	return frames, nil
+}
@@ -0,0 +1,85 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// zeroCrossingFreq estimates the frequency around a frame from the zero crossings nearby
+func zeroCrossingFreq(frames []wave.Frame, sr, at, width int) float64 {
+	crossings := 0
+	for i := at - width/2; i < at+width/2; i++ {
+		if (frames[i] < 0) != (frames[i+1] < 0) {
+			crossings++
+		}
+	}
+	return float64(crossings) / 2 / (float64(width) / float64(sr))
+}
+
+func TestSweeps(t *testing.T) {
+	sr := 48000
+	tests := []struct {
+		sweep    func(int, float64, float64, float64, float64) ([]wave.Frame, error)
+		expected func(t float64) float64 // frequency at time t
+	}{
+		{synth.LinearSweep, func(t float64) float64 { return 100 + 9900*t/2 }},
+		{synth.ExponentialSweep, func(t float64) float64 { return 100 * math.Pow(100, t/2) }},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			frames, err := test.sweep(sr, 100, 10000, 2, 1)
+			if err != nil {
+				t.Fatalf("Should be able to create sweep: %v", err)
+			}
+			if len(frames) != 2*sr {
+				t.Fatalf("Expected %v frames, got %v", 2*sr, len(frames))
+			}
+			for _, time := range []float64{.5, 1, 1.5} {
+				freq := zeroCrossingFreq(frames, sr, int(time*float64(sr)), sr/50)
+				if e := test.expected(time); math.Abs(freq-e)/e > .05 {
+					t.Fatalf("Expected %vHz at %vs, got %v", e, time, freq)
+				}
+			}
+		})
+	}
+
+	if _, err := synth.ExponentialSweep(sr, 0, 1000, 1, 1); err == nil {
+		t.Fatal("Expected an error for a sweep starting at 0Hz")
+	}
+	if _, err := synth.LinearSweep(sr, 100, 30000, 1, 1); err == nil {
+		t.Fatal("Expected an error for a sweep above Nyquist")
+	}
+}
+
+func TestSteppedTones(t *testing.T) {
+	sr := 8000
+	frames, err := synth.SteppedTones(sr, 100, 1600, 5, .5, .01, 1)
+	if err != nil {
+		t.Fatalf("Should be able to create tones: %v", err)
+	}
+	if len(frames) != 5*sr/2 {
+		t.Fatalf("Expected %v frames, got %v", 5*sr/2, len(frames))
+	}
+	for s, expected := range []float64{100, 200, 400, 800, 1600} {
+		freq := zeroCrossingFreq(frames, sr, s*sr/2+sr/4, sr/5)
+		if math.Abs(freq-expected)/expected > .05 {
+			t.Fatalf("Expected step %v at %vHz, got %v", s, expected, freq)
+		}
+	}
+	if frames[0] != 0 || math.Abs(float64(frames[sr/2-1])) > .01 {
+		t.Fatal("Expected the steps to fade in and out")
+	}
+}
+
+func TestImpulse(t *testing.T) {
+	frames, err := synth.Impulse(100, 1, .5)
+	if err != nil {
+		t.Fatalf("Should be able to create impulse: %v", err)
+	}
+	if len(frames) != 100 || frames[0] != .5 || peak(frames[1:]) != 0 {
+		t.Fatal("Expected a single sample followed by silence")
+	}
+}
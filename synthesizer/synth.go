@@ -67,8 +67,8 @@ func ADSR(maxamp, duration, attacktime, decaytime, sus, releasetime, controlrate
 
 // NoteToFrequency turns a given note & octave into a frequency
 // using Equal-Tempered tuning with reference pitch = A440
+// Other tuning systems are available through the Tuning interface.
 func NoteToFrequency(note string, octave int) float64 {
-	// TODO: Allow for tuning systems other than Equal-Tempered A440?
 	// clean the input
 	note = strings.ToLower(strings.TrimSpace(note))
 	ni := noteIndex[note]
@@ -0,0 +1,327 @@
+package synthesizer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Seeker is implemented by anything that can jump to a time in seconds
+type Seeker interface {
+	Seek(time float64)
+}
+
+// Track is a part of a song which follows the transport, such as a sequenced instrument.
+// Process overwrites the frames with the next block of the track.
+type Track interface {
+	Processor
+	Seeker
+}
+
+// Transport is the timeline of a song. It mixes its tracks and runs the master processors
+// block by block, so an offline render and a real-time stream play exactly the same.
+// Tracks, and master processors which are Seekers, are moved along whenever the transport
+// starts, seeks or loops.
+type Transport struct {
+	Tempo    *breakpoint.TempoMap
+	Channels int
+	Tracks   []Track
+	Master   []Processor // applied to the mix of the tracks
+
+	sr        int
+	position  int64 // samples per channel
+	playing   bool
+	loopStart int64
+	loopEnd   int64 // the loop is off when it ends at or before its start
+	mix       []wave.Frame
+	mu        sync.Mutex
+}
+
+// NewTransport creates a stopped transport at the start of the song
+func NewTransport(sr, channels int, tempo *breakpoint.TempoMap) (*Transport, error) {
+	if sr <= 0 {
+		return nil, errors.New("Sample rate should be positive")
+	}
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	if tempo == nil {
+		return nil, errors.New("Need a tempo map")
+	}
+	return &Transport{
+		Tempo:    tempo,
+		Channels: channels,
+		sr:       sr,
+	}, nil
+}
+
+// SampleRate returns the sample rate of the timeline
+func (t *Transport) SampleRate() int {
+	return t.sr
+}
+
+// Play starts the transport from its current position
+func (t *Transport) Play() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.playing {
+		t.seek(t.position)
+		t.playing = true
+	}
+}
+
+// Stop pauses the transport, which then outputs silence
+func (t *Transport) Stop() {
+	t.mu.Lock()
+	t.playing = false
+	t.mu.Unlock()
+}
+
+// Playing reports whether the transport is running
+func (t *Transport) Playing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.playing
+}
+
+// SeekSample moves the transport to a sample, counted per channel
+func (t *Transport) SeekSample(sample int64) error {
+	if sample < 0 {
+		return errors.New("Can't seek before the start")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seek(sample)
+	return nil
+}
+
+// SeekBeat moves the transport to the sample at which a beat is played
+func (t *Transport) SeekBeat(beat float64) error {
+	return t.SeekSample(int64(t.Tempo.Sample(beat, t.sr)))
+}
+
+// seek moves the position and everything following the transport
+func (t *Transport) seek(sample int64) {
+	t.position = sample
+	time := float64(sample) / float64(t.sr)
+	for _, tr := range t.Tracks {
+		tr.Seek(time)
+	}
+	for _, p := range t.Master {
+		if s, ok := p.(Seeker); ok {
+			s.Seek(time)
+		}
+	}
+}
+
+// SetLoop repeats the samples from start up to end once the transport reaches end
+func (t *Transport) SetLoop(start, end int64) error {
+	if start < 0 || end <= start {
+		return errors.New("A loop should end after it starts")
+	}
+	t.mu.Lock()
+	t.loopStart, t.loopEnd = start, end
+	t.mu.Unlock()
+	return nil
+}
+
+// SetLoopBeats repeats the beats from start up to end
+func (t *Transport) SetLoopBeats(start, end float64) error {
+	return t.SetLoop(int64(t.Tempo.Sample(start, t.sr)), int64(t.Tempo.Sample(end, t.sr)))
+}
+
+// ClearLoop lets the transport play on past the end of the loop
+func (t *Transport) ClearLoop() {
+	t.mu.Lock()
+	t.loopStart, t.loopEnd = 0, 0
+	t.mu.Unlock()
+}
+
+// Loop returns the start and end of the loop, and whether looping is on
+func (t *Transport) Loop() (start, end int64, on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.loopStart, t.loopEnd, t.loopEnd > t.loopStart
+}
+
+// Position returns the current sample, counted per channel
+func (t *Transport) Position() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.position
+}
+
+// Seconds returns the current position in seconds
+func (t *Transport) Seconds() float64 {
+	return float64(t.Position()) / float64(t.sr)
+}
+
+// Beat returns the current position in beats
+func (t *Transport) Beat() float64 {
+	return t.Tempo.Beats(t.Seconds())
+}
+
+// BarBeat returns the current bar, counting from bar 0, and the beat within that bar
+func (t *Transport) BarBeat() (bar int, beat float64) {
+	return t.Tempo.BarAt(t.Beat())
+}
+
+// Process overwrites the frames with the next block of the song, or with silence when stopped.
+// A block crossing the end of the loop continues from its start at the exact sample.
+func (t *Transport) Process(frames []wave.Frame) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range frames {
+		frames[i] = 0
+	}
+	if !t.playing {
+		return
+	}
+	n := int64(len(frames) / t.Channels)
+	for done := int64(0); done < n; {
+		chunk := n - done
+		looping := t.loopEnd > t.loopStart && t.position < t.loopEnd
+		if looping && t.position+chunk > t.loopEnd {
+			chunk = t.loopEnd - t.position
+		}
+		t.render(frames[done*int64(t.Channels) : (done+chunk)*int64(t.Channels)])
+		t.position += chunk
+		done += chunk
+		if looping && t.position == t.loopEnd {
+			t.seek(t.loopStart)
+		}
+	}
+}
+
+// render mixes the tracks into out and runs the master processors over it
+func (t *Transport) render(out []wave.Frame) {
+	if cap(t.mix) < len(out) {
+		t.mix = make([]wave.Frame, len(out))
+	}
+	mix := t.mix[:len(out)]
+	for _, tr := range t.Tracks {
+		for i := range mix {
+			mix[i] = 0
+		}
+		tr.Process(mix)
+		wave.MixInto(out, mix, 1)
+	}
+	for _, p := range t.Master {
+		p.Process(out)
+	}
+}
+
+// Fill processes out and returns its length, so the transport can be used as the callback
+// of a real-time stream
+func (t *Transport) Fill(out []wave.Frame) int {
+	t.Process(out)
+	return len(out)
+}
+
+// Render plays duration seconds of the song from the current position, in blocks of
+// blockSize samples per channel as a real-time stream would
+func (t *Transport) Render(duration float64, blockSize int) []wave.Frame {
+	frames, _ := t.RenderCtx(context.Background(), duration, blockSize)
+	return frames
+}
+
+// RenderCtx is Render which stops with the error of the context once it is done, checked
+// between blocks
+func (t *Transport) RenderCtx(ctx context.Context, duration float64, blockSize int) ([]wave.Frame, error) {
+	return t.RenderWithProgress(ctx, duration, blockSize, nil)
+}
+
+// RenderWithProgress is RenderCtx which reports the frames rendered out of all of them after
+// every block, when progress isn't nil
+func (t *Transport) RenderWithProgress(ctx context.Context, duration float64, blockSize int, progress wave.Progress) ([]wave.Frame, error) {
+	if blockSize < 1 {
+		blockSize = 1
+	}
+	t.Play()
+	frames := make([]wave.Frame, int(duration*float64(t.sr))*t.Channels)
+	step := blockSize * t.Channels
+	for start := 0; start < len(frames); start += step {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := start + step
+		if end > len(frames) {
+			end = len(frames)
+		}
+		t.Process(frames[start:end])
+		if progress != nil {
+			progress(int64(end), int64(len(frames)))
+		}
+	}
+	return frames, nil
+}
+
+// EventTrack plays note events on an instrument along the transport, such as the events of a Sequencer
+type EventTrack struct {
+	Instrument Instrument
+	Channels   int
+
+	sr       int
+	triggers []noteTrigger
+	next     int
+	position int
+	held     map[int]int // amount of note-ons without a note-off, by note
+}
+
+// NewEventTrack creates a track playing the events, with their times in seconds, on an instrument
+func NewEventTrack(inst Instrument, events []NoteEvent, sr, channels int) (*EventTrack, error) {
+	if inst == nil {
+		return nil, errors.New("A track needs an instrument")
+	}
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	return &EventTrack{
+		Instrument: inst,
+		Channels:   channels,
+		sr:         sr,
+		triggers:   eventTriggers(events, sr),
+		held:       map[int]int{},
+	}, nil
+}
+
+// Seek releases the notes being played and moves to a time in seconds.
+// Notes which started before that time are not played.
+func (e *EventTrack) Seek(time float64) {
+	for note, n := range e.held {
+		for ; n > 0; n-- {
+			e.Instrument.NoteOff(note)
+		}
+		delete(e.held, note)
+	}
+	e.position = int(math.Round(time * float64(e.sr)))
+	e.next = 0
+	for e.next < len(e.triggers) && e.triggers[e.next].frame < e.position {
+		e.next++
+	}
+}
+
+// Process writes the instrument to every channel of the frames
+func (e *EventTrack) Process(frames []wave.Frame) {
+	for i := 0; i+e.Channels <= len(frames); i += e.Channels {
+		for ; e.next < len(e.triggers) && e.triggers[e.next].frame <= e.position; e.next++ {
+			t := e.triggers[e.next]
+			if t.on {
+				e.Instrument.NoteOn(t.event.Note, t.event.Velocity)
+				e.held[t.event.Note]++
+			} else if e.held[t.event.Note] > 0 {
+				e.Instrument.NoteOff(t.event.Note)
+				e.held[t.event.Note]--
+			}
+		}
+		v := wave.Frame(e.Instrument.Tick())
+		for c := 0; c < e.Channels; c++ {
+			frames[i+c] = v
+		}
+		e.position++
+	}
+}
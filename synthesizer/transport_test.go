@@ -0,0 +1,160 @@
+package synthesizer_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/DylanMeeus/GoAudio/breakpoint"
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// rampTrack writes the sample it is at to every channel
+type rampTrack struct {
+	sr, channels int
+	position     int
+}
+
+func (r *rampTrack) Seek(time float64) { r.position = int(math.Round(time * float64(r.sr))) }
+
+func (r *rampTrack) Process(frames []wave.Frame) {
+	for i := 0; i < len(frames); i += r.channels {
+		for c := 0; c < r.channels; c++ {
+			frames[i+c] = wave.Frame(r.position)
+		}
+		r.position++
+	}
+}
+
+func newTransport(t *testing.T, sr, channels int) *synth.Transport {
+	tempo, _ := breakpoint.NewTempoMap(120)
+	tr, err := synth.NewTransport(sr, channels, tempo)
+	if err != nil {
+		t.Fatalf("Should be able to create transport: %v", err)
+	}
+	return tr
+}
+
+func TestTransportLoop(t *testing.T) {
+	tr := newTransport(t, 10, 2)
+	tr.Tracks = append(tr.Tracks, &rampTrack{sr: 10, channels: 2}, &rampTrack{sr: 10, channels: 2})
+
+	stopped := make([]wave.Frame, 4)
+	tr.Process(stopped)
+	for _, f := range stopped {
+		if f != 0 {
+			t.Fatalf("Expected silence while stopped, got %v", stopped)
+		}
+	}
+
+	if err := tr.SetLoop(2, 5); err != nil {
+		t.Fatalf("Should be able to set loop: %v", err)
+	}
+	// blocks of 3 samples cross the end of the loop halfway
+	frames := tr.Render(.8, 3)
+	expected := []float64{0, 1, 2, 3, 4, 2, 3, 4}
+	if len(frames) != 2*len(expected) {
+		t.Fatalf("Expected %v frames, got %v", 2*len(expected), len(frames))
+	}
+	for i, e := range expected {
+		// both tracks are mixed
+		if float64(frames[2*i]) != 2*e || float64(frames[2*i+1]) != 2*e {
+			t.Fatalf("Expected %v at sample %v, got %v", 2*e, i, frames[2*i:2*i+2])
+		}
+	}
+	if tr.Position() != 2 {
+		t.Fatalf("Expected transport back at the start of the loop, got %v", tr.Position())
+	}
+
+	if err := tr.SetLoop(5, 5); err == nil {
+		t.Fatal("Expected an error for an empty loop")
+	}
+	tr.ClearLoop()
+	frames = tr.Render(.4, 3)
+	if frames[len(frames)-1] != 10 {
+		t.Fatalf("Expected transport to play past the loop, got %v", frames)
+	}
+}
+
+func TestTransportPosition(t *testing.T) {
+	tr := newTransport(t, 100, 1)
+	if err := tr.SeekBeat(6); err != nil {
+		t.Fatalf("Should be able to seek: %v", err)
+	}
+	// at 120 bpm a beat lasts half a second
+	if tr.Position() != 300 || !floatFuzzyEquals(tr.Seconds(), 3) {
+		t.Fatalf("Expected to be at 3 seconds, got sample %v", tr.Position())
+	}
+	bar, beat := tr.BarBeat()
+	if bar != 1 || !floatFuzzyEquals(beat, 2) {
+		t.Fatalf("Expected beat 2 of bar 1, got %v of %v", beat, bar)
+	}
+	if err := tr.SeekSample(-1); err == nil {
+		t.Fatal("Expected an error when seeking before the start")
+	}
+}
+
+func TestTransportEvents(t *testing.T) {
+	tr := newTransport(t, 10, 1)
+	rec := newRecorder()
+	track, err := synth.NewEventTrack(rec, []synth.NoteEvent{{Time: .2, Duration: .5, Note: 60, Velocity: 1}}, 10, 1)
+	if err != nil {
+		t.Fatalf("Should be able to create track: %v", err)
+	}
+	tr.Tracks = append(tr.Tracks, track)
+
+	tr.Render(.4, 4)
+	if len(rec.ons[60]) != 1 || rec.ons[60][0] != 2 {
+		t.Fatalf("Expected note on at frame 2, got %v", rec.ons[60])
+	}
+	// seeking releases the note that is held
+	tr.SeekSample(0)
+	if len(rec.offs[60]) != 1 || rec.offs[60][0] != 4 {
+		t.Fatalf("Expected the note to be released on seek, got %v", rec.offs[60])
+	}
+	// the note is played again and ends once
+	tr.Render(1, 4)
+	if len(rec.ons[60]) != 2 || len(rec.offs[60]) != 2 || rec.offs[60][1] != 4+7 {
+		t.Fatalf("Expected the note to be played again, got ons %v and offs %v", rec.ons[60], rec.offs[60])
+	}
+}
+
+func TestTransportAutomation(t *testing.T) {
+	tr := newTransport(t, 4, 1)
+	tr.Tracks = append(tr.Tracks, &rampTrack{sr: 4, channels: 1})
+	fade, _ := synth.NewBreakpointSource(4, []breakpoint.Breakpoint{{Time: 0, Value: 1}, {Time: 1, Value: 0}})
+	gain, _ := synth.NewAutomated(&synth.Gain{Level: 1}, 1)
+	if err := gain.Bind("gain", fade); err != nil {
+		t.Fatalf("Should be able to bind gain: %v", err)
+	}
+	tr.Master = append(tr.Master, gain)
+
+	tr.SeekSample(2)
+	frames := tr.Render(.5, 1)
+	// the fade follows the transport to half a second
+	expected := []wave.Frame{2 * .5, 3 * .25}
+	for i := range expected {
+		if !floatFuzzyEquals(float64(frames[i]), float64(expected[i])) {
+			t.Fatalf("Expected %v, got %v", expected, frames)
+		}
+	}
+}
+
+func TestRenderCtx(t *testing.T) {
+	tr := newTransport(t, 10, 2)
+	tr.Tracks = append(tr.Tracks, &rampTrack{sr: 10, channels: 2})
+	frames, err := tr.RenderCtx(context.Background(), 1, 4)
+	if err != nil || len(frames) != 20 {
+		t.Fatalf("Expected 20 frames, got %v (%v)", len(frames), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tr.RenderCtx(ctx, 1, 4); err != context.Canceled {
+		t.Fatalf("Expected rendering to be cancelled, got %v", err)
+	}
+	if _, err := synth.ResampleCtx(ctx, frames, 2, 10, 20); err != context.Canceled {
+		t.Fatalf("Expected resampling to be cancelled, got %v", err)
+	}
+}
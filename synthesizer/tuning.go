@@ -0,0 +1,197 @@
+package synthesizer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// noteNames of the chromatic scale starting at C, used for MIDI note names
+var noteNames = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// Tuning turns MIDI notes into frequencies
+type Tuning interface {
+	Frequency(note int) float64
+}
+
+// EqualTemperament is the 12-tone equal-tempered tuning with an adjustable reference pitch
+type EqualTemperament struct {
+	A4 float64 // frequency of MIDI note 69, usually 440Hz
+}
+
+// Frequency returns the frequency of the MIDI note
+func (e EqualTemperament) Frequency(note int) float64 {
+	return e.A4 * math.Pow(2, float64(note-69)/12)
+}
+
+// MidiToFrequency converts a MIDI note to a frequency using equal temperament at A440
+func MidiToFrequency(note int) float64 {
+	return EqualTemperament{A4: 440}.Frequency(note)
+}
+
+// FrequencyToMidi converts a frequency to a (fractional) MIDI note using equal temperament at A440
+func FrequencyToMidi(freq float64) float64 {
+	return 69 + 12*math.Log2(freq/440)
+}
+
+// MidiToNoteName returns the name of a MIDI note such as C#4, where MIDI note 60 is C4
+func MidiToNoteName(note int) string {
+	octave := floorDiv(note, 12) - 1
+	return fmt.Sprintf("%s%d", noteNames[note-(octave+1)*12], octave)
+}
+
+// NoteNameToMidi parses a note name such as C#4 or Bb2 into a MIDI note
+func NoteNameToMidi(name string) (int, error) {
+	note, octave, err := parseNoteOctave(strings.TrimSpace(name))
+	if err != nil {
+		return 0, err
+	}
+	ni, ok := noteIndex[note]
+	if !ok {
+		return 0, fmt.Errorf("Invalid note %v", name)
+	}
+	// noteIndex counts from A, MIDI octaves start at C
+	return 12*(octave+1) + (ni+9)%12, nil
+}
+
+// Scale is a tuning described by the pitch of each degree in cents, as found in Scala files.
+// The last degree is the period of the scale (usually the octave, 1200 cents).
+type Scale struct {
+	Description   string
+	Cents         []float64
+	BaseNote      int     // MIDI note playing the first degree of the scale
+	BaseFrequency float64 // frequency of the base note
+}
+
+// NewCentsScale creates a scale from a custom table of cents, mapped so that
+// baseNote plays at baseFreq
+func NewCentsScale(cents []float64, baseNote int, baseFreq float64) (*Scale, error) {
+	if len(cents) == 0 {
+		return nil, errors.New("A scale needs at least one degree")
+	}
+	if cents[len(cents)-1] <= 0 {
+		return nil, errors.New("The period of a scale should be positive")
+	}
+	return &Scale{
+		Cents:         cents,
+		BaseNote:      baseNote,
+		BaseFrequency: baseFreq,
+	}, nil
+}
+
+// LoadScala reads a scale from a Scala (.scl) file
+func LoadScala(file string) (*Scale, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseScala(f)
+}
+
+// ParseScala parses a scale in the Scala (.scl) format.
+// The scale is mapped with its first degree on middle C (MIDI 60) at its equal-tempered pitch.
+func ParseScala(in io.Reader) (*Scale, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 2 {
+		return nil, errors.New("Scala file should contain a description and the amount of notes")
+	}
+
+	count, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid amount of notes in scala file: %v", err)
+	}
+	pitches := []float64{}
+	for _, line := range lines[2:] {
+		if line == "" {
+			continue
+		}
+		cents, err := parsePitch(firstField(line))
+		if err != nil {
+			return nil, err
+		}
+		pitches = append(pitches, cents)
+	}
+	if len(pitches) != count {
+		return nil, fmt.Errorf("Expected %v notes in scala file but found %v", count, len(pitches))
+	}
+
+	s, err := NewCentsScale(pitches, 60, MidiToFrequency(60))
+	if err != nil {
+		return nil, err
+	}
+	s.Description = lines[0]
+	return s, nil
+}
+
+// Frequency returns the frequency of the MIDI note
+func (s *Scale) Frequency(note int) float64 {
+	n := len(s.Cents)
+	degree := note - s.BaseNote
+	period := floorDiv(degree, n)
+	index := degree - period*n
+
+	cents := float64(period) * s.Cents[n-1]
+	if index > 0 {
+		cents += s.Cents[index-1]
+	}
+	return s.BaseFrequency * math.Pow(2, cents/1200)
+}
+
+// parsePitch parses a scala pitch, which is in cents when it contains a period
+// and a ratio (such as 3/2 or 2) otherwise
+func parsePitch(p string) (float64, error) {
+	if strings.Contains(p, ".") {
+		return strconv.ParseFloat(p, 64)
+	}
+	parts := strings.Split(p, "/")
+	num, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	den := 1.0
+	if len(parts) == 2 {
+		den, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if num <= 0 || den <= 0 {
+		return 0, fmt.Errorf("Invalid ratio %v", p)
+	}
+	return 1200 * math.Log2(num/den), nil
+}
+
+// firstField returns the text up to the first whitespace, scala lines may contain comments
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,101 @@
+package synthesizer_test
+
+import (
+	"strings"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	noteNameTests = []struct {
+		name string
+		midi int
+	}{
+		{"C4", 60},
+		{"A4", 69},
+		{"C#4", 61},
+		{"B3", 59},
+		{"A0", 21},
+		{"C-1", 0},
+		{"G9", 127},
+	}
+
+	// a just intonation major scale
+	justScala = `! just.scl
+!
+Just intonation major
+ 7
+!
+ 9/8
+ 5/4
+ 4/3
+ 3/2
+ 5/3
+ 15/8
+ 1200.0 ! octave
+`
+
+	// each key plays the next degree of the scale
+	scalaTests = []struct {
+		note int
+		freq float64
+	}{
+		{60, 261.63},
+		{61, 294.33},
+		{62, 327.03},
+		{64, 392.44},
+		{67, 523.25},
+		{53, 130.81},
+	}
+)
+
+func TestNoteNames(t *testing.T) {
+	for _, test := range noteNameTests {
+		t.Run("", func(t *testing.T) {
+			if name := synth.MidiToNoteName(test.midi); name != test.name {
+				t.Fatalf("Expected %v for note %v but got %v", test.name, test.midi, name)
+			}
+		})
+	}
+
+	if n, err := synth.NoteNameToMidi("bb2"); err != nil || n != 46 {
+		t.Fatalf("Expected Bb2 to be note 46, got %v (%v)", n, err)
+	}
+	if n, err := synth.NoteNameToMidi("A4"); err != nil || n != 69 {
+		t.Fatalf("Expected A4 to be note 69, got %v (%v)", n, err)
+	}
+}
+
+func TestEqualTemperament(t *testing.T) {
+	if f := synth.MidiToFrequency(69); f != 440 {
+		t.Fatalf("Expected 440Hz but got %v", f)
+	}
+	if f := (synth.EqualTemperament{A4: 432}).Frequency(81); !floatFuzzyEquals(f, 864) {
+		t.Fatalf("Expected 864Hz but got %v", f)
+	}
+	if n := synth.FrequencyToMidi(220); !floatFuzzyEquals(n, 57) {
+		t.Fatalf("Expected note 57 but got %v", n)
+	}
+}
+
+func TestScala(t *testing.T) {
+	scale, err := synth.ParseScala(strings.NewReader(justScala))
+	if err != nil {
+		t.Fatalf("Should be able to parse scala file: %v", err)
+	}
+	if scale.Description != "Just intonation major" {
+		t.Fatalf("Unexpected description %q", scale.Description)
+	}
+	for _, test := range scalaTests {
+		t.Run("", func(t *testing.T) {
+			if f := scale.Frequency(test.note); !floatFuzzyEquals(f, test.freq) {
+				t.Fatalf("Expected %v for note %v but got %v", test.freq, test.note, f)
+			}
+		})
+	}
+
+	if _, err := synth.ParseScala(strings.NewReader("broken\n 3\n 1.0\n")); err == nil {
+		t.Fatal("Expected an error for a scala file with missing notes")
+	}
+}
@@ -0,0 +1,95 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Unison stacks detuned copies of an oscillator spread across the stereo field,
+// as in the classic supersaw sound
+type Unison struct {
+	Detune float64 // distance in cents between the lowest and highest copy
+	Spread float64 // stereo width in the range [0;1], 0 puts every copy in the center
+
+	sr   float64
+	gens []Generator
+}
+
+// NewUnison creates n copies of an oscillator shape, each starting at a random phase
+func NewUnison(sr int, shape Shape, n int, seed int64) (*Unison, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	return NewUnisonFrom(sr, n, func() (Generator, error) {
+		return NewPhaseOscillator(sr, rnd.Float64(), shape)
+	})
+}
+
+// NewUnisonFrom creates n copies of any generator, newGen is called once for every copy
+func NewUnisonFrom(sr, n int, newGen func() (Generator, error)) (*Unison, error) {
+	if n < 1 {
+		return nil, errors.New("Unison needs at least one voice")
+	}
+	gens := make([]Generator, n)
+	for i := range gens {
+		g, err := newGen()
+		if err != nil {
+			return nil, err
+		}
+		gens[i] = g
+	}
+	return &Unison{
+		Detune: 20,
+		Spread: 1,
+		sr:     float64(sr),
+		gens:   gens,
+	}, nil
+}
+
+// Voices returns the amount of copies
+func (u *Unison) Voices() int {
+	return len(u.gens)
+}
+
+// position returns where copy i sits in the stack, in the range [-1;1]
+func (u *Unison) position(i int) float64 {
+	if len(u.gens) == 1 {
+		return 0
+	}
+	return 2*float64(i)/float64(len(u.gens)-1) - 1
+}
+
+// TickStereo returns the next left and right sample at the given frequency
+func (u *Unison) TickStereo(freq float64) (float64, float64) {
+	var left, right float64
+	norm := 1 / math.Sqrt(float64(len(u.gens)))
+	for i, g := range u.gens {
+		pos := u.position(i)
+		v := g.Tick(freq*math.Pow(2, pos*u.Detune/2/1200)) * norm
+		// equal power panning
+		angle := (pos*u.Spread + 1) * math.Pi / 4
+		left += v * math.Cos(angle)
+		right += v * math.Sin(angle)
+	}
+	return left, right
+}
+
+// Tick returns the next sample mixed down to mono, so a Unison can be used as a Generator.
+// Use either Tick or TickStereo, as both advance the oscillators.
+func (u *Unison) Tick(freq float64) float64 {
+	l, r := u.TickStereo(freq)
+	return (l + r) / math.Sqrt2
+}
+
+// Render returns duration seconds of interleaved stereo frames at the given frequency
+func (u *Unison) Render(freq, duration float64) []wave.Frame {
+	n := int(duration * u.sr)
+	frames := make([]wave.Frame, 2*n)
+	for i := 0; i < n; i++ {
+		l, r := u.TickStereo(freq)
+		frames[2*i] = wave.Frame(l)
+		frames[2*i+1] = wave.Frame(r)
+	}
+	return frames
+}
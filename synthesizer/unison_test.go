@@ -0,0 +1,61 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestUnisonSpread(t *testing.T) {
+	tests := []struct {
+		spread float64
+		same   bool // expect identical channels
+	}{
+		{0, true},
+		{1, false},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			u, err := synth.NewUnison(8000, synth.UPWARD_SAWTOOTH, 7, 1)
+			if err != nil {
+				t.Fatalf("Should be able to create unison: %v", err)
+			}
+			u.Spread = test.spread
+			frames := u.Render(110, 1)
+			left, right := channel(frames, 0), channel(frames, 1)
+			same := true
+			for i := range left {
+				if math.Abs(float64(left[i]-right[i])) > 1e-9 {
+					same = false
+				}
+			}
+			if same != test.same {
+				t.Fatalf("Expected identical channels to be %v", test.same)
+			}
+		})
+	}
+}
+
+func TestUnisonDetune(t *testing.T) {
+	// a single copy plays exactly at the frequency, regardless of the detune
+	u, _ := synth.NewUnison(8000, synth.SINE, 1, 1)
+	u.Detune = 50
+	mono := make([]float64, 8000)
+	for i := range mono {
+		mono[i] = u.Tick(100)
+	}
+	crossings := 0
+	for i := 1; i < len(mono); i++ {
+		if (mono[i-1] < 0) != (mono[i] < 0) {
+			crossings++
+		}
+	}
+	if crossings < 199 || crossings > 201 {
+		t.Fatalf("Expected 200 zero crossings, got %v", crossings)
+	}
+
+	if _, err := synth.NewUnison(8000, synth.SINE, 0, 1); err == nil {
+		t.Fatal("Expected an error for a unison without voices")
+	}
+}
@@ -0,0 +1,196 @@
+package synthesizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Generator is a sound source producing one sample per tick at the requested frequency
+type Generator interface {
+	Tick(freq float64) float64
+}
+
+// VoiceOscillator is one of the sound sources of a Voice
+type VoiceOscillator struct {
+	Generator
+	Detune float64 // in cents
+	Level  float64
+}
+
+// Voice is a subtractive synthesizer voice: oscillators run through a resonant filter,
+// shaped by an amplitude and a filter envelope and modulated by an LFO.
+type Voice struct {
+	Oscillators []VoiceOscillator
+	Filter      *SVF
+	AmpEnv      *Envelope
+	FilterEnv   *Envelope
+	LFO         *LFO
+	Glide       *Glide // portamento between notes, disabled when nil
+
+	Cutoff    float64 // base cutoff of the filter in Hz
+	Resonance float64
+	EnvAmount float64 // depth of the filter envelope in octaves
+	LFOPitch  float64 // depth of the vibrato in semitones
+	LFOCutoff float64 // depth of the filter modulation in octaves
+	Transpose float64 // pitch offset in semitones
+	Gain      float64 // output level
+	Velocity  bool    // scale the output by the velocity of the note
+	Legato    bool    // don't retrigger the envelopes when a note starts while another is held
+
+	sr       float64
+	freq     float64
+	velocity float64
+	gate     bool
+}
+
+// NewVoice creates a voice with an oscillator for every shape, a lowpass filter and
+// sensible default envelopes
+func NewVoice(sr int, shapes ...Shape) (*Voice, error) {
+	if len(shapes) == 0 {
+		return nil, errors.New("A voice needs at least one oscillator")
+	}
+	oscs := make([]VoiceOscillator, len(shapes))
+	for i, shape := range shapes {
+		osc, err := NewOscillator(sr, shape)
+		if err != nil {
+			return nil, err
+		}
+		oscs[i] = VoiceOscillator{
+			Generator: osc,
+			Level:     1 / float64(len(shapes)),
+		}
+	}
+	lfo, err := NewLFO(sr, SINE, 5)
+	if err != nil {
+		return nil, err
+	}
+	return &Voice{
+		Oscillators: oscs,
+		Filter:      NewSVF(sr, LOWPASS, 2000, .2),
+		AmpEnv:      NewDAHDSR(sr, 0, .01, 0, .1, .7, .3),
+		FilterEnv:   NewDAHDSR(sr, 0, .01, 0, .3, .2, .3),
+		LFO:         lfo,
+		Cutoff:      2000,
+		Resonance:   .2,
+		EnvAmount:   2,
+		Gain:        1,
+		Velocity:    true,
+		sr:          float64(sr),
+	}, nil
+}
+
+// NoteOn starts a note at the given frequency and velocity in the range [0;1]
+// When the voice is still sounding and a Glide is set, the pitch slides to the new note.
+func (v *Voice) NoteOn(freq, velocity float64) {
+	sounding := v.Active()
+	held := sounding && v.gate
+	v.freq = freq
+	v.velocity = velocity
+	v.gate = true
+	if v.Glide != nil {
+		if sounding {
+			v.Glide.Target(freq)
+		} else {
+			v.Glide.Set(freq)
+		}
+	}
+	if v.Legato && held {
+		return
+	}
+	v.AmpEnv.NoteOn()
+	v.FilterEnv.NoteOn()
+}
+
+// NoteOff releases the note
+func (v *Voice) NoteOff() {
+	v.gate = false
+	v.AmpEnv.NoteOff()
+	v.FilterEnv.NoteOff()
+}
+
+// Active returns true as long as the voice is producing sound
+func (v *Voice) Active() bool {
+	return !v.AmpEnv.Done()
+}
+
+// Frequency returns the frequency of the note being played
+func (v *Voice) Frequency() float64 {
+	return v.freq
+}
+
+// Level returns the current amplitude of the voice
+func (v *Voice) Level() float64 {
+	if v.Velocity {
+		return v.AmpEnv.Value() * v.velocity
+	}
+	return v.AmpEnv.Value()
+}
+
+// SetParam sets one of the parameters "pitch" (transpose in semitones), "cutoff", "resonance",
+// "envamount", "lfopitch", "lfocutoff" or "amp" (gain) so they can be modulated
+func (v *Voice) SetParam(name string, value float64) error {
+	switch name {
+	case "pitch":
+		v.Transpose = value
+	case "cutoff":
+		v.Cutoff = value
+	case "resonance":
+		v.Resonance = value
+	case "envamount":
+		v.EnvAmount = value
+	case "lfopitch":
+		v.LFOPitch = value
+	case "lfocutoff":
+		v.LFOCutoff = value
+	case "amp":
+		v.Gain = value
+	default:
+		return fmt.Errorf("Voice has no parameter %v", name)
+	}
+	return nil
+}
+
+// Tick returns the next sample of the voice
+func (v *Voice) Tick() float64 {
+	lfo := v.LFO.Tick()
+	freq := v.freq
+	if v.Glide != nil {
+		freq = v.Glide.Tick()
+	}
+	freq *= math.Pow(2, (lfo*v.LFOPitch+v.Transpose)/12)
+
+	out := 0.0
+	for _, osc := range v.Oscillators {
+		f := freq
+		if osc.Detune != 0 {
+			f *= math.Pow(2, osc.Detune/1200)
+		}
+		out += osc.Level * osc.Tick(f)
+	}
+
+	octaves := v.EnvAmount*v.FilterEnv.Tick() + v.LFOCutoff*lfo
+	v.Filter.Set(v.Cutoff*math.Pow(2, octaves), v.Resonance)
+	out = v.Filter.Tick(out) * v.AmpEnv.Tick() * v.Gain
+
+	if v.Velocity {
+		out *= v.velocity
+	}
+	return out
+}
+
+// Render plays a note held for gate seconds and returns duration seconds of audio
+func (v *Voice) Render(freq, velocity, gate, duration float64) []wave.Frame {
+	frames := make([]wave.Frame, int(duration*v.sr))
+	gateframes := int(gate * v.sr)
+	v.NoteOn(freq, velocity)
+	for i := range frames {
+		if i == gateframes {
+			v.NoteOff()
+		}
+		frames[i] = wave.Frame(v.Tick())
+	}
+	return frames
+}
@@ -0,0 +1,53 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+func TestVoice(t *testing.T) {
+	sr := 1000
+	voice, err := synth.NewVoice(sr, synth.SQUARE)
+	if err != nil {
+		t.Fatalf("Should be able to create voice: %v", err)
+	}
+	if voice.Active() {
+		t.Fatal("Voice should not be active before a note is played")
+	}
+
+	frames := voice.Render(50, 1, .5, 1)
+	if voice.Active() {
+		t.Fatal("Voice should have finished after the release")
+	}
+
+	peak := 0.0
+	for _, f := range frames[:sr/2] {
+		peak = math.Max(peak, math.Abs(float64(f)))
+	}
+	if peak == 0 || peak > 1.5 {
+		t.Fatalf("Expected audible output within range, got peak %v", peak)
+	}
+	if frames[len(frames)-1] != 0 {
+		t.Fatalf("Expected silence after the release, got %v", frames[len(frames)-1])
+	}
+
+	if _, err := synth.NewVoice(sr); err == nil {
+		t.Fatal("Expected an error for a voice without oscillators")
+	}
+}
+
+// TestSVF ensures the lowpass passes DC and the highpass blocks it
+func TestSVF(t *testing.T) {
+	low := synth.NewSVF(44100, synth.LOWPASS, 1000, .5)
+	high := synth.NewSVF(44100, synth.HIGHPASS, 1000, .5)
+	var l, h float64
+	for i := 0; i < 44100; i++ {
+		l = low.Tick(1)
+		h = high.Tick(1)
+	}
+	if !floatFuzzyEquals(l, 1) || !floatFuzzyEquals(h, 0) {
+		t.Fatalf("Expected lowpass to pass DC and highpass to block it, got %v and %v", l, h)
+	}
+}
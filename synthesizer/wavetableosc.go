@@ -0,0 +1,203 @@
+package synthesizer
+
+import (
+	"errors"
+	"math"
+
+	"github.com/DylanMeeus/GoAudio/wave"
+)
+
+// Interpolation determines how a value in between two table entries is calculated
+type Interpolation int
+
+// Interpolation modes for table lookups
+const (
+	TRUNCATE Interpolation = iota
+	LINEAR
+	CUBIC
+)
+
+// Wavetable holds a single-cycle waveform together with band-limited copies of it (a mipmap).
+// Each level contains half the harmonics of the previous level, so that the oscillator
+// can pick a table which does not alias at the frequency being played.
+type Wavetable struct {
+	levels   [][]float64
+	maxHarms []int // highest harmonic present in each level
+}
+
+// NewWavetable creates a mipmapped wavetable from a single cycle of arbitrary length.
+// The cycle is not modified.
+func NewWavetable(cycle []float64) (*Wavetable, error) {
+	n := len(cycle)
+	if n < 4 {
+		return nil, errors.New("A wavetable needs at least 4 samples")
+	}
+
+	original := make([]float64, n)
+	copy(original, cycle)
+
+	wt := &Wavetable{
+		levels:   [][]float64{original},
+		maxHarms: []int{n / 2},
+	}
+
+	costab := make([]float64, n)
+	sintab := make([]float64, n)
+	for i := range costab {
+		costab[i] = math.Cos(tau * float64(i) / float64(n))
+		sintab[i] = math.Sin(tau * float64(i) / float64(n))
+	}
+	// analyse the cycle once, each level is resynthesized from the same harmonics
+	re, im := harmonics(cycle, costab, sintab)
+
+	for h := n / 4; h >= 1; h /= 2 {
+		level := make([]float64, n)
+		for j := range level {
+			val := re[0]
+			for k := 1; k <= h; k++ {
+				idx := (k * j) % n
+				val += re[k]*costab[idx] + im[k]*sintab[idx]
+			}
+			level[j] = val
+		}
+		wt.levels = append(wt.levels, level)
+		wt.maxHarms = append(wt.maxHarms, h)
+	}
+	return wt, nil
+}
+
+// WavetableFromFrames creates a wavetable from interleaved frames, only the first channel is used
+func WavetableFromFrames(frames []wave.Frame, channels int) (*Wavetable, error) {
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel to create a wavetable")
+	}
+	cycle := make([]float64, 0, len(frames)/channels)
+	for i := 0; i < len(frames); i += channels {
+		cycle = append(cycle, float64(frames[i]))
+	}
+	return NewWavetable(cycle)
+}
+
+// LoadWavetable reads a single-cycle waveform from a .wav file
+func LoadWavetable(file string) (*Wavetable, error) {
+	w, err := wave.ReadWaveFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return WavetableFromFrames(w.Frames, w.NumChannels)
+}
+
+// Levels returns the amount of band-limited tables in the mipmap
+func (w *Wavetable) Levels() int {
+	return len(w.levels)
+}
+
+// levelFor returns the table with the most harmonics that stay below nyquist at the frequency
+func (w *Wavetable) levelFor(freq, sr float64) []float64 {
+	if freq == 0 {
+		return w.levels[0]
+	}
+	allowed := int(math.Ceil((sr/2)/math.Abs(freq))) - 1
+	for i, h := range w.maxHarms {
+		if h <= allowed {
+			return w.levels[i]
+		}
+	}
+	return w.levels[len(w.levels)-1]
+}
+
+// harmonics returns the cosine and sine amplitudes of the cycle for harmonics 0..n/2
+func harmonics(cycle, costab, sintab []float64) (re, im []float64) {
+	n := len(cycle)
+	re = make([]float64, n/2+1)
+	im = make([]float64, n/2+1)
+	for k := range re {
+		for j, x := range cycle {
+			idx := (k * j) % n
+			re[k] += x * costab[idx]
+			im[k] += x * sintab[idx]
+		}
+		scale := 2.0 / float64(n)
+		if k == 0 || 2*k == n {
+			scale = 1.0 / float64(n)
+		}
+		re[k] *= scale
+		im[k] *= scale
+	}
+	return
+}
+
+// WavetableOscillator plays back a user-supplied Wavetable at any frequency
+type WavetableOscillator struct {
+	Oscillator
+	Table         *Wavetable
+	Interpolation Interpolation
+	sr            float64
+	level         []float64
+}
+
+// NewWavetableOscillator creates an oscillator reading from the table.
+// The phase is expressed as a fraction of a cycle.
+func NewWavetableOscillator(sr int, t *Wavetable, phase float64) (*WavetableOscillator, error) {
+	if t == nil || len(t.levels) == 0 {
+		return nil, errors.New("Invalid table provided for wavetable oscillator")
+	}
+	return &WavetableOscillator{
+		Oscillator: Oscillator{
+			curphase: phase - math.Floor(phase),
+		},
+		Table:         t,
+		Interpolation: LINEAR,
+		sr:            float64(sr),
+		level:         t.levels[0],
+	}, nil
+}
+
+// Tick generates the next value of the waveform at the given frequency in Hz
+func (w *WavetableOscillator) Tick(freq float64) float64 {
+	if w.curfreq != freq {
+		w.curfreq = freq
+		w.incr = freq / w.sr
+		w.level = w.Table.levelFor(freq, w.sr)
+	}
+
+	val := lookup(w.level, w.curphase*float64(len(w.level)), w.Interpolation)
+
+	w.curphase += w.incr
+	w.curphase -= math.Floor(w.curphase)
+	return val
+}
+
+// BatchTick returns a slice of samples from the oscillator of the requested length
+func (w *WavetableOscillator) BatchTick(freq float64, nframes int) []float64 {
+	out := make([]float64, nframes)
+	for i := range out {
+		out[i] = w.Tick(freq)
+	}
+	return out
+}
+
+// lookup reads the table at a fractional position, wrapping around the end of the table
+func lookup(table []float64, pos float64, mode Interpolation) float64 {
+	n := len(table)
+	base := int(pos)
+	frac := pos - float64(base)
+	at := func(i int) float64 {
+		return table[((i%n)+n)%n]
+	}
+
+	switch mode {
+	case LINEAR:
+		a, b := at(base), at(base+1)
+		return a + frac*(b-a)
+	case CUBIC:
+		// 4-point Hermite interpolation
+		y0, y1, y2, y3 := at(base-1), at(base), at(base+1), at(base+2)
+		c1 := 0.5 * (y2 - y0)
+		c2 := y0 - 2.5*y1 + 2*y2 - 0.5*y3
+		c3 := 0.5*(y3-y0) + 1.5*(y1-y2)
+		return ((c3*frac+c2)*frac+c1)*frac + y1
+	default:
+		return at(base)
+	}
+}
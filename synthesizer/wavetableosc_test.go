@@ -0,0 +1,84 @@
+package synthesizer_test
+
+import (
+	"math"
+	"testing"
+
+	synth "github.com/DylanMeeus/GoAudio/synthesizer"
+)
+
+var (
+	wavetableInterpolationTests = []struct {
+		mode synth.Interpolation
+	}{
+		{synth.TRUNCATE},
+		{synth.LINEAR},
+		{synth.CUBIC},
+	}
+)
+
+// TestWavetableOscillator plays a sine cycle at a frequency where each tick lands on a table entry
+// so that every interpolation mode should reproduce the sine exactly.
+func TestWavetableOscillator(t *testing.T) {
+	size := 64
+	cycle := make([]float64, size)
+	for i := range cycle {
+		cycle[i] = math.Sin(2 * math.Pi * float64(i) / float64(size))
+	}
+	table, err := synth.NewWavetable(cycle)
+	if err != nil {
+		t.Fatalf("Should be able to create wavetable: %v", err)
+	}
+
+	sr := 6400
+	freq := float64(sr) / float64(size) // one table entry per tick
+	for _, test := range wavetableInterpolationTests {
+		t.Run("", func(t *testing.T) {
+			osc, err := synth.NewWavetableOscillator(sr, table, 0)
+			if err != nil {
+				t.Fatalf("Should be able to create oscillator: %v", err)
+			}
+			osc.Interpolation = test.mode
+			for i := 0; i < 2*size; i++ {
+				got := osc.Tick(freq)
+				if !floatFuzzyEquals(got, cycle[i%size]) {
+					t.Fatalf("Expected %v at tick %v, got %v", cycle[i%size], i, got)
+				}
+			}
+		})
+	}
+}
+
+// TestWavetableMipmap ensures that playing a note near nyquist does not use harmonics above it
+func TestWavetableMipmap(t *testing.T) {
+	size := 256
+	table, err := synth.NewWavetable(synth.SawTable(size/2, size)[:size])
+	if err != nil {
+		t.Fatalf("Should be able to create wavetable: %v", err)
+	}
+	if table.Levels() < 2 {
+		t.Fatalf("Expected multiple mipmap levels, got %v", table.Levels())
+	}
+
+	// at sr/4 only the fundamental fits below nyquist, so the output should be a pure sine
+	sr := 1024
+	osc, err := synth.NewWavetableOscillator(sr, table, 0)
+	if err != nil {
+		t.Fatalf("Should be able to create oscillator: %v", err)
+	}
+	out := osc.BatchTick(float64(sr)/4, 8)
+	for i := 0; i < 4; i++ {
+		if !floatFuzzyEquals(out[i], -out[i+2]) {
+			t.Fatalf("Expected band-limited output to be symmetric, got %v", out)
+		}
+	}
+}
+
+func TestWavetableInvalid(t *testing.T) {
+	if _, err := synth.NewWavetable([]float64{1, 2}); err == nil {
+		t.Fatal("Expected an error for a too short table")
+	}
+	if _, err := synth.NewWavetableOscillator(44100, nil, 0); err == nil {
+		t.Fatal("Expected an error for a missing table")
+	}
+}
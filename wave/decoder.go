@@ -0,0 +1,476 @@
+package wave
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+)
+
+// Decoder streams interleaved frames from an audio source without loading it entirely
+type Decoder interface {
+	Format() WaveFmt
+	// Read decodes up to len(frames) frames and returns io.EOF once the source is exhausted
+	Read(frames []Frame) (int, error)
+}
+
+// Seeker is implemented by decoders which can jump to a position, in samples per channel
+type Seeker interface {
+	SeekSample(sample int64) error
+}
+
+// Cloner is implemented by decoders which make copies of themselves sharing the source, each
+// with a position of its own, so goroutines can read different parts of one file at once
+type Cloner interface {
+	Clone() (Decoder, error)
+}
+
+// WaveDecoder streams the frames of a .wav file from an io.Reader
+type WaveDecoder struct {
+	WaveFmt
+
+	r         io.Reader
+	at        io.ReaderAt      // nil when the reader has no random access
+	order     binary.ByteOrder // big endian in RIFX files
+	start     int64            // offset of the sample data in the reader
+	size      int64            // size of the sample data in bytes, -1 for a stream of unknown length
+	remaining int64            // bytes of sample data left to read
+	buf       []byte
+	err       error // error which stopped Frames or Blocks
+}
+
+// NewDecoder reads the header of a .wav, RIFX or RF64 file up to its sample data.
+// When the reader is an io.Seeker the decoder can seek within the samples, and chunks before
+// the samples are seeked past rather than read. An io.ReaderAt also gives ReadFramesAt.
+// A data size of 0xFFFFFFFF, as written to pipes, reads the samples up to the end of the stream.
+func NewDecoder(r io.Reader) (*WaveDecoder, error) {
+	hdr := make([]byte, 12)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	if string(hdr[8:12]) != string(WaveID) {
+		return nil, ErrNotRIFF
+	}
+	d := &WaveDecoder{r: r, order: binary.LittleEndian}
+	switch string(hdr[0:4]) {
+	case string(ChunkID), "RF64":
+	case string(BigEndianChunkID):
+		d.order = binary.BigEndian
+	default:
+		return nil, ErrNotRIFF
+	}
+
+	d.at, _ = r.(io.ReaderAt)
+	offset := int64(12)
+	hasFmt := false
+	dataSize := int64(-1) // from the ds64 chunk of an RF64 file
+	for {
+		chunk := make([]byte, 8)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, fmt.Errorf("No data chunk found: %w", err)
+		}
+		offset += 8
+		id, size := string(chunk[0:4]), int64(d.order.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, ErrTruncatedChunk{ID: id, Want: 16, Got: size}
+			}
+			body, err := readChunk(r, id, size)
+			if err != nil {
+				return nil, err
+			}
+			if d.order == binary.BigEndian {
+				// readFmt reads little endian
+				swapFmt(chunk, body)
+			}
+			// readFmt expects the chunk at its usual place in the file
+			d.WaveFmt = readFmt(append(append(hdr, chunk...), body...))
+			hasFmt = true
+			logDebug(nil, "read fmt chunk", "format", d.AudioFormat, "channels", d.NumChannels,
+				"rate", d.SampleRate, "bits", d.BitsPerSample)
+		case "ds64":
+			body, err := readChunk(r, id, size)
+			if err != nil {
+				return nil, err
+			}
+			if size >= 16 {
+				dataSize = int64(binary.LittleEndian.Uint64(body[8:16]))
+			} else {
+				logDebug(nil, "ds64 chunk too short for a data size", "size", size)
+			}
+		case "data":
+			if !hasFmt {
+				return nil, errors.New("Data chunk found before the fmt chunk")
+			}
+			if err := d.checkBitDepth(); err != nil {
+				return nil, err
+			}
+			if align := d.NumChannels * d.BitsPerSample / 8; align == 0 || d.BlockAlign != align {
+				return nil, fmt.Errorf("%w: block align %v should be %v", ErrInvalidFormat, d.BlockAlign, align)
+			}
+			if size == unknownSize {
+				size = dataSize
+				if size >= 0 {
+					logDebug(nil, "using the data size of the ds64 chunk", "size", size)
+				}
+			}
+			// a truncated or crafted file can claim more samples than it has
+			if total := sourceSize(r); size >= 0 && total >= 0 && size > total-offset {
+				logDebug(nil, "data chunk runs past the end of the file", "size", size, "left", total-offset)
+				size = max(total-offset, 0)
+			}
+			d.start, d.size, d.remaining = offset, size, size
+			if size < 0 {
+				logDebug(nil, "data size unknown, reading samples to the end of the stream", "offset", offset)
+				d.remaining = math.MaxInt64
+			}
+			return d, nil
+		default:
+			logDebug(nil, "skipping chunk", "id", id, "offset", offset-8, "size", size)
+			// chunks are padded to an even size
+			if skipped, err := skip(r, size+size%2); err != nil {
+				return nil, truncated(id, size, skipped, err)
+			}
+			offset += size + size%2
+			continue
+		}
+		if size%2 == 1 {
+			if skipped, err := skip(r, 1); err != nil {
+				return nil, truncated(id, size+1, size+skipped, err)
+			}
+			size++
+		}
+		offset += size
+	}
+}
+
+// NewDecoderAt reads the header of a .wav or RF64 file from a source with random access, such
+// as an *os.File or a memory-mapped file. The decoder can seek and read frames at any position.
+func NewDecoderAt(r io.ReaderAt) (*WaveDecoder, error) {
+	size := sourceSize(r)
+	if size < 0 {
+		size = math.MaxInt64
+	}
+	return NewDecoder(io.NewSectionReader(r, 0, size))
+}
+
+// sourceSize returns the size of a file or of a reader in memory, or -1 when it can't tell
+func sourceSize(r any) int64 {
+	switch s := r.(type) {
+	case interface{ Size() int64 }:
+		return s.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := s.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return -1
+}
+
+// skip moves past n bytes of the reader, seeking when it can rather than reading them, and
+// returns the amount skipped
+func skip(r io.Reader, n int64) (int64, error) {
+	if s, ok := r.(io.Seeker); ok {
+		// a pipe is an *os.File too, but fails to seek
+		if _, err := s.Seek(n, io.SeekCurrent); err == nil {
+			return n, nil
+		}
+		logDebug(nil, "reader can't seek, reading past the chunk", "bytes", n)
+	}
+	return io.CopyN(ioutil.Discard, r, n)
+}
+
+// readChunk reads the body of a chunk. The body grows with what is read, rather than
+// trusting the size in the header.
+func readChunk(r io.Reader, id string, size int64) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) < size {
+		return nil, ErrTruncatedChunk{ID: id, Want: size, Got: int64(len(body))}
+	}
+	return body, nil
+}
+
+// truncated turns the end of the reader inside a chunk into an ErrTruncatedChunk
+func truncated(id string, want, got int64, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncatedChunk{ID: id, Want: want, Got: got}
+	}
+	return err
+}
+
+// Format returns the format of the samples
+func (d *WaveDecoder) Format() WaveFmt {
+	return d.WaveFmt
+}
+
+// Length returns the amount of samples per channel, or -1 when the stream doesn't tell
+func (d *WaveDecoder) Length() int64 {
+	if d.size < 0 {
+		return -1
+	}
+	return d.size / int64(d.BlockAlign)
+}
+
+// Read decodes up to len(frames) frames
+func (d *WaveDecoder) Read(frames []Frame) (int, error) {
+	buf, err := d.readRaw(len(frames))
+	return d.decode(frames, buf), err
+}
+
+// readRaw reads the bytes of up to count samples into the buffer of the decoder
+func (d *WaveDecoder) readRaw(count int) ([]byte, error) {
+	if d.remaining <= 0 {
+		return nil, io.EOF
+	}
+	n := int64(count * (d.BitsPerSample / 8))
+	if n > d.remaining {
+		n = d.remaining
+	}
+	if int64(cap(d.buf)) < n {
+		d.buf = make([]byte, n)
+	}
+	buf := d.buf[:n]
+	read, err := io.ReadFull(d.r, buf)
+	d.remaining -= int64(read)
+	if err == io.ErrUnexpectedEOF {
+		// a truncated file ends at the last complete sample
+		d.remaining, err = 0, nil
+	}
+	return buf[:read], err
+}
+
+// ReadFramesAt decodes up to len(frames) frames from a sample, counted per channel, without
+// moving the position of Read. It needs a reader with random access, and several goroutines
+// can read at once. It returns io.EOF when fewer frames are left.
+func (d *WaveDecoder) ReadFramesAt(frames []Frame, sample int64) (int, error) {
+	if d.at == nil {
+		return 0, errors.New("Reader does not support random access")
+	}
+	pos := sample * int64(d.BlockAlign)
+	if pos < 0 || (d.size >= 0 && pos > d.size) {
+		return 0, fmt.Errorf("Sample %v out of range", sample)
+	}
+	width := d.BitsPerSample / 8
+	n := int64(len(frames) * width)
+	if d.size >= 0 && n > d.size-pos {
+		n = d.size - pos
+	}
+	buf := acquireBytes(int(n))
+	defer releaseBytes(buf)
+	read, err := d.at.ReadAt(buf, d.start+pos)
+	if err == nil && read < len(frames)*width {
+		err = io.EOF
+	}
+	return d.decode(frames, buf[:read]), err
+}
+
+// decode converts the complete samples of buf into frames and returns their amount
+func (d *WaveDecoder) decode(frames []Frame, buf []byte) int {
+	return decodeSamples(frames, buf, d.WaveFmt, d.order)
+}
+
+// decodeSamples converts the complete samples of raw into frames and returns their amount
+func decodeSamples(frames []Frame, raw []byte, wfmt WaveFmt, order binary.ByteOrder) int {
+	width := wfmt.BitsPerSample / 8
+	count := len(raw) / width
+	switch {
+	case wfmt.isFloat() && width == 8:
+		for i := 0; i < count; i++ {
+			frames[i] = Frame(math.Float64frombits(order.Uint64(raw[8*i:])))
+		}
+	case wfmt.isFloat():
+		for i := 0; i < count; i++ {
+			frames[i] = Frame(math.Float32frombits(order.Uint32(raw[4*i:])))
+		}
+	case width == 2:
+		for i := 0; i < count; i++ {
+			frames[i] = scaleFrame(int(int16(order.Uint16(raw[2*i:]))), 16)
+		}
+	case width == 3:
+		// 24-bit samples are shifted into the upper bytes of an int32
+		high, low := 2, 0
+		if order == binary.BigEndian {
+			high, low = 0, 2
+		}
+		for i := 0; i < count; i++ {
+			b := raw[3*i : 3*i+3]
+			frames[i] = scaleFrame(int(int32(b[high])<<24|int32(b[1])<<16|int32(b[low])<<8), 32)
+		}
+	case width == 4:
+		for i := 0; i < count; i++ {
+			frames[i] = scaleFrame(int(int32(order.Uint32(raw[4*i:]))), 32)
+		}
+	}
+	return count
+}
+
+// Clone returns a decoder of the same samples which starts at the first sample. It needs a
+// reader with random access, such as an *os.File, which the clones read at the same time.
+func (d *WaveDecoder) Clone() (Decoder, error) {
+	if d.at == nil {
+		return nil, errors.New("Reader does not support random access")
+	}
+	r := io.NewSectionReader(d.at, 0, math.MaxInt64)
+	if _, err := r.Seek(d.start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	c := &WaveDecoder{WaveFmt: d.WaveFmt, r: r, at: d.at, order: d.order, start: d.start, size: d.size, remaining: d.size}
+	if d.size < 0 {
+		c.remaining = math.MaxInt64
+	}
+	return c, nil
+}
+
+// SeekSample jumps to a sample, counted per channel, when the underlying reader supports seeking
+func (d *WaveDecoder) SeekSample(sample int64) error {
+	s, ok := d.r.(io.Seeker)
+	if !ok {
+		return errors.New("Reader does not support seeking")
+	}
+	pos := sample * int64(d.BlockAlign)
+	if pos < 0 || (d.size >= 0 && pos > d.size) {
+		return fmt.Errorf("Sample %v out of range", sample)
+	}
+	if _, err := s.Seek(d.start+pos, io.SeekStart); err != nil {
+		return err
+	}
+	d.remaining = d.size - pos
+	if d.size < 0 {
+		d.remaining = math.MaxInt64
+	}
+	return nil
+}
+
+// parallelBlock is the amount of frames a worker of DecodeParallel decodes at a time
+const parallelBlock = 1 << 16
+
+// maxPreallocate is the most samples per channel DecodeCtx allocates before reading them
+const maxPreallocate = 1 << 22
+
+// DecodeParallel decodes all the frames of a .wav file at once, splitting the samples into a
+// range per worker which are decoded at the same time into one slice. On fast storage this
+// reads large files several times faster than a single decoder. The reader is shared by the
+// workers, as an *os.File can be.
+func DecodeParallel(r io.ReaderAt, workers int) ([]Frame, WaveFmt, error) {
+	return DecodeParallelCtx(context.Background(), r, workers)
+}
+
+// DecodeParallelCtx is DecodeParallel which stops with the error of the context once it is
+// done, checked by the workers between blocks
+func DecodeParallelCtx(ctx context.Context, r io.ReaderAt, workers int) ([]Frame, WaveFmt, error) {
+	if workers < 1 {
+		return nil, WaveFmt{}, errors.New("Need at least one worker")
+	}
+	d, err := NewDecoderAt(r)
+	if err != nil {
+		return nil, WaveFmt{}, err
+	}
+	length := d.Length()
+	if length < 0 {
+		return nil, d.WaveFmt, errors.New("Length of the samples is unknown")
+	}
+	if length > 0 {
+		// without the size of the source, make sure the samples are there before allocating
+		// room for them, and otherwise grow the frames as they are read
+		if _, err := d.at.ReadAt(make([]byte, 1), d.start+length*int64(d.BlockAlign)-1); err != nil {
+			frames, err := DecodeCtx(ctx, d)
+			if err != nil {
+				return nil, d.WaveFmt, err
+			}
+			return frames[:len(frames)/d.NumChannels*d.NumChannels], d.WaveFmt, nil
+		}
+	}
+	channels := int64(d.NumChannels)
+	frames := make([]Frame, length*channels)
+	if int64(workers) > length {
+		workers = int(length)
+	}
+
+	// worker i decodes the samples [starts[i];starts[i+1]), counted per channel
+	starts := make([]int64, workers+1)
+	for i := range starts {
+		starts[i] = length * int64(i) / int64(workers)
+	}
+	decoded := make([]int64, workers) // frames decoded by every worker
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			part := frames[starts[i]*channels : starts[i+1]*channels]
+			for int(decoded[i]) < len(part) {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					return
+				}
+				end := int(decoded[i]) + parallelBlock
+				if end > len(part) {
+					end = len(part)
+				}
+				n, err := d.ReadFramesAt(part[decoded[i]:end], starts[i]+decoded[i]/channels)
+				decoded[i] += int64(n)
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					errs[i] = err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, d.WaveFmt, err
+		}
+		// a truncated file ends at the last complete sample
+		if part := (starts[i+1] - starts[i]) * channels; decoded[i] < part {
+			return frames[:starts[i]*channels+decoded[i]/channels*channels], d.WaveFmt, nil
+		}
+	}
+	return frames, d.WaveFmt, nil
+}
+
+// DecodeCtx reads all the frames of a decoder a block at a time, and stops with the error of
+// the context once it is done
+func DecodeCtx(ctx context.Context, d Decoder) ([]Frame, error) {
+	channels := d.Format().NumChannels
+	if channels < 1 {
+		return nil, errors.New("Need at least one channel")
+	}
+	frames := []Frame{}
+	if wd, ok := d.(*WaveDecoder); ok && wd.Length() > 0 {
+		// the length of a stream is what its header claims, so it only goes as far as a
+		// first guess
+		frames = make([]Frame, 0, min(wd.Length(), maxPreallocate)*int64(channels))
+	}
+	block := parallelBlock / channels * channels
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if cap(frames)-len(frames) < block {
+			frames = append(frames[:cap(frames)], make([]Frame, block)...)[:len(frames)]
+		}
+		n, err := d.Read(frames[len(frames) : len(frames)+block])
+		frames = frames[:len(frames)+n]
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
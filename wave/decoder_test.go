@@ -0,0 +1,410 @@
+package wave
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	frames := make([]Frame, 1001)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// a chunk of odd size before the samples should be skipped, including its padding
+	list := []byte{'L', 'I', 'S', 'T', 3, 0, 0, 0, 'a', 'b', 'c', 0}
+	data = append(append(append([]byte{}, data[:36]...), list...), data[36:]...)
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Format().SampleRate != 8000 || d.Length() != 1001 {
+		t.Fatalf("Expected 1001 samples at 8000Hz, got %v at %v", d.Length(), d.Format().SampleRate)
+	}
+	decoded := []Frame{}
+	block := make([]Frame, 64)
+	for {
+		n, err := d.Read(block)
+		decoded = append(decoded, block[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(decoded) != len(frames) {
+		t.Fatalf("Expected %v frames, got %v", len(frames), len(decoded))
+	}
+	for i := range frames {
+		if math.Abs(float64(decoded[i]-frames[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[i], i, decoded[i])
+		}
+	}
+
+	if err := d.SeekSample(1000); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := d.Read(block); n != 1 || math.Abs(float64(block[0]-frames[1000])) > 1e-4 {
+		t.Fatalf("Expected the last frame after seeking, got %v frames", n)
+	}
+	if _, err := NewDecoder(bytes.NewReader([]byte("RIFF....WAVX"))); err == nil {
+		t.Fatal("Expected an error for a file which is not a WAVE file")
+	}
+}
+
+// sparseFile is a file of which the middle is a gap of zeros, which is never stored
+type sparseFile struct {
+	head, tail []byte
+	gap        int64
+	read       int64 // bytes read so far
+}
+
+func (f *sparseFile) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		at := off + int64(n)
+		switch {
+		case at < int64(len(f.head)):
+			n += copy(p[n:], f.head[at:])
+		case at < int64(len(f.head))+f.gap:
+			p[n] = 0
+			n++
+		case at < int64(len(f.head))+f.gap+int64(len(f.tail)):
+			n += copy(p[n:], f.tail[at-int64(len(f.head))-f.gap:])
+		default:
+			f.read += int64(n)
+			return n, io.EOF
+		}
+	}
+	f.read += int64(n)
+	return n, nil
+}
+
+func TestDecoderSkipsChunks(t *testing.T) {
+	frames := make([]Frame, 1000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// a chunk of a gigabyte before the samples
+	gap := int64(1 << 30)
+	head := append(append([]byte{}, data[:36]...), 'L', 'I', 'S', 'T', 0, 0, 0, 0x40)
+	f := &sparseFile{head: head, tail: data[36:], gap: gap}
+
+	d, err := NewDecoderAt(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.read > 1<<16 {
+		t.Fatalf("Expected the chunk to be skipped, read %v bytes", f.read)
+	}
+	block := make([]Frame, 10)
+	if n, err := d.ReadFramesAt(block, 500); n != 10 || err != nil {
+		t.Fatalf("Expected 10 frames, got %v and %v", n, err)
+	}
+	for i, f := range block {
+		if math.Abs(float64(f-frames[500+i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[500+i], 500+i, f)
+		}
+	}
+	if n, err := d.ReadFramesAt(block, 995); n != 5 || err != io.EOF {
+		t.Fatalf("Expected the last 5 frames and EOF, got %v and %v", n, err)
+	}
+	if n, err := d.Read(block); n != 10 || err != nil || math.Abs(float64(block[0]-frames[0])) > 1e-4 {
+		t.Fatalf("Expected Read to start at the first frame, got %v frames and %v", n, err)
+	}
+	if _, err := d.ReadFramesAt(block, 1001); err == nil {
+		t.Fatal("Expected an error for a sample out of range")
+	}
+}
+
+func TestDecoderChunkSize(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(make([]Frame, 1000), NewWaveFmt(1, 2, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	riff := append([]byte{}, buf.Bytes()...)
+	binary.LittleEndian.PutUint32(riff[16:20], 0xEB000010)
+	rifx := append([]byte{}, buf.Bytes()...)
+	copy(rifx, BigEndianChunkID)
+	binary.BigEndian.PutUint32(rifx[16:20], 0x52000000)
+
+	for _, data := range [][]byte{riff, rifx} {
+		t.Run(string(data[:4]), func(t *testing.T) {
+			before := runtime.MemStats{}
+			runtime.ReadMemStats(&before)
+			_, err := NewDecoder(bytes.NewReader(data))
+			after := runtime.MemStats{}
+			runtime.ReadMemStats(&after)
+			var chunk ErrTruncatedChunk
+			if !errors.As(err, &chunk) || chunk.ID != "fmt " {
+				t.Fatalf("Expected a truncated fmt chunk, got %v", err)
+			}
+			if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+				t.Fatalf("Expected the buffer not to follow the size in the header, allocated %v bytes", alloc)
+			}
+		})
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	frames := make([]Frame, 2*10001)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 2, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	// a header claiming 2GB of samples
+	crafted := append([]byte{}, buf.Bytes()...)
+	binary.LittleEndian.PutUint32(crafted[40:44], 0x7FFFFFF0)
+	tests := []struct {
+		data     []byte
+		workers  int
+		frames   int
+		sizeless bool // hide the size of the reader
+	}{
+		{buf.Bytes(), 1, len(frames), false},
+		{buf.Bytes(), 4, len(frames), false},
+		{buf.Bytes(), 100000, len(frames), false},
+		// a truncated file ends at the last complete sample
+		{buf.Bytes()[:buf.Len()-101], 4, len(frames) - 52, false},
+		{buf.Bytes()[:buf.Len()-101], 4, len(frames) - 52, true},
+		{crafted, 4, len(frames), false},
+		{crafted, 4, len(frames), true},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			var r io.ReaderAt = bytes.NewReader(test.data)
+			if test.sizeless {
+				r = struct{ io.ReaderAt }{r}
+			}
+			decoded, wfmt, err := DecodeParallel(r, test.workers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wfmt.NumChannels != 2 || len(decoded) != test.frames {
+				t.Fatalf("Expected %v frames of 2 channels, got %v of %v", test.frames, len(decoded), wfmt.NumChannels)
+			}
+			for i, f := range decoded {
+				if math.Abs(float64(f-frames[i])) > 1e-4 {
+					t.Fatalf("Expected %v at %v, got %v", frames[i], i, f)
+				}
+			}
+		})
+	}
+	if _, _, err := DecodeParallel(bytes.NewReader(buf.Bytes()), 0); err == nil {
+		t.Fatal("Expected an error without workers")
+	}
+}
+
+func TestDecoderClone(t *testing.T) {
+	frames := make([]Frame, 8000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDecoder(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every clone reads a quarter of the file at the same time
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		c, err := d.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func(i int, c Decoder) {
+			if err := c.(Seeker).SeekSample(int64(i * 2000)); err != nil {
+				errs <- err
+				return
+			}
+			block := make([]Frame, 2000)
+			if n, err := c.Read(block); n != len(block) || err != nil {
+				errs <- fmt.Errorf("Expected %v frames, got %v and %v", len(block), n, err)
+				return
+			}
+			for j, f := range block {
+				if math.Abs(float64(f-frames[i*2000+j])) > 1e-4 {
+					errs <- fmt.Errorf("Expected %v at %v, got %v", frames[i*2000+j], i*2000+j, f)
+					return
+				}
+			}
+			errs <- nil
+		}(i, c)
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the original still reads from its own position
+	block := make([]Frame, 1)
+	if n, _ := d.Read(block); n != 1 || math.Abs(float64(block[0]-frames[0])) > 1e-4 {
+		t.Fatalf("Expected the first frame from the original, got %v frames", n)
+	}
+
+	if _, err := (&WaveDecoder{r: &buf}).Clone(); err == nil {
+		t.Fatal("Expected an error without random access")
+	}
+}
+
+// countingEncoder counts the frames written to it
+type countingEncoder struct{ frames int }
+
+func (c *countingEncoder) Write(frames []Frame) error { c.frames += len(frames); return nil }
+func (c *countingEncoder) Close() error               { return nil }
+
+func TestContext(t *testing.T) {
+	frames := make([]Frame, 2*100000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeCtx(context.Background(), d)
+	if err != nil || len(decoded) != len(frames) {
+		t.Fatalf("Expected %v frames, got %v (%v)", len(frames), len(decoded), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d, _ = NewDecoder(bytes.NewReader(data))
+	if _, err := DecodeCtx(ctx, d); err != context.Canceled {
+		t.Fatalf("Expected decoding to be cancelled, got %v", err)
+	}
+	if _, _, err := DecodeParallelCtx(ctx, bytes.NewReader(data), 4); err != context.Canceled {
+		t.Fatalf("Expected parallel decoding to be cancelled, got %v", err)
+	}
+	enc := &countingEncoder{}
+	if err := EncodeCtx(ctx, enc, frames); err != context.Canceled || enc.frames != 0 {
+		t.Fatalf("Expected encoding to be cancelled, got %v after %v frames", err, enc.frames)
+	}
+	if err := EncodeCtx(context.Background(), enc, frames); err != nil || enc.frames != len(frames) {
+		t.Fatalf("Expected %v frames to be encoded, got %v (%v)", len(frames), enc.frames, err)
+	}
+
+	// a cancelled file is removed
+	file := filepath.Join(t.TempDir(), "cancelled.wav")
+	if err := WriteWaveFile(frames, wfmt, file, WithContext(ctx)); err != context.Canceled {
+		t.Fatalf("Expected writing to be cancelled, got %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("Expected the cancelled file to be removed, got %v", err)
+	}
+	written := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &written, WithContext(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+	d, _ = NewDecoder(bytes.NewReader(written.Bytes()))
+	decoded, err = DecodeCtx(context.Background(), d)
+	if err != nil || len(decoded) != len(frames) {
+		t.Fatalf("Expected %v frames written with a context, got %v (%v)", len(frames), len(decoded), err)
+	}
+	for i, f := range decoded {
+		if math.Abs(float64(f-frames[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[i], i, f)
+		}
+	}
+}
+
+// failingReader fails once its data is read
+type failingReader struct{ r io.Reader }
+
+func (f failingReader) Read(b []byte) (int, error) {
+	n, err := f.r.Read(b)
+	if err == io.EOF {
+		return n, errors.New("Disk on fire")
+	}
+	return n, err
+}
+
+func TestDecoderIterators(t *testing.T) {
+	frames := make([]Frame, 2*10001)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 2, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	for f := range d.Frames() {
+		if math.Abs(float64(f-frames[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[i], i, f)
+		}
+		i++
+	}
+	if i != len(frames) || d.Err() != nil {
+		t.Fatalf("Expected %v frames, got %v (%v)", len(frames), i, d.Err())
+	}
+
+	d, _ = NewDecoder(bytes.NewReader(data))
+	blocks := 0
+	for block := range d.Blocks(1000) {
+		if len(block) != 2000 {
+			t.Fatalf("Expected blocks of 1000 stereo samples, got %v frames", len(block))
+		}
+		if blocks++; blocks == 3 {
+			break
+		}
+	}
+	// the loop is picked up where it was broken
+	i = 3 * 2000
+	for f := range d.Frames() {
+		if math.Abs(float64(f-frames[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[i], i, f)
+		}
+		i++
+	}
+	if i != len(frames) {
+		t.Fatalf("Expected to continue up to %v frames, got %v", len(frames), i)
+	}
+
+	// the file claims more samples than the reader has
+	d, _ = NewDecoder(failingReader{bytes.NewReader(data[:len(data)-1000])})
+	for range d.Frames() {
+	}
+	if d.Err() == nil {
+		t.Fatal("Expected the error of the reader")
+	}
+}
@@ -0,0 +1,32 @@
+package wave
+
+import "context"
+
+// Encoder writes frames to a stream in an encoded format, such as MP3 or Opus.
+// Encoders are created on an io.Writer, which they don't close themselves.
+type Encoder interface {
+	Write(frames []Frame) error
+	// Close writes the frames which are still buffered and ends the stream
+	Close() error
+}
+
+// encodeBlock is the amount of frames EncodeCtx writes between checks of the context
+const encodeBlock = 1 << 16
+
+// EncodeCtx writes the frames to an encoder a block at a time, and stops with the error of
+// the context once it is done. The encoder is not closed.
+func EncodeCtx(ctx context.Context, e Encoder, frames []Frame) error {
+	for start := 0; start < len(frames); start += encodeBlock {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start + encodeBlock
+		if end > len(frames) {
+			end = len(frames)
+		}
+		if err := e.Write(frames[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package wave
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotRIFF is returned for data which is not a RIFF WAVE or RF64 file
+var ErrNotRIFF = errors.New("Not a RIFF WAVE file")
+
+// ErrInvalidFormat is returned for a WaveFmt of which the fields don't agree
+var ErrInvalidFormat = errors.New("Invalid format")
+
+// ErrClipped is returned when frames outside [-1;1] are written with WithFailOnClip
+var ErrClipped = errors.New("Frame clipped")
+
+// ErrUnsupportedBitDepth is returned for samples of a size which can't be read or written
+type ErrUnsupportedBitDepth struct {
+	Bits int
+}
+
+func (e ErrUnsupportedBitDepth) Error() string {
+	return fmt.Sprintf("%v bits per sample not supported", e.Bits)
+}
+
+// ErrTruncatedChunk is returned when a file ends inside a chunk
+type ErrTruncatedChunk struct {
+	ID   string
+	Want int64 // size of the chunk
+	Got  int64 // bytes of the chunk in the file
+}
+
+func (e ErrTruncatedChunk) Error() string {
+	return fmt.Sprintf("Chunk %q is truncated: %v of %v bytes", e.ID, e.Got, e.Want)
+}
+
+// errShortBuffer is returned when the buffer to encode into can't hold the samples
+var errShortBuffer = fmt.Errorf("Buffer is too small for the samples: %w", io.ErrShortBuffer)
@@ -0,0 +1,79 @@
+package wave
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// onlyReader hides the Seek of a reader
+type onlyReader struct {
+	io.Reader
+}
+
+func TestErrors(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(make([]Frame, 100), NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+	eightBit := append([]byte{}, valid...)
+	eightBit[34] = 8
+	list := append(append([]byte{}, valid[:36]...), 'L', 'I', 'S', 'T', 100, 0, 0, 0, 1, 2, 3)
+
+	_, err := NewDecoder(bytes.NewReader([]byte("RIFF....WAVX")))
+	if !errors.Is(err, ErrNotRIFF) {
+		t.Fatalf("Expected ErrNotRIFF, got %v", err)
+	}
+	_, err = ReadWaveFromReader(bytes.NewReader([]byte("not a wave file")))
+	if !errors.Is(err, ErrNotRIFF) {
+		t.Fatalf("Expected ErrNotRIFF, got %v", err)
+	}
+
+	var bitDepth ErrUnsupportedBitDepth
+	for _, err := range []error{
+		func() error { _, err := NewDecoder(bytes.NewReader(eightBit)); return err }(),
+		func() error { _, err := ReadWaveFromReader(bytes.NewReader(eightBit)); return err }(),
+		func() error { _, err := NewWaveWriter(&buf, NewWaveFmt(1, 1, 8000, 8, nil)); return err }(),
+		func() error {
+			_, err := EncodeFrames(make([]byte, 10), make([]Frame, 10), NewWaveFmt(1, 1, 8000, 8, nil))
+			return err
+		}(),
+	} {
+		if !errors.As(err, &bitDepth) || bitDepth.Bits != 8 {
+			t.Fatalf("Expected ErrUnsupportedBitDepth of 8 bits, got %v", err)
+		}
+	}
+
+	var chunk ErrTruncatedChunk
+	_, err = NewDecoder(onlyReader{bytes.NewReader(valid[:30])})
+	if !errors.As(err, &chunk) || chunk.ID != "fmt " || chunk.Want != 16 || chunk.Got != 10 {
+		t.Fatalf("Expected a truncated fmt chunk, got %v", err)
+	}
+	_, err = NewDecoder(onlyReader{bytes.NewReader(list)})
+	if !errors.As(err, &chunk) || chunk.ID != "LIST" || chunk.Want != 100 || chunk.Got != 3 {
+		t.Fatalf("Expected a truncated LIST chunk, got %v", err)
+	}
+	shortFmt := append(append([]byte{}, valid[:16]...), 4, 0, 0, 0, 1, 0, 1, 0)
+	_, err = NewDecoder(bytes.NewReader(shortFmt))
+	if !errors.As(err, &chunk) || chunk.ID != "fmt " || chunk.Want != 16 || chunk.Got != 4 {
+		t.Fatalf("Expected a fmt chunk which is too short, got %v", err)
+	}
+	for _, align := range []byte{0, 4} {
+		broken := append([]byte{}, valid...)
+		broken[32] = align
+		if _, err := NewDecoder(bytes.NewReader(broken)); !errors.Is(err, ErrInvalidFormat) {
+			t.Fatalf("Expected a block align of %v to be refused, got %v", align, err)
+		}
+	}
+	_, err = NewDecoder(bytes.NewReader(valid[:36]))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("Expected the missing data chunk to wrap EOF, got %v", err)
+	}
+
+	_, err = EncodeFrames(make([]byte, 10), make([]Frame, 10), NewWaveFmt(1, 1, 8000, 16, nil))
+	if !errors.Is(err, io.ErrShortBuffer) {
+		t.Fatalf("Expected io.ErrShortBuffer, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Chunk is a chunk of a RIFF file as found by Inspect
+type Chunk struct {
+	ID       string
+	Offset   int64  // of the header of the chunk in the file
+	Size     int64  // of the body, as stored in the header
+	Summary  string // what the body holds, for the chunks which are parsed
+	Problem  string // what is wrong with the chunk, such as a body cut off by the end of the file
+	Children []*Chunk
+}
+
+// inspectLimit is the largest body of a chunk Inspect reads to summarize it
+const inspectLimit = 1 << 20
+
+// Inspect lists the chunks of a .wav, RIFX or RF64 file with their offsets and sizes, and
+// summarizes those it knows. The children of the returned RIFF chunk are its chunks, those
+// of LIST chunks are listed as their children. Sample data is seeked past, so files of any
+// size are inspected quickly. Malformed chunks are marked with a Problem rather than
+// failing, only a file which is not RIFF at all and failed reads return an error.
+func Inspect(r io.ReadSeeker) (*Chunk, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, 12)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, ErrNotRIFF
+	}
+	id := string(hdr[0:4])
+	var order binary.ByteOrder = binary.LittleEndian
+	switch id {
+	case "RIFF", "RF64":
+	case "RIFX":
+		order = binary.BigEndian
+	default:
+		return nil, ErrNotRIFF
+	}
+	root := &Chunk{ID: id, Size: int64(order.Uint32(hdr[4:8])), Summary: string(hdr[8:12])}
+	if id != "RF64" && root.Size != end-8 {
+		root.Problem = fmt.Sprintf("size of %v bytes, the file has %v after the header", root.Size, end-8)
+	}
+	in := inspector{r: r, order: order, end: end, dataSize: -1}
+	if err := in.chunks(root, 12, end); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// inspector walks the chunks of a file, keeping what later chunks need to be summarized
+type inspector struct {
+	r        io.ReadSeeker
+	order    binary.ByteOrder
+	end      int64 // size of the file
+	wfmt     *WaveFmt
+	dataSize int64 // from the ds64 chunk of an RF64 file, -1 when there is none
+}
+
+// chunks adds the chunks between two offsets to the children of parent
+func (in *inspector) chunks(parent *Chunk, offset, end int64) error {
+	if end > in.end {
+		end = in.end
+	}
+	for offset+8 <= end {
+		if _, err := in.r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		hdr := make([]byte, 8)
+		if _, err := io.ReadFull(in.r, hdr); err != nil {
+			return err
+		}
+		c := &Chunk{ID: string(hdr[0:4]), Offset: offset, Size: int64(in.order.Uint32(hdr[4:8]))}
+		parent.Children = append(parent.Children, c)
+		size := c.Size
+		if c.ID == "data" && size == unknownSize && in.dataSize >= 0 {
+			size = in.dataSize
+		}
+		body := offset + 8
+		if body+size > end {
+			c.Problem = fmt.Sprintf("cut off after %v of %v bytes", end-body, size)
+			size = end - body
+		}
+		if err := in.summarize(c, parent, body, size); err != nil {
+			return err
+		}
+		// chunks are padded to an even size
+		offset = body + size + size%2
+	}
+	if offset < end {
+		parent.Problem = strings.TrimPrefix(parent.Problem+fmt.Sprintf("; %v stray bytes at the end", end-offset), "; ")
+	}
+	return nil
+}
+
+// summarize describes the body of a chunk of size bytes at an offset
+func (in *inspector) summarize(c, parent *Chunk, offset, size int64) error {
+	read := func() ([]byte, error) {
+		if size > inspectLimit {
+			return nil, errors.New("Chunk too large to summarize")
+		}
+		b := make([]byte, size)
+		if _, err := in.r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		_, err := io.ReadFull(in.r, b)
+		return b, err
+	}
+	switch c.ID {
+	case "fmt ", "ds64", "fact", "smpl", "cue ":
+		b, err := read()
+		if err != nil {
+			c.Problem = err.Error()
+			return nil
+		}
+		in.summarizeBody(c, b)
+	case "LIST":
+		if size < 4 {
+			c.Problem = "no list type"
+			return nil
+		}
+		if _, err := in.r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		form := make([]byte, 4)
+		if _, err := io.ReadFull(in.r, form); err != nil {
+			return err
+		}
+		c.Summary = string(form)
+		return in.chunks(c, offset+4, offset+size)
+	case "data":
+		if in.wfmt == nil {
+			c.Problem = "before the fmt chunk"
+			return nil
+		}
+		if in.wfmt.BlockAlign > 0 && in.wfmt.SampleRate > 0 {
+			samples := size / int64(in.wfmt.BlockAlign)
+			c.Summary = fmt.Sprintf("%v samples per channel, %.3fs", samples, float64(samples)/float64(in.wfmt.SampleRate))
+		}
+	case "JUNK", "junk", "PAD ", "FLLR":
+		c.Summary = "padding"
+	default:
+		// the text entries of an INFO list, such as INAM for the title
+		if parent.ID == "LIST" && parent.Summary == "INFO" && size <= 1024 {
+			b, err := read()
+			if err != nil {
+				c.Problem = err.Error()
+				return nil
+			}
+			c.Summary = fmt.Sprintf("%q", strings.TrimRight(string(b), "\x00"))
+		}
+	}
+	return nil
+}
+
+// summarizeBody describes the bodies of the chunks which hold numbers
+func (in *inspector) summarizeBody(c *Chunk, b []byte) {
+	u16 := func(i int) int { return int(in.order.Uint16(b[i:])) }
+	u32 := func(i int) int64 { return int64(in.order.Uint32(b[i:])) }
+	short := func(n int) bool {
+		if len(b) < n {
+			c.Problem = fmt.Sprintf("%v bytes, needs at least %v", len(b), n)
+			return true
+		}
+		return false
+	}
+	switch c.ID {
+	case "fmt ":
+		if short(16) {
+			return
+		}
+		in.wfmt = &WaveFmt{
+			AudioFormat:   u16(0),
+			NumChannels:   u16(2),
+			SampleRate:    int(u32(4)),
+			ByteRate:      int(u32(8)),
+			BlockAlign:    u16(12),
+			BitsPerSample: u16(14),
+		}
+		format := map[int]string{PCM: "PCM", IEEE_FLOAT: "IEEE float", EXTENSIBLE: "extensible"}[in.wfmt.AudioFormat]
+		if format == "" {
+			format = fmt.Sprintf("format %v", in.wfmt.AudioFormat)
+		}
+		c.Summary = fmt.Sprintf("%v, %v channels, %vHz, %v bits", format, in.wfmt.NumChannels, in.wfmt.SampleRate, in.wfmt.BitsPerSample)
+		if in.wfmt.AudioFormat == EXTENSIBLE && len(b) >= 26 {
+			c.Summary += fmt.Sprintf(", %v valid bits, channel mask %#x", u16(18), u32(20))
+		}
+		if in.wfmt.BlockAlign != in.wfmt.NumChannels*in.wfmt.BitsPerSample/8 {
+			c.Problem = fmt.Sprintf("block align of %v bytes, expected %v", in.wfmt.BlockAlign, in.wfmt.NumChannels*in.wfmt.BitsPerSample/8)
+		}
+	case "ds64":
+		if short(24) {
+			return
+		}
+		in.dataSize = int64(in.order.Uint64(b[8:]))
+		c.Summary = fmt.Sprintf("RIFF size %v, data size %v, %v samples", in.order.Uint64(b[0:]), in.dataSize, in.order.Uint64(b[16:]))
+	case "fact":
+		if short(4) {
+			return
+		}
+		c.Summary = fmt.Sprintf("%v samples per channel", u32(0))
+	case "smpl":
+		if short(36) {
+			return
+		}
+		c.Summary = fmt.Sprintf("unity note %v, %v loops", u32(12), u32(28))
+	case "cue ":
+		if short(4) {
+			return
+		}
+		c.Summary = fmt.Sprintf("%v cue points", u32(0))
+	}
+}
+
+// String prints the chunk and those within it as an indented tree, a line per chunk
+func (c *Chunk) String() string {
+	b := strings.Builder{}
+	c.write(&b, 0)
+	return b.String()
+}
+
+func (c *Chunk) write(b *strings.Builder, depth int) {
+	fmt.Fprintf(b, "%v%q at %v, %v bytes", strings.Repeat("  ", depth), c.ID, c.Offset, c.Size)
+	if c.Summary != "" {
+		fmt.Fprintf(b, ": %v", c.Summary)
+	}
+	if c.Problem != "" {
+		fmt.Fprintf(b, " (%v)", c.Problem)
+	}
+	b.WriteString("\n")
+	for _, child := range c.Children {
+		child.write(b, depth+1)
+	}
+}
@@ -0,0 +1,65 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	info := []byte("INFOINAM")
+	info = binary.LittleEndian.AppendUint32(info, 5)
+	info = append(info, "Song\x00\x00"...)
+	smpl := make([]byte, 36)
+	binary.LittleEndian.PutUint32(smpl[12:], 60)
+	buf := bytes.Buffer{}
+	err := WriteWaveToWriter(make([]Frame, 2*1000), NewWaveFmt(PCM, 2, 8000, 16, nil), &buf,
+		WithChunk("LIST", info), WithChunk("smpl", smpl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	root, err := Inspect(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `"RIFF" at 0, ` + strconv.Itoa(len(data)-8) + ` bytes: WAVE
+  "fmt " at 12, 16 bytes: PCM, 2 channels, 8000Hz, 16 bits
+  "data" at 36, 4000 bytes: 1000 samples per channel, 0.125s
+  "LIST" at 4044, 18 bytes: INFO
+    "INAM" at 4056, 5 bytes: "Song"
+  "smpl" at 4070, 36 bytes: unity note 60, 0 loops
+`
+	if root.String() != expected {
+		t.Fatalf("Expected\n%v\ngot\n%v", expected, root)
+	}
+
+	// a file cut off in its samples
+	root, err = Inspect(bytes.NewReader(data[:1000]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(root.Children) != 2 || root.Problem == "" || !strings.Contains(root.Children[1].Problem, "cut off after 956 of 4000 bytes") {
+		t.Fatalf("Expected the data chunk to be cut off, got\n%v", root)
+	}
+
+	// the numbers of a RIFX file are big endian
+	buf.Reset()
+	if err := WriteWaveToWriter(make([]Frame, 2*1000), NewWaveFmt(PCM, 2, 8000, 16, nil), &buf, WithBigEndian()); err != nil {
+		t.Fatal(err)
+	}
+	root, err = Inspect(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.ID != "RIFX" || root.Problem != "" || root.Children[0].Summary != "PCM, 2 channels, 8000Hz, 16 bits" {
+		t.Fatalf("Expected a RIFX file of 2 channels, got\n%v", root)
+	}
+
+	if _, err := Inspect(bytes.NewReader([]byte("not a wave file"))); err != ErrNotRIFF {
+		t.Fatalf("Expected %v, got %v", ErrNotRIFF, err)
+	}
+}
@@ -0,0 +1,150 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// IntFrame is a single sample as a 32 bit integer, with full scale at the limits of int32.
+// Simple operations on integer frames, such as gain by integer factors, routing channels and
+// splitting them, skip floating point entirely, for fixed point pipelines on small devices.
+type IntFrame int32
+
+// DecodeInt converts raw samples of 16, 24 or 32 bits to integer frames and returns the
+// amount converted
+func DecodeInt(dst []IntFrame, raw []byte, bits int) (int, error) {
+	toInt, ok := byteSizeToIntFunc[bits]
+	if !ok {
+		return 0, ErrUnsupportedBitDepth{bits}
+	}
+	width := bits / 8
+	n := len(raw) / width
+	if n > len(dst) {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		v := toInt(raw[i*width : (i+1)*width])
+		if bits == 16 {
+			v <<= 16
+		}
+		// 24-bit samples are already shifted into the upper bytes
+		dst[i] = IntFrame(v)
+	}
+	return n, nil
+}
+
+// EncodeInt converts integer frames to raw samples of 16, 24 or 32 bits and returns the amount
+// of bytes written. The lower bits which don't fit the samples are dropped.
+func EncodeInt(dst []byte, src []IntFrame, bits int) (int, error) {
+	width := bits / 8
+	n := len(src) * width
+	if len(dst) < n {
+		return 0, errShortBuffer
+	}
+	switch bits {
+	case 16:
+		for i, f := range src {
+			binary.LittleEndian.PutUint16(dst[2*i:], uint16(f>>16))
+		}
+	case 24:
+		for i, f := range src {
+			dst[3*i], dst[3*i+1], dst[3*i+2] = byte(f>>8), byte(f>>16), byte(f>>24)
+		}
+	case 32:
+		for i, f := range src {
+			binary.LittleEndian.PutUint32(dst[4*i:], uint32(f))
+		}
+	default:
+		return 0, ErrUnsupportedBitDepth{bits}
+	}
+	return n, nil
+}
+
+// GainInt multiplies the frames by num/den in place, clipping them at full scale
+func GainInt(frames []IntFrame, num, den int32) {
+	if den == 0 {
+		return
+	}
+	for i, f := range frames {
+		v := int64(f) * int64(num) / int64(den)
+		if v > math.MaxInt32 {
+			v = math.MaxInt32
+		} else if v < math.MinInt32 {
+			v = math.MinInt32
+		}
+		frames[i] = IntFrame(v)
+	}
+}
+
+// RouteInt copies the channels of interleaved frames into dst with another layout. Channel c
+// of dst plays channel routes[c] of src, a negative route is silent. It returns the amount of
+// samples per channel routed.
+func RouteInt(dst []IntFrame, src []IntFrame, srcChannels int, routes []int) (int, error) {
+	if srcChannels < 1 || len(routes) < 1 {
+		return 0, errors.New("Need at least one channel")
+	}
+	for _, r := range routes {
+		if r >= srcChannels {
+			return 0, fmt.Errorf("No channel %v to route", r)
+		}
+	}
+	n := len(src) / srcChannels
+	if m := len(dst) / len(routes); m < n {
+		n = m
+	}
+	for i := 0; i < n; i++ {
+		in, out := src[i*srcChannels:], dst[i*len(routes):]
+		for c, r := range routes {
+			if r < 0 {
+				out[c] = 0
+			} else {
+				out[c] = in[r]
+			}
+		}
+	}
+	return n, nil
+}
+
+// SplitInt splits interleaved frames into a slice per channel
+func SplitInt(src []IntFrame, channels int) [][]IntFrame {
+	if channels < 1 {
+		return nil
+	}
+	n := len(src) / channels
+	split := make([][]IntFrame, channels)
+	for c := range split {
+		split[c] = make([]IntFrame, n)
+		for i := range split[c] {
+			split[c][i] = src[i*channels+c]
+		}
+	}
+	return split
+}
+
+// ReadInt decodes up to len(frames) integer frames, like Read without converting to floats
+func (d *WaveDecoder) ReadInt(frames []IntFrame) (int, error) {
+	if d.isFloat() {
+		return 0, errors.New("Samples are floats, not integers")
+	}
+	buf, err := d.readRaw(len(frames))
+	if d.order == binary.BigEndian {
+		swapSamples(buf, d.BitsPerSample/8)
+	}
+	// the format was checked by NewDecoder
+	n, _ := DecodeInt(frames, buf, d.BitsPerSample)
+	return n, err
+}
+
+// WriteInt encodes integer frames to the stream, like Write without converting from floats
+func (ww *WaveWriter) WriteInt(frames []IntFrame) error {
+	if ww.closed {
+		return errors.New("WaveWriter is closed")
+	}
+	buf := ww.buffer(len(frames))
+	if _, err := EncodeInt(buf, frames, ww.BitsPerSample); err != nil {
+		return err
+	}
+	return ww.writeRaw(buf)
+}
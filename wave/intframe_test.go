@@ -0,0 +1,86 @@
+package wave
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestIntFrames(t *testing.T) {
+	for _, bits := range []int{16, 32} {
+		t.Run("", func(t *testing.T) {
+			// stereo with the left channel at half the level of the right
+			frames := make([]IntFrame, 2*500)
+			for i := 0; i < 500; i++ {
+				v := IntFrame(math.Sin(float64(i)/10) * (1 << 30))
+				frames[2*i], frames[2*i+1] = v/2, v
+			}
+			wfmt := NewWaveFmt(1, 2, 8000, bits, nil)
+			buf := bytes.Buffer{}
+			ww, err := NewWaveWriter(&buf, wfmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ww.WriteInt(frames); err != nil {
+				t.Fatal(err)
+			}
+			if err := ww.Close(); err != nil {
+				t.Fatal(err)
+			}
+			d, err := NewDecoder(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read := make([]IntFrame, len(frames)+2)
+			n, err := d.ReadInt(read)
+			if n != len(frames) || err != nil {
+				t.Fatalf("Expected %v frames, got %v and %v", len(frames), n, err)
+			}
+			if _, err := d.ReadInt(read); err != io.EOF {
+				t.Fatalf("Expected EOF, got %v", err)
+			}
+			// 16 bits keep the upper half of the frames
+			precision := IntFrame(1<<16 - 1)
+			if bits == 32 {
+				precision = 0
+			}
+			for i, f := range frames {
+				if d := f - read[i]; d < 0 || d > precision {
+					t.Fatalf("Expected %v at %v, got %v", f, i, read[i])
+				}
+			}
+
+			// swap the channels, double the left one and split them
+			swapped := make([]IntFrame, len(read))
+			if n, err := RouteInt(swapped, read[:len(frames)], 2, []int{1, 0}); n != 500 || err != nil {
+				t.Fatalf("Expected 500 routed samples, got %v and %v", n, err)
+			}
+			split := SplitInt(swapped[:len(frames)], 2)
+			GainInt(split[1], 2, 1)
+			for i := range split[0] {
+				if split[0][i] != read[2*i+1] || split[1][i] != 2*read[2*i] {
+					t.Fatalf("Expected %v and %v at %v, got %v and %v", read[2*i+1], 2*read[2*i], i, split[0][i], split[1][i])
+				}
+			}
+		})
+	}
+
+	clipped := []IntFrame{math.MaxInt32 / 2, -math.MaxInt32 / 2}
+	GainInt(clipped, 3, 1)
+	if clipped[0] != math.MaxInt32 || clipped[1] != math.MinInt32 {
+		t.Fatalf("Expected the gain to clip, got %v", clipped)
+	}
+	if _, err := RouteInt(nil, nil, 2, []int{2}); err == nil {
+		t.Fatal("Expected an error routing a missing channel")
+	}
+	raw := []byte{0x56, 0x34, 0x12}
+	decoded := make([]IntFrame, 1)
+	if _, err := DecodeInt(decoded, raw, 24); err != nil || decoded[0] != 0x12345600 {
+		t.Fatalf("Expected a 24 bit sample in the upper bytes, got %x", decoded[0])
+	}
+	encoded := make([]byte, 3)
+	if _, err := EncodeInt(encoded, decoded, 24); err != nil || !bytes.Equal(encoded, raw) {
+		t.Fatalf("Expected the 24 bit sample back, got %x", encoded)
+	}
+}
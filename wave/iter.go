@@ -0,0 +1,58 @@
+package wave
+
+import (
+	"io"
+	"iter"
+)
+
+// iterBlock is the amount of samples per channel Frames decodes at a time
+const iterBlock = 4096
+
+// Frames returns an iterator over the interleaved frames left to read, which are decoded a
+// block at a time. Breaking out of the loop stops reading, the frames after the last one
+// yielded are skipped. Err returns the error which ended the loop early, if any.
+func (d *WaveDecoder) Frames() iter.Seq[Frame] {
+	return func(yield func(Frame) bool) {
+		for block := range d.Blocks(iterBlock) {
+			for _, f := range block {
+				if !yield(f) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Blocks returns an iterator over the frames left to read in blocks of size samples per
+// channel, so the channels of a sample stay together. The last block can be shorter. The
+// slice is reused by the next block, it has to be copied to be kept. Err returns the error
+// which ended the loop early, if any.
+func (d *WaveDecoder) Blocks(size int) iter.Seq[[]Frame] {
+	return func(yield func([]Frame) bool) {
+		d.err = nil
+		if size < 1 {
+			size = 1
+		}
+		block := AcquireFrames(size * d.NumChannels)
+		defer ReleaseFrames(block)
+		for {
+			n, err := d.Read(block)
+			if n > 0 && !yield(block[:n]) {
+				return
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				d.err = err
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error of reading which stopped the last loop over Frames or Blocks, or nil
+// when the frames ran out or the loop was broken
+func (d *WaveDecoder) Err() error {
+	return d.err
+}
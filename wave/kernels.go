@@ -0,0 +1,187 @@
+package wave
+
+import "math"
+
+// The kernels below are the inner loops of mixing, gain and conversion. Mixing and gain are
+// written in assembly where it is available, see kernels_amd64.s, the other kernels are
+// unrolled so the compiler keeps them free of bounds checks.
+
+// MixInto adds the frames of src, scaled by gain, to dst. It mixes as many frames as the
+// shorter of both holds.
+func MixInto(dst, src []Frame, gain Frame) {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	if len(dst) == 0 {
+		return
+	}
+	mixFrames(dst, src[:len(dst)], gain)
+}
+
+// ApplyGain scales the frames in place
+func ApplyGain(frames []Frame, gain Frame) {
+	if len(frames) == 0 {
+		return
+	}
+	gainFrames(frames, gain)
+}
+
+// mixGeneric is the portable version of mixFrames, dst and src have the same length
+func mixGeneric(dst, src []Frame, gain Frame) {
+	src = src[:len(dst)]
+	i := 0
+	for ; i+4 <= len(dst); i += 4 {
+		d, s := dst[i:i+4:i+4], src[i:i+4:i+4]
+		d[0] += s[0] * gain
+		d[1] += s[1] * gain
+		d[2] += s[2] * gain
+		d[3] += s[3] * gain
+	}
+	for ; i < len(dst); i++ {
+		dst[i] += src[i] * gain
+	}
+}
+
+// gainGeneric is the portable version of gainFrames
+func gainGeneric(frames []Frame, gain Frame) {
+	i := 0
+	for ; i+4 <= len(frames); i += 4 {
+		f := frames[i : i+4 : i+4]
+		f[0] *= gain
+		f[1] *= gain
+		f[2] *= gain
+		f[3] *= gain
+	}
+	for ; i < len(frames); i++ {
+		frames[i] *= gain
+	}
+}
+
+// Interleave writes the samples of separate channels one after the other into dst, as many
+// samples per channel as the shortest channel and dst hold. It returns the amount of frames written.
+func Interleave(dst []Frame, channels [][]Frame) int {
+	if len(channels) == 0 {
+		return 0
+	}
+	n := len(dst) / len(channels)
+	for _, c := range channels {
+		if len(c) < n {
+			n = len(c)
+		}
+	}
+	if len(channels) == 2 {
+		left, right := channels[0][:n], channels[1][:n]
+		dst = dst[:2*n]
+		for i := range left {
+			d := dst[2*i : 2*i+2 : 2*i+2]
+			d[0], d[1] = left[i], right[i]
+		}
+		return 2 * n
+	}
+	for c, samples := range channels {
+		for i, f := range samples[:n] {
+			dst[i*len(channels)+c] = f
+		}
+	}
+	return n * len(channels)
+}
+
+// Deinterleave splits interleaved frames into separate channels, as many samples per channel
+// as src and the shortest channel hold. It returns the amount of samples per channel.
+func Deinterleave(channels [][]Frame, src []Frame) int {
+	if len(channels) == 0 {
+		return 0
+	}
+	n := len(src) / len(channels)
+	for _, c := range channels {
+		if len(c) < n {
+			n = len(c)
+		}
+	}
+	if len(channels) == 2 {
+		left, right := channels[0][:n], channels[1][:n]
+		src = src[:2*n]
+		for i := range left {
+			s := src[2*i : 2*i+2 : 2*i+2]
+			left[i], right[i] = s[0], s[1]
+		}
+		return n
+	}
+	for c, samples := range channels {
+		for i := range samples[:n] {
+			samples[i] = src[i*len(channels)+c]
+		}
+	}
+	return n
+}
+
+// FramesToFloat32 converts frames to the 32 bit floats most audio APIs take and returns the
+// amount converted
+func FramesToFloat32(dst []float32, src []Frame) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	src = src[:len(dst)]
+	i := 0
+	for ; i+4 <= len(dst); i += 4 {
+		d, s := dst[i:i+4:i+4], src[i:i+4:i+4]
+		d[0], d[1], d[2], d[3] = float32(s[0]), float32(s[1]), float32(s[2]), float32(s[3])
+	}
+	for ; i < len(dst); i++ {
+		dst[i] = float32(src[i])
+	}
+	return len(dst)
+}
+
+// Float32ToFrames converts 32 bit floats to frames and returns the amount converted
+func Float32ToFrames(dst []Frame, src []float32) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	src = src[:len(dst)]
+	i := 0
+	for ; i+4 <= len(dst); i += 4 {
+		d, s := dst[i:i+4:i+4], src[i:i+4:i+4]
+		d[0], d[1], d[2], d[3] = Frame(s[0]), Frame(s[1]), Frame(s[2]), Frame(s[3])
+	}
+	for ; i < len(dst); i++ {
+		dst[i] = Frame(src[i])
+	}
+	return len(dst)
+}
+
+// FramesToInt16 converts frames to 16 bit samples, clipping them to [-1;1], and returns the
+// amount converted
+func FramesToInt16(dst []int16, src []Frame) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	src = src[:len(dst)]
+	for i, f := range src {
+		if f > 1 {
+			f = 1
+		} else if f < -1 {
+			f = -1
+		}
+		dst[i] = int16(f * math.MaxInt16)
+	}
+	return len(dst)
+}
+
+// Int16ToFrames converts 16 bit samples to frames and returns the amount converted
+func Int16ToFrames(dst []Frame, src []int16) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	src = src[:len(dst)]
+	const scale = 1 / Frame(math.MaxInt16)
+	i := 0
+	for ; i+4 <= len(dst); i += 4 {
+		d, s := dst[i:i+4:i+4], src[i:i+4:i+4]
+		d[0], d[1], d[2], d[3] = Frame(s[0])*scale, Frame(s[1])*scale, Frame(s[2])*scale, Frame(s[3])*scale
+	}
+	for ; i < len(dst); i++ {
+		dst[i] = Frame(src[i]) * scale
+	}
+	return len(dst)
+}
@@ -0,0 +1,14 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package wave
+
+// mixFrames adds src times gain to dst with SSE2, dst and src have the same length
+//
+//go:noescape
+func mixFrames(dst, src []Frame, gain Frame)
+
+// gainFrames scales the frames with SSE2
+//
+//go:noescape
+func gainFrames(frames []Frame, gain Frame)
@@ -0,0 +1,87 @@
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// SSE2 is part of every amd64 processor, so these need no feature detection. Both kernels
+// handle four frames per iteration in two registers and finish the rest one at a time.
+
+// func mixFrames(dst, src []Frame, gain Frame)
+TEXT ·mixFrames(SB), NOSPLIT, $0-56
+	MOVQ  dst_base+0(FP), DI
+	MOVQ  dst_len+8(FP), CX
+	MOVQ  src_base+24(FP), SI
+	MOVSD gain+48(FP), X0
+	SHUFPD $0, X0, X0
+	MOVQ  CX, BX
+	SHRQ  $2, BX
+	JZ    mixtail
+
+mixloop:
+	MOVUPD (SI), X1
+	MOVUPD 16(SI), X2
+	MULPD  X0, X1
+	MULPD  X0, X2
+	MOVUPD (DI), X3
+	MOVUPD 16(DI), X4
+	ADDPD  X1, X3
+	ADDPD  X2, X4
+	MOVUPD X3, (DI)
+	MOVUPD X4, 16(DI)
+	ADDQ   $32, SI
+	ADDQ   $32, DI
+	DECQ   BX
+	JNZ    mixloop
+
+mixtail:
+	ANDQ $3, CX
+	JZ   mixdone
+
+mixone:
+	MOVSD (SI), X1
+	MULSD X0, X1
+	ADDSD (DI), X1
+	MOVSD X1, (DI)
+	ADDQ  $8, SI
+	ADDQ  $8, DI
+	DECQ  CX
+	JNZ   mixone
+
+mixdone:
+	RET
+
+// func gainFrames(frames []Frame, gain Frame)
+TEXT ·gainFrames(SB), NOSPLIT, $0-32
+	MOVQ  frames_base+0(FP), DI
+	MOVQ  frames_len+8(FP), CX
+	MOVSD gain+24(FP), X0
+	SHUFPD $0, X0, X0
+	MOVQ  CX, BX
+	SHRQ  $2, BX
+	JZ    gaintail
+
+gainloop:
+	MOVUPD (DI), X1
+	MOVUPD 16(DI), X2
+	MULPD  X0, X1
+	MULPD  X0, X2
+	MOVUPD X1, (DI)
+	MOVUPD X2, 16(DI)
+	ADDQ   $32, DI
+	DECQ   BX
+	JNZ    gainloop
+
+gaintail:
+	ANDQ $3, CX
+	JZ   gaindone
+
+gainone:
+	MOVSD (DI), X1
+	MULSD X0, X1
+	MOVSD X1, (DI)
+	ADDQ  $8, DI
+	DECQ  CX
+	JNZ   gainone
+
+gaindone:
+	RET
@@ -0,0 +1,12 @@
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package wave
+
+func mixFrames(dst, src []Frame, gain Frame) {
+	mixGeneric(dst, src, gain)
+}
+
+func gainFrames(frames []Frame, gain Frame) {
+	gainGeneric(frames, gain)
+}
@@ -0,0 +1,34 @@
+package wave
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// packageLogger is the logger set with SetLogger
+var packageLogger atomic.Pointer[slog.Logger]
+
+// SetLogger sets a logger for the debug events of reading and writing, such as the chunks
+// skipped and the formats picked when the file doesn't say. Nothing is logged by default,
+// nil turns the logging off again.
+func SetLogger(l *slog.Logger) {
+	packageLogger.Store(l)
+}
+
+// logDebug logs an event to the logger, or to the logger of the package when it is nil
+func logDebug(l *slog.Logger, msg string, args ...any) {
+	if l == nil {
+		l = packageLogger.Load()
+	}
+	if l != nil {
+		l.Log(context.Background(), slog.LevelDebug, msg, args...)
+	}
+}
+
+// WithLogger logs the debug events of the write to a logger rather than to that of SetLogger
+func WithLogger(l *slog.Logger) WriteOption {
+	return func(c *writeConfig) {
+		c.logger = l
+	}
+}
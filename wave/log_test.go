@@ -0,0 +1,56 @@
+package wave
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	wfmt := NewWaveFmt(1, 1, 8000, 16, nil)
+	frames := []Frame{0, .5, 1.5, -2}
+
+	// the logger of a write
+	written := bytes.Buffer{}
+	out := bytes.Buffer{}
+	l := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if err := WriteWaveToWriter(frames, wfmt, &written, WithLogger(l), WithChunk("LIST", []byte("abcd"))); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `msg="clamped samples past full scale"`) || !strings.Contains(out.String(), "samples=2") {
+		t.Fatalf("Expected the clamped samples to be logged, got %q", out.String())
+	}
+
+	// the logger of the package, which logs nothing once unset
+	b := written.Bytes()
+	withList := append(append(append([]byte{}, b[:36]...), "LIST\x04\x00\x00\x00abcd"...), b[36:]...)
+	tests := []struct {
+		read func() error
+		want string
+	}{
+		{func() error { _, err := NewDecoder(bytes.NewReader(withList)); return err }, "msg=\"skipping chunk\" id=LIST offset=36 size=4"},
+		{func() error { _, err := ReadWaveFromReader(bytes.NewReader(b)); return err }, "msg=\"skipping chunk\" id=LIST offset=52 size=4"},
+	}
+	defer SetLogger(nil)
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			out.Reset()
+			SetLogger(l)
+			if err := test.read(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), test.want) {
+				t.Fatalf("Expected %q in the log, got %q", test.want, out.String())
+			}
+			out.Reset()
+			SetLogger(nil)
+			if err := test.read(); err != nil {
+				t.Fatal(err)
+			}
+			if out.Len() > 0 {
+				t.Fatalf("Expected nothing to be logged, got %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package wave
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// MappedWave is a .wav file mapped into memory where the platform supports it, elsewhere it
+// is read into memory. The operating system reads the samples as they are touched, and they
+// are only converted to frames by ReadFramesAt, so opening a large file to look at a part of
+// it costs next to nothing.
+type MappedWave struct {
+	*WaveDecoder
+
+	data  []byte // the whole file
+	raw   []byte // the sample data
+	unmap func() error
+}
+
+// MapWaveFile maps a .wav or RF64 file into memory. It has to be closed to release the mapping.
+func MapWaveFile(path string) (*MappedWave, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	end := int64(len(data))
+	if d.size >= 0 && d.start+d.size < end {
+		end = d.start + d.size
+	}
+	// a truncated file ends at the last complete sample frame
+	end -= (end - d.start) % int64(d.BlockAlign)
+	d.size = end - d.start
+	d.remaining = d.size
+	return &MappedWave{WaveDecoder: d, data: data, raw: data[d.start:end], unmap: unmap}, nil
+}
+
+// Raw returns the sample data as it is stored in the file, without copying it. The slice is
+// only valid until the file is closed.
+func (m *MappedWave) Raw() []byte {
+	return m.raw
+}
+
+// ReadFramesAt converts up to len(frames) frames from a sample, counted per channel, straight
+// from the mapping. It returns io.EOF when fewer frames are left.
+func (m *MappedWave) ReadFramesAt(frames []Frame, sample int64) (int, error) {
+	pos := sample * int64(m.BlockAlign)
+	if pos < 0 || pos > int64(len(m.raw)) {
+		return 0, fmt.Errorf("Sample %v out of range", sample)
+	}
+	width := m.BitsPerSample / 8
+	end := pos + int64(len(frames)*width)
+	var err error
+	if end > int64(len(m.raw)) {
+		end, err = int64(len(m.raw)), io.EOF
+	}
+	return m.decode(frames, m.raw[pos:end]), err
+}
+
+// Close releases the mapping. The frames read before stay valid, the slice of Raw does not.
+func (m *MappedWave) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.unmap, m.data, m.raw = nil, nil, nil
+	// the decoder can't read from the mapping anymore
+	m.r, m.at, m.size, m.remaining = bytes.NewReader(nil), nil, 0, 0
+	return err
+}
@@ -0,0 +1,60 @@
+package wave
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapWaveFile(t *testing.T) {
+	frames := make([]Frame, 2*1001)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+	path := filepath.Join(t.TempDir(), "mapped.wav")
+	if err := WriteWaveFile(frames, wfmt, path); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := MapWaveFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if m.Length() != 1001 || m.NumChannels != 2 {
+		t.Fatalf("Expected 1001 samples of 2 channels, got %v of %v", m.Length(), m.NumChannels)
+	}
+	raw := samplesToRawData(t, frames, wfmt)
+	if string(m.Raw()) != string(raw) {
+		t.Fatal("Expected the raw data of the file")
+	}
+
+	block := make([]Frame, 10)
+	if n, err := m.ReadFramesAt(block, 500); n != 10 || err != nil {
+		t.Fatalf("Expected 10 frames, got %v and %v", n, err)
+	}
+	for i, f := range block {
+		if math.Abs(float64(f-frames[1000+i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", frames[1000+i], 1000+i, f)
+		}
+	}
+	if n, err := m.ReadFramesAt(block, 998); n != 6 || err != io.EOF {
+		t.Fatalf("Expected the last 6 frames and EOF, got %v and %v", n, err)
+	}
+	if n, err := m.Read(block); n != 10 || err != nil || math.Abs(float64(block[0]-frames[0])) > 1e-4 {
+		t.Fatalf("Expected Read to start at the first frame, got %v frames and %v", n, err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Read(block); err != io.EOF {
+		t.Fatalf("Expected a closed file to be at its end, got %v", err)
+	}
+	if _, err := MapWaveFile(filepath.Join(t.TempDir(), "missing.wav")); !os.IsNotExist(err) {
+		t.Fatalf("Expected a missing file to fail, got %v", err)
+	}
+}
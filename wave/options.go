@@ -0,0 +1,293 @@
+package wave
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+)
+
+// WriteOption changes how WriteWaveFile and WriteWaveToWriter write frames
+type WriteOption func(*writeConfig)
+
+// writeConfig is the result of the options of a write
+type writeConfig struct {
+	dither     bool
+	order      binary.AppendByteOrder
+	float      bool
+	chunks     []extraChunk
+	bufferSize int // bytes of samples encoded at a time, 0 encodes them at once
+	failOnClip bool
+	ctx        context.Context
+	progress   Progress
+	clips      *ClipReport
+	logger     *slog.Logger
+}
+
+// extraChunk is a chunk written after the samples
+type extraChunk struct {
+	id   string
+	data []byte
+}
+
+// WithDither adds triangular dither of one step of the bit depth before the frames are
+// rounded to integers, which turns the distortion of quiet passages into a constant hiss
+func WithDither() WriteOption {
+	return func(c *writeConfig) {
+		c.dither = true
+	}
+}
+
+// WithBigEndian writes a RIFX file, in which all numbers are stored big endian. Files in the
+// EXTENSIBLE format are not written big endian.
+func WithBigEndian() WriteOption {
+	return func(c *writeConfig) {
+		c.order = binary.BigEndian
+	}
+}
+
+// WithFloat writes the frames as IEEE floats of 64 bits when the format has 64 bits per
+// sample and of 32 bits otherwise, so they are stored without rounding or clipping
+func WithFloat() WriteOption {
+	return func(c *writeConfig) {
+		c.float = true
+	}
+}
+
+// WithChunk adds a chunk after the samples, such as a LIST chunk with metadata. The id has
+// to be four characters.
+func WithChunk(id string, data []byte) WriteOption {
+	return func(c *writeConfig) {
+		c.chunks = append(c.chunks, extraChunk{id: id, data: data})
+	}
+}
+
+// WithBufferSize encodes the samples in blocks of at most size bytes rather than all at once,
+// which keeps the memory of writing long files down
+func WithBufferSize(size int) WriteOption {
+	return func(c *writeConfig) {
+		c.bufferSize = size
+	}
+}
+
+// WithFailOnClip stops writing with ErrClipped at the first frame outside [-1;1], which
+// is otherwise clamped to full scale in the integer formats
+func WithFailOnClip() WriteOption {
+	return func(c *writeConfig) {
+		c.failOnClip = true
+	}
+}
+
+// ClipReport tells which frames of a write were outside [-1;1] and clamped to full scale
+type ClipReport struct {
+	Clipped int          // amount of frames clipped
+	Regions []ClipRegion // runs of samples with a clipped frame on any channel
+}
+
+// ClipRegion is a run of samples, counted per channel, from Start up to End
+type ClipRegion struct {
+	Start, End int
+}
+
+// add adds a clipped frame of a sample to the report
+func (r *ClipReport) add(sample int) {
+	r.Clipped++
+	if n := len(r.Regions); n > 0 && r.Regions[n-1].End >= sample {
+		r.Regions[n-1].End = sample + 1
+		return
+	}
+	r.Regions = append(r.Regions, ClipRegion{Start: sample, End: sample + 1})
+}
+
+// WithClipReport fills the report with the frames clipped by the write, which it resets.
+// Floats don't clip, so nothing is reported with WithFloat.
+func WithClipReport(report *ClipReport) WriteOption {
+	return func(c *writeConfig) {
+		*report = ClipReport{}
+		c.clips = report
+	}
+}
+
+// contextBlock is the amount of bytes of samples encoded between checks of the context of
+// WithContext and reports of WithProgress, unless WithBufferSize sets another size
+const contextBlock = 1 << 16
+
+// WithContext stops writing with the error of the context once it is done, which is checked
+// before every block of samples. The samples are written in blocks of 64KiB unless
+// WithBufferSize sets another size.
+func WithContext(ctx context.Context) WriteOption {
+	return func(c *writeConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithProgress reports the frames written out of all of them after every block of samples,
+// which are written in blocks of 64KiB unless WithBufferSize sets another size
+func WithProgress(progress Progress) WriteOption {
+	return func(c *writeConfig) {
+		c.progress = progress
+	}
+}
+
+// writeWithOptions writes a .wav file as configured by the options
+func writeWithOptions(samples []Frame, wfmt WaveFmt, writer io.Writer, opts []WriteOption) error {
+	c := writeConfig{order: binary.LittleEndian}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.float {
+		bits := 32
+		if wfmt.BitsPerSample == 64 {
+			bits = 64
+		} else if wfmt.BitsPerSample != 32 {
+			logDebug(c.logger, "writing 32 bit floats", "bits", wfmt.BitsPerSample)
+		}
+		wfmt = floatFormat(wfmt, bits)
+	}
+	if err := wfmt.checkBitDepth(); err != nil {
+		return err
+	}
+	if err := wfmt.Validate(); err != nil {
+		return err
+	}
+	if c.order == binary.BigEndian && wfmt.AudioFormat == EXTENSIBLE {
+		return fmt.Errorf("%w: the EXTENSIBLE format is not written to RIFX files", ErrInvalidFormat)
+	}
+	for _, chunk := range c.chunks {
+		if len(chunk.id) != 4 {
+			return fmt.Errorf("Chunk id %q is not four characters", chunk.id)
+		}
+	}
+	width := wfmt.BitsPerSample / 8
+	samples = samples[:len(samples)/wfmt.NumChannels*wfmt.NumChannels]
+	dataSize := len(samples) * width
+
+	fmtChunk := fmtToBytes(wfmt)
+	if c.order == binary.BigEndian {
+		swapFmt(fmtChunk[:8], fmtChunk[8:])
+	}
+	riffSize := 4 + len(fmtChunk) + 8 + dataSize + dataSize%2
+	for _, chunk := range c.chunks {
+		riffSize += 8 + len(chunk.data) + len(chunk.data)%2
+	}
+	hdr := append([]byte{}, ChunkID...)
+	if c.order == binary.BigEndian {
+		hdr = append(hdr[:0], BigEndianChunkID...)
+	}
+	hdr = c.order.AppendUint32(hdr, uint32(riffSize))
+	hdr = append(hdr, WaveID...)
+	hdr = append(hdr, fmtChunk...)
+	hdr = append(hdr, Subchunk2ID...)
+	hdr = c.order.AppendUint32(hdr, uint32(dataSize))
+	if _, err := writer.Write(hdr); err != nil {
+		return err
+	}
+
+	// the samples are encoded a block at a time into a buffer of whole sample frames
+	block := len(samples)
+	if (c.ctx != nil || c.progress != nil) && c.bufferSize <= 0 {
+		c.bufferSize = contextBlock
+	}
+	if c.bufferSize > 0 {
+		block = c.bufferSize / wfmt.BlockAlign * wfmt.NumChannels
+		if block < wfmt.NumChannels {
+			block = wfmt.NumChannels
+		}
+	}
+	if block > len(samples) {
+		block = len(samples)
+	}
+	buf := acquireBytes(block * width)
+	defer releaseBytes(buf)
+	float := wfmt.isFloat()
+	var dithered []Frame
+	if c.dither && !float {
+		dithered = AcquireFrames(block)
+		defer ReleaseFrames(dithered)
+	}
+	clipped := 0
+	scale := float64(maxValues[wfmt.BitsPerSample])
+	if wfmt.BitsPerSample == 24 {
+		scale = 1 << 23
+	}
+	for start := 0; start < len(samples); start += block {
+		if c.ctx != nil {
+			if err := c.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		end := start + block
+		if end > len(samples) {
+			end = len(samples)
+		}
+		frames := samples[start:end]
+		for i, s := range frames {
+			if s > 1 || s < -1 {
+				if c.failOnClip {
+					return fmt.Errorf("Frame %v is %v: %w", start+i, s, ErrClipped)
+				}
+				if !float {
+					clipped++
+					if c.clips != nil {
+						c.clips.add((start + i) / wfmt.NumChannels)
+					}
+				}
+			}
+		}
+		if dithered != nil {
+			for i, s := range frames {
+				dithered[i] = s + Frame((rand.Float64()-rand.Float64())/scale)
+			}
+			frames = dithered[:len(frames)]
+		}
+		n, err := EncodeFrames(buf, frames, wfmt)
+		if err != nil {
+			return err
+		}
+		if c.order == binary.BigEndian {
+			swapSamples(buf[:n], width)
+		}
+		if _, err := writer.Write(buf[:n]); err != nil {
+			return err
+		}
+		if c.progress != nil {
+			c.progress(int64(end), int64(len(samples)))
+		}
+	}
+
+	if clipped > 0 {
+		logDebug(c.logger, "clamped samples past full scale", "samples", clipped)
+	}
+	tail := []byte{}
+	if dataSize%2 == 1 {
+		tail = append(tail, 0)
+	}
+	for _, chunk := range c.chunks {
+		tail = append(tail, chunk.id...)
+		tail = c.order.AppendUint32(tail, uint32(len(chunk.data)))
+		tail = append(tail, chunk.data...)
+		if len(chunk.data)%2 == 1 {
+			tail = append(tail, 0)
+		}
+	}
+	_, err := writer.Write(tail)
+	return err
+}
+
+// floatFormat returns the format with floats of some bits as samples
+func floatFormat(wfmt WaveFmt, bits int) WaveFmt {
+	if wfmt.AudioFormat == EXTENSIBLE && len(wfmt.ExtraParams) >= 8 {
+		// the valid bits and the GUID of the format of the samples
+		wfmt.ExtraParams = append([]byte{}, wfmt.ExtraParams...)
+		binary.LittleEndian.PutUint16(wfmt.ExtraParams[0:2], uint16(bits))
+		binary.LittleEndian.PutUint16(wfmt.ExtraParams[6:8], IEEE_FLOAT)
+	} else {
+		wfmt.AudioFormat = IEEE_FLOAT
+	}
+	wfmt.BitsPerSample = bits
+	wfmt.BlockAlign = wfmt.NumChannels * bits / 8
+	wfmt.ByteRate = wfmt.SampleRate * wfmt.BlockAlign
+	return wfmt
+}
@@ -0,0 +1,81 @@
+package wave
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// maxPoolClass is the largest buffer kept in the pools, as a power of two
+const maxPoolClass = 27
+
+// the pools hold buffers by the power of two of their capacity, so a buffer fits any request
+// up to its capacity
+var (
+	framePools [maxPoolClass + 1]sync.Pool
+	bytePools  [maxPoolClass + 1]sync.Pool
+)
+
+// poolClass returns the power of two holding n elements
+func poolClass(n int) int {
+	return bits.Len(uint(n - 1))
+}
+
+// pooled returns the class of a buffer which can go back into a pool, or -1
+func pooled(capacity int) int {
+	if capacity == 0 || capacity&(capacity-1) != 0 {
+		return -1
+	}
+	if c := poolClass(capacity); c <= maxPoolClass {
+		return c
+	}
+	return -1
+}
+
+// AcquireFrames returns a buffer of n frames, reusing a released buffer when one is free.
+// The frames are not cleared. Buffers which are done with can be given back with
+// ReleaseFrames, so processing many files doesn't keep the garbage collector busy.
+func AcquireFrames(n int) []Frame {
+	if n <= 0 {
+		return []Frame{}
+	}
+	c := poolClass(n)
+	if c > maxPoolClass {
+		return make([]Frame, n)
+	}
+	if b, ok := framePools[c].Get().(*[]Frame); ok {
+		return (*b)[:n]
+	}
+	return make([]Frame, n, 1<<c)
+}
+
+// ReleaseFrames gives a buffer from AcquireFrames, or the frames read by ReadWaveFile, back
+// to be reused. The buffer should not be used afterwards.
+func ReleaseFrames(frames []Frame) {
+	if c := pooled(cap(frames)); c >= 0 {
+		frames = frames[:cap(frames)]
+		framePools[c].Put(&frames)
+	}
+}
+
+// acquireBytes returns a buffer of n bytes, which are not cleared
+func acquireBytes(n int) []byte {
+	if n <= 0 {
+		return []byte{}
+	}
+	c := poolClass(n)
+	if c > maxPoolClass {
+		return make([]byte, n)
+	}
+	if b, ok := bytePools[c].Get().(*[]byte); ok {
+		return (*b)[:n]
+	}
+	return make([]byte, n, 1<<c)
+}
+
+// releaseBytes gives a buffer from acquireBytes back
+func releaseBytes(b []byte) {
+	if c := pooled(cap(b)); c >= 0 {
+		b = b[:cap(b)]
+		bytePools[c].Put(&b)
+	}
+}
@@ -0,0 +1,30 @@
+package wave
+
+import "io"
+
+// Progress is called as a long operation goes on, with the amount done out of the total.
+// Depending on the operation these are frames or bytes, a total of -1 is unknown.
+type Progress func(done, total int64)
+
+// progressReader reports the bytes read from a reader
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress Progress
+}
+
+// NewProgressReader reports the bytes read through it out of total, such as the size of a
+// file, so reading can be followed with ReadWaveFromReader or NewDecoder
+func NewProgressReader(r io.Reader, total int64, progress Progress) io.Reader {
+	return &progressReader{r: r, total: total, progress: progress}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
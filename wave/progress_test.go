@@ -0,0 +1,50 @@
+package wave
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	frames := make([]Frame, 2*50000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+
+	// the reports of a write go up to the total
+	reports := [][2]int64{}
+	buf := bytes.Buffer{}
+	progress := func(done, total int64) { reports = append(reports, [2]int64{done, total}) }
+	if err := WriteWaveToWriter(frames, wfmt, &buf, WithProgress(progress)); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) < 2 {
+		t.Fatalf("Expected a report per block, got %v", reports)
+	}
+	for i, r := range reports {
+		if r[1] != int64(len(frames)) || (i > 0 && r[0] <= reports[i-1][0]) {
+			t.Fatalf("Expected growing reports out of %v, got %v", len(frames), reports)
+		}
+	}
+	if last := reports[len(reports)-1]; last[0] != last[1] {
+		t.Fatalf("Expected the last report to be complete, got %v", last)
+	}
+
+	// reading reports the bytes read
+	data := buf.Bytes()
+	var read int64
+	w, err := ReadWaveFromReader(NewProgressReader(bytes.NewReader(data), int64(len(data)), func(done, total int64) {
+		if total != int64(len(data)) || done < read {
+			t.Fatalf("Expected growing reports out of %v, got %v of %v", len(data), done, total)
+		}
+		read = done
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != int64(len(data)) || len(w.Frames) != len(frames) {
+		t.Fatalf("Expected to read %v bytes of %v frames, got %v bytes of %v", len(data), len(frames), read, len(w.Frames))
+	}
+}
@@ -0,0 +1,141 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// RawWave is the sample data of a .wav file as it is stored. Copying, trimming and joining
+// it moves the bytes without converting the samples to frames and back, so edits are fast
+// and keep every bit of the samples.
+type RawWave struct {
+	WaveFmt
+	Data []byte // interleaved samples in the format of the file
+}
+
+// ReadRawWave reads the format and sample data of a .wav, RIFX or RF64 file. The samples of
+// a RIFX file are turned little endian, as WriteTo writes them.
+func ReadRawWave(r io.Reader) (RawWave, error) {
+	d, err := NewDecoder(r)
+	if err != nil {
+		return RawWave{}, err
+	}
+	if d.BlockAlign <= 0 {
+		return RawWave{}, fmt.Errorf("%w: block align %v", ErrInvalidFormat, d.BlockAlign)
+	}
+	if d.size >= 0 {
+		// the buffer grows with what is read, rather than trusting the size in the header
+		r = io.LimitReader(r, d.size)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return RawWave{}, err
+	}
+	// a truncated file ends at the last complete sample frame
+	data = data[:len(data)/d.BlockAlign*d.BlockAlign]
+	if d.order == binary.BigEndian {
+		swapSamples(data, d.BitsPerSample/8)
+	}
+	return RawWave{WaveFmt: d.WaveFmt, Data: data}, nil
+}
+
+// ReadRawWaveFile reads the format and sample data of a .wav or RF64 file on disk
+func ReadRawWaveFile(path string) (RawWave, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return RawWave{}, err
+	}
+	defer f.Close()
+	return ReadRawWave(f)
+}
+
+// Length returns the amount of samples per channel
+func (w RawWave) Length() int64 {
+	if w.BlockAlign == 0 {
+		return 0
+	}
+	return int64(len(w.Data) / w.BlockAlign)
+}
+
+// Trim returns the samples from start up to end, counted per channel. It shares the data.
+func (w RawWave) Trim(start, end int64) (RawWave, error) {
+	if start < 0 || end < start || end > w.Length() {
+		return RawWave{}, fmt.Errorf("Can't trim samples %v to %v of %v", start, end, w.Length())
+	}
+	align := int64(w.BlockAlign)
+	w.Data = w.Data[start*align : end*align]
+	return w, nil
+}
+
+// ConcatRaw joins waves of the same format into a new one
+func ConcatRaw(waves ...RawWave) (RawWave, error) {
+	if len(waves) == 0 {
+		return RawWave{}, errors.New("Need at least one wave")
+	}
+	size := 0
+	first := waves[0].WaveFmt
+	for _, w := range waves {
+		if w.AudioFormat != first.AudioFormat || w.NumChannels != first.NumChannels ||
+			w.SampleRate != first.SampleRate || w.BitsPerSample != first.BitsPerSample {
+			return RawWave{}, errors.New("Waves have different formats")
+		}
+		size += len(w.Data)
+	}
+	data := make([]byte, 0, size)
+	for _, w := range waves {
+		data = append(data, w.Data...)
+	}
+	return RawWave{WaveFmt: first, Data: data}, nil
+}
+
+// Frames converts the samples to frames, for the 16, 24 and 32 bit integer formats and the
+// 32 and 64 bit float formats
+func (w RawWave) Frames() ([]Frame, error) {
+	if err := w.checkBitDepth(); err != nil {
+		return nil, err
+	}
+	frames := make([]Frame, len(w.Data)/(w.BitsPerSample/8))
+	decodeSamples(frames, w.Data, w.WaveFmt, binary.LittleEndian)
+	return frames, nil
+}
+
+// WriteTo writes the wave as a .wav file, with the samples as they are
+func (w RawWave) WriteTo(writer io.Writer) (int64, error) {
+	fmtChunk := fmtToBytes(w.WaveFmt)
+	// the size of the format read has to match the chunk written
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(len(fmtChunk)-8))
+	pad := len(w.Data) % 2
+
+	hdr := append([]byte{}, ChunkID...)
+	hdr = appendInt32(hdr, 4+len(fmtChunk)+8+len(w.Data)+pad)
+	hdr = append(hdr, WaveID...)
+	hdr = append(hdr, fmtChunk...)
+	hdr = append(hdr, Subchunk2ID...)
+	hdr = appendInt32(hdr, len(w.Data))
+	written := int64(0)
+	for _, b := range [][]byte{hdr, w.Data, make([]byte, pad)} {
+		n, err := writer.Write(b)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// WriteFile writes the wave to a .wav file on disk
+func (w RawWave) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,99 @@
+package wave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"runtime"
+	"testing"
+)
+
+func TestRawWave(t *testing.T) {
+	frames := make([]Frame, 2*1000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 10))
+	}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	w, err := ReadRawWave(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Length() != 1000 {
+		t.Fatalf("Expected 1000 samples, got %v", w.Length())
+	}
+
+	// the last 100 samples followed by the first 101
+	tail, err := w.Trim(900, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := w.Trim(0, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined, err := ConcatRaw(tail, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := bytes.Buffer{}
+	if _, err := joined.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadRawWave(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read.Data, append(append([]byte{}, w.Data[3600:]...), w.Data[:404]...)) {
+		t.Fatal("Expected the samples to be copied as they are")
+	}
+	decoded, err := read.Frames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(append([]Frame{}, frames[1800:]...), frames[:202]...)
+	if len(decoded) != len(expected) {
+		t.Fatalf("Expected %v frames, got %v", len(expected), len(decoded))
+	}
+	for i, f := range decoded {
+		if math.Abs(float64(f-expected[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", expected[i], i, f)
+		}
+	}
+
+	if _, err := w.Trim(10, 1001); err == nil {
+		t.Fatal("Expected an error trimming past the end")
+	}
+	mono := RawWave{WaveFmt: NewWaveFmt(1, 1, 8000, 16, nil)}
+	if _, err := ConcatRaw(w, mono); err == nil {
+		t.Fatal("Expected an error joining different formats")
+	}
+}
+
+// TestReadRawWaveTruncated reads a file claiming 4GB of samples, which has 9 bytes of them
+func TestReadRawWaveTruncated(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(make([]Frame, 8), NewWaveFmt(1, 2, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()[:53]
+	binary.LittleEndian.PutUint32(b[40:44], 0xFFFFFFF0)
+
+	before := runtime.MemStats{}
+	runtime.ReadMemStats(&before)
+	w, err := ReadRawWave(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := runtime.MemStats{}
+	runtime.ReadMemStats(&after)
+	if len(w.Data) != 8 {
+		t.Fatalf("Expected the 2 complete samples, got %v bytes", len(w.Data))
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+		t.Fatalf("Expected the buffer not to follow the size in the header, allocated %v bytes", alloc)
+	}
+}
@@ -1,12 +1,14 @@
 package wave
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"math"
 	"os"
-	"strings"
 )
 
 // type aliases for conversion functions
@@ -18,7 +20,7 @@ type (
 var (
 	// figure out which 'to int' function to use..
 	byteSizeToIntFunc = map[int]bytesToIntF{
-		16: bits16ToInt,
+		16: sample16ToInt,
 		24: bits24ToInt,
 		32: bits32ToInt,
 	}
@@ -47,7 +49,27 @@ func ReadWaveFile(f string) (Wave, error) {
 	}
 	defer file.Close()
 
-	return ReadWaveFromReader(file)
+	w, err := ReadWaveFromReader(file)
+	if err != nil {
+		return Wave{}, fmt.Errorf("Reading %v: %w", f, err)
+	}
+	return w, nil
+}
+
+// ReadWaveFromFS parses a .wave file of a file system, such as the files embedded with
+// go:embed or an fstest.MapFS
+func ReadWaveFromFS(fsys fs.FS, name string) (Wave, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Wave{}, err
+	}
+	defer file.Close()
+
+	w, err := ReadWaveFromReader(file)
+	if err != nil {
+		return Wave{}, fmt.Errorf("Reading %v: %w", name, err)
+	}
+	return w, nil
 }
 
 // ReadWaveFromReader parses an io.Reader into a Wave struct
@@ -56,22 +78,36 @@ func ReadWaveFromReader(reader io.Reader) (Wave, error) {
 	if err != nil {
 		return Wave{}, err
 	}
+	if len(data) < 12 {
+		return Wave{}, ErrNotRIFF
+	}
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return Wave{}, err
+	}
 
-	data = deleteJunk(data)
-
-	hdr := readHeader(data)
-
-	wfmt := readFmt(data)
-
-	wavdata := readData(data, wfmt)
-
-	frames := parseRawData(wfmt, wavdata.RawData)
-	wavdata.Frames = frames
+	// the decoder stops at the samples, which follow the header of the data chunk
+	raw := data[d.start:]
+	if d.size >= 0 && d.size < int64(len(raw)) {
+		raw = raw[:d.size]
+	}
+	frames := make([]Frame, len(raw)/(d.BitsPerSample/8))
+	decodeSamples(frames, raw, d.WaveFmt, d.order)
 
 	return Wave{
-		WaveHeader: hdr,
-		WaveFmt:    wfmt,
-		WaveData:   wavdata,
+		WaveHeader: WaveHeader{
+			ChunkID:   data[0:4],
+			ChunkSize: int(int32(d.order.Uint32(data[4:8]))),
+			Format:    string(data[8:12]),
+		},
+		WaveFmt: d.WaveFmt,
+		WaveData: WaveData{
+			Subchunk2ID:   data[d.start-8 : d.start-4],
+			Subchunk2Size: int(int32(d.order.Uint32(data[d.start-4 : d.start]))),
+			RawData:       raw,
+			Frames:        frames,
+		},
+		Sampler: readSampler(data, int(d.start)+len(raw), d.order),
 	}, nil
 }
 
@@ -98,6 +134,11 @@ func bits16ToInt(b []byte) int {
 	return int(out)
 }
 
+// samples are signed, unlike the 16-bit fields of the header
+func sample16ToInt(b []byte) int {
+	return int(int16(bits16ToInt(b)))
+}
+
 func bits24ToInt(b []byte) int {
 	_ = b[2]
 	out := (int32(b[2]) << 24) | (int32(b[1]) << 16) | (int32(b[0]) << 8)
@@ -111,36 +152,46 @@ func bits32ToInt(b []byte) int {
 	return int(out)
 }
 
-func readData(b []byte, wfmt WaveFmt) WaveData {
-	wd := WaveData{}
-
-	start := 36 + wfmt.ExtraParamSize
-	subchunk2ID := b[start : start+4]
-	wd.Subchunk2ID = subchunk2ID
-
-	subsize := bits32ToInt(b[start+4 : start+8])
-	wd.Subchunk2Size = subsize
-
-	wd.RawData = b[start+8:]
-
-	return wd
+// readSampler looks for a smpl chunk in the chunks following the data, which ends at end
+func readSampler(b []byte, end int, order binary.ByteOrder) *SamplerInfo {
+	// chunks are padded to an even size
+	i := end + end%2
+	for i+8 <= len(b) {
+		id := string(b[i : i+4])
+		size := int(order.Uint32(b[i+4 : i+8]))
+		if size < 0 || i+8+size > len(b) {
+			return nil
+		}
+		if id == "smpl" {
+			return parseSampler(b[i+8:i+8+size], order)
+		}
+		logDebug(nil, "skipping chunk", "id", id, "offset", i, "size", size)
+		i += 8 + size + size%2
+	}
+	return nil
 }
 
-// Should we do n-channel separation at this point?
-func parseRawData(wfmt WaveFmt, rawdata []byte) []Frame {
-	bytesSampleSize := wfmt.BitsPerSample / 8
-	// TODO: sanity-check that this is a power of 2? I think only those sample sizes are
-	// possible
-
-	frames := []Frame{}
-	// read the chunks
-	for i := 0; i < len(rawdata); i += bytesSampleSize {
-		rawFrame := rawdata[i : i+bytesSampleSize]
-		unscaledFrame := byteSizeToIntFunc[wfmt.BitsPerSample](rawFrame)
-		scaled := scaleFrame(unscaledFrame, wfmt.BitsPerSample)
-		frames = append(frames, scaled)
-	}
-	return frames
+// parseSampler parses the body of a smpl chunk
+func parseSampler(b []byte, order binary.ByteOrder) *SamplerInfo {
+	if len(b) < 36 {
+		return nil
+	}
+	u32 := func(b []byte) int { return int(int32(order.Uint32(b))) }
+	info := &SamplerInfo{
+		UnityNote:     u32(b[12:16]),
+		PitchFraction: int(order.Uint32(b[16:20])),
+	}
+	n := u32(b[28:32])
+	for l := 0; l < n && 36+24*(l+1) <= len(b); l++ {
+		loop := b[36+24*l : 36+24*(l+1)]
+		info.Loops = append(info.Loops, SampleLoop{
+			Type:      u32(loop[4:8]),
+			Start:     u32(loop[8:12]),
+			End:       u32(loop[12:16]),
+			PlayCount: u32(loop[20:24]),
+		})
+	}
+	return info
 }
 
 func scaleFrame(unscaled, bits int) Frame {
@@ -149,30 +200,6 @@ func scaleFrame(unscaled, bits int) Frame {
 
 }
 
-// deleteJunk will remove the JUNK chunks if they are present
-func deleteJunk(b []byte) []byte {
-	var junkStart, junkEnd int
-
-	for i := 0; i < len(b)-4; i++ {
-		if strings.ToLower(string(b[i:i+4])) == "junk" {
-			junkStart = i
-		}
-
-		if strings.ToLower(string(b[i:i+3])) == "fmt" {
-			junkEnd = i
-		}
-	}
-
-	if junkStart != 0 {
-		cpy := make([]byte, len(b[0:junkStart]))
-		copy(cpy, b[0:junkStart])
-		cpy = append(cpy, b[junkEnd:]...)
-		return cpy
-	}
-
-	return b
-}
-
 // readFmt parses the FMT portion of the WAVE file
 // assumes the entire binary representation is passed!
 func readFmt(b []byte) WaveFmt {
@@ -203,32 +230,16 @@ func readFmt(b []byte) WaveFmt {
 
 	// parse extra (optional) elements..
 
-	if subchunksize != 16 {
+	if subchunksize > 16 && len(b) >= 38 {
 		// only for compressed files (non-PCM)
 		extraSize := bits16ToInt(b[36:38])
+		if 38+extraSize > len(b) {
+			logDebug(nil, "fmt extension cut off", "size", extraSize, "available", len(b)-38)
+			extraSize = len(b) - 38
+		}
 		wfmt.ExtraParamSize = extraSize
 		wfmt.ExtraParams = b[38 : 38+extraSize]
 	}
 
 	return wfmt
 }
-
-// TODO: make safe.
-func readHeader(b []byte) WaveHeader {
-	// the start of the bte slice..
-	hdr := WaveHeader{}
-	hdr.ChunkID = b[0:4]
-	if string(hdr.ChunkID) != "RIFF" {
-		panic("Invalid file")
-	}
-
-	chunkSize := b[4:8]
-	hdr.ChunkSize = bits32ToInt(chunkSize) // easier to work with ints
-
-	format := b[8:12]
-	if string(format) != "WAVE" {
-		panic("Format should be WAVE")
-	}
-	hdr.Format = string(format)
-	return hdr
-}
@@ -1,8 +1,13 @@
 package wave
 
 import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
 	"runtime/debug"
 	"testing"
+	"testing/fstest"
 )
 
 var (
@@ -79,6 +84,35 @@ func TestReadFile(t *testing.T) {
 	}
 }
 
+func TestReadWaveFromFS(t *testing.T) {
+	wav, err := ReadWaveFromFS(os.DirFS("golden"), "maybe-next-time.wav")
+	if err != nil {
+		t.Fatalf("Should be able to read wave file: %v", err)
+	}
+	if wav.SampleRate != 44100 || wav.NumChannels != 2 {
+		t.Fatalf("Expected 44100Hz stereo, got %vHz with %v channels", wav.SampleRate, wav.NumChannels)
+	}
+
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter([]Frame{0, .5, -.5}, NewWaveFmt(1, 1, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"sounds/beep.wav": {Data: buf.Bytes()},
+		"sounds/text.wav": {Data: []byte("not a wave file")},
+	}
+	wav, err = ReadWaveFromFS(fsys, "sounds/beep.wav")
+	if err != nil || len(wav.Frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %v (%v)", len(wav.Frames), err)
+	}
+	if _, err := ReadWaveFromFS(fsys, "sounds/missing.wav"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected a missing file, got %v", err)
+	}
+	if _, err := ReadWaveFromFS(fsys, "sounds/text.wav"); !errors.Is(err, ErrNotRIFF) {
+		t.Fatalf("Expected a file which isn't RIFF, got %v", err)
+	}
+}
+
 func TestScaleFrames(t *testing.T) {
 	for _, test := range scaleFrameTests {
 		t.Run("", func(t *testing.T) {
@@ -114,3 +148,75 @@ func TestJunkChunkFile(t *testing.T) {
 		t.Fatalf("Expected 2 channels, got: %v", wav.NumChannels)
 	}
 }
+
+// TestSamplerChunk ensures chunks after the data are not read as samples and the smpl chunk is parsed
+func TestSamplerChunk(t *testing.T) {
+	frames := []Frame{0, .5, -.5, 0}
+	buf := &bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 1, 8000, 16, nil), buf); err != nil {
+		t.Fatalf("Should be able to write wave: %v", err)
+	}
+
+	smpl := []byte("smpl")
+	smpl = appendInt32(smpl, 36+24)
+	for _, v := range []int{0, 0, 125000, 69, 0, 0, 0, 1, 0} {
+		smpl = appendInt32(smpl, v)
+	}
+	for _, v := range []int{0, 0, 1, 2, 0, 0} {
+		smpl = appendInt32(smpl, v)
+	}
+
+	wav, err := ReadWaveFromReader(bytes.NewReader(append(buf.Bytes(), smpl...)))
+	if err != nil {
+		t.Fatalf("Should be able to read wave: %v", err)
+	}
+	if len(wav.Frames) != len(frames) {
+		t.Fatalf("Expected %v frames, got %v", len(frames), len(wav.Frames))
+	}
+	if wav.Sampler == nil {
+		t.Fatal("Expected the smpl chunk to be read")
+	}
+	if wav.Sampler.UnityNote != 69 {
+		t.Fatalf("Expected unity note 69, got %v", wav.Sampler.UnityNote)
+	}
+	if len(wav.Sampler.Loops) != 1 || wav.Sampler.Loops[0].Start != 1 || wav.Sampler.Loops[0].End != 2 {
+		t.Fatalf("Expected a loop from 1 to 2, got %v", wav.Sampler.Loops)
+	}
+}
+
+// TestSigned16 ensures 16 bit samples are read as two's complement, so negative samples
+// stay negative
+func TestSigned16(t *testing.T) {
+	frames := []Frame{0, -.5, .25, -1}
+	buf := &bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 1, 8000, 16, nil), buf); err != nil {
+		t.Fatalf("Should be able to write wave: %v", err)
+	}
+	if b := buf.Bytes()[46:48]; b[0] != 0x01 || b[1] != 0xC0 {
+		t.Fatalf("Expected -.5 to be stored as 0xC001, got % x", b)
+	}
+	wav, err := ReadWaveFromReader(buf)
+	if err != nil {
+		t.Fatalf("Should be able to read wave: %v", err)
+	}
+	for i, f := range frames {
+		if d := wav.Frames[i] - f; d > 1e-4 || d < -1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", f, i, wav.Frames[i])
+		}
+	}
+}
+
+// TestReadFramesOwned ensures the frames of a Wave are not borrowed from the pool
+func TestReadFramesOwned(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteWaveToWriter(make([]Frame, 3), NewWaveFmt(1, 1, 8000, 16, nil), buf); err != nil {
+		t.Fatalf("Should be able to write wave: %v", err)
+	}
+	wav, err := ReadWaveFromReader(buf)
+	if err != nil {
+		t.Fatalf("Should be able to read wave: %v", err)
+	}
+	if len(wav.Frames) != 3 || cap(wav.Frames) != 3 {
+		t.Fatalf("Expected 3 frames with a capacity of 3, got %v and %v", len(wav.Frames), cap(wav.Frames))
+	}
+}
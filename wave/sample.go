@@ -0,0 +1,104 @@
+package wave
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Sample is a type holding raw audio data. Frame is used throughout the packages, Frame32
+// holds the same audio in half the memory and bandwidth.
+type Sample interface {
+	~float32 | ~float64
+}
+
+// Frame32 is a single float32 value of raw audio data
+type Frame32 float32
+
+// sampleBlock is the amount of frames samples of other types are converted in at a time
+const sampleBlock = 4096
+
+// ConvertSamples converts samples from one type to another and returns the amount converted
+func ConvertSamples[To, From Sample](dst []To, src []From) int {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	src = src[:len(dst)]
+	for i, s := range src {
+		dst[i] = To(s)
+	}
+	return len(dst)
+}
+
+// EncodeSamples is EncodeFrames for samples of any type
+func EncodeSamples[T Sample](dst []byte, samples []T, wfmt WaveFmt) (int, error) {
+	if err := wfmt.checkBitDepth(); err != nil {
+		return 0, err
+	}
+	n := len(samples) * wfmt.BitsPerSample / 8
+	if len(dst) < n {
+		return 0, errShortBuffer
+	}
+	// the same as rescaleFrame, without looking up the scale for every sample
+	scale := float64(maxValues[wfmt.BitsPerSample])
+	switch {
+	case wfmt.isFloat() && wfmt.BitsPerSample == 64:
+		for i, s := range samples {
+			binary.LittleEndian.PutUint64(dst[8*i:], math.Float64bits(float64(s)))
+		}
+	case wfmt.isFloat():
+		for i, s := range samples {
+			binary.LittleEndian.PutUint32(dst[4*i:], math.Float32bits(float32(s)))
+		}
+	case wfmt.BitsPerSample == 16:
+		for i, s := range samples {
+			binary.LittleEndian.PutUint16(dst[2*i:], uint16(clampSample(float64(s)*scale, 16)))
+		}
+	case wfmt.BitsPerSample == 24:
+		for i, s := range samples {
+			v := clampSample(float64(s)*(1<<23), 24)
+			dst[3*i], dst[3*i+1], dst[3*i+2] = byte(v), byte(v>>8), byte(v>>16)
+		}
+	case wfmt.BitsPerSample == 32:
+		for i, s := range samples {
+			binary.LittleEndian.PutUint32(dst[4*i:], uint32(clampSample(float64(s)*scale, 32)))
+		}
+	}
+	return n, nil
+}
+
+// ReadSamples reads up to len(dst) samples from a decoder, converting the frames it decodes
+// a block at a time, so a whole file can be held as Frame32 without a copy as Frames
+func ReadSamples[T Sample](d Decoder, dst []T) (int, error) {
+	block := AcquireFrames(sampleBlock)
+	defer ReleaseFrames(block)
+	n := 0
+	for n < len(dst) {
+		size := len(dst) - n
+		if size > len(block) {
+			size = len(block)
+		}
+		read, err := d.Read(block[:size])
+		n += ConvertSamples(dst[n:n+read], block[:read])
+		if err != nil {
+			return n, err
+		}
+		if read == 0 {
+			break
+		}
+	}
+	return n, nil
+}
+
+// WriteSamples writes samples of any type to an encoder, such as a WaveWriter, converting
+// them to frames a block at a time
+func WriteSamples[T Sample](e Encoder, samples []T) error {
+	block := AcquireFrames(sampleBlock)
+	defer ReleaseFrames(block)
+	for start := 0; start < len(samples); start += len(block) {
+		n := ConvertSamples(block, samples[start:])
+		if err := e.Write(block[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
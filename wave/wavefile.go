@@ -1,5 +1,10 @@
 package wave
 
+import (
+	"encoding/binary"
+	"fmt"
+)
+
 // representation of the wave file, used by reader.go and writer.go
 
 // Frame is a single float64 value of raw audio data
@@ -55,6 +60,7 @@ type Wave struct {
 	WaveHeader
 	WaveFmt
 	WaveData
+	Sampler *SamplerInfo // contents of the smpl chunk, nil when the file has none
 }
 
 // WaveHeader describes the header each WAVE file should start with
@@ -86,11 +92,41 @@ type WaveData struct {
 	Frames        []Frame
 }
 
-// NewWaveFmt can be used to generate a complete WaveFmt by calculating the remaining props
+// SamplerInfo describes the smpl chunk, which holds the pitch and loop points of an instrument sample
+type SamplerInfo struct {
+	UnityNote     int // MIDI note at which the sample plays at its original pitch
+	PitchFraction int // fine tuning above the unity note, as a fraction of a semitone (0x80000000 is 50 cents)
+	Loops         []SampleLoop
+}
+
+// SampleLoop is a loop inside the sample data, Start and End are inclusive offsets in frames
+type SampleLoop struct {
+	Type      int // 0 = forward, 1 = alternating, 2 = backward
+	Start     int
+	End       int
+	PlayCount int // 0 loops forever
+}
+
+// Audio formats of the fmt chunk
+const (
+	PCM        = 1
+	IEEE_FLOAT = 3
+	EXTENSIBLE = 0xFFFE
+)
+
+// NewWaveFmt can be used to generate a complete WaveFmt by calculating the remaining props.
+// The extra params, such as the extension of the EXTENSIBLE format, follow the size of
+// the extension in the fmt chunk. The combination is not checked, so it can describe formats
+// which are only read, such as compressed ones, and keeps its signature; formats to write are
+// better made with NewValidWaveFmt or checked with Validate.
 func NewWaveFmt(format, channels, samplerate, bitspersample int, extraparams []byte) WaveFmt {
+	size := 16
+	if len(extraparams) > 0 {
+		size = 18 + len(extraparams)
+	}
 	return WaveFmt{
 		Subchunk1ID:    Format,
-		Subchunk1Size:  16, // assume PCM for now
+		Subchunk1Size:  size,
 		AudioFormat:    format,
 		NumChannels:    channels,
 		SampleRate:     samplerate,
@@ -102,6 +138,79 @@ func NewWaveFmt(format, channels, samplerate, bitspersample int, extraparams []b
 	}
 }
 
+// NewValidWaveFmt is NewWaveFmt for the PCM and IEEE_FLOAT formats, which returns the error
+// of Validate for a combination which can't be written and read back
+func NewValidWaveFmt(format, channels, samplerate, bitspersample int) (WaveFmt, error) {
+	wfmt := NewWaveFmt(format, channels, samplerate, bitspersample, nil)
+	if err := wfmt.Validate(); err != nil {
+		return WaveFmt{}, err
+	}
+	return wfmt, nil
+}
+
+// Validate checks that the fields of the format agree with each other, and that the samples
+// are integers of 16, 24 or 32 bits or floats of 32 or 64 bits, so the files written with it
+// can be read back
+func (wfmt WaveFmt) Validate() error {
+	if wfmt.NumChannels < 1 || wfmt.NumChannels > 0xFFFF {
+		return fmt.Errorf("%w: %v channels", ErrInvalidFormat, wfmt.NumChannels)
+	}
+	if wfmt.SampleRate < 1 {
+		return fmt.Errorf("%w: sample rate of %v", ErrInvalidFormat, wfmt.SampleRate)
+	}
+	switch wfmt.AudioFormat {
+	case PCM, IEEE_FLOAT:
+	case EXTENSIBLE:
+		// the valid bits, channel mask and format of the samples
+		if len(wfmt.ExtraParams) < 22 {
+			return fmt.Errorf("%w: extension of %v bytes", ErrInvalidFormat, len(wfmt.ExtraParams))
+		}
+		if f := binary.LittleEndian.Uint16(wfmt.ExtraParams[6:8]); f != PCM && f != IEEE_FLOAT {
+			return fmt.Errorf("%w: samples of format %v", ErrInvalidFormat, f)
+		}
+	default:
+		return fmt.Errorf("%w: audio format %v", ErrInvalidFormat, wfmt.AudioFormat)
+	}
+	if err := wfmt.checkBitDepth(); err != nil {
+		return err
+	}
+	if align := wfmt.NumChannels * wfmt.BitsPerSample / 8; wfmt.BlockAlign != align {
+		return fmt.Errorf("%w: block align %v should be %v", ErrInvalidFormat, wfmt.BlockAlign, align)
+	}
+	if rate := wfmt.SampleRate * wfmt.BlockAlign; wfmt.ByteRate != rate {
+		return fmt.Errorf("%w: byte rate %v should be %v", ErrInvalidFormat, wfmt.ByteRate, rate)
+	}
+	size := 16
+	if len(wfmt.ExtraParams) > 0 {
+		size = 18 + len(wfmt.ExtraParams)
+	}
+	if wfmt.Subchunk1Size != size && !(wfmt.Subchunk1Size == 18 && size == 16) {
+		return fmt.Errorf("%w: fmt chunk of %v bytes should be %v", ErrInvalidFormat, wfmt.Subchunk1Size, size)
+	}
+	return nil
+}
+
+// isFloat tells whether the samples are IEEE floats, which the EXTENSIBLE format tells in
+// the first bytes of the GUID of its extension
+func (wfmt WaveFmt) isFloat() bool {
+	if wfmt.AudioFormat == EXTENSIBLE && len(wfmt.ExtraParams) >= 8 {
+		return binary.LittleEndian.Uint16(wfmt.ExtraParams[6:8]) == IEEE_FLOAT
+	}
+	return wfmt.AudioFormat == IEEE_FLOAT
+}
+
+// checkBitDepth returns ErrUnsupportedBitDepth for samples which can't be read or written,
+// which are integers of 16, 24 or 32 bits and floats of 32 or 64 bits
+func (wfmt WaveFmt) checkBitDepth() error {
+	switch b := wfmt.BitsPerSample; {
+	case wfmt.isFloat() && (b == 32 || b == 64):
+	case !wfmt.isFloat() && (b == 16 || b == 24 || b == 32):
+	default:
+		return ErrUnsupportedBitDepth{b}
+	}
+	return nil
+}
+
 // SetChannels changes the FMT to adapt to a new amount of channels
 func (wfmt *WaveFmt) SetChannels(n uint) {
 	wfmt.NumChannels = int(n)
@@ -0,0 +1,88 @@
+package wave
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// 24 valid bits of PCM samples
+	extension := []byte{24, 0, 0x3F, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0x10, 0, 0x80, 0, 0, 0xAA, 0, 0x38, 0x9B, 0x71}
+	broken := NewWaveFmt(PCM, 2, 44100, 16, nil)
+	broken.BlockAlign = 2
+	tests := []struct {
+		wfmt WaveFmt
+		err  error
+	}{
+		{NewWaveFmt(PCM, 2, 44100, 16, nil), nil},
+		{NewWaveFmt(PCM, 1, 8000, 24, nil), nil},
+		{NewWaveFmt(IEEE_FLOAT, 2, 48000, 32, nil), nil},
+		{NewWaveFmt(EXTENSIBLE, 6, 48000, 24, extension), nil},
+		{NewWaveFmt(PCM, 0, 44100, 16, nil), ErrInvalidFormat},
+		{NewWaveFmt(PCM, 2, 0, 16, nil), ErrInvalidFormat},
+		{NewWaveFmt(PCM, 2, 44100, 12, nil), ErrUnsupportedBitDepth{12}},
+		{NewWaveFmt(IEEE_FLOAT, 2, 44100, 16, nil), ErrUnsupportedBitDepth{16}},
+		{NewWaveFmt(EXTENSIBLE, 2, 44100, 16, nil), ErrInvalidFormat},
+		{NewWaveFmt(EXTENSIBLE, 2, 44100, 16, make([]byte, 22)), ErrInvalidFormat},
+		// the samples of these can't be read back
+		{NewWaveFmt(PCM, 1, 8000, 8, nil), ErrUnsupportedBitDepth{8}},
+		{NewWaveFmt(2, 1, 8000, 16, nil), ErrInvalidFormat},
+		{broken, ErrInvalidFormat},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			if err := test.wfmt.Validate(); !errors.Is(err, test.err) && err != test.err {
+				t.Fatalf("Expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestNewValidWaveFmt(t *testing.T) {
+	wfmt, err := NewValidWaveFmt(IEEE_FLOAT, 2, 48000, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wfmt.BlockAlign != 16 || wfmt.ByteRate != 48000*16 || wfmt.Subchunk1Size != 16 {
+		t.Fatalf("Expected the derived fields of 2 channels of 64 bits, got %+v", wfmt)
+	}
+	if _, err := NewValidWaveFmt(PCM, 2, 44100, 8); !errors.Is(err, ErrUnsupportedBitDepth{8}) {
+		t.Fatalf("Expected 8 bit samples to be refused, got %v", err)
+	}
+	if _, err := NewValidWaveFmt(EXTENSIBLE, 2, 44100, 16); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected the EXTENSIBLE format without extension to be refused, got %v", err)
+	}
+}
+
+func TestFmtExtension(t *testing.T) {
+	extension := []byte{16, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0x10, 0, 0x80, 0, 0, 0xAA, 0, 0x38, 0x9B, 0x71}
+	wfmt := NewWaveFmt(EXTENSIBLE, 2, 8000, 16, extension)
+	if wfmt.Subchunk1Size != 40 {
+		t.Fatalf("Expected a fmt chunk of 40 bytes, got %v", wfmt.Subchunk1Size)
+	}
+	frames := []Frame{0, .5, -.5, .25}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	w, err := ReadWaveFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w.ExtraParams, extension) || len(w.Frames) != len(frames) {
+		t.Fatalf("Expected the extension and %v frames, got %v and %v", len(frames), w.ExtraParams, len(w.Frames))
+	}
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := make([]Frame, 10)
+	n, _ := d.Read(decoded)
+	for i, f := range frames {
+		if diff := decoded[i] - f; n != len(frames) || diff > 1e-4 || diff < -1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", f, i, decoded[i])
+		}
+	}
+}
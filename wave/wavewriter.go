@@ -0,0 +1,145 @@
+package wave
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// unknownSize is the size in the header of a stream of which the length isn't known up front
+const unknownSize = 0xFFFFFFFF
+
+// maxRIFFSize is the largest size a RIFF header holds, larger files are written as RF64
+var maxRIFFSize int64 = unknownSize - 1
+
+// ds64Size is the size of the body of a ds64 chunk without a table
+const ds64Size = 28
+
+// WaveWriter encodes frames to a .wav stream as they come in, so files of any length can be
+// written without keeping them in memory. When the writer can seek, Close fills in the sizes
+// in the header and switches to RF64 for files over 4GB. On a pipe, such as stdout feeding
+// ffmpeg or sox, the sizes are left at 0xFFFFFFFF, which readers take as "until the end".
+type WaveWriter struct {
+	WaveFmt
+
+	w       io.Writer
+	seeker  io.Seeker // nil when the writer can't seek
+	start   int64     // offset of the header in the writer
+	dataAt  int64     // offset of the data chunk from the start
+	written int64     // bytes of sample data
+	closed  bool
+	buf     []byte // encoded samples, reused between writes
+}
+
+// NewWaveWriter writes the header of a .wav stream. The writer is not closed by the WaveWriter.
+func NewWaveWriter(w io.Writer, wfmt WaveFmt) (*WaveWriter, error) {
+	if err := wfmt.Validate(); err != nil {
+		return nil, err
+	}
+	ww := &WaveWriter{WaveFmt: wfmt, w: w}
+	// a pipe is an *os.File too, but fails to seek
+	if s, ok := w.(io.Seeker); ok {
+		if pos, err := s.Seek(0, io.SeekCurrent); err == nil {
+			ww.seeker, ww.start = s, pos
+		}
+	}
+
+	hdr := make([]byte, 0, 80)
+	hdr = append(hdr, ChunkID...)
+	hdr = binary.LittleEndian.AppendUint32(hdr, unknownSize)
+	hdr = append(hdr, WaveID...)
+	if ww.seeker != nil {
+		// room for the ds64 chunk in case the file grows past 4GB
+		hdr = append(hdr, "JUNK"...)
+		hdr = appendInt32(hdr, ds64Size)
+		hdr = append(hdr, make([]byte, ds64Size)...)
+	}
+	hdr = append(hdr, fmtToBytes(wfmt)...)
+	ww.dataAt = int64(len(hdr))
+	hdr = append(hdr, Subchunk2ID...)
+	hdr = binary.LittleEndian.AppendUint32(hdr, unknownSize)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return ww, nil
+}
+
+// Write encodes frames to the stream
+func (ww *WaveWriter) Write(frames []Frame) error {
+	if ww.closed {
+		return errors.New("WaveWriter is closed")
+	}
+	buf := ww.buffer(len(frames))
+	if _, err := EncodeFrames(buf, frames, ww.WaveFmt); err != nil {
+		return err
+	}
+	return ww.writeRaw(buf)
+}
+
+// buffer returns the reused buffer with room for the bytes of count samples
+func (ww *WaveWriter) buffer(count int) []byte {
+	size := count * ww.BitsPerSample / 8
+	if cap(ww.buf) < size {
+		ww.buf = make([]byte, size)
+	}
+	return ww.buf[:size]
+}
+
+// writeRaw writes encoded samples and counts them
+func (ww *WaveWriter) writeRaw(b []byte) error {
+	n, err := ww.w.Write(b)
+	ww.written += int64(n)
+	return err
+}
+
+// Close pads the sample data to an even size and fills in the sizes when the writer can seek
+func (ww *WaveWriter) Close() error {
+	if ww.closed {
+		return nil
+	}
+	ww.closed = true
+	if ww.written%2 == 1 {
+		if _, err := ww.w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	if ww.seeker == nil {
+		return nil
+	}
+	end, err := ww.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	riffSize := end - ww.start - 8
+	if riffSize <= maxRIFFSize {
+		if err := ww.patch(4, appendInt32(nil, int(riffSize))); err != nil {
+			return err
+		}
+		if err := ww.patch(ww.dataAt+4, appendInt32(nil, int(ww.written))); err != nil {
+			return err
+		}
+	} else {
+		ds64 := binary.LittleEndian.AppendUint32([]byte("RF64"), unknownSize)
+		ds64 = append(ds64, "WAVE"...)
+		ds64 = append(ds64, "ds64"...)
+		ds64 = appendInt32(ds64, ds64Size)
+		ds64 = binary.LittleEndian.AppendUint64(ds64, uint64(riffSize))
+		ds64 = binary.LittleEndian.AppendUint64(ds64, uint64(ww.written))
+		ds64 = binary.LittleEndian.AppendUint64(ds64, uint64(ww.written/int64(ww.BlockAlign)))
+		ds64 = appendInt32(ds64, 0)
+		if err := ww.patch(0, ds64); err != nil {
+			return err
+		}
+	}
+	_, err = ww.seeker.Seek(end, io.SeekStart)
+	return err
+}
+
+// patch overwrites bytes of the header
+func (ww *WaveWriter) patch(offset int64, b []byte) error {
+	if _, err := ww.seeker.Seek(ww.start+offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := ww.w.Write(b)
+	return err
+}
@@ -0,0 +1,171 @@
+package wave
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readAll decodes every frame of a decoder
+func readAll(t *testing.T, d Decoder) []Frame {
+	frames := []Frame{}
+	block := make([]Frame, 100)
+	for {
+		n, err := d.Read(block)
+		frames = append(frames, block[:n]...)
+		if err == io.EOF {
+			return frames
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func compareFrames(t *testing.T, got, want []Frame) {
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v frames, got %v", len(want), len(got))
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", want[i], i, got[i])
+		}
+	}
+}
+
+func TestWaveWriter(t *testing.T) {
+	frames := make([]Frame, 1000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 7))
+	}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		rf64    bool
+		size    uint32 // of the data chunk in the header
+		samples int64
+	}{
+		{"pipe", false, unknownSize, -1},
+		{"file.wav", false, 2000, 500},
+		{"large.wav", true, unknownSize, 500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data []byte
+			if test.name == "pipe" {
+				r, w, err := os.Pipe()
+				if err != nil {
+					t.Fatal(err)
+				}
+				go func() {
+					ww, err := NewWaveWriter(w, wfmt)
+					if err == nil {
+						ww.Write(frames[:300])
+						ww.Write(frames[300:])
+						ww.Close()
+					}
+					w.Close()
+				}()
+				d, err := NewDecoder(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d.Length() != test.samples {
+					t.Fatalf("Expected a length of %v, got %v", test.samples, d.Length())
+				}
+				compareFrames(t, readAll(t, d), frames)
+				r.Close()
+				return
+			}
+
+			if test.rf64 {
+				defer func(max int64) { maxRIFFSize = max }(maxRIFFSize)
+				maxRIFFSize = 1000
+			}
+			name := filepath.Join(dir, test.name)
+			f, err := os.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ww, err := NewWaveWriter(f, wfmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ww.Write(frames[:300])
+			ww.Write(frames[300:])
+			if err := ww.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if data, err = os.ReadFile(name); err != nil {
+				t.Fatal(err)
+			}
+			if (string(data[:4]) == "RF64") != test.rf64 {
+				t.Fatalf("Expected RF64 to be %v, got %q", test.rf64, data[:4])
+			}
+			if size := uint32(bits32ToInt(data[4:8])); !test.rf64 && int(size) != len(data)-8 {
+				t.Fatalf("Expected a RIFF size of %v, got %v", len(data)-8, size)
+			}
+			if size := uint32(bits32ToInt(data[76:80])); size != test.size {
+				t.Fatalf("Expected a data size of %v, got %v", test.size, size)
+			}
+			d, err := NewDecoder(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Length() != test.samples {
+				t.Fatalf("Expected a length of %v, got %v", test.samples, d.Length())
+			}
+			compareFrames(t, readAll(t, d), frames)
+		})
+	}
+}
+
+func TestWaveWriterFormats(t *testing.T) {
+	frames := make([]Frame, 1000)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i)/7) * 0.9)
+	}
+	tests := []struct {
+		format int
+		bits   int
+	}{
+		{1, 16},
+		{1, 24},
+		{1, 32},
+		{3, 32},
+		{3, 64},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			wfmt, err := NewValidWaveFmt(test.format, 2, 48000, test.bits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, w := io.Pipe()
+			go func() {
+				ww, err := NewWaveWriter(w, wfmt)
+				if err != nil {
+					w.CloseWithError(err)
+					return
+				}
+				ww.Write(frames[:300])
+				ww.Write(frames[300:])
+				w.CloseWithError(ww.Close())
+			}()
+			d, err := NewDecoder(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.AudioFormat != test.format || d.BitsPerSample != test.bits {
+				t.Fatalf("Expected format %v with %v bits, got %v with %v bits", test.format, test.bits, d.AudioFormat, d.BitsPerSample)
+			}
+			compareFrames(t, readAll(t, d), frames)
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package wave
 
 import (
+	"context"
 	"encoding/binary"
+	"errors"
 	"io"
 	"os"
+	"slices"
 )
 
 // Consts that appear in the .WAVE file format
@@ -29,39 +32,30 @@ var (
 // WriteFrames writes the slice to disk as a .wav file
 // the WaveFmt metadata needs to be correct
 // WaveData and WaveHeader are inferred from the samples however..
-func WriteFrames(samples []Frame, wfmt WaveFmt, file string) error {
-	return WriteWaveFile(samples, wfmt, file)
+func WriteFrames(samples []Frame, wfmt WaveFmt, file string, opts ...WriteOption) error {
+	return WriteWaveFile(samples, wfmt, file, opts...)
 }
 
-func WriteWaveFile(samples []Frame, wfmt WaveFmt, file string) error {
+// WriteWaveFile writes the frames as a .wav file. A file of which the writing is stopped by
+// the context of WithContext is removed.
+func WriteWaveFile(samples []Frame, wfmt WaveFmt, file string, opts ...WriteOption) error {
 	f, err := os.Create(file)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	return WriteWaveToWriter(samples, wfmt, f)
-}
-
-func WriteWaveToWriter(samples []Frame, wfmt WaveFmt, writer io.Writer) error {
-	wfb := fmtToBytes(wfmt)
-	data, databits := framesToData(samples, wfmt)
-	hdr := createHeader(data)
-
-	_, err := writer.Write(hdr)
-	if err != nil {
-		return err
-	}
-	_, err = writer.Write(wfb)
-	if err != nil {
-		return err
-	}
-	_, err = writer.Write(databits)
-	if err != nil {
-		return err
+	err = WriteWaveToWriter(samples, wfmt, f, opts...)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		f.Close()
+		os.Remove(file)
 	}
+	return err
+}
 
-	return nil
+// WriteWaveToWriter writes the frames as a .wav file, the options change the encoding
+func WriteWaveToWriter(samples []Frame, wfmt WaveFmt, writer io.Writer, opts ...WriteOption) error {
+	return writeWithOptions(samples, wfmt, writer, opts)
 }
 
 func appendInt16(b []byte, i int) []byte {
@@ -74,43 +68,31 @@ func appendInt32(b []byte, i int) []byte {
 	return binary.LittleEndian.AppendUint32(b, in)
 }
 
-func framesToData(frames []Frame, wfmt WaveFmt) (WaveData, []byte) {
-	raw := samplesToRawData(frames, wfmt)
-
-	// We receive frames but have to store the size of the samples
-	// The size of the samples is frames / channels..
-	subchunksize := (len(frames) * wfmt.NumChannels * wfmt.BitsPerSample) / 8
-
-	// construct the data part..
-	b := make([]byte, 0, 8+len(raw))
-	b = append(b, Subchunk2ID...)
-	b = appendInt32(b, subchunksize)
-	b = append(b, raw...)
-
-	wd := WaveData{
-		Subchunk2ID:   Subchunk2ID,
-		Subchunk2Size: subchunksize,
-		RawData:       raw,
-		Frames:        frames,
-	}
-	return wd, b
-}
-
-// Turn the samples into raw data...
-func samplesToRawData(samples []Frame, props WaveFmt) []byte {
-	raw := []byte{}
-	for _, s := range samples {
-		// the samples are scaled - rescale them?
-		rescaled := rescaleFrame(s, props.BitsPerSample)
-		raw = appendIntFm[props.BitsPerSample](raw, rescaled)
-	}
-	return raw
+// EncodeFrames encodes the samples into dst as the raw data of the format and returns the
+// amount of bytes written. dst needs room for len(samples)*BitsPerSample/8 bytes, reusing it
+// between calls encodes without allocating. Integers of 16, 24 and 32 bits and floats of 32
+// and 64 bits are encoded, little endian.
+func EncodeFrames(dst []byte, samples []Frame, wfmt WaveFmt) (int, error) {
+	return EncodeSamples(dst, samples, wfmt)
 }
 
 // rescale frames back to the original values..
 func rescaleFrame(s Frame, bits int) int {
 	rescaled := float64(s) * float64(maxValues[bits])
-	return int(rescaled)
+	return int(clampSample(rescaled, bits))
+}
+
+// clampSample limits a scaled sample to the integers of a bit depth, so frames outside
+// [-1;1] clip rather than wrap around into loud noise
+func clampSample(v float64, bits int) int64 {
+	max := float64(int64(1)<<(bits-1) - 1)
+	if v > max {
+		return int64(max)
+	}
+	if v < -max-1 {
+		return int64(-max - 1)
+	}
+	return int64(v)
 }
 
 func fmtToBytes(wfmt WaveFmt) []byte {
@@ -123,20 +105,29 @@ func fmtToBytes(wfmt WaveFmt) []byte {
 	b = appendInt32(b, wfmt.ByteRate)
 	b = appendInt16(b, wfmt.BlockAlign)
 	b = appendInt16(b, wfmt.BitsPerSample)
+	if wfmt.Subchunk1Size > 16 {
+		b = appendInt16(b, len(wfmt.ExtraParams))
+		b = append(b, wfmt.ExtraParams...)
+	}
 
 	return b
 }
 
-// turn the sample to a valid header
-func createHeader(wd WaveData) []byte {
-	// write chunkID
-
-	chunksize := 36 + wd.Subchunk2Size
-
-	bits := make([]byte, 0, 12)
-	bits = append(bits, ChunkID...) // in theory switch on endianness..
-	bits = appendInt32(bits, chunksize)
-	bits = append(bits, WaveID...)
+// swapFmt turns the numbers of a fmt chunk over to the other byte order, between the little
+// endian of fmtToBytes and readFmt and the big endian of RIFX. The header of the chunk holds
+// the size, the body the fields up to the size of the extension.
+func swapFmt(hdr, body []byte) {
+	slices.Reverse(hdr[4:8])
+	for _, field := range [][2]int{{0, 2}, {2, 4}, {4, 8}, {8, 12}, {12, 14}, {14, 16}, {16, 18}} {
+		if field[1] <= len(body) {
+			slices.Reverse(body[field[0]:field[1]])
+		}
+	}
+}
 
-	return bits
+// swapSamples turns samples of width bytes over to the other byte order
+func swapSamples(b []byte, width int) {
+	for i := 0; i+width <= len(b); i += width {
+		slices.Reverse(b[i : i+width])
+	}
 }
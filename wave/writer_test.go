@@ -1,6 +1,15 @@
 package wave
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -30,6 +39,17 @@ var (
 			16,
 			-32_767,
 		},
+		// frames past full scale are clamped
+		{
+			Frame(1.5),
+			16,
+			32_767,
+		},
+		{
+			Frame(-2),
+			16,
+			-32_768,
+		},
 	}
 )
 
@@ -56,3 +76,427 @@ func TestWriteWave(t *testing.T) {
 		t.Fatalf("Should be able to write file: %v", err)
 	}
 }
+
+// TestWriteHeader checks the sizes in the header of a stereo file, which has a sample per
+// interleaved frame
+func TestWriteHeader(t *testing.T) {
+	frames := []Frame{0, .5, -.5, 1, .25, -1, 0, 0}
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, NewWaveFmt(1, 2, 8000, 16, nil), &buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if len(b) != 44+16 {
+		t.Fatalf("Expected a file of %v bytes, got %v", 44+16, len(b))
+	}
+	if size := binary.LittleEndian.Uint32(b[4:]); size != 36+16 {
+		t.Fatalf("Expected a RIFF size of %v, got %v", 36+16, size)
+	}
+	if size := binary.LittleEndian.Uint32(b[40:]); size != 16 {
+		t.Fatalf("Expected a data size of 16, got %v", size)
+	}
+	w, err := ReadWaveFromReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Frames) != len(frames) || w.Subchunk2Size != 16 {
+		t.Fatalf("Expected %v frames in 16 bytes, got %v in %v", len(frames), len(w.Frames), w.Subchunk2Size)
+	}
+}
+
+// samplesToRawData encodes the samples into a new buffer
+func samplesToRawData(t testing.TB, samples []Frame, props WaveFmt) []byte {
+	t.Helper()
+	raw := make([]byte, len(samples)*props.BitsPerSample/8)
+	if _, err := EncodeFrames(raw, samples, props); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestEncodeFrames(t *testing.T) {
+	samples := []Frame{0, 1, -1, .5}
+	for _, bits := range []int{16, 24, 32} {
+		t.Run("", func(t *testing.T) {
+			wfmt := NewWaveFmt(1, 1, 44100, bits, nil)
+			dst := make([]byte, len(samples)*bits/8+3)
+			n, err := EncodeFrames(dst, samples, wfmt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(samples)*bits/8 || string(dst[:n]) != string(samplesToRawData(t, samples, wfmt)) {
+				t.Fatalf("Expected the raw data of the samples, got %v", dst[:n])
+			}
+			if _, err := EncodeFrames(dst[:n-1], samples, wfmt); err == nil {
+				t.Fatal("Expected an error for a buffer which is too small")
+			}
+		})
+	}
+	if _, err := EncodeFrames(make([]byte, 100), samples, NewWaveFmt(1, 1, 44100, 8, nil)); err == nil {
+		t.Fatal("Expected an error for samples which can't be encoded")
+	}
+}
+
+// benchmarkSamples is a minute of stereo audio
+var benchmarkSamples = make([]Frame, 44100*60*2)
+
+// BenchmarkSamplesToRawData benchmarks encoding a minute of audio into a new buffer
+func BenchmarkSamplesToRawData(b *testing.B) {
+	wfmt := NewWaveFmt(1, 2, 44100, 16, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		samplesToRawData(b, benchmarkSamples, wfmt)
+	}
+}
+
+// BenchmarkEncodeFrames benchmarks encoding a minute of audio into a reused buffer
+func BenchmarkEncodeFrames(b *testing.B) {
+	wfmt := NewWaveFmt(1, 2, 44100, 16, nil)
+	dst := make([]byte, len(benchmarkSamples)*2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncodeFrames(dst, benchmarkSamples, wfmt)
+	}
+}
+
+func TestFramePool(t *testing.T) {
+	frames := AcquireFrames(1000)
+	if len(frames) != 1000 || cap(frames) != 1024 {
+		t.Fatalf("Expected 1000 frames in a buffer of 1024, got %v of %v", len(frames), cap(frames))
+	}
+	ReleaseFrames(frames)
+	if again := AcquireFrames(600); len(again) != 600 || cap(again) != 1024 {
+		t.Fatalf("Expected 600 frames in a buffer of 1024, got %v of %v", len(again), cap(again))
+	}
+	// buffers which don't come from the pool are left alone
+	ReleaseFrames(make([]Frame, 1000))
+	if frames := AcquireFrames(0); len(frames) != 0 {
+		t.Fatal("Expected an empty buffer")
+	}
+}
+
+// BenchmarkWriteWave benchmarks writing a minute of audio, of which the buffers are pooled
+func BenchmarkWriteWave(b *testing.B) {
+	wfmt := NewWaveFmt(1, 2, 44100, 16, nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		WriteWaveToWriter(benchmarkSamples, wfmt, ioutil.Discard)
+	}
+}
+
+func TestKernels(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 4, 7, 64, 1001} {
+		t.Run("", func(t *testing.T) {
+			src, dst := make([]Frame, n), make([]Frame, n)
+			for i := range src {
+				src[i], dst[i] = Frame(i%13)/13-.5, Frame(i%7)/7
+			}
+
+			mixed := append([]Frame{}, dst...)
+			MixInto(mixed, src, .3)
+			scaled := append([]Frame{}, src...)
+			ApplyGain(scaled, -2)
+			for i := range src {
+				if want := dst[i] + src[i]*.3; mixed[i] != want {
+					t.Fatalf("Expected mixed frame %v to be %v, got %v", i, want, mixed[i])
+				}
+				if want := src[i] * -2; scaled[i] != want {
+					t.Fatalf("Expected scaled frame %v to be %v, got %v", i, want, scaled[i])
+				}
+			}
+
+			floats := make([]float32, n)
+			FramesToFloat32(floats, src)
+			back := make([]Frame, n)
+			Float32ToFrames(back, floats)
+			ints := make([]int16, n)
+			FramesToInt16(ints, scaled)
+			fromInts := make([]Frame, n)
+			Int16ToFrames(fromInts, ints)
+			for i := range src {
+				if back[i] != Frame(float32(src[i])) {
+					t.Fatalf("Expected float frame %v to be %v, got %v", i, float32(src[i]), back[i])
+				}
+				want := scaled[i]
+				if want > 1 {
+					want = 1
+				} else if want < -1 {
+					want = -1
+				}
+				if d := fromInts[i] - want; d > 1./32767 || d < -1./32767 {
+					t.Fatalf("Expected 16 bit frame %v to be %v, got %v", i, want, fromInts[i])
+				}
+			}
+
+			for _, channels := range []int{1, 2, 3} {
+				split := make([][]Frame, channels)
+				for c := range split {
+					split[c] = make([]Frame, n/channels)
+				}
+				if got := Deinterleave(split, src); got != n/channels {
+					t.Fatalf("Expected %v samples per channel, got %v", n/channels, got)
+				}
+				joined := make([]Frame, n)
+				if got := Interleave(joined, split); got != n/channels*channels {
+					t.Fatalf("Expected %v interleaved frames, got %v", n/channels*channels, got)
+				}
+				for i := 0; i < n/channels*channels; i++ {
+					if joined[i] != src[i] {
+						t.Fatalf("Expected interleaved frame %v to be %v, got %v", i, src[i], joined[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMixInto benchmarks mixing a minute of audio into another
+func BenchmarkMixInto(b *testing.B) {
+	dst := make([]Frame, len(benchmarkSamples))
+	for i := 0; i < b.N; i++ {
+		MixInto(dst, benchmarkSamples, .5)
+	}
+}
+
+// BenchmarkMixLoop benchmarks mixing a minute of audio with a plain loop, as a reference for MixInto
+func BenchmarkMixLoop(b *testing.B) {
+	dst := make([]Frame, len(benchmarkSamples))
+	for i := 0; i < b.N; i++ {
+		for j, f := range benchmarkSamples {
+			dst[j] += f * .5
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	wfmt := NewWaveFmt(1, 2, 44100, 16, nil)
+	samples := make([]Frame32, 10001*2)
+	for i := range samples {
+		samples[i] = Frame32(i%200)/100 - 1
+	}
+	var buf bytes.Buffer
+	ww, err := NewWaveWriter(&buf, wfmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSamples(ww, samples); err != nil {
+		t.Fatal(err)
+	}
+	if err := ww.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDecoder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := make([]Frame32, len(samples)+10)
+	n, err := ReadSamples(d, read)
+	if n != len(samples) || err != io.EOF {
+		t.Fatalf("Expected %v samples and EOF, got %v and %v", len(samples), n, err)
+	}
+	for i, s := range samples {
+		if d := read[i] - s; d > 1./32767 || d < -1./32767 {
+			t.Fatalf("Expected sample %v to be %v, got %v", i, s, read[i])
+		}
+	}
+
+	encoded := make([]byte, 2*len(samples))
+	frames := make([]Frame, len(samples))
+	ConvertSamples(frames, samples)
+	expected := make([]byte, 2*len(samples))
+	EncodeFrames(expected, frames, wfmt)
+	if _, err := EncodeSamples(encoded, samples, wfmt); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, expected) {
+		t.Fatal("Expected Frame32 samples to encode like frames")
+	}
+}
+
+func TestWriteOptions(t *testing.T) {
+	frames := []Frame{0, .5, -.5, 1, .25, -1}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+
+	// the same file in blocks of a sample frame
+	plain, blocks := bytes.Buffer{}, bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &plain, WithChunk("LIST", []byte("abc"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWaveToWriter(frames, wfmt, &blocks, WithChunk("LIST", []byte("abc")), WithBufferSize(1)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain.Bytes(), blocks.Bytes()) {
+		t.Fatal("Expected the buffer size not to change the file")
+	}
+	w, err := ReadWaveFromReader(&plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range frames {
+		if d := w.Frames[i] - f; d > 1e-4 || d < -1e-4 {
+			t.Fatalf("Expected %v at %v, got %v", f, i, w.Frames[i])
+		}
+	}
+	if tail := blocks.Bytes()[44+12:]; string(tail) != "LIST\x03\x00\x00\x00abc\x00" {
+		t.Fatalf("Expected the chunk after the samples, got %q", tail)
+	}
+
+	big := bytes.Buffer{}
+	if err := WriteWaveToWriter(frames, wfmt, &big, WithBigEndian(), WithFloat()); err != nil {
+		t.Fatal(err)
+	}
+	b := big.Bytes()
+	if string(b[0:4]) != "RIFX" || b[21] != 3 || b[35] != 32 || len(b) != 44+4*len(frames) {
+		t.Fatalf("Expected a big endian float file, got %q", b[:44])
+	}
+	if got := math.Float32frombits(binary.BigEndian.Uint32(b[48:])); got != .5 {
+		t.Fatalf("Expected the second frame to be .5, got %v", got)
+	}
+
+	err = WriteWaveToWriter([]Frame{0, 1.5}, wfmt, ioutil.Discard, WithFailOnClip())
+	if !errors.Is(err, ErrClipped) {
+		t.Fatalf("Expected ErrClipped, got %v", err)
+	}
+	if err := WriteWaveToWriter(frames, wfmt, ioutil.Discard, WithChunk("LONGER", nil)); err == nil {
+		t.Fatal("Expected an error for a chunk id of six characters")
+	}
+
+	// dither moves silence by at most a step
+	dithered := bytes.Buffer{}
+	if err := WriteWaveToWriter(make([]Frame, 1000), wfmt, &dithered, WithDither()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 44; i < dithered.Len(); i += 2 {
+		if v := int16(binary.LittleEndian.Uint16(dithered.Bytes()[i:])); v < -1 || v > 1 {
+			t.Fatalf("Expected dither of at most a step, got %v", v)
+		}
+	}
+}
+
+// TestWriteOptionsRoundTrip reads back the files written with every option, with both readers
+func TestWriteOptionsRoundTrip(t *testing.T) {
+	frames := make([]Frame, 2*500)
+	for i := range frames {
+		frames[i] = Frame(math.Sin(float64(i) / 7))
+	}
+	// the PCM format in the extension, of which WithFloat changes the format of the samples
+	extension := []byte{16, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0x10, 0, 0x80, 0, 0, 0xAA, 0, 0x38, 0x9B, 0x71}
+	extensible := NewWaveFmt(EXTENSIBLE, 2, 8000, 16, extension)
+	report := ClipReport{}
+	tests := []struct {
+		wfmt      WaveFmt
+		opts      []WriteOption
+		tolerance float64
+	}{
+		{NewWaveFmt(1, 2, 8000, 16, nil), nil, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 24, nil), nil, 1e-6},
+		{NewWaveFmt(1, 2, 8000, 32, nil), nil, 1e-9},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithDither()}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithBigEndian()}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 24, nil), []WriteOption{WithBigEndian()}, 1e-6},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithFloat()}, 1e-7},
+		{NewWaveFmt(3, 2, 8000, 64, nil), []WriteOption{WithFloat()}, 0},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithFloat(), WithBigEndian()}, 1e-7},
+		{extensible, nil, 1e-4},
+		{extensible, []WriteOption{WithFloat()}, 1e-7},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithChunk("LIST", []byte("abc"))}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithBufferSize(6)}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithFailOnClip()}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithClipReport(&report)}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithContext(context.Background())}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithProgress(func(done, total int64) {})}, 1e-4},
+		{NewWaveFmt(1, 2, 8000, 16, nil), []WriteOption{WithLogger(slog.New(slog.NewTextHandler(ioutil.Discard, nil)))}, 1e-4},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			buf := bytes.Buffer{}
+			if err := WriteWaveToWriter(frames, test.wfmt, &buf, test.opts...); err != nil {
+				t.Fatal(err)
+			}
+			w, err := ReadWaveFromReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := NewDecoder(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := DecodeCtx(context.Background(), d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := ReadRawWave(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromRaw, err := raw.Frames()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, read := range map[string][]Frame{"Wave": w.Frames, "Decoder": decoded, "RawWave": fromRaw} {
+				if len(read) != len(frames) {
+					t.Fatalf("Expected %v frames from the %v, got %v", len(frames), name, len(read))
+				}
+				for i, f := range frames {
+					if d := math.Abs(float64(read[i] - f)); d > test.tolerance {
+						t.Fatalf("Expected %v at %v from the %v, got %v", f, i, name, read[i])
+					}
+				}
+			}
+		})
+	}
+	if report.Clipped != 0 {
+		t.Fatalf("Expected nothing to clip, got %+v", report)
+	}
+	err := WriteWaveToWriter(frames, extensible, ioutil.Discard, WithBigEndian())
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Fatalf("Expected the EXTENSIBLE format to be refused in a RIFX file, got %v", err)
+	}
+}
+
+func TestClipping(t *testing.T) {
+	// two stereo samples clip on the left, then a sample on the right and one on both
+	frames := []Frame{1.5, 0, -2, 0, 0, 0, 0, 1.01, -1.2, 1.2}
+	wfmt := NewWaveFmt(1, 2, 8000, 16, nil)
+
+	for _, opts := range [][]WriteOption{nil, {WithBufferSize(4)}} {
+		buf := bytes.Buffer{}
+		if err := WriteWaveToWriter(frames, wfmt, &buf, opts...); err != nil {
+			t.Fatal(err)
+		}
+		w, err := ReadWaveFromReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range frames {
+			want := math.Max(-1, math.Min(1, float64(f)))
+			if d := float64(w.Frames[i]) - want; d > 1e-4 || d < -1e-4 {
+				t.Fatalf("Expected %v to be clamped to %v, got %v", f, want, w.Frames[i])
+			}
+		}
+	}
+
+	report := ClipReport{Clipped: 10}
+	if err := WriteWaveToWriter(frames, wfmt, ioutil.Discard, WithClipReport(&report)); err != nil {
+		t.Fatal(err)
+	}
+	regions := []ClipRegion{{0, 2}, {3, 5}}
+	if report.Clipped != 5 || !reflect.DeepEqual(report.Regions, regions) {
+		t.Fatalf("Expected 5 frames clipped in %v, got %+v", regions, report)
+	}
+
+	// full scale doesn't overflow 24 bits
+	buf := bytes.Buffer{}
+	if err := WriteWaveToWriter([]Frame{1, -1}, NewWaveFmt(1, 1, 8000, 24, nil), &buf, WithClipReport(&report)); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()[44:]
+	if b[0] != 0xFF || b[1] != 0xFF || b[2] != 0x7F || b[3] != 0 || b[4] != 0 || b[5] != 0x80 {
+		t.Fatalf("Expected the largest and smallest 24 bit samples, got % x", b)
+	}
+	if report.Clipped != 0 {
+		t.Fatalf("Expected nothing to clip, got %+v", report)
+	}
+}